@@ -18,6 +18,7 @@ import (
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 
 	"gitea.com/macaron/session"
 	archiver "github.com/mholt/archiver/v3"
@@ -48,6 +49,30 @@ func addFile(w archiver.Writer, filePath string, absPath string, verbose bool) e
 	})
 }
 
+func addObject(w archiver.Writer, objPath string, obj storage.Object, verbose bool) error {
+	if verbose {
+		log.Info("Adding object %s\n", objPath)
+	}
+	fileInfo, err := obj.Stat()
+	if err != nil {
+		return err
+	}
+
+	return w.Write(archiver.File{
+		FileInfo: archiver.FileInfo{
+			FileInfo:   fileInfo,
+			CustomName: objPath,
+		},
+		ReadCloser: obj,
+	})
+}
+
+func addStorage(w archiver.Writer, dirPath string, objStorage storage.ObjectStorage, verbose bool) error {
+	return objStorage.IterateObjects(func(objPath string, obj storage.Object) error {
+		return addObject(w, path.Join(dirPath, objPath), obj, verbose)
+	})
+}
+
 func addRecursive(w archiver.Writer, dirPath string, absPath string, verbose bool) error {
 	if verbose {
 		log.Info("Adding dir  %s\n", dirPath)
@@ -192,6 +217,10 @@ func runDump(ctx *cli.Context) error {
 		return err
 	}
 
+	if err := storage.Init(); err != nil {
+		return err
+	}
+
 	if file == nil {
 		file, err = os.Create(fileName)
 		if err != nil {
@@ -226,11 +255,9 @@ func runDump(ctx *cli.Context) error {
 			fatal("Failed to include repositories: %v", err)
 		}
 
-		if _, err := os.Stat(setting.LFS.ContentPath); !os.IsNotExist(err) {
-			log.Info("Dumping lfs... %s", setting.LFS.ContentPath)
-			if err := addRecursive(w, "lfs", setting.LFS.ContentPath, verbose); err != nil {
-				fatal("Failed to include lfs: %v", err)
-			}
+		log.Info("Dumping lfs...")
+		if err := addStorage(w, "lfs", storage.LFS, verbose); err != nil {
+			fatal("Failed to include lfs: %v", err)
 		}
 	}
 
@@ -300,6 +327,26 @@ func runDump(ctx *cli.Context) error {
 		}
 	}
 
+	// Files kept in a remote storage are not part of the data directory,
+	// so they are fetched from the storage itself.
+	for _, s := range []struct {
+		name    string
+		cfg     setting.Storage
+		storage storage.ObjectStorage
+	}{
+		{"attachments", setting.AttachmentStorage, storage.Attachments},
+		{"avatars", setting.AvatarStorage, storage.Avatars},
+		{"repo-avatars", setting.RepositoryAvatarStorage, storage.RepoAvatars},
+	} {
+		if s.cfg.Type == setting.LocalStorageType {
+			continue
+		}
+		log.Info("Dumping %s from %s storage...", s.name, s.cfg.Type)
+		if err := addStorage(w, path.Join("data", s.name), s.storage, verbose); err != nil {
+			fatal("Failed to include %s: %v", s.name, err)
+		}
+	}
+
 	// Doesn't check if LogRootPath exists before processing --skip-log intentionally,
 	// ensuring that it's clear the dump is skipped whether the directory's initialized
 	// yet or not.
@@ -0,0 +1,209 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/migrations"
+	"code.gitea.io/gitea/modules/migrations/base"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/structs"
+
+	archiver "github.com/mholt/archiver/v3"
+	"github.com/urfave/cli"
+)
+
+// CmdDumpRepository represents the available dump repository sub-command.
+var CmdDumpRepository = cli.Command{
+	Name:        "dump-repo",
+	Usage:       "Dump the repository from git/github/gitea/gitlab/gogs",
+	Description: "This is a command for dumping the repository data into a directory or a tarball which can be restored by restore-repo.",
+	Action:      runDumpRepository,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "git_service",
+			Value: "",
+			Usage: "Git service, git, github, gitea, gitlab or gogs. If not set, it is detected from the clone address",
+		},
+		cli.StringFlag{
+			Name:  "repo_dir, r",
+			Value: "./data",
+			Usage: "Repository dir path to store the data, a path ending with .tar.gz creates a tarball",
+		},
+		cli.StringFlag{
+			Name:  "clone_addr",
+			Value: "",
+			Usage: "The URL will be clone, currently could be a git/github/gitea/gitlab/gogs http/https URL",
+		},
+		cli.StringFlag{
+			Name:  "auth_username",
+			Value: "",
+			Usage: "The username to visit the clone_addr, or an access token for gitea",
+		},
+		cli.StringFlag{
+			Name:  "auth_password",
+			Value: "",
+			Usage: "The password to visit the clone_addr",
+		},
+		cli.StringFlag{
+			Name:  "owner_name",
+			Value: "",
+			Usage: "The data will be stored on a directory with owner name if not empty",
+		},
+		cli.StringFlag{
+			Name:  "repo_name",
+			Value: "",
+			Usage: "The data will be stored on a directory with repository name if not empty",
+		},
+		cli.StringFlag{
+			Name:  "units",
+			Value: "",
+			Usage: `Which items will be migrated, one or more units should be separated as comma.
+wiki, milestones, labels, releases, issues, comments, pull_requests are allowed. Empty means all units.`,
+		},
+	},
+}
+
+// migrateUnits are the units which can be given to dump-repo and restore-repo
+var migrateUnits = []string{"wiki", "milestones", "labels", "releases", "issues", "comments", "pull_requests"}
+
+// parseUnits splits the comma separated units and checks that they are known
+func parseUnits(s string) ([]string, error) {
+	var units []string
+	for _, unit := range strings.Split(s, ",") {
+		unit = strings.ToLower(strings.TrimSpace(unit))
+		if unit == "" {
+			continue
+		}
+		var known bool
+		for _, u := range migrateUnits {
+			if u == unit {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown unit %q", unit)
+		}
+		units = append(units, unit)
+	}
+	return units, nil
+}
+
+func runDumpRepository(ctx *cli.Context) error {
+	if err := argsSet(ctx, "clone_addr"); err != nil {
+		return err
+	}
+	units, err := parseUnits(ctx.String("units"))
+	if err != nil {
+		return err
+	}
+
+	setting.NewContext()
+	setting.NewServices()
+
+	log.Trace("AppPath: %s", setting.AppPath)
+	log.Trace("AppWorkPath: %s", setting.AppWorkPath)
+	log.Trace("Custom path: %s", setting.CustomPath)
+	log.Trace("Log path: %s", setting.LogRootPath)
+
+	var serviceType = structs.NotMigrated
+	switch serviceName := strings.ToLower(ctx.String("git_service")); serviceName {
+	case "":
+	case "git":
+		serviceType = structs.PlainGitService
+	default:
+		for _, tp := range structs.SupportedFullGitService {
+			if tp.Name() == serviceName {
+				serviceType = tp
+				break
+			}
+		}
+		if serviceType == structs.NotMigrated {
+			return fmt.Errorf("unknown git service %q", serviceName)
+		}
+	}
+
+	repoName := ctx.String("repo_name")
+	if repoName == "" {
+		repoName = strings.TrimSuffix(filepath.Base(strings.TrimSuffix(ctx.String("clone_addr"), "/")), ".git")
+	}
+
+	var opts = base.MigrateOptions{
+		GitServiceType: serviceType,
+		CloneAddr:      ctx.String("clone_addr"),
+		AuthUsername:   ctx.String("auth_username"),
+		AuthPassword:   ctx.String("auth_password"),
+		RepoName:       repoName,
+		OriginalURL:    ctx.String("clone_addr"),
+	}
+	if len(units) == 0 {
+		opts.Wiki = true
+		opts.Issues = true
+		opts.Milestones = true
+		opts.Labels = true
+		opts.Releases = true
+		opts.Comments = true
+		opts.PullRequests = true
+	} else {
+		for _, unit := range units {
+			switch unit {
+			case "wiki":
+				opts.Wiki = true
+			case "milestones":
+				opts.Milestones = true
+			case "labels":
+				opts.Labels = true
+			case "releases":
+				opts.Releases = true
+			case "issues":
+				opts.Issues = true
+			case "comments":
+				opts.Comments = true
+			case "pull_requests":
+				opts.PullRequests = true
+			}
+		}
+	}
+
+	repoDir := ctx.String("repo_dir")
+	tarball := strings.HasSuffix(repoDir, ".tar.gz")
+	dumpDir := repoDir
+	if tarball {
+		if _, err := os.Stat(repoDir); err == nil {
+			return fmt.Errorf("%s already exists", repoDir)
+		}
+		tmpDir, err := ioutil.TempDir(os.TempDir(), "gitea-dump-repo")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmpDir)
+		dumpDir = filepath.Join(tmpDir, strings.TrimSuffix(filepath.Base(repoDir), ".tar.gz"))
+	} else if ownerName := ctx.String("owner_name"); ownerName != "" {
+		dumpDir = filepath.Join(repoDir, ownerName, repoName)
+	}
+
+	if err := migrations.DumpRepository(context.Background(), dumpDir, ctx.String("owner_name"), opts); err != nil {
+		log.Fatal("Failed to dump repository: %v", err)
+		return err
+	}
+
+	if tarball {
+		if err := archiver.Archive([]string{dumpDir}, repoDir); err != nil {
+			return fmt.Errorf("create %s: %v", repoDir, err)
+		}
+	}
+
+	log.Trace("Dump finished!!!")
+	fmt.Printf("Repository %s dumped to %s\n", opts.CloneAddr, repoDir)
+	return nil
+}
@@ -12,7 +12,6 @@ import (
 	"net/http"
 	"os"
 	"strconv"
-	"strings"
 	"time"
 
 	"code.gitea.io/gitea/models"
@@ -220,34 +219,31 @@ Gitea or set your environment appropriately.`, "")
 		total++
 		lastline++
 
-		// If the ref is a branch, check if it's protected
-		if strings.HasPrefix(refFullName, git.BranchPrefix) {
-			oldCommitIDs[count] = oldCommitID
-			newCommitIDs[count] = newCommitID
-			refFullNames[count] = refFullName
-			count++
-			fmt.Fprintf(out, "*")
-
-			if count >= hookBatchSize {
-				fmt.Fprintf(out, " Checking %d branches\n", count)
-
-				hookOptions.OldCommitIDs = oldCommitIDs
-				hookOptions.NewCommitIDs = newCommitIDs
-				hookOptions.RefFullNames = refFullNames
-				statusCode, msg := private.HookPreReceive(username, reponame, hookOptions)
-				switch statusCode {
-				case http.StatusOK:
-					// no-op
-				case http.StatusInternalServerError:
-					fail("Internal Server Error", msg)
-				default:
-					fail(msg, "")
-				}
-				count = 0
-				lastline = 0
+		// Every reference is checked: branches may be protected, pushes to refs/for/ propose
+		// pull requests and users who can only propose them must not push anything else
+		oldCommitIDs[count] = oldCommitID
+		newCommitIDs[count] = newCommitID
+		refFullNames[count] = refFullName
+		count++
+		fmt.Fprintf(out, "*")
+
+		if count >= hookBatchSize {
+			fmt.Fprintf(out, " Checking %d references\n", count)
+
+			hookOptions.OldCommitIDs = oldCommitIDs
+			hookOptions.NewCommitIDs = newCommitIDs
+			hookOptions.RefFullNames = refFullNames
+			statusCode, msg := private.HookPreReceive(username, reponame, hookOptions)
+			switch statusCode {
+			case http.StatusOK:
+				// no-op
+			case http.StatusInternalServerError:
+				fail("Internal Server Error", msg)
+			default:
+				fail(msg, "")
 			}
-		} else {
-			fmt.Fprintf(out, ".")
+			count = 0
+			lastline = 0
 		}
 		if lastline >= hookBatchSize {
 			fmt.Fprintf(out, "\n")
@@ -260,7 +256,7 @@ Gitea or set your environment appropriately.`, "")
 		hookOptions.NewCommitIDs = newCommitIDs[:count]
 		hookOptions.RefFullNames = refFullNames[:count]
 
-		fmt.Fprintf(out, " Checking %d branches\n", count)
+		fmt.Fprintf(out, " Checking %d references\n", count)
 
 		statusCode, msg := private.HookPreReceive(username, reponame, hookOptions)
 		switch statusCode {
@@ -0,0 +1,194 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/migrations"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
+
+	"github.com/urfave/cli"
+)
+
+// CmdMigrateStorage represents the available migrate storage sub-command.
+var CmdMigrateStorage = cli.Command{
+	Name:        "migrate-storage",
+	Usage:       "Migrate the storage",
+	Description: "This is a command for migrating attachments, LFS objects and avatars from the configured storage to another one.",
+	Action:      runMigrateStorage,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "type, t",
+			Value: "",
+			Usage: "Kinds of files to migrate: 'attachments', 'lfs', 'avatars' or 'repo-avatars'",
+		},
+		cli.StringFlag{
+			Name:  "storage, s",
+			Value: setting.LocalStorageType,
+			Usage: "New storage type, local or minio",
+		},
+		cli.StringFlag{
+			Name:  "path, p",
+			Value: "",
+			Usage: "New storage placement if store is local (leave blank for default)",
+		},
+		cli.StringFlag{
+			Name:  "minio-endpoint",
+			Value: "",
+			Usage: "Minio storage endpoint",
+		},
+		cli.StringFlag{
+			Name:  "minio-access-key-id",
+			Value: "",
+			Usage: "Minio storage accessKeyID",
+		},
+		cli.StringFlag{
+			Name:  "minio-secret-access-key",
+			Value: "",
+			Usage: "Minio storage secretAccessKey",
+		},
+		cli.StringFlag{
+			Name:  "minio-bucket",
+			Value: "",
+			Usage: "Minio storage bucket",
+		},
+		cli.StringFlag{
+			Name:  "minio-location",
+			Value: "",
+			Usage: "Minio storage location to create bucket",
+		},
+		cli.StringFlag{
+			Name:  "minio-base-path",
+			Value: "",
+			Usage: "Minio storage basepath on the bucket",
+		},
+		cli.BoolFlag{
+			Name:  "minio-use-ssl",
+			Usage: "Enable SSL for minio",
+		},
+	},
+}
+
+func migrateAttachments(dstStorage storage.ObjectStorage) error {
+	return models.IterateAttachment(func(attach *models.Attachment) error {
+		_, err := storage.Copy(dstStorage, attach.RelativePath(), storage.Attachments, attach.RelativePath())
+		return err
+	})
+}
+
+// migrateLFS copies the LFS objects and the package blobs which share the LFS storage
+func migrateLFS(dstStorage storage.ObjectStorage) error {
+	if err := models.IterateLFS(func(mo *models.LFSMetaObject) error {
+		_, err := storage.Copy(dstStorage, mo.RelativePath(), storage.LFS, mo.RelativePath())
+		return err
+	}); err != nil {
+		return err
+	}
+
+	return models.IteratePackageBlobs(func(blob *models.PackageBlob) error {
+		_, err := storage.Copy(dstStorage, blob.RelativePath(), storage.LFS, blob.RelativePath())
+		return err
+	})
+}
+
+// migrateObjects copies every object of the source storage, it is used for the
+// avatars whose storages only contain files referenced by users or repositories.
+func migrateObjects(dstStorage, srcStorage storage.ObjectStorage) error {
+	return srcStorage.IterateObjects(func(p string, obj storage.Object) error {
+		size := int64(-1)
+		if fi, err := obj.Stat(); err == nil {
+			size = fi.Size()
+		}
+		_, err := dstStorage.Save(p, obj, size)
+		return err
+	})
+}
+
+func runMigrateStorage(ctx *cli.Context) error {
+	if err := initDB(); err != nil {
+		return err
+	}
+
+	log.Trace("AppPath: %s", setting.AppPath)
+	log.Trace("AppWorkPath: %s", setting.AppWorkPath)
+	log.Trace("Custom path: %s", setting.CustomPath)
+	log.Trace("Log path: %s", setting.LogRootPath)
+	setting.InitDBConfig()
+
+	if err := models.NewEngine(context.Background(), migrations.EnsureUpToDate); err != nil {
+		log.Fatal("Failed to initialize ORM engine: %v", err)
+		return err
+	}
+
+	if err := storage.Init(); err != nil {
+		return err
+	}
+
+	tp := strings.ToLower(ctx.String("type"))
+	var cfg setting.Storage
+	switch tp {
+	case "attachments":
+		cfg = setting.AttachmentStorage
+	case "lfs":
+		cfg = setting.LFS.Storage
+	case "avatars":
+		cfg = setting.AvatarStorage
+	case "repo-avatars":
+		cfg = setting.RepositoryAvatarStorage
+	default:
+		return fmt.Errorf("Unsupported storage: %s", ctx.String("type"))
+	}
+
+	var dstStorage storage.ObjectStorage
+	var err error
+	switch strings.ToLower(ctx.String("storage")) {
+	case setting.LocalStorageType:
+		p := ctx.String("path")
+		if p == "" {
+			log.Fatal("Path must be given when storage is local")
+			return nil
+		}
+		dstStorage, err = storage.NewLocalStorage(p)
+	case setting.MinioStorageType:
+		dstStorage, err = storage.NewMinioStorage(setting.MinioStorageConfig{
+			Endpoint:        ctx.String("minio-endpoint"),
+			AccessKeyID:     ctx.String("minio-access-key-id"),
+			SecretAccessKey: ctx.String("minio-secret-access-key"),
+			Bucket:          ctx.String("minio-bucket"),
+			Location:        ctx.String("minio-location"),
+			BasePath:        ctx.String("minio-base-path"),
+			UseSSL:          ctx.Bool("minio-use-ssl"),
+		})
+	default:
+		return fmt.Errorf("Unsupported storage type: %s", ctx.String("storage"))
+	}
+	if err != nil {
+		return err
+	}
+
+	switch tp {
+	case "attachments":
+		err = migrateAttachments(dstStorage)
+	case "lfs":
+		err = migrateLFS(dstStorage)
+	case "avatars":
+		err = migrateObjects(dstStorage, storage.Avatars)
+	case "repo-avatars":
+		err = migrateObjects(dstStorage, storage.RepoAvatars)
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Warn("All files have been copied to the new placement but old files are still on the original placement (%s).", cfg.Type)
+
+	return nil
+}
@@ -0,0 +1,146 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	migrations_db "code.gitea.io/gitea/models/migrations"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/migrations"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
+
+	archiver "github.com/mholt/archiver/v3"
+	"github.com/urfave/cli"
+)
+
+// CmdRestoreRepository represents the available restore a repository sub-command.
+var CmdRestoreRepository = cli.Command{
+	Name:        "restore-repo",
+	Usage:       "Restore the repository from disk",
+	Description: "This is a command for restoring the repository data dumped by dump-repo from a directory or a tarball.",
+	Action:      runRestoreRepository,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "repo_dir, r",
+			Value: "./data",
+			Usage: "Repository dir path or tarball to restore from",
+		},
+		cli.StringFlag{
+			Name:  "owner_name",
+			Value: "",
+			Usage: "Restore destination owner name",
+		},
+		cli.StringFlag{
+			Name:  "repo_name",
+			Value: "",
+			Usage: "Restore destination repository name",
+		},
+		cli.StringFlag{
+			Name:  "doer_name",
+			Value: "",
+			Usage: "The user who restores the repository, defaults to the owner. Required if the owner is an organization",
+		},
+		cli.StringFlag{
+			Name:  "units",
+			Value: "",
+			Usage: `Which items will be restored, one or more units should be separated as comma.
+wiki, milestones, labels, releases, issues, comments, pull_requests are allowed. Empty means all units.`,
+		},
+	},
+}
+
+func runRestoreRepository(ctx *cli.Context) error {
+	if err := argsSet(ctx, "owner_name", "repo_name"); err != nil {
+		return err
+	}
+	units, err := parseUnits(ctx.String("units"))
+	if err != nil {
+		return err
+	}
+
+	if err := initDB(); err != nil {
+		return err
+	}
+
+	log.Trace("AppPath: %s", setting.AppPath)
+	log.Trace("AppWorkPath: %s", setting.AppWorkPath)
+	log.Trace("Custom path: %s", setting.CustomPath)
+	log.Trace("Log path: %s", setting.LogRootPath)
+
+	if err := models.NewEngine(context.Background(), migrations_db.EnsureUpToDate); err != nil {
+		log.Fatal("Failed to initialize ORM engine: %v", err)
+		return err
+	}
+
+	setting.NewServices()
+	if err := storage.Init(); err != nil {
+		return err
+	}
+
+	owner, err := models.GetUserByName(ctx.String("owner_name"))
+	if err != nil {
+		return err
+	}
+	doer := owner
+	if doerName := ctx.String("doer_name"); doerName != "" {
+		if doer, err = models.GetUserByName(doerName); err != nil {
+			return err
+		}
+	} else if owner.IsOrganization() {
+		return fmt.Errorf("doer_name is required to restore into the organization %s", owner.Name)
+	}
+
+	repoDir := ctx.String("repo_dir")
+	if strings.HasSuffix(repoDir, ".tar.gz") {
+		tmpDir, err := ioutil.TempDir(os.TempDir(), "gitea-restore-repo")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmpDir)
+		if err := archiver.Unarchive(repoDir, tmpDir); err != nil {
+			return fmt.Errorf("extract %s: %v", repoDir, err)
+		}
+		if repoDir, err = findRepositoryDump(tmpDir); err != nil {
+			return err
+		}
+	}
+
+	repo, err := migrations.RestoreRepository(context.Background(), repoDir, doer, owner.Name, ctx.String("repo_name"), units)
+	if err != nil {
+		log.Fatal("Failed to restore repository: %v", err)
+		return err
+	}
+
+	fmt.Printf("Repository restored to %s\n", repo.FullName())
+	return nil
+}
+
+// findRepositoryDump returns the directory of the extracted tarball which contains repo.yml
+func findRepositoryDump(dir string) (string, error) {
+	if _, err := os.Stat(filepath.Join(dir, "repo.yml")); err == nil {
+		return dir, nil
+	}
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	for _, fi := range fis {
+		if !fi.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, fi.Name(), "repo.yml")); err == nil {
+			return filepath.Join(dir, fi.Name()), nil
+		}
+	}
+	return "", fmt.Errorf("no repository dump found in %s", dir)
+}
@@ -0,0 +1,108 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testRunnerToken = "7f6e1b3c0d5a4e2f9b8c7a6d5e4f3a2b1c0d9e8f"
+
+func newRunnerRequest(t *testing.T, method, url string, body string) *http.Request {
+	req := NewRequestWithBody(t, method, url, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+testRunnerToken)
+	if strings.HasPrefix(body, "{") {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req
+}
+
+func TestActionsRunner(t *testing.T) {
+	onGiteaRun(t, testActionsRunner)
+}
+
+func testActionsRunner(t *testing.T, u *url.URL) {
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+
+	hasActions := true
+	req := NewRequestWithJSON(t, "PATCH", "/api/v1/repos/user2/repo1?token="+token, &api.EditRepoOption{
+		HasActions: &hasActions,
+	})
+	session.MakeRequest(t, req, http.StatusOK)
+
+	opts := getCreateFileOptions()
+	opts.Content = base64.StdEncoding.EncodeToString([]byte(`
+name: CI
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - run: make build
+`))
+	req = NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/contents/.gitea/workflows/ci.yml?token="+token, &opts)
+	resp := session.MakeRequest(t, req, http.StatusCreated)
+	var fileResponse api.FileResponse
+	DecodeJSON(t, resp, &fileResponse)
+	sha := fileResponse.Commit.SHA
+
+	run := models.AssertExistsAndLoadBean(t, &models.ActionRun{RepoID: 1, CommitSHA: sha}).(*models.ActionRun)
+	assert.Equal(t, "CI", run.Title)
+	models.AssertExistsAndLoadBean(t, &models.CommitStatus{RepoID: 1, SHA: sha, State: api.CommitStatusPending})
+
+	req = NewRequest(t, "POST", "/api/actions/runner/fetch")
+	MakeRequest(t, req, http.StatusUnauthorized)
+
+	req = newRunnerRequest(t, "POST", "/api/actions/runner/fetch", "")
+	resp = MakeRequest(t, req, http.StatusOK)
+	var task api.ActionTask
+	DecodeJSON(t, resp, &task)
+	assert.Equal(t, "user2/repo1", task.Repository)
+	assert.Equal(t, "refs/heads/master", task.Ref)
+	assert.Equal(t, sha, task.SHA)
+	assert.Equal(t, "build", task.JobID)
+	assert.Contains(t, string(task.Job), "make build")
+
+	// there are no other jobs for the runner
+	req = newRunnerRequest(t, "POST", "/api/actions/runner/fetch", "")
+	MakeRequest(t, req, http.StatusNoContent)
+
+	jobURL := fmt.Sprintf("/api/actions/runner/jobs/%d", task.ID)
+	req = newRunnerRequest(t, "POST", jobURL+"/logs", "building\n")
+	MakeRequest(t, req, http.StatusNoContent)
+	req = newRunnerRequest(t, "POST", jobURL+"/steps/0", `{"status":"success"}`)
+	MakeRequest(t, req, http.StatusNoContent)
+	req = newRunnerRequest(t, "POST", jobURL+"/status", `{"status":"waiting"}`)
+	MakeRequest(t, req, http.StatusUnprocessableEntity)
+	req = newRunnerRequest(t, "POST", jobURL+"/status", `{"status":"success"}`)
+	MakeRequest(t, req, http.StatusNoContent)
+
+	// the job is done, the runner must not report anymore
+	req = newRunnerRequest(t, "POST", jobURL+"/logs", "more\n")
+	MakeRequest(t, req, http.StatusConflict)
+
+	models.AssertExistsAndLoadBean(t, &models.ActionRun{ID: run.ID, Status: models.ActionStatusSuccess})
+	models.AssertExistsAndLoadBean(t, &models.CommitStatus{RepoID: 1, SHA: sha, State: api.CommitStatusSuccess, Context: "CI / build (push)"})
+
+	req = NewRequestf(t, "GET", "/user2/repo1/actions/runs/%d/jobs/%d/logs", run.Index, task.ID)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	assert.Equal(t, "building\n", resp.Body.String())
+
+	req = NewRequestf(t, "GET", "/user2/repo1/actions/runs/%d", run.Index)
+	session.MakeRequest(t, req, http.StatusOK)
+	req = NewRequest(t, "GET", "/user2/repo1/actions")
+	session.MakeRequest(t, req, http.StatusOK)
+}
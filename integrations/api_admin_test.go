@@ -144,3 +144,32 @@ func TestAPIListUsersNonAdmin(t *testing.T) {
 	req := NewRequestf(t, "GET", "/api/v1/admin/users?token=%s", token)
 	session.MakeRequest(t, req, http.StatusForbidden)
 }
+
+func TestAPIListAuditEvents(t *testing.T) {
+	defer prepareTestEnv(t)()
+	session := loginUser(t, "user1")
+	// creating the token is recorded in the audit log
+	token := getTokenForLoggedInUser(t, session)
+
+	req := NewRequestf(t, "GET", "/api/v1/admin/audit?actor=user1&action=access_token_create&token=%s", token)
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	var events []*api.AuditEvent
+	DecodeJSON(t, resp, &events)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, "user1", events[0].ActorName)
+		assert.Equal(t, "access_token", events[0].TargetType)
+	}
+
+	req = NewRequestf(t, "GET", "/api/v1/admin/audit?actor=user2&token=%s", token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &events)
+	assert.Empty(t, events)
+
+	req = NewRequestf(t, "GET", "/api/v1/admin/audit?action=unknown&token=%s", token)
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+	session = loginUser(t, "user2")
+	token = getTokenForLoggedInUser(t, session)
+	req = NewRequestf(t, "GET", "/api/v1/admin/audit?token=%s", token)
+	session.MakeRequest(t, req, http.StatusForbidden)
+}
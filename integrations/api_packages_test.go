@@ -0,0 +1,102 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageGeneric(t *testing.T) {
+	defer prepareTestEnv(t)()
+	user := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+
+	content := []byte{1, 2, 3, 4}
+	url := fmt.Sprintf("/api/packages/%s/generic/my-package/1.2.3/file.bin", user.Name)
+
+	req := NewRequestWithBody(t, "PUT", url, bytes.NewReader(content))
+	MakeRequest(t, req, http.StatusUnauthorized)
+
+	req = NewRequestWithBody(t, "PUT", url, bytes.NewReader(content))
+	req = AddBasicAuthHeader(req, user.Name)
+	MakeRequest(t, req, http.StatusCreated)
+
+	req = NewRequestWithBody(t, "PUT", url, bytes.NewReader(content))
+	req = AddBasicAuthHeader(req, user.Name)
+	MakeRequest(t, req, http.StatusConflict)
+
+	req = NewRequest(t, "GET", url)
+	resp := MakeRequest(t, req, http.StatusOK)
+	assert.Equal(t, content, resp.Body.Bytes())
+
+	req = NewRequestf(t, "GET", "/api/v1/packages/%s?type=generic", user.Name)
+	resp = MakeRequest(t, req, http.StatusOK)
+	var apiPackages []*api.Package
+	DecodeJSON(t, resp, &apiPackages)
+	if assert.Len(t, apiPackages, 2) {
+		assert.Equal(t, "my-package", apiPackages[0].Name)
+	}
+
+	req = NewRequestf(t, "GET", "/api/v1/packages/%s/generic/my-package/1.2.3/files", user.Name)
+	resp = MakeRequest(t, req, http.StatusOK)
+	var apiFiles []*api.PackageFile
+	DecodeJSON(t, resp, &apiFiles)
+	if assert.Len(t, apiFiles, 1) {
+		assert.EqualValues(t, len(content), apiFiles[0].Size)
+	}
+
+	req = NewRequest(t, "DELETE", url)
+	req = AddBasicAuthHeader(req, user.Name)
+	MakeRequest(t, req, http.StatusNoContent)
+
+	req = NewRequest(t, "GET", url)
+	MakeRequest(t, req, http.StatusNotFound)
+}
+
+func TestPackageUploadSizeLimit(t *testing.T) {
+	defer prepareTestEnv(t)()
+	user := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+
+	oldMaxFileSize := setting.Packages.MaxFileSize
+	defer func() {
+		setting.Packages.MaxFileSize = oldMaxFileSize
+	}()
+	setting.Packages.MaxFileSize = 4
+
+	url := fmt.Sprintf("/api/packages/%s/generic/my-package/1.2.3/", user.Name)
+
+	req := NewRequestWithBody(t, "PUT", url+"exact.bin", bytes.NewReader([]byte{1, 2, 3, 4}))
+	req = AddBasicAuthHeader(req, user.Name)
+	MakeRequest(t, req, http.StatusCreated)
+
+	req = NewRequestWithBody(t, "PUT", url+"large.bin", bytes.NewReader([]byte{1, 2, 3, 4, 5}))
+	req = AddBasicAuthHeader(req, user.Name)
+	MakeRequest(t, req, http.StatusRequestEntityTooLarge)
+
+	// without a content length the upload fails while it is read
+	req = NewRequestWithBody(t, "PUT", url+"large.bin", bytes.NewReader([]byte{1, 2, 3, 4, 5}))
+	req.ContentLength = -1
+	req = AddBasicAuthHeader(req, user.Name)
+	MakeRequest(t, req, http.StatusRequestEntityTooLarge)
+
+	req = NewRequestWithBody(t, "PUT", fmt.Sprintf("/api/packages/%s/npm/my-package", user.Name), bytes.NewReader([]byte(`{"name":"my-package"}`)))
+	req = AddBasicAuthHeader(req, user.Name)
+	MakeRequest(t, req, http.StatusRequestEntityTooLarge)
+
+	req = NewRequestWithBody(t, "PUT", fmt.Sprintf("/api/packages/%s/maven/com/gitea/my-package/1.2.3/my-package-1.2.3.jar", user.Name), bytes.NewReader([]byte{1, 2, 3, 4, 5}))
+	req = AddBasicAuthHeader(req, user.Name)
+	MakeRequest(t, req, http.StatusRequestEntityTooLarge)
+
+	req = NewRequest(t, "GET", url+"large.bin")
+	MakeRequest(t, req, http.StatusNotFound)
+}
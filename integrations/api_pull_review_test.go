@@ -123,3 +123,66 @@ func TestAPIPullReview(t *testing.T) {
 	req = NewRequestf(t, http.MethodDelete, "/api/v1/repos/%s/%s/pulls/%d/reviews/%d?token=%s", repo.OwnerName, repo.Name, pullIssue.Index, review.ID, token)
 	resp = session.MakeRequest(t, req, http.StatusNoContent)
 }
+
+func TestAPIPullReviewMultiLineAndFileComments(t *testing.T) {
+	defer prepareTestEnv(t)()
+	pullIssue := models.AssertExistsAndLoadBean(t, &models.Issue{ID: 3}).(*models.Issue)
+	assert.NoError(t, pullIssue.LoadAttributes())
+	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: pullIssue.RepoID}).(*models.Repository)
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+
+	// the first line must come before the last one
+	req := NewRequestWithJSON(t, http.MethodPost, fmt.Sprintf("/api/v1/repos/%s/%s/pulls/%d/reviews?token=%s", repo.OwnerName, repo.Name, pullIssue.Index, token), &api.CreatePullReviewOptions{
+		Body: "body1",
+		Comments: []api.CreatePullReviewComment{{
+			Path:            "README.md",
+			Body:            "backwards",
+			NewStartLineNum: 2,
+			NewLineNum:      1,
+		}},
+	})
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+	req = NewRequestWithJSON(t, http.MethodPost, fmt.Sprintf("/api/v1/repos/%s/%s/pulls/%d/reviews?token=%s", repo.OwnerName, repo.Name, pullIssue.Index, token), &api.CreatePullReviewOptions{
+		Body: "body1",
+		Comments: []api.CreatePullReviewComment{{
+			Path:            "README.md",
+			Body:            "two new lines",
+			NewStartLineNum: 1,
+			NewLineNum:      2,
+		}, {
+			Path:          "README.md",
+			Body:          "the whole file",
+			NewLineNum:    1,
+			IsFileComment: true,
+		}},
+	})
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	var review api.PullReview
+	DecodeJSON(t, resp, &review)
+	assert.EqualValues(t, 2, review.CodeCommentsCount)
+
+	req = NewRequestf(t, http.MethodGet, "/api/v1/repos/%s/%s/pulls/%d/reviews/%d/comments?token=%s", repo.OwnerName, repo.Name, pullIssue.Index, review.ID, token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	var reviewComments []*api.PullReviewComment
+	DecodeJSON(t, resp, &reviewComments)
+	if !assert.Len(t, reviewComments, 2) {
+		return
+	}
+	for _, comment := range reviewComments {
+		switch comment.Body {
+		case "two new lines":
+			assert.EqualValues(t, 1, comment.StartLineNum)
+			assert.EqualValues(t, 2, comment.LineNum)
+			assert.False(t, comment.IsFileComment)
+		case "the whole file":
+			assert.EqualValues(t, 0, comment.StartLineNum)
+			assert.EqualValues(t, 0, comment.LineNum)
+			assert.True(t, comment.IsFileComment)
+		default:
+			assert.Fail(t, "unexpected comment", comment.Body)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIRepoPushMirror(t *testing.T) {
+	defer prepareTestEnv(t)()
+	user2 := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	repo1 := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+
+	session := loginUser(t, user2.Name)
+	token := getTokenForLoggedInUser(t, session)
+	url := fmt.Sprintf("/api/v1/repos/%s/%s/push_mirrors?token=%s", user2.Name, repo1.Name, token)
+
+	for _, interval := range []string{"1m", "-1h", "bogus"} {
+		req := NewRequestWithJSON(t, "POST", url, &api.CreatePushMirrorOption{
+			RemoteAddress: "https://example.com/user2/repo1.git",
+			Interval:      interval,
+		})
+		session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+	}
+
+	for _, address := range []string{"ssh://example.com/user2/repo1.git", "https://example.com", "https://example.com/user2/repo 1.git"} {
+		req := NewRequestWithJSON(t, "POST", url, &api.CreatePushMirrorOption{
+			RemoteAddress: address,
+		})
+		session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+	}
+
+	for interval, expected := range map[string]string{"": "0s", "0": "0s", "8h": "8h0m0s"} {
+		req := NewRequestWithJSON(t, "POST", url, &api.CreatePushMirrorOption{
+			RemoteAddress: "https://example.com/user2/repo1.git",
+			Interval:      interval,
+		})
+		resp := session.MakeRequest(t, req, http.StatusCreated)
+		var pushMirror api.PushMirror
+		DecodeJSON(t, resp, &pushMirror)
+		assert.Equal(t, expected, pushMirror.Interval)
+	}
+
+	req := NewRequest(t, "GET", url)
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	var pushMirrors []*api.PushMirror
+	DecodeJSON(t, resp, &pushMirrors)
+	assert.Len(t, pushMirrors, 3)
+}
@@ -5,11 +5,15 @@
 package integrations
 
 import (
+	"bytes"
 	"net/http"
 	"testing"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/setting"
 	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
 )
 
 // TestAPICreateAndDeleteToken tests that token that was just created can be deleted
@@ -48,3 +52,140 @@ func TestAPIDeleteMissingToken(t *testing.T) {
 	req = AddBasicAuthHeader(req, user.Name)
 	MakeRequest(t, req, http.StatusNotFound)
 }
+
+func createRestrictedToken(t *testing.T, username, name string, scopes, repos []string) string {
+	req := NewRequestWithJSON(t, "POST", "/api/v1/users/"+username+"/tokens", &api.CreateAccessTokenOption{
+		Name:         name,
+		Scopes:       scopes,
+		Repositories: repos,
+	})
+	req = AddBasicAuthHeader(req, username)
+	resp := MakeRequest(t, req, http.StatusCreated)
+	var token api.AccessToken
+	DecodeJSON(t, resp, &token)
+	return token.Token
+}
+
+// TestAPIRestrictedTokenListings ensures that the listings only contain the
+// repositories a restricted token may access
+func TestAPIRestrictedTokenListings(t *testing.T) {
+	defer prepareTestEnv(t)()
+	token := createRestrictedToken(t, "user2", "restricted", nil, []string{"user2/repo1"})
+
+	for _, url := range []string{
+		"/api/v1/user/repos",
+		"/api/v1/users/user2/repos",
+		"/api/v1/orgs/user3/repos",
+	} {
+		req := NewRequest(t, "GET", url+"?token="+token)
+		resp := MakeRequest(t, req, http.StatusOK)
+		var repos []*api.Repository
+		DecodeJSON(t, resp, &repos)
+		for _, repo := range repos {
+			assert.EqualValues(t, 1, repo.ID, url)
+		}
+		if url != "/api/v1/orgs/user3/repos" {
+			assert.Len(t, repos, 1, url)
+		}
+	}
+
+	req := NewRequest(t, "GET", "/api/v1/repos/search?token="+token)
+	resp := MakeRequest(t, req, http.StatusOK)
+	var results api.SearchResults
+	DecodeJSON(t, resp, &results)
+	if assert.Len(t, results.Data, 1) {
+		assert.EqualValues(t, 1, results.Data[0].ID)
+	}
+
+	req = NewRequest(t, "GET", "/api/v1/repos/issues/search?state=all&token="+token)
+	resp = MakeRequest(t, req, http.StatusOK)
+	var issues []*api.Issue
+	DecodeJSON(t, resp, &issues)
+	assert.NotEmpty(t, issues)
+	for _, issue := range issues {
+		assert.EqualValues(t, 1, issue.Repo.ID)
+	}
+
+	req = NewRequest(t, "GET", "/api/v1/notifications?all=true&token="+token)
+	resp = MakeRequest(t, req, http.StatusOK)
+	var threads []*api.NotificationThread
+	DecodeJSON(t, resp, &threads)
+	assert.NotEmpty(t, threads)
+	for _, thread := range threads {
+		assert.EqualValues(t, 1, thread.Repository.ID)
+	}
+}
+
+// TestRestrictedTokenGitAccess ensures that a restricted token can't access
+// other repositories through LFS nor create repositories by pushing
+func TestRestrictedTokenGitAccess(t *testing.T) {
+	defer prepareTestEnv(t)()
+	repo2 := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 2}).(*models.Repository)
+	content := []byte("restricted token content")
+	oid := storeObjectInRepo(t, repo2.ID, &content)
+	defer repo2.RemoveLFSMetaObjectByOid(oid)
+
+	restricted := createRestrictedToken(t, "user2", "restricted", nil, []string{"user2/repo1"})
+	readOnly := createRestrictedToken(t, "user2", "read-only", []string{"repo:read"}, []string{"user2/repo2"})
+
+	req := NewRequest(t, "GET", "/user2/repo2.git/info/lfs/objects/"+oid+"/test")
+	req.SetBasicAuth("user2", restricted)
+	MakeRequest(t, req, http.StatusUnauthorized)
+
+	req = NewRequest(t, "GET", "/user2/repo2.git/info/lfs/objects/"+oid+"/test")
+	req.SetBasicAuth("user2", readOnly)
+	MakeRequest(t, req, http.StatusOK)
+
+	req = NewRequestWithBody(t, "PUT", "/user2/repo2.git/info/lfs/objects/"+oid, bytes.NewReader(content))
+	req.SetBasicAuth("user2", readOnly)
+	MakeRequest(t, req, http.StatusUnauthorized)
+
+	defer func(enabled bool) {
+		setting.Repository.EnablePushCreateUser = enabled
+	}(setting.Repository.EnablePushCreateUser)
+	setting.Repository.EnablePushCreateUser = true
+	req = NewRequest(t, "GET", "/user2/push-created.git/info/refs?service=git-receive-pack")
+	req.SetBasicAuth("user2", restricted)
+	MakeRequest(t, req, http.StatusForbidden)
+}
+
+// TestAPIRestrictedTokenAccountManagement ensures that restricted tokens can neither
+// create new tokens nor add keys giving access to all the repositories of the user
+func TestAPIRestrictedTokenAccountManagement(t *testing.T) {
+	defer prepareTestEnv(t)()
+	restricted := createRestrictedToken(t, "user2", "restricted", nil, []string{"user2/repo1"})
+	scoped := createRestrictedToken(t, "user2", "scoped", []string{"user"}, nil)
+	unrestricted := createRestrictedToken(t, "user2", "unrestricted", nil, nil)
+
+	for _, token := range []string{restricted, scoped, unrestricted} {
+		req := NewRequestWithJSON(t, "POST", "/api/v1/users/user2/tokens", &api.CreateAccessTokenOption{
+			Name: "created-by-token",
+		})
+		req.SetBasicAuth("user2", token)
+		MakeRequest(t, req, http.StatusForbidden)
+
+		req = NewRequestWithJSON(t, "POST", "/api/v1/users/user2/tokens", &api.CreateAccessTokenOption{
+			Name: "created-by-token",
+		})
+		req.SetBasicAuth(token, "x-oauth-basic")
+		MakeRequest(t, req, http.StatusForbidden)
+	}
+	models.AssertNotExistsBean(t, &models.AccessToken{Name: "created-by-token"})
+
+	for _, token := range []string{restricted, scoped} {
+		for _, url := range []string{"/api/v1/user/keys", "/api/v1/user/gpg_keys"} {
+			req := NewRequestWithJSON(t, "POST", url+"?token="+token, &api.CreateKeyOption{
+				Title: "key",
+				Key:   "key",
+			})
+			MakeRequest(t, req, http.StatusForbidden)
+		}
+	}
+
+	// the unrestricted token gets through to the validation of the key
+	req := NewRequestWithJSON(t, "POST", "/api/v1/user/keys?token="+unrestricted, &api.CreateKeyOption{
+		Title: "key",
+		Key:   "key",
+	})
+	MakeRequest(t, req, http.StatusUnprocessableEntity)
+}
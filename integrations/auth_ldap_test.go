@@ -97,7 +97,12 @@ func getLDAPServerHost() string {
 	return host
 }
 
-func addAuthSourceLDAP(t *testing.T, sshKeyAttribute string) {
+func addAuthSourceLDAP(t *testing.T, sshKeyAttribute string, groupTeamMap ...string) {
+	var groupAttribute, groupTeamMapping string
+	if len(groupTeamMap) > 0 {
+		groupAttribute, groupTeamMapping = "memberOf", groupTeamMap[0]
+	}
+
 	session := loginUser(t, "user1")
 	csrf := GetCSRF(t, session, "/admin/auths/new")
 	req := NewRequestWithValues(t, "POST", "/admin/auths/new", map[string]string{
@@ -117,6 +122,8 @@ func addAuthSourceLDAP(t *testing.T, sshKeyAttribute string) {
 		"attribute_surname":        "sn",
 		"attribute_mail":           "mail",
 		"attribute_ssh_public_key": sshKeyAttribute,
+		"attribute_groups":         groupAttribute,
+		"group_team_map":           groupTeamMapping,
 		"is_sync_enabled":          "on",
 		"is_active":                "on",
 	})
@@ -240,3 +247,42 @@ func TestLDAPUserSSHKeySync(t *testing.T) {
 		assert.ElementsMatch(t, u.SSHKeys, syncedKeys)
 	}
 }
+
+func TestLDAPGroupTeamSync(t *testing.T) {
+	if skipLDAPTests() {
+		t.Skip()
+		return
+	}
+	defer prepareTestEnv(t)()
+	addAuthSourceLDAP(t, "", `{
+		"cn=ship_crew,ou=people,dc=planetexpress,dc=com": {"user3": ["team1"]},
+		"cn=admin_staff,ou=people,dc=planetexpress,dc=com": {"user3": ["test_team"]}
+	}`)
+	source := models.AssertExistsAndLoadBean(t, &models.LoginSource{Name: "ldap"}).(*models.LoginSource)
+
+	models.SyncExternalUsers(context.Background(), true)
+
+	fry := models.AssertExistsAndLoadBean(t, &models.User{Name: "fry"}).(*models.User)
+	professor := models.AssertExistsAndLoadBean(t, &models.User{Name: "professor"}).(*models.User)
+	models.AssertExistsAndLoadBean(t, &models.TeamUser{TeamID: 2, UID: fry.ID})
+	models.AssertNotExistsBean(t, &models.TeamUser{TeamID: 7, UID: fry.ID})
+	models.AssertExistsAndLoadBean(t, &models.TeamUser{TeamID: 7, UID: professor.ID})
+	models.AssertNotExistsBean(t, &models.TeamUser{TeamID: 2, UID: professor.ID})
+
+	// a membership not backed by a directory group is reported and removed
+	testTeam := models.AssertExistsAndLoadBean(t, &models.Team{ID: 7}).(*models.Team)
+	assert.NoError(t, models.AddTeamMember(testTeam, fry.ID))
+
+	session := loginUser(t, "user1")
+	req := NewRequestf(t, "GET", "/admin/auths/%d/team_sync", source.ID)
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	rows := NewHTMLParser(t, resp.Body).doc.Find("table.table tbody tr")
+	assert.Equal(t, 1, rows.Length())
+	assert.Contains(t, rows.Text(), "fry")
+	assert.Contains(t, rows.Text(), "user3/test_team")
+	models.AssertExistsAndLoadBean(t, &models.TeamUser{TeamID: 7, UID: fry.ID})
+
+	models.SyncExternalUsers(context.Background(), true)
+	models.AssertNotExistsBean(t, &models.TeamUser{TeamID: 7, UID: fry.ID})
+	models.AssertExistsAndLoadBean(t, &models.TeamUser{TeamID: 2, UID: fry.ID})
+}
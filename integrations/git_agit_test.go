@@ -0,0 +1,95 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAGitPullRequest(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		baseRepo := models.AssertExistsAndLoadBean(t, &models.Repository{OwnerName: "user2", Name: "repo1"}).(*models.Repository)
+
+		// user4 can only read user2/repo1
+		u.Path = "user2/repo1.git"
+		u.User = url.UserPassword("user4", userPassword)
+
+		dstPath, err := ioutil.TempDir("", "repo1-agit")
+		assert.NoError(t, err)
+		defer os.RemoveAll(dstPath)
+
+		t.Run("Clone", doGitClone(dstPath, u))
+
+		_, err = generateCommitWithNewData(littleSize, dstPath, "user4@example.com", "User Four", "agit-")
+		assert.NoError(t, err)
+
+		t.Run("PushBranchFails", doGitPushTestRepositoryFail(dstPath, "origin", "HEAD:refs/heads/agit"))
+		t.Run("PushTagFails", doGitPushTestRepositoryFail(dstPath, "origin", "HEAD:refs/tags/agit"))
+		t.Run("PushWithoutTopicFails", doGitPushTestRepositoryFail(dstPath, "origin", "HEAD:refs/for/master"))
+		t.Run("PushToMissingBranchFails", doGitPushTestRepositoryFail(dstPath, "origin", "HEAD:refs/for/missing/topic"))
+
+		t.Run("PushCreatesPullRequest", doGitPushTestRepository(dstPath, "origin", "HEAD:refs/for/master/topic"))
+		pr := models.AssertExistsAndLoadBean(t, &models.PullRequest{
+			BaseRepoID: baseRepo.ID,
+			HeadBranch: "user4/topic",
+			Flow:       models.PullRequestFlowAGit,
+		}).(*models.PullRequest)
+		assert.EqualValues(t, baseRepo.ID, pr.HeadRepoID)
+		assert.EqualValues(t, "master", pr.BaseBranch)
+		assert.False(t, git.IsReferenceExist(baseRepo.RepoPath(), "refs/for/master/topic"))
+
+		headCommitID, err := git.NewCommand("rev-parse", "HEAD").RunInDir(dstPath)
+		assert.NoError(t, err)
+		prHeadCommitID, err := git.GetFullCommitID(baseRepo.RepoPath(), pr.GetGitRefName())
+		assert.NoError(t, err)
+		assert.Equal(t, headCommitID[:40], prHeadCommitID)
+
+		// Pushing to the same topic again updates the pull request
+		_, err = generateCommitWithNewData(littleSize, dstPath, "user4@example.com", "User Four", "agit-")
+		assert.NoError(t, err)
+		t.Run("PushUpdatesPullRequest", doGitPushTestRepository(dstPath, "origin", "HEAD:refs/for/master/topic"))
+		assert.EqualValues(t, 1, models.GetCount(t, &models.PullRequest{HeadBranch: "user4/topic"}))
+
+		headCommitID, err = git.NewCommand("rev-parse", "HEAD").RunInDir(dstPath)
+		assert.NoError(t, err)
+		prHeadCommitID, err = git.GetFullCommitID(baseRepo.RepoPath(), pr.GetGitRefName())
+		assert.NoError(t, err)
+		assert.Equal(t, headCommitID[:40], prHeadCommitID)
+
+		// The pull request is found by its poster and topic, not by the recorded head
+		// branch, so it is still updated after the poster was renamed
+		user4 := models.AssertExistsAndLoadBean(t, &models.User{Name: "user4"}).(*models.User)
+		assert.NoError(t, models.ChangeUserName(user4, "user4-renamed"))
+		user4.Name, user4.LowerName = "user4-renamed", "user4-renamed"
+		assert.NoError(t, models.UpdateUserCols(user4, "name", "lower_name"))
+		u.User = url.UserPassword("user4-renamed", userPassword)
+		_, err = git.NewCommand("remote", "set-url", "origin", u.String()).RunInDir(dstPath)
+		assert.NoError(t, err)
+		_, err = generateCommitWithNewData(littleSize, dstPath, "user4@example.com", "User Four", "agit-")
+		assert.NoError(t, err)
+		t.Run("PushUpdatesPullRequestOfRenamedPoster", doGitPushTestRepository(dstPath, "origin", "HEAD:refs/for/master/topic"))
+		assert.EqualValues(t, 1, models.GetCount(t, &models.PullRequest{BaseRepoID: baseRepo.ID, AGitTopic: "topic"}))
+		headCommitID, err = git.NewCommand("rev-parse", "HEAD").RunInDir(dstPath)
+		assert.NoError(t, err)
+		prHeadCommitID, err = git.GetFullCommitID(baseRepo.RepoPath(), pr.GetGitRefName())
+		assert.NoError(t, err)
+		assert.Equal(t, headCommitID[:40], prHeadCommitID)
+
+		// The owner merges it like any other pull request
+		session := loginUser(t, "user2")
+		testPullMerge(t, session, "user2", "repo1", fmt.Sprintf("%d", pr.Index), models.MergeStyleMerge)
+		pr = models.AssertExistsAndLoadBean(t, &models.PullRequest{ID: pr.ID}).(*models.PullRequest)
+		assert.True(t, pr.HasMerged)
+	})
+}
@@ -0,0 +1,55 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/lfs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPutLFSMismatch(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	repo, err := models.GetRepositoryByOwnerAndName("user2", "repo1")
+	assert.NoError(t, err)
+	session := loginUser(t, "user2")
+	contentStore := lfs.NewContentStore()
+
+	// An object already in the store is not replaced by a corrupt upload
+	content := []byte("the stored content")
+	oid := storeObjectInRepo(t, repo.ID, &content)
+	defer repo.RemoveLFSMetaObjectByOid(oid)
+	req := NewRequestWithBody(t, "PUT", "/user2/repo1.git/info/lfs/objects/"+oid, bytes.NewReader([]byte("a corrupt content")))
+	session.MakeRequest(t, req, http.StatusOK)
+	rc, err := contentStore.Get(&models.LFSMetaObject{Oid: oid, Size: int64(len(content))}, 0)
+	if assert.NoError(t, err) {
+		defer rc.Close()
+		var stored bytes.Buffer
+		_, err = stored.ReadFrom(rc)
+		assert.NoError(t, err)
+		assert.Equal(t, content, stored.Bytes())
+	}
+
+	// A new object whose content does not match its OID is not stored
+	expected := []byte("the expected content")
+	newOid, err := GenerateLFSOid(bytes.NewReader(expected))
+	assert.NoError(t, err)
+	for _, body := range []string{"the unexpected content", "the expected contenT", "truncated"} {
+		// the failed upload removes the meta object
+		meta, err := models.NewLFSMetaObject(&models.LFSMetaObject{Oid: newOid, Size: int64(len(expected)), RepositoryID: repo.ID})
+		assert.NoError(t, err)
+		req = NewRequestWithBody(t, "PUT", "/user2/repo1.git/info/lfs/objects/"+newOid, bytes.NewReader([]byte(body)))
+		session.MakeRequest(t, req, http.StatusInternalServerError)
+		exist, err := contentStore.Exists(meta)
+		assert.NoError(t, err)
+		assert.False(t, exist)
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/repofiles"
+	"code.gitea.io/gitea/modules/test"
+	pull_service "code.gitea.io/gitea/services/pull"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testCreateCodeOwnersPull(t *testing.T, session *TestSession, repo *models.Repository, branch, title string) (*models.Issue, *models.PullRequest) {
+	link := fmt.Sprintf("/%s/%s/compare/master...%s", repo.OwnerName, repo.Name, branch)
+	req := NewRequest(t, "GET", link)
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	req = NewRequestWithValues(t, "POST", link, map[string]string{
+		"_csrf": htmlDoc.GetCSRF(),
+		"title": title,
+	})
+	resp = session.MakeRequest(t, req, http.StatusFound)
+	elem := strings.Split(test.RedirectURL(resp), "/")
+	assert.EqualValues(t, "pulls", elem[3])
+
+	assert.NoError(t, models.UpdateProtectBranch(repo, &models.ProtectedBranch{
+		RepoID:                   repo.ID,
+		BranchName:               "master",
+		RequireCodeOwnerApproval: true,
+	}, models.WhitelistOptions{}))
+
+	issue := models.AssertExistsAndLoadBean(t, &models.Issue{RepoID: repo.ID, Title: title}).(*models.Issue)
+	assert.NoError(t, issue.LoadRepo())
+	pr, err := issue.GetPullRequest()
+	assert.NoError(t, err)
+	return issue, pr
+}
+
+func TestPullCodeOwnerApproval(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, giteaURL *url.URL) {
+		user2 := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+		user5 := models.AssertExistsAndLoadBean(t, &models.User{ID: 5}).(*models.User)
+		repo1 := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+
+		_, err := repofiles.CreateOrUpdateRepoFile(repo1, user2, &repofiles.UpdateRepoFileOptions{
+			OldBranch: repo1.DefaultBranch,
+			TreePath:  "CODEOWNERS",
+			Content:   "README.md @user5\n",
+			IsNewFile: true,
+		})
+		assert.NoError(t, err)
+
+		session := loginUser(t, "user2")
+		testEditFileToNewBranch(t, session, "user2", "repo1", "master", "codeowners", "README.md", "Hello, code owners!")
+		issue, pr := testCreateCodeOwnersPull(t, session, repo1, "codeowners", "Change a file owned by user5")
+
+		gitRepo, err := git.OpenRepository(repo1.RepoPath())
+		assert.NoError(t, err)
+		defer gitRepo.Close()
+		headCommitID, err := gitRepo.GetRefCommitID(pr.GetGitRefName())
+		assert.NoError(t, err)
+
+		// user5 can read but not write the repository, the approval is not official
+		review, _, err := pull_service.SubmitReview(user5, gitRepo, issue, models.ReviewTypeApprove, "LGTM", headCommitID)
+		assert.NoError(t, err)
+		assert.False(t, review.Official)
+
+		approved, err := pull_service.HasCodeOwnerApprovals(pr)
+		assert.NoError(t, err)
+		assert.False(t, approved)
+		assert.True(t, models.IsErrNotAllowedToMerge(pull_service.CheckPRReadyToMerge(pr)))
+
+		// as a collaborator with write access the approval of user5 is official
+		assert.NoError(t, repo1.AddCollaborator(user5))
+		review, _, err = pull_service.SubmitReview(user5, gitRepo, issue, models.ReviewTypeApprove, "LGTM", headCommitID)
+		assert.NoError(t, err)
+		assert.True(t, review.Official)
+
+		approved, err = pull_service.HasCodeOwnerApprovals(pr)
+		assert.NoError(t, err)
+		assert.True(t, approved)
+		assert.NoError(t, pull_service.CheckPRReadyToMerge(pr))
+	})
+}
+
+func TestPullCodeOwnerMovedFile(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, giteaURL *url.URL) {
+		user2 := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+		repo1 := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+
+		_, err := repofiles.CreateOrUpdateRepoFile(repo1, user2, &repofiles.UpdateRepoFileOptions{
+			OldBranch: repo1.DefaultBranch,
+			TreePath:  "CODEOWNERS",
+			Content:   "README.md @user5\n",
+			IsNewFile: true,
+		})
+		assert.NoError(t, err)
+
+		gitRepo, err := git.OpenRepository(repo1.RepoPath())
+		assert.NoError(t, err)
+		defer gitRepo.Close()
+		commit, err := gitRepo.GetBranchCommit(repo1.DefaultBranch)
+		assert.NoError(t, err)
+		entry, err := commit.GetTreeEntryByPath("README.md")
+		assert.NoError(t, err)
+		content, err := entry.Blob().GetBlobContent()
+		assert.NoError(t, err)
+
+		// moving the owned file out of the owned path is a change of the owned file
+		_, err = repofiles.CreateOrUpdateRepoFile(repo1, user2, &repofiles.UpdateRepoFileOptions{
+			OldBranch:    repo1.DefaultBranch,
+			NewBranch:    "move-readme",
+			FromTreePath: "README.md",
+			TreePath:     "MOVED.md",
+			SHA:          entry.ID.String(),
+			Content:      content,
+		})
+		assert.NoError(t, err)
+
+		session := loginUser(t, "user2")
+		_, pr := testCreateCodeOwnersPull(t, session, repo1, "move-readme", "Move a file owned by user5")
+
+		approved, err := pull_service.HasCodeOwnerApprovals(pr)
+		assert.NoError(t, err)
+		assert.False(t, approved)
+		assert.True(t, models.IsErrNotAllowedToMerge(pull_service.CheckPRReadyToMerge(pr)))
+	})
+}
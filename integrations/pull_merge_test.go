@@ -17,7 +17,9 @@ import (
 	"time"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/auth"
 	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/repofiles"
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/test"
 	"code.gitea.io/gitea/services/pull"
@@ -125,6 +127,80 @@ func TestPullRebaseMerge(t *testing.T) {
 	})
 }
 
+func testAllowMergeStyles(t *testing.T, session *TestSession, user, repo string, opts api.EditRepoOption) {
+	hasPullRequests := true
+	opts.HasPullRequests = &hasPullRequests
+	token := getTokenForLoggedInUser(t, session)
+	req := NewRequestWithJSON(t, "PATCH", fmt.Sprintf("/api/v1/repos/%s/%s?token=%s", user, repo, token), &opts)
+	session.MakeRequest(t, req, http.StatusOK)
+}
+
+func TestPullFastForwardOnly(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, giteaURL *url.URL) {
+		session := loginUser(t, "user1")
+		testRepoFork(t, session, "user2", "repo1", "user1", "repo1")
+		testEditFile(t, session, "user1", "repo1", "master", "README.md", "Hello, World (Edited)\n")
+
+		allow := true
+		testAllowMergeStyles(t, session, "user2", "repo1", api.EditRepoOption{AllowFastForwardOnly: &allow})
+
+		resp := testPullCreate(t, session, "user1", "repo1", "master", "This is a pull title")
+
+		elem := strings.Split(test.RedirectURL(resp), "/")
+		assert.EqualValues(t, "pulls", elem[3])
+		testPullMerge(t, session, elem[1], elem[2], elem[4], models.MergeStyleFastForwardOnly)
+
+		// The base branch must now point at the head commit itself
+		headRepo, err := git.OpenRepository(models.RepoPath("user1", "repo1"))
+		assert.NoError(t, err)
+		defer headRepo.Close()
+		headCommitID, err := headRepo.GetBranchCommitID("master")
+		assert.NoError(t, err)
+
+		baseRepo, err := git.OpenRepository(models.RepoPath("user2", "repo1"))
+		assert.NoError(t, err)
+		defer baseRepo.Close()
+		baseCommitID, err := baseRepo.GetBranchCommitID("master")
+		assert.NoError(t, err)
+		assert.EqualValues(t, headCommitID, baseCommitID)
+	})
+}
+
+func TestPullSemiLinear(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, giteaURL *url.URL) {
+		session := loginUser(t, "user1")
+		testRepoFork(t, session, "user2", "repo1", "user1", "repo1")
+		testEditFile(t, session, "user1", "repo1", "master", "README.md", "Hello, World (Edited)\n")
+
+		allow := true
+		testAllowMergeStyles(t, session, "user2", "repo1", api.EditRepoOption{AllowSemiLinear: &allow})
+
+		resp := testPullCreate(t, session, "user1", "repo1", "master", "This is a pull title")
+
+		elem := strings.Split(test.RedirectURL(resp), "/")
+		assert.EqualValues(t, "pulls", elem[3])
+		testPullMerge(t, session, elem[1], elem[2], elem[4], models.MergeStyleSemiLinear)
+
+		// Even though the head could be fast-forwarded a merge commit must be created,
+		// which merges the head commit itself
+		headRepo, err := git.OpenRepository(models.RepoPath("user1", "repo1"))
+		assert.NoError(t, err)
+		defer headRepo.Close()
+		headCommitID, err := headRepo.GetBranchCommitID("master")
+		assert.NoError(t, err)
+
+		baseRepo, err := git.OpenRepository(models.RepoPath("user2", "repo1"))
+		assert.NoError(t, err)
+		defer baseRepo.Close()
+		commit, err := baseRepo.GetBranchCommit("master")
+		assert.NoError(t, err)
+		assert.EqualValues(t, 2, commit.ParentCount())
+		mergedID, err := commit.ParentID(1)
+		assert.NoError(t, err)
+		assert.EqualValues(t, headCommitID, mergedID.String())
+	})
+}
+
 func TestPullSquash(t *testing.T) {
 	onGiteaRun(t, func(t *testing.T, giteaURL *url.URL) {
 		hookTasks, err := models.HookTasks(1, 1) //Retrieve previous hook number
@@ -183,6 +259,189 @@ func TestPullCleanUpAfterMerge(t *testing.T) {
 	})
 }
 
+func TestPullAutoMergeWhenChecksSucceed(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, giteaURL *url.URL) {
+		session := loginUser(t, "user1")
+		testRepoFork(t, session, "user2", "repo1", "user1", "repo1")
+		testEditFile(t, session, "user1", "repo1", "master", "README.md", "Hello, World (Edited)\n")
+
+		resp := testPullCreate(t, session, "user1", "repo1", "master", "This is a pull title")
+		elem := strings.Split(test.RedirectURL(resp), "/")
+		assert.EqualValues(t, "pulls", elem[3])
+
+		ownerSession := loginUser(t, "user2")
+		token := getTokenForLoggedInUser(t, ownerSession)
+		req := NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/branch_protections?token="+token, &api.CreateBranchProtectionOption{
+			BranchName:          "master",
+			EnableStatusCheck:   true,
+			StatusCheckContexts: []string{"testci"},
+		})
+		ownerSession.MakeRequest(t, req, http.StatusCreated)
+
+		// The required status is missing, so the merge is only scheduled
+		mergeURL := fmt.Sprintf("/api/v1/repos/user2/repo1/pulls/%s/merge?token=%s", elem[4], token)
+		mergeForm := &auth.MergePullRequestForm{
+			Do:                     string(models.MergeStyleMerge),
+			MergeWhenChecksSucceed: true,
+		}
+		req = NewRequestWithJSON(t, "POST", mergeURL, mergeForm)
+		ownerSession.MakeRequest(t, req, http.StatusAccepted)
+		req = NewRequestWithJSON(t, "POST", mergeURL, mergeForm)
+		ownerSession.MakeRequest(t, req, http.StatusConflict)
+
+		req = NewRequest(t, "GET", path.Join("user2", "repo1", "pulls", elem[4]))
+		resp = ownerSession.MakeRequest(t, req, http.StatusOK)
+		htmlDoc := NewHTMLParser(t, resp.Body)
+		assert.EqualValues(t, 1, htmlDoc.doc.Find("form[action$='/cancel_auto_merge']").Length())
+
+		baseRepo := models.AssertExistsAndLoadBean(t, &models.Repository{OwnerName: "user2", Name: "repo1"}).(*models.Repository)
+		pr := models.AssertExistsAndLoadBean(t, &models.PullRequest{BaseRepoID: baseRepo.ID, HeadBranch: "master"}).(*models.PullRequest)
+		assert.False(t, pr.HasMerged)
+		models.AssertExistsAndLoadBean(t, &models.PullAutoMerge{PullID: pr.ID})
+
+		headRepo, err := git.OpenRepository(models.RepoPath("user1", "repo1"))
+		assert.NoError(t, err)
+		defer headRepo.Close()
+		headCommitID, err := headRepo.GetBranchCommitID("master")
+		assert.NoError(t, err)
+
+		req = NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/repos/user2/repo1/statuses/%s?token=%s", headCommitID, token), api.CreateStatusOption{
+			State:   api.StatusState(api.CommitStatusSuccess),
+			Context: "testci",
+		})
+		ownerSession.MakeRequest(t, req, http.StatusCreated)
+
+		// The merge happens in the background once the status is reported, and the
+		// scheduled merge is only removed after the pull request is marked as merged
+		scheduled := true
+		for i := 0; i < 100 && (!pr.HasMerged || scheduled); i++ {
+			time.Sleep(100 * time.Millisecond)
+			pr = models.AssertExistsAndLoadBean(t, &models.PullRequest{ID: pr.ID}).(*models.PullRequest)
+			scheduled = models.GetCount(t, &models.PullAutoMerge{PullID: pr.ID}) > 0
+		}
+		assert.True(t, pr.HasMerged)
+		models.AssertNotExistsBean(t, &models.PullAutoMerge{PullID: pr.ID})
+	})
+}
+
+func TestPullAutoMergeUpdatesOutdatedHead(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, giteaURL *url.URL) {
+		session := loginUser(t, "user2")
+		token := getTokenForLoggedInUser(t, session)
+		testEditFileToNewBranch(t, session, "user2", "repo1", "master", "outdated", "README.md", "Hello, World (Edited)\n")
+		req := NewRequest(t, "GET", "/user2/repo1/compare/master...outdated")
+		resp := session.MakeRequest(t, req, http.StatusOK)
+		req = NewRequestWithValues(t, "POST", "/user2/repo1/compare/master...outdated", map[string]string{
+			"_csrf": NewHTMLParser(t, resp.Body).GetCSRF(),
+			"title": "This is a pull title",
+		})
+		resp = session.MakeRequest(t, req, http.StatusFound)
+		elem := strings.Split(test.RedirectURL(resp), "/")
+		assert.EqualValues(t, "pulls", elem[3])
+
+		// the base branch moves on after the pull request was created
+		user2 := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+		baseRepo := models.AssertExistsAndLoadBean(t, &models.Repository{OwnerName: "user2", Name: "repo1"}).(*models.Repository)
+		_, err := repofiles.CreateOrUpdateRepoFile(baseRepo, user2, &repofiles.UpdateRepoFileOptions{
+			OldBranch: "master",
+			TreePath:  "other.txt",
+			Content:   "other",
+			IsNewFile: true,
+		})
+		assert.NoError(t, err)
+
+		req = NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/branch_protections?token="+token, &api.CreateBranchProtectionOption{
+			BranchName:          "master",
+			EnableStatusCheck:   true,
+			StatusCheckContexts: []string{"testci"},
+		})
+		session.MakeRequest(t, req, http.StatusCreated)
+
+		mergeURL := fmt.Sprintf("/api/v1/repos/user2/repo1/pulls/%s/merge?token=%s", elem[4], token)
+		req = NewRequestWithJSON(t, "POST", mergeURL, &auth.MergePullRequestForm{
+			Do:                     string(models.MergeStyleMerge),
+			MergeWhenChecksSucceed: true,
+		})
+		session.MakeRequest(t, req, http.StatusAccepted)
+		pr := models.AssertExistsAndLoadBean(t, &models.PullRequest{BaseRepoID: baseRepo.ID, HeadBranch: "outdated"}).(*models.PullRequest)
+
+		gitRepo, err := git.OpenRepository(baseRepo.RepoPath())
+		assert.NoError(t, err)
+		defer gitRepo.Close()
+		reportSuccess := func(commitID string) {
+			req := NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/repos/user2/repo1/statuses/%s?token=%s", commitID, token), api.CreateStatusOption{
+				State:   api.StatusState(api.CommitStatusSuccess),
+				Context: "testci",
+			})
+			session.MakeRequest(t, req, http.StatusCreated)
+		}
+
+		// the checks of the head commit do not cover the new commit of the base branch,
+		// the base branch is merged into the head branch instead of merging the pull request
+		headCommitID, err := gitRepo.GetBranchCommitID("outdated")
+		assert.NoError(t, err)
+		reportSuccess(headCommitID)
+		updatedCommitID := headCommitID
+		for i := 0; i < 100 && updatedCommitID == headCommitID; i++ {
+			time.Sleep(100 * time.Millisecond)
+			updatedCommitID, err = gitRepo.GetBranchCommitID("outdated")
+			assert.NoError(t, err)
+		}
+		assert.NotEqual(t, headCommitID, updatedCommitID)
+		pr = models.AssertExistsAndLoadBean(t, &models.PullRequest{ID: pr.ID}).(*models.PullRequest)
+		assert.False(t, pr.HasMerged)
+		models.AssertExistsAndLoadBean(t, &models.PullAutoMerge{PullID: pr.ID})
+
+		// the combined commit is merged once its checks succeed
+		reportSuccess(updatedCommitID)
+		for i := 0; i < 100 && !pr.HasMerged; i++ {
+			time.Sleep(100 * time.Millisecond)
+			pr = models.AssertExistsAndLoadBean(t, &models.PullRequest{ID: pr.ID}).(*models.PullRequest)
+		}
+		assert.True(t, pr.HasMerged)
+	})
+}
+
+func TestPullAutoMergeCancelledByPush(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, giteaURL *url.URL) {
+		session := loginUser(t, "user4")
+		testRepoFork(t, session, "user2", "repo1", "user4", "repo1")
+		testEditFile(t, session, "user4", "repo1", "master", "README.md", "Hello, World (Edited)\n")
+		resp := testPullCreate(t, session, "user4", "repo1", "master", "This is a pull title")
+		elem := strings.Split(test.RedirectURL(resp), "/")
+		assert.EqualValues(t, "pulls", elem[3])
+
+		ownerSession := loginUser(t, "user2")
+		token := getTokenForLoggedInUser(t, ownerSession)
+		req := NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/branch_protections?token="+token, &api.CreateBranchProtectionOption{
+			BranchName:          "master",
+			EnableStatusCheck:   true,
+			StatusCheckContexts: []string{"testci"},
+		})
+		ownerSession.MakeRequest(t, req, http.StatusCreated)
+		req = NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/repos/user2/repo1/pulls/%s/merge?token=%s", elem[4], token), &auth.MergePullRequestForm{
+			Do:                     string(models.MergeStyleMerge),
+			MergeWhenChecksSucceed: true,
+		})
+		ownerSession.MakeRequest(t, req, http.StatusAccepted)
+
+		baseRepo := models.AssertExistsAndLoadBean(t, &models.Repository{OwnerName: "user2", Name: "repo1"}).(*models.Repository)
+		pr := models.AssertExistsAndLoadBean(t, &models.PullRequest{BaseRepoID: baseRepo.ID, HeadBranch: "master"}).(*models.PullRequest)
+		models.AssertExistsAndLoadBean(t, &models.PullAutoMerge{PullID: pr.ID})
+
+		// user4 can not write to the base repository, the commits pushed after the merge was
+		// scheduled must not be merged without another look
+		testEditFile(t, session, "user4", "repo1", "master", "README.md", "Hello, World (Edited again)\n")
+		scheduled := true
+		for i := 0; i < 100 && scheduled; i++ {
+			time.Sleep(100 * time.Millisecond)
+			scheduled = models.GetCount(t, &models.PullAutoMerge{PullID: pr.ID}) > 0
+		}
+		assert.False(t, scheduled)
+		models.AssertExistsAndLoadBean(t, &models.Comment{Type: models.CommentTypePRUnScheduledToAutoMerge, IssueID: pr.IssueID, PosterID: 4})
+	})
+}
+
 func TestCantMergeWorkInProgress(t *testing.T) {
 	onGiteaRun(t, func(t *testing.T, giteaURL *url.URL) {
 		session := loginUser(t, "user1")
@@ -249,6 +508,77 @@ func TestCantMergeConflict(t *testing.T) {
 	})
 }
 
+func TestCantMergeDivergingBranches(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, giteaURL *url.URL) {
+		session := loginUser(t, "user1")
+		testRepoFork(t, session, "user2", "repo1", "user1", "repo1")
+		testEditFileToNewBranch(t, session, "user1", "repo1", "master", "diverging", "README.md", "Hello, World (Edited Once)\n")
+
+		allow := true
+		testAllowMergeStyles(t, session, "user1", "repo1", api.EditRepoOption{
+			AllowFastForwardOnly: &allow,
+			AllowSemiLinear:      &allow,
+		})
+
+		user1 := models.AssertExistsAndLoadBean(t, &models.User{
+			Name: "user1",
+		}).(*models.User)
+		repo1 := models.AssertExistsAndLoadBean(t, &models.Repository{
+			OwnerID: user1.ID,
+			Name:    "repo1",
+		}).(*models.Repository)
+
+		// The base moves on without conflicting with the head
+		_, err := createFileInBranch(user1, repo1, "new-file.txt", "master")
+		assert.NoError(t, err)
+
+		token := getTokenForLoggedInUser(t, session)
+		req := NewRequestWithJSON(t, http.MethodPost, fmt.Sprintf("/api/v1/repos/%s/%s/pulls?token=%s", "user1", "repo1", token), &api.CreatePullRequestOption{
+			Head:  "diverging",
+			Base:  "master",
+			Title: "create a diverging pr",
+		})
+		session.MakeRequest(t, req, 201)
+
+		pr := models.AssertExistsAndLoadBean(t, &models.PullRequest{
+			HeadRepoID: repo1.ID,
+			BaseRepoID: repo1.ID,
+			HeadBranch: "diverging",
+			BaseBranch: "master",
+		}).(*models.PullRequest)
+
+		gitRepo, err := git.OpenRepository(models.RepoPath(user1.Name, repo1.Name))
+		assert.NoError(t, err)
+		defer gitRepo.Close()
+
+		err = pull.Merge(pr, user1, gitRepo, models.MergeStyleFastForwardOnly, "DIVERGING")
+		assert.Error(t, err, "Merge should return an error due to diverging branches")
+		assert.True(t, models.IsErrMergeDivergingBranches(err), "Merge error is not a diverging branches error")
+
+		// Semi-linear rebases the head, which is behind the base, onto the base and
+		// creates a merge commit
+		baseCommitID, err := gitRepo.GetBranchCommitID("master")
+		assert.NoError(t, err)
+		headCommitID, err := gitRepo.GetBranchCommitID("diverging")
+		assert.NoError(t, err)
+		assert.NoError(t, pull.Merge(pr, user1, gitRepo, models.MergeStyleSemiLinear, "DIVERGING"))
+		commit, err := gitRepo.GetBranchCommit("master")
+		assert.NoError(t, err)
+		assert.EqualValues(t, 2, commit.ParentCount())
+		firstParentID, err := commit.ParentID(0)
+		assert.NoError(t, err)
+		assert.EqualValues(t, baseCommitID, firstParentID.String())
+		mergedID, err := commit.ParentID(1)
+		assert.NoError(t, err)
+		assert.NotEqual(t, headCommitID, mergedID.String())
+		merged, err := gitRepo.GetCommit(mergedID.String())
+		assert.NoError(t, err)
+		rebasedOntoID, err := merged.ParentID(0)
+		assert.NoError(t, err)
+		assert.EqualValues(t, baseCommitID, rebasedOntoID.String())
+	})
+}
+
 func TestCantMergeUnrelated(t *testing.T) {
 	onGiteaRun(t, func(t *testing.T, giteaURL *url.URL) {
 		session := loginUser(t, "user1")
@@ -4,8 +4,17 @@
 package integrations
 
 import (
+	"fmt"
 	"net/http"
+	"net/url"
+	"path"
+	"strings"
 	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/test"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestPullView_ReviewerMissed(t *testing.T) {
@@ -18,3 +27,148 @@ func TestPullView_ReviewerMissed(t *testing.T) {
 	req = NewRequest(t, "GET", "/user2/repo1/pulls/3")
 	session.MakeRequest(t, req, http.StatusOK)
 }
+
+func TestPullApplySuggestions(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		session := loginUser(t, "user1")
+		testRepoFork(t, session, "user2", "repo1", "user1", "repo1")
+		testEditFile(t, session, "user1", "repo1", "master", "README.md", "Hello, World (Edited)\nSecond line\n")
+		resp := testPullCreate(t, session, "user1", "repo1", "master", "This is a pull title")
+		elem := strings.Split(test.RedirectURL(resp), "/")
+		assert.EqualValues(t, "pulls", elem[3])
+		filesURL := path.Join(elem[1], elem[2], "pulls", elem[4], "files")
+
+		// The owner of the base repository suggests a change
+		ownerSession := loginUser(t, "user2")
+		req := NewRequest(t, "GET", filesURL)
+		resp = ownerSession.MakeRequest(t, req, http.StatusOK)
+		htmlDoc := NewHTMLParser(t, resp.Body)
+		req = NewRequestWithValues(t, "POST", filesURL+"/reviews/comments", map[string]string{
+			"_csrf":   htmlDoc.GetCSRF(),
+			"content": "Better:\n```suggestion\nSecond line, improved\n```",
+			"side":    "proposed",
+			"line":    "2",
+			"path":    "README.md",
+		})
+		ownerSession.MakeRequest(t, req, http.StatusFound)
+		comment := models.AssertExistsAndLoadBean(t, &models.Comment{Type: models.CommentTypeCode, TreePath: "README.md", Line: 2}).(*models.Comment)
+
+		// It is shown as a diff, but the owner can not push to the head branch
+		req = NewRequest(t, "GET", filesURL)
+		resp = ownerSession.MakeRequest(t, req, http.StatusOK)
+		htmlDoc = NewHTMLParser(t, resp.Body)
+		assert.EqualValues(t, 1, htmlDoc.doc.Find("code.language-diff").Length())
+		assert.EqualValues(t, 0, htmlDoc.doc.Find("#apply-suggestions-form").Length())
+		req = NewRequestWithValues(t, "POST", filesURL+"/suggestions", map[string]string{
+			"_csrf":       htmlDoc.GetCSRF(),
+			"comment_ids": fmt.Sprintf("%d", comment.ID),
+		})
+		ownerSession.MakeRequest(t, req, http.StatusFound)
+
+		req = NewRequest(t, "GET", "/user1/repo1/raw/branch/master/README.md")
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		assert.EqualValues(t, "Hello, World (Edited)\nSecond line\n", resp.Body.String())
+
+		// The author of the pull request applies it
+		req = NewRequest(t, "GET", filesURL)
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		htmlDoc = NewHTMLParser(t, resp.Body)
+		assert.EqualValues(t, 1, htmlDoc.doc.Find(fmt.Sprintf("input[name=comment_ids][value='%d']", comment.ID)).Length())
+		link, exists := htmlDoc.doc.Find("#apply-suggestions-form").Attr("action")
+		assert.True(t, exists, "The template has changed")
+		req = NewRequestWithValues(t, "POST", link, map[string]string{
+			"_csrf":       htmlDoc.GetCSRF(),
+			"comment_ids": fmt.Sprintf("%d", comment.ID),
+		})
+		session.MakeRequest(t, req, http.StatusFound)
+
+		req = NewRequest(t, "GET", "/user1/repo1/raw/branch/master/README.md")
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		assert.EqualValues(t, "Hello, World (Edited)\nSecond line, improved\n", resp.Body.String())
+
+		// The commented line has changed, so the suggestion does not apply anymore
+		req = NewRequestWithValues(t, "POST", link, map[string]string{
+			"_csrf":       htmlDoc.GetCSRF(),
+			"comment_ids": fmt.Sprintf("%d", comment.ID),
+		})
+		session.MakeRequest(t, req, http.StatusFound)
+		flashCookie := session.GetCookie("macaron_flash")
+		assert.NotNil(t, flashCookie)
+		assert.Contains(t, flashCookie.Value, "error")
+
+		req = NewRequest(t, "GET", "/user1/repo1/raw/branch/master/README.md")
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		assert.EqualValues(t, "Hello, World (Edited)\nSecond line, improved\n", resp.Body.String())
+	})
+}
+
+func TestPullMultiLineAndFileComments(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		session := loginUser(t, "user1")
+		testRepoFork(t, session, "user2", "repo1", "user1", "repo1")
+		testEditFile(t, session, "user1", "repo1", "master", "README.md", "Hello, World (Edited)\nSecond line\nThird line\n")
+		resp := testPullCreate(t, session, "user1", "repo1", "master", "This is a pull title")
+		elem := strings.Split(test.RedirectURL(resp), "/")
+		assert.EqualValues(t, "pulls", elem[3])
+		filesURL := path.Join(elem[1], elem[2], "pulls", elem[4], "files")
+
+		req := NewRequest(t, "GET", filesURL)
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		htmlDoc := NewHTMLParser(t, resp.Body)
+		assert.EqualValues(t, 1, htmlDoc.doc.Find(".diff-file-comments input[name=is_file_comment][value=true]").Length())
+
+		req = NewRequestWithValues(t, "POST", filesURL+"/reviews/comments", map[string]string{
+			"_csrf":           htmlDoc.GetCSRF(),
+			"content":         "On the whole file",
+			"side":            "proposed",
+			"path":            "README.md",
+			"is_file_comment": "true",
+		})
+		session.MakeRequest(t, req, http.StatusFound)
+		models.AssertExistsAndLoadBean(t, &models.Comment{Type: models.CommentTypeCode, TreePath: "README.md", IsFileComment: true, Line: 0})
+
+		// The lines must be on the same side of the diff
+		req = NewRequestWithValues(t, "POST", filesURL+"/reviews/comments", map[string]string{
+			"_csrf":      htmlDoc.GetCSRF(),
+			"content":    "Backwards",
+			"side":       "proposed",
+			"start_line": "3",
+			"line":       "2",
+			"path":       "README.md",
+		})
+		session.MakeRequest(t, req, http.StatusFound)
+		flashCookie := session.GetCookie("macaron_flash")
+		assert.NotNil(t, flashCookie)
+		assert.Contains(t, flashCookie.Value, "error")
+
+		req = NewRequestWithValues(t, "POST", filesURL+"/reviews/comments", map[string]string{
+			"_csrf":      htmlDoc.GetCSRF(),
+			"content":    "```suggestion\nSecond and third lines\n```",
+			"side":       "proposed",
+			"start_line": "2",
+			"line":       "3",
+			"path":       "README.md",
+		})
+		session.MakeRequest(t, req, http.StatusFound)
+		comment := models.AssertExistsAndLoadBean(t, &models.Comment{Type: models.CommentTypeCode, TreePath: "README.md", StartLine: 2, Line: 3}).(*models.Comment)
+
+		req = NewRequest(t, "GET", filesURL)
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		htmlDoc = NewHTMLParser(t, resp.Body)
+		assert.Contains(t, htmlDoc.doc.Find(".diff-file-comments .render-content").Text(), "On the whole file")
+		assert.EqualValues(t, 1, htmlDoc.doc.Find(".comment-lines").Length())
+
+		// A suggestion replaces all the commented lines
+		link, exists := htmlDoc.doc.Find("#apply-suggestions-form").Attr("action")
+		assert.True(t, exists, "The template has changed")
+		req = NewRequestWithValues(t, "POST", link, map[string]string{
+			"_csrf":       htmlDoc.GetCSRF(),
+			"comment_ids": fmt.Sprintf("%d", comment.ID),
+		})
+		session.MakeRequest(t, req, http.StatusFound)
+
+		req = NewRequest(t, "GET", "/user1/repo1/raw/branch/master/README.md")
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		assert.EqualValues(t, "Hello, World (Edited)\nSecond and third lines\n", resp.Body.String())
+	})
+}
@@ -0,0 +1,195 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	samlauth "code.gitea.io/gitea/modules/auth/saml"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/crewjam/saml"
+	"github.com/crewjam/saml/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+// samlTestIdP is a local stand-in for a SAML identity provider that signs in
+// whatever session it is given without asking for credentials
+type samlTestIdP struct {
+	*saml.IdentityProvider
+	spMetadata *saml.EntityDescriptor
+	session    *saml.Session
+}
+
+func (idp *samlTestIdP) GetServiceProvider(r *http.Request, serviceProviderID string) (*saml.EntityDescriptor, error) {
+	return idp.spMetadata, nil
+}
+
+func (idp *samlTestIdP) GetSession(w http.ResponseWriter, r *http.Request, req *saml.IdpAuthnRequest) *saml.Session {
+	return idp.session
+}
+
+func newSAMLTestIdP(t *testing.T) *samlTestIdP {
+	certPEM, keyPEM, err := samlauth.GenerateKeyPair("idp.example.com")
+	assert.NoError(t, err)
+	keyPair, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+	assert.NoError(t, err)
+	keyPair.Leaf, err = x509.ParseCertificate(keyPair.Certificate[0])
+	assert.NoError(t, err)
+
+	metadataURL, _ := url.Parse("https://idp.example.com/metadata")
+	ssoURL, _ := url.Parse("https://idp.example.com/sso")
+	idp := &samlTestIdP{
+		IdentityProvider: &saml.IdentityProvider{
+			Key:         keyPair.PrivateKey,
+			Certificate: keyPair.Leaf,
+			Logger:      logger.DefaultLogger,
+			MetadataURL: *metadataURL,
+			SSOURL:      *ssoURL,
+		},
+	}
+	idp.ServiceProviderProvider = idp
+	idp.SessionProvider = idp
+	return idp
+}
+
+func addAuthSourceSAML(t *testing.T, idp *samlTestIdP) {
+	metadata, err := xml.Marshal(idp.Metadata())
+	assert.NoError(t, err)
+
+	session := loginUser(t, "user1")
+	csrf := GetCSRF(t, session, "/admin/auths/new")
+	req := NewRequestWithValues(t, "POST", "/admin/auths/new", map[string]string{
+		"_csrf":                           csrf,
+		"type":                            "8",
+		"name":                            "idp",
+		"saml_identity_provider_metadata": string(metadata),
+		"saml_attribute_username":         "uid",
+		"saml_attribute_email":            "eduPersonPrincipalName",
+		"saml_attribute_full_name":        "cn",
+		"saml_attribute_groups":           "eduPersonAffiliation",
+		"saml_group_team_map":             `{"developers": {"user3": ["team1"]}}`,
+		"saml_group_team_map_removal":     "on",
+		"is_active":                       "on",
+	})
+	session.MakeRequest(t, req, http.StatusFound)
+
+	req = NewRequest(t, "GET", "/user/saml/idp/metadata")
+	resp := MakeRequest(t, req, http.StatusOK)
+	idp.spMetadata = &saml.EntityDescriptor{}
+	assert.NoError(t, xml.Unmarshal(resp.Body.Bytes(), idp.spMetadata))
+}
+
+// samlSignIn runs the SP initiated sign in flow through the identity provider
+// and returns the response of the assertion consumer service
+func samlSignIn(t *testing.T, session *TestSession, idp *samlTestIdP, expectedStatus int) *httptest.ResponseRecorder {
+	req := NewRequest(t, "GET", "/user/saml/idp")
+	resp := session.MakeRequest(t, req, http.StatusFound)
+	location := resp.Header().Get("Location")
+	assert.Contains(t, location, "https://idp.example.com/sso?")
+
+	idpResp := httptest.NewRecorder()
+	idp.ServeSSO(idpResp, httptest.NewRequest("GET", location, nil))
+	assert.Equal(t, http.StatusOK, idpResp.Code)
+	htmlDoc := NewHTMLParser(t, idpResp.Body)
+	samlResponse := htmlDoc.GetInputValueByName("SAMLResponse")
+	assert.NotEmpty(t, samlResponse)
+
+	// the response is a cross-site POST from the identity provider, which only
+	// carries the request tracking cookie and not the SameSite=Lax session cookie
+	req = NewRequestWithValues(t, "POST", "/user/saml/idp/acs", map[string]string{
+		"SAMLResponse": samlResponse,
+		"RelayState":   htmlDoc.GetInputValueByName("RelayState"),
+	})
+	baseURL, err := url.Parse(setting.AppURL)
+	assert.NoError(t, err)
+	for _, c := range session.jar.Cookies(baseURL) {
+		if strings.HasPrefix(c.Name, "saml_") {
+			req.AddCookie(c)
+		}
+	}
+	resp = MakeRequest(t, req, expectedStatus)
+
+	ch := http.Header{}
+	ch.Add("Cookie", strings.Join(resp.Header()["Set-Cookie"], ";"))
+	cr := http.Request{Header: ch}
+	session.jar.SetCookies(baseURL, cr.Cookies())
+	return resp
+}
+
+func TestSAMLSignIn(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	idp := newSAMLTestIdP(t)
+	addAuthSourceSAML(t, idp)
+	source := models.AssertExistsAndLoadBean(t, &models.LoginSource{Name: "idp"}).(*models.LoginSource)
+	assert.True(t, source.IsSAML())
+	assert.NotEmpty(t, source.SAML().ServiceProviderPrivateKey)
+
+	idp.session = &saml.Session{
+		ID:             "session-1",
+		NameID:         "subject-1",
+		UserName:       "samluser",
+		UserEmail:      "samluser@example.com",
+		UserCommonName: "SAML User",
+		Groups:         []string{"developers"},
+	}
+	session := emptyTestSession(t)
+	resp := samlSignIn(t, session, idp, http.StatusFound)
+	assert.Equal(t, "/", resp.Header().Get("Location"))
+
+	user := models.AssertExistsAndLoadBean(t, &models.User{Name: "samluser"}).(*models.User)
+	assert.Equal(t, "samluser@example.com", user.Email)
+	assert.Equal(t, "SAML User", user.FullName)
+	assert.Equal(t, models.LoginSAML, user.LoginType)
+	assert.Equal(t, source.ID, user.LoginSource)
+	models.AssertExistsAndLoadBean(t, &models.ExternalLoginUser{ExternalID: "subject-1", LoginSourceID: source.ID, UserID: user.ID})
+	models.AssertExistsAndLoadBean(t, &models.TeamUser{TeamID: 2, UID: user.ID})
+
+	req := NewRequest(t, "GET", "/user/settings")
+	session.MakeRequest(t, req, http.StatusOK)
+
+	// the subject stays linked to the same user, group changes are synced
+	idp.session.UserName = "renamed"
+	idp.session.Groups = nil
+	session = emptyTestSession(t)
+	resp = samlSignIn(t, session, idp, http.StatusFound)
+	assert.Equal(t, "/", resp.Header().Get("Location"))
+	models.AssertNotExistsBean(t, &models.User{Name: "renamed"})
+	models.AssertNotExistsBean(t, &models.TeamUser{TeamID: 2, UID: user.ID})
+
+	// a new subject asserting a taken username is not registered
+	idp.session.NameID = "subject-2"
+	idp.session.UserName = "user2"
+	idp.session.UserEmail = "other@example.com"
+	session = emptyTestSession(t)
+	resp = samlSignIn(t, session, idp, http.StatusFound)
+	assert.Equal(t, "/user/login", resp.Header().Get("Location"))
+	models.AssertNotExistsBean(t, &models.ExternalLoginUser{ExternalID: "subject-2", LoginSourceID: source.ID})
+}
+
+func TestSAMLAssertionConsumerWithoutRequest(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	idp := newSAMLTestIdP(t)
+	addAuthSourceSAML(t, idp)
+
+	req := NewRequestWithValues(t, "POST", "/user/saml/idp/acs", map[string]string{
+		"SAMLResponse": "invalid",
+	})
+	resp := MakeRequest(t, req, http.StatusFound)
+	assert.Equal(t, "/user/login", resp.Header().Get("Location"))
+
+	req = NewRequest(t, "GET", "/user/saml/nonexistent")
+	MakeRequest(t, req, http.StatusNotFound)
+}
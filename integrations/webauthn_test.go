@@ -0,0 +1,109 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/auth/webauthn"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/duo-labs/webauthn/protocol/webauthncose"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func webAuthnAssertion(t *testing.T, key *ecdsa.PrivateKey, credID []byte, userID string, challenge string) *webauthn.CredentialResponse {
+	clientData, err := json.Marshal(map[string]string{
+		"type":      "webauthn.get",
+		"challenge": challenge,
+		"origin":    setting.WebAuthn.Origin,
+	})
+	assert.NoError(t, err)
+	rpIDHash := sha256.Sum256([]byte(setting.WebAuthn.RPID))
+	// user present and verified, signature counter 1
+	authData := append(rpIDHash[:], 0x05, 0, 0, 0, 1)
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	assert.NoError(t, err)
+	sig, err := asn1.Marshal(struct{ R, S interface{} }{r, s})
+	assert.NoError(t, err)
+
+	return &webauthn.CredentialResponse{
+		ID:    webauthn.EncodeToString(credID),
+		RawID: webauthn.EncodeToString(credID),
+		Type:  "public-key",
+		Response: webauthn.AuthenticatorResponse{
+			ClientDataJSON:    webauthn.EncodeToString(clientData),
+			AuthenticatorData: webauthn.EncodeToString(authData),
+			Signature:         webauthn.EncodeToString(sig),
+			UserHandle:        webauthn.EncodeToString([]byte(userID)),
+		},
+	}
+}
+
+func TestWebAuthnPasswordlessSignIn(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	user := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	x, y := make([]byte, 32), make([]byte, 32)
+	xb, yb := key.X.Bytes(), key.Y.Bytes()
+	copy(x[32-len(xb):], xb)
+	copy(y[32-len(yb):], yb)
+	publicKey, err := cbor.Marshal(&webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  1, // P-256
+		XCoord: x,
+		YCoord: y,
+	})
+	assert.NoError(t, err)
+
+	for _, passwordless := range []bool{false, true} {
+		credID := []byte(fmt.Sprintf("credential-%t", passwordless))
+		_, err = models.CreateWebAuthnCredential(user, string(credID), passwordless, &webauthn.Credential{ID: credID, PublicKey: publicKey})
+		assert.NoError(t, err)
+
+		session := emptyTestSession(t)
+		req := NewRequest(t, "GET", "/user/webauthn/passwordless")
+		resp := session.MakeRequest(t, req, http.StatusOK)
+		var opts webauthn.RequestOptions
+		DecodeJSON(t, resp, &opts)
+		assert.Empty(t, opts.AllowCredentials)
+		assert.Equal(t, "required", opts.UserVerification)
+
+		body, err := json.Marshal(webAuthnAssertion(t, key, credID, "2", opts.Challenge))
+		assert.NoError(t, err)
+		req = NewRequestWithBody(t, "POST", "/user/webauthn/passwordless", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Csrf-Token", GetCSRF(t, session, "/user/login"))
+		if !passwordless {
+			// credentials used as second factor can not sign in on their own
+			session.MakeRequest(t, req, http.StatusUnauthorized)
+			continue
+		}
+		session.MakeRequest(t, req, http.StatusOK)
+
+		req = NewRequest(t, "GET", "/user/settings")
+		session.MakeRequest(t, req, http.StatusOK)
+		models.AssertExistsAndLoadBean(t, &models.WebAuthnCredential{UserID: user.ID, Passwordless: true, SignCount: 1})
+	}
+}
@@ -63,6 +63,9 @@ arguments - which can alternatively be run by running the subcommand web.`
 		cmd.CmdAdmin,
 		cmd.CmdGenerate,
 		cmd.CmdMigrate,
+		cmd.CmdMigrateStorage,
+		cmd.CmdDumpRepository,
+		cmd.CmdRestoreRepository,
 		cmd.CmdKeys,
 		cmd.CmdConvert,
 		cmd.CmdDoctor,
@@ -0,0 +1,658 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+	"time"
+
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	"xorm.io/builder"
+)
+
+// ActionStatus represents the status of an action run, job or step
+type ActionStatus int
+
+// Enumerates all the action statuses
+const (
+	ActionStatusUnknown   ActionStatus = iota // 0
+	ActionStatusWaiting                       // 1 waiting for a runner
+	ActionStatusRunning                       // 2
+	ActionStatusSuccess                       // 3
+	ActionStatusFailure                       // 4
+	ActionStatusCancelled                     // 5
+	ActionStatusSkipped                       // 6
+	ActionStatusBlocked                       // 7 waiting for the jobs it needs
+)
+
+var actionStatusNames = map[ActionStatus]string{
+	ActionStatusUnknown:   "unknown",
+	ActionStatusWaiting:   "waiting",
+	ActionStatusRunning:   "running",
+	ActionStatusSuccess:   "success",
+	ActionStatusFailure:   "failure",
+	ActionStatusCancelled: "cancelled",
+	ActionStatusSkipped:   "skipped",
+	ActionStatusBlocked:   "blocked",
+}
+
+// String returns the name of the status
+func (s ActionStatus) String() string {
+	return actionStatusNames[s]
+}
+
+// ActionStatusFromString returns the status with the given name
+func ActionStatusFromString(name string) (ActionStatus, bool) {
+	for s, n := range actionStatusNames {
+		if n == name {
+			return s, true
+		}
+	}
+	return ActionStatusUnknown, false
+}
+
+// IsDone returns true if the status is final
+func (s ActionStatus) IsDone() bool {
+	switch s {
+	case ActionStatusSuccess, ActionStatusFailure, ActionStatusCancelled, ActionStatusSkipped:
+		return true
+	}
+	return false
+}
+
+// CommitStatusState returns the state of the commit status reporting the status
+func (s ActionStatus) CommitStatusState() api.CommitStatusState {
+	switch s {
+	case ActionStatusSuccess, ActionStatusSkipped:
+		return api.CommitStatusSuccess
+	case ActionStatusFailure:
+		return api.CommitStatusFailure
+	case ActionStatusCancelled:
+		return api.CommitStatusError
+	default:
+		return api.CommitStatusPending
+	}
+}
+
+// ErrActionRunNotExist represents a "ActionRunNotExist" kind of error.
+type ErrActionRunNotExist struct {
+	ID     int64
+	RepoID int64
+	Index  int64
+}
+
+// IsErrActionRunNotExist checks if an error is a ErrActionRunNotExist.
+func IsErrActionRunNotExist(err error) bool {
+	_, ok := err.(ErrActionRunNotExist)
+	return ok
+}
+
+func (err ErrActionRunNotExist) Error() string {
+	return fmt.Sprintf("action run does not exist [id: %d, repo_id: %d, index: %d]", err.ID, err.RepoID, err.Index)
+}
+
+// ErrActionRunJobNotExist represents a "ActionRunJobNotExist" kind of error.
+type ErrActionRunJobNotExist struct {
+	ID int64
+}
+
+// IsErrActionRunJobNotExist checks if an error is a ErrActionRunJobNotExist.
+func IsErrActionRunJobNotExist(err error) bool {
+	_, ok := err.(ErrActionRunJobNotExist)
+	return ok
+}
+
+func (err ErrActionRunJobNotExist) Error() string {
+	return fmt.Sprintf("action run job does not exist [id: %d]", err.ID)
+}
+
+// ActionRun represents a run of a workflow triggered by an event
+type ActionRun struct {
+	ID            int64       `xorm:"pk autoincr"`
+	Index         int64       `xorm:"INDEX UNIQUE(repo_index)"`
+	RepoID        int64       `xorm:"INDEX UNIQUE(repo_index)"`
+	Repo          *Repository `xorm:"-"`
+	WorkflowID    string      // file name of the workflow
+	Title         string
+	TriggerUserID int64
+	TriggerUser   *User  `xorm:"-"`
+	Event         string // push or pull_request
+	Ref           string
+	CommitSHA     string       `xorm:"VARCHAR(40)"`
+	PullRequestID int64        `xorm:"INDEX"`
+	Status        ActionStatus `xorm:"INDEX"`
+
+	StartedUnix timeutil.TimeStamp
+	StoppedUnix timeutil.TimeStamp
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+}
+
+// LoadAttributes loads the repository and the user who triggered the run
+func (run *ActionRun) LoadAttributes() error {
+	return run.loadAttributes(x)
+}
+
+func (run *ActionRun) loadAttributes(e Engine) (err error) {
+	if run.Repo == nil {
+		if run.Repo, err = getRepositoryByID(e, run.RepoID); err != nil {
+			return err
+		}
+	}
+	if run.TriggerUser == nil {
+		if run.TriggerUser, err = getUserByID(e, run.TriggerUserID); err != nil {
+			if !IsErrUserNotExist(err) {
+				return err
+			}
+			run.TriggerUser = NewGhostUser()
+		}
+	}
+	return nil
+}
+
+// Link returns the relative URL of the run, the repository must be loaded
+func (run *ActionRun) Link() string {
+	return fmt.Sprintf("%s/actions/runs/%d", run.Repo.Link(), run.Index)
+}
+
+// HTMLURL returns the URL of the run, the repository must be loaded
+func (run *ActionRun) HTMLURL() string {
+	return fmt.Sprintf("%s/actions/runs/%d", run.Repo.HTMLURL(), run.Index)
+}
+
+// Duration returns the time the run has been running
+func (run *ActionRun) Duration() time.Duration {
+	return actionDuration(run.StartedUnix, run.StoppedUnix)
+}
+
+func actionDuration(started, stopped timeutil.TimeStamp) time.Duration {
+	if started == 0 {
+		return 0
+	}
+	if stopped == 0 {
+		stopped = timeutil.TimeStampNow()
+	}
+	return time.Duration(stopped-started) * time.Second
+}
+
+// ActionRunJob represents a job of an action run, the unit of work of a runner
+type ActionRunJob struct {
+	ID          int64      `xorm:"pk autoincr"`
+	RunID       int64      `xorm:"INDEX"`
+	Run         *ActionRun `xorm:"-"`
+	RepoID      int64      `xorm:"INDEX"`
+	JobID       string     // key of the job in the workflow
+	Name        string
+	RunsOn      []string         `xorm:"JSON TEXT"`
+	Needs       []string         `xorm:"JSON TEXT"`
+	Payload     string           `xorm:"LONGTEXT"` // the job sent to the runner
+	Steps       []*ActionRunStep `xorm:"-"`
+	Status      ActionStatus     `xorm:"INDEX"`
+	RunnerID    int64            `xorm:"INDEX"`
+	LogChunks   int
+	StartedUnix timeutil.TimeStamp
+	StoppedUnix timeutil.TimeStamp
+	CreatedUnix timeutil.TimeStamp `xorm:"created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
+}
+
+// LoadRun loads the run of the job with its attributes
+func (job *ActionRunJob) LoadRun() error {
+	return job.loadRun(x)
+}
+
+func (job *ActionRunJob) loadRun(e Engine) error {
+	if job.Run == nil {
+		run := new(ActionRun)
+		has, err := e.ID(job.RunID).Get(run)
+		if err != nil {
+			return err
+		} else if !has {
+			return ErrActionRunNotExist{ID: job.RunID}
+		}
+		job.Run = run
+	}
+	return job.Run.loadAttributes(e)
+}
+
+// Duration returns the time the job has been running
+func (job *ActionRunJob) Duration() time.Duration {
+	return actionDuration(job.StartedUnix, job.StoppedUnix)
+}
+
+// LogPath returns the path of a chunk of the log in the actions log storage
+func (job *ActionRunJob) LogPath(chunk int) string {
+	return fmt.Sprintf("%d/%d/%d.log", job.RepoID, job.ID, chunk)
+}
+
+// ActionRunStep represents a step of a job
+type ActionRunStep struct {
+	ID          int64 `xorm:"pk autoincr"`
+	JobID       int64 `xorm:"INDEX"`
+	Index       int
+	Name        string
+	Status      ActionStatus
+	StartedUnix timeutil.TimeStamp
+	StoppedUnix timeutil.TimeStamp
+}
+
+// Duration returns the time the step has been running
+func (step *ActionRunStep) Duration() time.Duration {
+	return actionDuration(step.StartedUnix, step.StoppedUnix)
+}
+
+// InsertActionRun inserts a run with its jobs and their steps
+func InsertActionRun(run *ActionRun, jobs []*ActionRunJob) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	run.Status = ActionStatusWaiting
+	if _, err := sess.SetExpr("`index`", "coalesce(MAX(`index`),0)+1").
+		Where("repo_id=?", run.RepoID).
+		Insert(run); err != nil {
+		return err
+	}
+	// Patch Index with the value calculated by the database
+	if _, err := sess.ID(run.ID).Cols("`index`").Get(run); err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		job.RunID = run.ID
+		job.RepoID = run.RepoID
+		job.Status = ActionStatusWaiting
+		if len(job.Needs) > 0 {
+			job.Status = ActionStatusBlocked
+		}
+		if _, err := sess.Insert(job); err != nil {
+			return err
+		}
+		for _, step := range job.Steps {
+			step.JobID = job.ID
+			step.Status = ActionStatusWaiting
+			if _, err := sess.Insert(step); err != nil {
+				return err
+			}
+		}
+	}
+
+	return sess.Commit()
+}
+
+// GetActionRunByIndex returns the run of the repository with the given index
+func GetActionRunByIndex(repoID, index int64) (*ActionRun, error) {
+	run := new(ActionRun)
+	has, err := x.Where("repo_id = ? AND `index` = ?", repoID, index).Get(run)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrActionRunNotExist{RepoID: repoID, Index: index}
+	}
+	return run, nil
+}
+
+// ActionRunSearchOptions represents the options to search for action runs
+type ActionRunSearchOptions struct {
+	ListOptions
+	RepoID int64
+	Status ActionStatus
+}
+
+func (opts *ActionRunSearchOptions) toConds() builder.Cond {
+	cond := builder.NewCond()
+	if opts.RepoID > 0 {
+		cond = cond.And(builder.Eq{"repo_id": opts.RepoID})
+	}
+	if opts.Status != ActionStatusUnknown {
+		cond = cond.And(builder.Eq{"status": opts.Status})
+	}
+	return cond
+}
+
+// GetActionRuns returns the runs matching the options, newest first
+func GetActionRuns(opts ActionRunSearchOptions) ([]*ActionRun, int64, error) {
+	count, err := x.Where(opts.toConds()).Count(new(ActionRun))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sess := x.Where(opts.toConds()).Desc("id")
+	if opts.Page > 0 {
+		sess = opts.setSessionPagination(sess)
+	}
+	runs := make([]*ActionRun, 0, opts.PageSize)
+	return runs, count, sess.Find(&runs)
+}
+
+// GetActionRunJobByID returns the job with the given ID
+func GetActionRunJobByID(id int64) (*ActionRunJob, error) {
+	return getActionRunJobByID(x, id)
+}
+
+func getActionRunJobByID(e Engine, id int64) (*ActionRunJob, error) {
+	job := new(ActionRunJob)
+	has, err := e.ID(id).Get(job)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrActionRunJobNotExist{id}
+	}
+	return job, nil
+}
+
+// GetActionRunJobs returns the jobs of the run
+func GetActionRunJobs(runID int64) ([]*ActionRunJob, error) {
+	return getActionRunJobs(x, runID)
+}
+
+func getActionRunJobs(e Engine, runID int64) ([]*ActionRunJob, error) {
+	jobs := make([]*ActionRunJob, 0, 5)
+	return jobs, e.Where("run_id = ?", runID).Asc("id").Find(&jobs)
+}
+
+// GetActionRunSteps returns the steps of the job
+func GetActionRunSteps(jobID int64) ([]*ActionRunStep, error) {
+	steps := make([]*ActionRunStep, 0, 10)
+	return steps, x.Where("job_id = ?", jobID).Asc("`index`").Find(&steps)
+}
+
+// AssignActionRunJob assigns the oldest waiting job the runner can run to the runner
+func AssignActionRunJob(runner *ActionRunner) (*ActionRunJob, error) {
+	jobs := make([]*ActionRunJob, 0, 50)
+	if err := x.Where("status = ?", ActionStatusWaiting).Asc("id").Limit(50).Find(&jobs); err != nil {
+		return nil, err
+	}
+
+	for _, job := range jobs {
+		if !runner.CanRunJob(job) {
+			continue
+		}
+
+		job.Status = ActionStatusRunning
+		job.RunnerID = runner.ID
+		job.StartedUnix = timeutil.TimeStampNow()
+		// another runner may have taken the job in the meantime
+		affected, err := x.ID(job.ID).Where("status = ?", ActionStatusWaiting).
+			Cols("status", "runner_id", "started_unix").Update(job)
+		if err != nil {
+			return nil, err
+		} else if affected == 0 {
+			continue
+		}
+
+		if err := updateActionRunStatus(x, job.RunID); err != nil {
+			return nil, err
+		}
+		return job, nil
+	}
+	return nil, nil
+}
+
+// UpdateActionRunJobStatus updates the status of the job and of the run, the
+// jobs which needed the job are unblocked or skipped. All the jobs whose status
+// changed are returned.
+func UpdateActionRunJobStatus(job *ActionRunJob, status ActionStatus) ([]*ActionRunJob, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	if err := updateActionRunJobStatus(sess, job, status); err != nil {
+		return nil, err
+	}
+	changed := []*ActionRunJob{job}
+	if status.IsDone() {
+		unblocked, err := resolveBlockedActionRunJobs(sess, job.RunID)
+		if err != nil {
+			return nil, err
+		}
+		changed = append(changed, unblocked...)
+	}
+
+	if err := updateActionRunStatus(sess, job.RunID); err != nil {
+		return nil, err
+	}
+	return changed, sess.Commit()
+}
+
+func updateActionRunJobStatus(e Engine, job *ActionRunJob, status ActionStatus) error {
+	job.Status = status
+	cols := []string{"status"}
+	if status == ActionStatusRunning && job.StartedUnix == 0 {
+		job.StartedUnix = timeutil.TimeStampNow()
+		cols = append(cols, "started_unix")
+	}
+	if status.IsDone() {
+		job.StoppedUnix = timeutil.TimeStampNow()
+		cols = append(cols, "stopped_unix")
+
+		// steps which did not finish will never do
+		if _, err := e.Where("job_id = ?", job.ID).
+			And(builder.In("status", ActionStatusWaiting, ActionStatusRunning)).
+			Cols("status").Update(&ActionRunStep{Status: ActionStatusSkipped}); err != nil {
+			return err
+		}
+	}
+	_, err := e.ID(job.ID).Cols(cols...).Update(job)
+	return err
+}
+
+// resolveBlockedActionRunJobs starts the blocked jobs of the run whose needed
+// jobs succeeded and skips the ones whose needed jobs did not
+func resolveBlockedActionRunJobs(e Engine, runID int64) ([]*ActionRunJob, error) {
+	jobs, err := getActionRunJobs(e, runID)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make(map[string]ActionStatus, len(jobs))
+	for _, job := range jobs {
+		statuses[job.JobID] = job.Status
+	}
+
+	var changed []*ActionRunJob
+	for resolved := true; resolved; {
+		resolved = false
+		for _, job := range jobs {
+			if job.Status != ActionStatusBlocked {
+				continue
+			}
+
+			status := ActionStatusWaiting
+			for _, need := range job.Needs {
+				s := statuses[need]
+				if !s.IsDone() {
+					status = ActionStatusBlocked
+					break
+				}
+				if s != ActionStatusSuccess {
+					status = ActionStatusSkipped
+				}
+			}
+			if status == ActionStatusBlocked {
+				continue
+			}
+
+			if err := updateActionRunJobStatus(e, job, status); err != nil {
+				return nil, err
+			}
+			statuses[job.JobID] = status
+			changed = append(changed, job)
+			resolved = true
+		}
+	}
+	return changed, nil
+}
+
+// updateActionRunStatus derives the status of the run from the status of its jobs
+func updateActionRunStatus(e Engine, runID int64) error {
+	run := new(ActionRun)
+	if has, err := e.ID(runID).Get(run); err != nil {
+		return err
+	} else if !has {
+		return ErrActionRunNotExist{ID: runID}
+	}
+	jobs, err := getActionRunJobs(e, runID)
+	if err != nil {
+		return err
+	}
+
+	var done, running, failed, cancelled, skipped int
+	for _, job := range jobs {
+		switch job.Status {
+		case ActionStatusRunning:
+			running++
+		case ActionStatusFailure:
+			failed++
+		case ActionStatusCancelled:
+			cancelled++
+		case ActionStatusSkipped:
+			skipped++
+		}
+		if job.Status.IsDone() {
+			done++
+		}
+	}
+
+	status := ActionStatusWaiting
+	switch {
+	case done == len(jobs) && failed > 0:
+		status = ActionStatusFailure
+	case done == len(jobs) && cancelled > 0:
+		status = ActionStatusCancelled
+	case done == len(jobs) && skipped == len(jobs):
+		status = ActionStatusSkipped
+	case done == len(jobs):
+		status = ActionStatusSuccess
+	case running > 0 || done > 0:
+		status = ActionStatusRunning
+	}
+	if status == run.Status {
+		return nil
+	}
+
+	run.Status = status
+	cols := []string{"status"}
+	if status != ActionStatusWaiting && run.StartedUnix == 0 {
+		run.StartedUnix = timeutil.TimeStampNow()
+		cols = append(cols, "started_unix")
+	}
+	if status.IsDone() {
+		run.StoppedUnix = timeutil.TimeStampNow()
+		cols = append(cols, "stopped_unix")
+	}
+	_, err = e.ID(run.ID).Cols(cols...).Update(run)
+	return err
+}
+
+// UpdateActionRunStep updates the status of the step of the job with the given index
+func UpdateActionRunStep(job *ActionRunJob, index int, status ActionStatus) error {
+	step := &ActionRunStep{Status: status}
+	cols := []string{"status"}
+	if status == ActionStatusRunning {
+		step.StartedUnix = timeutil.TimeStampNow()
+		cols = append(cols, "started_unix")
+	}
+	if status.IsDone() {
+		step.StoppedUnix = timeutil.TimeStampNow()
+		cols = append(cols, "stopped_unix")
+	}
+	_, err := x.Where("job_id = ? AND `index` = ?", job.ID, index).Cols(cols...).Update(step)
+	return err
+}
+
+// UpdateActionRunJobLogChunks records a new chunk of the log of the job.
+// It fails if another chunk was added in the meantime.
+func UpdateActionRunJobLogChunks(job *ActionRunJob) (bool, error) {
+	affected, err := x.ID(job.ID).Where("log_chunks = ?", job.LogChunks).
+		Cols("log_chunks").Update(&ActionRunJob{LogChunks: job.LogChunks + 1})
+	if err != nil {
+		return false, err
+	}
+	if affected > 0 {
+		job.LogChunks++
+	}
+	return affected > 0, nil
+}
+
+// CancelActionRun cancels all jobs of the run which are not done yet and
+// returns them
+func CancelActionRun(run *ActionRun) ([]*ActionRunJob, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	jobs, err := getActionRunJobs(sess, run.ID)
+	if err != nil {
+		return nil, err
+	}
+	cancelled := make([]*ActionRunJob, 0, len(jobs))
+	for _, job := range jobs {
+		if job.Status.IsDone() {
+			continue
+		}
+		if err := updateActionRunJobStatus(sess, job, ActionStatusCancelled); err != nil {
+			return nil, err
+		}
+		cancelled = append(cancelled, job)
+	}
+
+	if err := updateActionRunStatus(sess, run.ID); err != nil {
+		return nil, err
+	}
+	return cancelled, sess.Commit()
+}
+
+// TouchActionRunJob records that the runner of the job is alive
+func TouchActionRunJob(job *ActionRunJob) error {
+	job.UpdatedUnix = timeutil.TimeStampNow()
+	_, err := x.ID(job.ID).NoAutoTime().Cols("updated_unix").Update(job)
+	return err
+}
+
+// GetZombieActionRunJobs returns the running jobs which were not updated by their runner for the duration
+func GetZombieActionRunJobs(olderThan time.Duration) ([]*ActionRunJob, error) {
+	jobs := make([]*ActionRunJob, 0, 10)
+	return jobs, x.Where("status = ? AND updated_unix < ?", ActionStatusRunning, timeutil.TimeStampNow().AddDuration(-olderThan)).
+		Find(&jobs)
+}
+
+// deleteActionRunsByRepoID deletes the runs of the repository and returns the log paths of their jobs
+func deleteActionRunsByRepoID(e Engine, repoID int64) ([]string, error) {
+	jobs := make([]*ActionRunJob, 0, 10)
+	if err := e.Where("repo_id = ?", repoID).Find(&jobs); err != nil {
+		return nil, err
+	}
+
+	var logPaths []string
+	jobIDs := make([]int64, 0, len(jobs))
+	for _, job := range jobs {
+		jobIDs = append(jobIDs, job.ID)
+		for i := 0; i < job.LogChunks; i++ {
+			logPaths = append(logPaths, job.LogPath(i))
+		}
+	}
+
+	for i := 0; i < len(jobIDs); i += 50 {
+		if _, err := e.In("job_id", jobIDs[i:util.Min(i+50, len(jobIDs))]).Delete(new(ActionRunStep)); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := e.Where("repo_id = ?", repoID).Delete(new(ActionRunJob)); err != nil {
+		return nil, err
+	}
+	if _, err := e.Where("repo_id = ?", repoID).Delete(new(ActionRun)); err != nil {
+		return nil, err
+	}
+	return logPaths, nil
+}
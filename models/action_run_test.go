@@ -0,0 +1,114 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInsertActionRun(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	run := &ActionRun{
+		RepoID:        1,
+		WorkflowID:    "lint.yml",
+		Title:         "Lint",
+		TriggerUserID: 2,
+		Event:         "push",
+		Ref:           "refs/heads/master",
+		CommitSHA:     "65f1bf27bc3bf70f64657658635e66094edbcb4d",
+	}
+	jobs := []*ActionRunJob{
+		{JobID: "lint", Name: "lint", RunsOn: []string{"ubuntu-latest"}, Steps: []*ActionRunStep{{Index: 0, Name: "Lint"}}},
+		{JobID: "report", Name: "report", Needs: []string{"lint"}},
+	}
+	assert.NoError(t, InsertActionRun(run, jobs))
+	assert.EqualValues(t, 2, run.Index)
+
+	AssertExistsAndLoadBean(t, &ActionRun{ID: run.ID, RepoID: 1, Index: 2, Status: ActionStatusWaiting})
+	AssertExistsAndLoadBean(t, &ActionRunJob{ID: jobs[0].ID, RunID: run.ID, Status: ActionStatusWaiting})
+	AssertExistsAndLoadBean(t, &ActionRunJob{ID: jobs[1].ID, RunID: run.ID, Status: ActionStatusBlocked})
+	AssertExistsAndLoadBean(t, &ActionRunStep{JobID: jobs[0].ID, Name: "Lint", Status: ActionStatusWaiting})
+}
+
+func TestAssignActionRunJob(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	runner := AssertExistsAndLoadBean(t, &ActionRunner{ID: 1}).(*ActionRunner)
+	job, err := AssignActionRunJob(runner)
+	assert.NoError(t, err)
+	assert.Nil(t, job)
+
+	run := &ActionRun{RepoID: 1, TriggerUserID: 2}
+	assert.NoError(t, InsertActionRun(run, []*ActionRunJob{
+		{JobID: "windows", RunsOn: []string{"windows-latest"}},
+		{JobID: "ubuntu", RunsOn: []string{"ubuntu-latest"}},
+	}))
+
+	job, err = AssignActionRunJob(runner)
+	assert.NoError(t, err)
+	if assert.NotNil(t, job) {
+		assert.Equal(t, "ubuntu", job.JobID)
+		AssertExistsAndLoadBean(t, &ActionRunJob{ID: job.ID, Status: ActionStatusRunning, RunnerID: runner.ID})
+		AssertExistsAndLoadBean(t, &ActionRun{ID: run.ID, Status: ActionStatusRunning})
+	}
+}
+
+func TestUpdateActionRunJobStatus(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	job := AssertExistsAndLoadBean(t, &ActionRunJob{ID: 1}).(*ActionRunJob)
+	changed, err := UpdateActionRunJobStatus(job, ActionStatusSuccess)
+	assert.NoError(t, err)
+	assert.Len(t, changed, 2)
+	AssertExistsAndLoadBean(t, &ActionRunJob{ID: 2, Status: ActionStatusWaiting})
+	AssertExistsAndLoadBean(t, &ActionRunStep{ID: 2, Status: ActionStatusSkipped})
+	AssertExistsAndLoadBean(t, &ActionRun{ID: 1, Status: ActionStatusRunning})
+
+	job = AssertExistsAndLoadBean(t, &ActionRunJob{ID: 2}).(*ActionRunJob)
+	_, err = UpdateActionRunJobStatus(job, ActionStatusFailure)
+	assert.NoError(t, err)
+	AssertExistsAndLoadBean(t, &ActionRun{ID: 1, Status: ActionStatusFailure})
+}
+
+func TestUpdateActionRunJobStatus_SkipDependents(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	job := AssertExistsAndLoadBean(t, &ActionRunJob{ID: 1}).(*ActionRunJob)
+	changed, err := UpdateActionRunJobStatus(job, ActionStatusFailure)
+	assert.NoError(t, err)
+	assert.Len(t, changed, 2)
+	AssertExistsAndLoadBean(t, &ActionRunJob{ID: 2, Status: ActionStatusSkipped})
+	AssertExistsAndLoadBean(t, &ActionRun{ID: 1, Status: ActionStatusFailure})
+}
+
+func TestCancelActionRun(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	run := AssertExistsAndLoadBean(t, &ActionRun{ID: 1}).(*ActionRun)
+	cancelled, err := CancelActionRun(run)
+	assert.NoError(t, err)
+	assert.Len(t, cancelled, 2)
+	AssertExistsAndLoadBean(t, &ActionRunJob{ID: 1, Status: ActionStatusCancelled})
+	AssertExistsAndLoadBean(t, &ActionRunJob{ID: 2, Status: ActionStatusCancelled})
+	AssertExistsAndLoadBean(t, &ActionRun{ID: 1, Status: ActionStatusCancelled})
+}
+
+func TestUpdateActionRunJobLogChunks(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	job := AssertExistsAndLoadBean(t, &ActionRunJob{ID: 1}).(*ActionRunJob)
+	stale := *job
+	ok, err := UpdateActionRunJobLogChunks(job)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 1, job.LogChunks)
+
+	ok, err = UpdateActionRunJobLogChunks(&stale)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
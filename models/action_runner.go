@@ -0,0 +1,160 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"time"
+
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/generate"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	gouuid "github.com/google/uuid"
+)
+
+// ErrActionRunnerNotExist represents a "ActionRunnerNotExist" kind of error.
+type ErrActionRunnerNotExist struct {
+	ID int64
+}
+
+// IsErrActionRunnerNotExist checks if an error is a ErrActionRunnerNotExist.
+func IsErrActionRunnerNotExist(err error) bool {
+	_, ok := err.(ErrActionRunnerNotExist)
+	return ok
+}
+
+func (err ErrActionRunnerNotExist) Error() string {
+	return fmt.Sprintf("action runner does not exist [id: %d]", err.ID)
+}
+
+// ActionRunner represents an external runner which executes the jobs of action runs
+type ActionRunner struct {
+	ID             int64 `xorm:"pk autoincr"`
+	Name           string
+	Labels         []string `xorm:"JSON TEXT"` // accepts all jobs if empty
+	Token          string   `xorm:"-"`
+	TokenHash      string   `xorm:"UNIQUE"` // sha256 of token
+	TokenSalt      string
+	TokenLastEight string `xorm:"INDEX token_last_eight"`
+
+	LastOnlineUnix timeutil.TimeStamp `xorm:"INDEX"`
+	CreatedUnix    timeutil.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix    timeutil.TimeStamp `xorm:"INDEX updated"`
+}
+
+// IsOnline returns true if the runner asked for jobs recently
+func (r *ActionRunner) IsOnline() bool {
+	return r.LastOnlineUnix.AddDuration(time.Minute) > timeutil.TimeStampNow()
+}
+
+// CanRunJob returns true if the runner has all the labels the job runs on
+func (r *ActionRunner) CanRunJob(job *ActionRunJob) bool {
+	if len(r.Labels) == 0 {
+		return true
+	}
+	for _, label := range job.RunsOn {
+		if !util.IsStringInSlice(label, r.Labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// NewActionRunner creates a new runner and its token
+func NewActionRunner(r *ActionRunner) error {
+	salt, err := generate.GetRandomString(10)
+	if err != nil {
+		return err
+	}
+	r.TokenSalt = salt
+	r.Token = base.EncodeSha1(gouuid.New().String())
+	r.TokenHash = hashToken(r.Token, r.TokenSalt)
+	r.TokenLastEight = r.Token[len(r.Token)-8:]
+	if r.Labels == nil {
+		r.Labels = []string{}
+	}
+	_, err = x.Insert(r)
+	return err
+}
+
+// GetActionRunnerByID returns the runner with the given ID
+func GetActionRunnerByID(id int64) (*ActionRunner, error) {
+	r := new(ActionRunner)
+	has, err := x.ID(id).Get(r)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrActionRunnerNotExist{id}
+	}
+	return r, nil
+}
+
+// GetActionRunnerByToken returns the runner the token belongs to
+func GetActionRunnerByToken(token string) (*ActionRunner, error) {
+	if len(token) < 8 {
+		return nil, ErrActionRunnerNotExist{}
+	}
+	var runners []*ActionRunner
+	if err := x.Where("token_last_eight = ?", token[len(token)-8:]).Find(&runners); err != nil {
+		return nil, err
+	}
+	for _, r := range runners {
+		if subtle.ConstantTimeCompare([]byte(r.TokenHash), []byte(hashToken(token, r.TokenSalt))) == 1 {
+			return r, nil
+		}
+	}
+	return nil, ErrActionRunnerNotExist{}
+}
+
+// GetActionRunners returns all runners
+func GetActionRunners() ([]*ActionRunner, error) {
+	runners := make([]*ActionRunner, 0, 10)
+	return runners, x.Asc("name").Find(&runners)
+}
+
+// UpdateActionRunnerLastOnline records that the runner is online
+func UpdateActionRunnerLastOnline(r *ActionRunner) error {
+	r.LastOnlineUnix = timeutil.TimeStampNow()
+	_, err := x.ID(r.ID).NoAutoTime().Cols("last_online_unix").Update(r)
+	return err
+}
+
+// DeleteActionRunner deletes a runner, the jobs it is running are failed.
+// All the jobs whose status changed are returned.
+func DeleteActionRunner(id int64) ([]*ActionRunJob, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	if _, err := sess.ID(id).Delete(new(ActionRunner)); err != nil {
+		return nil, err
+	}
+
+	jobs := make([]*ActionRunJob, 0, 10)
+	if err := sess.Where("runner_id = ? AND status = ?", id, ActionStatusRunning).Find(&jobs); err != nil {
+		return nil, err
+	}
+	changed := jobs
+	for _, job := range jobs {
+		if err := updateActionRunJobStatus(sess, job, ActionStatusFailure); err != nil {
+			return nil, err
+		}
+		skipped, err := resolveBlockedActionRunJobs(sess, job.RunID)
+		if err != nil {
+			return nil, err
+		}
+		changed = append(changed, skipped...)
+		if err := updateActionRunStatus(sess, job.RunID); err != nil {
+			return nil, err
+		}
+	}
+
+	return changed, sess.Commit()
+}
@@ -0,0 +1,49 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetActionRunnerByToken(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	runner, err := GetActionRunnerByToken("7f6e1b3c0d5a4e2f9b8c7a6d5e4f3a2b1c0d9e8f")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, runner.ID)
+
+	_, err = GetActionRunnerByToken("0000000000000000000000000000000a1c0d9e8f")
+	assert.True(t, IsErrActionRunnerNotExist(err))
+	_, err = GetActionRunnerByToken("")
+	assert.True(t, IsErrActionRunnerNotExist(err))
+}
+
+func TestNewActionRunner(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	runner := &ActionRunner{Name: "runner-2"}
+	assert.NoError(t, NewActionRunner(runner))
+	assert.NotEmpty(t, runner.Token)
+
+	loaded, err := GetActionRunnerByToken(runner.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, runner.ID, loaded.ID)
+	assert.True(t, loaded.CanRunJob(&ActionRunJob{RunsOn: []string{"anything"}}))
+}
+
+func TestDeleteActionRunner(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	jobs, err := DeleteActionRunner(1)
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 2)
+	AssertNotExistsBean(t, &ActionRunner{ID: 1})
+	AssertExistsAndLoadBean(t, &ActionRunJob{ID: 1, Status: ActionStatusFailure})
+	AssertExistsAndLoadBean(t, &ActionRunJob{ID: 2, Status: ActionStatusSkipped})
+	AssertExistsAndLoadBean(t, &ActionRun{ID: 1, Status: ActionStatusFailure})
+}
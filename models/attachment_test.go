@@ -21,6 +21,8 @@ func TestUploadAttachment(t *testing.T) {
 	f, err := os.Open(fPath)
 	assert.NoError(t, err)
 	defer f.Close()
+	fi, err := f.Stat()
+	assert.NoError(t, err)
 
 	var buf = make([]byte, 1024)
 	n, err := f.Read(buf)
@@ -30,8 +32,9 @@ func TestUploadAttachment(t *testing.T) {
 	attach, err := NewAttachment(&Attachment{
 		UploaderID: user.ID,
 		Name:       filepath.Base(fPath),
-	}, buf, f)
+	}, buf, f, fi.Size())
 	assert.NoError(t, err)
+	assert.EqualValues(t, fi.Size(), attach.Size)
 
 	attachment, err := GetAttachmentByUUID(attach.UUID)
 	assert.NoError(t, err)
@@ -0,0 +1,176 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+// AuditAction is the type of a security-relevant action recorded in the audit log
+type AuditAction string
+
+// Possible audit actions
+const (
+	AuditUserCreate AuditAction = "user_create"
+	AuditUserEdit   AuditAction = "user_edit"
+	AuditUserDelete AuditAction = "user_delete"
+
+	AuditAccessTokenCreate AuditAction = "access_token_create"
+	AuditAccessTokenDelete AuditAction = "access_token_delete"
+
+	AuditTwoFactorEnable  AuditAction = "two_factor_enable"
+	AuditTwoFactorDisable AuditAction = "two_factor_disable"
+
+	AuditWebAuthnRegister AuditAction = "webauthn_register"
+	AuditWebAuthnRemove   AuditAction = "webauthn_remove"
+
+	AuditLoginSourceCreate AuditAction = "login_source_create"
+	AuditLoginSourceEdit   AuditAction = "login_source_edit"
+	AuditLoginSourceDelete AuditAction = "login_source_delete"
+
+	AuditBranchProtectionUpdate AuditAction = "branch_protection_update"
+	AuditBranchProtectionDelete AuditAction = "branch_protection_delete"
+
+	AuditCollaboratorAdd    AuditAction = "collaborator_add"
+	AuditCollaboratorAccess AuditAction = "collaborator_access"
+	AuditCollaboratorRemove AuditAction = "collaborator_remove"
+
+	AuditTeamCreate       AuditAction = "team_create"
+	AuditTeamEdit         AuditAction = "team_edit"
+	AuditTeamDelete       AuditAction = "team_delete"
+	AuditTeamMemberAdd    AuditAction = "team_member_add"
+	AuditTeamMemberRemove AuditAction = "team_member_remove"
+	AuditTeamRepoAdd      AuditAction = "team_repo_add"
+	AuditTeamRepoRemove   AuditAction = "team_repo_remove"
+
+	AuditAdminRunTask AuditAction = "admin_run_task"
+)
+
+// AuditActions are all audit actions in the order they are offered as filter
+var AuditActions = []AuditAction{
+	AuditUserCreate,
+	AuditUserEdit,
+	AuditUserDelete,
+	AuditAccessTokenCreate,
+	AuditAccessTokenDelete,
+	AuditTwoFactorEnable,
+	AuditTwoFactorDisable,
+	AuditWebAuthnRegister,
+	AuditWebAuthnRemove,
+	AuditLoginSourceCreate,
+	AuditLoginSourceEdit,
+	AuditLoginSourceDelete,
+	AuditBranchProtectionUpdate,
+	AuditBranchProtectionDelete,
+	AuditCollaboratorAdd,
+	AuditCollaboratorAccess,
+	AuditCollaboratorRemove,
+	AuditTeamCreate,
+	AuditTeamEdit,
+	AuditTeamDelete,
+	AuditTeamMemberAdd,
+	AuditTeamMemberRemove,
+	AuditTeamRepoAdd,
+	AuditTeamRepoRemove,
+	AuditAdminRunTask,
+}
+
+// IsValidAuditAction returns true if given name is a valid audit action
+func IsValidAuditAction(name string) bool {
+	for _, action := range AuditActions {
+		if string(action) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// AuditTargetType is the type of the object an audited action is applied to
+type AuditTargetType string
+
+// Possible audit target types
+const (
+	AuditTargetUser            AuditTargetType = "user"
+	AuditTargetAccessToken     AuditTargetType = "access_token"
+	AuditTargetLoginSource     AuditTargetType = "login_source"
+	AuditTargetProtectedBranch AuditTargetType = "protected_branch"
+	AuditTargetTeam            AuditTargetType = "team"
+	AuditTargetRepository      AuditTargetType = "repository"
+	AuditTargetTask            AuditTargetType = "task"
+)
+
+// AuditEvent is an entry of the audit log. The log is append-only, entries are
+// never updated and they keep the names of the actor, repository, organization
+// and target so they stay readable when these are renamed or deleted.
+type AuditEvent struct {
+	ID        int64       `xorm:"pk autoincr"`
+	Action    AuditAction `xorm:"INDEX NOT NULL"`
+	ActorID   int64       `xorm:"INDEX"` // 0 for actions of the system
+	ActorName string
+	IPAddress string `xorm:"ip_address"`
+
+	RepoID   int64 `xorm:"INDEX"`
+	RepoName string
+	OrgID    int64 `xorm:"INDEX"`
+	OrgName  string
+
+	TargetType AuditTargetType
+	TargetID   int64
+	TargetName string
+	Details    string `xorm:"TEXT"`
+
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+}
+
+// CreateAuditEvent appends an event to the audit log
+func CreateAuditEvent(e *AuditEvent) error {
+	_, err := x.Insert(e)
+	return err
+}
+
+// FindAuditEventsOptions are the conditions to find audit events
+type FindAuditEventsOptions struct {
+	ListOptions
+	ActorID int64
+	RepoID  int64
+	OrgID   int64
+	Action  AuditAction
+}
+
+func (opts *FindAuditEventsOptions) toCond() builder.Cond {
+	cond := builder.NewCond()
+	if opts.ActorID != 0 {
+		cond = cond.And(builder.Eq{"actor_id": opts.ActorID})
+	}
+	if opts.RepoID != 0 {
+		cond = cond.And(builder.Eq{"repo_id": opts.RepoID})
+	}
+	if opts.OrgID != 0 {
+		cond = cond.And(builder.Eq{"org_id": opts.OrgID})
+	}
+	if opts.Action != "" {
+		cond = cond.And(builder.Eq{"action": opts.Action})
+	}
+	return cond
+}
+
+// FindAuditEvents returns the audit events matching the options, the latest first,
+// and the total count of matching events
+func FindAuditEvents(opts *FindAuditEventsOptions) ([]*AuditEvent, int64, error) {
+	cond := opts.toCond()
+	count, err := x.Where(cond).Count(new(AuditEvent))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sess := x.Where(cond).Desc("id")
+	if opts.Page != 0 {
+		sess = opts.setSessionPagination(sess)
+	}
+	events := make([]*AuditEvent, 0, opts.PageSize)
+	return events, count, sess.Find(&events)
+}
@@ -0,0 +1,60 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindAuditEvents(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	for _, e := range []*AuditEvent{
+		{Action: AuditUserCreate, ActorID: 1, ActorName: "user1", TargetType: AuditTargetUser, TargetID: 2},
+		{Action: AuditCollaboratorAdd, ActorID: 2, ActorName: "user2", RepoID: 1, TargetType: AuditTargetUser, TargetID: 4},
+		{Action: AuditTeamCreate, ActorID: 2, ActorName: "user2", OrgID: 3, TargetType: AuditTargetTeam, TargetID: 1},
+	} {
+		assert.NoError(t, CreateAuditEvent(e))
+		assert.NotZero(t, e.CreatedUnix)
+	}
+
+	events, count, err := FindAuditEvents(&FindAuditEventsOptions{})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, count)
+	if assert.Len(t, events, 3) {
+		// the latest event comes first
+		assert.Equal(t, AuditTeamCreate, events[0].Action)
+	}
+
+	events, count, err = FindAuditEvents(&FindAuditEventsOptions{ActorID: 2})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+	assert.Len(t, events, 2)
+
+	events, _, err = FindAuditEvents(&FindAuditEventsOptions{RepoID: 1})
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, AuditCollaboratorAdd, events[0].Action)
+	}
+
+	events, _, err = FindAuditEvents(&FindAuditEventsOptions{OrgID: 3, Action: AuditTeamCreate})
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+
+	events, count, err = FindAuditEvents(&FindAuditEventsOptions{ListOptions: ListOptions{Page: 2, PageSize: 2}})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, count)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, AuditUserCreate, events[0].Action)
+	}
+}
+
+func TestIsValidAuditAction(t *testing.T) {
+	assert.True(t, IsValidAuditAction("two_factor_enable"))
+	assert.False(t, IsValidAuditAction(""))
+	assert.False(t, IsValidAuditAction("unknown"))
+}
@@ -0,0 +1,167 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"code.gitea.io/gitea/modules/log"
+
+	"github.com/gobwas/glob"
+)
+
+// CodeOwnerRule represents a line of a CODEOWNERS file: a path pattern and the
+// users and teams owning the files matching it.
+type CodeOwnerRule struct {
+	Pattern string
+	Rule    glob.Glob
+	Users   []*User
+	Teams   []*Team
+}
+
+// HasOwners returns true if at least one owner of the rule could be resolved.
+func (rule *CodeOwnerRule) HasOwners() bool {
+	return len(rule.Users) > 0 || len(rule.Teams) > 0
+}
+
+// IsOwner returns true if the user is one of the owners of the rule or a member of one of its teams.
+func (rule *CodeOwnerRule) IsOwner(user *User) (bool, error) {
+	for _, u := range rule.Users {
+		if u.ID == user.ID {
+			return true, nil
+		}
+	}
+	for _, t := range rule.Teams {
+		isMember, err := IsTeamMember(t.OrgID, t.ID, user.ID)
+		if err != nil {
+			return false, err
+		} else if isMember {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetOwnerUsers returns the owners of the rule, including the members of its teams.
+func (rule *CodeOwnerRule) GetOwnerUsers() ([]*User, error) {
+	users := make([]*User, 0, len(rule.Users))
+	users = append(users, rule.Users...)
+	for _, t := range rule.Teams {
+		if err := t.GetMembers(&SearchMembersOptions{}); err != nil {
+			return nil, fmt.Errorf("GetMembers: %v", err)
+		}
+		users = append(users, t.Members...)
+	}
+	return users, nil
+}
+
+// compileCodeOwnerPattern converts a gitignore style pattern of a CODEOWNERS file into a glob.
+// Patterns starting with or containing a slash are relative to the repository root,
+// others match at any depth. A pattern matching a directory matches everything below it.
+func compileCodeOwnerPattern(pattern string) (glob.Glob, error) {
+	expr := pattern
+	anchored := strings.HasPrefix(expr, "/")
+	expr = strings.TrimPrefix(expr, "/")
+	dirOnly := strings.HasSuffix(expr, "/")
+	expr = strings.TrimSuffix(expr, "/")
+	if !anchored && strings.Contains(expr, "/") {
+		anchored = true
+	}
+
+	alternatives := []string{expr + "/**"}
+	if !dirOnly {
+		alternatives = append(alternatives, expr)
+	}
+	if !anchored {
+		for _, alt := range alternatives {
+			alternatives = append(alternatives, "**/"+alt)
+		}
+	}
+	return glob.Compile("{"+strings.Join(alternatives, ",")+"}", '/')
+}
+
+// ParseCodeOwners parses the content of a CODEOWNERS file of the repository.
+// Owners are given as @user, @org/team or by email. Lines with an invalid
+// pattern and owners which cannot be resolved are skipped and reported as warnings.
+func ParseCodeOwners(repo *Repository, content string) ([]*CodeOwnerRule, []string) {
+	var rules []*CodeOwnerRule
+	var warnings []string
+
+	if err := repo.GetOwner(); err != nil {
+		return nil, []string{fmt.Sprintf("GetOwner: %v", err)}
+	}
+
+	for i, line := range strings.Split(content, "\n") {
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		g, err := compileCodeOwnerPattern(fields[0])
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("line %d: invalid pattern %q: %v", i+1, fields[0], err))
+			continue
+		}
+
+		rule := &CodeOwnerRule{
+			Pattern: fields[0],
+			Rule:    g,
+		}
+		for _, owner := range fields[1:] {
+			switch {
+			case strings.HasPrefix(owner, "@") && strings.Contains(owner, "/"):
+				parts := strings.SplitN(owner[1:], "/", 2)
+				if !repo.Owner.IsOrganization() || !strings.EqualFold(parts[0], repo.Owner.Name) {
+					warnings = append(warnings, fmt.Sprintf("line %d: team %q does not belong to %s", i+1, owner, repo.Owner.Name))
+					continue
+				}
+				team, err := GetTeam(repo.OwnerID, parts[1])
+				if err != nil {
+					warnings = append(warnings, fmt.Sprintf("line %d: unknown team %q", i+1, owner))
+					continue
+				}
+				rule.Teams = append(rule.Teams, team)
+			case strings.HasPrefix(owner, "@"):
+				user, err := GetUserByName(owner[1:])
+				if err != nil {
+					warnings = append(warnings, fmt.Sprintf("line %d: unknown user %q", i+1, owner))
+					continue
+				}
+				rule.Users = append(rule.Users, user)
+			case strings.Contains(owner, "@"):
+				user, err := GetUserByEmail(owner)
+				if err != nil {
+					warnings = append(warnings, fmt.Sprintf("line %d: unknown email %q", i+1, owner))
+					continue
+				}
+				rule.Users = append(rule.Users, user)
+			default:
+				warnings = append(warnings, fmt.Sprintf("line %d: invalid owner %q", i+1, owner))
+			}
+		}
+		rules = append(rules, rule)
+	}
+
+	for _, warning := range warnings {
+		log.Trace("CODEOWNERS of %s: %s", repo.FullName(), warning)
+	}
+
+	return rules, warnings
+}
+
+// GetCodeOwnerRule returns the rule which applies to the given file, the last
+// matching rule takes precedence. It returns nil if no rule matches.
+func GetCodeOwnerRule(rules []*CodeOwnerRule, file string) *CodeOwnerRule {
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].Rule.Match(file) {
+			return rules[i]
+		}
+	}
+	return nil
+}
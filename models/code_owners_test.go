@@ -0,0 +1,66 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCodeOwners(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	repo := AssertExistsAndLoadBean(t, &Repository{ID: 3}).(*Repository)
+	content := `# comment
+*              @user2
+/docs/         user4@example.com # documentation
+*.go           @user3/owners @user5
+/cmd/main.go   @unknown @user3/unknown
+invalid-line
+`
+	rules, warnings := ParseCodeOwners(repo, content)
+	assert.Len(t, rules, 5)
+	assert.Len(t, warnings, 2)
+
+	assert.Len(t, rules[0].Users, 1)
+	assert.EqualValues(t, 2, rules[0].Users[0].ID)
+	assert.Len(t, rules[1].Users, 1)
+	assert.EqualValues(t, 4, rules[1].Users[0].ID)
+	assert.Len(t, rules[2].Teams, 1)
+	assert.EqualValues(t, 1, rules[2].Teams[0].ID)
+	assert.False(t, rules[3].HasOwners())
+	assert.False(t, rules[4].HasOwners())
+
+	for file, pattern := range map[string]string{
+		"README.md":             "*",
+		"docs/index.md":         "/docs/",
+		"docs/api/v1/readme.md": "/docs/",
+		"modules/docs/x.md":     "*",
+		"main.go":               "*.go",
+		"docs/gen.go":           "*.go",
+		"cmd/main.go":           "/cmd/main.go",
+		"cmd/web.go":            "*.go",
+	} {
+		rule := GetCodeOwnerRule(rules[:4], file)
+		if assert.NotNil(t, rule, file) {
+			assert.Equal(t, pattern, rule.Pattern, file)
+		}
+	}
+	assert.Nil(t, GetCodeOwnerRule(rules[1:2], "README.md"))
+
+	user2 := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	user4 := AssertExistsAndLoadBean(t, &User{ID: 4}).(*User)
+	isOwner, err := rules[2].IsOwner(user2)
+	assert.NoError(t, err)
+	assert.True(t, isOwner)
+	isOwner, err = rules[2].IsOwner(user4)
+	assert.NoError(t, err)
+	assert.False(t, isOwner)
+
+	users, err := rules[2].GetOwnerUsers()
+	assert.NoError(t, err)
+	assert.Len(t, users, 2)
+}
@@ -1134,6 +1134,39 @@ func (err ErrCommitIDDoesNotMatch) Error() string {
 	return fmt.Sprintf("file CommitID does not match [given: %s, expected: %s]", err.GivenCommitID, err.CurrentCommitID)
 }
 
+// ErrSuggestionOutdated represents a "SuggestionOutdated" kind of error.
+type ErrSuggestionOutdated struct {
+	CommentID int64
+	Path      string
+	Line      int64
+}
+
+// IsErrSuggestionOutdated checks if an error is a ErrSuggestionOutdated.
+func IsErrSuggestionOutdated(err error) bool {
+	_, ok := err.(ErrSuggestionOutdated)
+	return ok
+}
+
+func (err ErrSuggestionOutdated) Error() string {
+	return fmt.Sprintf("suggestion does not apply to the current content [comment: %d, path: %s, line: %d]", err.CommentID, err.Path, err.Line)
+}
+
+// ErrSuggestionsOverlap represents a "SuggestionsOverlap" kind of error.
+type ErrSuggestionsOverlap struct {
+	Path string
+	Line int64
+}
+
+// IsErrSuggestionsOverlap checks if an error is a ErrSuggestionsOverlap.
+func IsErrSuggestionsOverlap(err error) bool {
+	_, ok := err.(ErrSuggestionsOverlap)
+	return ok
+}
+
+func (err ErrSuggestionsOverlap) Error() string {
+	return fmt.Sprintf("suggestions change the same lines [path: %s, line: %d]", err.Path, err.Line)
+}
+
 // ErrSHAOrCommitIDNotProvided represents a "SHAOrCommitIDNotProvided" kind of error.
 type ErrSHAOrCommitIDNotProvided struct{}
 
@@ -1169,6 +1202,22 @@ func (err ErrWebhookNotExist) Error() string {
 	return fmt.Sprintf("webhook does not exist [id: %d]", err.ID)
 }
 
+// ErrHookTaskNotExist represents a "HookTaskNotExist" kind of error.
+type ErrHookTaskNotExist struct {
+	ID     int64
+	HookID int64
+}
+
+// IsErrHookTaskNotExist checks if an error is a ErrHookTaskNotExist.
+func IsErrHookTaskNotExist(err error) bool {
+	_, ok := err.(ErrHookTaskNotExist)
+	return ok
+}
+
+func (err ErrHookTaskNotExist) Error() string {
+	return fmt.Sprintf("hook task does not exist [id: %d, hook_id: %d]", err.ID, err.HookID)
+}
+
 // .___
 // |   | ______ ________ __   ____
 // |   |/  ___//  ___/  |  \_/ __ \
@@ -1424,6 +1473,24 @@ func (err ErrMergeUnrelatedHistories) Error() string {
 	return fmt.Sprintf("Merge UnrelatedHistories Error: %v: %s\n%s", err.Err, err.StdErr, err.StdOut)
 }
 
+// ErrMergeDivergingBranches represents an error if a fast-forward-only merge fails because the head branch does not contain the base branch
+type ErrMergeDivergingBranches struct {
+	Style  MergeStyle
+	StdOut string
+	StdErr string
+	Err    error
+}
+
+// IsErrMergeDivergingBranches checks if an error is a ErrMergeDivergingBranches.
+func IsErrMergeDivergingBranches(err error) bool {
+	_, ok := err.(ErrMergeDivergingBranches)
+	return ok
+}
+
+func (err ErrMergeDivergingBranches) Error() string {
+	return fmt.Sprintf("Merge DivergingBranches Error: %v: %s\n%s", err.Err, err.StdErr, err.StdOut)
+}
+
 // ErrRebaseConflicts represents an error if rebase fails with a conflict
 type ErrRebaseConflicts struct {
 	Style     MergeStyle
@@ -1465,6 +1532,22 @@ func (err ErrPullRequestHasMerged) Error() string {
 		err.ID, err.IssueID, err.HeadRepoID, err.BaseRepoID, err.HeadBranch, err.BaseBranch)
 }
 
+// ErrPullAlreadyScheduledToAutoMerge represents a "PullAlreadyScheduledToAutoMerge"-error
+type ErrPullAlreadyScheduledToAutoMerge struct {
+	PullID int64
+}
+
+// IsErrPullAlreadyScheduledToAutoMerge checks if an error is a ErrPullAlreadyScheduledToAutoMerge.
+func IsErrPullAlreadyScheduledToAutoMerge(err error) bool {
+	_, ok := err.(ErrPullAlreadyScheduledToAutoMerge)
+	return ok
+}
+
+// Error does pretty-printing :D
+func (err ErrPullAlreadyScheduledToAutoMerge) Error() string {
+	return fmt.Sprintf("pull request is already scheduled to auto merge when checks succeed [pull_id: %d]", err.PullID)
+}
+
 // _________                                       __
 // \_   ___ \  ____   _____   _____   ____   _____/  |_
 // /    \  \/ /  _ \ /     \ /     \_/ __ \ /    \   __\
@@ -1488,6 +1571,22 @@ func (err ErrCommentNotExist) Error() string {
 	return fmt.Sprintf("comment does not exist [id: %d, issue_id: %d]", err.ID, err.IssueID)
 }
 
+// ErrInvalidCodeCommentLines represents a "InvalidCodeCommentLines" kind of error.
+type ErrInvalidCodeCommentLines struct {
+	StartLine int64
+	Line      int64
+}
+
+// IsErrInvalidCodeCommentLines checks if an error is a ErrInvalidCodeCommentLines.
+func IsErrInvalidCodeCommentLines(err error) bool {
+	_, ok := err.(ErrInvalidCodeCommentLines)
+	return ok
+}
+
+func (err ErrInvalidCodeCommentLines) Error() string {
+	return fmt.Sprintf("invalid lines of code comment [start_line: %d, line: %d]", err.StartLine, err.Line)
+}
+
 //  _________ __                                __         .__
 //  /   _____//  |_  ____ ________  _  _______ _/  |_  ____ |  |__
 //  \_____  \\   __\/  _ \\____ \ \/ \/ /\__  \\   __\/ ___\|  |  \
@@ -1688,6 +1787,22 @@ func (err ErrLoginSourceInUse) Error() string {
 	return fmt.Sprintf("login source is still used by some users [id: %d]", err.ID)
 }
 
+// ErrExternalUserMissingEmail represents a "ExternalUserMissingEmail" kind of error.
+type ErrExternalUserMissingEmail struct {
+	ExternalID    string
+	LoginSourceID int64
+}
+
+// IsErrExternalUserMissingEmail checks if an error is a ErrExternalUserMissingEmail.
+func IsErrExternalUserMissingEmail(err error) bool {
+	_, ok := err.(ErrExternalUserMissingEmail)
+	return ok
+}
+
+func (err ErrExternalUserMissingEmail) Error() string {
+	return fmt.Sprintf("external user has no email address [external_id: %s, login_source_id: %d]", err.ExternalID, err.LoginSourceID)
+}
+
 // ___________
 // \__    ___/___ _____    _____
 //   |    |_/ __ \\__  \  /     \
@@ -1811,25 +1926,41 @@ func (err ErrExternalLoginUserNotExist) Error() string {
 	return fmt.Sprintf("external login user link does not exists [userID: %d, loginSourceID: %d]", err.UserID, err.LoginSourceID)
 }
 
-// ____ ________________________________              .__          __                 __  .__
-// |    |   \_____  \_   _____/\______   \ ____   ____ |__| _______/  |_____________ _/  |_|__| ____   ____
-// |    |   //  ____/|    __)   |       _// __ \ / ___\|  |/  ___/\   __\_  __ \__  \\   __\  |/  _ \ /    \
-// |    |  //       \|     \    |    |   \  ___// /_/  >  |\___ \  |  |  |  | \// __ \|  | |  (  <_> )   |  \
-// |______/ \_______ \___  /    |____|_  /\___  >___  /|__/____  > |__|  |__|  (____  /__| |__|\____/|___|  /
-// \/   \/            \/     \/_____/         \/                   \/                    \/
+//  __      __          ___.        _____              __    .__
+// /  \    /  \   ____  \_ |__     /  _  \    __ __  _/  |_  |  |__     ____
+// \   \/\/   / _/ __ \  | __ \   /  /_\  \  |  |  \ \   __\ |  |  \   /    \
+//  \        /  \  ___/  | \_\ \ /    |    \ |  |  /  |  |   |   Y  \ |   |  \
+//   \__/\  /    \___  > |___  / \____|__  / |____/   |__|   |___|  / |___|  /
+//        \/         \/      \/          \/                       \/       \/
 
-// ErrU2FRegistrationNotExist represents a "ErrU2FRegistrationNotExist" kind of error.
-type ErrU2FRegistrationNotExist struct {
-	ID int64
+// ErrWebAuthnCredentialNotExist represents a "ErrWebAuthnCredentialNotExist" kind of error.
+type ErrWebAuthnCredentialNotExist struct {
+	ID           int64
+	CredentialID string
+}
+
+func (err ErrWebAuthnCredentialNotExist) Error() string {
+	return fmt.Sprintf("WebAuthn credential does not exist [id: %d, credential_id: %s]", err.ID, err.CredentialID)
+}
+
+// IsErrWebAuthnCredentialNotExist checks if an error is a ErrWebAuthnCredentialNotExist.
+func IsErrWebAuthnCredentialNotExist(err error) bool {
+	_, ok := err.(ErrWebAuthnCredentialNotExist)
+	return ok
+}
+
+// ErrWebAuthnCredentialAlreadyExist represents a "ErrWebAuthnCredentialAlreadyExist" kind of error.
+type ErrWebAuthnCredentialAlreadyExist struct {
+	CredentialID string
 }
 
-func (err ErrU2FRegistrationNotExist) Error() string {
-	return fmt.Sprintf("U2F registration does not exist [id: %d]", err.ID)
+func (err ErrWebAuthnCredentialAlreadyExist) Error() string {
+	return fmt.Sprintf("WebAuthn credential already exists [credential_id: %s]", err.CredentialID)
 }
 
-// IsErrU2FRegistrationNotExist checks if an error is a ErrU2FRegistrationNotExist.
-func IsErrU2FRegistrationNotExist(err error) bool {
-	_, ok := err.(ErrU2FRegistrationNotExist)
+// IsErrWebAuthnCredentialAlreadyExist checks if an error is a ErrWebAuthnCredentialAlreadyExist.
+func IsErrWebAuthnCredentialAlreadyExist(err error) bool {
+	_, ok := err.(ErrWebAuthnCredentialAlreadyExist)
 	return ok
 }
 
@@ -55,7 +55,11 @@ func ListAccountLinks(user *User) ([]*ExternalLoginUser, error) {
 
 // LinkExternalToUser link the external user to the user
 func LinkExternalToUser(user *User, externalLoginUser *ExternalLoginUser) error {
-	has, err := x.Where("external_id=? AND login_source_id=?", externalLoginUser.ExternalID, externalLoginUser.LoginSourceID).
+	return linkExternalToUser(x, user, externalLoginUser)
+}
+
+func linkExternalToUser(e Engine, user *User, externalLoginUser *ExternalLoginUser) error {
+	has, err := e.Where("external_id=? AND login_source_id=?", externalLoginUser.ExternalID, externalLoginUser.LoginSourceID).
 		NoAutoCondition().
 		Exist(externalLoginUser)
 	if err != nil {
@@ -64,7 +68,7 @@ func LinkExternalToUser(user *User, externalLoginUser *ExternalLoginUser) error
 		return ErrExternalLoginUserAlreadyExist{externalLoginUser.ExternalID, user.ID, externalLoginUser.LoginSourceID}
 	}
 
-	_, err = x.Insert(externalLoginUser)
+	_, err = e.Insert(externalLoginUser)
 	return err
 }
 
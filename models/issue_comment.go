@@ -97,6 +97,12 @@ const (
 	CommentTypeMergePull
 	// push to PR head branch
 	CommentTypePullPush
+	// add or remove issue from a project
+	CommentTypeProject
+	// schedule a pull request to be merged automatically
+	CommentTypePRScheduledToAutoMerge
+	// cancel the automatic merge of a pull request
+	CommentTypePRUnScheduledToAutoMerge
 )
 
 // CommentTag defines comment tag type
@@ -126,6 +132,10 @@ type Comment struct {
 	MilestoneID      int64
 	OldMilestone     *Milestone `xorm:"-"`
 	Milestone        *Milestone `xorm:"-"`
+	OldProjectID     int64
+	ProjectID        int64
+	OldProject       *Project `xorm:"-"`
+	Project          *Project `xorm:"-"`
 	AssigneeID       int64
 	RemovedAssignee  bool
 	Assignee         *User `xorm:"-"`
@@ -140,6 +150,8 @@ type Comment struct {
 
 	CommitID        int64
 	Line            int64 // - previous line / + proposed line
+	StartLine       int64 `xorm:"NOT NULL DEFAULT 0"` // first line of a comment on several lines, on the same side as Line
+	IsFileComment   bool  `xorm:"NOT NULL DEFAULT false"`
 	TreePath        string
 	Content         string `xorm:"TEXT"`
 	RenderedContent string `xorm:"-"`
@@ -413,6 +425,30 @@ func (c *Comment) LoadMilestone() error {
 	return nil
 }
 
+// LoadProject if comment.Type is CommentTypeProject, then load the projects
+func (c *Comment) LoadProject() error {
+	if c.OldProjectID > 0 {
+		var oldProject Project
+		has, err := x.ID(c.OldProjectID).Get(&oldProject)
+		if err != nil {
+			return err
+		} else if has {
+			c.OldProject = &oldProject
+		}
+	}
+
+	if c.ProjectID > 0 {
+		var project Project
+		has, err := x.ID(c.ProjectID).Get(&project)
+		if err != nil {
+			return err
+		} else if has {
+			c.Project = &project
+		}
+	}
+	return nil
+}
+
 // LoadPoster loads comment poster
 func (c *Comment) LoadPoster() error {
 	return c.loadPoster(x)
@@ -539,8 +575,17 @@ func (c *Comment) LoadReview() error {
 var notEnoughLines = regexp.MustCompile(`fatal: file .* has only \d+ lines?`)
 
 func (c *Comment) checkInvalidation(doer *User, repo *git.Repository, branch string) error {
+	if c.IsFileComment {
+		// The comment is not attached to any line that could change
+		return nil
+	}
 	// FIXME differentiate between previous and proposed line
-	commit, err := repo.LineBlame(branch, repo.Path, c.TreePath, uint(c.UnsignedLine()))
+	startLine := c.UnsignedStartLine()
+	if startLine == 0 {
+		startLine = c.UnsignedLine()
+	}
+	// A change of any of the commented lines invalidates the comment
+	commit, err := repo.LinesBlame(branch, repo.Path, c.TreePath, uint(startLine), uint(c.UnsignedLine()))
 	if err != nil && (strings.Contains(err.Error(), "fatal: no such path") || notEnoughLines.MatchString(err.Error())) {
 		c.Invalidated = true
 		return UpdateComment(c, doer)
@@ -577,6 +622,15 @@ func (c *Comment) UnsignedLine() uint64 {
 	return uint64(c.Line)
 }
 
+// UnsignedStartLine returns the first LOC of a code comment on several lines without + or -,
+// or 0 if the comment is on a single line
+func (c *Comment) UnsignedStartLine() uint64 {
+	if c.StartLine < 0 {
+		return uint64(c.StartLine * -1)
+	}
+	return uint64(c.StartLine)
+}
+
 // CodeCommentURL returns the url to a comment in code
 func (c *Comment) CodeCommentURL() string {
 	err := c.LoadIssue()
@@ -647,11 +701,15 @@ func createComment(e *xorm.Session, opts *CreateCommentOptions) (_ *Comment, err
 		LabelID:          LabelID,
 		OldMilestoneID:   opts.OldMilestoneID,
 		MilestoneID:      opts.MilestoneID,
+		OldProjectID:     opts.OldProjectID,
+		ProjectID:        opts.ProjectID,
 		RemovedAssignee:  opts.RemovedAssignee,
 		AssigneeID:       opts.AssigneeID,
 		CommitID:         opts.CommitID,
 		CommitSHA:        opts.CommitSHA,
 		Line:             opts.LineNum,
+		StartLine:        opts.StartLineNum,
+		IsFileComment:    opts.IsFileComment,
 		Content:          opts.Content,
 		OldTitle:         opts.OldTitle,
 		NewTitle:         opts.NewTitle,
@@ -810,6 +868,8 @@ type CreateCommentOptions struct {
 	DependentIssueID int64
 	OldMilestoneID   int64
 	MilestoneID      int64
+	OldProjectID     int64
+	ProjectID        int64
 	AssigneeID       int64
 	RemovedAssignee  bool
 	OldTitle         string
@@ -820,6 +880,8 @@ type CreateCommentOptions struct {
 	CommitSHA        string
 	Patch            string
 	LineNum          int64
+	StartLineNum     int64
+	IsFileComment    bool
 	TreePath         string
 	ReviewID         int64
 	Content          string
@@ -1074,7 +1136,7 @@ func fetchCodeCommentsByReview(e Engine, issue *Issue, currentUser *User, review
 			comment.Review = re
 		}
 
-		comment.RenderedContent = string(markdown.Render([]byte(comment.Content), issue.Repo.Link(),
+		comment.RenderedContent = string(markdown.Render([]byte(comment.ContentWithSuggestionDiffs()), issue.Repo.Link(),
 			issue.Repo.ComposeMetas()))
 		if pathToLineToComment[comment.TreePath] == nil {
 			pathToLineToComment[comment.TreePath] = make(map[int64][]*Comment)
@@ -0,0 +1,132 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"strings"
+)
+
+// Suggestion represents a change proposed in a ```suggestion block of a code comment,
+// replacing the commented lines OldLines with NewLines
+type Suggestion struct {
+	OldLines []string
+	NewLines []string
+}
+
+// suggestionFence returns the fence opening a ```suggestion block if line is one
+func suggestionFence(line string) string {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 {
+		return ""
+	}
+	for _, char := range []string{"`", "~"} {
+		info := strings.TrimLeft(trimmed, char)
+		if n := len(trimmed) - len(info); n >= 3 && strings.TrimSpace(info) == "suggestion" {
+			return trimmed[:n]
+		}
+	}
+	return ""
+}
+
+// isClosingFence returns true if line closes the block opened by fence
+func isClosingFence(line, fence string) bool {
+	trimmed := strings.TrimSpace(line)
+	return len(line)-len(strings.TrimLeft(line, " ")) <= 3 &&
+		strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == ""
+}
+
+// replaceSuggestionBlocks calls replace with the fence and the lines of every ```suggestion
+// block of content and substitutes the whole block, fences included, by the returned lines
+func replaceSuggestionBlocks(content string, replace func(fence string, lines []string) []string) string {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	result := make([]string, 0, len(lines))
+	for i := 0; i < len(lines); i++ {
+		fence := suggestionFence(lines[i])
+		if len(fence) == 0 {
+			result = append(result, lines[i])
+			continue
+		}
+		block := make([]string, 0, 5)
+		for i++; i < len(lines) && !isClosingFence(lines[i], fence); i++ {
+			block = append(block, lines[i])
+		}
+		result = append(result, replace(fence, block)...)
+	}
+	return strings.Join(result, "\n")
+}
+
+// commentedLines returns the content of the lines the code comment is attached to, which
+// end its patch, or nil if they are unknown
+func (c *Comment) commentedLines() []string {
+	if len(c.Patch) == 0 {
+		return nil
+	}
+	count := 1
+	if c.StartLine != 0 {
+		count = int(c.UnsignedLine()-c.UnsignedStartLine()) + 1
+	}
+
+	// Collect the lines of the new side from the end of the patch
+	patchLines := strings.Split(c.Patch, "\n")
+	last := patchLines[len(patchLines)-1]
+	if len(last) == 0 || (last[0] != '+' && last[0] != ' ') {
+		return nil
+	}
+	lines := make([]string, count)
+	for i := len(patchLines) - 1; i >= 0 && count > 0; i-- {
+		patchLine := patchLines[i]
+		if len(patchLine) == 0 || strings.HasPrefix(patchLine, "@@") {
+			break
+		}
+		if patchLine[0] == '+' || patchLine[0] == ' ' {
+			count--
+			lines[count] = patchLine[1:]
+		}
+	}
+	if count > 0 {
+		// The patch does not cover all the commented lines
+		return nil
+	}
+	return lines
+}
+
+// Suggestions returns the changes proposed by the ```suggestion blocks of a code comment.
+// Only comments on the new side of the diff whose commented lines are known propose changes.
+func (c *Comment) Suggestions() []*Suggestion {
+	if c.Type != CommentTypeCode || c.Line <= 0 {
+		return nil
+	}
+	oldLines := c.commentedLines()
+	if oldLines == nil {
+		return nil
+	}
+
+	var suggestions []*Suggestion
+	replaceSuggestionBlocks(c.Content, func(fence string, lines []string) []string {
+		suggestions = append(suggestions, &Suggestion{OldLines: oldLines, NewLines: lines})
+		return nil
+	})
+	return suggestions
+}
+
+// ContentWithSuggestionDiffs returns the content of the comment to be rendered, with its
+// ```suggestion blocks turned into diffs of the proposed changes
+func (c *Comment) ContentWithSuggestionDiffs() string {
+	if len(c.Suggestions()) == 0 {
+		return c.Content
+	}
+	oldLines := c.commentedLines()
+	return replaceSuggestionBlocks(c.Content, func(fence string, lines []string) []string {
+		diff := make([]string, 0, len(oldLines)+len(lines)+2)
+		diff = append(diff, fence+"diff")
+		for _, line := range oldLines {
+			diff = append(diff, "-"+line)
+		}
+		for _, line := range lines {
+			diff = append(diff, "+"+line)
+		}
+		return append(diff, fence)
+	})
+}
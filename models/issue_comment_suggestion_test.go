@@ -0,0 +1,60 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComment_Suggestions(t *testing.T) {
+	patch := "diff --git a/README.md b/README.md\n--- a/README.md\n+++ b/README.md\n@@ -1,2 +1,2 @@\n # repo1\n+Description for repo1"
+
+	comment := &Comment{
+		Type:    CommentTypeCode,
+		Line:    2,
+		Patch:   patch,
+		Content: "Better:\n```suggestion\nA description\nfor repo1\n```\nand\n~~~~ suggestion \n~~~~",
+	}
+	suggestions := comment.Suggestions()
+	if assert.Len(t, suggestions, 2) {
+		assert.EqualValues(t, []string{"Description for repo1"}, suggestions[0].OldLines)
+		assert.EqualValues(t, []string{"A description", "for repo1"}, suggestions[0].NewLines)
+		assert.EqualValues(t, []string{"Description for repo1"}, suggestions[1].OldLines)
+		assert.Empty(t, suggestions[1].NewLines)
+	}
+	assert.Equal(t, "Better:\n```diff\n-Description for repo1\n+A description\n+for repo1\n```\nand\n~~~~diff\n-Description for repo1\n~~~~",
+		comment.ContentWithSuggestionDiffs())
+
+	// Other code blocks are left alone
+	comment.Content = "```go\nfmt.Println()\n```\n    ```suggestion\n"
+	assert.Empty(t, comment.Suggestions())
+	assert.Equal(t, comment.Content, comment.ContentWithSuggestionDiffs())
+
+	// Comments on the previous side do not propose changes
+	comment.Content = "```suggestion\nA description\n```"
+	comment.Line = -2
+	assert.Empty(t, comment.Suggestions())
+	assert.Equal(t, comment.Content, comment.ContentWithSuggestionDiffs())
+
+	// A comment on several lines replaces all of them
+	comment.Line = 2
+	comment.StartLine = 1
+	suggestions = comment.Suggestions()
+	if assert.Len(t, suggestions, 1) {
+		assert.EqualValues(t, []string{"# repo1", "Description for repo1"}, suggestions[0].OldLines)
+		assert.EqualValues(t, []string{"A description"}, suggestions[0].NewLines)
+	}
+
+	// Comments whose commented lines are not all in the patch do not propose changes
+	comment.Patch = "diff --git a/README.md b/README.md\n--- a/README.md\n+++ b/README.md\n@@ -2 +2 @@\n+Description for repo1"
+	assert.Empty(t, comment.Suggestions())
+
+	// Neither do comments whose commented line is unknown
+	comment.StartLine = 0
+	comment.Patch = ""
+	assert.Empty(t, comment.Suggestions())
+}
@@ -17,6 +17,7 @@ import (
 	"code.gitea.io/gitea/modules/auth/ldap"
 	"code.gitea.io/gitea/modules/auth/oauth2"
 	"code.gitea.io/gitea/modules/auth/pam"
+	"code.gitea.io/gitea/modules/auth/saml"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/timeutil"
@@ -39,6 +40,7 @@ const (
 	LoginDLDAP            // 5
 	LoginOAuth2           // 6
 	LoginSSPI             // 7
+	LoginSAML             // 8
 )
 
 // LoginNames contains the name of LoginType values.
@@ -49,6 +51,7 @@ var LoginNames = map[LoginType]string{
 	LoginPAM:    "PAM",
 	LoginOAuth2: "OAuth2",
 	LoginSSPI:   "SPNEGO with SSPI",
+	LoginSAML:   "SAML 2.0",
 }
 
 // SecurityProtocolNames contains the name of SecurityProtocol values.
@@ -65,6 +68,7 @@ var (
 	_ convert.Conversion = &PAMConfig{}
 	_ convert.Conversion = &OAuth2Config{}
 	_ convert.Conversion = &SSPIConfig{}
+	_ convert.Conversion = &SAMLConfig{}
 )
 
 // LDAPConfig holds configuration for LDAP login source.
@@ -130,6 +134,8 @@ type OAuth2Config struct {
 	ClientSecret                  string
 	OpenIDConnectAutoDiscoveryURL string
 	CustomURLMapping              *oauth2.CustomURLMapping
+	GroupClaimName                string
+	GroupTeamMap                  string
 }
 
 // FromDB fills up an OAuth2Config from serialized format.
@@ -161,6 +167,21 @@ func (cfg *SSPIConfig) ToDB() ([]byte, error) {
 	return json.Marshal(cfg)
 }
 
+// SAMLConfig holds configuration for the SAML 2.0 login source.
+type SAMLConfig struct {
+	*saml.Source
+}
+
+// FromDB fills up a SAMLConfig from serialized format.
+func (cfg *SAMLConfig) FromDB(bs []byte) error {
+	return json.Unmarshal(bs, &cfg)
+}
+
+// ToDB exports a SAMLConfig to a serialized format.
+func (cfg *SAMLConfig) ToDB() ([]byte, error) {
+	return json.Marshal(cfg)
+}
+
 // LoginSource represents an external way for authorizing users.
 type LoginSource struct {
 	ID            int64 `xorm:"pk autoincr"`
@@ -199,6 +220,8 @@ func (source *LoginSource) BeforeSet(colName string, val xorm.Cell) {
 			source.Cfg = new(OAuth2Config)
 		case LoginSSPI:
 			source.Cfg = new(SSPIConfig)
+		case LoginSAML:
+			source.Cfg = new(SAMLConfig)
 		default:
 			panic("unrecognized login source type: " + com.ToStr(*val))
 		}
@@ -240,11 +263,16 @@ func (source *LoginSource) IsSSPI() bool {
 	return source.Type == LoginSSPI
 }
 
+// IsSAML returns true of this source is of the SAML type.
+func (source *LoginSource) IsSAML() bool {
+	return source.Type == LoginSAML
+}
+
 // HasTLS returns true of this source supports TLS.
 func (source *LoginSource) HasTLS() bool {
 	return ((source.IsLDAP() || source.IsDLDAP()) &&
 		source.LDAP().SecurityProtocol > ldap.SecurityProtocolUnencrypted) ||
-		source.IsSMTP()
+		source.IsSMTP() || source.IsSAML()
 }
 
 // UseTLS returns true of this source is configured to use TLS.
@@ -267,6 +295,8 @@ func (source *LoginSource) SkipVerify() bool {
 		return source.LDAP().SkipVerify
 	case LoginSMTP:
 		return source.SMTP().SkipVerify
+	case LoginSAML:
+		return source.SAML().SkipVerify
 	}
 
 	return false
@@ -297,6 +327,11 @@ func (source *LoginSource) SSPI() *SSPIConfig {
 	return source.Cfg.(*SSPIConfig)
 }
 
+// SAML returns SAMLConfig for this source, if of SAML type.
+func (source *LoginSource) SAML() *SAMLConfig {
+	return source.Cfg.(*SAMLConfig)
+}
+
 // CreateLoginSource inserts a LoginSource in the DB if not already
 // existing with the given name.
 func CreateLoginSource(source *LoginSource) error {
@@ -535,6 +570,46 @@ func LoginViaLDAP(user *User, login, password string, source *LoginSource) (*Use
 	return user, err
 }
 
+// PreviewLDAPGroupTeamSync returns the team membership changes the next user
+// synchronization of the LDAP source would make for its existing users,
+// without applying them
+func PreviewLDAPGroupTeamSync(source *LoginSource) ([]*GroupTeamSyncChange, error) {
+	mapping, err := ParseGroupTeamMapping(source.LDAP().GroupTeamMap)
+	if err != nil || len(mapping) == 0 {
+		return nil, err
+	}
+
+	var users []*User
+	if err := x.Where("login_type = ?", LoginLDAP).
+		And("login_source = ?", source.ID).
+		Find(&users); err != nil {
+		return nil, err
+	}
+	usersByName := make(map[string]*User, len(users))
+	for _, u := range users {
+		usersByName[u.LowerName] = u
+	}
+
+	sr, err := source.LDAP().SearchEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []*GroupTeamSyncChange
+	for _, su := range sr {
+		u, ok := usersByName[strings.ToLower(su.Username)]
+		if !ok {
+			continue
+		}
+		userChanges, err := PlanGroupTeamSync(u, su.Groups, mapping, true)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, userChanges...)
+	}
+	return changes, nil
+}
+
 //   _________   __________________________
 //  /   _____/  /     \__    ___/\______   \
 //  \_____  \  /  \ /  \|    |    |     ___/
@@ -762,7 +837,7 @@ func UserSignIn(username, password string) (*User, error) {
 
 	if hasUser {
 		switch user.LoginType {
-		case LoginNoType, LoginPlain, LoginOAuth2:
+		case LoginNoType, LoginPlain, LoginOAuth2, LoginSAML:
 			if user.IsPasswordSet() && user.ValidatePassword(password) {
 
 				// Update password hash if server password hash algorithm have changed
@@ -803,8 +878,8 @@ func UserSignIn(username, password string) (*User, error) {
 	}
 
 	for _, source := range sources {
-		if source.IsOAuth2() || source.IsSSPI() {
-			// don't try to authenticate against OAuth2 and SSPI sources here
+		if source.IsOAuth2() || source.IsSSPI() || source.IsSAML() {
+			// don't try to authenticate against OAuth2, SSPI and SAML sources here
 			continue
 		}
 		authUser, err := ExternalUserLogin(nil, username, password, source)
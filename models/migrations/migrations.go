@@ -220,6 +220,32 @@ var migrations = []Migration{
 	NewMigration("Ensure Repository.IsArchived is not null", setIsArchivedToFalse),
 	// v143 -> v144
 	NewMigration("recalculate Stars number for all user", recalculateStars),
+	// v144 -> v145
+	NewMigration("add push mirror table", addPushMirrorTable),
+	// v145 -> v146
+	NewMigration("Add RequireCodeOwnerApproval to ProtectedBranch", addRequireCodeOwnerApproval),
+	// v146 -> v147
+	NewMigration("Add scope, repository restriction and expiry to access tokens", addScopeToAccessToken),
+	// v147 -> v148
+	NewMigration("Add projects, project boards and project issues tables", addProjectsTables),
+	// v148 -> v149
+	NewMigration("Add package registry tables", addPackagesTables),
+	// v149 -> v150
+	NewMigration("Add action runner, run, job and step tables", addActionsTables),
+	// v150 -> v151
+	NewMigration("Add delivery attempts to hook tasks", addAttemptsToHookTask),
+	// v151 -> v152
+	NewMigration("Add signing options and previous secret to webhooks", addSigningOptionsToWebhook),
+	// v152 -> v153
+	NewMigration("Add audit event table", addAuditEventTable),
+	// v153 -> v154
+	NewMigration("Convert U2F registrations to WebAuthn credentials", convertU2FToWebAuthn),
+	// v154 -> v155
+	NewMigration("Add pull auto merge table", addPullAutoMergeTable),
+	// v155 -> v156
+	NewMigration("Add flow to pull request", addPullRequestFlow),
+	// v156 -> v157
+	NewMigration("Add start line and file level flag to code comments", addCodeCommentStartLineAndFileLevel),
 }
 
 // GetCurrentDBVersion returns the current db version
@@ -0,0 +1,62 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addProjectsTables(x *xorm.Engine) error {
+	type Project struct {
+		ID          int64  `xorm:"pk autoincr"`
+		Title       string `xorm:"INDEX NOT NULL"`
+		Description string `xorm:"TEXT"`
+		RepoID      int64  `xorm:"INDEX"`
+		OwnerID     int64  `xorm:"INDEX"`
+		CreatorID   int64  `xorm:"NOT NULL"`
+		IsClosed    bool   `xorm:"INDEX"`
+		BoardType   uint8
+		Type        uint8
+
+		CreatedUnix    timeutil.TimeStamp `xorm:"INDEX created"`
+		UpdatedUnix    timeutil.TimeStamp `xorm:"INDEX updated"`
+		ClosedDateUnix timeutil.TimeStamp
+	}
+
+	type ProjectBoard struct {
+		ID        int64  `xorm:"pk autoincr"`
+		Title     string `xorm:"NOT NULL"`
+		IsDefault bool   `xorm:"NOT NULL DEFAULT false"`
+		IsDone    bool   `xorm:"NOT NULL DEFAULT false"`
+		Sorting   int8   `xorm:"NOT NULL DEFAULT 0"`
+		ProjectID int64  `xorm:"INDEX NOT NULL"`
+		CreatorID int64  `xorm:"NOT NULL"`
+
+		CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+		UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
+	}
+
+	type ProjectIssue struct {
+		ID             int64 `xorm:"pk autoincr"`
+		IssueID        int64 `xorm:"INDEX"`
+		ProjectID      int64 `xorm:"INDEX"`
+		ProjectBoardID int64 `xorm:"INDEX"`
+		Sorting        int64 `xorm:"NOT NULL DEFAULT 0"`
+	}
+
+	type Comment struct {
+		OldProjectID int64
+		ProjectID    int64
+	}
+
+	if err := x.Sync2(new(Project), new(ProjectBoard), new(ProjectIssue), new(Comment)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
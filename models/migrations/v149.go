@@ -0,0 +1,80 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addActionsTables(x *xorm.Engine) error {
+	type ActionRunner struct {
+		ID             int64 `xorm:"pk autoincr"`
+		Name           string
+		Labels         []string `xorm:"JSON TEXT"`
+		TokenHash      string   `xorm:"UNIQUE"`
+		TokenSalt      string
+		TokenLastEight string `xorm:"INDEX token_last_eight"`
+
+		LastOnlineUnix timeutil.TimeStamp `xorm:"INDEX"`
+		CreatedUnix    timeutil.TimeStamp `xorm:"INDEX created"`
+		UpdatedUnix    timeutil.TimeStamp `xorm:"INDEX updated"`
+	}
+
+	type ActionRun struct {
+		ID            int64 `xorm:"pk autoincr"`
+		Index         int64 `xorm:"INDEX UNIQUE(repo_index)"`
+		RepoID        int64 `xorm:"INDEX UNIQUE(repo_index)"`
+		WorkflowID    string
+		Title         string
+		TriggerUserID int64
+		Event         string
+		Ref           string
+		CommitSHA     string `xorm:"VARCHAR(40)"`
+		PullRequestID int64  `xorm:"INDEX"`
+		Status        int    `xorm:"INDEX"`
+
+		StartedUnix timeutil.TimeStamp
+		StoppedUnix timeutil.TimeStamp
+		CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+		UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+	}
+
+	type ActionRunJob struct {
+		ID          int64 `xorm:"pk autoincr"`
+		RunID       int64 `xorm:"INDEX"`
+		RepoID      int64 `xorm:"INDEX"`
+		JobID       string
+		Name        string
+		RunsOn      []string `xorm:"JSON TEXT"`
+		Needs       []string `xorm:"JSON TEXT"`
+		Payload     string   `xorm:"LONGTEXT"`
+		Status      int      `xorm:"INDEX"`
+		RunnerID    int64    `xorm:"INDEX"`
+		LogChunks   int
+		StartedUnix timeutil.TimeStamp
+		StoppedUnix timeutil.TimeStamp
+		CreatedUnix timeutil.TimeStamp `xorm:"created"`
+		UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
+	}
+
+	type ActionRunStep struct {
+		ID          int64 `xorm:"pk autoincr"`
+		JobID       int64 `xorm:"INDEX"`
+		Index       int
+		Name        string
+		Status      int
+		StartedUnix timeutil.TimeStamp
+		StoppedUnix timeutil.TimeStamp
+	}
+
+	if err := x.Sync2(new(ActionRunner), new(ActionRun), new(ActionRunJob), new(ActionRunStep)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
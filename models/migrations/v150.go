@@ -0,0 +1,30 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addAttemptsToHookTask(x *xorm.Engine) error {
+	type HookTask struct {
+		Attempts        int                `xorm:"NOT NULL DEFAULT 0"`
+		NextAttemptUnix timeutil.TimeStamp `xorm:"INDEX NOT NULL DEFAULT 0"`
+	}
+
+	if err := x.Sync2(new(HookTask)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+
+	// delivered tasks have been attempted once
+	if _, err := x.Exec("UPDATE `hook_task` SET `attempts` = 1 WHERE `is_delivered` = ?", true); err != nil {
+		return fmt.Errorf("update attempts: %v", err)
+	}
+	return nil
+}
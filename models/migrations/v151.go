@@ -0,0 +1,27 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addSigningOptionsToWebhook(x *xorm.Engine) error {
+	type Webhook struct {
+		OldSecret            string `xorm:"TEXT"`
+		OldSecretExpiresUnix timeutil.TimeStamp
+		SignatureAlgorithm   string
+		SignTimestamp        bool `xorm:"NOT NULL DEFAULT false"`
+	}
+
+	if err := x.Sync2(new(Webhook)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addAuditEventTable(x *xorm.Engine) error {
+	type AuditEvent struct {
+		ID        int64  `xorm:"pk autoincr"`
+		Action    string `xorm:"INDEX NOT NULL"`
+		ActorID   int64  `xorm:"INDEX"`
+		ActorName string
+		IPAddress string `xorm:"ip_address"`
+
+		RepoID   int64 `xorm:"INDEX"`
+		RepoName string
+		OrgID    int64 `xorm:"INDEX"`
+		OrgName  string
+
+		TargetType string
+		TargetID   int64
+		TargetName string
+		Details    string `xorm:"TEXT"`
+
+		CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+	}
+
+	if err := x.Sync2(new(AuditEvent)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"crypto/elliptic"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"github.com/duo-labs/webauthn/protocol/webauthncose"
+	"github.com/fxamacker/cbor/v2"
+	"xorm.io/xorm"
+)
+
+func convertU2FToWebAuthn(x *xorm.Engine) error {
+	type WebAuthnCredential struct {
+		ID           int64 `xorm:"pk autoincr"`
+		Name         string
+		LowerName    string `xorm:"unique(s)"`
+		UserID       int64  `xorm:"INDEX unique(s)"`
+		CredentialID string `xorm:"UNIQUE VARCHAR(410)"`
+		PublicKey    []byte
+		AAGUID       []byte
+		SignCount    uint32             `xorm:"BIGINT"`
+		LegacyU2F    bool               `xorm:"legacy_u2f NOT NULL DEFAULT false"`
+		Passwordless bool               `xorm:"NOT NULL DEFAULT false"`
+		CreatedUnix  timeutil.TimeStamp `xorm:"INDEX created"`
+		UpdatedUnix  timeutil.TimeStamp `xorm:"INDEX updated"`
+	}
+
+	type U2FRegistration struct {
+		ID          int64 `xorm:"pk autoincr"`
+		Name        string
+		UserID      int64 `xorm:"INDEX"`
+		Raw         []byte
+		Counter     uint32             `xorm:"BIGINT"`
+		CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+		UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Table("webauthn_credential").Sync2(new(WebAuthnCredential)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+
+	exist, err := x.IsTableExist("u2f_registration")
+	if err != nil {
+		return err
+	} else if !exist {
+		return nil
+	}
+
+	// All the registrations are converted in one transaction: the U2F registrations are
+	// only dropped once every one of them has been converted.
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	names := make(map[int64]map[string]bool)
+	credentialIDs := make(map[string]bool)
+	batchSize := setting.Database.IterateBufferSize
+	for start := 0; ; start += batchSize {
+		regs := make([]*U2FRegistration, 0, batchSize)
+		if err := sess.Table("u2f_registration").Asc("id").Limit(batchSize, start).Find(&regs); err != nil {
+			return err
+		}
+		if len(regs) == 0 {
+			break
+		}
+
+		for _, reg := range regs {
+			credentialID, publicKey, err := parseU2FRegistration(reg.Raw)
+			if err != nil {
+				return fmt.Errorf("unable to convert U2F registration %d of user %d, delete it from the u2f_registration table to continue the migration: %v", reg.ID, reg.UserID, err)
+			}
+			// the same key may have been registered twice, its credential can only be kept once
+			encodedID := base64.RawURLEncoding.EncodeToString(credentialID)
+			if credentialIDs[encodedID] {
+				log.Warn("Skipping U2F registration %d of user %d: the key is already registered", reg.ID, reg.UserID)
+				continue
+			}
+			credentialIDs[encodedID] = true
+
+			if names[reg.UserID] == nil {
+				names[reg.UserID] = make(map[string]bool)
+			}
+			name := reg.Name
+			if names[reg.UserID][strings.ToLower(name)] {
+				name = fmt.Sprintf("%s (%d)", name, reg.ID)
+			}
+			names[reg.UserID][strings.ToLower(name)] = true
+
+			if _, err := sess.Table("webauthn_credential").NoAutoTime().Insert(&WebAuthnCredential{
+				Name:         name,
+				LowerName:    strings.ToLower(name),
+				UserID:       reg.UserID,
+				CredentialID: encodedID,
+				PublicKey:    publicKey,
+				AAGUID:       make([]byte, 16),
+				SignCount:    reg.Counter,
+				LegacyU2F:    true,
+				CreatedUnix:  reg.CreatedUnix,
+				UpdatedUnix:  reg.UpdatedUnix,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	if err := sess.Commit(); err != nil {
+		return err
+	}
+
+	return x.DropTables("u2f_registration")
+}
+
+// parseU2FRegistration returns the key handle and the COSE encoded public key of a
+// raw U2F registration response: 0x05 | public key | key handle length | key handle
+// | attestation certificate | signature
+func parseU2FRegistration(raw []byte) ([]byte, []byte, error) {
+	if len(raw) < 1+65+1 || raw[0] != 0x05 {
+		return nil, nil, fmt.Errorf("invalid registration data")
+	}
+	if px, _ := elliptic.Unmarshal(elliptic.P256(), raw[1:66]); px == nil {
+		return nil, nil, fmt.Errorf("invalid public key")
+	}
+	khLen := int(raw[66])
+	if len(raw) < 67+khLen {
+		return nil, nil, fmt.Errorf("invalid key handle")
+	}
+
+	publicKey, err := cbor.Marshal(&webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  1, // P-256
+		XCoord: raw[2:34],
+		YCoord: raw[34:66],
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return raw[67 : 67+khLen], publicKey, nil
+}
@@ -0,0 +1,37 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addPullAutoMergeTable(x *xorm.Engine) error {
+	type PullAutoMerge struct {
+		ID          int64              `xorm:"pk autoincr"`
+		PullID      int64              `xorm:"UNIQUE"`
+		DoerID      int64              `xorm:"NOT NULL"`
+		MergeStyle  string             `xorm:"varchar(30)"`
+		Message     string             `xorm:"TEXT"`
+		CreatedUnix timeutil.TimeStamp `xorm:"created"`
+	}
+
+	type PullAutoMergeLock struct {
+		ID          int64  `xorm:"pk autoincr"`
+		RepoID      int64  `xorm:"UNIQUE(s) NOT NULL"`
+		Branch      string `xorm:"UNIQUE(s) NOT NULL"`
+		Token       string `xorm:"NOT NULL"`
+		ExpiredUnix timeutil.TimeStamp
+	}
+
+	if err := x.Sync2(new(PullAutoMerge), new(PullAutoMergeLock)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
@@ -116,7 +116,7 @@ func init() {
 		new(LFSLock),
 		new(Reaction),
 		new(IssueAssignees),
-		new(U2FRegistration),
+		new(WebAuthnCredential),
 		new(TeamUnit),
 		new(Review),
 		new(OAuth2Application),
@@ -125,6 +125,21 @@ func init() {
 		new(Task),
 		new(LanguageStat),
 		new(EmailHash),
+		new(PushMirror),
+		new(Project),
+		new(ProjectBoard),
+		new(ProjectIssue),
+		new(Package),
+		new(PackageVersion),
+		new(PackageFile),
+		new(PackageBlob),
+		new(ActionRunner),
+		new(ActionRun),
+		new(ActionRunJob),
+		new(ActionRunStep),
+		new(AuditEvent),
+		new(PullAutoMerge),
+		new(PullAutoMergeLock),
 	)
 
 	gonicNames := []string{"SSL", "UID"}
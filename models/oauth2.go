@@ -6,8 +6,11 @@ package models
 
 import (
 	"sort"
+	"strings"
 
 	"code.gitea.io/gitea/modules/auth/oauth2"
+
+	"github.com/markbates/goth"
 )
 
 // OAuth2Provider describes the display values of a single OAuth2 provider
@@ -139,3 +142,40 @@ func wrapOpenIDConnectInitializeError(err error, providerName string, oAuth2Conf
 	}
 	return err
 }
+
+// oauth2ClaimValues returns the values of a claim holding either a list of
+// strings or a single string
+func oauth2ClaimValues(rawData map[string]interface{}, claim string) []string {
+	switch v := rawData[claim].(type) {
+	case string:
+		if v = strings.TrimSpace(v); v != "" {
+			return []string{v}
+		}
+	case []string:
+		return v
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, value := range v {
+			if s, ok := value.(string); ok && s != "" {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// SyncOAuth2GroupTeams synchronizes the team memberships of the user with the
+// groups in the group claim of the OAuth2 login source. Users are removed from
+// the mapped teams of groups missing from the claim.
+func SyncOAuth2GroupTeams(source *LoginSource, user *User, gothUser goth.User) error {
+	cfg := source.OAuth2()
+	if cfg.GroupClaimName == "" {
+		return nil
+	}
+	mapping, err := ParseGroupTeamMapping(cfg.GroupTeamMap)
+	if err != nil {
+		return err
+	}
+	return SyncGroupTeams(user, oauth2ClaimValues(gothUser.RawData, cfg.GroupClaimName), mapping, true)
+}
@@ -0,0 +1,52 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOAuth2ClaimValues(t *testing.T) {
+	rawData := map[string]interface{}{
+		"list":   []interface{}{"a", 1, "", "b"},
+		"single": " a ",
+		"empty":  "",
+	}
+	assert.Equal(t, []string{"a", "b"}, oauth2ClaimValues(rawData, "list"))
+	assert.Equal(t, []string{"a"}, oauth2ClaimValues(rawData, "single"))
+	assert.Empty(t, oauth2ClaimValues(rawData, "empty"))
+	assert.Empty(t, oauth2ClaimValues(rawData, "missing"))
+}
+
+func TestSyncOAuth2GroupTeams(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	user := AssertExistsAndLoadBean(t, &User{ID: 5}).(*User)
+	source := &LoginSource{
+		Type: LoginOAuth2,
+		Cfg: &OAuth2Config{
+			Provider:     "openidConnect",
+			GroupTeamMap: `{"developers": {"user3": ["team1"]}}`,
+		},
+	}
+	gothUser := goth.User{RawData: map[string]interface{}{
+		"groups": []interface{}{"developers"},
+	}}
+
+	// without a group claim name nothing is synchronized
+	assert.NoError(t, SyncOAuth2GroupTeams(source, user, gothUser))
+	AssertNotExistsBean(t, &TeamUser{TeamID: 2, UID: user.ID})
+
+	source.OAuth2().GroupClaimName = "groups"
+	assert.NoError(t, SyncOAuth2GroupTeams(source, user, gothUser))
+	AssertExistsAndLoadBean(t, &TeamUser{TeamID: 2, UID: user.ID})
+
+	// the user is removed once the group disappears from the claim
+	assert.NoError(t, SyncOAuth2GroupTeams(source, user, goth.User{}))
+	AssertNotExistsBean(t, &TeamUser{TeamID: 2, UID: user.ID})
+}
@@ -12,9 +12,9 @@ import (
 
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 	"code.gitea.io/gitea/modules/structs"
 
-	"github.com/unknwon/com"
 	"xorm.io/builder"
 	"xorm.io/xorm"
 )
@@ -275,7 +275,16 @@ func DeleteOrganization(org *User) (err error) {
 		}
 	}
 
-	return sess.Commit()
+	blobPaths, err := deletePackagesByOwnerID(sess, org.ID)
+	if err != nil {
+		return fmt.Errorf("deletePackagesByOwnerID: %v", err)
+	}
+
+	if err = sess.Commit(); err != nil {
+		return err
+	}
+	removePackageBlobs(blobPaths)
+	return nil
 }
 
 func deleteOrg(e *xorm.Session, u *User) error {
@@ -296,6 +305,10 @@ func deleteOrg(e *xorm.Session, u *User) error {
 		return fmt.Errorf("deleteBeans: %v", err)
 	}
 
+	if err := deleteProjectsByCond(e, builder.Eq{"owner_id": u.ID, "type": ProjectTypeOrganization}); err != nil {
+		return fmt.Errorf("deleteProjectsByCond: %v", err)
+	}
+
 	if _, err = e.ID(u.ID).Delete(new(User)); err != nil {
 		return fmt.Errorf("Delete: %v", err)
 	}
@@ -310,11 +323,9 @@ func deleteOrg(e *xorm.Session, u *User) error {
 	}
 
 	if len(u.Avatar) > 0 {
-		avatarPath := u.CustomAvatarPath()
-		if com.IsExist(avatarPath) {
-			if err := os.Remove(avatarPath); err != nil {
-				return fmt.Errorf("Failed to remove %s: %v", avatarPath, err)
-			}
+		avatarPath := u.CustomAvatarRelativePath()
+		if err := storage.Avatars.Delete(avatarPath); err != nil && !storage.IsNotExist(err) {
+			return fmt.Errorf("Failed to remove %s: %v", avatarPath, err)
 		}
 	}
 
@@ -0,0 +1,167 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"code.gitea.io/gitea/modules/log"
+)
+
+// GroupTeamMapping maps the groups of an external login source to the teams
+// of organizations: group -> organization name -> team names
+type GroupTeamMapping map[string]map[string][]string
+
+// ParseGroupTeamMapping parses a JSON encoded group to team mapping, an empty
+// string is an empty mapping
+func ParseGroupTeamMapping(s string) (GroupTeamMapping, error) {
+	mapping := make(GroupTeamMapping)
+	if strings.TrimSpace(s) == "" {
+		return mapping, nil
+	}
+	if err := json.Unmarshal([]byte(s), &mapping); err != nil {
+		return nil, err
+	}
+	return mapping, nil
+}
+
+// teamKey identifies a team by its lower case organization and team name
+type teamKey struct {
+	org  string
+	team string
+}
+
+// teams returns the teams mapped from the groups accepted by filter
+func (mapping GroupTeamMapping) teams(filter func(group string) bool) map[teamKey]bool {
+	teams := make(map[teamKey]bool)
+	for group, orgs := range mapping {
+		if !filter(group) {
+			continue
+		}
+		for org, names := range orgs {
+			for _, name := range names {
+				teams[teamKey{strings.ToLower(org), strings.ToLower(name)}] = true
+			}
+		}
+	}
+	return teams
+}
+
+// GroupTeamSyncChange is a team membership change of a group to team
+// synchronization
+type GroupTeamSyncChange struct {
+	User *User
+	Org  *User
+	Team *Team
+	Add  bool // whether the user is added to or removed from the team
+}
+
+// PlanGroupTeamSync returns the team membership changes needed for the user to
+// be a member of exactly the teams mapped from the groups they are a member of.
+// If removeUnmapped is false, no removals are planned. Organizations and teams
+// that do not exist are skipped.
+func PlanGroupTeamSync(user *User, groups []string, mapping GroupTeamMapping, removeUnmapped bool) ([]*GroupTeamSyncChange, error) {
+	if len(mapping) == 0 {
+		return nil, nil
+	}
+	isMember := make(map[string]bool, len(groups))
+	for _, group := range groups {
+		isMember[strings.ToLower(group)] = true
+	}
+	want := mapping.teams(func(group string) bool {
+		return isMember[strings.ToLower(group)]
+	})
+	all := mapping.teams(func(string) bool {
+		return true
+	})
+
+	keys := make([]teamKey, 0, len(all))
+	for key := range all {
+		if want[key] || removeUnmapped {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].org != keys[j].org {
+			return keys[i].org < keys[j].org
+		}
+		return keys[i].team < keys[j].team
+	})
+
+	var changes []*GroupTeamSyncChange
+	for _, key := range keys {
+		org, err := GetOrgByName(key.org)
+		if err != nil {
+			if IsErrOrgNotExist(err) {
+				log.Warn("PlanGroupTeamSync: organization %s of the group mapping does not exist", key.org)
+				continue
+			}
+			return nil, err
+		}
+		team, err := org.GetTeam(key.team)
+		if err != nil {
+			if IsErrTeamNotExist(err) {
+				log.Warn("PlanGroupTeamSync: team %s/%s of the group mapping does not exist", key.org, key.team)
+				continue
+			}
+			return nil, err
+		}
+
+		member, err := IsTeamMember(org.ID, team.ID, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if member != want[key] {
+			changes = append(changes, &GroupTeamSyncChange{
+				User: user,
+				Org:  org,
+				Team: team,
+				Add:  want[key],
+			})
+		}
+	}
+	return changes, nil
+}
+
+// RecordGroupTeamSyncChange is called with every applied team membership change of
+// a group to team synchronization, the audit log sets it to record the changes.
+var RecordGroupTeamSyncChange = func(change *GroupTeamSyncChange) {}
+
+// ApplyGroupTeamSync applies team membership changes. The last owner of an
+// organization is never removed from its owners team.
+func ApplyGroupTeamSync(changes []*GroupTeamSyncChange) error {
+	for _, change := range changes {
+		if change.Add {
+			if err := AddTeamMember(change.Team, change.User.ID); err != nil {
+				return err
+			}
+			RecordGroupTeamSyncChange(change)
+			continue
+		}
+		if err := RemoveTeamMember(change.Team, change.User.ID); err != nil {
+			if IsErrLastOrgOwner(err) {
+				log.Warn("ApplyGroupTeamSync: cannot remove %s as the last owner of %s", change.User.Name, change.Org.Name)
+				continue
+			}
+			return err
+		}
+		RecordGroupTeamSyncChange(change)
+	}
+	return nil
+}
+
+// SyncGroupTeams adds the user to the teams mapped from the groups they are a
+// member of. If removeUnmapped is true, the user is also removed from the teams
+// in the mapping which none of their groups map to. Organizations and teams that
+// do not exist are skipped.
+func SyncGroupTeams(user *User, groups []string, mapping GroupTeamMapping, removeUnmapped bool) error {
+	changes, err := PlanGroupTeamSync(user, groups, mapping, removeUnmapped)
+	if err != nil {
+		return err
+	}
+	return ApplyGroupTeamSync(changes)
+}
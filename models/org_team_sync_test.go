@@ -0,0 +1,98 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseGroupTeamMapping(t *testing.T) {
+	mapping, err := ParseGroupTeamMapping("")
+	assert.NoError(t, err)
+	assert.Empty(t, mapping)
+
+	mapping, err = ParseGroupTeamMapping(`{"developers": {"user3": ["team1", "test_team"]}}`)
+	assert.NoError(t, err)
+	assert.Equal(t, GroupTeamMapping{"developers": {"user3": {"team1", "test_team"}}}, mapping)
+
+	_, err = ParseGroupTeamMapping(`{"developers": ["team1"]}`)
+	assert.Error(t, err)
+}
+
+func TestSyncGroupTeams(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	user := AssertExistsAndLoadBean(t, &User{ID: 5}).(*User)
+	mapping := GroupTeamMapping{
+		"Developers": {"User3": {"Team1"}, "nonexistent": {"team"}},
+		"testers":    {"user3": {"test_team", "nonexistent"}},
+	}
+
+	isMember := func(teamID int64) bool {
+		member, err := IsTeamMember(3, teamID, user.ID)
+		assert.NoError(t, err)
+		return member
+	}
+
+	assert.NoError(t, SyncGroupTeams(user, []string{"developers", "testers"}, mapping, false))
+	assert.True(t, isMember(2))
+	assert.True(t, isMember(7))
+
+	// without removal, teams of groups the user left are kept
+	assert.NoError(t, SyncGroupTeams(user, []string{"developers"}, mapping, false))
+	assert.True(t, isMember(2))
+	assert.True(t, isMember(7))
+
+	assert.NoError(t, SyncGroupTeams(user, []string{"developers"}, mapping, true))
+	assert.True(t, isMember(2))
+	assert.False(t, isMember(7))
+
+	assert.NoError(t, SyncGroupTeams(user, nil, mapping, true))
+	assert.False(t, isMember(2))
+	assert.False(t, isMember(7))
+
+	// the last owner of an organization is never removed
+	owner := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	assert.NoError(t, SyncGroupTeams(owner, nil, GroupTeamMapping{"admins": {"user3": {"owners"}}}, true))
+	member, err := IsTeamMember(3, 1, owner.ID)
+	assert.NoError(t, err)
+	assert.True(t, member)
+}
+
+func TestPlanGroupTeamSync(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	// user 2 is a member of team1 but not of test_team
+	user := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	mapping := GroupTeamMapping{
+		"developers": {"user3": {"team1"}},
+		"testers":    {"user3": {"test_team"}},
+	}
+
+	changes, err := PlanGroupTeamSync(user, []string{"developers", "testers"}, mapping, true)
+	assert.NoError(t, err)
+	if assert.Len(t, changes, 1) {
+		assert.EqualValues(t, 7, changes[0].Team.ID)
+		assert.EqualValues(t, 3, changes[0].Org.ID)
+		assert.True(t, changes[0].Add)
+	}
+
+	changes, err = PlanGroupTeamSync(user, nil, mapping, true)
+	assert.NoError(t, err)
+	if assert.Len(t, changes, 1) {
+		assert.EqualValues(t, 2, changes[0].Team.ID)
+		assert.False(t, changes[0].Add)
+	}
+
+	changes, err = PlanGroupTeamSync(user, nil, mapping, false)
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+
+	// planning changes nothing
+	AssertExistsAndLoadBean(t, &TeamUser{TeamID: 2, UID: user.ID})
+	AssertNotExistsBean(t, &TeamUser{TeamID: 7, UID: user.ID})
+}
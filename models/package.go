@@ -0,0 +1,549 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"code.gitea.io/gitea/modules/storage"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+// PackageType represents the protocol a package is published with
+type PackageType int
+
+const (
+	// PackageTypeGeneric is a package of arbitrary files uploaded over plain HTTP
+	PackageTypeGeneric PackageType = iota + 1
+	// PackageTypeNpm is a package of the npm registry protocol
+	PackageTypeNpm
+	// PackageTypeMaven is a package of the Maven repository protocol
+	PackageTypeMaven
+)
+
+var packageTypeNames = map[PackageType]string{
+	PackageTypeGeneric: "generic",
+	PackageTypeNpm:     "npm",
+	PackageTypeMaven:   "maven",
+}
+
+// Name returns the name of the package type as it is used in URLs
+func (pt PackageType) Name() string {
+	return packageTypeNames[pt]
+}
+
+// PackageTypeFromName returns the package type with the given name
+func PackageTypeFromName(name string) (PackageType, bool) {
+	for pt, n := range packageTypeNames {
+		if n == strings.ToLower(name) {
+			return pt, true
+		}
+	}
+	return 0, false
+}
+
+// ErrPackageNotExist represents a "PackageNotExist" kind of error.
+type ErrPackageNotExist struct {
+	OwnerID int64
+	Type    PackageType
+	Name    string
+}
+
+// IsErrPackageNotExist checks if an error is a ErrPackageNotExist
+func IsErrPackageNotExist(err error) bool {
+	_, ok := err.(ErrPackageNotExist)
+	return ok
+}
+
+func (err ErrPackageNotExist) Error() string {
+	return fmt.Sprintf("package does not exist [owner_id: %d, type: %s, name: %s]", err.OwnerID, err.Type.Name(), err.Name)
+}
+
+// ErrPackageVersionNotExist represents a "PackageVersionNotExist" kind of error.
+type ErrPackageVersionNotExist struct {
+	PackageID int64
+	Version   string
+}
+
+// IsErrPackageVersionNotExist checks if an error is a ErrPackageVersionNotExist
+func IsErrPackageVersionNotExist(err error) bool {
+	_, ok := err.(ErrPackageVersionNotExist)
+	return ok
+}
+
+func (err ErrPackageVersionNotExist) Error() string {
+	return fmt.Sprintf("package version does not exist [package_id: %d, version: %s]", err.PackageID, err.Version)
+}
+
+// ErrPackageFileNotExist represents a "PackageFileNotExist" kind of error.
+type ErrPackageFileNotExist struct {
+	VersionID int64
+	Name      string
+}
+
+// IsErrPackageFileNotExist checks if an error is a ErrPackageFileNotExist
+func IsErrPackageFileNotExist(err error) bool {
+	_, ok := err.(ErrPackageFileNotExist)
+	return ok
+}
+
+func (err ErrPackageFileNotExist) Error() string {
+	return fmt.Sprintf("package file does not exist [version_id: %d, name: %s]", err.VersionID, err.Name)
+}
+
+// ErrPackageFileAlreadyExist represents a "PackageFileAlreadyExist" kind of error.
+type ErrPackageFileAlreadyExist struct {
+	VersionID int64
+	Name      string
+}
+
+// IsErrPackageFileAlreadyExist checks if an error is a ErrPackageFileAlreadyExist
+func IsErrPackageFileAlreadyExist(err error) bool {
+	_, ok := err.(ErrPackageFileAlreadyExist)
+	return ok
+}
+
+func (err ErrPackageFileAlreadyExist) Error() string {
+	return fmt.Sprintf("package file already exists [version_id: %d, name: %s]", err.VersionID, err.Name)
+}
+
+// Package represents a named package of a user or an organization
+type Package struct {
+	ID        int64       `xorm:"pk autoincr"`
+	OwnerID   int64       `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	Owner     *User       `xorm:"-"`
+	Type      PackageType `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	Name      string      `xorm:"NOT NULL"`
+	LowerName string      `xorm:"UNIQUE(s) INDEX NOT NULL"`
+
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
+}
+
+// PackageVersion represents a published version of a package
+type PackageVersion struct {
+	ID            int64  `xorm:"pk autoincr"`
+	PackageID     int64  `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	CreatorID     int64  `xorm:"NOT NULL DEFAULT 0"`
+	Creator       *User  `xorm:"-"`
+	Version       string `xorm:"NOT NULL"`
+	LowerVersion  string `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	MetadataJSON  string `xorm:"metadata_json TEXT"`
+	DownloadCount int64  `xorm:"NOT NULL DEFAULT 0"`
+
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+}
+
+// PackageFile represents a file of a package version
+type PackageFile struct {
+	ID        int64        `xorm:"pk autoincr"`
+	VersionID int64        `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	BlobID    int64        `xorm:"INDEX NOT NULL"`
+	Blob      *PackageBlob `xorm:"-"`
+	Name      string       `xorm:"NOT NULL"`
+	LowerName string       `xorm:"UNIQUE(s) INDEX NOT NULL"`
+
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+}
+
+// PackageBlob represents the content of package files. Blobs are shared by
+// all files with the same content.
+type PackageBlob struct {
+	ID         int64  `xorm:"pk autoincr"`
+	Size       int64  `xorm:"NOT NULL DEFAULT 0"`
+	HashMD5    string `xorm:"hash_md5 char(32) NOT NULL"`
+	HashSHA1   string `xorm:"hash_sha1 char(40) NOT NULL"`
+	HashSHA256 string `xorm:"hash_sha256 char(64) UNIQUE NOT NULL"`
+	HashSHA512 string `xorm:"hash_sha512 char(128) NOT NULL"`
+
+	CreatedUnix timeutil.TimeStamp `xorm:"created"`
+}
+
+// RelativePath returns the path of the blob in the LFS storage
+func (b *PackageBlob) RelativePath() string {
+	return path.Join("packages", b.HashSHA256[0:2], b.HashSHA256[2:4], b.HashSHA256)
+}
+
+// LoadOwner loads the owner of the package
+func (p *Package) LoadOwner() (err error) {
+	if p.Owner == nil {
+		p.Owner, err = GetUserByID(p.OwnerID)
+	}
+	return err
+}
+
+// HTMLURL returns the URL of the package page
+func (p *Package) HTMLURL() string {
+	return p.Owner.HTMLURL() + "/-/packages/" + p.Type.Name() + "/" + p.Name
+}
+
+// LoadCreator loads the user who published the version
+func (pv *PackageVersion) LoadCreator() (err error) {
+	if pv.Creator == nil && pv.CreatorID > 0 {
+		pv.Creator, err = getUserByID(x, pv.CreatorID)
+		if IsErrUserNotExist(err) {
+			pv.Creator, err = NewGhostUser(), nil
+		}
+	}
+	return err
+}
+
+// LoadBlob loads the blob of the file
+func (pf *PackageFile) LoadBlob() (err error) {
+	if pf.Blob == nil {
+		pf.Blob, err = getPackageBlobByID(x, pf.BlobID)
+	}
+	return err
+}
+
+// GetPackageByName returns the package of the owner with the given type and name
+func GetPackageByName(ownerID int64, packageType PackageType, name string) (*Package, error) {
+	p := &Package{OwnerID: ownerID, Type: packageType, LowerName: strings.ToLower(name)}
+	has, err := x.Get(p)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPackageNotExist{OwnerID: ownerID, Type: packageType, Name: name}
+	}
+	return p, nil
+}
+
+// PackageSearchOptions are options for GetPackages
+type PackageSearchOptions struct {
+	ListOptions
+	OwnerID int64
+	Type    PackageType
+	Keyword string
+}
+
+func (opts *PackageSearchOptions) toConds() builder.Cond {
+	cond := builder.NewCond().And(builder.Eq{"owner_id": opts.OwnerID})
+	if opts.Type > 0 {
+		cond = cond.And(builder.Eq{"type": opts.Type})
+	}
+	if opts.Keyword != "" {
+		cond = cond.And(builder.Like{"lower_name", strings.ToLower(opts.Keyword)})
+	}
+	return cond
+}
+
+// GetPackages returns the packages matching the options and their total count
+func GetPackages(opts PackageSearchOptions) ([]*Package, int64, error) {
+	sess := x.Where(opts.toConds())
+	if opts.Page > 0 {
+		sess = opts.setSessionPagination(sess)
+	}
+
+	packages := make([]*Package, 0, opts.PageSize)
+	count, err := sess.Desc("updated_unix").FindAndCount(&packages)
+	return packages, count, err
+}
+
+// GetPackageVersion returns the version of the package
+func GetPackageVersion(packageID int64, version string) (*PackageVersion, error) {
+	pv := &PackageVersion{PackageID: packageID, LowerVersion: strings.ToLower(version)}
+	has, err := x.Get(pv)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPackageVersionNotExist{PackageID: packageID, Version: version}
+	}
+	return pv, nil
+}
+
+// GetPackageVersions returns all versions of the package, newest first
+func GetPackageVersions(packageID int64) ([]*PackageVersion, error) {
+	versions := make([]*PackageVersion, 0, 10)
+	return versions, x.Where("package_id = ?", packageID).Desc("created_unix").Desc("id").Find(&versions)
+}
+
+// GetOrCreatePackageVersion returns the version of the package, both are created
+// if they do not exist yet. The returned bool reports whether the version was created.
+func GetOrCreatePackageVersion(p *Package, pv *PackageVersion) (*PackageVersion, bool, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return nil, false, err
+	}
+
+	p.LowerName = strings.ToLower(p.Name)
+	existingPackage := &Package{OwnerID: p.OwnerID, Type: p.Type, LowerName: p.LowerName}
+	has, err := sess.Get(existingPackage)
+	if err != nil {
+		return nil, false, err
+	}
+	if has {
+		p.ID = existingPackage.ID
+		p.Name = existingPackage.Name
+		p.CreatedUnix = existingPackage.CreatedUnix
+	} else if _, err = sess.Insert(p); err != nil {
+		return nil, false, err
+	}
+
+	existing := &PackageVersion{PackageID: p.ID, LowerVersion: strings.ToLower(pv.Version)}
+	has, err = sess.Get(existing)
+	if err != nil {
+		return nil, false, err
+	} else if has {
+		return existing, false, sess.Commit()
+	}
+
+	pv.PackageID = p.ID
+	pv.LowerVersion = strings.ToLower(pv.Version)
+	if _, err = sess.Insert(pv); err != nil {
+		return nil, false, err
+	}
+	// Bump the package so that recently published packages are listed first.
+	if _, err = sess.ID(p.ID).Cols("updated_unix").Update(&Package{UpdatedUnix: timeutil.TimeStampNow()}); err != nil {
+		return nil, false, err
+	}
+
+	return pv, true, sess.Commit()
+}
+
+// UpdatePackageVersionMetadata updates the metadata of the version
+func UpdatePackageVersionMetadata(pv *PackageVersion) error {
+	_, err := x.ID(pv.ID).Cols("metadata_json").Update(pv)
+	return err
+}
+
+// IncreasePackageVersionDownloadCount increases the download counter of the version
+func IncreasePackageVersionDownloadCount(pv *PackageVersion) error {
+	_, err := x.Incr("download_count").ID(pv.ID).NoAutoTime().Update(new(PackageVersion))
+	return err
+}
+
+// GetPackageFiles returns the files of the version
+func GetPackageFiles(versionID int64) ([]*PackageFile, error) {
+	files := make([]*PackageFile, 0, 5)
+	if err := x.Where("version_id = ?", versionID).Asc("lower_name").Find(&files); err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		if err := f.LoadBlob(); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// GetPackageFile returns the file of the version with the given name
+func GetPackageFile(versionID int64, name string) (*PackageFile, error) {
+	pf := &PackageFile{VersionID: versionID, LowerName: strings.ToLower(name)}
+	has, err := x.Get(pf)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPackageFileNotExist{VersionID: versionID, Name: name}
+	}
+	return pf, pf.LoadBlob()
+}
+
+func getPackageBlobByID(e Engine, id int64) (*PackageBlob, error) {
+	pb := new(PackageBlob)
+	has, err := e.ID(id).Get(pb)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, fmt.Errorf("package blob does not exist [id: %d]", id)
+	}
+	return pb, nil
+}
+
+// AddPackageFile adds a file with the content of the blob to the version. The
+// blob is stored in the database if it is not known yet, save is called to
+// store its content then. The blob is locked until the file references it, so
+// it can't be removed as orphaned meanwhile.
+func AddPackageFile(pv *PackageVersion, name string, blob *PackageBlob, save func() error) (_ *PackageFile, err error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	pf := &PackageFile{VersionID: pv.ID, LowerName: strings.ToLower(name)}
+	has, err := sess.Get(pf)
+	if err != nil {
+		return nil, err
+	} else if has {
+		return nil, ErrPackageFileAlreadyExist{VersionID: pv.ID, Name: name}
+	}
+
+	existingBlob := &PackageBlob{HashSHA256: blob.HashSHA256}
+	has, err = sess.ForUpdate().Get(existingBlob)
+	if err != nil {
+		return nil, err
+	}
+	if has {
+		*blob = *existingBlob
+	} else {
+		if _, err = sess.Insert(blob); err != nil {
+			return nil, err
+		}
+		if err = save(); err != nil {
+			return nil, err
+		}
+		// the content is only kept if the blob is committed
+		defer func() {
+			if err != nil {
+				removeStorageWithNotice(x, storage.LFS, "Delete uncommitted package blob", blob.RelativePath())
+			}
+		}()
+	}
+
+	pf.Name = name
+	pf.BlobID = blob.ID
+	pf.Blob = blob
+	if _, err = sess.Insert(pf); err != nil {
+		return nil, err
+	}
+
+	return pf, sess.Commit()
+}
+
+// DeletePackageFile deletes a file of a version. The blob is removed from the
+// storage if no other file uses it anymore.
+func DeletePackageFile(pf *PackageFile) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err := sess.ID(pf.ID).Delete(new(PackageFile)); err != nil {
+		return err
+	}
+	blobPaths, err := deleteOrphanedPackageBlobs(sess, []int64{pf.BlobID})
+	if err != nil {
+		return err
+	}
+
+	if err := sess.Commit(); err != nil {
+		return err
+	}
+	removePackageBlobs(blobPaths)
+	return nil
+}
+
+// DeletePackageVersion deletes a version with all its files. The package is
+// deleted as well if it has no versions left.
+func DeletePackageVersion(pv *PackageVersion) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	blobPaths, err := deletePackageVersionsByCond(sess, builder.Eq{"id": pv.ID})
+	if err != nil {
+		return err
+	}
+
+	count, err := sess.Count(&PackageVersion{PackageID: pv.PackageID})
+	if err != nil {
+		return err
+	} else if count == 0 {
+		if _, err = sess.ID(pv.PackageID).Delete(new(Package)); err != nil {
+			return err
+		}
+	}
+
+	if err := sess.Commit(); err != nil {
+		return err
+	}
+	removePackageBlobs(blobPaths)
+	return nil
+}
+
+func deletePackageVersionsByCond(e Engine, cond builder.Cond) ([]string, error) {
+	versionIDs := builder.Select("id").From("package_version").Where(cond)
+
+	blobIDs := make([]int64, 0, 10)
+	if err := e.Table("package_file").Distinct("blob_id").In("version_id", versionIDs).Find(&blobIDs); err != nil {
+		return nil, err
+	}
+	if _, err := e.In("version_id", versionIDs).Delete(new(PackageFile)); err != nil {
+		return nil, err
+	}
+	if _, err := e.Where(cond).Delete(new(PackageVersion)); err != nil {
+		return nil, err
+	}
+	return deleteOrphanedPackageBlobs(e, blobIDs)
+}
+
+// deletePackagesByOwnerID deletes all packages of a user or an organization. It returns
+// the storage paths of the orphaned blobs, which are removed once the deletion is committed.
+func deletePackagesByOwnerID(e Engine, ownerID int64) ([]string, error) {
+	packageIDs := builder.Select("id").From("package").Where(builder.Eq{"owner_id": ownerID})
+	blobPaths, err := deletePackageVersionsByCond(e, builder.In("package_id", packageIDs))
+	if err != nil {
+		return nil, err
+	}
+	_, err = e.Delete(&Package{OwnerID: ownerID})
+	return blobPaths, err
+}
+
+// deleteOrphanedPackageBlobs deletes the blobs which are not used by any file and
+// returns their storage paths. The content must only be removed from the storage
+// after the transaction is committed.
+func deleteOrphanedPackageBlobs(e Engine, blobIDs []int64) ([]string, error) {
+	blobPaths := make([]string, 0, len(blobIDs))
+	for _, id := range blobIDs {
+		// the blob is locked first, so no file can be added for it until it is deleted
+		blob := new(PackageBlob)
+		has, err := e.ID(id).ForUpdate().Get(blob)
+		if err != nil {
+			return nil, err
+		} else if !has {
+			continue
+		}
+
+		count, err := e.Count(&PackageFile{BlobID: id})
+		if err != nil {
+			return nil, err
+		} else if count > 0 {
+			continue
+		}
+
+		if _, err = e.ID(id).Delete(new(PackageBlob)); err != nil {
+			return nil, err
+		}
+		blobPaths = append(blobPaths, blob.RelativePath())
+	}
+	return blobPaths, nil
+}
+
+// removePackageBlobs removes the content of deleted blobs from the storage
+func removePackageBlobs(blobPaths []string) {
+	for _, p := range blobPaths {
+		removeStorageWithNotice(x, storage.LFS, "Delete orphaned package blob", p)
+	}
+}
+
+// IteratePackageBlobs iterates over all package blobs
+func IteratePackageBlobs(f func(blob *PackageBlob) error) error {
+	var start int
+	const batchSize = 100
+	for {
+		var blobs = make([]*PackageBlob, 0, batchSize)
+		if err := x.Limit(batchSize, start).Asc("id").Find(&blobs); err != nil {
+			return err
+		}
+		if len(blobs) == 0 {
+			return nil
+		}
+		start += len(blobs)
+
+		for _, blob := range blobs {
+			if err := f(blob); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageTypeFromName(t *testing.T) {
+	pt, ok := PackageTypeFromName("NPM")
+	assert.True(t, ok)
+	assert.Equal(t, PackageTypeNpm, pt)
+	assert.Equal(t, "maven", PackageTypeMaven.Name())
+
+	_, ok = PackageTypeFromName("rubygems")
+	assert.False(t, ok)
+}
+
+func TestGetPackages(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	packages, count, err := GetPackages(PackageSearchOptions{OwnerID: 2})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+	if assert.Len(t, packages, 1) {
+		assert.Equal(t, "test-package", packages[0].Name)
+	}
+
+	_, count, err = GetPackages(PackageSearchOptions{OwnerID: 2, Type: PackageTypeNpm})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, count)
+
+	_, count, err = GetPackages(PackageSearchOptions{OwnerID: 3, Keyword: "LIB"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+
+	p, err := GetPackageByName(3, PackageTypeNpm, "@Scope/Lib")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, p.ID)
+
+	_, err = GetPackageByName(3, PackageTypeGeneric, "@scope/lib")
+	assert.True(t, IsErrPackageNotExist(err))
+}
+
+func TestGetOrCreatePackageVersion(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	pv, created, err := GetOrCreatePackageVersion(&Package{OwnerID: 2, Type: PackageTypeGeneric, Name: "Test-Package"}, &PackageVersion{CreatorID: 2, Version: "1.0.0"})
+	assert.NoError(t, err)
+	assert.False(t, created)
+	assert.EqualValues(t, 1, pv.ID)
+
+	p := &Package{OwnerID: 2, Type: PackageTypeMaven, Name: "org.example:lib"}
+	pv, created, err = GetOrCreatePackageVersion(p, &PackageVersion{CreatorID: 2, Version: "2.0"})
+	assert.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, p.ID, pv.PackageID)
+	AssertExistsAndLoadBean(t, &Package{ID: p.ID, LowerName: "org.example:lib"})
+	AssertExistsAndLoadBean(t, &PackageVersion{ID: pv.ID, LowerVersion: "2.0"})
+}
+
+func TestAddPackageFile(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	pv := AssertExistsAndLoadBean(t, &PackageVersion{ID: 1}).(*PackageVersion)
+
+	saved := 0
+	save := func() error {
+		saved++
+		return nil
+	}
+
+	_, err := AddPackageFile(pv, "FILE.bin", &PackageBlob{HashSHA256: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"}, save)
+	assert.True(t, IsErrPackageFileAlreadyExist(err))
+
+	// known blobs are shared
+	pf, err := AddPackageFile(pv, "copy.bin", &PackageBlob{HashSHA256: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"}, save)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, pf.BlobID)
+	assert.EqualValues(t, 4, pf.Blob.Size)
+	assert.Equal(t, 0, saved)
+
+	pf, err = AddPackageFile(pv, "new.bin", &PackageBlob{Size: 1, HashSHA256: "ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb"}, save)
+	assert.NoError(t, err)
+	assert.NotEqual(t, int64(1), pf.BlobID)
+	assert.Equal(t, 1, saved)
+
+	// the blob is not kept when its content could not be stored
+	_, err = AddPackageFile(pv, "failed.bin", &PackageBlob{Size: 1, HashSHA256: "3e23e8160039594a33894f6564e1b1348bbd7a0088d42c4acb73eeaed59c009d"}, func() error {
+		return fmt.Errorf("storage failure")
+	})
+	assert.Error(t, err)
+	AssertNotExistsBean(t, &PackageBlob{HashSHA256: "3e23e8160039594a33894f6564e1b1348bbd7a0088d42c4acb73eeaed59c009d"})
+
+	files, err := GetPackageFiles(pv.ID)
+	assert.NoError(t, err)
+	assert.Len(t, files, 3)
+}
+
+func TestDeletePackageVersion(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	pv := AssertExistsAndLoadBean(t, &PackageVersion{ID: 1}).(*PackageVersion)
+	assert.NoError(t, DeletePackageVersion(pv))
+
+	AssertNotExistsBean(t, &PackageVersion{ID: 1})
+	AssertNotExistsBean(t, &PackageFile{VersionID: 1})
+	// the package has no versions left
+	AssertNotExistsBean(t, &Package{ID: 1})
+	// the blob is still used by the npm package
+	AssertExistsAndLoadBean(t, &PackageBlob{ID: 1})
+
+	pv = AssertExistsAndLoadBean(t, &PackageVersion{ID: 2}).(*PackageVersion)
+	assert.NoError(t, DeletePackageVersion(pv))
+	AssertNotExistsBean(t, &PackageBlob{ID: 1})
+}
@@ -0,0 +1,423 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	"xorm.io/builder"
+)
+
+// ProjectType is used to identify the owner of a project
+type ProjectType uint8
+
+const (
+	// ProjectTypeRepository is a project that is tied to a repository
+	ProjectTypeRepository ProjectType = iota + 1
+	// ProjectTypeOrganization is a project that is tied to an organization
+	// and can hold the issues of all its repositories
+	ProjectTypeOrganization
+)
+
+// ProjectBoardType is used to represent the columns a project is created with
+type ProjectBoardType uint8
+
+const (
+	// ProjectBoardTypeNone is a project without any predefined column
+	ProjectBoardTypeNone ProjectBoardType = iota
+	// ProjectBoardTypeBasicKanban is a project with "To Do", "In Progress" and "Done" columns
+	ProjectBoardTypeBasicKanban
+)
+
+// ProjectBoardConfig is used to identify the type of board that is being created
+type ProjectBoardConfig struct {
+	BoardType   ProjectBoardType
+	Translation string
+}
+
+// GetProjectBoardConfigs retrieves the types of board a project can be created with
+func GetProjectBoardConfigs() []ProjectBoardConfig {
+	return []ProjectBoardConfig{
+		{ProjectBoardTypeNone, "repo.projects.type.none"},
+		{ProjectBoardTypeBasicKanban, "repo.projects.type.basic_kanban"},
+	}
+}
+
+// ProjectBoardTypeFromName returns the board type of the name used by the API
+func ProjectBoardTypeFromName(name string) (ProjectBoardType, bool) {
+	switch name {
+	case "", "none":
+		return ProjectBoardTypeNone, true
+	case "basic_kanban":
+		return ProjectBoardTypeBasicKanban, true
+	}
+	return ProjectBoardTypeNone, false
+}
+
+// IsValid checks if the board type is known
+func (t ProjectBoardType) IsValid() bool {
+	switch t {
+	case ProjectBoardTypeNone, ProjectBoardTypeBasicKanban:
+		return true
+	}
+	return false
+}
+
+// ErrProjectNotExist represents a "ProjectNotExist" kind of error.
+type ErrProjectNotExist struct {
+	ID     int64
+	RepoID int64
+}
+
+// IsErrProjectNotExist checks if an error is a ErrProjectNotExist
+func IsErrProjectNotExist(err error) bool {
+	_, ok := err.(ErrProjectNotExist)
+	return ok
+}
+
+func (err ErrProjectNotExist) Error() string {
+	return fmt.Sprintf("project does not exist [id: %d, repo_id: %d]", err.ID, err.RepoID)
+}
+
+// Project represents a project board of a repository or an organization
+type Project struct {
+	ID              int64       `xorm:"pk autoincr"`
+	Title           string      `xorm:"INDEX NOT NULL"`
+	Description     string      `xorm:"TEXT"`
+	RenderedContent string      `xorm:"-"`
+	RepoID          int64       `xorm:"INDEX"`
+	Repo            *Repository `xorm:"-"`
+	OwnerID         int64       `xorm:"INDEX"` // set for organization projects only
+	Owner           *User       `xorm:"-"`
+	CreatorID       int64       `xorm:"NOT NULL"`
+	Creator         *User       `xorm:"-"`
+	IsClosed        bool        `xorm:"INDEX"`
+	BoardType       ProjectBoardType
+	Type            ProjectType
+
+	NumOpenIssues   int `xorm:"-"`
+	NumClosedIssues int `xorm:"-"`
+
+	CreatedUnix    timeutil.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix    timeutil.TimeStamp `xorm:"INDEX updated"`
+	ClosedDateUnix timeutil.TimeStamp
+}
+
+// LoadAttributes loads the repository or the organization and the creator of the project
+func (p *Project) LoadAttributes() error {
+	return p.loadAttributes(x)
+}
+
+func (p *Project) loadAttributes(e Engine) (err error) {
+	if p.Type == ProjectTypeRepository && p.Repo == nil {
+		if p.Repo, err = getRepositoryByID(e, p.RepoID); err != nil {
+			return fmt.Errorf("getRepositoryByID [%d]: %v", p.RepoID, err)
+		}
+		if err = p.Repo.getOwner(e); err != nil {
+			return err
+		}
+	}
+	if p.Type == ProjectTypeOrganization && p.Owner == nil {
+		if p.Owner, err = getUserByID(e, p.OwnerID); err != nil {
+			return fmt.Errorf("getUserByID [%d]: %v", p.OwnerID, err)
+		}
+	}
+	if p.Creator == nil {
+		p.Creator, err = getUserByID(e, p.CreatorID)
+		if IsErrUserNotExist(err) {
+			p.Creator = NewGhostUser()
+		} else if err != nil {
+			return fmt.Errorf("getUserByID [%d]: %v", p.CreatorID, err)
+		}
+	}
+	return nil
+}
+
+// Link returns the link to the project, the attributes must be loaded
+func (p *Project) Link() string {
+	if p.Type == ProjectTypeOrganization {
+		return fmt.Sprintf("%s/org/%s/projects/%d", setting.AppSubURL, p.Owner.Name, p.ID)
+	}
+	return fmt.Sprintf("%s/projects/%d", p.Repo.Link(), p.ID)
+}
+
+// CanContainIssue returns true if the issue may be added to the project,
+// organization projects hold the issues of all the organization repositories
+func (p *Project) CanContainIssue(issue *Issue) bool {
+	return p.canContainIssue(x, issue)
+}
+
+func (p *Project) canContainIssue(e Engine, issue *Issue) bool {
+	if p.Type == ProjectTypeOrganization {
+		if err := issue.loadRepo(e); err != nil {
+			return false
+		}
+		return issue.Repo.OwnerID == p.OwnerID
+	}
+	return issue.RepoID == p.RepoID
+}
+
+// ProjectSearchOptions are options for GetProjects
+type ProjectSearchOptions struct {
+	RepoID   int64
+	OwnerID  int64
+	Page     int
+	PageSize int // defaults to the issue paging number
+	IsClosed util.OptionalBool
+	SortType string
+	Type     ProjectType
+}
+
+func (opts ProjectSearchOptions) toConds() builder.Cond {
+	cond := builder.NewCond()
+	if opts.RepoID > 0 {
+		cond = cond.And(builder.Eq{"repo_id": opts.RepoID})
+	}
+	if opts.OwnerID > 0 {
+		cond = cond.And(builder.Eq{"owner_id": opts.OwnerID})
+	}
+	switch opts.IsClosed {
+	case util.OptionalBoolTrue:
+		cond = cond.And(builder.Eq{"is_closed": true})
+	case util.OptionalBoolFalse:
+		cond = cond.And(builder.Eq{"is_closed": false})
+	}
+	if opts.Type > 0 {
+		cond = cond.And(builder.Eq{"type": opts.Type})
+	}
+	return cond
+}
+
+// GetProjects returns a list of all projects that have been created in the repository or organization
+func GetProjects(opts ProjectSearchOptions) (ProjectList, int64, error) {
+	cond := opts.toConds()
+	count, err := x.Where(cond).Count(new(Project))
+	if err != nil {
+		return nil, 0, fmt.Errorf("Count: %v", err)
+	}
+
+	sess := x.Where(cond)
+	if opts.Page > 0 {
+		pageSize := opts.PageSize
+		if pageSize <= 0 {
+			pageSize = setting.UI.IssuePagingNum
+		}
+		sess = sess.Limit(pageSize, (opts.Page-1)*pageSize)
+	}
+
+	switch opts.SortType {
+	case "oldest":
+		sess.Asc("created_unix")
+	case "recentupdate":
+		sess.Desc("updated_unix")
+	case "leastupdate":
+		sess.Asc("updated_unix")
+	default:
+		sess.Desc("created_unix")
+	}
+
+	projects := make([]*Project, 0, setting.UI.IssuePagingNum)
+	if err := sess.Find(&projects); err != nil {
+		return nil, 0, err
+	}
+	return projects, count, nil
+}
+
+// CountProjects returns the number of open and closed projects matching the options
+func CountProjects(opts ProjectSearchOptions) (open, closed int64, err error) {
+	opts.IsClosed = util.OptionalBoolFalse
+	if open, err = x.Where(opts.toConds()).Count(new(Project)); err != nil {
+		return
+	}
+	opts.IsClosed = util.OptionalBoolTrue
+	closed, err = x.Where(opts.toConds()).Count(new(Project))
+	return
+}
+
+// ProjectList is a list of projects offering additional functionality
+type ProjectList []*Project
+
+// LoadAttributes loads the attributes of all the projects
+func (projects ProjectList) LoadAttributes() error {
+	for _, p := range projects {
+		if err := p.loadAttributes(x); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadIssueCounts loads the number of open and closed issues of each project
+func (projects ProjectList) LoadIssueCounts() error {
+	if len(projects) == 0 {
+		return nil
+	}
+	ids := make([]int64, 0, len(projects))
+	for _, p := range projects {
+		ids = append(ids, p.ID)
+	}
+
+	counts := make([]*struct {
+		ProjectID int64
+		IsClosed  bool
+		Count     int
+	}, 0, len(projects)*2)
+	if err := x.Table("project_issue").
+		Join("INNER", "issue", "issue.id = project_issue.issue_id").
+		In("project_issue.project_id", ids).
+		GroupBy("project_issue.project_id, issue.is_closed").
+		Select("project_issue.project_id AS project_id, issue.is_closed AS is_closed, COUNT(*) AS count").
+		Find(&counts); err != nil {
+		return err
+	}
+
+	for _, p := range projects {
+		p.NumOpenIssues, p.NumClosedIssues = 0, 0
+		for _, c := range counts {
+			if c.ProjectID != p.ID {
+				continue
+			}
+			if c.IsClosed {
+				p.NumClosedIssues = c.Count
+			} else {
+				p.NumOpenIssues = c.Count
+			}
+		}
+	}
+	return nil
+}
+
+// NewProject creates a new project and the columns of its board type
+func NewProject(p *Project) error {
+	if !p.BoardType.IsValid() {
+		p.BoardType = ProjectBoardTypeNone
+	}
+	switch p.Type {
+	case ProjectTypeRepository:
+		p.OwnerID = 0
+	case ProjectTypeOrganization:
+		p.RepoID = 0
+	default:
+		return fmt.Errorf("unknown project type: %d", p.Type)
+	}
+	p.Title = strings.TrimSpace(p.Title)
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err := sess.Insert(p); err != nil {
+		return err
+	}
+
+	if err := createBoardsForProjectsType(sess, p); err != nil {
+		return err
+	}
+
+	return sess.Commit()
+}
+
+func getProjectByID(e Engine, id int64) (*Project, error) {
+	p := new(Project)
+	has, err := e.ID(id).Get(p)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrProjectNotExist{ID: id}
+	}
+	return p, nil
+}
+
+// GetProjectByID returns the project by its ID
+func GetProjectByID(id int64) (*Project, error) {
+	return getProjectByID(x, id)
+}
+
+// GetProjectByRepoID returns the project of the repository by its ID
+func GetProjectByRepoID(repoID, id int64) (*Project, error) {
+	p, err := getProjectByID(x, id)
+	if err != nil {
+		return nil, err
+	}
+	if p.Type != ProjectTypeRepository || p.RepoID != repoID {
+		return nil, ErrProjectNotExist{ID: id, RepoID: repoID}
+	}
+	return p, nil
+}
+
+// GetProjectByOwnerID returns the project of the organization by its ID
+func GetProjectByOwnerID(ownerID, id int64) (*Project, error) {
+	p, err := getProjectByID(x, id)
+	if err != nil {
+		return nil, err
+	}
+	if p.Type != ProjectTypeOrganization || p.OwnerID != ownerID {
+		return nil, ErrProjectNotExist{ID: id}
+	}
+	return p, nil
+}
+
+// UpdateProject updates the title and the description of the project
+func UpdateProject(p *Project) error {
+	p.Title = strings.TrimSpace(p.Title)
+	_, err := x.ID(p.ID).Cols("title", "description").Update(p)
+	return err
+}
+
+// ChangeProjectStatus opens or closes the project
+func ChangeProjectStatus(p *Project, isClosed bool) error {
+	p.IsClosed = isClosed
+	if isClosed {
+		p.ClosedDateUnix = timeutil.TimeStampNow()
+	}
+	_, err := x.ID(p.ID).Cols("is_closed", "closed_date_unix").Update(p)
+	return err
+}
+
+// DeleteProjectByID deletes the project with its boards and cards
+func DeleteProjectByID(id int64) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if err := deleteProjectByID(sess, id); err != nil {
+		return err
+	}
+
+	return sess.Commit()
+}
+
+func deleteProjectByID(e Engine, id int64) error {
+	if _, err := e.Where("project_id = ?", id).Delete(new(ProjectIssue)); err != nil {
+		return err
+	}
+	if _, err := e.Where("project_id = ?", id).Delete(new(ProjectBoard)); err != nil {
+		return err
+	}
+	_, err := e.ID(id).Delete(new(Project))
+	return err
+}
+
+// deleteProjectsByCond deletes the projects matching the condition with their boards and cards
+func deleteProjectsByCond(e Engine, cond builder.Cond) error {
+	projectIDs := builder.Select("id").From("project").Where(cond)
+	if _, err := e.In("project_id", projectIDs).Delete(new(ProjectIssue)); err != nil {
+		return err
+	}
+	if _, err := e.In("project_id", projectIDs).Delete(new(ProjectBoard)); err != nil {
+		return err
+	}
+	_, err := e.Where(cond).Delete(new(Project))
+	return err
+}
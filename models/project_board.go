@@ -0,0 +1,211 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+// ErrProjectBoardNotExist represents a "ProjectBoardNotExist" kind of error.
+type ErrProjectBoardNotExist struct {
+	BoardID int64
+}
+
+// IsErrProjectBoardNotExist checks if an error is a ErrProjectBoardNotExist
+func IsErrProjectBoardNotExist(err error) bool {
+	_, ok := err.(ErrProjectBoardNotExist)
+	return ok
+}
+
+func (err ErrProjectBoardNotExist) Error() string {
+	return fmt.Sprintf("project board does not exist [id: %d]", err.BoardID)
+}
+
+// ProjectBoard is a column of a project, holding the cards of issues and pull requests
+type ProjectBoard struct {
+	ID        int64  `xorm:"pk autoincr"`
+	Title     string `xorm:"NOT NULL"`
+	IsDefault bool   `xorm:"NOT NULL DEFAULT false"` // new and reopened issues are put on the default board
+	IsDone    bool   `xorm:"NOT NULL DEFAULT false"` // closed issues are moved to the done board
+	Sorting   int8   `xorm:"NOT NULL DEFAULT 0"`
+	ProjectID int64  `xorm:"INDEX NOT NULL"`
+	CreatorID int64  `xorm:"NOT NULL"`
+
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
+
+	Issues []*Issue `xorm:"-"`
+}
+
+// IsUncategorized returns true if the board is the implicit board of the
+// cards which have not been put on any board
+func (b *ProjectBoard) IsUncategorized() bool {
+	return b.ID == 0
+}
+
+func createBoardsForProjectsType(e Engine, project *Project) error {
+	var titles []string
+	switch project.BoardType {
+	case ProjectBoardTypeBasicKanban:
+		titles = []string{"To Do", "In Progress", "Done"}
+	default:
+		return nil
+	}
+
+	boards := make([]ProjectBoard, 0, len(titles))
+	for i, title := range titles {
+		boards = append(boards, ProjectBoard{
+			Title:     title,
+			IsDefault: i == 0,
+			IsDone:    i == len(titles)-1,
+			Sorting:   int8(i),
+			ProjectID: project.ID,
+			CreatorID: project.CreatorID,
+		})
+	}
+	_, err := e.Insert(boards)
+	return err
+}
+
+// NewProjectBoard adds a new board to a project
+func NewProjectBoard(board *ProjectBoard) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	board.Title = strings.TrimSpace(board.Title)
+	if _, err := sess.Insert(board); err != nil {
+		return err
+	}
+	if err := resetUniqueBoardFlags(sess, board); err != nil {
+		return err
+	}
+
+	return sess.Commit()
+}
+
+func getProjectBoard(e Engine, boardID int64) (*ProjectBoard, error) {
+	board := new(ProjectBoard)
+	has, err := e.ID(boardID).Get(board)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrProjectBoardNotExist{BoardID: boardID}
+	}
+	return board, nil
+}
+
+// GetProjectBoard fetches the board of a project
+func GetProjectBoard(projectID, boardID int64) (*ProjectBoard, error) {
+	board, err := getProjectBoard(x, boardID)
+	if err != nil {
+		return nil, err
+	}
+	if board.ProjectID != projectID {
+		return nil, ErrProjectBoardNotExist{BoardID: boardID}
+	}
+	return board, nil
+}
+
+// UpdateProjectBoard updates the title, the position and the flags of a board
+func UpdateProjectBoard(board *ProjectBoard) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	board.Title = strings.TrimSpace(board.Title)
+	if _, err := sess.ID(board.ID).Cols("title", "sorting", "is_default", "is_done").Update(board); err != nil {
+		return err
+	}
+	if err := resetUniqueBoardFlags(sess, board); err != nil {
+		return err
+	}
+
+	return sess.Commit()
+}
+
+// resetUniqueBoardFlags makes sure only one board of the project is the default or the done board
+func resetUniqueBoardFlags(e *xorm.Session, board *ProjectBoard) error {
+	if board.IsDefault {
+		if _, err := e.Exec("UPDATE `project_board` SET is_default = ? WHERE project_id = ? AND id != ?", false, board.ProjectID, board.ID); err != nil {
+			return err
+		}
+	}
+	if board.IsDone {
+		if _, err := e.Exec("UPDATE `project_board` SET is_done = ? WHERE project_id = ? AND id != ?", false, board.ProjectID, board.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteProjectBoardByID removes a board from its project, its cards become uncategorized
+func DeleteProjectBoardByID(boardID int64) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	board, err := getProjectBoard(sess, boardID)
+	if err != nil {
+		if IsErrProjectBoardNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if _, err = sess.Exec("UPDATE `project_issue` SET project_board_id = 0 WHERE project_board_id = ?", board.ID); err != nil {
+		return err
+	}
+	if _, err = sess.ID(board.ID).Delete(board); err != nil {
+		return err
+	}
+
+	return sess.Commit()
+}
+
+// ProjectBoardList is a list of boards offering additional functionality
+type ProjectBoardList []*ProjectBoard
+
+func getProjectBoards(e Engine, projectID int64) (ProjectBoardList, error) {
+	boards := make([]*ProjectBoard, 0, 5)
+	return boards, e.Where("project_id = ?", projectID).Asc("sorting").Asc("id").Find(&boards)
+}
+
+// GetProjectBoards returns the boards of a project ordered by their position
+func GetProjectBoards(projectID int64) (ProjectBoardList, error) {
+	return getProjectBoards(x, projectID)
+}
+
+// GetUncategorizedBoard returns the implicit board holding the cards of the project which
+// have not been put on any board
+func GetUncategorizedBoard(projectID int64) *ProjectBoard {
+	return &ProjectBoard{
+		ProjectID: projectID,
+		Title:     "Uncategorized",
+	}
+}
+
+// LoadIssues loads the issues visible to the doer of every board ordered by the position of their card
+func (bs ProjectBoardList) LoadIssues(doer *User) error {
+	for _, b := range bs {
+		issues, err := getProjectBoardIssues(x, b.ProjectID, b.ID, doer)
+		if err != nil {
+			return err
+		}
+		b.Issues = issues
+	}
+	return nil
+}
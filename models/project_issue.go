@@ -0,0 +1,361 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// ErrProjectIssueNotExist represents a "ProjectIssueNotExist" kind of error.
+type ErrProjectIssueNotExist struct {
+	ProjectID int64
+}
+
+// IsErrProjectIssueNotExist checks if an error is a ErrProjectIssueNotExist
+func IsErrProjectIssueNotExist(err error) bool {
+	_, ok := err.(ErrProjectIssueNotExist)
+	return ok
+}
+
+func (err ErrProjectIssueNotExist) Error() string {
+	return fmt.Sprintf("all issues have to be added to the project first [project_id: %d]", err.ProjectID)
+}
+
+// ProjectIssue is the card of an issue or a pull request on a project board
+type ProjectIssue struct {
+	ID             int64 `xorm:"pk autoincr"`
+	IssueID        int64 `xorm:"INDEX"`
+	ProjectID      int64 `xorm:"INDEX"`
+	ProjectBoardID int64 `xorm:"INDEX"` // 0 if the card is uncategorized
+	Sorting        int64 `xorm:"NOT NULL DEFAULT 0"`
+}
+
+func getProjectIssueByIssueID(e Engine, issueID int64) (*ProjectIssue, error) {
+	pi := new(ProjectIssue)
+	has, err := e.Where("issue_id = ?", issueID).Get(pi)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, nil
+	}
+	return pi, nil
+}
+
+// LoadProject loads the project the issue has been added to
+func (issue *Issue) LoadProject() error {
+	return issue.loadProject(x)
+}
+
+func (issue *Issue) loadProject(e Engine) error {
+	if issue.Project != nil {
+		return nil
+	}
+	pi, err := getProjectIssueByIssueID(e, issue.ID)
+	if err != nil || pi == nil {
+		return err
+	}
+	issue.Project, err = getProjectByID(e, pi.ProjectID)
+	if err != nil {
+		if IsErrProjectNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return issue.Project.loadAttributes(e)
+}
+
+// ProjectID returns the ID of the project the issue has been added to, 0 if none
+func (issue *Issue) ProjectID() int64 {
+	if err := issue.loadProject(x); err != nil || issue.Project == nil {
+		return 0
+	}
+	return issue.Project.ID
+}
+
+func getProjectBoardIssues(e Engine, projectID, boardID int64, doer *User) ([]*Issue, error) {
+	cond := builder.NewCond().And(
+		builder.Eq{"project_issue.project_id": projectID},
+		builder.Eq{"project_issue.project_board_id": boardID},
+	)
+	// organization projects hold issues of several repositories, only show the accessible ones
+	if doer == nil || !doer.IsAdmin {
+		cond = cond.And(builder.In("issue.repo_id",
+			builder.Select("`repository`.id").From("repository").Where(accessibleRepositoryCondition(doer))))
+	}
+
+	issues := make([]*Issue, 0, 10)
+	if err := e.Table("issue").
+		Join("INNER", "project_issue", "project_issue.issue_id = issue.id").
+		Where(cond).
+		Asc("project_issue.sorting").
+		Asc("project_issue.id").
+		Select("issue.*").
+		Find(&issues); err != nil {
+		return nil, err
+	}
+	return issues, IssueList(issues).loadAttributes(e)
+}
+
+// GetProjectBoardIssues returns the issues on a board of a project which are visible to the doer,
+// ordered by the position of their card
+func GetProjectBoardIssues(projectID, boardID int64, doer *User) ([]*Issue, error) {
+	return getProjectBoardIssues(x, projectID, boardID, doer)
+}
+
+// ChangeProjectAssign adds the issue to a project, removing it from its previous one.
+// A project ID of 0 removes the issue from its project.
+func ChangeProjectAssign(issue *Issue, doer *User, newProjectID int64) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if err := changeProjectAssign(sess, doer, issue, newProjectID); err != nil {
+		return err
+	}
+
+	return sess.Commit()
+}
+
+func changeProjectAssign(e *xorm.Session, doer *User, issue *Issue, newProjectID int64) error {
+	oldProject, err := getProjectIssueByIssueID(e, issue.ID)
+	if err != nil {
+		return err
+	}
+	var oldProjectID int64
+	if oldProject != nil {
+		oldProjectID = oldProject.ProjectID
+	}
+	if oldProjectID == newProjectID {
+		return nil
+	}
+
+	var board *ProjectBoard
+	if newProjectID > 0 {
+		project, err := getProjectByID(e, newProjectID)
+		if err != nil {
+			return err
+		}
+		if !project.canContainIssue(e, issue) {
+			return ErrProjectNotExist{ID: newProjectID, RepoID: issue.RepoID}
+		}
+		if board, err = getDefaultProjectBoard(e, newProjectID); err != nil {
+			return err
+		}
+	}
+
+	if _, err := e.Where("issue_id = ?", issue.ID).Delete(new(ProjectIssue)); err != nil {
+		return err
+	}
+
+	if newProjectID > 0 {
+		sorting, err := nextProjectBoardSorting(e, newProjectID, board.ID)
+		if err != nil {
+			return err
+		}
+		if _, err := e.Insert(&ProjectIssue{
+			IssueID:        issue.ID,
+			ProjectID:      newProjectID,
+			ProjectBoardID: board.ID,
+			Sorting:        sorting,
+		}); err != nil {
+			return err
+		}
+	}
+	issue.Project = nil
+
+	if err := issue.loadRepo(e); err != nil {
+		return err
+	}
+	_, err = createComment(e, &CreateCommentOptions{
+		Type:         CommentTypeProject,
+		Doer:         doer,
+		Repo:         issue.Repo,
+		Issue:        issue,
+		OldProjectID: oldProjectID,
+		ProjectID:    newProjectID,
+	})
+	return err
+}
+
+// getDefaultProjectBoard returns the default board of the project, the uncategorized board if there is none
+func getDefaultProjectBoard(e Engine, projectID int64) (*ProjectBoard, error) {
+	board := new(ProjectBoard)
+	has, err := e.Where("project_id = ? AND is_default = ?", projectID, true).Get(board)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return GetUncategorizedBoard(projectID), nil
+	}
+	return board, nil
+}
+
+func nextProjectBoardSorting(e Engine, projectID, boardID int64) (int64, error) {
+	var max int64
+	if _, err := e.Table("project_issue").
+		Where("project_id = ? AND project_board_id = ?", projectID, boardID).
+		Select("COALESCE(MAX(sorting), -1)").
+		Get(&max); err != nil {
+		return 0, err
+	}
+	return max + 1, nil
+}
+
+// MoveIssuesOnProjectBoard puts the cards of the given issues on the board,
+// the map holds the position of the card of each issue
+func MoveIssuesOnProjectBoard(board *ProjectBoard, issueSortings map[int64]int64) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	issueIDs := make([]int64, 0, len(issueSortings))
+	for issueID := range issueSortings {
+		issueIDs = append(issueIDs, issueID)
+	}
+	count, err := sess.Table("project_issue").
+		Where("project_id = ?", board.ProjectID).
+		In("issue_id", issueIDs).
+		Count()
+	if err != nil {
+		return err
+	}
+	if int(count) != len(issueSortings) {
+		return ErrProjectIssueNotExist{ProjectID: board.ProjectID}
+	}
+
+	for issueID, sorting := range issueSortings {
+		if _, err := sess.Exec("UPDATE `project_issue` SET project_board_id = ?, sorting = ? WHERE project_id = ? AND issue_id = ?",
+			board.ID, sorting, board.ProjectID, issueID); err != nil {
+			return err
+		}
+	}
+
+	return sess.Commit()
+}
+
+// MoveIssueOnProjectBoard puts the card of the issue at the position on the board,
+// the cards at or after this position are moved down
+func MoveIssueOnProjectBoard(board *ProjectBoard, issueID, sorting int64) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	pi, err := getProjectIssueByIssueID(sess, issueID)
+	if err != nil {
+		return err
+	} else if pi == nil || pi.ProjectID != board.ProjectID {
+		return ErrProjectIssueNotExist{ProjectID: board.ProjectID}
+	}
+
+	if _, err := sess.Exec("UPDATE `project_issue` SET sorting = sorting + 1 WHERE project_id = ? AND project_board_id = ? AND sorting >= ? AND id != ?",
+		board.ProjectID, board.ID, sorting, pi.ID); err != nil {
+		return err
+	}
+	if _, err := sess.Exec("UPDATE `project_issue` SET project_board_id = ?, sorting = ? WHERE id = ?", board.ID, sorting, pi.ID); err != nil {
+		return err
+	}
+
+	return sess.Commit()
+}
+
+// ProjectCard is the card of an issue on a board
+type ProjectCard struct {
+	BoardID int64
+	Sorting int64
+	Issue   *Issue
+}
+
+// GetProjectBoardCards returns the cards of the issues on a board of a project which are
+// visible to the doer, ordered by their position
+func GetProjectBoardCards(projectID, boardID int64, doer *User) ([]*ProjectCard, error) {
+	issues, err := getProjectBoardIssues(x, projectID, boardID, doer)
+	if err != nil {
+		return nil, err
+	}
+	if len(issues) == 0 {
+		return []*ProjectCard{}, nil
+	}
+
+	ids := make([]int64, 0, len(issues))
+	for _, issue := range issues {
+		ids = append(ids, issue.ID)
+	}
+	pis := make([]*ProjectIssue, 0, len(issues))
+	if err := x.Where("project_id = ?", projectID).In("issue_id", ids).Find(&pis); err != nil {
+		return nil, err
+	}
+	sortings := make(map[int64]int64, len(pis))
+	for _, pi := range pis {
+		sortings[pi.IssueID] = pi.Sorting
+	}
+
+	cards := make([]*ProjectCard, 0, len(issues))
+	for _, issue := range issues {
+		cards = append(cards, &ProjectCard{
+			BoardID: boardID,
+			Sorting: sortings[issue.ID],
+			Issue:   issue,
+		})
+	}
+	return cards, nil
+}
+
+// MoveIssueOnStatusChange moves the card of a closed issue to the done board of
+// its project and the card of a reopened issue back to the default board.
+func MoveIssueOnStatusChange(issue *Issue, isClosed bool) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	pi, err := getProjectIssueByIssueID(sess, issue.ID)
+	if err != nil || pi == nil {
+		return err
+	}
+
+	var target *ProjectBoard
+	if isClosed {
+		target = new(ProjectBoard)
+		has, err := sess.Where("project_id = ? AND is_done = ?", pi.ProjectID, true).Get(target)
+		if err != nil || !has {
+			return err
+		}
+	} else {
+		current := new(ProjectBoard)
+		has, err := sess.ID(pi.ProjectBoardID).Get(current)
+		if err != nil {
+			return err
+		}
+		// only move cards which have been moved automatically to the done board
+		if !has || !current.IsDone {
+			return nil
+		}
+		if target, err = getDefaultProjectBoard(sess, pi.ProjectID); err != nil {
+			return err
+		}
+	}
+	if target.ID == pi.ProjectBoardID {
+		return nil
+	}
+
+	sorting, err := nextProjectBoardSorting(sess, pi.ProjectID, target.ID)
+	if err != nil {
+		return err
+	}
+	if _, err := sess.Exec("UPDATE `project_issue` SET project_board_id = ?, sorting = ? WHERE id = ?", target.ID, sorting, pi.ID); err != nil {
+		return err
+	}
+
+	return sess.Commit()
+}
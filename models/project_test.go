@@ -0,0 +1,167 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewProject(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	project := &Project{
+		Title:     "New project",
+		RepoID:    1,
+		CreatorID: 2,
+		BoardType: ProjectBoardTypeBasicKanban,
+		Type:      ProjectTypeRepository,
+	}
+	assert.NoError(t, NewProject(project))
+	AssertExistsAndLoadBean(t, &Project{ID: project.ID, RepoID: 1})
+
+	boards, err := GetProjectBoards(project.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, boards, 3) {
+		assert.True(t, boards[0].IsDefault)
+		assert.True(t, boards[2].IsDone)
+	}
+
+	assert.Error(t, NewProject(&Project{Title: "Untyped project", RepoID: 1, CreatorID: 2}))
+}
+
+func TestGetProjects(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	projects, count, err := GetProjects(ProjectSearchOptions{RepoID: 1, Type: ProjectTypeRepository})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+	assert.Len(t, projects, 2)
+
+	projects, _, err = GetProjects(ProjectSearchOptions{RepoID: 1, Type: ProjectTypeRepository, IsClosed: util.OptionalBoolTrue})
+	assert.NoError(t, err)
+	if assert.Len(t, projects, 1) {
+		assert.EqualValues(t, 2, projects[0].ID)
+	}
+
+	open, closed, err := CountProjects(ProjectSearchOptions{OwnerID: 3, Type: ProjectTypeOrganization})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, open)
+	assert.EqualValues(t, 0, closed)
+
+	_, err = GetProjectByRepoID(2, 1)
+	assert.True(t, IsErrProjectNotExist(err))
+	_, err = GetProjectByOwnerID(3, 1)
+	assert.True(t, IsErrProjectNotExist(err))
+}
+
+func TestProjectList_LoadIssueCounts(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	project := AssertExistsAndLoadBean(t, &Project{ID: 1}).(*Project)
+	assert.NoError(t, ProjectList{project}.LoadIssueCounts())
+	assert.EqualValues(t, 2, project.NumOpenIssues)
+	assert.EqualValues(t, 1, project.NumClosedIssues)
+}
+
+func TestChangeProjectAssign(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	doer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	issue := AssertExistsAndLoadBean(t, &Issue{ID: 11}).(*Issue)
+
+	// issues are put on the default board
+	assert.NoError(t, ChangeProjectAssign(issue, doer, 1))
+	pi := AssertExistsAndLoadBean(t, &ProjectIssue{IssueID: issue.ID}).(*ProjectIssue)
+	assert.EqualValues(t, 1, pi.ProjectID)
+	assert.EqualValues(t, 1, pi.ProjectBoardID)
+	assert.EqualValues(t, 2, pi.Sorting)
+	AssertExistsAndLoadBean(t, &Comment{Type: CommentTypeProject, IssueID: issue.ID, ProjectID: 1})
+
+	// an issue belongs to one project at most
+	assert.NoError(t, ChangeProjectAssign(issue, doer, 2))
+	pi = AssertExistsAndLoadBean(t, &ProjectIssue{IssueID: issue.ID}).(*ProjectIssue)
+	assert.EqualValues(t, 2, pi.ProjectID)
+	assert.EqualValues(t, 0, pi.ProjectBoardID)
+
+	assert.NoError(t, ChangeProjectAssign(issue, doer, 0))
+	AssertNotExistsBean(t, &ProjectIssue{IssueID: issue.ID})
+	AssertExistsAndLoadBean(t, &Comment{Type: CommentTypeProject, IssueID: issue.ID, OldProjectID: 2})
+
+	// organization projects only hold the issues of the organization repositories
+	assert.True(t, IsErrProjectNotExist(ChangeProjectAssign(issue, doer, 3)))
+	issue = AssertExistsAndLoadBean(t, &Issue{ID: 6}).(*Issue)
+	assert.True(t, (&Project{Type: ProjectTypeOrganization, OwnerID: 3}).CanContainIssue(issue))
+}
+
+func TestMoveIssuesOnProjectBoard(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	board := AssertExistsAndLoadBean(t, &ProjectBoard{ID: 2}).(*ProjectBoard)
+	assert.NoError(t, MoveIssuesOnProjectBoard(board, map[int64]int64{2: 0, 1: 1}))
+	AssertExistsAndLoadBean(t, &ProjectIssue{IssueID: 2, ProjectBoardID: 2, Sorting: 0})
+	AssertExistsAndLoadBean(t, &ProjectIssue{IssueID: 1, ProjectBoardID: 2, Sorting: 1})
+
+	// cards sharing a position are all moved
+	assert.NoError(t, MoveIssuesOnProjectBoard(board, map[int64]int64{2: 0, 1: 0}))
+	AssertExistsAndLoadBean(t, &ProjectIssue{IssueID: 2, ProjectBoardID: 2, Sorting: 0})
+	AssertExistsAndLoadBean(t, &ProjectIssue{IssueID: 1, ProjectBoardID: 2, Sorting: 0})
+	assert.NoError(t, MoveIssuesOnProjectBoard(board, map[int64]int64{2: 0, 1: 1}))
+
+	assert.True(t, IsErrProjectIssueNotExist(MoveIssuesOnProjectBoard(board, map[int64]int64{6: 0})))
+
+	assert.NoError(t, MoveIssueOnProjectBoard(board, 5, 0))
+	AssertExistsAndLoadBean(t, &ProjectIssue{IssueID: 5, ProjectBoardID: 2, Sorting: 0})
+	AssertExistsAndLoadBean(t, &ProjectIssue{IssueID: 2, ProjectBoardID: 2, Sorting: 1})
+	AssertExistsAndLoadBean(t, &ProjectIssue{IssueID: 1, ProjectBoardID: 2, Sorting: 2})
+
+	cards, err := GetProjectBoardCards(1, 2, nil)
+	assert.NoError(t, err)
+	if assert.Len(t, cards, 3) {
+		assert.EqualValues(t, 5, cards[0].Issue.ID)
+		assert.EqualValues(t, 1, cards[2].Issue.ID)
+	}
+}
+
+func TestMoveIssueOnStatusChange(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	issue := AssertExistsAndLoadBean(t, &Issue{ID: 1}).(*Issue)
+	assert.NoError(t, MoveIssueOnStatusChange(issue, true))
+	AssertExistsAndLoadBean(t, &ProjectIssue{IssueID: 1, ProjectBoardID: 3})
+
+	assert.NoError(t, MoveIssueOnStatusChange(issue, false))
+	AssertExistsAndLoadBean(t, &ProjectIssue{IssueID: 1, ProjectBoardID: 1})
+
+	// cards which have been moved by hand are kept on their board
+	board := AssertExistsAndLoadBean(t, &ProjectBoard{ID: 2}).(*ProjectBoard)
+	assert.NoError(t, MoveIssuesOnProjectBoard(board, map[int64]int64{1: 0}))
+	assert.NoError(t, MoveIssueOnStatusChange(issue, false))
+	AssertExistsAndLoadBean(t, &ProjectIssue{IssueID: 1, ProjectBoardID: 2})
+}
+
+func TestDeleteProjectBoardByID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	assert.NoError(t, DeleteProjectBoardByID(1))
+	AssertNotExistsBean(t, &ProjectBoard{ID: 1})
+	AssertExistsAndLoadBean(t, &ProjectIssue{IssueID: 1, ProjectBoardID: 0})
+	AssertExistsAndLoadBean(t, &ProjectIssue{IssueID: 2, ProjectBoardID: 0})
+}
+
+func TestDeleteProjectByID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	assert.NoError(t, DeleteProjectByID(1))
+	AssertNotExistsBean(t, &Project{ID: 1})
+	AssertNotExistsBean(t, &ProjectBoard{ProjectID: 1})
+	AssertNotExistsBean(t, &ProjectIssue{ProjectID: 1})
+
+	issue := AssertExistsAndLoadBean(t, &Issue{ID: 1}).(*Issue)
+	assert.EqualValues(t, 0, issue.ProjectID())
+}
@@ -10,6 +10,7 @@ import (
 	"io"
 	"strings"
 
+	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/timeutil"
@@ -24,6 +25,18 @@ const (
 	PullRequestGit
 )
 
+// PullRequestFlow defines how the head of a pull request is pushed
+type PullRequestFlow int
+
+// Enumerate all the pull request flows
+const (
+	// PullRequestFlowGithub the head is a branch of the head repository
+	PullRequestFlowGithub PullRequestFlow = iota
+	// PullRequestFlowAGit the head was pushed to refs/for/<base branch>/<topic> of the base
+	// repository, there is no head branch
+	PullRequestFlowAGit
+)
+
 // PullRequestStatus defines pull request status
 type PullRequestStatus int
 
@@ -57,6 +70,9 @@ type PullRequest struct {
 	BaseBranch      string
 	ProtectedBranch *ProtectedBranch `xorm:"-"`
 	MergeBase       string           `xorm:"VARCHAR(40)"`
+	Flow            PullRequestFlow  `xorm:"NOT NULL DEFAULT 0"`
+	AGitTopic       string           `xorm:"agit_topic"` // the topic an AGit flow pull request was pushed to
+	HeadCommitID    string           `xorm:"-"`
 
 	HasMerged      bool               `xorm:"INDEX"`
 	MergedCommitID string             `xorm:"VARCHAR(40)"`
@@ -319,6 +335,16 @@ func (pr *PullRequest) GetGitRefName() string {
 	return fmt.Sprintf("refs/pull/%d/head", pr.Index)
 }
 
+// GetGitHeadRefName returns the git ref the head commits of the pull request are read
+// from. AGit flow pull requests have no head branch, their head only lives in the
+// hidden pull request ref of the base repository.
+func (pr *PullRequest) GetGitHeadRefName() string {
+	if pr.Flow == PullRequestFlowAGit {
+		return pr.GetGitRefName()
+	}
+	return git.BranchPrefix + pr.HeadBranch
+}
+
 // IsChecking returns true if this pull request is still checking conflict.
 func (pr *PullRequest) IsChecking() bool {
 	return pr.Status == PullRequestStatusChecking
@@ -341,6 +367,10 @@ const (
 	MergeStyleRebaseMerge MergeStyle = "rebase-merge"
 	// MergeStyleSquash squash commits into single commit before merging
 	MergeStyleSquash MergeStyle = "squash"
+	// MergeStyleFastForwardOnly fast-forward the base branch, failing if the head is not a descendant of the base (--ff-only)
+	MergeStyleFastForwardOnly MergeStyle = "fast-forward-only"
+	// MergeStyleSemiLinear rebase onto the base and always create a merge commit, keeping a semi-linear history
+	MergeStyleSemiLinear MergeStyle = "semi-linear"
 )
 
 // SetMerged sets a pull request to merged and closes the corresponding issue
@@ -461,12 +491,12 @@ func newPullRequestAttempt(repo *Repository, pull *Issue, labelIDs []int64, uuid
 }
 
 // GetUnmergedPullRequest returns a pull request that is open and has not been merged
-// by given head/base and repo/branch.
-func GetUnmergedPullRequest(headRepoID, baseRepoID int64, headBranch, baseBranch string) (*PullRequest, error) {
+// by given head/base and repo/branch and flow.
+func GetUnmergedPullRequest(headRepoID, baseRepoID int64, headBranch, baseBranch string, flow PullRequestFlow) (*PullRequest, error) {
 	pr := new(PullRequest)
 	has, err := x.
-		Where("head_repo_id=? AND head_branch=? AND base_repo_id=? AND base_branch=? AND has_merged=? AND issue.is_closed=?",
-			headRepoID, headBranch, baseRepoID, baseBranch, false, false).
+		Where("head_repo_id=? AND head_branch=? AND base_repo_id=? AND base_branch=? AND has_merged=? AND flow=? AND issue.is_closed=?",
+			headRepoID, headBranch, baseRepoID, baseBranch, false, flow, false).
 		Join("INNER", "issue", "issue.id=pull_request.issue_id").
 		Get(pr)
 	if err != nil {
@@ -478,12 +508,30 @@ func GetUnmergedPullRequest(headRepoID, baseRepoID int64, headBranch, baseBranch
 	return pr, nil
 }
 
+// GetUnmergedAGitPullRequest returns the open AGit flow pull request which the poster
+// pushed into baseBranch for the topic
+func GetUnmergedAGitPullRequest(repoID, posterID int64, baseBranch, topic string) (*PullRequest, error) {
+	pr := new(PullRequest)
+	has, err := x.
+		Where("base_repo_id=? AND base_branch=? AND agit_topic=? AND flow=? AND has_merged=? AND issue.poster_id=? AND issue.is_closed=?",
+			repoID, baseBranch, topic, PullRequestFlowAGit, false, posterID, false).
+		Join("INNER", "issue", "issue.id=pull_request.issue_id").
+		Get(pr)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPullRequestNotExist{0, 0, repoID, repoID, "", baseBranch}
+	}
+
+	return pr, nil
+}
+
 // GetLatestPullRequestByHeadInfo returns the latest pull request (regardless of its status)
-// by given head information (repo and branch).
+// by given head information (repo and branch), AGit flow pull requests are ignored.
 func GetLatestPullRequestByHeadInfo(repoID int64, branch string) (*PullRequest, error) {
 	pr := new(PullRequest)
 	has, err := x.
-		Where("head_repo_id = ? AND head_branch = ?", repoID, branch).
+		Where("head_repo_id = ? AND head_branch = ? AND flow = ?", repoID, branch, PullRequestFlowGithub).
 		OrderBy("id DESC").
 		Get(pr)
 	if !has {
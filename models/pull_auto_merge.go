@@ -0,0 +1,186 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"time"
+
+	"code.gitea.io/gitea/modules/generate"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// PullAutoMerge represents a pull request scheduled to be merged automatically
+// once its required status checks and approvals are satisfied
+type PullAutoMerge struct {
+	ID          int64              `xorm:"pk autoincr"`
+	PullID      int64              `xorm:"UNIQUE"`
+	DoerID      int64              `xorm:"NOT NULL"`
+	Doer        *User              `xorm:"-"`
+	MergeStyle  MergeStyle         `xorm:"varchar(30)"`
+	Message     string             `xorm:"TEXT"`
+	CreatedUnix timeutil.TimeStamp `xorm:"created"`
+}
+
+// LoadDoer loads the user who scheduled the merge
+func (m *PullAutoMerge) LoadDoer() (err error) {
+	if m.Doer == nil {
+		m.Doer, err = GetUserByID(m.DoerID)
+	}
+	return err
+}
+
+// ScheduleAutoMerge schedules a pull request to be merged with the given style and
+// message by doer as soon as it is ready
+func ScheduleAutoMerge(doer *User, pr *PullRequest, style MergeStyle, message string) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if exist, err := sess.Exist(&PullAutoMerge{PullID: pr.ID}); err != nil {
+		return err
+	} else if exist {
+		return ErrPullAlreadyScheduledToAutoMerge{PullID: pr.ID}
+	}
+
+	if _, err := sess.Insert(&PullAutoMerge{
+		PullID:     pr.ID,
+		DoerID:     doer.ID,
+		MergeStyle: style,
+		Message:    message,
+	}); err != nil {
+		return err
+	}
+
+	if err := createAutoMergeComment(sess, CommentTypePRScheduledToAutoMerge, pr, doer); err != nil {
+		return err
+	}
+
+	return sess.Commit()
+}
+
+// GetScheduledMergeByPullID returns the scheduled merge of a pull request if there is one
+func GetScheduledMergeByPullID(pullID int64) (bool, *PullAutoMerge, error) {
+	scheduledMerge := new(PullAutoMerge)
+	exist, err := x.Where("pull_id = ?", pullID).Get(scheduledMerge)
+	if err != nil || !exist {
+		return false, nil, err
+	}
+	return true, scheduledMerge, scheduledMerge.LoadDoer()
+}
+
+// GetScheduledMerges returns the scheduled merges of the open pull requests into the
+// given base repository, oldest first. If baseBranch is not empty only the merges into
+// that branch are returned.
+func GetScheduledMerges(baseRepoID int64, baseBranch string) ([]*PullAutoMerge, error) {
+	cond := builder.Eq{
+		"pull_request.base_repo_id": baseRepoID,
+		"pull_request.has_merged":   false,
+		"issue.is_closed":           false,
+	}
+	if len(baseBranch) > 0 {
+		cond["pull_request.base_branch"] = baseBranch
+	}
+
+	scheduledMerges := make([]*PullAutoMerge, 0, 5)
+	return scheduledMerges, x.
+		Join("INNER", "pull_request", "pull_request.id = pull_auto_merge.pull_id").
+		Join("INNER", "issue", "issue.id = pull_request.issue_id").
+		Where(cond).
+		Asc("pull_auto_merge.created_unix", "pull_auto_merge.id").
+		Find(&scheduledMerges)
+}
+
+// RemoveScheduledAutoMerge cancels the scheduled merge of a pull request. If doer is
+// not nil the cancellation is recorded on the pull request timeline.
+func RemoveScheduledAutoMerge(doer *User, pr *PullRequest) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err := sess.Where("pull_id = ?", pr.ID).Delete(new(PullAutoMerge)); err != nil {
+		return err
+	}
+
+	if doer != nil {
+		if err := createAutoMergeComment(sess, CommentTypePRUnScheduledToAutoMerge, pr, doer); err != nil {
+			return err
+		}
+	}
+
+	return sess.Commit()
+}
+
+// PullAutoMergeLock is held while a scheduled pull request is tested and merged into a base
+// branch, it serializes the merges into the same branch across all the Gitea instances
+// sharing the database
+type PullAutoMergeLock struct {
+	ID          int64  `xorm:"pk autoincr"`
+	RepoID      int64  `xorm:"UNIQUE(s) NOT NULL"`
+	Branch      string `xorm:"UNIQUE(s) NOT NULL"`
+	Token       string `xorm:"NOT NULL"`
+	ExpiredUnix timeutil.TimeStamp
+}
+
+// TryLockAutoMerge takes the auto merge lock of the base branch for the given duration. A
+// lock which has expired, e.g. because its holder has crashed, is taken over. It returns
+// the token to release the lock, which is empty if the lock is held by someone else.
+func TryLockAutoMerge(repoID int64, branch string, duration time.Duration) (string, error) {
+	token, err := generate.GetRandomString(40)
+	if err != nil {
+		return "", err
+	}
+	now := timeutil.TimeStampNow()
+	lock := &PullAutoMergeLock{
+		RepoID:      repoID,
+		Branch:      branch,
+		Token:       token,
+		ExpiredUnix: now.AddDuration(duration),
+	}
+
+	if n, err := x.Where("repo_id = ? AND branch = ? AND expired_unix < ?", repoID, branch, now).
+		Cols("token", "expired_unix").Update(lock); err != nil {
+		return "", err
+	} else if n > 0 {
+		return token, nil
+	}
+
+	if _, err := x.Insert(lock); err != nil {
+		// the insert fails on the unique constraint if the lock is held
+		if exist, err2 := x.Exist(&PullAutoMergeLock{RepoID: repoID, Branch: branch}); err2 == nil && exist {
+			return "", nil
+		}
+		return "", err
+	}
+	return token, nil
+}
+
+// UnlockAutoMerge releases the auto merge lock of the base branch if it is still held with the token
+func UnlockAutoMerge(repoID int64, branch, token string) error {
+	_, err := x.Delete(&PullAutoMergeLock{RepoID: repoID, Branch: branch, Token: token})
+	return err
+}
+
+func createAutoMergeComment(e *xorm.Session, typ CommentType, pr *PullRequest, doer *User) error {
+	if err := pr.loadIssue(e); err != nil {
+		return err
+	}
+	if err := pr.loadBaseRepo(e); err != nil {
+		return err
+	}
+	_, err := createComment(e, &CreateCommentOptions{
+		Type:  typ,
+		Doer:  doer,
+		Repo:  pr.BaseRepo,
+		Issue: pr.Issue,
+	})
+	return err
+}
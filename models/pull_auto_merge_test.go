@@ -0,0 +1,104 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleAutoMerge(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	doer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	pr := AssertExistsAndLoadBean(t, &PullRequest{ID: 2}).(*PullRequest)
+
+	assert.NoError(t, ScheduleAutoMerge(doer, pr, MergeStyleSquash, "squashed"))
+	AssertExistsAndLoadBean(t, &PullAutoMerge{PullID: pr.ID, DoerID: doer.ID})
+	AssertExistsAndLoadBean(t, &Comment{Type: CommentTypePRScheduledToAutoMerge, IssueID: pr.IssueID, PosterID: doer.ID})
+
+	err := ScheduleAutoMerge(doer, pr, MergeStyleMerge, "")
+	assert.True(t, IsErrPullAlreadyScheduledToAutoMerge(err))
+
+	exist, scheduledMerge, err := GetScheduledMergeByPullID(pr.ID)
+	assert.NoError(t, err)
+	assert.True(t, exist)
+	assert.Equal(t, MergeStyleSquash, scheduledMerge.MergeStyle)
+	assert.Equal(t, "squashed", scheduledMerge.Message)
+	assert.Equal(t, doer.ID, scheduledMerge.Doer.ID)
+
+	exist, _, err = GetScheduledMergeByPullID(1)
+	assert.NoError(t, err)
+	assert.False(t, exist)
+}
+
+func TestGetScheduledMerges(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	doer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	pr := AssertExistsAndLoadBean(t, &PullRequest{ID: 2}).(*PullRequest)
+	assert.NoError(t, ScheduleAutoMerge(doer, pr, MergeStyleMerge, ""))
+
+	scheduledMerges, err := GetScheduledMerges(pr.BaseRepoID, "")
+	assert.NoError(t, err)
+	if assert.Len(t, scheduledMerges, 1) {
+		assert.Equal(t, pr.ID, scheduledMerges[0].PullID)
+	}
+
+	scheduledMerges, err = GetScheduledMerges(pr.BaseRepoID, pr.BaseBranch)
+	assert.NoError(t, err)
+	assert.Len(t, scheduledMerges, 1)
+
+	scheduledMerges, err = GetScheduledMerges(pr.BaseRepoID, "branch2")
+	assert.NoError(t, err)
+	assert.Len(t, scheduledMerges, 0)
+}
+
+func TestRemoveScheduledAutoMerge(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	doer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	pr := AssertExistsAndLoadBean(t, &PullRequest{ID: 2}).(*PullRequest)
+	assert.NoError(t, ScheduleAutoMerge(doer, pr, MergeStyleMerge, ""))
+
+	assert.NoError(t, RemoveScheduledAutoMerge(doer, pr))
+	AssertNotExistsBean(t, &PullAutoMerge{PullID: pr.ID})
+	AssertExistsAndLoadBean(t, &Comment{Type: CommentTypePRUnScheduledToAutoMerge, IssueID: pr.IssueID, PosterID: doer.ID})
+
+	// removing a merge which is not scheduled is a no-op
+	assert.NoError(t, RemoveScheduledAutoMerge(nil, pr))
+}
+
+func TestAutoMergeLock(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	token, err := TryLockAutoMerge(1, "master", time.Hour)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, token)
+
+	// the lock is held, other branches can be locked
+	other, err := TryLockAutoMerge(1, "master", time.Hour)
+	assert.NoError(t, err)
+	assert.Empty(t, other)
+	other, err = TryLockAutoMerge(1, "branch2", time.Hour)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, other)
+
+	// only the holder releases the lock
+	assert.NoError(t, UnlockAutoMerge(1, "master", "wrong token"))
+	other, err = TryLockAutoMerge(1, "master", time.Hour)
+	assert.NoError(t, err)
+	assert.Empty(t, other)
+	assert.NoError(t, UnlockAutoMerge(1, "master", token))
+	token, err = TryLockAutoMerge(1, "master", -time.Hour)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, token)
+
+	// an expired lock is taken over and can not be released by its former holder anymore
+	other, err = TryLockAutoMerge(1, "master", time.Hour)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, other)
+	assert.NoError(t, UnlockAutoMerge(1, "master", token))
+	AssertExistsAndLoadBean(t, &PullAutoMergeLock{RepoID: 1, Branch: "master", Token: other})
+}
@@ -11,6 +11,7 @@ import (
 	"errors"
 	"fmt"
 	"html/template"
+	"io"
 	"unicode/utf8"
 
 	// Needed for jpeg support
@@ -31,6 +32,7 @@ import (
 	"code.gitea.io/gitea/modules/markup"
 	"code.gitea.io/gitea/modules/options"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/timeutil"
 	"code.gitea.io/gitea/modules/util"
@@ -43,6 +45,9 @@ var (
 	// ErrMirrorNotExist mirror does not exist error
 	ErrMirrorNotExist = errors.New("Mirror does not exist")
 
+	// ErrPushMirrorNotExist push mirror does not exist error
+	ErrPushMirrorNotExist = errors.New("PushMirror does not exist")
+
 	// ErrNameEmpty name is empty error
 	ErrNameEmpty = errors.New("Name is empty")
 )
@@ -337,12 +342,22 @@ func (repo *Repository) innerAPIFormat(e Engine, mode AccessMode, isParent bool)
 			ExternalWikiURL: config.ExternalWikiURL,
 		}
 	}
+	hasProjects := false
+	if _, err := repo.getUnit(e, UnitTypeProjects); err == nil {
+		hasProjects = true
+	}
+	hasActions := false
+	if _, err := repo.getUnit(e, UnitTypeActions); err == nil {
+		hasActions = true
+	}
 	hasPullRequests := false
 	ignoreWhitespaceConflicts := false
 	allowMerge := false
 	allowRebase := false
 	allowRebaseMerge := false
 	allowSquash := false
+	allowFastForwardOnly := false
+	allowSemiLinear := false
 	if unit, err := repo.getUnit(e, UnitTypePullRequests); err == nil {
 		config := unit.PullRequestsConfig()
 		hasPullRequests = true
@@ -351,6 +366,8 @@ func (repo *Repository) innerAPIFormat(e Engine, mode AccessMode, isParent bool)
 		allowRebase = config.AllowRebase
 		allowRebaseMerge = config.AllowRebaseMerge
 		allowSquash = config.AllowSquash
+		allowFastForwardOnly = config.AllowFastForwardOnly
+		allowSemiLinear = config.AllowSemiLinear
 	}
 
 	repo.mustOwner(e)
@@ -391,11 +408,15 @@ func (repo *Repository) innerAPIFormat(e Engine, mode AccessMode, isParent bool)
 		HasWiki:                   hasWiki,
 		ExternalWiki:              externalWiki,
 		HasPullRequests:           hasPullRequests,
+		HasProjects:               hasProjects,
+		HasActions:                hasActions,
 		IgnoreWhitespaceConflicts: ignoreWhitespaceConflicts,
 		AllowMerge:                allowMerge,
 		AllowRebase:               allowRebase,
 		AllowRebaseMerge:          allowRebaseMerge,
 		AllowSquash:               allowSquash,
+		AllowFastForwardOnly:      allowFastForwardOnly,
+		AllowSemiLinear:           allowSemiLinear,
 		AvatarURL:                 repo.avatarLink(e),
 		Internal:                  !repo.IsPrivate && repo.Owner.Visibility == api.VisibleTypePrivate,
 	}
@@ -1574,19 +1595,25 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 	}
 	releaseAttachments := make([]string, 0, len(attachments))
 	for i := 0; i < len(attachments); i++ {
-		releaseAttachments = append(releaseAttachments, attachments[i].LocalPath())
+		releaseAttachments = append(releaseAttachments, attachments[i].RelativePath())
 	}
 
 	if _, err = sess.Exec("UPDATE `user` SET num_stars=num_stars-1 WHERE id IN (SELECT `uid` FROM `star` WHERE repo_id = ?)", repo.ID); err != nil {
 		return err
 	}
 
+	if _, err = sess.In("pull_id", builder.Select("id").From("pull_request").Where(builder.Eq{"base_repo_id": repoID})).
+		Delete(new(PullAutoMerge)); err != nil {
+		return fmt.Errorf("delete scheduled merges: %v", err)
+	}
+
 	if err = deleteBeans(sess,
 		&Access{RepoID: repo.ID},
 		&Action{RepoID: repo.ID},
 		&Watch{RepoID: repoID},
 		&Star{RepoID: repoID},
 		&Mirror{RepoID: repoID},
+		&PushMirror{RepoID: repoID},
 		&Milestone{RepoID: repoID},
 		&Release{RepoID: repoID},
 		&Collaboration{RepoID: repoID},
@@ -1605,6 +1632,15 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 		return fmt.Errorf("deleteBeans: %v", err)
 	}
 
+	if err = deleteProjectsByCond(sess, builder.Eq{"repo_id": repoID, "type": ProjectTypeRepository}); err != nil {
+		return fmt.Errorf("deleteProjectsByCond: %v", err)
+	}
+
+	actionLogPaths, err := deleteActionRunsByRepoID(sess, repoID)
+	if err != nil {
+		return fmt.Errorf("deleteActionRunsByRepoID: %v", err)
+	}
+
 	// Delete Issues and related objects
 	var attachmentPaths []string
 	if attachmentPaths, err = deleteIssuesByRepoID(sess, repoID); err != nil {
@@ -1655,8 +1691,7 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 			continue
 		}
 
-		oidPath := filepath.Join(setting.LFS.ContentPath, v.Oid[0:2], v.Oid[2:4], v.Oid[4:len(v.Oid)])
-		removeAllWithNotice(sess, "Delete orphaned LFS file", oidPath)
+		removeStorageWithNotice(sess, storage.LFS, "Delete orphaned LFS file", v.RelativePath())
 	}
 
 	if _, err := sess.Delete(&LFSMetaObject{RepositoryID: repoID}); err != nil {
@@ -1687,20 +1722,25 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 
 	// Remove issue attachment files.
 	for i := range attachmentPaths {
-		removeAllWithNotice(x, "Delete issue attachment", attachmentPaths[i])
+		removeStorageWithNotice(x, storage.Attachments, "Delete issue attachment", attachmentPaths[i])
 	}
 
 	// Remove release attachment files.
 	for i := range releaseAttachments {
-		removeAllWithNotice(x, "Delete release attachment", releaseAttachments[i])
+		removeStorageWithNotice(x, storage.Attachments, "Delete release attachment", releaseAttachments[i])
+	}
+
+	// Remove action logs, the storage is only initialized if actions are enabled.
+	if storage.ActionsLog != nil {
+		for i := range actionLogPaths {
+			removeStorageWithNotice(x, storage.ActionsLog, "Delete action log", actionLogPaths[i])
+		}
 	}
 
 	if len(repo.Avatar) > 0 {
-		avatarPath := repo.CustomAvatarPath()
-		if com.IsExist(avatarPath) {
-			if err := os.Remove(avatarPath); err != nil {
-				return fmt.Errorf("Failed to remove %s: %v", avatarPath, err)
-			}
+		avatarPath := repo.CustomAvatarRelativePath()
+		if err := storage.RepoAvatars.Delete(avatarPath); err != nil && !storage.IsNotExist(err) {
+			return fmt.Errorf("Failed to remove %s: %v", avatarPath, err)
 		}
 	}
 
@@ -1780,6 +1820,9 @@ func GetUserRepositories(opts *SearchRepoOptions) ([]*Repository, int64, error)
 	if !opts.Private {
 		cond = cond.And(builder.Eq{"is_private": false})
 	}
+	if len(opts.RepoIDs) > 0 {
+		cond = cond.And(builder.In("id", opts.RepoIDs))
+	}
 
 	sess := x.NewSession()
 	defer sess.Close()
@@ -2135,13 +2178,9 @@ func (repo *Repository) GetUserFork(userID int64) (*Repository, error) {
 	return &forkedRepo, nil
 }
 
-// CustomAvatarPath returns repository custom avatar file path.
-func (repo *Repository) CustomAvatarPath() string {
-	// Avatar empty by default
-	if len(repo.Avatar) == 0 {
-		return ""
-	}
-	return filepath.Join(setting.RepositoryAvatarUploadPath, repo.Avatar)
+// CustomAvatarRelativePath returns repository custom avatar file path.
+func (repo *Repository) CustomAvatarRelativePath() string {
+	return repo.Avatar
 }
 
 // generateRandomAvatar generates a random avatar for repository.
@@ -2155,17 +2194,14 @@ func (repo *Repository) generateRandomAvatar(e Engine) error {
 	}
 
 	repo.Avatar = idToString
-	if err = os.MkdirAll(filepath.Dir(repo.CustomAvatarPath()), os.ModePerm); err != nil {
-		return fmt.Errorf("MkdirAll: %v", err)
-	}
-	fw, err := os.Create(repo.CustomAvatarPath())
-	if err != nil {
-		return fmt.Errorf("Create: %v", err)
-	}
-	defer fw.Close()
 
-	if err = png.Encode(fw, img); err != nil {
-		return fmt.Errorf("Encode: %v", err)
+	if err := storage.SaveFrom(storage.RepoAvatars, repo.CustomAvatarRelativePath(), func(w io.Writer) error {
+		if err := png.Encode(w, img); err != nil {
+			log.Error("Encode: %v", err)
+		}
+		return err
+	}); err != nil {
+		return fmt.Errorf("Failed to create avatar %s: %v", repo.CustomAvatarRelativePath(), err)
 	}
 	log.Info("New random avatar created for repository: %d", repo.ID)
 
@@ -2203,8 +2239,12 @@ func (repo *Repository) RelAvatarLink() string {
 
 func (repo *Repository) relAvatarLink(e Engine) string {
 	// If no avatar - path is empty
-	avatarPath := repo.CustomAvatarPath()
-	if len(avatarPath) == 0 || !com.IsFile(avatarPath) {
+	avatarPath := repo.CustomAvatarRelativePath()
+	var err error
+	if len(avatarPath) > 0 {
+		_, err = storage.RepoAvatars.Stat(avatarPath)
+	}
+	if len(avatarPath) == 0 || err != nil {
 		switch mode := setting.RepositoryAvatarFallback; mode {
 		case "image":
 			return setting.RepositoryAvatarFallbackImage
@@ -2246,7 +2286,7 @@ func (repo *Repository) UploadAvatar(data []byte) error {
 		return err
 	}
 
-	oldAvatarPath := repo.CustomAvatarPath()
+	oldAvatarPath := repo.CustomAvatarRelativePath()
 
 	// Users can upload the same image to other repo - prefix it with ID
 	// Then repo will be removed - only it avatar file will be removed
@@ -2255,22 +2295,17 @@ func (repo *Repository) UploadAvatar(data []byte) error {
 		return fmt.Errorf("UploadAvatar: Update repository avatar: %v", err)
 	}
 
-	if err := os.MkdirAll(setting.RepositoryAvatarUploadPath, os.ModePerm); err != nil {
-		return fmt.Errorf("UploadAvatar: Failed to create dir %s: %v", setting.RepositoryAvatarUploadPath, err)
-	}
-
-	fw, err := os.Create(repo.CustomAvatarPath())
-	if err != nil {
-		return fmt.Errorf("UploadAvatar: Create file: %v", err)
-	}
-	defer fw.Close()
-
-	if err = png.Encode(fw, *m); err != nil {
-		return fmt.Errorf("UploadAvatar: Encode png: %v", err)
+	if err := storage.SaveFrom(storage.RepoAvatars, repo.CustomAvatarRelativePath(), func(w io.Writer) error {
+		if err := png.Encode(w, *m); err != nil {
+			log.Error("Encode: %v", err)
+		}
+		return err
+	}); err != nil {
+		return fmt.Errorf("UploadAvatar: Failed to create avatar %s: %v", repo.CustomAvatarRelativePath(), err)
 	}
 
-	if len(oldAvatarPath) > 0 && oldAvatarPath != repo.CustomAvatarPath() {
-		if err := os.Remove(oldAvatarPath); err != nil {
+	if len(oldAvatarPath) > 0 && oldAvatarPath != repo.CustomAvatarRelativePath() {
+		if err := storage.RepoAvatars.Delete(oldAvatarPath); err != nil {
 			return fmt.Errorf("UploadAvatar: Failed to remove old repo avatar %s: %v", oldAvatarPath, err)
 		}
 	}
@@ -2286,7 +2321,7 @@ func (repo *Repository) DeleteAvatar() error {
 		return nil
 	}
 
-	avatarPath := repo.CustomAvatarPath()
+	avatarPath := repo.CustomAvatarRelativePath()
 	log.Trace("DeleteAvatar[%d]: %s", repo.ID, avatarPath)
 
 	sess := x.NewSession()
@@ -2300,8 +2335,8 @@ func (repo *Repository) DeleteAvatar() error {
 		return fmt.Errorf("DeleteAvatar: Update repository avatar: %v", err)
 	}
 
-	if _, err := os.Stat(avatarPath); err == nil {
-		if err := os.Remove(avatarPath); err != nil {
+	if _, err := storage.RepoAvatars.Stat(avatarPath); err == nil {
+		if err := storage.RepoAvatars.Delete(avatarPath); err != nil {
 			return fmt.Errorf("DeleteAvatar: Failed to remove %s: %v", avatarPath, err)
 		}
 	} else {
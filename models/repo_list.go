@@ -175,6 +175,8 @@ type SearchRepoOptions struct {
 	// True -> include just has milestones
 	// False -> include just has no milestone
 	HasMilestones util.OptionalBool
+	// restricts the results to these repositories if not empty
+	RepoIDs []int64
 }
 
 //SearchOrderBy is used to sort the result
@@ -344,6 +346,10 @@ func SearchRepositoryCondition(opts *SearchRepoOptions) builder.Cond {
 		cond = cond.And(builder.Eq{"num_milestones": 0}.Or(builder.IsNull{"num_milestones"}))
 	}
 
+	if len(opts.RepoIDs) > 0 {
+		cond = cond.And(builder.In("id", opts.RepoIDs))
+	}
+
 	return cond
 }
 
@@ -96,6 +96,12 @@ func (p *Permission) CanWriteIssuesOrPulls(isPull bool) bool {
 	return p.CanWrite(UnitTypeIssues)
 }
 
+// CanPushAGitPullRequest returns true if user could propose a pull request by pushing to
+// refs/for/<branch>/<topic>, which only requires to read the code and the pull requests
+func (p *Permission) CanPushAGitPullRequest() bool {
+	return p.CanRead(UnitTypeCode) && p.CanRead(UnitTypePullRequests)
+}
+
 // ColorFormat writes a colored string for these Permissions
 func (p *Permission) ColorFormat(s fmt.State) {
 	noColor := log.ColorBytes(log.Reset)
@@ -0,0 +1,114 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"time"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+// PushMirror represents a remote repository which is kept in sync
+// by pushing the local repository to it.
+type PushMirror struct {
+	ID         int64       `xorm:"pk autoincr"`
+	RepoID     int64       `xorm:"INDEX"`
+	Repo       *Repository `xorm:"-"`
+	RemoteName string
+
+	Interval       time.Duration
+	CreatedUnix    timeutil.TimeStamp `xorm:"created"`
+	LastUpdateUnix timeutil.TimeStamp `xorm:"INDEX last_update"`
+	LastError      string             `xorm:"text"`
+}
+
+// AfterLoad is invoked from XORM after setting the values of all fields of this object.
+func (m *PushMirror) AfterLoad(session *xorm.Session) {
+	if m == nil {
+		return
+	}
+
+	var err error
+	m.Repo, err = getRepositoryByID(session, m.RepoID)
+	if err != nil {
+		log.Error("getRepositoryByID[%d]: %v", m.ID, err)
+	}
+}
+
+// GetRepository returns the repository the push mirror belongs to.
+func (m *PushMirror) GetRepository() *Repository {
+	return m.Repo
+}
+
+// InsertPushMirror inserts a push mirror to database
+func InsertPushMirror(m *PushMirror) error {
+	_, err := x.Insert(m)
+	return err
+}
+
+// UpdatePushMirror updates the push mirror
+func UpdatePushMirror(m *PushMirror) error {
+	_, err := x.ID(m.ID).AllCols().Update(m)
+	return err
+}
+
+// DeletePushMirrorByID deletes a push mirror by its ID
+func DeletePushMirrorByID(id int64) error {
+	_, err := x.ID(id).Delete(&PushMirror{})
+	return err
+}
+
+// DeletePushMirrorsByRepoID deletes all push mirrors of a repository
+func DeletePushMirrorsByRepoID(repoID int64) error {
+	_, err := x.Delete(&PushMirror{RepoID: repoID})
+	return err
+}
+
+// GetPushMirrorByID returns push mirror information.
+func GetPushMirrorByID(id int64) (*PushMirror, error) {
+	m := &PushMirror{}
+	has, err := x.ID(id).Get(m)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPushMirrorNotExist
+	}
+	return m, nil
+}
+
+// GetPushMirrorByRepoIDAndName returns the push mirror of a repository with the given remote name.
+func GetPushMirrorByRepoIDAndName(repoID int64, remoteName string) (*PushMirror, error) {
+	m := &PushMirror{}
+	has, err := x.Where("repo_id = ? AND remote_name = ?", repoID, remoteName).Get(m)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPushMirrorNotExist
+	}
+	return m, nil
+}
+
+// GetPushMirrorsByRepoID returns push mirror information of a repository.
+func GetPushMirrorsByRepoID(repoID int64) ([]*PushMirror, error) {
+	mirrors := make([]*PushMirror, 0, 10)
+	return mirrors, x.Where("repo_id = ?", repoID).Find(&mirrors)
+}
+
+// IsDue returns true if the push mirror has a sync interval and the interval
+// has elapsed since its last update.
+func (m *PushMirror) IsDue() bool {
+	if m.Interval <= 0 {
+		return false
+	}
+	return m.LastUpdateUnix.AddDuration(m.Interval) <= timeutil.TimeStampNow()
+}
+
+// PushMirrorsIterate iterates all push mirrors.
+func PushMirrorsIterate(f func(idx int, bean interface{}) error) error {
+	return x.Iterate(new(PushMirror), f)
+}
@@ -0,0 +1,58 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPushMirrors(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	m := &PushMirror{
+		RepoID:     1,
+		RemoteName: "test-1",
+		Interval:   time.Hour,
+	}
+	assert.NoError(t, InsertPushMirror(m))
+	assert.NoError(t, InsertPushMirror(&PushMirror{RepoID: 1, RemoteName: "test-2"}))
+
+	mirrors, err := GetPushMirrorsByRepoID(1)
+	assert.NoError(t, err)
+	assert.Len(t, mirrors, 2)
+
+	m, err = GetPushMirrorByRepoIDAndName(1, "test-1")
+	assert.NoError(t, err)
+	assert.NotNil(t, m.Repo)
+	assert.EqualValues(t, 1, m.Repo.ID)
+	assert.True(t, m.IsDue())
+
+	m.LastUpdateUnix = timeutil.TimeStampNow()
+	m.LastError = "push failed"
+	assert.NoError(t, UpdatePushMirror(m))
+	m, err = GetPushMirrorByID(m.ID)
+	assert.NoError(t, err)
+	assert.False(t, m.IsDue())
+	assert.Equal(t, "push failed", m.LastError)
+
+	_, err = GetPushMirrorByRepoIDAndName(1, "test-3")
+	assert.Equal(t, ErrPushMirrorNotExist, err)
+
+	assert.NoError(t, DeletePushMirrorByID(m.ID))
+	mirrors, err = GetPushMirrorsByRepoID(1)
+	assert.NoError(t, err)
+	assert.Len(t, mirrors, 1)
+	assert.False(t, mirrors[0].IsDue())
+
+	assert.NoError(t, DeletePushMirrorsByRepoID(1))
+	mirrors, err = GetPushMirrorsByRepoID(1)
+	assert.NoError(t, err)
+	assert.Len(t, mirrors, 0)
+}
@@ -0,0 +1,24 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPullRequestsConfig_IsMergeStyleAllowed(t *testing.T) {
+	cfg := &PullRequestsConfig{AllowMerge: true, AllowFastForwardOnly: true}
+	assert.True(t, cfg.IsMergeStyleAllowed(MergeStyleMerge))
+	assert.True(t, cfg.IsMergeStyleAllowed(MergeStyleFastForwardOnly))
+	assert.False(t, cfg.IsMergeStyleAllowed(MergeStyleSemiLinear))
+	assert.False(t, cfg.IsMergeStyleAllowed(MergeStyleRebaseMerge))
+
+	cfg = &PullRequestsConfig{AllowSemiLinear: true}
+	assert.True(t, cfg.IsMergeStyleAllowed(MergeStyleSemiLinear))
+	assert.False(t, cfg.IsMergeStyleAllowed(MergeStyleFastForwardOnly))
+	assert.False(t, cfg.IsMergeStyleAllowed(MergeStyle("unknown")))
+}
@@ -0,0 +1,110 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"code.gitea.io/gitea/modules/auth/saml"
+	"code.gitea.io/gitea/modules/log"
+)
+
+// GetActiveSAMLLoginSourceByName returns a SAML LoginSource based on the given name
+func GetActiveSAMLLoginSourceByName(name string) (*LoginSource, error) {
+	loginSource := new(LoginSource)
+	has, err := x.Where("name = ? and type = ? and is_actived = ?", name, LoginSAML, true).Get(loginSource)
+	if !has || err != nil {
+		return nil, err
+	}
+
+	return loginSource, nil
+}
+
+// UserSignInSAML returns the user linked to the SAML subject. If there is none,
+// a user is registered from the asserted details and linked to the subject, in
+// which case created is true. The team memberships of the user are synchronized
+// from their groups.
+func UserSignInSAML(source *LoginSource, info *saml.UserInfo) (user *User, created bool, err error) {
+	cfg := source.SAML()
+	mapping, err := ParseGroupTeamMapping(cfg.GroupTeamMap)
+	if err != nil {
+		return nil, false, err
+	}
+
+	externalLoginUser := &ExternalLoginUser{
+		ExternalID:    info.NameID,
+		LoginSourceID: source.ID,
+	}
+	has, err := GetExternalLogin(externalLoginUser)
+	if err != nil {
+		return nil, false, err
+	}
+
+	link := &ExternalLoginUser{
+		ExternalID:    info.NameID,
+		LoginSourceID: source.ID,
+		RawData:       info.Attributes,
+		Provider:      "saml",
+		Email:         info.Email,
+		Name:          info.FullName,
+		NickName:      info.Username,
+	}
+	if has {
+		if user, err = GetUserByID(externalLoginUser.UserID); err != nil {
+			return nil, false, err
+		}
+		link.UserID = user.ID
+		if _, err = x.Where("external_id=? AND login_source_id=?", info.NameID, source.ID).AllCols().Update(link); err != nil {
+			return nil, false, err
+		}
+	} else {
+		if info.Email == "" {
+			return nil, false, ErrExternalUserMissingEmail{info.NameID, source.ID}
+		}
+		user = &User{
+			Name:        info.Username,
+			FullName:    info.FullName,
+			Email:       info.Email,
+			IsActive:    true,
+			LoginType:   LoginSAML,
+			LoginSource: source.ID,
+			LoginName:   info.NameID,
+		}
+		if err = registerSAMLUser(user, link); err != nil {
+			return nil, false, err
+		}
+		created = true
+		log.Trace("UserSignInSAML[%s]: registered user %s", source.Name, user.Name)
+	}
+
+	if err := SyncGroupTeams(user, info.Groups, mapping, cfg.GroupTeamMapRemoval); err != nil {
+		return nil, created, err
+	}
+
+	// WARN: DON'T check user.IsActive, that will be checked on reqSign so that
+	// user could be hint to resend confirm email.
+	if user.ProhibitLogin {
+		return nil, created, ErrUserProhibitLogin{user.ID, user.Name}
+	}
+	return user, created, nil
+}
+
+// registerSAMLUser creates the user and links it to the SAML subject at once,
+// so that a failure doesn't leave a user which can't sign in
+func registerSAMLUser(user *User, externalLoginUser *ExternalLoginUser) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if err := createUser(sess, user); err != nil {
+		return err
+	}
+	externalLoginUser.UserID = user.ID
+	if err := linkExternalToUser(sess, user, externalLoginUser); err != nil {
+		return err
+	}
+
+	return sess.Commit()
+}
@@ -7,6 +7,7 @@ package models
 
 import (
 	"crypto/subtle"
+	"strings"
 	"time"
 
 	"code.gitea.io/gitea/modules/base"
@@ -25,9 +26,12 @@ type AccessToken struct {
 	TokenHash      string `xorm:"UNIQUE"` // sha256 of token
 	TokenSalt      string
 	TokenLastEight string `xorm:"token_last_eight"`
+	Scope          AccessTokenScope
+	RepoIDs        []int64 `xorm:"JSON TEXT"` // restricts the token to these repositories if not empty
 
 	CreatedUnix       timeutil.TimeStamp `xorm:"INDEX created"`
 	UpdatedUnix       timeutil.TimeStamp `xorm:"INDEX updated"`
+	ExpiresUnix       timeutil.TimeStamp `xorm:"INDEX"` // never expires if 0
 	HasRecentActivity bool               `xorm:"-"`
 	HasUsed           bool               `xorm:"-"`
 }
@@ -38,6 +42,16 @@ func (t *AccessToken) AfterLoad() {
 	t.HasRecentActivity = t.UpdatedUnix.AddDuration(7*24*time.Hour) > timeutil.TimeStampNow()
 }
 
+// IsExpired returns true if the token has an expiry date which has passed.
+func (t *AccessToken) IsExpired() bool {
+	return t.ExpiresUnix != 0 && t.ExpiresUnix <= timeutil.TimeStampNow()
+}
+
+// CanAccessRepo returns true if the token is not restricted to other repositories.
+func (t *AccessToken) CanAccessRepo(repoID int64) bool {
+	return len(t.RepoIDs) == 0 || base.Int64sContains(t.RepoIDs, repoID)
+}
+
 // NewAccessToken creates new access token.
 func NewAccessToken(t *AccessToken) error {
 	salt, err := generate.GetRandomString(10)
@@ -48,11 +62,15 @@ func NewAccessToken(t *AccessToken) error {
 	t.Token = base.EncodeSha1(gouuid.New().String())
 	t.TokenHash = hashToken(t.Token, t.TokenSalt)
 	t.TokenLastEight = t.Token[len(t.Token)-8:]
+	if t.Scope == "" {
+		t.Scope = AccessTokenScopeAll
+	}
 	_, err = x.Insert(t)
 	return err
 }
 
-// GetAccessTokenBySHA returns access token by given token value
+// GetAccessTokenBySHA returns access token by given token value,
+// expired tokens are reported as not existing.
 func GetAccessTokenBySHA(token string) (*AccessToken, error) {
 	if token == "" {
 		return nil, ErrAccessTokenEmpty{}
@@ -71,12 +89,69 @@ func GetAccessTokenBySHA(token string) (*AccessToken, error) {
 	for _, t := range tokens {
 		tempHash := hashToken(token, t.TokenSalt)
 		if subtle.ConstantTimeCompare([]byte(t.TokenHash), []byte(tempHash)) == 1 {
+			if t.IsExpired() {
+				return nil, ErrAccessTokenNotExist{token}
+			}
 			return &t, nil
 		}
 	}
 	return nil, ErrAccessTokenNotExist{token}
 }
 
+// ResolveAccessTokenRepositories returns the IDs of the given "owner/name"
+// repositories, the user must have access to each of them.
+func ResolveAccessTokenRepositories(u *User, fullNames []string) ([]int64, error) {
+	ids := make([]int64, 0, len(fullNames))
+	for _, fullName := range fullNames {
+		fullName = strings.TrimSpace(fullName)
+		if fullName == "" {
+			continue
+		}
+		parts := strings.SplitN(fullName, "/", 2)
+		if len(parts) != 2 {
+			return nil, ErrRepoNotExist{0, 0, "", fullName}
+		}
+		repo, err := GetRepositoryByOwnerAndName(parts[0], parts[1])
+		if err != nil {
+			return nil, err
+		}
+		perm, err := GetUserRepoPermission(repo, u)
+		if err != nil {
+			return nil, err
+		}
+		if !perm.HasAccess() {
+			return nil, ErrRepoNotExist{0, 0, parts[0], parts[1]}
+		}
+		if !base.Int64sContains(ids, repo.ID) {
+			ids = append(ids, repo.ID)
+		}
+	}
+	return ids, nil
+}
+
+// RepositoryFullNames returns the full names of the repositories the token is restricted to.
+func (t *AccessToken) RepositoryFullNames() ([]string, error) {
+	if len(t.RepoIDs) == 0 {
+		return []string{}, nil
+	}
+	repos, err := GetRepositoriesMapByIDs(t.RepoIDs)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(repos))
+	for _, id := range t.RepoIDs {
+		repo, ok := repos[id]
+		if !ok {
+			continue
+		}
+		if err := repo.GetOwner(); err != nil {
+			return nil, err
+		}
+		names = append(names, repo.FullName())
+	}
+	return names, nil
+}
+
 // AccessTokenByNameExists checks if a token name has been used already by a user.
 func AccessTokenByNameExists(token *AccessToken) (bool, error) {
 	return x.Table("access_token").Where("name = ?", token.Name).And("uid = ?", token.UID).Exist()
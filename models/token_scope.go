@@ -0,0 +1,128 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AccessTokenScope is a comma separated list of the scopes granted to an access token
+type AccessTokenScope string
+
+// enumerates all the access token scopes
+const (
+	AccessTokenScopeAll       AccessTokenScope = "all"
+	AccessTokenScopeRepoRead  AccessTokenScope = "repo:read"
+	AccessTokenScopeRepoWrite AccessTokenScope = "repo:write"
+	AccessTokenScopeIssue     AccessTokenScope = "issue"
+	AccessTokenScopeAdmin     AccessTokenScope = "admin"
+	AccessTokenScopeOrg       AccessTokenScope = "org"
+	AccessTokenScopePackage   AccessTokenScope = "package"
+	AccessTokenScopeUser      AccessTokenScope = "user"
+)
+
+// AllAccessTokenScopes contains all the scopes which can be granted to an access token
+var AllAccessTokenScopes = []AccessTokenScope{
+	AccessTokenScopeAll,
+	AccessTokenScopeRepoRead,
+	AccessTokenScopeRepoWrite,
+	AccessTokenScopeIssue,
+	AccessTokenScopeAdmin,
+	AccessTokenScopeOrg,
+	AccessTokenScopePackage,
+	AccessTokenScopeUser,
+}
+
+// ErrAccessTokenInvalidScope represents an unknown access token scope
+type ErrAccessTokenInvalidScope struct {
+	Scope string
+}
+
+// IsErrAccessTokenInvalidScope checks if an error is a ErrAccessTokenInvalidScope.
+func IsErrAccessTokenInvalidScope(err error) bool {
+	_, ok := err.(ErrAccessTokenInvalidScope)
+	return ok
+}
+
+func (err ErrAccessTokenInvalidScope) Error() string {
+	return fmt.Sprintf("invalid access token scope [scope: %s]", err.Scope)
+}
+
+// ParseAccessTokenScope validates the given scopes and joins them into an AccessTokenScope.
+// No scope at all grants all the scopes.
+func ParseAccessTokenScope(scopes []string) (AccessTokenScope, error) {
+	parsed := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if scope == "" {
+			continue
+		}
+		valid := false
+		for _, s := range AllAccessTokenScopes {
+			if string(s) == scope {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return "", ErrAccessTokenInvalidScope{scope}
+		}
+
+		duplicate := false
+		for _, s := range parsed {
+			if s == scope {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			parsed = append(parsed, scope)
+		}
+	}
+	if len(parsed) == 0 {
+		return AccessTokenScopeAll, nil
+	}
+	return AccessTokenScope(strings.Join(parsed, ",")), nil
+}
+
+// StringSlice returns the scopes as a slice of strings
+func (s AccessTokenScope) StringSlice() []string {
+	if s == "" {
+		return []string{string(AccessTokenScopeAll)}
+	}
+	return strings.Split(string(s), ",")
+}
+
+// Has returns true if the scope grants the given scope. Tokens created before
+// scopes were introduced have an empty scope and are granted all the scopes.
+func (s AccessTokenScope) Has(scope AccessTokenScope) bool {
+	for _, granted := range s.StringSlice() {
+		switch AccessTokenScope(granted) {
+		case AccessTokenScopeAll, scope:
+			return true
+		case AccessTokenScopeRepoWrite:
+			if scope == AccessTokenScopeRepoRead {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// LocaleKey returns the suffix of the locale key describing the scope
+func (s AccessTokenScope) LocaleKey() string {
+	return strings.Replace(string(s), ":", "_", -1)
+}
+
+// HasAny returns true if the scope grants at least one of the given scopes
+func (s AccessTokenScope) HasAny(scopes ...AccessTokenScope) bool {
+	for _, scope := range scopes {
+		if s.Has(scope) {
+			return true
+		}
+	}
+	return false
+}
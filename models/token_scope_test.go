@@ -0,0 +1,44 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAccessTokenScope(t *testing.T) {
+	scope, err := ParseAccessTokenScope(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, AccessTokenScopeAll, scope)
+
+	scope, err = ParseAccessTokenScope([]string{"repo:read", " Issue", "repo:read", ""})
+	assert.NoError(t, err)
+	assert.Equal(t, AccessTokenScope("repo:read,issue"), scope)
+	assert.Equal(t, []string{"repo:read", "issue"}, scope.StringSlice())
+
+	_, err = ParseAccessTokenScope([]string{"repo:delete"})
+	assert.True(t, IsErrAccessTokenInvalidScope(err))
+}
+
+func TestAccessTokenScope_Has(t *testing.T) {
+	for _, scope := range AllAccessTokenScopes {
+		assert.True(t, AccessTokenScope("").Has(scope))
+		assert.True(t, AccessTokenScopeAll.Has(scope))
+	}
+
+	scope := AccessTokenScope("repo:write,org")
+	assert.True(t, scope.Has(AccessTokenScopeRepoRead))
+	assert.True(t, scope.Has(AccessTokenScopeRepoWrite))
+	assert.True(t, scope.Has(AccessTokenScopeOrg))
+	assert.False(t, scope.Has(AccessTokenScopeAdmin))
+	assert.False(t, scope.Has(AccessTokenScopeIssue))
+	assert.True(t, scope.HasAny(AccessTokenScopeIssue, AccessTokenScopeOrg))
+
+	scope = AccessTokenScopeRepoRead
+	assert.False(t, scope.Has(AccessTokenScopeRepoWrite))
+	assert.Equal(t, "repo_read", scope.LocaleKey())
+}
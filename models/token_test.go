@@ -7,6 +7,8 @@ package models
 import (
 	"testing"
 
+	"code.gitea.io/gitea/modules/timeutil"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -79,6 +81,35 @@ func TestGetAccessTokenBySHA(t *testing.T) {
 	_, err = GetAccessTokenBySHA("")
 	assert.Error(t, err)
 	assert.True(t, IsErrAccessTokenEmpty(err))
+
+	token.ExpiresUnix = timeutil.TimeStampNow().Add(-60)
+	assert.NoError(t, UpdateAccessToken(token))
+	_, err = GetAccessTokenBySHA("d2c6c1ba3890b309189a8e618c72a162e4efbf36")
+	assert.True(t, IsErrAccessTokenNotExist(err))
+}
+
+func TestResolveAccessTokenRepositories(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	user2 := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	user4 := AssertExistsAndLoadBean(t, &User{ID: 4}).(*User)
+
+	ids, err := ResolveAccessTokenRepositories(user2, []string{"user2/repo1", " user2/repo2", "user2/repo1", ""})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, ids)
+
+	// user4 has no access to the private repo2
+	_, err = ResolveAccessTokenRepositories(user4, []string{"user2/repo2"})
+	assert.True(t, IsErrRepoNotExist(err))
+
+	_, err = ResolveAccessTokenRepositories(user2, []string{"repo1"})
+	assert.True(t, IsErrRepoNotExist(err))
+
+	token := &AccessToken{RepoIDs: ids}
+	assert.True(t, token.CanAccessRepo(2))
+	assert.False(t, token.CanAccessRepo(3))
+	names, err := token.RepositoryFullNames()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user2/repo1", "user2/repo2"}, names)
 }
 
 func TestListAccessTokens(t *testing.T) {
@@ -24,6 +24,8 @@ const (
 	UnitTypeWiki                                // 5 Wiki
 	UnitTypeExternalWiki                        // 6 ExternalWiki
 	UnitTypeExternalTracker                     // 7 ExternalTracker
+	UnitTypeProjects                            // 8 Kanban board
+	UnitTypeActions                             // 9 Actions
 )
 
 // Value returns integer value for unit type
@@ -47,6 +49,10 @@ func (u UnitType) String() string {
 		return "UnitTypeExternalWiki"
 	case UnitTypeExternalTracker:
 		return "UnitTypeExternalTracker"
+	case UnitTypeProjects:
+		return "UnitTypeProjects"
+	case UnitTypeActions:
+		return "UnitTypeActions"
 	}
 	return fmt.Sprintf("Unknown UnitType %d", u)
 }
@@ -68,6 +74,8 @@ var (
 		UnitTypeWiki,
 		UnitTypeExternalWiki,
 		UnitTypeExternalTracker,
+		UnitTypeProjects,
+		UnitTypeActions,
 	}
 
 	// DefaultRepoUnits contains the default unit types
@@ -77,6 +85,7 @@ var (
 		UnitTypePullRequests,
 		UnitTypeReleases,
 		UnitTypeWiki,
+		UnitTypeProjects,
 	}
 
 	// NotAllowedDefaultRepoUnits contains units that can't be default
@@ -242,6 +251,22 @@ var (
 		4,
 	}
 
+	UnitProjects = Unit{
+		UnitTypeProjects,
+		"repo.projects",
+		"/projects",
+		"repo.projects.desc",
+		5,
+	}
+
+	UnitActions = Unit{
+		UnitTypeActions,
+		"repo.actions",
+		"/actions",
+		"repo.actions.desc",
+		6,
+	}
+
 	// Units contains all the units
 	Units = map[UnitType]Unit{
 		UnitTypeCode:            UnitCode,
@@ -251,6 +276,8 @@ var (
 		UnitTypeReleases:        UnitReleases,
 		UnitTypeWiki:            UnitWiki,
 		UnitTypeExternalWiki:    UnitExternalWiki,
+		UnitTypeProjects:        UnitProjects,
+		UnitTypeActions:         UnitActions,
 	}
 )
 
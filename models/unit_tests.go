@@ -16,6 +16,7 @@ import (
 
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/unknwon/com"
@@ -67,6 +68,14 @@ func MainTest(m *testing.M, pathToGiteaRoot string) {
 		fatalTestError("url.Parse: %v\n", err)
 	}
 
+	setting.AttachmentStorage = setting.Storage{Type: setting.LocalStorageType, Path: filepath.Join(setting.AppDataPath, "attachments")}
+	setting.LFS.Storage = setting.Storage{Type: setting.LocalStorageType, Path: filepath.Join(setting.AppDataPath, "lfs")}
+	setting.AvatarStorage = setting.Storage{Type: setting.LocalStorageType, Path: filepath.Join(setting.AppDataPath, "avatars")}
+	setting.RepositoryAvatarStorage = setting.Storage{Type: setting.LocalStorageType, Path: filepath.Join(setting.AppDataPath, "repo-avatars")}
+	if err = storage.Init(); err != nil {
+		fatalTestError("storage.Init: %v\n", err)
+	}
+
 	if err = removeAllWithRetry(setting.RepoRootPath); err != nil {
 		fatalTestError("os.RemoveAll: %v\n", err)
 	}
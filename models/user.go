@@ -16,6 +16,7 @@ import (
 	"fmt"
 	_ "image/jpeg" // Needed for jpeg support
 	"image/png"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -31,6 +32,7 @@ import (
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/public"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 	"code.gitea.io/gitea/modules/structs"
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/timeutil"
@@ -347,9 +349,18 @@ func (u *User) GenerateActivateCode() string {
 	return u.GenerateEmailActivateCode(u.Email)
 }
 
-// CustomAvatarPath returns user custom avatar file path.
-func (u *User) CustomAvatarPath() string {
-	return filepath.Join(setting.AvatarUploadPath, u.Avatar)
+// CustomAvatarRelativePath returns user custom avatar relative path.
+func (u *User) CustomAvatarRelativePath() string {
+	return u.Avatar
+}
+
+// HasCustomAvatarFile returns true if the avatar file of the user exists in the avatars storage.
+func (u *User) HasCustomAvatarFile() bool {
+	if len(u.Avatar) == 0 {
+		return false
+	}
+	_, err := storage.Avatars.Stat(u.CustomAvatarRelativePath())
+	return err == nil
 }
 
 // GenerateRandomAvatar generates a random avatar for user.
@@ -372,21 +383,18 @@ func (u *User) generateRandomAvatar(e Engine) error {
 	if u.Avatar == "" {
 		u.Avatar = fmt.Sprintf("%d", u.ID)
 	}
-	if err = os.MkdirAll(filepath.Dir(u.CustomAvatarPath()), os.ModePerm); err != nil {
-		return fmt.Errorf("MkdirAll: %v", err)
-	}
-	fw, err := os.Create(u.CustomAvatarPath())
-	if err != nil {
-		return fmt.Errorf("Create: %v", err)
-	}
-	defer fw.Close()
 
-	if _, err := e.ID(u.ID).Cols("avatar").Update(u); err != nil {
+	if err := storage.SaveFrom(storage.Avatars, u.CustomAvatarRelativePath(), func(w io.Writer) error {
+		if err := png.Encode(w, img); err != nil {
+			log.Error("Encode: %v", err)
+		}
 		return err
+	}); err != nil {
+		return fmt.Errorf("Failed to create avatar %s: %v", u.CustomAvatarRelativePath(), err)
 	}
 
-	if err = png.Encode(fw, img); err != nil {
-		return fmt.Errorf("Encode: %v", err)
+	if _, err := e.ID(u.ID).Cols("avatar").Update(u); err != nil {
+		return err
 	}
 
 	log.Info("New random avatar created: %d", u.ID)
@@ -413,12 +421,12 @@ func (u *User) RealSizedAvatarLink(size int) string {
 
 	switch {
 	case u.UseCustomAvatar:
-		if !com.IsFile(u.CustomAvatarPath()) {
+		if !u.HasCustomAvatarFile() {
 			return base.DefaultAvatarLink()
 		}
 		return setting.AppSubURL + "/avatars/" + u.Avatar
 	case setting.DisableGravatar, setting.OfflineMode:
-		if !com.IsFile(u.CustomAvatarPath()) {
+		if !u.HasCustomAvatarFile() {
 			if err := u.GenerateRandomAvatar(); err != nil {
 				log.Error("GenerateRandomAvatar: %v", err)
 			}
@@ -561,18 +569,13 @@ func (u *User) UploadAvatar(data []byte) error {
 		return fmt.Errorf("updateUser: %v", err)
 	}
 
-	if err := os.MkdirAll(setting.AvatarUploadPath, os.ModePerm); err != nil {
-		return fmt.Errorf("Failed to create dir %s: %v", setting.AvatarUploadPath, err)
-	}
-
-	fw, err := os.Create(u.CustomAvatarPath())
-	if err != nil {
-		return fmt.Errorf("Create: %v", err)
-	}
-	defer fw.Close()
-
-	if err = png.Encode(fw, *m); err != nil {
-		return fmt.Errorf("Encode: %v", err)
+	if err := storage.SaveFrom(storage.Avatars, u.CustomAvatarRelativePath(), func(w io.Writer) error {
+		if err := png.Encode(w, *m); err != nil {
+			log.Error("Encode: %v", err)
+		}
+		return err
+	}); err != nil {
+		return fmt.Errorf("Failed to create avatar %s: %v", u.CustomAvatarRelativePath(), err)
 	}
 
 	return sess.Commit()
@@ -580,10 +583,10 @@ func (u *User) UploadAvatar(data []byte) error {
 
 // DeleteAvatar deletes the user's custom avatar.
 func (u *User) DeleteAvatar() error {
-	log.Trace("DeleteAvatar[%d]: %s", u.ID, u.CustomAvatarPath())
+	log.Trace("DeleteAvatar[%d]: %s", u.ID, u.CustomAvatarRelativePath())
 	if len(u.Avatar) > 0 {
-		if err := os.Remove(u.CustomAvatarPath()); err != nil {
-			return fmt.Errorf("Failed to remove %s: %v", u.CustomAvatarPath(), err)
+		if err := storage.Avatars.Delete(u.CustomAvatarRelativePath()); err != nil {
+			return fmt.Errorf("Failed to remove %s: %v", u.CustomAvatarRelativePath(), err)
 		}
 	}
 
@@ -956,17 +959,25 @@ func IsUsableUsername(name string) error {
 
 // CreateUser creates record of a new user.
 func CreateUser(u *User) (err error) {
-	if err = IsUsableUsername(u.Name); err != nil {
-		return err
-	}
-
 	sess := x.NewSession()
 	defer sess.Close()
 	if err = sess.Begin(); err != nil {
 		return err
 	}
 
-	isExist, err := isUserExist(sess, 0, u.Name)
+	if err = createUser(sess, u); err != nil {
+		return err
+	}
+
+	return sess.Commit()
+}
+
+func createUser(e Engine, u *User) (err error) {
+	if err = IsUsableUsername(u.Name); err != nil {
+		return err
+	}
+
+	isExist, err := isUserExist(e, 0, u.Name)
 	if err != nil {
 		return err
 	} else if isExist {
@@ -974,7 +985,7 @@ func CreateUser(u *User) (err error) {
 	}
 
 	u.Email = strings.ToLower(u.Email)
-	isExist, err = sess.
+	isExist, err = e.
 		Where("email=?", u.Email).
 		Get(new(User))
 	if err != nil {
@@ -983,7 +994,7 @@ func CreateUser(u *User) (err error) {
 		return ErrEmailAlreadyUsed{u.Email}
 	}
 
-	isExist, err = isEmailUsed(sess, u.Email)
+	isExist, err = isEmailUsed(e, u.Email)
 	if err != nil {
 		return err
 	} else if isExist {
@@ -1007,11 +1018,8 @@ func CreateUser(u *User) (err error) {
 	u.MaxRepoCreation = -1
 	u.Theme = setting.UI.DefaultTheme
 
-	if _, err = sess.Insert(u); err != nil {
-		return err
-	}
-
-	return sess.Commit()
+	_, err = e.Insert(u)
+	return err
 }
 
 func countUsers(e Engine) int64 {
@@ -1242,6 +1250,7 @@ func deleteUser(e *xorm.Session, u *User) error {
 		&TeamUser{UID: u.ID},
 		&Collaboration{UserID: u.ID},
 		&Stopwatch{UserID: u.ID},
+		&WebAuthnCredential{UserID: u.ID},
 	); err != nil {
 		return fmt.Errorf("deleteBeans: %v", err)
 	}
@@ -1287,11 +1296,9 @@ func deleteUser(e *xorm.Session, u *User) error {
 	}
 
 	if len(u.Avatar) > 0 {
-		avatarPath := u.CustomAvatarPath()
-		if com.IsExist(avatarPath) {
-			if err := os.Remove(avatarPath); err != nil {
-				return fmt.Errorf("Failed to remove %s: %v", avatarPath, err)
-			}
+		avatarPath := u.CustomAvatarRelativePath()
+		if err := storage.Avatars.Delete(avatarPath); err != nil && !storage.IsNotExist(err) {
+			return fmt.Errorf("Failed to remove %s: %v", avatarPath, err)
 		}
 	}
 
@@ -1311,12 +1318,21 @@ func DeleteUser(u *User) (err error) {
 		return err
 	}
 
+	blobPaths, err := deletePackagesByOwnerID(sess, u.ID)
+	if err != nil {
+		return fmt.Errorf("deletePackagesByOwnerID: %v", err)
+	}
+
 	if err = deleteUser(sess, u); err != nil {
 		// Note: don't wrapper error here.
 		return err
 	}
 
-	return sess.Commit()
+	if err = sess.Commit(); err != nil {
+		return err
+	}
+	removePackageBlobs(blobPaths)
+	return nil
 }
 
 // DeleteInactiveUsers deletes all inactive users and email addresses.
@@ -1875,6 +1891,11 @@ func SyncExternalUsers(ctx context.Context, updateExisting bool) error {
 				continue
 			}
 
+			groupTeamMapping, err := ParseGroupTeamMapping(s.LDAP().GroupTeamMap)
+			if err != nil {
+				log.Error("SyncExternalUsers[%s]: Invalid group to team mapping, teams are not synchronized: %v", s.Name, err)
+			}
+
 			if len(sr) == 0 {
 				if !s.LDAP().AllowDeactivateAll {
 					log.Error("LDAP search found no entries but did not report an error. Refusing to deactivate all users")
@@ -1916,6 +1937,7 @@ func SyncExternalUsers(ctx context.Context, updateExisting bool) error {
 				}
 
 				fullName := composeFullName(su.Name, su.Surname, su.Username)
+				isNew := usr == nil
 				// If no existing user found, create one
 				if usr == nil {
 					log.Trace("SyncExternalUsers[%s]: Creating user %s", s.Name, su.Username)
@@ -1978,6 +2000,13 @@ func SyncExternalUsers(ctx context.Context, updateExisting bool) error {
 						}
 					}
 				}
+
+				// Mirror the directory groups of the user to the mapped teams
+				if usr.ID > 0 && (isNew || updateExisting) {
+					if err := SyncGroupTeams(usr, su.Groups, groupTeamMapping, true); err != nil {
+						log.Error("SyncExternalUsers[%s]: Error synchronizing teams of user %s: %v", s.Name, usr.Name, err)
+					}
+				}
 			}
 
 			// Rewrite authorized_keys file if LDAP Public SSH Key attribute is set and any key was added or removed
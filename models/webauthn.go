@@ -0,0 +1,157 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"strings"
+
+	"code.gitea.io/gitea/modules/auth/webauthn"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+// WebAuthnCredential represents a WebAuthn credential of a user, either a security
+// key or a platform authenticator
+type WebAuthnCredential struct {
+	ID           int64 `xorm:"pk autoincr"`
+	Name         string
+	LowerName    string `xorm:"unique(s)"`
+	UserID       int64  `xorm:"INDEX unique(s)"`
+	CredentialID string `xorm:"UNIQUE VARCHAR(410)"` // base64url encoded raw ID
+	PublicKey    []byte // COSE encoded public key
+	AAGUID       []byte
+	SignCount    uint32 `xorm:"BIGINT"`
+	// LegacyU2F is set for security keys which were registered with the U2F API
+	LegacyU2F bool `xorm:"legacy_u2f NOT NULL DEFAULT false"`
+	// Passwordless credentials are discoverable and can sign in without a password
+	Passwordless bool               `xorm:"NOT NULL DEFAULT false"`
+	CreatedUnix  timeutil.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix  timeutil.TimeStamp `xorm:"INDEX updated"`
+}
+
+// TableName returns a better table name for WebAuthnCredential
+func (cred WebAuthnCredential) TableName() string {
+	return "webauthn_credential"
+}
+
+// Credential returns the credential as it is verified by the webauthn module
+func (cred *WebAuthnCredential) Credential() (*webauthn.Credential, error) {
+	id, err := webauthn.DecodeString(cred.CredentialID)
+	if err != nil {
+		return nil, err
+	}
+	return &webauthn.Credential{
+		ID:        id,
+		PublicKey: cred.PublicKey,
+		AAGUID:    cred.AAGUID,
+		SignCount: cred.SignCount,
+		LegacyU2F: cred.LegacyU2F,
+	}, nil
+}
+
+// UpdateSignCount will update the database value of the signature counter
+func (cred *WebAuthnCredential) UpdateSignCount() error {
+	_, err := x.ID(cred.ID).Cols("sign_count").Update(cred)
+	return err
+}
+
+// WebAuthnCredentialList is a list of *WebAuthnCredential
+type WebAuthnCredentialList []*WebAuthnCredential
+
+// Credentials returns the credentials as they are verified by the webauthn module
+func (list WebAuthnCredentialList) Credentials() ([]*webauthn.Credential, error) {
+	creds := make([]*webauthn.Credential, 0, len(list))
+	for _, cred := range list {
+		c, err := cred.Credential()
+		if err != nil {
+			return nil, err
+		}
+		creds = append(creds, c)
+	}
+	return creds, nil
+}
+
+// GetWebAuthnCredentialsByUID returns all WebAuthn credentials of the given user
+func GetWebAuthnCredentialsByUID(uid int64) (WebAuthnCredentialList, error) {
+	creds := make(WebAuthnCredentialList, 0)
+	return creds, x.Where("user_id = ?", uid).Find(&creds)
+}
+
+// HasWebAuthnCredentialsByUID returns true if the given user has WebAuthn credentials
+func HasWebAuthnCredentialsByUID(uid int64) (bool, error) {
+	return x.Where("user_id = ?", uid).Exist(new(WebAuthnCredential))
+}
+
+// ExistWebAuthnCredentialName returns true if the user has a credential with the name
+func ExistWebAuthnCredentialName(uid int64, name string) (bool, error) {
+	return x.Where("user_id = ? AND lower_name = ?", uid, strings.ToLower(name)).Exist(new(WebAuthnCredential))
+}
+
+// GetWebAuthnCredentialByCredID returns the WebAuthn credential with the raw ID,
+// which must belong to the user unless uid is 0
+func GetWebAuthnCredentialByCredID(uid int64, credID []byte) (*WebAuthnCredential, error) {
+	cond := builder.Eq{"credential_id": webauthn.EncodeToString(credID)}
+	if uid != 0 {
+		cond["user_id"] = uid
+	}
+	cred := new(WebAuthnCredential)
+	if found, err := x.Where(cond).Get(cred); err != nil {
+		return nil, err
+	} else if !found {
+		return nil, ErrWebAuthnCredentialNotExist{CredentialID: webauthn.EncodeToString(credID)}
+	}
+	return cred, nil
+}
+
+// CreateWebAuthnCredential creates a new WebAuthn credential of the user
+func CreateWebAuthnCredential(user *User, name string, passwordless bool, c *webauthn.Credential) (*WebAuthnCredential, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	cred := &WebAuthnCredential{
+		Name:         name,
+		LowerName:    strings.ToLower(name),
+		UserID:       user.ID,
+		CredentialID: webauthn.EncodeToString(c.ID),
+		PublicKey:    c.PublicKey,
+		AAGUID:       c.AAGUID,
+		SignCount:    c.SignCount,
+		Passwordless: passwordless,
+	}
+	// a credential can only be registered once, whichever user registered it
+	if exist, err := sess.Where("credential_id = ?", cred.CredentialID).Exist(new(WebAuthnCredential)); err != nil {
+		return nil, err
+	} else if exist {
+		return nil, ErrWebAuthnCredentialAlreadyExist{CredentialID: cred.CredentialID}
+	}
+	if _, err := sess.InsertOne(cred); err != nil {
+		return nil, err
+	}
+	return cred, sess.Commit()
+}
+
+// DeleteWebAuthnCredential deletes the WebAuthn credential with the ID of the user
+func DeleteWebAuthnCredential(id, userID int64) (*WebAuthnCredential, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	cred := new(WebAuthnCredential)
+	if found, err := sess.Where("id = ? AND user_id = ?", id, userID).Get(cred); err != nil {
+		return nil, err
+	} else if !found {
+		return nil, ErrWebAuthnCredentialNotExist{ID: id}
+	}
+	if _, err := sess.ID(cred.ID).Delete(new(WebAuthnCredential)); err != nil {
+		return nil, err
+	}
+	return cred, sess.Commit()
+}
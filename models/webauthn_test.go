@@ -0,0 +1,93 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/modules/auth/webauthn"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetWebAuthnCredentialByCredID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	res, err := GetWebAuthnCredentialByCredID(0, []byte("credential-id"))
+	assert.NoError(t, err)
+	assert.Equal(t, "WebAuthn Key", res.Name)
+
+	res, err = GetWebAuthnCredentialByCredID(1, []byte("credential-id"))
+	assert.NoError(t, err)
+	assert.Equal(t, "WebAuthn Key", res.Name)
+
+	_, err = GetWebAuthnCredentialByCredID(2, []byte("credential-id"))
+	assert.True(t, IsErrWebAuthnCredentialNotExist(err))
+
+	_, err = GetWebAuthnCredentialByCredID(0, []byte("unknown"))
+	assert.Error(t, err)
+	assert.True(t, IsErrWebAuthnCredentialNotExist(err))
+}
+
+func TestGetWebAuthnCredentialsByUID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	res, err := GetWebAuthnCredentialsByUID(1)
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+	assert.Equal(t, "WebAuthn Key", res[0].Name)
+
+	creds, err := res.Credentials()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("credential-id"), creds[0].ID)
+
+	has, err := HasWebAuthnCredentialsByUID(1)
+	assert.NoError(t, err)
+	assert.True(t, has)
+	has, err = HasWebAuthnCredentialsByUID(2)
+	assert.NoError(t, err)
+	assert.False(t, has)
+}
+
+func TestWebAuthnCredential_UpdateLargeSignCount(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	cred := AssertExistsAndLoadBean(t, &WebAuthnCredential{ID: 1}).(*WebAuthnCredential)
+	cred.SignCount = 0xffffffff
+	assert.NoError(t, cred.UpdateSignCount())
+	AssertExistsIf(t, true, &WebAuthnCredential{ID: 1, SignCount: 0xffffffff})
+}
+
+func TestCreateWebAuthnCredential(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	user := AssertExistsAndLoadBean(t, &User{ID: 1}).(*User)
+
+	res, err := CreateWebAuthnCredential(user, "WebAuthn Created Key", true, &webauthn.Credential{ID: []byte("Test"), PublicKey: []byte("Key")})
+	assert.NoError(t, err)
+	assert.Equal(t, "webauthn created key", res.LowerName)
+	assert.Equal(t, []byte("Key"), res.PublicKey)
+
+	AssertExistsIf(t, true, &WebAuthnCredential{Name: "WebAuthn Created Key", UserID: user.ID, Passwordless: true})
+
+	exist, err := ExistWebAuthnCredentialName(user.ID, "webauthn CREATED key")
+	assert.NoError(t, err)
+	assert.True(t, exist)
+
+	// the same credential can't be registered again, not even by another user
+	other := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	_, err = CreateWebAuthnCredential(other, "Same Key", false, &webauthn.Credential{ID: []byte("Test"), PublicKey: []byte("Key")})
+	assert.True(t, IsErrWebAuthnCredentialAlreadyExist(err))
+}
+
+func TestDeleteWebAuthnCredential(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	_, err := DeleteWebAuthnCredential(1, 2)
+	assert.True(t, IsErrWebAuthnCredentialNotExist(err))
+
+	cred, err := DeleteWebAuthnCredential(1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "WebAuthn Key", cred.Name)
+	AssertNotExistsBean(t, &WebAuthnCredential{ID: 1})
+}
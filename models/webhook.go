@@ -55,6 +55,27 @@ func IsValidHookContentType(name string) bool {
 	return ok
 }
 
+// HookSignatureAlgorithm is the hash function web hook payloads are signed with
+type HookSignatureAlgorithm string
+
+const (
+	// HookSignatureSHA1 signs web hook payloads with HMAC-SHA1
+	HookSignatureSHA1 HookSignatureAlgorithm = "sha1"
+	// HookSignatureSHA256 signs web hook payloads with HMAC-SHA256
+	HookSignatureSHA256 HookSignatureAlgorithm = "sha256"
+	// HookSignatureSHA512 signs web hook payloads with HMAC-SHA512
+	HookSignatureSHA512 HookSignatureAlgorithm = "sha512"
+)
+
+// IsValidHookSignatureAlgorithm returns true if given name is a valid hook signature algorithm.
+func IsValidHookSignatureAlgorithm(name string) bool {
+	switch HookSignatureAlgorithm(name) {
+	case HookSignatureSHA1, HookSignatureSHA256, HookSignatureSHA512:
+		return true
+	}
+	return false
+}
+
 // HookEvents is a set of web hook events
 type HookEvents struct {
 	Create               bool `json:"create"`
@@ -75,6 +96,8 @@ type HookEvents struct {
 	PullRequestSync      bool `json:"pull_request_sync"`
 	Repository           bool `json:"repository"`
 	Release              bool `json:"release"`
+	User                 bool `json:"user"`
+	Organization         bool `json:"organization"`
 }
 
 // HookEvent represents events that will delivery hook.
@@ -116,6 +139,13 @@ type Webhook struct {
 	Meta            string     `xorm:"TEXT"` // store hook-specific attributes
 	LastStatus      HookStatus // Last delivery status
 
+	// OldSecret is the secret before the last change, it stays valid until
+	// OldSecretExpiresUnix so receivers can be switched to the new one.
+	OldSecret            string `xorm:"TEXT"`
+	OldSecretExpiresUnix timeutil.TimeStamp
+	SignatureAlgorithm   HookSignatureAlgorithm
+	SignTimestamp        bool `xorm:"NOT NULL DEFAULT false"`
+
 	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
 	UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
 }
@@ -128,6 +158,47 @@ func (w *Webhook) AfterLoad() {
 	}
 }
 
+// SetSecret changes the secret of the webhook, the previous secret stays valid
+// for the configured rotation period.
+func (w *Webhook) SetSecret(secret string) {
+	if secret == w.Secret {
+		return
+	}
+	if len(w.Secret) > 0 && setting.Webhook.SecretRotationPeriod > 0 {
+		w.OldSecret = w.Secret
+		w.OldSecretExpiresUnix = timeutil.TimeStampNow().AddDuration(setting.Webhook.SecretRotationPeriod)
+	} else {
+		w.OldSecret = ""
+		w.OldSecretExpiresUnix = 0
+	}
+	w.Secret = secret
+}
+
+// HasValidOldSecret returns true if payloads are signed with the previous secret as well
+func (w *Webhook) HasValidOldSecret() bool {
+	return len(w.OldSecret) > 0 && w.OldSecretExpiresUnix > timeutil.TimeStampNow()
+}
+
+// Secrets returns the secrets payloads are signed with, the current secret comes first.
+func (w *Webhook) Secrets() []string {
+	var secrets []string
+	if len(w.Secret) > 0 {
+		secrets = append(secrets, w.Secret)
+	}
+	if w.HasValidOldSecret() {
+		secrets = append(secrets, w.OldSecret)
+	}
+	return secrets
+}
+
+// SignatureHash returns the signature algorithm of the webhook, it defaults to sha256
+func (w *Webhook) SignatureHash() HookSignatureAlgorithm {
+	if IsValidHookSignatureAlgorithm(string(w.SignatureAlgorithm)) {
+		return w.SignatureAlgorithm
+	}
+	return HookSignatureSHA256
+}
+
 // History returns history of webhook by given conditions.
 func (w *Webhook) History(page int) ([]*HookTask, error) {
 	return HookTasks(w.ID, page)
@@ -260,6 +331,18 @@ func (w *Webhook) HasRepositoryEvent() bool {
 		(w.ChooseEvents && w.HookEvents.Repository)
 }
 
+// HasUserEvent returns if hook enabled user event.
+func (w *Webhook) HasUserEvent() bool {
+	return w.SendEverything ||
+		(w.ChooseEvents && w.HookEvents.User)
+}
+
+// HasOrganizationEvent returns if hook enabled organization event.
+func (w *Webhook) HasOrganizationEvent() bool {
+	return w.SendEverything ||
+		(w.ChooseEvents && w.HookEvents.Organization)
+}
+
 // EventCheckers returns event checkers
 func (w *Webhook) EventCheckers() []struct {
 	Has  func() bool
@@ -289,6 +372,8 @@ func (w *Webhook) EventCheckers() []struct {
 		{w.HasPullRequestSyncEvent, HookEventPullRequestSync},
 		{w.HasRepositoryEvent, HookEventRepository},
 		{w.HasReleaseEvent, HookEventRelease},
+		{w.HasUserEvent, HookEventUser},
+		{w.HasOrganizationEvent, HookEventOrganization},
 	}
 }
 
@@ -450,6 +535,15 @@ func getSystemWebhooks(e Engine) ([]*Webhook, error) {
 		Find(&webhooks)
 }
 
+// GetActiveSystemWebhooks returns all active admin system webhooks.
+func GetActiveSystemWebhooks() ([]*Webhook, error) {
+	webhooks := make([]*Webhook, 0, 5)
+	return webhooks, x.
+		Where("repo_id=? AND org_id=? AND is_system_webhook=?", 0, 0, true).
+		And("is_active=?", true).
+		Find(&webhooks)
+}
+
 // UpdateWebhook updates information of webhook.
 func UpdateWebhook(w *Webhook) error {
 	_, err := x.ID(w.ID).AllCols().Update(w)
@@ -560,6 +654,7 @@ const (
 	MSTEAMS
 	FEISHU
 	MATRIX
+	CUSTOM
 )
 
 var hookTaskTypes = map[string]HookTaskType{
@@ -572,6 +667,7 @@ var hookTaskTypes = map[string]HookTaskType{
 	"msteams":  MSTEAMS,
 	"feishu":   FEISHU,
 	"matrix":   MATRIX,
+	"custom":   CUSTOM,
 }
 
 // ToHookTaskType returns HookTaskType by given name.
@@ -600,6 +696,8 @@ func (t HookTaskType) Name() string {
 		return "feishu"
 	case MATRIX:
 		return "matrix"
+	case CUSTOM:
+		return "custom"
 	}
 	return ""
 }
@@ -635,6 +733,8 @@ const (
 	HookEventPullRequestSync           HookEventType = "pull_request_sync"
 	HookEventRepository                HookEventType = "repository"
 	HookEventRelease                   HookEventType = "release"
+	HookEventUser                      HookEventType = "user"
+	HookEventOrganization              HookEventType = "organization"
 )
 
 // Event returns the HookEventType as an event string
@@ -665,6 +765,10 @@ func (h HookEventType) Event() string {
 		return "repository"
 	case HookEventRelease:
 		return "release"
+	case HookEventUser:
+		return "user"
+	case HookEventOrganization:
+		return "organization"
 	}
 	return ""
 }
@@ -700,6 +804,11 @@ type HookTask struct {
 	Delivered       int64
 	DeliveredString string `xorm:"-"`
 
+	// Retry info, a failed task which is not delivered yet is attempted
+	// again at the next attempt time.
+	Attempts        int                `xorm:"NOT NULL DEFAULT 0"`
+	NextAttemptUnix timeutil.TimeStamp `xorm:"INDEX NOT NULL DEFAULT 0"`
+
 	// History info.
 	IsSucceed       bool
 	RequestContent  string        `xorm:"TEXT"`
@@ -758,6 +867,15 @@ func HookTasks(hookID int64, page int) ([]*HookTask, error) {
 		Find(&tasks)
 }
 
+// GetHookTasksByHookID returns the hook tasks of the webhook, the latest first.
+func GetHookTasksByHookID(hookID int64, listOptions ListOptions) ([]*HookTask, error) {
+	tasks := make([]*HookTask, 0, listOptions.PageSize)
+	return tasks, listOptions.getPaginatedSession().
+		Where("hook_id=?", hookID).
+		Desc("id").
+		Find(&tasks)
+}
+
 // CreateHookTask creates a new hook task,
 // it handles conversion from Payload to PayloadContent.
 func CreateHookTask(t *HookTask) error {
@@ -765,16 +883,43 @@ func CreateHookTask(t *HookTask) error {
 }
 
 func createHookTask(e Engine, t *HookTask) error {
-	data, err := t.Payloader.JSONPayload()
-	if err != nil {
-		return err
+	// a redelivered task has no payloader but the content of the original task
+	if t.Payloader != nil {
+		data, err := t.Payloader.JSONPayload()
+		if err != nil {
+			return err
+		}
+		t.PayloadContent = string(data)
 	}
 	t.UUID = gouuid.New().String()
-	t.PayloadContent = string(data)
-	_, err = e.Insert(t)
+	_, err := e.Insert(t)
 	return err
 }
 
+// GetHookTaskByID returns the hook task by given ID.
+func GetHookTaskByID(id int64) (*HookTask, error) {
+	t := new(HookTask)
+	has, err := x.ID(id).Get(t)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrHookTaskNotExist{ID: id}
+	}
+	return t, nil
+}
+
+// GetHookTaskByHookID returns the hook task of the webhook by given ID.
+func GetHookTaskByHookID(hookID, id int64) (*HookTask, error) {
+	t := &HookTask{ID: id, HookID: hookID}
+	has, err := x.Get(t)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrHookTaskNotExist{ID: id, HookID: hookID}
+	}
+	return t, nil
+}
+
 // UpdateHookTask updates information of hook task.
 func UpdateHookTask(t *HookTask) error {
 	_, err := x.ID(t.ID).AllCols().Update(t)
@@ -789,12 +934,3 @@ func FindUndeliveredHookTasks() ([]*HookTask, error) {
 	}
 	return tasks, nil
 }
-
-// FindRepoUndeliveredHookTasks represents find the undelivered hook tasks of one repository
-func FindRepoUndeliveredHookTasks(repoID int64) ([]*HookTask, error) {
-	tasks := make([]*HookTask, 0, 5)
-	if err := x.Where("repo_id=? AND is_delivered=?", repoID, false).Find(&tasks); err != nil {
-		return nil, err
-	}
-	return tasks, nil
-}
@@ -7,8 +7,11 @@ package models
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
+	"code.gitea.io/gitea/modules/setting"
 	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -24,6 +27,50 @@ func TestIsValidHookContentType(t *testing.T) {
 	assert.False(t, IsValidHookContentType("invalid"))
 }
 
+func TestIsValidHookSignatureAlgorithm(t *testing.T) {
+	assert.True(t, IsValidHookSignatureAlgorithm("sha1"))
+	assert.True(t, IsValidHookSignatureAlgorithm("sha256"))
+	assert.True(t, IsValidHookSignatureAlgorithm("sha512"))
+	assert.False(t, IsValidHookSignatureAlgorithm(""))
+	assert.False(t, IsValidHookSignatureAlgorithm("md5"))
+}
+
+func TestWebhook_SetSecret(t *testing.T) {
+	defer func(period time.Duration) {
+		setting.Webhook.SecretRotationPeriod = period
+	}(setting.Webhook.SecretRotationPeriod)
+	setting.Webhook.SecretRotationPeriod = time.Hour
+
+	webhook := &Webhook{}
+	webhook.SetSecret("first")
+	assert.Equal(t, []string{"first"}, webhook.Secrets())
+
+	// the previous secret stays valid for the rotation period
+	webhook.SetSecret("second")
+	assert.Equal(t, "first", webhook.OldSecret)
+	assert.True(t, webhook.HasValidOldSecret())
+	assert.Equal(t, []string{"second", "first"}, webhook.Secrets())
+
+	// setting the same secret keeps the rotation
+	webhook.SetSecret("second")
+	assert.Equal(t, []string{"second", "first"}, webhook.Secrets())
+
+	webhook.OldSecretExpiresUnix = timeutil.TimeStampNow().Add(-1)
+	assert.False(t, webhook.HasValidOldSecret())
+	assert.Equal(t, []string{"second"}, webhook.Secrets())
+
+	setting.Webhook.SecretRotationPeriod = 0
+	webhook.SetSecret("third")
+	assert.Empty(t, webhook.OldSecret)
+	assert.Equal(t, []string{"third"}, webhook.Secrets())
+}
+
+func TestWebhook_SignatureHash(t *testing.T) {
+	assert.Equal(t, HookSignatureSHA256, (&Webhook{}).SignatureHash())
+	assert.Equal(t, HookSignatureSHA512, (&Webhook{SignatureAlgorithm: HookSignatureSHA512}).SignatureHash())
+	assert.Equal(t, HookSignatureSHA256, (&Webhook{SignatureAlgorithm: "md5"}).SignatureHash())
+}
+
 func TestWebhook_History(t *testing.T) {
 	assert.NoError(t, PrepareTestDatabase())
 	webhook := AssertExistsAndLoadBean(t, &Webhook{ID: 1}).(*Webhook)
@@ -65,7 +112,8 @@ func TestWebhook_EventsArray(t *testing.T) {
 		"issues", "issue_assign", "issue_label", "issue_milestone", "issue_comment",
 		"pull_request", "pull_request_assign", "pull_request_label", "pull_request_milestone",
 		"pull_request_comment", "pull_request_review_approved", "pull_request_review_rejected",
-		"pull_request_review_comment", "pull_request_sync", "repository", "release"},
+		"pull_request_review_comment", "pull_request_sync", "repository", "release",
+		"user", "organization"},
 		(&Webhook{
 			HookEvent: &HookEvent{SendEverything: true},
 		}).EventsArray(),
@@ -190,6 +238,7 @@ func TestToHookTaskType(t *testing.T) {
 	assert.Equal(t, SLACK, ToHookTaskType("slack"))
 	assert.Equal(t, GITEA, ToHookTaskType("gitea"))
 	assert.Equal(t, TELEGRAM, ToHookTaskType("telegram"))
+	assert.Equal(t, CUSTOM, ToHookTaskType("custom"))
 }
 
 func TestHookTaskType_Name(t *testing.T) {
@@ -197,6 +246,7 @@ func TestHookTaskType_Name(t *testing.T) {
 	assert.Equal(t, "slack", SLACK.Name())
 	assert.Equal(t, "gitea", GITEA.Name())
 	assert.Equal(t, "telegram", TELEGRAM.Name())
+	assert.Equal(t, "custom", CUSTOM.Name())
 }
 
 func TestIsValidHookTaskType(t *testing.T) {
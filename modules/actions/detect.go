@@ -0,0 +1,65 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package actions
+
+import (
+	"io"
+	"io/ioutil"
+	"path"
+
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+)
+
+// WorkflowsDir is the directory of the workflow files in the repository
+const WorkflowsDir = ".gitea/workflows"
+
+// maxWorkflowSize is the size of the largest workflow file which is read
+const maxWorkflowSize = 1024 * 1024
+
+// ListWorkflows returns the content of the workflow files in the commit by file name
+func ListWorkflows(commit *git.Commit) (map[string][]byte, error) {
+	tree, err := commit.SubTree(WorkflowsDir)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	entries, err := tree.ListEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	workflows := make(map[string][]byte, len(entries))
+	for _, entry := range entries {
+		if !entry.IsRegular() {
+			continue
+		}
+		if ext := path.Ext(entry.Name()); ext != ".yml" && ext != ".yaml" {
+			continue
+		}
+		if entry.Size() > maxWorkflowSize {
+			log.Warn("Workflow %s of commit %s is too large", entry.Name(), commit.ID)
+			continue
+		}
+
+		content, err := readBlob(entry.Blob())
+		if err != nil {
+			return nil, err
+		}
+		workflows[entry.Name()] = content
+	}
+	return workflows, nil
+}
+
+func readBlob(blob *git.Blob) ([]byte, error) {
+	rc, err := blob.DataAsync()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return ioutil.ReadAll(io.LimitReader(rc, maxWorkflowSize))
+}
@@ -0,0 +1,250 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gobwas/glob"
+	"gopkg.in/yaml.v2"
+)
+
+// Events supported as workflow triggers
+const (
+	EventPush        = "push"
+	EventPullRequest = "pull_request"
+)
+
+// StringList is a list of strings which can be written as a single string in the workflow file
+type StringList []string
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (l *StringList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err == nil {
+		*l = StringList{s}
+		return nil
+	}
+	var list []string
+	if err := unmarshal(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// EventFilter restricts the refs an event triggers the workflow for
+type EventFilter struct {
+	Branches       StringList `yaml:"branches"`
+	BranchesIgnore StringList `yaml:"branches-ignore"`
+	Tags           StringList `yaml:"tags"`
+	TagsIgnore     StringList `yaml:"tags-ignore"`
+}
+
+// Events maps the events which trigger the workflow to their filters, a nil
+// filter matches all refs
+type Events map[string]*EventFilter
+
+// UnmarshalYAML implements yaml.Unmarshaler, the events can be written as
+// a single event, a list of events or a map of events to their filters
+func (e *Events) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var names StringList
+	if err := unmarshal(&names); err == nil {
+		*e = make(Events, len(names))
+		for _, name := range names {
+			(*e)[name] = nil
+		}
+		return nil
+	}
+	var filters map[string]*EventFilter
+	if err := unmarshal(&filters); err != nil {
+		return err
+	}
+	*e = filters
+	return nil
+}
+
+// Step is a step of a job, either a command to run or an action to use
+type Step struct {
+	Name             string            `yaml:"name" json:"name"`
+	Run              string            `yaml:"run" json:"run,omitempty"`
+	Uses             string            `yaml:"uses" json:"uses,omitempty"`
+	With             map[string]string `yaml:"with" json:"with,omitempty"`
+	Env              map[string]string `yaml:"env" json:"env,omitempty"`
+	Shell            string            `yaml:"shell" json:"shell,omitempty"`
+	WorkingDirectory string            `yaml:"working-directory" json:"working_directory,omitempty"`
+	ContinueOnError  bool              `yaml:"continue-on-error" json:"continue_on_error,omitempty"`
+}
+
+// Job is a job of a workflow, it is run by a single runner
+type Job struct {
+	Name           string            `yaml:"name" json:"name"`
+	RunsOn         StringList        `yaml:"runs-on" json:"runs_on"`
+	Needs          StringList        `yaml:"needs" json:"needs,omitempty"`
+	Env            map[string]string `yaml:"env" json:"env,omitempty"`
+	TimeoutMinutes int               `yaml:"timeout-minutes" json:"timeout_minutes,omitempty"`
+	Steps          []*Step           `yaml:"steps" json:"steps"`
+}
+
+// Workflow represents a workflow file in .gitea/workflows
+type Workflow struct {
+	Name string            `yaml:"name"`
+	On   Events            `yaml:"on"`
+	Env  map[string]string `yaml:"env"`
+	Jobs map[string]*Job   `yaml:"jobs"`
+}
+
+// Parse parses and validates the content of a workflow file
+func Parse(content []byte) (*Workflow, error) {
+	w := new(Workflow)
+	if err := yaml.Unmarshal(content, w); err != nil {
+		return nil, err
+	}
+
+	if len(w.On) == 0 {
+		return nil, errors.New("workflow has no trigger")
+	}
+	if len(w.Jobs) == 0 {
+		return nil, errors.New("workflow has no jobs")
+	}
+	for id, job := range w.Jobs {
+		if job == nil || len(job.Steps) == 0 {
+			return nil, fmt.Errorf("job %q has no steps", id)
+		}
+		if job.Name == "" {
+			job.Name = id
+		}
+		for i, step := range job.Steps {
+			if step == nil || (step.Run == "") == (step.Uses == "") {
+				return nil, fmt.Errorf("step %d of job %q must either run a command or use an action", i, id)
+			}
+			if step.Name == "" {
+				step.Name = step.Uses
+				if step.Name == "" {
+					step.Name = strings.SplitN(strings.TrimSpace(step.Run), "\n", 2)[0]
+				}
+			}
+		}
+		for _, need := range job.Needs {
+			if _, ok := w.Jobs[need]; !ok {
+				return nil, fmt.Errorf("job %q needs unknown job %q", id, need)
+			}
+		}
+	}
+	if err := w.checkCycles(); err != nil {
+		return nil, err
+	}
+
+	// the workflow environment is the default of the jobs
+	for _, job := range w.Jobs {
+		env := make(map[string]string, len(w.Env)+len(job.Env))
+		for k, v := range w.Env {
+			env[k] = v
+		}
+		for k, v := range job.Env {
+			env[k] = v
+		}
+		job.Env = env
+	}
+	return w, nil
+}
+
+func (w *Workflow) checkCycles() error {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int, len(w.Jobs))
+	var visit func(id string) error
+	visit = func(id string) error {
+		switch state[id] {
+		case visiting:
+			return fmt.Errorf("job %q depends on itself", id)
+		case visited:
+			return nil
+		}
+		state[id] = visiting
+		for _, need := range w.Jobs[id].Needs {
+			if err := visit(need); err != nil {
+				return err
+			}
+		}
+		state[id] = visited
+		return nil
+	}
+	for _, id := range w.JobIDs() {
+		if err := visit(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// JobIDs returns the sorted IDs of the jobs of the workflow
+func (w *Workflow) JobIDs() []string {
+	ids := make([]string, 0, len(w.Jobs))
+	for id := range w.Jobs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Match returns true if the event on the ref triggers the workflow, for pull
+// requests the ref is the base branch
+func (w *Workflow) Match(event, ref string) bool {
+	filter, ok := w.On[event]
+	if !ok {
+		return false
+	}
+	if filter == nil {
+		return true
+	}
+
+	if strings.HasPrefix(ref, "refs/tags/") {
+		// a filter on branches only excludes all tags
+		if len(filter.Tags) == 0 && len(filter.TagsIgnore) == 0 &&
+			(len(filter.Branches) > 0 || len(filter.BranchesIgnore) > 0) {
+			return false
+		}
+		return matchPatterns(strings.TrimPrefix(ref, "refs/tags/"), filter.Tags, filter.TagsIgnore)
+	}
+
+	branch := strings.TrimPrefix(ref, "refs/heads/")
+	// a filter on tags only excludes all branches
+	if len(filter.Branches) == 0 && len(filter.BranchesIgnore) == 0 &&
+		(len(filter.Tags) > 0 || len(filter.TagsIgnore) > 0) {
+		return false
+	}
+	return matchPatterns(branch, filter.Branches, filter.BranchesIgnore)
+}
+
+func matchPatterns(name string, patterns, ignorePatterns []string) bool {
+	for _, pattern := range ignorePatterns {
+		if matchPattern(name, pattern) {
+			return false
+		}
+	}
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if matchPattern(name, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchPattern(name, pattern string) bool {
+	g, err := glob.Compile(pattern, '/')
+	if err != nil {
+		return false
+	}
+	return g.Match(name)
+}
@@ -0,0 +1,103 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package actions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	w, err := Parse([]byte(`
+name: CI
+on:
+  push:
+    branches: [master, 'release/*']
+  pull_request:
+env:
+  GOFLAGS: -mod=vendor
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    env:
+      CGO_ENABLED: 0
+    steps:
+      - uses: actions/checkout@v2
+      - run: |
+          make build
+          make test
+  lint:
+    name: Lint the code
+    runs-on: [ubuntu-latest, go]
+    needs: build
+    steps:
+      - name: Lint
+        run: make lint
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, "CI", w.Name)
+	assert.Equal(t, []string{"build", "lint"}, w.JobIDs())
+
+	build := w.Jobs["build"]
+	assert.Equal(t, "build", build.Name)
+	assert.EqualValues(t, []string{"ubuntu-latest"}, build.RunsOn)
+	assert.Equal(t, map[string]string{"GOFLAGS": "-mod=vendor", "CGO_ENABLED": "0"}, build.Env)
+	assert.Len(t, build.Steps, 2)
+	assert.Equal(t, "actions/checkout@v2", build.Steps[0].Name)
+	assert.Equal(t, "make build", build.Steps[1].Name)
+
+	lint := w.Jobs["lint"]
+	assert.Equal(t, "Lint the code", lint.Name)
+	assert.EqualValues(t, []string{"ubuntu-latest", "go"}, lint.RunsOn)
+	assert.EqualValues(t, []string{"build"}, lint.Needs)
+
+	assert.True(t, w.Match(EventPush, "refs/heads/master"))
+	assert.True(t, w.Match(EventPush, "refs/heads/release/1.13"))
+	assert.False(t, w.Match(EventPush, "refs/heads/feature"))
+	assert.False(t, w.Match(EventPush, "refs/tags/v1.0.0"))
+	assert.True(t, w.Match(EventPullRequest, "refs/heads/feature"))
+}
+
+func TestParse_Events(t *testing.T) {
+	steps := "\njobs:\n  a:\n    steps:\n      - run: true\n"
+
+	w, err := Parse([]byte("on: push" + steps))
+	assert.NoError(t, err)
+	assert.True(t, w.Match(EventPush, "refs/tags/v1.0.0"))
+	assert.False(t, w.Match(EventPullRequest, "refs/heads/master"))
+
+	w, err = Parse([]byte("on: [push, pull_request]" + steps))
+	assert.NoError(t, err)
+	assert.True(t, w.Match(EventPush, "refs/heads/master"))
+	assert.True(t, w.Match(EventPullRequest, "refs/heads/master"))
+
+	w, err = Parse([]byte("on:\n  push:\n    tags: ['v*']\n    branches-ignore: ['wip/**']" + steps))
+	assert.NoError(t, err)
+	assert.True(t, w.Match(EventPush, "refs/tags/v1.0.0"))
+	assert.False(t, w.Match(EventPush, "refs/tags/latest"))
+	assert.True(t, w.Match(EventPush, "refs/heads/master"))
+	assert.False(t, w.Match(EventPush, "refs/heads/wip/a/b"))
+
+	w, err = Parse([]byte("on:\n  push:\n    tags: ['v*']" + steps))
+	assert.NoError(t, err)
+	assert.False(t, w.Match(EventPush, "refs/heads/master"))
+}
+
+func TestParse_Invalid(t *testing.T) {
+	kases := map[string]string{
+		"no trigger":     "jobs:\n  a:\n    steps:\n      - run: true\n",
+		"no jobs":        "on: push\n",
+		"no steps":       "on: push\njobs:\n  a:\n    runs-on: ubuntu-latest\n",
+		"run and uses":   "on: push\njobs:\n  a:\n    steps:\n      - run: true\n        uses: actions/checkout@v2\n",
+		"unknown need":   "on: push\njobs:\n  a:\n    needs: b\n    steps:\n      - run: true\n",
+		"cyclic needs":   "on: push\njobs:\n  a:\n    needs: b\n    steps:\n      - run: true\n  b:\n    needs: a\n    steps:\n      - run: true\n",
+		"invalid syntax": "on: push\njobs: [",
+	}
+	for name, content := range kases {
+		_, err := Parse([]byte(content))
+		assert.Error(t, err, name)
+	}
+}
@@ -0,0 +1,219 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+func init() {
+	models.RecordGroupTeamSyncChange = RecordGroupTeamSync
+}
+
+// logEntry is an audit event as it is written to the audit logger
+type logEntry struct {
+	Time       time.Time              `json:"time"`
+	Action     models.AuditAction     `json:"action"`
+	ActorID    int64                  `json:"actor_id"`
+	ActorName  string                 `json:"actor_name"`
+	IPAddress  string                 `json:"ip_address,omitempty"`
+	RepoID     int64                  `json:"repo_id,omitempty"`
+	RepoName   string                 `json:"repo_name,omitempty"`
+	OrgID      int64                  `json:"org_id,omitempty"`
+	OrgName    string                 `json:"org_name,omitempty"`
+	TargetType models.AuditTargetType `json:"target_type,omitempty"`
+	TargetID   int64                  `json:"target_id,omitempty"`
+	TargetName string                 `json:"target_name,omitempty"`
+	Details    string                 `json:"details,omitempty"`
+}
+
+// Record adds an event to the audit log. The actor is the doer, or the system if
+// doer is nil, and the repository and organization names are looked up from their
+// IDs. Failures are logged but never interrupt the audited action.
+func Record(doer *models.User, remoteAddr string, e *models.AuditEvent) {
+	if doer != nil {
+		e.ActorID = doer.ID
+		e.ActorName = doer.Name
+	}
+	e.IPAddress = remoteAddr
+
+	if e.RepoID != 0 && e.RepoName == "" {
+		repo, err := models.GetRepositoryByID(e.RepoID)
+		if err != nil {
+			log.Error("GetRepositoryByID[%d]: %v", e.RepoID, err)
+		} else if err = repo.GetOwner(); err != nil {
+			log.Error("GetOwner[%d]: %v", repo.ID, err)
+		} else {
+			e.RepoName = repo.FullName()
+			if e.OrgID == 0 && repo.Owner.IsOrganization() {
+				e.OrgID = repo.OwnerID
+				e.OrgName = repo.OwnerName
+			}
+		}
+	}
+	if e.TargetType == models.AuditTargetUser && e.TargetID != 0 && e.TargetName == "" {
+		u, err := models.GetUserByID(e.TargetID)
+		if err != nil {
+			log.Error("GetUserByID[%d]: %v", e.TargetID, err)
+		} else {
+			e.TargetName = u.Name
+		}
+	}
+	if e.OrgID != 0 && e.OrgName == "" {
+		org, err := models.GetUserByID(e.OrgID)
+		if err != nil {
+			log.Error("GetUserByID[%d]: %v", e.OrgID, err)
+		} else {
+			e.OrgName = org.Name
+		}
+	}
+
+	if err := models.CreateAuditEvent(e); err != nil {
+		log.Error("CreateAuditEvent[%s]: %v", e.Action, err)
+	}
+
+	if setting.EnableAuditLog {
+		writeLog(e)
+	}
+}
+
+// writeLog sends the event as a JSON line to the audit logger
+func writeLog(e *models.AuditEvent) {
+	data, err := json.Marshal(&logEntry{
+		Time:       e.CreatedUnix.AsTime(),
+		Action:     e.Action,
+		ActorID:    e.ActorID,
+		ActorName:  e.ActorName,
+		IPAddress:  e.IPAddress,
+		RepoID:     e.RepoID,
+		RepoName:   e.RepoName,
+		OrgID:      e.OrgID,
+		OrgName:    e.OrgName,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+		TargetName: e.TargetName,
+		Details:    e.Details,
+	})
+	if err != nil {
+		log.Error("json.Marshal: %v", err)
+		return
+	}
+	if err := log.GetLogger("audit").SendLog(log.INFO, "", "", 0, string(data), ""); err != nil {
+		log.Error("SendLog: %v", err)
+	}
+}
+
+// RecordBranchProtection adds the update or removal of a branch protection rule to
+// the audit log, updates include the restrictions of the rule
+func RecordBranchProtection(doer *models.User, remoteAddr string, action models.AuditAction, protectBranch *models.ProtectedBranch) {
+	e := &models.AuditEvent{
+		Action:     action,
+		RepoID:     protectBranch.RepoID,
+		TargetType: models.AuditTargetProtectedBranch,
+		TargetID:   protectBranch.ID,
+		TargetName: protectBranch.BranchName,
+	}
+	if action == models.AuditBranchProtectionUpdate {
+		e.Details = fmt.Sprintf("push: %t, push whitelist: %t, merge whitelist: %t, status check: %t, required approvals: %d, code owner approval: %t, signed commits: %t",
+			protectBranch.CanPush, protectBranch.EnableWhitelist, protectBranch.EnableMergeWhitelist,
+			protectBranch.EnableStatusCheck, protectBranch.RequiredApprovals, protectBranch.RequireCodeOwnerApproval,
+			protectBranch.RequireSignedCommits)
+	}
+	Record(doer, remoteAddr, e)
+}
+
+// RecordTeam adds a change of a team of an organization to the audit log. Creations
+// and edits include the permission of the team unless other details are given.
+func RecordTeam(doer *models.User, remoteAddr string, action models.AuditAction, team *models.Team, details string) {
+	if details == "" && (action == models.AuditTeamCreate || action == models.AuditTeamEdit) {
+		details = fmt.Sprintf("permission: %s, all repositories: %t, create repositories: %t",
+			team.Authorize, team.IncludesAllRepositories, team.CanCreateOrgRepo)
+	}
+	Record(doer, remoteAddr, &models.AuditEvent{
+		Action:     action,
+		OrgID:      team.OrgID,
+		TargetType: models.AuditTargetTeam,
+		TargetID:   team.ID,
+		TargetName: team.Name,
+		Details:    details,
+	})
+}
+
+// RecordGroupTeamSync adds a team membership change of the synchronization of the
+// groups of an external login source to the audit log, it is performed by the system
+func RecordGroupTeamSync(change *models.GroupTeamSyncChange) {
+	action := models.AuditTeamMemberRemove
+	if change.Add {
+		action = models.AuditTeamMemberAdd
+	}
+	RecordTeam(nil, "", action, change.Team, "member: "+change.User.Name+", group synchronization")
+}
+
+// RecordTeamRepository adds the addition or removal of a repository of a team to
+// the audit log, repoID is 0 if all repositories of the organization are affected
+func RecordTeamRepository(doer *models.User, remoteAddr string, action models.AuditAction, team *models.Team, repoID int64) {
+	e := &models.AuditEvent{
+		Action:     action,
+		RepoID:     repoID,
+		OrgID:      team.OrgID,
+		TargetType: models.AuditTargetTeam,
+		TargetID:   team.ID,
+		TargetName: team.Name,
+	}
+	if repoID == 0 {
+		e.Details = "all repositories"
+	}
+	Record(doer, remoteAddr, e)
+}
+
+// FilterOptions returns the options to find the audit events of the actor, the
+// repository ("owner/name") and the organization with the given names. Empty names
+// do not filter, names which do not exist match no event.
+func FilterOptions(actor, repo, org string, action models.AuditAction) (*models.FindAuditEventsOptions, error) {
+	opts := &models.FindAuditEventsOptions{Action: action}
+
+	if actor != "" {
+		u, err := models.GetUserByName(actor)
+		if err != nil && !models.IsErrUserNotExist(err) {
+			return nil, err
+		}
+		opts.ActorID = -1
+		if u != nil {
+			opts.ActorID = u.ID
+		}
+	}
+
+	if repo != "" {
+		opts.RepoID = -1
+		if fields := strings.SplitN(repo, "/", 2); len(fields) == 2 {
+			r, err := models.GetRepositoryByOwnerAndName(fields[0], fields[1])
+			if err != nil && !models.IsErrRepoNotExist(err) {
+				return nil, err
+			}
+			if r != nil {
+				opts.RepoID = r.ID
+			}
+		}
+	}
+
+	if org != "" {
+		o, err := models.GetUserByName(org)
+		if err != nil && !models.IsErrUserNotExist(err) {
+			return nil, err
+		}
+		opts.OrgID = -1
+		if o != nil && o.IsOrganization() {
+			opts.OrgID = o.ID
+		}
+	}
+	return opts, nil
+}
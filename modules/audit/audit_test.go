@@ -0,0 +1,101 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package audit
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecord(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+	doer := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+
+	// the names of the repository, its organization and the target user are looked up
+	Record(doer, "127.0.0.1", &models.AuditEvent{
+		Action:     models.AuditCollaboratorAdd,
+		RepoID:     3,
+		TargetType: models.AuditTargetUser,
+		TargetID:   4,
+	})
+	models.AssertExistsAndLoadBean(t, &models.AuditEvent{
+		Action:     models.AuditCollaboratorAdd,
+		ActorID:    2,
+		ActorName:  "user2",
+		IPAddress:  "127.0.0.1",
+		RepoID:     3,
+		RepoName:   "user3/repo3",
+		OrgID:      3,
+		OrgName:    "user3",
+		TargetName: "user4",
+	})
+
+	// actions without doer are performed by the system
+	Record(nil, "", &models.AuditEvent{Action: models.AuditAdminRunTask, TargetType: models.AuditTargetTask, TargetName: "gc"})
+	models.AssertExistsAndLoadBean(t, &models.AuditEvent{Action: models.AuditAdminRunTask, TargetName: "gc"}, "actor_id = 0")
+}
+
+func TestFilterOptions(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+
+	opts, err := FilterOptions("user2", "user3/repo3", "user3", models.AuditTeamCreate)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, opts.ActorID)
+	assert.EqualValues(t, 3, opts.RepoID)
+	assert.EqualValues(t, 3, opts.OrgID)
+	assert.Equal(t, models.AuditTeamCreate, opts.Action)
+
+	// unknown names and users which are no organization match nothing
+	opts, err = FilterOptions("nobody", "user2/missing", "user2", "")
+	assert.NoError(t, err)
+	assert.EqualValues(t, -1, opts.ActorID)
+	assert.EqualValues(t, -1, opts.RepoID)
+	assert.EqualValues(t, -1, opts.OrgID)
+
+	opts, err = FilterOptions("", "", "", "")
+	assert.NoError(t, err)
+	assert.Zero(t, opts.ActorID)
+	assert.Zero(t, opts.RepoID)
+	assert.Zero(t, opts.OrgID)
+}
+
+func TestRecordBranchProtection(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+	doer := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+
+	RecordBranchProtection(doer, "", models.AuditBranchProtectionUpdate, &models.ProtectedBranch{
+		ID:                       1,
+		RepoID:                   1,
+		BranchName:               "master",
+		RequireCodeOwnerApproval: true,
+	})
+	e := models.AssertExistsAndLoadBean(t, &models.AuditEvent{Action: models.AuditBranchProtectionUpdate, TargetName: "master"}).(*models.AuditEvent)
+	assert.Contains(t, e.Details, "code owner approval: true")
+}
+
+func TestRecordGroupTeamSync(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+	user := models.AssertExistsAndLoadBean(t, &models.User{ID: 5}).(*models.User)
+	mapping := models.GroupTeamMapping{"developers": {"user3": {"team1"}}}
+
+	assert.NoError(t, models.SyncGroupTeams(user, []string{"developers"}, mapping, true))
+	models.AssertExistsAndLoadBean(t, &models.AuditEvent{
+		Action:     models.AuditTeamMemberAdd,
+		OrgID:      3,
+		TargetID:   2,
+		TargetName: "team1",
+		Details:    "member: user5, group synchronization",
+	}, "actor_id = 0")
+
+	assert.NoError(t, models.SyncGroupTeams(user, nil, mapping, true))
+	models.AssertExistsAndLoadBean(t, &models.AuditEvent{
+		Action:     models.AuditTeamMemberRemove,
+		TargetName: "team1",
+		Details:    "member: user5, group synchronization",
+	}, "actor_id = 0")
+}
@@ -59,3 +59,14 @@ type AdminDashboardForm struct {
 func (f *AdminDashboardForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
 	return validate(errs, ctx.Data, f, ctx.Locale)
 }
+
+// AdminCreateActionRunnerForm form for admin to register an action runner
+type AdminCreateActionRunnerForm struct {
+	Name   string `binding:"Required;MaxSize(255)"`
+	Labels string `binding:"MaxSize(1024)"`
+}
+
+// Validate validates form fields
+func (f *AdminCreateActionRunnerForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
@@ -11,51 +11,65 @@ import (
 
 // AuthenticationForm form for authentication
 type AuthenticationForm struct {
-	ID                            int64
-	Type                          int    `binding:"Range(2,7)"`
-	Name                          string `binding:"Required;MaxSize(30)"`
-	Host                          string
-	Port                          int
-	BindDN                        string
-	BindPassword                  string
-	UserBase                      string
-	UserDN                        string
-	AttributeUsername             string
-	AttributeName                 string
-	AttributeSurname              string
-	AttributeMail                 string
-	AttributeSSHPublicKey         string
-	AttributesInBind              bool
-	UsePagedSearch                bool
-	SearchPageSize                int
-	Filter                        string
-	AdminFilter                   string
-	RestrictedFilter              string
-	AllowDeactivateAll            bool
-	IsActive                      bool
-	IsSyncEnabled                 bool
-	SMTPAuth                      string
-	SMTPHost                      string
-	SMTPPort                      int
-	AllowedDomains                string
-	SecurityProtocol              int `binding:"Range(0,2)"`
-	TLS                           bool
-	SkipVerify                    bool
-	PAMServiceName                string
-	Oauth2Provider                string
-	Oauth2Key                     string
-	Oauth2Secret                  string
-	OpenIDConnectAutoDiscoveryURL string
-	Oauth2UseCustomURL            bool
-	Oauth2TokenURL                string
-	Oauth2AuthURL                 string
-	Oauth2ProfileURL              string
-	Oauth2EmailURL                string
-	SSPIAutoCreateUsers           bool
-	SSPIAutoActivateUsers         bool
-	SSPIStripDomainNames          bool
-	SSPISeparatorReplacement      string `binding:"AlphaDashDot;MaxSize(5)"`
-	SSPIDefaultLanguage           string
+	ID                              int64
+	Type                            int    `binding:"Range(2,8)"`
+	Name                            string `binding:"Required;MaxSize(30)"`
+	Host                            string
+	Port                            int
+	BindDN                          string
+	BindPassword                    string
+	UserBase                        string
+	UserDN                          string
+	AttributeUsername               string
+	AttributeName                   string
+	AttributeSurname                string
+	AttributeMail                   string
+	AttributeSSHPublicKey           string
+	AttributeGroups                 string
+	GroupTeamMap                    string
+	AttributesInBind                bool
+	UsePagedSearch                  bool
+	SearchPageSize                  int
+	Filter                          string
+	AdminFilter                     string
+	RestrictedFilter                string
+	AllowDeactivateAll              bool
+	IsActive                        bool
+	IsSyncEnabled                   bool
+	SMTPAuth                        string
+	SMTPHost                        string
+	SMTPPort                        int
+	AllowedDomains                  string
+	SecurityProtocol                int `binding:"Range(0,2)"`
+	TLS                             bool
+	SkipVerify                      bool
+	PAMServiceName                  string
+	Oauth2Provider                  string
+	Oauth2Key                       string
+	Oauth2Secret                    string
+	OpenIDConnectAutoDiscoveryURL   string
+	Oauth2UseCustomURL              bool
+	Oauth2TokenURL                  string
+	Oauth2AuthURL                   string
+	Oauth2ProfileURL                string
+	Oauth2EmailURL                  string
+	Oauth2GroupClaimName            string
+	Oauth2GroupTeamMap              string
+	SSPIAutoCreateUsers             bool
+	SSPIAutoActivateUsers           bool
+	SSPIStripDomainNames            bool
+	SSPISeparatorReplacement        string `binding:"AlphaDashDot;MaxSize(5)"`
+	SSPIDefaultLanguage             string
+	SAMLIdentityProviderMetadata    string
+	SAMLIdentityProviderMetadataURL string
+	SAMLServiceProviderCertificate  string
+	SAMLServiceProviderPrivateKey   string
+	SAMLAttributeUsername           string
+	SAMLAttributeEmail              string
+	SAMLAttributeFullName           string
+	SAMLAttributeGroups             string
+	SAMLGroupTeamMap                string
+	SAMLGroupTeamMapRemoval         bool
 }
 
 // Validate validates fields
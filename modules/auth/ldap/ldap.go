@@ -43,6 +43,8 @@ type Source struct {
 	AttributeMail         string // E-mail attribute
 	AttributesInBind      bool   // fetch attributes in bind context (not user)
 	AttributeSSHPublicKey string // LDAP SSH Public Key attribute
+	AttributeGroups       string // Group membership attribute, e.g. memberOf
+	GroupTeamMap          string // JSON mapping of group DNs to organization teams
 	SearchPageSize        uint32 // Search with paging page size
 	Filter                string // Query filter to validate entry
 	AdminFilter           string // Query filter to check if user is admin
@@ -58,6 +60,7 @@ type SearchResult struct {
 	Surname      string   // Surname
 	Mail         string   // E-mail address
 	SSHPublicKey []string // SSH Public Key
+	Groups       []string // DNs of the groups of the user
 	IsAdmin      bool     // if user is administrator
 	IsRestricted bool     // if user is restricted
 }
@@ -370,8 +373,11 @@ func (ls *Source) SearchEntries() ([]*SearchResult, error) {
 	if isAttributeSSHPublicKeySet {
 		attribs = append(attribs, ls.AttributeSSHPublicKey)
 	}
+	if ls.AttributeGroups != "" {
+		attribs = append(attribs, ls.AttributeGroups)
+	}
 
-	log.Trace("Fetching attributes '%v', '%v', '%v', '%v', '%v' with filter %s and base %s", ls.AttributeUsername, ls.AttributeName, ls.AttributeSurname, ls.AttributeMail, ls.AttributeSSHPublicKey, userFilter, ls.UserBase)
+	log.Trace("Fetching attributes '%v', '%v', '%v', '%v', '%v', '%v' with filter %s and base %s", ls.AttributeUsername, ls.AttributeName, ls.AttributeSurname, ls.AttributeMail, ls.AttributeSSHPublicKey, ls.AttributeGroups, userFilter, ls.UserBase)
 	search := ldap.NewSearchRequest(
 		ls.UserBase, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false, userFilter,
 		attribs, nil)
@@ -403,6 +409,9 @@ func (ls *Source) SearchEntries() ([]*SearchResult, error) {
 		if isAttributeSSHPublicKeySet {
 			result[i].SSHPublicKey = v.GetAttributeValues(ls.AttributeSSHPublicKey)
 		}
+		if ls.AttributeGroups != "" {
+			result[i].Groups = v.GetAttributeValues(ls.AttributeGroups)
+		}
 	}
 
 	return result, nil
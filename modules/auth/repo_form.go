@@ -11,6 +11,7 @@ import (
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/routers/utils"
 
 	"gitea.com/macaron/binding"
@@ -71,6 +72,9 @@ type MigrateRepoForm struct {
 	Issues       bool   `json:"issues"`
 	PullRequests bool   `json:"pull_requests"`
 	Releases     bool   `json:"releases"`
+	// The git service the repository is migrated from: git, github, gitea, gitlab or gogs.
+	// It is detected from the clone address if empty.
+	Service string `json:"service"`
 }
 
 // Validate validates the fields
@@ -78,6 +82,23 @@ func (f *MigrateRepoForm) Validate(ctx *macaron.Context, errs binding.Errors) bi
 	return validate(errs, ctx.Data, f, ctx.Locale)
 }
 
+// GitServiceType returns the git service chosen by the service field or
+// detected from the clone address
+func (f MigrateRepoForm) GitServiceType() structs.GitServiceType {
+	for _, tp := range structs.SupportedFullGitService {
+		if strings.EqualFold(f.Service, tp.Name()) {
+			return tp
+		}
+	}
+	if f.Service == "" {
+		u, err := url.Parse(f.CloneAddr)
+		if err == nil && strings.EqualFold(u.Host, "github.com") {
+			return structs.GithubService
+		}
+	}
+	return structs.PlainGitService
+}
+
 // ParseRemoteAddr checks if given remote address is valid,
 // and returns composed URL with needed username and password.
 // It also checks if given user has permission when remote address
@@ -119,8 +140,17 @@ type RepoSettingForm struct {
 	Template       bool
 	EnablePrune    bool
 
+	// Push mirror settings
+	PushMirrorID       int64
+	PushMirrorAddress  string
+	PushMirrorUsername string
+	PushMirrorPassword string
+	PushMirrorInterval string
+
 	// Advanced settings
 	EnableWiki                       bool
+	EnableProjects                   bool
+	EnableActions                    bool
 	EnableExternalWiki               bool
 	ExternalWikiURL                  string
 	EnableIssues                     bool
@@ -134,6 +164,8 @@ type RepoSettingForm struct {
 	PullsAllowRebase                 bool
 	PullsAllowRebaseMerge            bool
 	PullsAllowSquash                 bool
+	PullsAllowFastForwardOnly        bool
+	PullsAllowSemiLinear             bool
 	EnableTimetracker                bool
 	AllowOnlyContributorsToTrackTime bool
 	EnableIssueDependencies          bool
@@ -174,6 +206,7 @@ type ProtectBranchForm struct {
 	ApprovalsWhitelistTeams  string
 	BlockOnRejectedReviews   bool
 	BlockOnOutdatedBranch    bool
+	RequireCodeOwnerApproval bool
 	DismissStaleApprovals    bool
 	RequireSignedCommits     bool
 	ProtectedFilePatterns    string
@@ -212,6 +245,8 @@ type WebhookForm struct {
 	PullRequestReview    bool
 	PullRequestSync      bool
 	Repository           bool
+	User                 bool
+	Organization         bool
 	Active               bool
 	BranchFilter         string `binding:"GlobPattern"`
 }
@@ -233,10 +268,12 @@ func (f WebhookForm) ChooseEvents() bool {
 
 // NewWebhookForm form for creating web hook
 type NewWebhookForm struct {
-	PayloadURL  string `binding:"Required;ValidUrl"`
-	HTTPMethod  string `binding:"Required;In(POST,GET)"`
-	ContentType int    `binding:"Required"`
-	Secret      string
+	PayloadURL         string `binding:"Required;ValidUrl"`
+	HTTPMethod         string `binding:"Required;In(POST,GET)"`
+	ContentType        int    `binding:"Required"`
+	Secret             string
+	SignatureAlgorithm string `binding:"In(,sha1,sha256,sha512)"`
+	SignTimestamp      bool
 	WebhookForm
 }
 
@@ -247,9 +284,11 @@ func (f *NewWebhookForm) Validate(ctx *macaron.Context, errs binding.Errors) bin
 
 // NewGogshookForm form for creating gogs hook
 type NewGogshookForm struct {
-	PayloadURL  string `binding:"Required;ValidUrl"`
-	ContentType int    `binding:"Required"`
-	Secret      string
+	PayloadURL         string `binding:"Required;ValidUrl"`
+	ContentType        int    `binding:"Required"`
+	Secret             string
+	SignatureAlgorithm string `binding:"In(,sha1,sha256,sha512)"`
+	SignTimestamp      bool
 	WebhookForm
 }
 
@@ -294,6 +333,7 @@ func (f *NewDiscordHookForm) Validate(ctx *macaron.Context, errs binding.Errors)
 // NewDingtalkHookForm form for creating dingtalk hook
 type NewDingtalkHookForm struct {
 	PayloadURL string `binding:"Required;ValidUrl"`
+	Secret     string
 	WebhookForm
 }
 
@@ -328,6 +368,25 @@ func (f *NewMatrixHookForm) Validate(ctx *macaron.Context, errs binding.Errors)
 	return validate(errs, ctx.Data, f, ctx.Locale)
 }
 
+// NewCustomHookForm form for creating custom hook
+type NewCustomHookForm struct {
+	PayloadURL         string `binding:"Required;ValidUrl"`
+	HTTPMethod         string `binding:"Required;In(POST,PUT,PATCH)"`
+	ContentType        string `binding:"Required;MaxSize(255)" locale:"repo.settings.custom.content_type"`
+	Headers            string
+	Template           string `binding:"Required" locale:"repo.settings.custom.template"`
+	Secret             string
+	SignatureAlgorithm string `binding:"In(,sha1,sha256,sha512)"`
+	SignTimestamp      bool
+	Preview            bool
+	WebhookForm
+}
+
+// Validate validates the fields
+func (f *NewCustomHookForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
 // NewMSTeamsHookForm form for creating MS Teams hook
 type NewMSTeamsHookForm struct {
 	PayloadURL string `binding:"Required;ValidUrl"`
@@ -342,6 +401,7 @@ func (f *NewMSTeamsHookForm) Validate(ctx *macaron.Context, errs binding.Errors)
 // NewFeishuHookForm form for creating feishu hook
 type NewFeishuHookForm struct {
 	PayloadURL string `binding:"Required;ValidUrl"`
+	Secret     string
 	WebhookForm
 }
 
@@ -441,6 +501,31 @@ func (f *CreateMilestoneForm) Validate(ctx *macaron.Context, errs binding.Errors
 	return validate(errs, ctx.Data, f, ctx.Locale)
 }
 
+// CreateProjectForm form for creating a project
+type CreateProjectForm struct {
+	Title     string `binding:"Required;MaxSize(100)"`
+	Content   string
+	BoardType models.ProjectBoardType
+}
+
+// Validate validates the fields
+func (f *CreateProjectForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
+// EditProjectBoardForm is a form for creating or editing a project board
+type EditProjectBoardForm struct {
+	Title     string `binding:"Required;MaxSize(100)"`
+	Sorting   int8
+	IsDefault bool
+	IsDone    bool
+}
+
+// Validate validates the fields
+func (f *EditProjectBoardForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
 // .____          ___.          .__
 // |    |   _____ \_ |__   ____ |  |
 // |    |   \__  \ | __ \_/ __ \|  |
@@ -482,11 +567,13 @@ func (f *InitializeLabelsForm) Validate(ctx *macaron.Context, errs binding.Error
 // swagger:model MergePullRequestOption
 type MergePullRequestForm struct {
 	// required: true
-	// enum: merge,rebase,rebase-merge,squash
-	Do                string `binding:"Required;In(merge,rebase,rebase-merge,squash)"`
+	// enum: merge,rebase,rebase-merge,squash,fast-forward-only,semi-linear
+	Do                string `binding:"Required;In(merge,rebase,rebase-merge,squash,fast-forward-only,semi-linear)"`
 	MergeTitleField   string
 	MergeMessageField string
 	ForceMerge        *bool `json:"force_merge,omitempty"`
+	// schedule the merge to happen as soon as all required checks succeed
+	MergeWhenChecksSucceed bool `json:"merge_when_checks_succeed,omitempty"`
 }
 
 // Validate validates the fields
@@ -499,6 +586,8 @@ type CodeCommentForm struct {
 	Content        string `binding:"Required"`
 	Side           string `binding:"Required;In(previous,proposed)"`
 	Line           int64
+	StartLine      int64
+	IsFileComment  bool   `form:"is_file_comment"`
 	TreePath       string `form:"path" binding:"Required"`
 	IsReview       bool   `form:"is_review"`
 	Reply          int64  `form:"reply"`
@@ -544,6 +633,17 @@ func (f SubmitReviewForm) HasEmptyContent() bool {
 		len(strings.TrimSpace(f.Content)) == 0
 }
 
+// ApplySuggestionsForm form for committing the changes suggested in code comments
+type ApplySuggestionsForm struct {
+	CommentIDs []int64 `form:"comment_ids" binding:"Required"`
+	Message    string
+}
+
+// Validate validates the fields
+func (f *ApplySuggestionsForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
 // __________       .__
 // \______   \ ____ |  |   ____ _____    ______ ____
 //  |       _// __ \|  | _/ __ \\__  \  /  ___// __ \
@@ -0,0 +1,279 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package saml provides the service provider side of SAML 2.0 web browser
+// single sign-on against an external identity provider
+package saml
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/crewjam/saml"
+)
+
+// Source holds the configuration of a SAML 2.0 identity provider
+type Source struct {
+	IdentityProviderMetadata    string // metadata XML of the identity provider
+	IdentityProviderMetadataURL string // URL the metadata was imported from
+	SkipVerify                  bool   // skip TLS verification when fetching the metadata
+	ServiceProviderCertificate  string // PEM encoded certificate of the service provider
+	ServiceProviderPrivateKey   string // PEM encoded RSA private key of the service provider
+	AttributeUsername           string // Username attribute, the NameID is used if empty
+	AttributeEmail              string // E-mail attribute
+	AttributeFullName           string // Full name attribute
+	AttributeGroups             string // Group membership attribute
+	GroupTeamMap                string // JSON mapping of groups to organization teams
+	GroupTeamMapRemoval         bool   // remove users from mapped teams of groups they are not in
+}
+
+// UserInfo holds the user details asserted by the identity provider
+type UserInfo struct {
+	NameID     string
+	Username   string
+	Email      string
+	FullName   string
+	Groups     []string
+	Attributes map[string]interface{}
+}
+
+// ErrNoSingleSignOnService is returned if the identity provider metadata
+// does not provide a usable single sign-on endpoint
+var ErrNoSingleSignOnService = errors.New("identity provider has no single sign-on service with HTTP-Redirect binding")
+
+// ParseMetadata parses the metadata XML of an identity provider, an entities
+// descriptor is searched for the first identity provider it contains
+func ParseMetadata(data []byte) (*saml.EntityDescriptor, error) {
+	entity := &saml.EntityDescriptor{}
+	err := xml.Unmarshal(data, entity)
+	if err != nil && strings.Contains(err.Error(), "but have <EntitiesDescriptor>") {
+		entities := &saml.EntitiesDescriptor{}
+		if err := xml.Unmarshal(data, entities); err != nil {
+			return nil, err
+		}
+		for i := range entities.EntityDescriptors {
+			if len(entities.EntityDescriptors[i].IDPSSODescriptors) > 0 {
+				return &entities.EntityDescriptors[i], nil
+			}
+		}
+		return nil, errors.New("no entity with an identity provider descriptor found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(entity.IDPSSODescriptors) == 0 {
+		return nil, errors.New("entity has no identity provider descriptor")
+	}
+	return entity, nil
+}
+
+// FetchMetadata downloads and validates the metadata XML of an identity provider
+func FetchMetadata(metadataURL string, skipVerify bool) (string, error) {
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: skipVerify},
+		},
+	}
+	resp, err := client.Get(metadataURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status fetching metadata: %s", resp.Status)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	if _, err := ParseMetadata(data); err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// GenerateKeyPair generates a self-signed certificate and RSA private key for
+// signing and decryption by the service provider, both PEM encoded
+func GenerateKeyPair(commonName string) (string, string, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return "", "", err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return "", "", err
+	}
+
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	priv := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return string(cert), string(priv), nil
+}
+
+func parseKeyPair(certPEM, keyPEM string) (*x509.Certificate, *rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(certPEM))
+	if block == nil {
+		return nil, nil, errors.New("invalid service provider certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	block, _ = pem.Decode([]byte(keyPEM))
+	if block == nil {
+		return nil, nil, errors.New("invalid service provider private key")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return cert, key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, nil, errors.New("service provider private key is not an RSA key")
+	}
+	return cert, key, nil
+}
+
+// BaseURL returns the URL below which the service provider endpoints of the
+// login source with the given name are served
+func BaseURL(name string) string {
+	return setting.AppURL + "user/saml/" + url.PathEscape(name)
+}
+
+// ServiceProvider returns the service provider for the login source with the
+// given name, its metadata is served at BaseURL/metadata and responses are
+// consumed at BaseURL/acs
+func (source *Source) ServiceProvider(name string) (*saml.ServiceProvider, error) {
+	cert, key, err := parseKeyPair(source.ServiceProviderCertificate, source.ServiceProviderPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	idpMetadata, err := ParseMetadata([]byte(source.IdentityProviderMetadata))
+	if err != nil {
+		return nil, fmt.Errorf("invalid identity provider metadata: %v", err)
+	}
+
+	baseURL := BaseURL(name)
+	metadataURL, err := url.Parse(baseURL + "/metadata")
+	if err != nil {
+		return nil, err
+	}
+	acsURL, err := url.Parse(baseURL + "/acs")
+	if err != nil {
+		return nil, err
+	}
+
+	sp := &saml.ServiceProvider{
+		Key:               key,
+		Certificate:       cert,
+		MetadataURL:       *metadataURL,
+		AcsURL:            *acsURL,
+		IDPMetadata:       idpMetadata,
+		AuthnNameIDFormat: saml.UnspecifiedNameIDFormat,
+	}
+	if sp.GetSSOBindingLocation(saml.HTTPRedirectBinding) == "" {
+		return nil, ErrNoSingleSignOnService
+	}
+	return sp, nil
+}
+
+// attributeValues returns the values of the attribute matching name by either
+// its name or friendly name
+func attributeValues(assertion *saml.Assertion, name string) []string {
+	if name == "" {
+		return nil
+	}
+	var values []string
+	for _, statement := range assertion.AttributeStatements {
+		for _, attr := range statement.Attributes {
+			if attr.Name != name && attr.FriendlyName != name {
+				continue
+			}
+			for _, value := range attr.Values {
+				if v := strings.TrimSpace(value.Value); v != "" {
+					values = append(values, v)
+				}
+			}
+		}
+	}
+	return values
+}
+
+func firstValue(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// UserInfo maps a verified assertion to the user details using the
+// configured attribute names
+func (source *Source) UserInfo(assertion *saml.Assertion) (*UserInfo, error) {
+	if assertion.Subject == nil || assertion.Subject.NameID == nil || strings.TrimSpace(assertion.Subject.NameID.Value) == "" {
+		return nil, errors.New("assertion has no subject name identifier")
+	}
+
+	info := &UserInfo{
+		NameID:     strings.TrimSpace(assertion.Subject.NameID.Value),
+		Username:   firstValue(attributeValues(assertion, source.AttributeUsername)),
+		Email:      firstValue(attributeValues(assertion, source.AttributeEmail)),
+		FullName:   firstValue(attributeValues(assertion, source.AttributeFullName)),
+		Groups:     attributeValues(assertion, source.AttributeGroups),
+		Attributes: make(map[string]interface{}),
+	}
+	if info.Username == "" {
+		info.Username = info.NameID
+	}
+
+	for _, statement := range assertion.AttributeStatements {
+		for _, attr := range statement.Attributes {
+			name := attr.Name
+			if attr.FriendlyName != "" {
+				name = attr.FriendlyName
+			}
+			values := make([]string, 0, len(attr.Values))
+			for _, value := range attr.Values {
+				values = append(values, value.Value)
+			}
+			info.Attributes[name] = values
+		}
+	}
+	return info, nil
+}
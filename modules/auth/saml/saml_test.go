@@ -0,0 +1,126 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package saml
+
+import (
+	"encoding/xml"
+	"net/url"
+	"testing"
+
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/crewjam/saml"
+	"github.com/stretchr/testify/assert"
+)
+
+func testIdentityProviderMetadata(t *testing.T) string {
+	certPEM, keyPEM, err := GenerateKeyPair("idp")
+	assert.NoError(t, err)
+	cert, key, err := parseKeyPair(certPEM, keyPEM)
+	assert.NoError(t, err)
+
+	metadataURL, _ := url.Parse("https://idp.example.com/metadata")
+	ssoURL, _ := url.Parse("https://idp.example.com/sso")
+	idp := &saml.IdentityProvider{
+		Key:         key,
+		Certificate: cert,
+		MetadataURL: *metadataURL,
+		SSOURL:      *ssoURL,
+	}
+	data, err := xml.Marshal(idp.Metadata())
+	assert.NoError(t, err)
+	return string(data)
+}
+
+func TestParseMetadata(t *testing.T) {
+	metadata := testIdentityProviderMetadata(t)
+
+	entity, err := ParseMetadata([]byte(metadata))
+	assert.NoError(t, err)
+	assert.Equal(t, "https://idp.example.com/metadata", entity.EntityID)
+
+	entities := `<EntitiesDescriptor xmlns="urn:oasis:names:tc:SAML:2.0:metadata">` +
+		`<EntityDescriptor entityID="https://sp.example.com"><SPSSODescriptor protocolSupportEnumeration="urn:oasis:names:tc:SAML:2.0:protocol"></SPSSODescriptor></EntityDescriptor>` +
+		metadata + `</EntitiesDescriptor>`
+	entity, err = ParseMetadata([]byte(entities))
+	assert.NoError(t, err)
+	assert.Equal(t, "https://idp.example.com/metadata", entity.EntityID)
+
+	_, err = ParseMetadata([]byte(`<EntityDescriptor xmlns="urn:oasis:names:tc:SAML:2.0:metadata" entityID="https://sp.example.com"></EntityDescriptor>`))
+	assert.Error(t, err)
+	_, err = ParseMetadata([]byte("not xml"))
+	assert.Error(t, err)
+}
+
+func TestSource_ServiceProvider(t *testing.T) {
+	oldAppURL := setting.AppURL
+	setting.AppURL = "https://gitea.example.com/"
+	defer func() {
+		setting.AppURL = oldAppURL
+	}()
+
+	cert, key, err := GenerateKeyPair("gitea")
+	assert.NoError(t, err)
+	source := &Source{
+		IdentityProviderMetadata:   testIdentityProviderMetadata(t),
+		ServiceProviderCertificate: cert,
+		ServiceProviderPrivateKey:  key,
+	}
+
+	sp, err := source.ServiceProvider("my idp")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://gitea.example.com/user/saml/my%20idp/metadata", sp.MetadataURL.String())
+	assert.Equal(t, "https://gitea.example.com/user/saml/my%20idp/acs", sp.AcsURL.String())
+	assert.Equal(t, "https://idp.example.com/sso", sp.GetSSOBindingLocation(saml.HTTPRedirectBinding))
+
+	source.ServiceProviderPrivateKey = "invalid"
+	_, err = source.ServiceProvider("my idp")
+	assert.Error(t, err)
+}
+
+func TestSource_UserInfo(t *testing.T) {
+	source := &Source{
+		AttributeUsername: "uid",
+		AttributeEmail:    "urn:oid:0.9.2342.19200300.100.1.3",
+		AttributeFullName: "cn",
+		AttributeGroups:   "groups",
+	}
+	attribute := func(name, friendlyName string, values ...string) saml.Attribute {
+		attr := saml.Attribute{Name: name, FriendlyName: friendlyName}
+		for _, value := range values {
+			attr.Values = append(attr.Values, saml.AttributeValue{Value: value})
+		}
+		return attr
+	}
+	assertion := &saml.Assertion{
+		Subject: &saml.Subject{NameID: &saml.NameID{Value: " subject-1 "}},
+		AttributeStatements: []saml.AttributeStatement{{
+			Attributes: []saml.Attribute{
+				attribute("urn:oid:0.9.2342.19200300.100.1.1", "uid", "jdoe"),
+				attribute("urn:oid:0.9.2342.19200300.100.1.3", "mail", "jdoe@example.com"),
+				attribute("cn", "", "John Doe"),
+				attribute("groups", "", "developers", " ", "testers"),
+			},
+		}},
+	}
+
+	info, err := source.UserInfo(assertion)
+	assert.NoError(t, err)
+	assert.Equal(t, "subject-1", info.NameID)
+	assert.Equal(t, "jdoe", info.Username)
+	assert.Equal(t, "jdoe@example.com", info.Email)
+	assert.Equal(t, "John Doe", info.FullName)
+	assert.Equal(t, []string{"developers", "testers"}, info.Groups)
+	assert.Equal(t, []string{"jdoe@example.com"}, info.Attributes["mail"])
+
+	source.AttributeUsername = ""
+	info, err = source.UserInfo(assertion)
+	assert.NoError(t, err)
+	assert.Equal(t, "subject-1", info.Username)
+
+	assertion.Subject = nil
+	_, err = source.UserInfo(assertion)
+	assert.Error(t, err)
+}
@@ -12,6 +12,7 @@ import (
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification"
 	"code.gitea.io/gitea/modules/setting"
 
 	"gitea.com/macaron/macaron"
@@ -172,6 +173,7 @@ func (s *SSPI) newUser(ctx *macaron.Context, username string, cfg *models.SSPICo
 	if err := models.CreateUser(user); err != nil {
 		return nil, err
 	}
+	notification.NotifyCreateUser(user, user)
 	return user, nil
 }
 
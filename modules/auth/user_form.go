@@ -302,7 +302,10 @@ func (f *AddKeyForm) Validate(ctx *macaron.Context, errs binding.Errors) binding
 
 // NewAccessTokenForm form for creating access token
 type NewAccessTokenForm struct {
-	Name string `binding:"Required;MaxSize(255)"`
+	Name         string `binding:"Required;MaxSize(255)"`
+	Scopes       []string
+	Repositories string
+	ExpiresAt    string
 }
 
 // Validate validates the fields
@@ -341,22 +344,23 @@ func (f *TwoFactorScratchAuthForm) Validate(ctx *macaron.Context, errs binding.E
 	return validate(errs, ctx.Data, f, ctx.Locale)
 }
 
-// U2FRegistrationForm for reserving an U2F name
-type U2FRegistrationForm struct {
-	Name string `binding:"Required"`
+// WebAuthnRegistrationForm for reserving a WebAuthn credential name
+type WebAuthnRegistrationForm struct {
+	Name         string `binding:"Required;MaxSize(255)"`
+	Passwordless bool
 }
 
 // Validate validates the fields
-func (f *U2FRegistrationForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+func (f *WebAuthnRegistrationForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
 	return validate(errs, ctx.Data, f, ctx.Locale)
 }
 
-// U2FDeleteForm for deleting U2F keys
-type U2FDeleteForm struct {
+// WebAuthnDeleteForm for deleting WebAuthn credentials
+type WebAuthnDeleteForm struct {
 	ID int64 `binding:"Required"`
 }
 
 // Validate validates the fields
-func (f *U2FDeleteForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+func (f *WebAuthnDeleteForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
 	return validate(errs, ctx.Data, f, ctx.Locale)
 }
@@ -0,0 +1,362 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/duo-labs/webauthn/protocol"
+	"github.com/duo-labs/webauthn/protocol/webauthncose"
+	"github.com/fxamacker/cbor/v2"
+)
+
+// The responses of the authenticators are parsed and verified with the protocol
+// package of github.com/duo-labs/webauthn. Attestation is not requested, so any
+// authenticator, including platform authenticators, can be registered.
+
+const (
+	challengeSize = 32
+	timeout       = 60000
+
+	credentialType = "public-key"
+)
+
+// SupportedAlgorithms are the COSE algorithms accepted for new credentials in order
+// of preference
+var SupportedAlgorithms = []int64{
+	int64(webauthncose.AlgES256),
+	int64(webauthncose.AlgEdDSA),
+	int64(webauthncose.AlgRS256),
+}
+
+var (
+	// ErrInvalidSignature is returned if the signature of an assertion is invalid
+	ErrInvalidSignature = errors.New("webauthn: invalid signature")
+	// ErrSignCountRegression is returned if the signature counter of an authenticator
+	// did not increase, which indicates a cloned authenticator
+	ErrSignCountRegression = errors.New("webauthn: signature counter did not increase")
+)
+
+// RelyingParty identifies Gitea to the authenticator
+type RelyingParty struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name"`
+}
+
+// UserEntity identifies the user a credential is created for
+type UserEntity struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// CredentialParameter is a type of credential accepted for registration
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+// CredentialDescriptor identifies an existing credential
+type CredentialDescriptor struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// AuthenticatorSelection are the requirements for the authenticator of a new credential
+type AuthenticatorSelection struct {
+	ResidentKey        string `json:"residentKey"`
+	RequireResidentKey bool   `json:"requireResidentKey"`
+	UserVerification   string `json:"userVerification"`
+}
+
+// CreationOptions are the options of navigator.credentials.create, binary values
+// are base64url encoded
+type CreationOptions struct {
+	Challenge              string                 `json:"challenge"`
+	RP                     RelyingParty           `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int                    `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestExtensions are the extensions requested for an assertion
+type RequestExtensions struct {
+	AppID string `json:"appid,omitempty"`
+}
+
+// RequestOptions are the options of navigator.credentials.get, binary values are
+// base64url encoded
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	Timeout          int                    `json:"timeout"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+	Extensions       RequestExtensions      `json:"extensions"`
+}
+
+// SessionData is the state of a ceremony kept in the session between sending the
+// options and receiving the response of the authenticator
+type SessionData struct {
+	Challenge        string
+	UserID           int64
+	UserVerification bool
+}
+
+// AuthenticatorResponse is the response of the authenticator, binary values are
+// base64url encoded
+type AuthenticatorResponse struct {
+	ClientDataJSON    string `json:"clientDataJSON"`
+	AttestationObject string `json:"attestationObject"`
+	AuthenticatorData string `json:"authenticatorData"`
+	Signature         string `json:"signature"`
+	UserHandle        string `json:"userHandle"`
+}
+
+// ClientExtensionResults are the results of the requested extensions
+type ClientExtensionResults struct {
+	AppID bool `json:"appid"`
+}
+
+// CredentialResponse is a PublicKeyCredential returned by the browser
+type CredentialResponse struct {
+	ID                     string                 `json:"id"`
+	RawID                  string                 `json:"rawId"`
+	Type                   string                 `json:"type"`
+	Response               AuthenticatorResponse  `json:"response"`
+	ClientExtensionResults ClientExtensionResults `json:"clientExtensionResults"`
+}
+
+// Credential is a credential of a user as it is stored
+type Credential struct {
+	ID        []byte
+	PublicKey []byte
+	AAGUID    []byte
+	SignCount uint32
+	// LegacyU2F is set for credentials registered with the U2F API, these are
+	// scoped to the legacy App ID instead of the RP ID
+	LegacyU2F bool
+}
+
+// EncodeToString encodes binary values as they are exchanged with the browser
+func EncodeToString(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeString decodes binary values sent by the browser
+func DecodeString(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// CredentialID returns the raw ID of the credential of the response
+func (resp *CredentialResponse) CredentialID() ([]byte, error) {
+	if resp.Type != credentialType {
+		return nil, fmt.Errorf("webauthn: unsupported credential type %q", resp.Type)
+	}
+	return DecodeString(resp.RawID)
+}
+
+// UserID returns the ID of the user the credential belongs to as it is returned for
+// discoverable credentials, it is 0 if the authenticator did not return a user handle
+func (resp *CredentialResponse) UserID() (int64, error) {
+	handle, err := DecodeString(resp.Response.UserHandle)
+	if err != nil || len(handle) == 0 {
+		return 0, err
+	}
+	return strconv.ParseInt(string(handle), 10, 64)
+}
+
+func newChallenge() (string, error) {
+	challenge := make([]byte, challengeSize)
+	if _, err := rand.Read(challenge); err != nil {
+		return "", err
+	}
+	return EncodeToString(challenge), nil
+}
+
+func userVerificationRequirement(required bool) string {
+	if required {
+		return "required"
+	}
+	return "discouraged"
+}
+
+// BeginRegistration returns the options to create a new credential for the user
+// which must not be one of the excluded credentials. Passwordless credentials are
+// discoverable and require user verification.
+func BeginRegistration(userID int64, name, displayName string, exclude [][]byte, passwordless bool) (*CreationOptions, *SessionData, error) {
+	challenge, err := newChallenge()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	opts := &CreationOptions{
+		Challenge: challenge,
+		RP: RelyingParty{
+			ID:   setting.WebAuthn.RPID,
+			Name: setting.WebAuthn.RPName,
+		},
+		User: UserEntity{
+			ID:          EncodeToString([]byte(strconv.FormatInt(userID, 10))),
+			Name:        name,
+			DisplayName: displayName,
+		},
+		PubKeyCredParams:   make([]CredentialParameter, 0, len(SupportedAlgorithms)),
+		Timeout:            timeout,
+		ExcludeCredentials: make([]CredentialDescriptor, 0, len(exclude)),
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:      "discouraged",
+			UserVerification: userVerificationRequirement(passwordless),
+		},
+		Attestation: "none",
+	}
+	if passwordless {
+		opts.AuthenticatorSelection.ResidentKey = "required"
+		opts.AuthenticatorSelection.RequireResidentKey = true
+	}
+	for _, alg := range SupportedAlgorithms {
+		opts.PubKeyCredParams = append(opts.PubKeyCredParams, CredentialParameter{Type: credentialType, Alg: alg})
+	}
+	for _, id := range exclude {
+		opts.ExcludeCredentials = append(opts.ExcludeCredentials, CredentialDescriptor{Type: credentialType, ID: EncodeToString(id)})
+	}
+
+	return opts, &SessionData{
+		Challenge:        challenge,
+		UserID:           userID,
+		UserVerification: passwordless,
+	}, nil
+}
+
+// FinishRegistration verifies the response of the authenticator to the creation
+// options and returns the new credential
+func FinishRegistration(session *SessionData, resp *CredentialResponse) (*Credential, error) {
+	id, err := resp.CredentialID()
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := protocol.ParseCredentialCreationResponseBody(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if err := parsed.Verify(session.Challenge, session.UserVerification, setting.WebAuthn.RPID, setting.WebAuthn.Origin); err != nil {
+		return nil, err
+	}
+
+	attested := parsed.Response.AttestationObject.AuthData.AttData
+	if !bytes.Equal(attested.CredentialID, id) {
+		return nil, errors.New("webauthn: credential ID does not match")
+	}
+	if err := checkPublicKey(attested.CredentialPublicKey); err != nil {
+		return nil, err
+	}
+
+	return &Credential{
+		ID:        attested.CredentialID,
+		PublicKey: attested.CredentialPublicKey,
+		AAGUID:    attested.AAGUID,
+		SignCount: parsed.Response.AttestationObject.AuthData.Counter,
+	}, nil
+}
+
+// BeginLogin returns the options to get an assertion of one of the given credentials
+// of a user. Without credentials any discoverable credential is allowed and user
+// verification is required, which signs the user in without a password.
+func BeginLogin(userID int64, credentials []*Credential) (*RequestOptions, *SessionData, error) {
+	challenge, err := newChallenge()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	passwordless := len(credentials) == 0
+	opts := &RequestOptions{
+		Challenge:        challenge,
+		Timeout:          timeout,
+		RPID:             setting.WebAuthn.RPID,
+		AllowCredentials: make([]CredentialDescriptor, 0, len(credentials)),
+		UserVerification: userVerificationRequirement(passwordless),
+	}
+	for _, cred := range credentials {
+		opts.AllowCredentials = append(opts.AllowCredentials, CredentialDescriptor{Type: credentialType, ID: EncodeToString(cred.ID)})
+		if cred.LegacyU2F {
+			opts.Extensions.AppID = setting.WebAuthn.LegacyAppID
+		}
+	}
+
+	return opts, &SessionData{
+		Challenge:        challenge,
+		UserID:           userID,
+		UserVerification: passwordless,
+	}, nil
+}
+
+// FinishLogin verifies the assertion of the credential and returns the new value of
+// its signature counter
+func FinishLogin(session *SessionData, resp *CredentialResponse, cred *Credential) (uint32, error) {
+	id, err := resp.CredentialID()
+	if err != nil {
+		return 0, err
+	}
+	if !bytes.Equal(id, cred.ID) {
+		return 0, errors.New("webauthn: credential ID does not match")
+	}
+	body, err := json.Marshal(resp)
+	if err != nil {
+		return 0, err
+	}
+	parsed, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	rpID := setting.WebAuthn.RPID
+	if cred.LegacyU2F && resp.ClientExtensionResults.AppID {
+		rpID = setting.WebAuthn.LegacyAppID
+	}
+	if err := parsed.Verify(session.Challenge, rpID, setting.WebAuthn.Origin, session.UserVerification, cred.PublicKey); err != nil {
+		if protocolErr, ok := err.(*protocol.Error); ok && protocolErr.Type == protocol.ErrAssertionSignature.Type {
+			return 0, ErrInvalidSignature
+		}
+		return 0, err
+	}
+
+	// Authenticators without a counter always return 0
+	signCount := parsed.Response.AuthenticatorData.Counter
+	if (signCount != 0 || cred.SignCount != 0) && signCount <= cred.SignCount {
+		return 0, ErrSignCountRegression
+	}
+	return signCount, nil
+}
+
+// checkPublicKey checks that the credential public key uses one of the supported algorithms
+func checkPublicKey(data []byte) error {
+	var key webauthncose.PublicKeyData
+	if err := cbor.Unmarshal(data, &key); err != nil {
+		return fmt.Errorf("webauthn: invalid public key: %v", err)
+	}
+	for _, alg := range SupportedAlgorithms {
+		if key.Algorithm == alg {
+			_, err := webauthncose.ParsePublicKey(data)
+			return err
+		}
+	}
+	return fmt.Errorf("webauthn: unsupported public key algorithm %d", key.Algorithm)
+}
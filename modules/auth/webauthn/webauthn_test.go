@@ -0,0 +1,218 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/duo-labs/webauthn/protocol"
+	"github.com/duo-labs/webauthn/protocol/webauthncose"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	flagUserPresent  = byte(protocol.FlagUserPresent)
+	flagUserVerified = byte(protocol.FlagUserVerified)
+)
+
+func coseKey(pub *ecdsa.PublicKey) []byte {
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	xb, yb := pub.X.Bytes(), pub.Y.Bytes()
+	copy(x[32-len(xb):], xb)
+	copy(y[32-len(yb):], yb)
+	key, _ := cbor.Marshal(&webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  1, // P-256
+		XCoord: x,
+		YCoord: y,
+	})
+	return key
+}
+
+type testAuthenticator struct {
+	t         *testing.T
+	key       *ecdsa.PrivateKey
+	id        []byte
+	signCount uint32
+}
+
+func newTestAuthenticator(t *testing.T) *testAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	return &testAuthenticator{t: t, key: key, id: []byte("credential-id")}
+}
+
+func (a *testAuthenticator) authData(rpID string, flags byte, attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	data := append(rpIDHash[:], flags, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(data[33:], a.signCount)
+	if attested {
+		data[32] |= byte(protocol.FlagAttestedCredentialData)
+		data = append(data, make([]byte, 16)...)
+		data = append(data, byte(len(a.id)>>8), byte(len(a.id)))
+		data = append(data, a.id...)
+		data = append(data, coseKey(&a.key.PublicKey)...)
+	}
+	return data
+}
+
+func clientDataJSON(typ, challenge, origin string) string {
+	data, _ := json.Marshal(&protocol.CollectedClientData{Type: protocol.CeremonyType(typ), Challenge: challenge, Origin: origin})
+	return EncodeToString(data)
+}
+
+func (a *testAuthenticator) create(session *SessionData, flags byte) *CredentialResponse {
+	attestation, err := cbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": a.authData(setting.WebAuthn.RPID, flags, true),
+	})
+	assert.NoError(a.t, err)
+
+	return &CredentialResponse{
+		ID:    EncodeToString(a.id),
+		RawID: EncodeToString(a.id),
+		Type:  "public-key",
+		Response: AuthenticatorResponse{
+			ClientDataJSON:    clientDataJSON("webauthn.create", session.Challenge, setting.WebAuthn.Origin),
+			AttestationObject: EncodeToString(attestation),
+		},
+	}
+}
+
+func (a *testAuthenticator) get(session *SessionData, rpID string, flags byte) *CredentialResponse {
+	a.signCount++
+	authData := a.authData(rpID, flags, false)
+	clientData := clientDataJSON("webauthn.get", session.Challenge, setting.WebAuthn.Origin)
+	raw, _ := DecodeString(clientData)
+	clientDataHash := sha256.Sum256(raw)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	r, s, err := ecdsa.Sign(rand.Reader, a.key, digest[:])
+	assert.NoError(a.t, err)
+	sig, err := asn1.Marshal(struct{ R, S interface{} }{r, s})
+	assert.NoError(a.t, err)
+
+	return &CredentialResponse{
+		ID:    EncodeToString(a.id),
+		RawID: EncodeToString(a.id),
+		Type:  "public-key",
+		Response: AuthenticatorResponse{
+			ClientDataJSON:    clientData,
+			AuthenticatorData: EncodeToString(authData),
+			Signature:         EncodeToString(sig),
+			UserHandle:        EncodeToString([]byte("1")),
+		},
+	}
+}
+
+func setTestSettings() {
+	setting.WebAuthn.RPID = "localhost"
+	setting.WebAuthn.RPName = "Gitea"
+	setting.WebAuthn.Origin = "http://localhost:3000"
+	setting.WebAuthn.LegacyAppID = "http://localhost:3000"
+}
+
+func TestRegistrationAndLogin(t *testing.T) {
+	setTestSettings()
+	a := newTestAuthenticator(t)
+
+	opts, session, err := BeginRegistration(1, "user1", "User One", [][]byte{[]byte("other")}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost", opts.RP.ID)
+	assert.Equal(t, EncodeToString([]byte("1")), opts.User.ID)
+	assert.Len(t, opts.ExcludeCredentials, 1)
+	assert.Equal(t, "discouraged", opts.AuthenticatorSelection.UserVerification)
+
+	cred, err := FinishRegistration(session, a.create(session, flagUserPresent))
+	assert.NoError(t, err)
+	assert.Equal(t, a.id, cred.ID)
+	assert.Equal(t, coseKey(&a.key.PublicKey), cred.PublicKey)
+
+	_, err = FinishRegistration(&SessionData{Challenge: "other"}, a.create(session, flagUserPresent))
+	assert.Error(t, err)
+
+	opts2, session2, err := BeginLogin(1, []*Credential{cred})
+	assert.NoError(t, err)
+	assert.Len(t, opts2.AllowCredentials, 1)
+	assert.Empty(t, opts2.Extensions.AppID)
+
+	count, err := FinishLogin(session2, a.get(session2, "localhost", flagUserPresent), cred)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+	cred.SignCount = count
+
+	// replayed or cloned authenticators do not increase the counter
+	a.signCount = 0
+	_, err = FinishLogin(session2, a.get(session2, "localhost", flagUserPresent), cred)
+	assert.Equal(t, ErrSignCountRegression, err)
+
+	_, err = FinishLogin(session2, a.get(session2, "example.com", flagUserPresent), cred)
+	assert.Error(t, err)
+
+	resp := a.get(session2, "localhost", flagUserPresent)
+	resp.Response.Signature = EncodeToString([]byte{0x30, 0x00})
+	_, err = FinishLogin(session2, resp, cred)
+	assert.Equal(t, ErrInvalidSignature, err)
+}
+
+func TestPasswordlessLogin(t *testing.T) {
+	setTestSettings()
+	a := newTestAuthenticator(t)
+
+	opts, session, err := BeginRegistration(1, "user1", "User One", nil, true)
+	assert.NoError(t, err)
+	assert.True(t, opts.AuthenticatorSelection.RequireResidentKey)
+	assert.Equal(t, "required", opts.AuthenticatorSelection.UserVerification)
+
+	_, err = FinishRegistration(session, a.create(session, flagUserPresent))
+	assert.Error(t, err)
+	cred, err := FinishRegistration(session, a.create(session, flagUserPresent|flagUserVerified))
+	assert.NoError(t, err)
+
+	opts2, session2, err := BeginLogin(0, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, opts2.AllowCredentials)
+	assert.Equal(t, "required", opts2.UserVerification)
+
+	_, err = FinishLogin(session2, a.get(session2, "localhost", flagUserPresent), cred)
+	assert.Error(t, err)
+	resp := a.get(session2, "localhost", flagUserPresent|flagUserVerified)
+	_, err = FinishLogin(session2, resp, cred)
+	assert.NoError(t, err)
+	userID, err := resp.UserID()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, userID)
+}
+
+func TestLegacyU2FLogin(t *testing.T) {
+	setTestSettings()
+	a := newTestAuthenticator(t)
+	cred := &Credential{ID: a.id, PublicKey: coseKey(&a.key.PublicKey), LegacyU2F: true}
+
+	opts, session, err := BeginLogin(1, []*Credential{cred})
+	assert.NoError(t, err)
+	assert.Equal(t, "http://localhost:3000", opts.Extensions.AppID)
+
+	resp := a.get(session, setting.WebAuthn.LegacyAppID, flagUserPresent)
+	_, err = FinishLogin(session, resp, cred)
+	assert.Error(t, err)
+	resp.ClientExtensionResults.AppID = true
+	_, err = FinishLogin(session, resp, cred)
+	assert.NoError(t, err)
+}
@@ -44,8 +44,9 @@ type Context struct {
 	IsSigned    bool
 	IsBasicAuth bool
 
-	Repo *Repository
-	Org  *Organization
+	Repo    *Repository
+	Org     *Organization
+	Package *Package
 }
 
 // IsUserSiteAdmin returns true if current user is a site admin
@@ -342,6 +343,8 @@ func Contexter() macaron.Handler {
 
 		ctx.Data["EnableSwagger"] = setting.API.EnableSwagger
 		ctx.Data["EnableOpenIDSignIn"] = setting.Service.EnableOpenIDSignIn
+		ctx.Data["EnablePackages"] = setting.Packages.Enabled
+		ctx.Data["EnableActions"] = setting.Actions.Enabled
 
 		c.Map(ctx)
 	}
@@ -0,0 +1,129 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+
+	"gitea.com/macaron/macaron"
+)
+
+// Package contains the owner and the access mode of the package routes
+type Package struct {
+	Owner      *models.User
+	AccessMode models.AccessMode
+}
+
+// PackageAssignment returns a middleware to handle the package owner given by the :username parameter
+func PackageAssignment() macaron.Handler {
+	return func(ctx *Context) {
+		packageAssignment(ctx, func(status int, title string, obj interface{}) {
+			err, ok := obj.(error)
+			if !ok {
+				err = fmt.Errorf("%s", obj)
+			}
+			if status == http.StatusNotFound {
+				ctx.NotFound(title, err)
+			} else {
+				ctx.ServerError(title, err)
+			}
+		})
+	}
+}
+
+// PackageAssignmentAPI returns a middleware to handle the package owner given by the :username parameter
+func PackageAssignmentAPI() macaron.Handler {
+	return func(ctx *APIContext) {
+		packageAssignment(ctx.Context, ctx.Error)
+	}
+}
+
+// RequirePackageAccessAPI returns a middleware requiring the doer to have at least the access mode
+// to the packages of the owner. With askAuthentication, anonymous requests are asked to authenticate,
+// which is what package clients expect before they send their credentials; otherwise packages the
+// doer can't read are not found.
+func RequirePackageAccessAPI(accessMode models.AccessMode, askAuthentication bool) macaron.Handler {
+	return func(ctx *APIContext) {
+		if ctx.Package.AccessMode >= accessMode {
+			return
+		}
+		if askAuthentication && ctx.User == nil {
+			ctx.Resp.Header().Set("WWW-Authenticate", `Basic realm="Gitea Package API"`)
+			ctx.Error(http.StatusUnauthorized, "RequirePackageAccessAPI", "authentication required")
+			return
+		}
+		if !askAuthentication && accessMode == models.AccessModeRead {
+			ctx.NotFound()
+			return
+		}
+		ctx.Error(http.StatusForbidden, "RequirePackageAccessAPI", "user does not have the required permission")
+	}
+}
+
+func packageAssignment(ctx *Context, errCb func(int, string, interface{})) {
+	owner, err := models.GetUserByName(ctx.Params(":username"))
+	if err != nil {
+		if models.IsErrUserNotExist(err) {
+			errCb(http.StatusNotFound, "GetUserByName", err)
+		} else {
+			errCb(http.StatusInternalServerError, "GetUserByName", err)
+		}
+		return
+	}
+
+	accessMode, err := packageAccessMode(ctx.User, owner)
+	if err != nil {
+		errCb(http.StatusInternalServerError, "packageAccessMode", err)
+		return
+	}
+
+	ctx.Package = &Package{
+		Owner:      owner,
+		AccessMode: accessMode,
+	}
+	ctx.Data["PackageOwner"] = owner
+	ctx.Data["IsPackageWriter"] = accessMode >= models.AccessModeWrite
+}
+
+// packageAccessMode returns the access mode of the doer to the packages of the owner:
+// users publish their own packages and the members of an organization publish its packages.
+func packageAccessMode(doer, owner *models.User) (models.AccessMode, error) {
+	if doer != nil && doer.IsAdmin {
+		return models.AccessModeOwner, nil
+	}
+
+	if !owner.IsOrganization() {
+		if doer != nil && doer.ID == owner.ID {
+			return models.AccessModeOwner, nil
+		}
+		return models.AccessModeRead, nil
+	}
+
+	if !models.HasOrgVisible(owner, doer) {
+		return models.AccessModeNone, nil
+	}
+	if doer != nil {
+		isMember, err := owner.IsOrgMember(doer.ID)
+		if err != nil {
+			return models.AccessModeNone, err
+		}
+		if isMember {
+			return models.AccessModeWrite, nil
+		}
+	}
+	return models.AccessModeRead, nil
+}
+
+// RequirePackageWriter returns a middleware for requiring write access to the packages of the owner
+func RequirePackageWriter() macaron.Handler {
+	return func(ctx *Context) {
+		if ctx.Package.AccessMode < models.AccessModeWrite {
+			ctx.NotFound("RequirePackageWriter", nil)
+		}
+	}
+}
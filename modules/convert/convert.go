@@ -6,6 +6,7 @@ package convert
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 
 	"code.gitea.io/gitea/models"
@@ -119,6 +120,7 @@ func ToBranchProtection(bp *models.ProtectedBranch) *api.BranchProtection {
 		ApprovalsWhitelistTeams:     approvalsWhitelistTeams,
 		BlockOnRejectedReviews:      bp.BlockOnRejectedReviews,
 		BlockOnOutdatedBranch:       bp.BlockOnOutdatedBranch,
+		RequireCodeOwnerApproval:    bp.RequireCodeOwnerApproval,
 		DismissStaleApprovals:       bp.DismissStaleApprovals,
 		RequireSignedCommits:        bp.RequireSignedCommits,
 		ProtectedFilePatterns:       bp.ProtectedFilePatterns,
@@ -253,8 +255,10 @@ func ToGPGKeyEmail(email *models.EmailAddress) *api.GPGKeyEmail {
 // ToHook convert models.Webhook to api.Hook
 func ToHook(repoLink string, w *models.Webhook) *api.Hook {
 	config := map[string]string{
-		"url":          w.URL,
-		"content_type": w.ContentType.Name(),
+		"url":                 w.URL,
+		"content_type":        w.ContentType.Name(),
+		"signature_algorithm": string(w.SignatureHash()),
+		"sign_timestamp":      strconv.FormatBool(w.SignTimestamp),
 	}
 	if w.HookTaskType == models.SLACK {
 		s := webhook.GetSlackHook(w)
@@ -263,6 +267,12 @@ func ToHook(repoLink string, w *models.Webhook) *api.Hook {
 		config["icon_url"] = s.IconURL
 		config["color"] = s.Color
 	}
+	if w.HookTaskType == models.CUSTOM {
+		custom := webhook.GetCustomHook(w)
+		config["content_type"] = custom.ContentType
+		config["http_method"] = w.HTTPMethod
+		config["template"] = custom.Template
+	}
 
 	return &api.Hook{
 		ID:      w.ID,
@@ -276,6 +286,30 @@ func ToHook(repoLink string, w *models.Webhook) *api.Hook {
 	}
 }
 
+// ToHookDelivery convert models.HookTask to api.HookDelivery
+func ToHookDelivery(t *models.HookTask) *api.HookDelivery {
+	d := &api.HookDelivery{
+		ID:          t.ID,
+		UUID:        t.UUID,
+		Event:       t.EventType.Event(),
+		URL:         t.URL,
+		IsDelivered: t.IsDelivered,
+		IsSucceed:   t.IsSucceed,
+		Attempts:    t.Attempts,
+	}
+	if t.ResponseInfo != nil {
+		d.StatusCode = t.ResponseInfo.Status
+	}
+	if t.Delivered > 0 {
+		delivered := time.Unix(0, t.Delivered)
+		d.Delivered = &delivered
+	}
+	if !t.IsDelivered && t.NextAttemptUnix > 0 {
+		d.NextAttempt = t.NextAttemptUnix.AsTimePtr()
+	}
+	return d
+}
+
 // ToGitHook convert git.Hook to api.GitHook
 func ToGitHook(h *git.Hook) *api.GitHook {
 	return &api.GitHook{
@@ -413,3 +447,23 @@ func ToOAuth2Application(app *models.OAuth2Application) *api.OAuth2Application {
 		Created:      app.CreatedUnix.AsTime(),
 	}
 }
+
+// ToAuditEvent convert from models.AuditEvent to api.AuditEvent
+func ToAuditEvent(e *models.AuditEvent) *api.AuditEvent {
+	return &api.AuditEvent{
+		ID:         e.ID,
+		Action:     string(e.Action),
+		ActorID:    e.ActorID,
+		ActorName:  e.ActorName,
+		IPAddress:  e.IPAddress,
+		RepoID:     e.RepoID,
+		RepoName:   e.RepoName,
+		OrgID:      e.OrgID,
+		OrgName:    e.OrgName,
+		TargetType: string(e.TargetType),
+		TargetID:   e.TargetID,
+		TargetName: e.TargetName,
+		Details:    e.Details,
+		Created:    e.CreatedUnix.AsTime(),
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package convert
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/packages"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// ToAPIPackage converts Package into API Format, the owner has to be loaded
+func ToAPIPackage(p *models.Package) *api.Package {
+	return &api.Package{
+		ID:      p.ID,
+		Owner:   p.Owner.APIFormat(),
+		Type:    p.Type.Name(),
+		Name:    p.Name,
+		Created: p.CreatedUnix.AsTime(),
+		Updated: p.UpdatedUnix.AsTime(),
+	}
+}
+
+// ToAPIPackageVersion converts PackageVersion into API Format, the creator has to be loaded
+func ToAPIPackageVersion(p *models.Package, pv *models.PackageVersion) *api.PackageVersion {
+	return &api.PackageVersion{
+		ID:            pv.ID,
+		Package:       ToAPIPackage(p),
+		Creator:       pv.Creator.APIFormat(),
+		Version:       pv.Version,
+		DownloadCount: pv.DownloadCount,
+		Created:       pv.CreatedUnix.AsTime(),
+	}
+}
+
+// ToAPIPackageFile converts PackageFile into API Format, the blob has to be loaded
+func ToAPIPackageFile(p *models.Package, pv *models.PackageVersion, pf *models.PackageFile) *api.PackageFile {
+	return &api.PackageFile{
+		ID:          pf.ID,
+		Name:        pf.Name,
+		Size:        pf.Blob.Size,
+		HashMD5:     pf.Blob.HashMD5,
+		HashSHA1:    pf.Blob.HashSHA1,
+		HashSHA256:  pf.Blob.HashSHA256,
+		HashSHA512:  pf.Blob.HashSHA512,
+		DownloadURL: packages.FileURL(p, pv, pf.Name),
+	}
+}
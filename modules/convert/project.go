@@ -0,0 +1,68 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package convert
+
+import (
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// ToAPIProject converts Project into API Format,
+// the attributes and the issue counts of the project have to be loaded
+func ToAPIProject(p *models.Project) *api.Project {
+	apiProject := &api.Project{
+		ID:           p.ID,
+		Title:        p.Title,
+		Description:  p.Description,
+		Creator:      p.Creator.APIFormat(),
+		State:        api.StateOpen,
+		OpenIssues:   p.NumOpenIssues,
+		ClosedIssues: p.NumClosedIssues,
+		Created:      p.CreatedUnix.AsTime(),
+		Updated:      p.UpdatedUnix.AsTime(),
+	}
+	if p.IsClosed {
+		apiProject.State = api.StateClosed
+		apiProject.Closed = p.ClosedDateUnix.AsTimePtr()
+	}
+
+	switch p.Type {
+	case models.ProjectTypeRepository:
+		apiProject.Type = "repository"
+		apiProject.Repo = &api.RepositoryMeta{
+			ID:       p.Repo.ID,
+			Name:     p.Repo.Name,
+			Owner:    p.Repo.OwnerName,
+			FullName: p.Repo.FullName(),
+		}
+	case models.ProjectTypeOrganization:
+		apiProject.Type = "organization"
+		apiProject.Owner = p.Owner.APIFormat()
+	}
+	return apiProject
+}
+
+// ToAPIProjectBoard converts ProjectBoard into API Format
+func ToAPIProjectBoard(b *models.ProjectBoard) *api.ProjectBoard {
+	return &api.ProjectBoard{
+		ID:        b.ID,
+		ProjectID: b.ProjectID,
+		Title:     b.Title,
+		Position:  b.Sorting,
+		IsDefault: b.IsDefault,
+		IsDone:    b.IsDone,
+		Created:   b.CreatedUnix.AsTime(),
+		Updated:   b.UpdatedUnix.AsTime(),
+	}
+}
+
+// ToAPIProjectCard converts ProjectCard into API Format
+func ToAPIProjectCard(c *models.ProjectCard) *api.ProjectCard {
+	return &api.ProjectCard{
+		BoardID:  c.BoardID,
+		Position: c.Sorting,
+		Issue:    ToAPIIssue(c.Issue),
+	}
+}
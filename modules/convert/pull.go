@@ -106,7 +106,12 @@ func ToAPIPullRequest(pr *models.PullRequest) *api.PullRequest {
 		}
 		defer headGitRepo.Close()
 
-		headBranch, err = headGitRepo.GetBranch(pr.HeadBranch)
+		if pr.Flow == models.PullRequestFlowAGit {
+			// The head of an AGit flow pull request is only kept in its hidden ref
+			err = git.ErrBranchNotExist{Name: pr.HeadBranch}
+		} else {
+			headBranch, err = headGitRepo.GetBranch(pr.HeadBranch)
+		}
 		if err != nil && !git.IsErrBranchNotExist(err) {
 			log.Error("GetBranch[%s]: %v", pr.HeadBranch, err)
 			return nil
@@ -108,9 +108,12 @@ func ToPullReviewCommentList(review *models.Review, doer *models.User) ([]*api.P
 
 				if comment.Line < 0 {
 					apiComment.OldLineNum = comment.UnsignedLine()
+					apiComment.OldStartLineNum = comment.UnsignedStartLine()
 				} else {
 					apiComment.LineNum = comment.UnsignedLine()
+					apiComment.StartLineNum = comment.UnsignedStartLine()
 				}
+				apiComment.IsFileComment = comment.IsFileComment
 				apiComments = append(apiComments, apiComment)
 			}
 		}
@@ -11,6 +11,8 @@ import (
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/migrations"
 	repository_service "code.gitea.io/gitea/modules/repository"
+	"code.gitea.io/gitea/modules/setting"
+	actions_service "code.gitea.io/gitea/services/actions"
 	mirror_service "code.gitea.io/gitea/services/mirror"
 )
 
@@ -107,6 +109,16 @@ func registerUpdateMigrationPosterID() {
 	})
 }
 
+func registerStopZombieActionJobs() {
+	RegisterTaskFatal("stop_zombie_action_jobs", &BaseConfig{
+		Enabled:    true,
+		RunAtStart: true,
+		Schedule:   "@every 5m",
+	}, func(ctx context.Context, _ *models.User, _ Config) error {
+		return actions_service.StopZombieJobs(ctx)
+	})
+}
+
 func initBasicTasks() {
 	registerUpdateMirrorTask()
 	registerRepoHealthCheck()
@@ -115,4 +127,7 @@ func initBasicTasks() {
 	registerSyncExternalUsers()
 	registerDeletedBranchesCleanup()
 	registerUpdateMigrationPosterID()
+	if setting.Actions.Enabled {
+		registerStopZombieActionJobs()
+	}
 }
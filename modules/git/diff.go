@@ -207,6 +207,8 @@ func CutDiffAroundLine(originalDiff io.Reader, line int64, old bool, numbersOfLi
 				} else {
 					otherLine++
 				}
+			case '\\':
+				// "\ No newline at end of file" is not a line of either side
 			default:
 				currentLine++
 				otherLine++
@@ -246,6 +248,7 @@ func CutDiffAroundLine(originalDiff io.Reader, line int64, old bool, numbersOfLi
 		case '-':
 			oldBegin--
 			oldNumOfLines++
+		case '\\':
 		default:
 			oldBegin--
 			newBegin--
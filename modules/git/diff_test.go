@@ -49,6 +49,20 @@ func TestCutDiffAroundLine(t *testing.T) {
 	// Line is out of scope
 	emptyResult = CutDiffAroundLine(strings.NewReader(exampleDiff), 434, false, 0)
 	assert.Empty(t, emptyResult)
+
+	// "\ No newline at end of file" is not counted as a line
+	const noNewlineDiff = `diff --git a/README.md b/README.md
+--- a/README.md
++++ b/README.md
+@@ -1 +1,2 @@
+-Description
+\ No newline at end of file
++Hello
++World`
+	result = CutDiffAroundLine(strings.NewReader(noNewlineDiff), 2, false, 300)
+	assert.Equal(t, noNewlineDiff, result)
+	result = CutDiffAroundLine(strings.NewReader(noNewlineDiff), 1, false, 300)
+	assert.True(t, strings.HasSuffix(result, "\n+Hello"))
 }
 
 func BenchmarkCutDiffAroundLine(b *testing.B) {
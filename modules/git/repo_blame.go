@@ -4,7 +4,10 @@
 
 package git
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // FileBlame return the Blame object of file
 func (repo *Repository) FileBlame(revision, path, file string) ([]byte, error) {
@@ -22,3 +25,43 @@ func (repo *Repository) LineBlame(revision, path, file string, line uint) (*Comm
 	}
 	return repo.GetCommit(res[:40])
 }
+
+// LinesBlame returns the latest commit which changed any of the lines from startLine to
+// line, which is the commit LineBlame returns if both are the same line
+func (repo *Repository) LinesBlame(revision, path, file string, startLine, line uint) (*Commit, error) {
+	res, err := NewCommand("blame", fmt.Sprintf("-L %d,%d", startLine, line), "-p", revision, "--", file).RunInDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// the headers of each line start with its commit, its code is prefixed by a tab
+	args := []string{"rev-list", "--topo-order", "--max-count=1"}
+	seen := make(map[string]bool)
+	var count uint
+	for _, l := range strings.Split(res, "\n") {
+		if strings.HasPrefix(l, "\t") {
+			count++
+		} else if sha := shaLineRegex.FindString(l); sha != "" && !seen[sha] {
+			seen[sha] = true
+			args = append(args, sha)
+		}
+	}
+	if len(seen) == 0 {
+		return nil, fmt.Errorf("invalid result of blame: %s", res)
+	}
+	// newer versions of git blame the lines up to the end of the file instead of failing
+	if count < line-startLine+1 {
+		return nil, fmt.Errorf("fatal: file %s has only %d lines", file, startLine-1+count)
+	}
+
+	// children are listed before their parents, so the first commit is not an ancestor
+	// of any of the others
+	res, err = NewCommand(args...).RunInDir(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(res) < 40 {
+		return nil, fmt.Errorf("invalid result of rev-list: %s", res)
+	}
+	return repo.GetCommit(res[:40])
+}
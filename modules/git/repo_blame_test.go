@@ -0,0 +1,54 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepository_LinesBlame(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "repo-blame")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	assert.NoError(t, InitRepository(tmpDir, false))
+
+	sig := &Signature{Name: "Tester", Email: "tester@example.com"}
+	commitFile := func(content, message string) string {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(tmpDir, "file.txt"), []byte(content), 0644))
+		assert.NoError(t, AddChanges(tmpDir, true))
+		assert.NoError(t, CommitChanges(tmpDir, CommitChangesOptions{Committer: sig, Message: message}))
+		id, err := NewCommand("rev-parse", "HEAD").RunInDir(tmpDir)
+		assert.NoError(t, err)
+		return id[:40]
+	}
+	first := commitFile("line 1\nline 2\nline 3\n", "first")
+	second := commitFile("line 1\nline 2\nline 3 changed\n", "second")
+
+	repo, err := OpenRepository(tmpDir)
+	assert.NoError(t, err)
+	defer repo.Close()
+
+	commit, err := repo.LinesBlame("HEAD", tmpDir, "file.txt", 1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, first, commit.ID.String())
+
+	commit, err = repo.LinesBlame("HEAD", tmpDir, "file.txt", 1, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, second, commit.ID.String())
+
+	commit, err = repo.LineBlame("HEAD", tmpDir, "file.txt", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, second, commit.ID.String())
+
+	_, err = repo.LinesBlame("HEAD", tmpDir, "file.txt", 2, 4)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "has only 3 lines")
+	}
+}
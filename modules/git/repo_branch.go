@@ -15,6 +15,10 @@ import (
 // BranchPrefix base dir of the branch information file store on git
 const BranchPrefix = "refs/heads/"
 
+// AGitPullRequestPrefix is the namespace a pull request is proposed by pushing to, in the
+// form refs/for/<base branch>/<topic>, without the need of a head branch
+const AGitPullRequestPrefix = "refs/for/"
+
 // IsReferenceExist returns true if given reference exists in the repository.
 func IsReferenceExist(repoPath, name string) bool {
 	_, err := NewCommand("show-ref", "--verify", "--", name).RunInDir(repoPath)
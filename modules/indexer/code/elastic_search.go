@@ -0,0 +1,352 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package code
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/analyze"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/charset"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"github.com/go-enry/go-enry/v2"
+	"github.com/olivere/elastic/v7"
+)
+
+// the highlight tags must not occur in text files, they are used to find the
+// location of the keyword in the content
+const (
+	esHighlightPreTag  = "\x00"
+	esHighlightPostTag = "\x01"
+)
+
+var (
+	_ Indexer = &ElasticSearchIndexer{}
+)
+
+// ElasticSearchIndexer implements Indexer interface
+type ElasticSearchIndexer struct {
+	client      *elastic.Client
+	indexerName string
+}
+
+type elasticLogger struct {
+	*log.Logger
+}
+
+func (l elasticLogger) Printf(format string, args ...interface{}) {
+	_ = l.Logger.Log(2, l.Logger.GetLevel(), format, args...)
+}
+
+// esRepoIndexerData is the document stored in the elasticsearch index
+type esRepoIndexerData struct {
+	RepoID    int64              `json:"repo_id"`
+	Filename  string             `json:"filename"`
+	CommitID  string             `json:"commit_id"`
+	Content   string             `json:"content"`
+	Language  string             `json:"language"`
+	UpdatedAt timeutil.TimeStamp `json:"updated_at"`
+}
+
+// NewElasticSearchIndexer creates a new elasticsearch indexer
+func NewElasticSearchIndexer(url, indexerName string) (*ElasticSearchIndexer, bool, error) {
+	opts := []elastic.ClientOptionFunc{
+		elastic.SetURL(url),
+		elastic.SetSniff(false),
+		elastic.SetHealthcheckInterval(10 * time.Second),
+		elastic.SetGzip(false),
+	}
+
+	logger := elasticLogger{log.GetLogger(log.DEFAULT)}
+
+	if logger.GetLevel() == log.TRACE || logger.GetLevel() == log.DEBUG {
+		opts = append(opts, elastic.SetTraceLog(logger))
+	} else if logger.GetLevel() == log.ERROR || logger.GetLevel() == log.CRITICAL || logger.GetLevel() == log.FATAL {
+		opts = append(opts, elastic.SetErrorLog(logger))
+	} else if logger.GetLevel() == log.INFO || logger.GetLevel() == log.WARN {
+		opts = append(opts, elastic.SetInfoLog(logger))
+	}
+
+	client, err := elastic.NewClient(opts...)
+	if err != nil {
+		return nil, false, err
+	}
+
+	indexer := &ElasticSearchIndexer{
+		client:      client,
+		indexerName: indexerName,
+	}
+	created, err := indexer.init()
+	return indexer, created, err
+}
+
+const (
+	defaultMapping = `{
+		"mappings": {
+			"properties": {
+				"repo_id": {
+					"type": "long",
+					"index": true
+				},
+				"filename": {
+					"type": "keyword",
+					"index": true
+				},
+				"commit_id": {
+					"type": "keyword",
+					"index": true
+				},
+				"content": {
+					"type": "text",
+					"term_vector": "with_positions_offsets",
+					"index": true
+				},
+				"language": {
+					"type": "keyword",
+					"index": true
+				},
+				"updated_at": {
+					"type": "long",
+					"index": true
+				}
+			}
+		}
+	}`
+)
+
+// init creates the index if it does not exist yet
+func (b *ElasticSearchIndexer) init() (bool, error) {
+	ctx := context.Background()
+	exists, err := b.client.IndexExists(b.indexerName).Do(ctx)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		return false, nil
+	}
+
+	createIndex, err := b.client.CreateIndex(b.indexerName).BodyString(defaultMapping).Do(ctx)
+	if err != nil {
+		return false, err
+	}
+	if !createIndex.Acknowledged {
+		return false, errors.New("init failed")
+	}
+	return true, nil
+}
+
+func (b *ElasticSearchIndexer) addUpdate(sha string, update fileUpdate, repo *models.Repository) ([]elastic.BulkableRequest, error) {
+	// Ignore vendored files in code search
+	if setting.Indexer.ExcludeVendored && enry.IsVendor(update.Filename) {
+		return nil, nil
+	}
+	stdout, err := git.NewCommand("cat-file", "-s", update.BlobSha).
+		RunInDir(repo.RepoPath())
+	if err != nil {
+		return nil, err
+	}
+	if size, err := strconv.Atoi(strings.TrimSpace(stdout)); err != nil {
+		return nil, fmt.Errorf("Misformatted git cat-file output: %v", err)
+	} else if int64(size) > setting.Indexer.MaxIndexerFileSize {
+		return []elastic.BulkableRequest{b.addDelete(update.Filename, repo)}, nil
+	}
+
+	fileContents, err := git.NewCommand("cat-file", "blob", update.BlobSha).
+		RunInDirBytes(repo.RepoPath())
+	if err != nil {
+		return nil, err
+	} else if !base.IsTextFile(fileContents) {
+		// FIXME: UTF-16 files will probably fail here
+		return nil, nil
+	}
+
+	return []elastic.BulkableRequest{
+		elastic.NewBulkIndexRequest().
+			Index(b.indexerName).
+			Id(filenameIndexerID(repo.ID, update.Filename)).
+			Doc(&esRepoIndexerData{
+				RepoID:    repo.ID,
+				Filename:  update.Filename,
+				CommitID:  sha,
+				Content:   string(charset.ToUTF8DropErrors(fileContents)),
+				Language:  analyze.GetCodeLanguage(update.Filename, fileContents),
+				UpdatedAt: timeutil.TimeStampNow(),
+			}),
+	}, nil
+}
+
+func (b *ElasticSearchIndexer) addDelete(filename string, repo *models.Repository) elastic.BulkableRequest {
+	return elastic.NewBulkDeleteRequest().
+		Index(b.indexerName).
+		Id(filenameIndexerID(repo.ID, filename))
+}
+
+// Index indexes the data
+func (b *ElasticSearchIndexer) Index(repoID int64) error {
+	repo, err := models.GetRepositoryByID(repoID)
+	if err != nil {
+		return err
+	}
+
+	sha, err := getDefaultBranchSha(repo)
+	if err != nil {
+		return err
+	}
+	changes, err := getRepoChanges(repo, sha)
+	if err != nil {
+		return err
+	} else if changes == nil {
+		return nil
+	}
+
+	ctx := context.Background()
+	bulk := b.client.Bulk().Index(b.indexerName)
+	flush := func() error {
+		if bulk.NumberOfActions() == 0 {
+			return nil
+		}
+		res, err := bulk.Do(ctx)
+		if err != nil {
+			return err
+		}
+		if failed := res.Failed(); len(failed) > 0 && failed[0].Error != nil {
+			return fmt.Errorf("%s: %s", failed[0].Error.Type, failed[0].Error.Reason)
+		}
+		return nil
+	}
+
+	for _, update := range changes.Updates {
+		reqs, err := b.addUpdate(sha, update, repo)
+		if err != nil {
+			return err
+		}
+		bulk.Add(reqs...)
+		if bulk.NumberOfActions() >= maxBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	for _, filename := range changes.RemovedFilenames {
+		bulk.Add(b.addDelete(filename, repo))
+		if bulk.NumberOfActions() >= maxBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	return repo.UpdateIndexerStatus(models.RepoIndexerTypeCode, sha)
+}
+
+// Delete deletes indexes by ids
+func (b *ElasticSearchIndexer) Delete(repoID int64) error {
+	_, err := b.client.DeleteByQuery(b.indexerName).
+		Query(elastic.NewTermsQuery("repo_id", repoID)).
+		Do(context.Background())
+	return err
+}
+
+// highlightRange returns the byte range of the content between the first and
+// the last highlighted term
+func highlightRange(highlighted string) (int, int) {
+	start := strings.Index(highlighted, esHighlightPreTag)
+	end := strings.LastIndex(highlighted, esHighlightPostTag)
+	if start < 0 || end < 0 {
+		return -1, -1
+	}
+	tags := strings.Count(highlighted[:end], esHighlightPreTag)
+	end -= tags*len(esHighlightPreTag) + (tags-1)*len(esHighlightPostTag)
+	return start, end
+}
+
+// Search searches for codes and language stats by given conditions.
+func (b *ElasticSearchIndexer) Search(repoIDs []int64, language, keyword string, page, pageSize int) (int64, []*SearchResult, []*SearchResultLanguages, error) {
+	query := elastic.NewBoolQuery()
+	query = query.Must(elastic.NewMatchPhraseQuery("content", keyword))
+	if len(repoIDs) > 0 {
+		var repoStrs = make([]interface{}, 0, len(repoIDs))
+		for _, repoID := range repoIDs {
+			repoStrs = append(repoStrs, repoID)
+		}
+		query = query.Must(elastic.NewTermsQuery("repo_id", repoStrs...))
+	}
+
+	search := b.client.Search().
+		Index(b.indexerName).
+		Query(query).
+		Highlight(elastic.NewHighlight().
+			Field("content").
+			NumOfFragments(0).
+			PreTags(esHighlightPreTag).
+			PostTags(esHighlightPostTag)).
+		// the languages are counted without the language filter
+		Aggregation("language", elastic.NewTermsAggregation().Field("language").Size(10)).
+		From((page - 1) * pageSize).Size(pageSize)
+	if len(language) > 0 {
+		search = search.PostFilter(elastic.NewTermQuery("language", language))
+	}
+
+	searchResult, err := search.Do(context.Background())
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	searchResults := make([]*SearchResult, 0, len(searchResult.Hits.Hits))
+	for _, hit := range searchResult.Hits.Hits {
+		var data esRepoIndexerData
+		if err := json.Unmarshal(hit.Source, &data); err != nil {
+			return 0, nil, nil, err
+		}
+		var startIndex, endIndex int = -1, -1
+		if highlighted, ok := hit.Highlight["content"]; ok && len(highlighted) > 0 {
+			startIndex, endIndex = highlightRange(highlighted[0])
+		}
+		searchResults = append(searchResults, &SearchResult{
+			RepoID:      data.RepoID,
+			StartIndex:  startIndex,
+			EndIndex:    endIndex,
+			Filename:    data.Filename,
+			Content:     data.Content,
+			CommitID:    data.CommitID,
+			UpdatedUnix: data.UpdatedAt,
+			Language:    data.Language,
+			Color:       enry.GetColor(data.Language),
+		})
+	}
+
+	searchResultLanguages := make([]*SearchResultLanguages, 0, 10)
+	if terms, ok := searchResult.Aggregations.Terms("language"); ok {
+		for _, bucket := range terms.Buckets {
+			lang, _ := bucket.Key.(string)
+			if len(lang) == 0 {
+				continue
+			}
+			searchResultLanguages = append(searchResultLanguages, &SearchResultLanguages{
+				Language: lang,
+				Color:    enry.GetColor(lang),
+				Count:    int(bucket.DocCount),
+			})
+		}
+	}
+
+	return searchResult.TotalHits(), searchResults, searchResultLanguages, nil
+}
+
+// Close implements indexer
+func (b *ElasticSearchIndexer) Close() {}
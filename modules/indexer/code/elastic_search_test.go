@@ -0,0 +1,65 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package code
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// esTestIndexer refreshes the index after indexing, so the documents are
+// visible to the searches of the test
+type esTestIndexer struct {
+	*ElasticSearchIndexer
+}
+
+func (b esTestIndexer) Index(repoID int64) error {
+	if err := b.ElasticSearchIndexer.Index(repoID); err != nil {
+		return err
+	}
+	_, err := b.client.Refresh(b.indexerName).Do(context.Background())
+	return err
+}
+
+func TestESIndexAndSearch(t *testing.T) {
+	models.PrepareTestEnv(t)
+
+	u := os.Getenv("TEST_INDEXER_CODE_ES_URL")
+	if u == "" {
+		t.SkipNow()
+		return
+	}
+
+	indexer, _, err := NewElasticSearchIndexer(u, "gitea_codes")
+	if err != nil {
+		assert.Fail(t, "Unable to create ES indexer Error: %v", err)
+		if indexer != nil {
+			indexer.Close()
+		}
+		return
+	}
+	defer indexer.Close()
+
+	testIndexer("elastic_search", t, esTestIndexer{indexer})
+}
+
+func TestHighlightRange(t *testing.T) {
+	start, end := highlightRange("no match")
+	assert.EqualValues(t, -1, start)
+	assert.EqualValues(t, -1, end)
+
+	start, end = highlightRange("a \x00repo1\x01 b")
+	assert.EqualValues(t, 2, start)
+	assert.EqualValues(t, 7, end)
+
+	start, end = highlightRange("a \x00foo\x01 \x00bar\x01 b")
+	assert.EqualValues(t, 2, start)
+	assert.EqualValues(t, 9, end)
+}
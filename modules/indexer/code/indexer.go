@@ -63,7 +63,7 @@ func Init() {
 	waitChannel := make(chan time.Duration)
 	go func() {
 		start := time.Now()
-		log.Info("PID: %d Initializing Repository Indexer at: %s", os.Getpid(), setting.Indexer.RepoPath)
+		log.Info("PID: %d Initializing Repository Indexer: %s", os.Getpid(), setting.Indexer.RepoType)
 		defer func() {
 			if err := recover(); err != nil {
 				log.Error("PANIC whilst initializing repository indexer: %v\nStacktrace: %s", err, log.Stack(2))
@@ -75,17 +75,40 @@ func Init() {
 				log.Fatal("PID: %d Unable to initialize the Repository Indexer at path: %s Error: %v", os.Getpid(), setting.Indexer.RepoPath, err)
 			}
 		}()
-		bleveIndexer, created, err := NewBleveIndexer(setting.Indexer.RepoPath)
-		if err != nil {
-			if bleveIndexer != nil {
-				bleveIndexer.Close()
+		var (
+			rIndexer Indexer
+			created  bool
+			err      error
+		)
+		switch setting.Indexer.RepoType {
+		case "bleve":
+			var bleveIndexer *BleveIndexer
+			bleveIndexer, created, err = NewBleveIndexer(setting.Indexer.RepoPath)
+			if err != nil {
+				if bleveIndexer != nil {
+					bleveIndexer.Close()
+				}
+				cancel()
+				indexer.Close()
+				close(waitChannel)
+				log.Fatal("PID: %d Unable to initialize the Repository Indexer at path: %s Error: %v", os.Getpid(), setting.Indexer.RepoPath, err)
+			}
+			rIndexer = bleveIndexer
+		case "elasticsearch":
+			rIndexer, created, err = NewElasticSearchIndexer(setting.Indexer.RepoConnStr, setting.Indexer.RepoIndexerName)
+			if err != nil {
+				cancel()
+				indexer.Close()
+				close(waitChannel)
+				log.Fatal("PID: %d Unable to initialize the Repository Indexer at connection: %s Error: %v", os.Getpid(), setting.Indexer.RepoConnStr, err)
 			}
+		default:
 			cancel()
 			indexer.Close()
 			close(waitChannel)
-			log.Fatal("PID: %d Unable to initialize the Repository Indexer at path: %s Error: %v", os.Getpid(), setting.Indexer.RepoPath, err)
+			log.Fatal("PID: %d Unknown Repository Indexer type: %s", os.Getpid(), setting.Indexer.RepoType)
 		}
-		indexer.set(bleveIndexer)
+		indexer.set(rIndexer)
 
 		go processRepoIndexerOperationQueue(indexer)
 
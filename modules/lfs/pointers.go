@@ -53,9 +53,9 @@ func IsPointerFile(buf *[]byte) *models.LFSMetaObject {
 		return nil
 	}
 
-	contentStore := &ContentStore{BasePath: setting.LFS.ContentPath}
+	contentStore := NewContentStore()
 	meta := &models.LFSMetaObject{Oid: oid, Size: size}
-	if !contentStore.Exists(meta) {
+	if exist, _ := contentStore.Exists(meta); !exist {
 		return nil
 	}
 
@@ -64,6 +64,6 @@ func IsPointerFile(buf *[]byte) *models.LFSMetaObject {
 
 // ReadMetaObject will read a models.LFSMetaObject and return a reader
 func ReadMetaObject(meta *models.LFSMetaObject) (io.ReadCloser, error) {
-	contentStore := &ContentStore{BasePath: setting.LFS.ContentPath}
+	contentStore := NewContentStore()
 	return contentStore.Get(meta, 0)
 }
@@ -9,18 +9,18 @@ import "time"
 
 // Issue is a standard issue information
 type Issue struct {
-	Number      int64
-	PosterID    int64
-	PosterName  string
-	PosterEmail string
-	Title       string
-	Content     string
-	Milestone   string
-	State       string // closed, open
-	IsLocked    bool
-	Created     time.Time
-	Updated     time.Time
-	Closed      *time.Time
-	Labels      []*Label
-	Reactions   []*Reaction
+	Number      int64       `yaml:"number"`
+	PosterID    int64       `yaml:"poster_id"`
+	PosterName  string      `yaml:"poster_name"`
+	PosterEmail string      `yaml:"poster_email"`
+	Title       string      `yaml:"title"`
+	Content     string      `yaml:"content"`
+	Milestone   string      `yaml:"milestone"`
+	State       string      `yaml:"state"` // closed, open
+	IsLocked    bool        `yaml:"is_locked"`
+	Created     time.Time   `yaml:"created"`
+	Updated     time.Time   `yaml:"updated"`
+	Closed      *time.Time  `yaml:"closed"`
+	Labels      []*Label    `yaml:"labels"`
+	Reactions   []*Reaction `yaml:"reactions"`
 }
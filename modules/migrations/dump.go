@@ -0,0 +1,387 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/migrations/base"
+	"code.gitea.io/gitea/modules/repository"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/structs"
+
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	_ base.Uploader = &RepositoryDumper{}
+)
+
+// The files of a repository dump below its base directory
+const (
+	dumpRepoFile        = "repo.yml"
+	dumpGitBundle       = "git.bundle"
+	dumpWikiBundle      = "wiki.bundle"
+	dumpTopicFile       = "topic.yml"
+	dumpMilestoneFile   = "milestone.yml"
+	dumpLabelFile       = "label.yml"
+	dumpReleaseFile     = "release.yml"
+	dumpIssueFile       = "issue.yml"
+	dumpPullFile        = "pull_request.yml"
+	dumpCommentDir      = "comments"
+	dumpReviewDir       = "reviews"
+	dumpPatchDir        = "patches"
+	dumpReleaseAssetDir = "release_assets"
+)
+
+// dumpedRepository is the content of repo.yml, the repository and the items
+// which were migrated into the dump
+type dumpedRepository struct {
+	base.Repository `yaml:",inline"`
+	GitServiceType  string `yaml:"service_type"`
+	Wiki            bool   `yaml:"wiki"`
+	Milestones      bool   `yaml:"milestones"`
+	Labels          bool   `yaml:"labels"`
+	Releases        bool   `yaml:"releases"`
+	Issues          bool   `yaml:"issues"`
+	Comments        bool   `yaml:"comments"`
+	PullRequests    bool   `yaml:"pull_requests"`
+}
+
+// RepositoryDumper implements an Uploader which writes the repository with
+// all its migrated items into a directory. The git data is stored as bundles,
+// the items as YAML files, attachments and patches are downloaded next to them.
+type RepositoryDumper struct {
+	ctx       context.Context
+	baseDir   string
+	repoOwner string
+	repoName  string
+	opts      base.MigrateOptions
+	files     map[string]*os.File
+}
+
+// NewRepositoryDumper creates a repository dumper which writes into baseDir
+func NewRepositoryDumper(ctx context.Context, baseDir, repoOwner, repoName string, opts base.MigrateOptions) (*RepositoryDumper, error) {
+	if err := os.MkdirAll(baseDir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	return &RepositoryDumper{
+		ctx:       ctx,
+		baseDir:   baseDir,
+		repoOwner: repoOwner,
+		repoName:  repoName,
+		opts:      opts,
+		files:     make(map[string]*os.File),
+	}, nil
+}
+
+// MaxBatchInsertSize returns the table's max batch insert size
+func (g *RepositoryDumper) MaxBatchInsertSize(tp string) int {
+	return 1000
+}
+
+// bundle mirrors the remote repository into a temporary directory and writes
+// all its refs into the bundle file
+func (g *RepositoryDumper) bundle(remoteAddr, bundleFile string) error {
+	tmpDir := filepath.Join(g.baseDir, bundleFile+".tmp")
+	defer os.RemoveAll(tmpDir)
+
+	if err := git.Clone(remoteAddr, tmpDir, git.CloneRepoOptions{
+		Mirror:  true,
+		Quiet:   true,
+		Timeout: time.Duration(setting.Git.Timeout.Migrate) * time.Second,
+	}); err != nil {
+		return fmt.Errorf("Clone: %v", err)
+	}
+
+	// git refuses to create an empty bundle
+	refs, err := git.NewCommand("show-ref").RunInDir(tmpDir)
+	if err != nil && strings.TrimSpace(refs) != "" {
+		return err
+	} else if strings.TrimSpace(refs) == "" {
+		return nil
+	}
+	_, err = git.NewCommand("bundle", "create", filepath.Join(g.baseDir, bundleFile), "--all").RunInDir(tmpDir)
+	return err
+}
+
+// CreateRepo writes repo.yml and bundles the git data of the repository and its wiki
+func (g *RepositoryDumper) CreateRepo(repo *base.Repository, opts base.MigrateOptions) error {
+	var remoteAddr = repo.CloneURL
+	if len(opts.AuthUsername) > 0 {
+		u, err := url.Parse(repo.CloneURL)
+		if err != nil {
+			return err
+		}
+		u.User = url.UserPassword(opts.AuthUsername, opts.AuthPassword)
+		remoteAddr = u.String()
+	}
+
+	dumped := *repo
+	dumped.Owner = g.repoOwner
+	dumped.Name = g.repoName
+	dumped.CloneURL = dumpGitBundle
+	if err := g.writeYAML(dumpRepoFile, &dumpedRepository{
+		Repository:     dumped,
+		GitServiceType: opts.GitServiceType.Name(),
+		Wiki:           opts.Wiki,
+		Milestones:     opts.Milestones,
+		Labels:         opts.Labels,
+		Releases:       opts.Releases,
+		Issues:         opts.Issues,
+		Comments:       opts.Comments,
+		PullRequests:   opts.PullRequests,
+	}); err != nil {
+		return err
+	}
+
+	if err := g.bundle(remoteAddr, dumpGitBundle); err != nil {
+		return err
+	}
+	if opts.Wiki {
+		if wikiRemoteAddr := repository.WikiRemoteURL(remoteAddr); wikiRemoteAddr != "" {
+			if err := g.bundle(wikiRemoteAddr, dumpWikiBundle); err != nil {
+				log.Warn("Bundle wiki: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// Close closes this uploader
+func (g *RepositoryDumper) Close() {
+	for name, f := range g.files {
+		if err := f.Close(); err != nil {
+			log.Error("Unable to close %s: %v", name, err)
+		}
+	}
+	g.files = make(map[string]*os.File)
+}
+
+// writeYAML overwrites the file with the YAML of v
+func (g *RepositoryDumper) writeYAML(name string, v interface{}) error {
+	bs, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(g.baseDir, name), bs)
+}
+
+// appendYAML appends the items to the YAML list in the file, the file stays
+// open until the dumper is closed
+func (g *RepositoryDumper) appendYAML(name string, items interface{}) error {
+	f, ok := g.files[name]
+	if !ok {
+		p := filepath.Join(g.baseDir, name)
+		if err := os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+			return err
+		}
+		var err error
+		f, err = os.OpenFile(p, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		g.files[name] = f
+	}
+
+	// the YAML of consecutive lists is one list
+	bs, err := yaml.Marshal(items)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(bs)
+	return err
+}
+
+// download downloads the URL into the file below the base directory
+func (g *RepositoryDumper) download(rawURL, name string) error {
+	rc, err := openRemoteURI(rawURL)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	p := filepath.Join(g.baseDir, name)
+	if err := os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+		return err
+	}
+	f, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(f, rc)
+	return err
+}
+
+// CreateTopics writes the topics
+func (g *RepositoryDumper) CreateTopics(topics ...string) error {
+	return g.writeYAML(dumpTopicFile, map[string][]string{"topics": topics})
+}
+
+// CreateMilestones writes the milestones
+func (g *RepositoryDumper) CreateMilestones(milestones ...*base.Milestone) error {
+	return g.appendYAML(dumpMilestoneFile, milestones)
+}
+
+// CreateLabels writes the labels
+func (g *RepositoryDumper) CreateLabels(labels ...*base.Label) error {
+	return g.appendYAML(dumpLabelFile, labels)
+}
+
+// CreateReleases writes the releases and downloads their assets
+func (g *RepositoryDumper) CreateReleases(releases ...*base.Release) error {
+	dumped := make([]*base.Release, 0, len(releases))
+	for _, release := range releases {
+		rel := *release
+		rel.Assets = make([]base.ReleaseAsset, 0, len(release.Assets))
+		for i, asset := range release.Assets {
+			// the index keeps the names of the assets unique
+			name := filepath.Join(dumpReleaseAssetDir, rel.TagName, strconv.Itoa(i)+"_"+filepath.Base(asset.Name))
+			if err := g.download(asset.URL, name); err != nil {
+				return fmt.Errorf("download asset %s of release %s: %v", asset.Name, rel.TagName, err)
+			}
+			asset.URL = filepath.ToSlash(name)
+			rel.Assets = append(rel.Assets, asset)
+		}
+		dumped = append(dumped, &rel)
+	}
+	return g.appendYAML(dumpReleaseFile, dumped)
+}
+
+// SyncTags does nothing, the tags are in the git bundle
+func (g *RepositoryDumper) SyncTags() error {
+	return nil
+}
+
+// CreateIssues writes the issues
+func (g *RepositoryDumper) CreateIssues(issues ...*base.Issue) error {
+	return g.appendYAML(dumpIssueFile, issues)
+}
+
+// CreateComments writes the comments into one file per issue
+func (g *RepositoryDumper) CreateComments(comments ...*base.Comment) error {
+	var byIssue = make(map[int64][]*base.Comment)
+	for _, comment := range comments {
+		byIssue[comment.IssueIndex] = append(byIssue[comment.IssueIndex], comment)
+	}
+	for index, cs := range byIssue {
+		if err := g.appendYAML(filepath.Join(dumpCommentDir, fmt.Sprintf("%d.yml", index)), cs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreatePullRequests writes the pull requests and downloads their patches
+func (g *RepositoryDumper) CreatePullRequests(prs ...*base.PullRequest) error {
+	dumped := make([]*base.PullRequest, 0, len(prs))
+	for _, pr := range prs {
+		p := *pr
+		if p.PatchURL != "" {
+			name := filepath.Join(dumpPatchDir, fmt.Sprintf("%d.patch", p.Number))
+			if err := g.download(p.PatchURL, name); err != nil {
+				return fmt.Errorf("download patch of pull request %d: %v", p.Number, err)
+			}
+			p.PatchURL = filepath.ToSlash(name)
+		}
+		dumped = append(dumped, &p)
+	}
+	return g.appendYAML(dumpPullFile, dumped)
+}
+
+// CreateReviews writes the reviews into one file per pull request
+func (g *RepositoryDumper) CreateReviews(reviews ...*base.Review) error {
+	var byIssue = make(map[int64][]*base.Review)
+	for _, review := range reviews {
+		byIssue[review.IssueIndex] = append(byIssue[review.IssueIndex], review)
+	}
+	for index, rs := range byIssue {
+		if err := g.appendYAML(filepath.Join(dumpReviewDir, fmt.Sprintf("%d.yml", index)), rs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rollback removes the incomplete dump
+func (g *RepositoryDumper) Rollback() error {
+	g.Close()
+	return os.RemoveAll(g.baseDir)
+}
+
+func writeFile(p string, bs []byte) error {
+	if err := os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+		return err
+	}
+	f, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(bs)
+	return err
+}
+
+// DumpRepository dumps the repository described by the migrate options, it is
+// downloaded like a migrated repository and uploaded into the base directory
+func DumpRepository(ctx context.Context, baseDir, ownerName string, opts base.MigrateOptions) error {
+	downloader, err := newDownloader(ownerName, &opts)
+	if err != nil {
+		return err
+	}
+	downloader.SetContext(ctx)
+
+	uploader, err := NewRepositoryDumper(ctx, baseDir, ownerName, opts.RepoName, opts)
+	if err != nil {
+		return err
+	}
+
+	if err := migrateRepository(downloader, uploader, opts); err != nil {
+		if err1 := uploader.Rollback(); err1 != nil {
+			log.Error("rollback failed: %v", err1)
+		}
+		return err
+	}
+	return nil
+}
+
+// openRemoteURI opens a remote http(s):// URI. Other schemes are refused as the URI
+// comes from the migrated server and must not read local files.
+func openRemoteURI(uri string) (io.ReadCloser, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported scheme of URL %q", uri)
+	}
+	resp, err := http.Get(uri)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// gitServiceTypeByName returns the git service type of the name written by the dumper
+func gitServiceTypeByName(name string) structs.GitServiceType {
+	for _, tp := range structs.SupportedFullGitService {
+		if tp.Name() == name {
+			return tp
+		}
+	}
+	return structs.PlainGitService
+}
@@ -0,0 +1,279 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/migrations/base"
+	"code.gitea.io/gitea/modules/storage"
+	"code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryDownloader returns fixed items, it is the source of the dump tests
+type memoryDownloader struct {
+	repo     *base.Repository
+	labels   []*base.Label
+	releases []*base.Release
+	issues   []*base.Issue
+	comments []*base.Comment
+	prs      []*base.PullRequest
+	reviews  []*base.Review
+}
+
+func (d *memoryDownloader) SetContext(ctx context.Context) {}
+
+func (d *memoryDownloader) GetRepoInfo() (*base.Repository, error) {
+	return d.repo, nil
+}
+
+func (d *memoryDownloader) GetTopics() ([]string, error) {
+	return []string{"dump", "restore"}, nil
+}
+
+func (d *memoryDownloader) GetMilestones() ([]*base.Milestone, error) {
+	return nil, nil
+}
+
+func (d *memoryDownloader) GetLabels() ([]*base.Label, error) {
+	return d.labels, nil
+}
+
+func (d *memoryDownloader) GetReleases() ([]*base.Release, error) {
+	return d.releases, nil
+}
+
+func (d *memoryDownloader) GetIssues(page, perPage int) ([]*base.Issue, bool, error) {
+	start, end := pageRange(page, perPage, len(d.issues))
+	return d.issues[start:end], end >= len(d.issues), nil
+}
+
+func (d *memoryDownloader) GetComments(issueNumber int64) ([]*base.Comment, error) {
+	var comments []*base.Comment
+	for _, c := range d.comments {
+		if c.IssueIndex == issueNumber {
+			comments = append(comments, c)
+		}
+	}
+	return comments, nil
+}
+
+func (d *memoryDownloader) GetPullRequests(page, perPage int) ([]*base.PullRequest, error) {
+	start, end := pageRange(page, perPage, len(d.prs))
+	return d.prs[start:end], nil
+}
+
+func (d *memoryDownloader) GetReviews(pullRequestNumber int64) ([]*base.Review, error) {
+	var reviews []*base.Review
+	for _, r := range d.reviews {
+		if r.IssueIndex == pullRequestNumber {
+			reviews = append(reviews, r)
+		}
+	}
+	return reviews, nil
+}
+
+func TestDumpAndRestoreRepository(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+	assert.NoError(t, storage.Init())
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/1.patch":
+			_, _ = w.Write([]byte("From 65f1bf27bc3bf70f64657658635e66094edbcb4d\n"))
+		case "/asset.txt":
+			_, _ = w.Write([]byte("release asset"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	cloneURL, err := filepath.Abs(filepath.Join("..", "..", "integrations", "gitea-repositories-meta", "user2", "repo1.git"))
+	assert.NoError(t, err)
+
+	created := time.Date(2020, 9, 1, 10, 0, 0, 0, time.UTC)
+	size, downloads := 13, 2
+	sha := "65f1bf27bc3bf70f64657658635e66094edbcb4d"
+	downloader := &memoryDownloader{
+		repo: &base.Repository{
+			Name:        "repo1",
+			Owner:       "user2",
+			Description: "dumped repository",
+			CloneURL:    cloneURL,
+			OriginalURL: "https://example.com/user2/repo1",
+		},
+		labels: []*base.Label{
+			{Name: "bug", Color: "ee0701"},
+			{Name: "feature", Color: "84b6eb"},
+		},
+		releases: []*base.Release{
+			{
+				TagName:         "v1.1",
+				TargetCommitish: "master",
+				Name:            "First release",
+				PublisherName:   "user2",
+				Created:         created,
+				Published:       created,
+				Assets: []base.ReleaseAsset{
+					{URL: srv.URL + "/asset.txt", Name: "asset.txt", Size: &size, DownloadCount: &downloads, Created: created, Updated: created},
+				},
+			},
+		},
+		issues: []*base.Issue{
+			{Number: 2, Title: "Second issue", PosterName: "user2", State: "open", Created: created, Updated: created, Labels: []*base.Label{{Name: "bug"}}},
+			{Number: 3, Title: "Third issue", PosterName: "user2", State: "closed", Created: created, Updated: created},
+		},
+		comments: []*base.Comment{
+			{IssueIndex: 2, PosterName: "user2", Content: "first comment", Created: created, Updated: created},
+			{IssueIndex: 1, PosterName: "user2", Content: "pull request comment", Created: created, Updated: created},
+		},
+		prs: []*base.PullRequest{
+			{
+				Number:     1,
+				Title:      "First pull request",
+				PosterName: "user2",
+				State:      "open",
+				Created:    created,
+				Updated:    created,
+				PatchURL:   srv.URL + "/1.patch",
+				Head:       base.PullRequestBranch{Ref: "branch1", SHA: sha, OwnerName: "user2", RepoName: "repo1"},
+				Base:       base.PullRequestBranch{Ref: "master", SHA: sha, OwnerName: "user2", RepoName: "repo1"},
+			},
+		},
+		reviews: []*base.Review{
+			{IssueIndex: 1, ReviewerName: "user2", Content: "looks good", State: base.ReviewStateApproved, CreatedAt: created},
+		},
+	}
+
+	dumpDir, err := ioutil.TempDir(os.TempDir(), "gitea-dump-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dumpDir)
+
+	opts := base.MigrateOptions{
+		RepoName:       "repo1",
+		GitServiceType: structs.GiteaService,
+		OriginalURL:    "https://example.com/user2/repo1",
+		Labels:         true,
+		Releases:       true,
+		Issues:         true,
+		Comments:       true,
+		PullRequests:   true,
+	}
+	dumper, err := NewRepositoryDumper(context.Background(), dumpDir, "user2", "repo1", opts)
+	assert.NoError(t, err)
+	assert.NoError(t, migrateRepository(downloader, dumper, opts))
+
+	for _, name := range []string{"repo.yml", "git.bundle", "topic.yml", "label.yml", "release.yml", "issue.yml",
+		"pull_request.yml", "comments/1.yml", "comments/2.yml", "reviews/1.yml", "patches/1.patch", "release_assets/v1.1/0_asset.txt"} {
+		assert.FileExists(t, filepath.Join(dumpDir, name))
+	}
+
+	// the dump must not refer to the source server anymore
+	restorer, err := NewRepositoryRestorer(context.Background(), dumpDir)
+	assert.NoError(t, err)
+	prs, err := restorer.GetPullRequests(1, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, prs, 1) {
+		assert.Equal(t, "file://"+filepath.ToSlash(filepath.Join(dumpDir, "patches", "1.patch")), prs[0].PatchURL)
+	}
+	issues, isEnd, err := restorer.GetIssues(1, 1)
+	assert.NoError(t, err)
+	assert.False(t, isEnd)
+	if assert.Len(t, issues, 1) {
+		assert.EqualValues(t, 2, issues[0].Number)
+		assert.Equal(t, created, issues[0].Created)
+	}
+	srv.Close()
+
+	user := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	repo, err := RestoreRepository(context.Background(), dumpDir, user, user.Name, "restored", nil)
+	assert.NoError(t, err)
+	if !assert.NotNil(t, repo) {
+		return
+	}
+	assert.Equal(t, "dumped repository", repo.Description)
+	assert.Equal(t, structs.GiteaService, repo.OriginalServiceType)
+	assert.False(t, repo.IsEmpty)
+
+	labels, err := models.GetLabelsByRepoID(repo.ID, "", models.ListOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, labels, 2)
+
+	models.AssertExistsAndLoadBean(t, &models.Issue{RepoID: repo.ID, Index: 2, Title: "Second issue"})
+	models.AssertExistsAndLoadBean(t, &models.Issue{RepoID: repo.ID, Index: 3, IsClosed: true})
+	pull := models.AssertExistsAndLoadBean(t, &models.Issue{RepoID: repo.ID, Index: 1, IsPull: true}).(*models.Issue)
+	models.AssertExistsAndLoadBean(t, &models.Review{IssueID: pull.ID, Content: "looks good"})
+	assert.FileExists(t, filepath.Join(repo.RepoPath(), "pulls", "1.patch"))
+
+	release := models.AssertExistsAndLoadBean(t, &models.Release{RepoID: repo.ID, TagName: "v1.1"}).(*models.Release)
+	attach := models.AssertExistsAndLoadBean(t, &models.Attachment{ReleaseID: release.ID, Name: "asset.txt"}).(*models.Attachment)
+	assert.EqualValues(t, 13, attach.Size)
+}
+
+func TestRestoreRefusesFilesOutsideDump(t *testing.T) {
+	dumpDir, err := ioutil.TempDir(os.TempDir(), "gitea-dump-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dumpDir)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dumpDir, "repo.yml"), []byte("name: repo1\nowner: user2\n"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dumpDir, "release.yml"), []byte("- tag_name: v1.0\n  assets:\n  - name: passwd\n    url: ../../etc/passwd\n"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dumpDir, "pull_request.yml"), []byte("- number: 1\n  patch_url: patches/../../1.patch\n"), 0644))
+
+	restorer, err := NewRepositoryRestorer(context.Background(), dumpDir)
+	assert.NoError(t, err)
+	_, err = restorer.GetReleases()
+	assert.Error(t, err)
+	_, err = restorer.GetPullRequests(1, 10)
+	assert.Error(t, err)
+
+	_, err = restorer.openFile("file:///etc/passwd")
+	assert.Error(t, err)
+	_, err = restorer.openFile("file://" + filepath.ToSlash(filepath.Join(dumpDir, "..", "other", "1.patch")))
+	assert.Error(t, err)
+	rc, err := restorer.openFile("file://" + filepath.ToSlash(filepath.Join(dumpDir, "repo.yml")))
+	if assert.NoError(t, err) {
+		rc.Close()
+	}
+
+	// extracted archives may contain symbolic links to files outside of the dump
+	outsideDir, err := ioutil.TempDir(os.TempDir(), "gitea-dump-test-outside")
+	assert.NoError(t, err)
+	defer os.RemoveAll(outsideDir)
+	outsideFile := filepath.Join(outsideDir, "secret")
+	assert.NoError(t, ioutil.WriteFile(outsideFile, []byte("- secret\n"), 0644))
+	assert.NoError(t, os.Symlink(outsideFile, filepath.Join(dumpDir, "secret")))
+	assert.NoError(t, os.Symlink(outsideFile, filepath.Join(dumpDir, "topic.yml")))
+	assert.NoError(t, os.Symlink(outsideDir, filepath.Join(dumpDir, "attachments")))
+	assert.NoError(t, os.Symlink(filepath.Join(dumpDir, "repo.yml"), filepath.Join(dumpDir, "link.yml")))
+
+	_, err = restorer.openFile("file://" + filepath.ToSlash(filepath.Join(dumpDir, "secret")))
+	assert.Error(t, err)
+	_, err = restorer.openFile("file://" + filepath.ToSlash(filepath.Join(dumpDir, "attachments", "secret")))
+	assert.Error(t, err)
+	_, err = restorer.GetTopics()
+	assert.Error(t, err)
+	_, err = restorer.unbundle("secret", "repo")
+	assert.Error(t, err)
+	rc, err = restorer.openFile("file://" + filepath.ToSlash(filepath.Join(dumpDir, "link.yml")))
+	if assert.NoError(t, err) {
+		rc.Close()
+	}
+
+	// migrated servers can not make the uploader read local files
+	uploader := NewGiteaLocalUploader(context.Background(), nil, "user2", "repo1")
+	_, err = uploader.openURI("file://" + filepath.ToSlash(filepath.Join(dumpDir, "repo.yml")))
+	assert.Error(t, err)
+}
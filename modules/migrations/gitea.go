@@ -10,10 +10,8 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"net/http"
 	"net/url"
 	"os"
-	"path"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -26,6 +24,7 @@ import (
 	"code.gitea.io/gitea/modules/repository"
 	repo_module "code.gitea.io/gitea/modules/repository"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 	"code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/timeutil"
 
@@ -51,6 +50,7 @@ type GiteaLocalUploader struct {
 	userMap        map[int64]int64 // external user id mapping to user id
 	prCache        map[int64]*models.PullRequest
 	gitServiceType structs.GitServiceType
+	restorer       *RepositoryRestorer // set when restoring a dump, whose files are read locally
 }
 
 // NewGiteaLocalUploader creates an gitea Uploader via gitea API v1
@@ -66,6 +66,14 @@ func NewGiteaLocalUploader(ctx context.Context, doer *models.User, repoOwner, re
 	}
 }
 
+// openURI opens the URI of a release asset or of a pull request patch
+func (g *GiteaLocalUploader) openURI(uri string) (io.ReadCloser, error) {
+	if g.restorer != nil {
+		return g.restorer.openFile(uri)
+	}
+	return openRemoteURI(uri)
+}
+
 // MaxBatchInsertSize returns the table's max batch insert size
 func (g *GiteaLocalUploader) MaxBatchInsertSize(tp string) int {
 	switch tp {
@@ -269,24 +277,13 @@ func (g *GiteaLocalUploader) CreateReleases(releases ...*base.Release) error {
 
 			// download attachment
 			err = func() error {
-				resp, err := http.Get(asset.URL)
+				rc, err := g.openURI(asset.URL)
 				if err != nil {
 					return err
 				}
-				defer resp.Body.Close()
-
-				localPath := attach.LocalPath()
-				if err = os.MkdirAll(path.Dir(localPath), os.ModePerm); err != nil {
-					return fmt.Errorf("MkdirAll: %v", err)
-				}
-
-				fw, err := os.Create(localPath)
-				if err != nil {
-					return fmt.Errorf("Create: %v", err)
-				}
-				defer fw.Close()
+				defer rc.Close()
 
-				_, err = io.Copy(fw, resp.Body)
+				_, err = storage.Attachments.Save(attach.RelativePath(), rc, attach.Size)
 				return err
 			}()
 			if err != nil {
@@ -547,11 +544,11 @@ func (g *GiteaLocalUploader) newPullRequest(pr *base.PullRequest) (*models.PullR
 
 	// download patch file
 	err := func() error {
-		resp, err := http.Get(pr.PatchURL)
+		rc, err := g.openURI(pr.PatchURL)
 		if err != nil {
 			return err
 		}
-		defer resp.Body.Close()
+		defer rc.Close()
 		pullDir := filepath.Join(g.repo.RepoPath(), "pulls")
 		if err = os.MkdirAll(pullDir, os.ModePerm); err != nil {
 			return err
@@ -561,7 +558,7 @@ func (g *GiteaLocalUploader) newPullRequest(pr *base.PullRequest) (*models.PullR
 			return err
 		}
 		defer f.Close()
-		_, err = io.Copy(f, resp.Body)
+		_, err = io.Copy(f, rc)
 		return err
 	}()
 	if err != nil {
@@ -0,0 +1,643 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/migrations/base"
+	"code.gitea.io/gitea/modules/structs"
+)
+
+var (
+	_ base.Downloader        = &GiteaDownloader{}
+	_ base.DownloaderFactory = &GiteaDownloaderFactory{}
+	_ base.DownloaderFactory = &GogsDownloaderFactory{}
+)
+
+func init() {
+	RegisterDownloaderFactory(&GiteaDownloaderFactory{})
+	RegisterDownloaderFactory(&GogsDownloaderFactory{})
+}
+
+// GiteaDownloaderFactory defines a gitea downloader factory
+type GiteaDownloaderFactory struct {
+}
+
+// Match returns true if the migration was requested from a gitea instance
+func (f *GiteaDownloaderFactory) Match(opts base.MigrateOptions) (bool, error) {
+	return opts.GitServiceType == structs.GiteaService, nil
+}
+
+// New returns a Downloader related to this factory according MigrateOptions
+func (f *GiteaDownloaderFactory) New(opts base.MigrateOptions) (base.Downloader, error) {
+	baseURL, owner, repo, err := parseGiteaCloneAddr(opts.CloneAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Trace("Create gitea downloader. BaseURL: %s RepoOwner: %s RepoName: %s", baseURL, owner, repo)
+
+	return NewGiteaDownloader(baseURL, owner, repo, opts.AuthUsername, opts.AuthPassword), nil
+}
+
+// GitServiceType returns the type of git service
+func (f *GiteaDownloaderFactory) GitServiceType() structs.GitServiceType {
+	return structs.GiteaService
+}
+
+// GogsDownloaderFactory defines a gogs downloader factory
+type GogsDownloaderFactory struct {
+}
+
+// Match returns true if the migration was requested from a gogs instance
+func (f *GogsDownloaderFactory) Match(opts base.MigrateOptions) (bool, error) {
+	return opts.GitServiceType == structs.GogsService, nil
+}
+
+// New returns a Downloader related to this factory according MigrateOptions
+func (f *GogsDownloaderFactory) New(opts base.MigrateOptions) (base.Downloader, error) {
+	baseURL, owner, repo, err := parseGiteaCloneAddr(opts.CloneAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Trace("Create gogs downloader. BaseURL: %s RepoOwner: %s RepoName: %s", baseURL, owner, repo)
+
+	return NewGogsDownloader(baseURL, owner, repo, opts.AuthUsername, opts.AuthPassword), nil
+}
+
+// GitServiceType returns the type of git service
+func (f *GogsDownloaderFactory) GitServiceType() structs.GitServiceType {
+	return structs.GogsService
+}
+
+// parseGiteaCloneAddr splits the clone address of a repository into the
+// root URL of the instance, which may have a sub path, and the repository
+func parseGiteaCloneAddr(cloneAddr string) (baseURL, owner, repo string, err error) {
+	u, err := url.Parse(cloneAddr)
+	if err != nil {
+		return "", "", "", err
+	}
+	fields := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(fields) < 2 {
+		return "", "", "", fmt.Errorf("invalid repository path: %s", u.Path)
+	}
+	owner = fields[len(fields)-2]
+	repo = strings.TrimSuffix(fields[len(fields)-1], ".git")
+
+	u.User = nil
+	u.Path = strings.Join(fields[:len(fields)-2], "/")
+	u.RawQuery = ""
+	u.Fragment = ""
+	return strings.TrimSuffix(u.String(), "/"), owner, repo, nil
+}
+
+// GiteaDownloader implements a Downloader interface to get repository informations
+// from the API of a gitea instance. Gogs shares most of the API, but has no
+// pagination and lacks topics, pull requests, reviews and reactions.
+// - GetIssues and GetPullRequests must be called for consecutive pages, the
+// items are buffered to hand out pages of the requested size whatever the
+// page size of the remote instance is.
+type GiteaDownloader struct {
+	ctx      context.Context
+	client   *http.Client
+	baseURL  string
+	owner    string
+	repo     string
+	username string
+	password string
+	gogs     bool
+
+	issues      []*base.Issue
+	issueStates []string
+	issuePage   int
+	prs         []*base.PullRequest
+	prPage      int
+	prsDone     bool
+}
+
+// NewGiteaDownloader creates a gitea Downloader via the gitea API. It uses either a username/password,
+// an access token entered into the username field, or anonymous/public access
+func NewGiteaDownloader(baseURL, owner, repo, username, password string) *GiteaDownloader {
+	return &GiteaDownloader{
+		ctx:         context.Background(),
+		client:      &http.Client{Timeout: time.Minute},
+		baseURL:     baseURL,
+		owner:       owner,
+		repo:        repo,
+		username:    username,
+		password:    password,
+		issueStates: []string{"all"},
+		issuePage:   1,
+		prPage:      1,
+	}
+}
+
+// NewGogsDownloader creates a Downloader via the gogs API
+func NewGogsDownloader(baseURL, owner, repo, username, password string) *GiteaDownloader {
+	g := NewGiteaDownloader(baseURL, owner, repo, username, password)
+	g.gogs = true
+	// gogs lists either the open or the closed issues
+	g.issueStates = []string{"open", "closed"}
+	return g
+}
+
+// SetContext set context
+func (g *GiteaDownloader) SetContext(ctx context.Context) {
+	g.ctx = ctx
+}
+
+// errGiteaNotFound is returned by get if the API endpoint or object does not exist
+var errGiteaNotFound = fmt.Errorf("not found")
+
+// get requests the API path relative to the repository and decodes the response into v
+func (g *GiteaDownloader) get(v interface{}, format string, args ...interface{}) error {
+	u := fmt.Sprintf("%s/api/v1/repos/%s/%s", g.baseURL, url.PathEscape(g.owner), url.PathEscape(g.repo)) + fmt.Sprintf(format, args...)
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(g.ctx)
+	req.Header.Set("Accept", "application/json")
+	if g.username != "" {
+		if g.password == "" {
+			req.Header.Set("Authorization", "token "+g.username)
+		} else {
+			req.SetBasicAuth(g.username, g.password)
+		}
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errGiteaNotFound
+	} else if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("GET %s: %s %s", u, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// giteaPageSize is the page size requested from gitea, its default MAX_RESPONSE_ITEMS
+const giteaPageSize = 50
+
+// getAll requests all the pages of a list, gogs always returns the complete list
+func (g *GiteaDownloader) getAll(list func(page int) (int, error)) error {
+	for page := 1; ; page++ {
+		n, err := list(page)
+		if err != nil {
+			return err
+		}
+		if g.gogs || n == 0 {
+			return nil
+		}
+	}
+}
+
+func convertGiteaUser(u *structs.User) (id int64, name, email string) {
+	if u == nil {
+		return 0, "", ""
+	}
+	return u.ID, u.UserName, u.Email
+}
+
+func convertGiteaLabels(labels []*structs.Label) []*base.Label {
+	res := make([]*base.Label, 0, len(labels))
+	for _, label := range labels {
+		res = append(res, &base.Label{
+			Name:        label.Name,
+			Color:       strings.TrimPrefix(label.Color, "#"),
+			Description: label.Description,
+		})
+	}
+	return res
+}
+
+// GetRepoInfo returns a repository information
+func (g *GiteaDownloader) GetRepoInfo() (*base.Repository, error) {
+	var repo structs.Repository
+	if err := g.get(&repo, ""); err != nil {
+		return nil, err
+	}
+
+	var owner string
+	if repo.Owner != nil {
+		owner = repo.Owner.UserName
+	}
+	return &base.Repository{
+		Name:        repo.Name,
+		Owner:       owner,
+		IsPrivate:   repo.Private,
+		Description: repo.Description,
+		CloneURL:    repo.CloneURL,
+		OriginalURL: repo.HTMLURL,
+	}, nil
+}
+
+// GetTopics return gitea topics
+func (g *GiteaDownloader) GetTopics() ([]string, error) {
+	if g.gogs {
+		return []string{}, nil
+	}
+	var topics structs.TopicName
+	if err := g.get(&topics, "/topics"); err == errGiteaNotFound {
+		// older versions of gitea have no topics
+		return []string{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return topics.TopicNames, nil
+}
+
+// GetMilestones returns milestones
+func (g *GiteaDownloader) GetMilestones() ([]*base.Milestone, error) {
+	var milestones = make([]*base.Milestone, 0, giteaPageSize)
+	err := g.getAll(func(page int) (int, error) {
+		var ms []*structs.Milestone
+		if err := g.get(&ms, "/milestones?state=all&page=%d&limit=%d", page, giteaPageSize); err != nil {
+			return 0, err
+		}
+		for _, m := range ms {
+			// the API does not expose the creation time of milestones
+			milestones = append(milestones, &base.Milestone{
+				Title:       m.Title,
+				Description: m.Description,
+				Deadline:    m.Deadline,
+				Created:     time.Now(),
+				Closed:      m.Closed,
+				State:       string(m.State),
+			})
+		}
+		return len(ms), nil
+	})
+	return milestones, err
+}
+
+// GetLabels returns labels
+func (g *GiteaDownloader) GetLabels() ([]*base.Label, error) {
+	var labels = make([]*base.Label, 0, giteaPageSize)
+	err := g.getAll(func(page int) (int, error) {
+		var ls []*structs.Label
+		if err := g.get(&ls, "/labels?page=%d&limit=%d", page, giteaPageSize); err != nil {
+			return 0, err
+		}
+		labels = append(labels, convertGiteaLabels(ls)...)
+		return len(ls), nil
+	})
+	return labels, err
+}
+
+func convertGiteaRelease(rel *structs.Release) *base.Release {
+	r := &base.Release{
+		TagName:         rel.TagName,
+		TargetCommitish: rel.Target,
+		Name:            rel.Title,
+		Body:            rel.Note,
+		Draft:           rel.IsDraft,
+		Prerelease:      rel.IsPrerelease,
+		Created:         rel.CreatedAt,
+		Published:       rel.PublishedAt,
+	}
+	r.PublisherID, r.PublisherName, r.PublisherEmail = convertGiteaUser(rel.Publisher)
+
+	for _, asset := range rel.Attachments {
+		size := int(asset.Size)
+		downloadCount := int(asset.DownloadCount)
+		r.Assets = append(r.Assets, base.ReleaseAsset{
+			URL:           asset.DownloadURL,
+			Name:          asset.Name,
+			Size:          &size,
+			DownloadCount: &downloadCount,
+			Created:       asset.Created,
+			Updated:       asset.Created,
+		})
+	}
+	return r
+}
+
+// GetReleases returns releases
+func (g *GiteaDownloader) GetReleases() ([]*base.Release, error) {
+	var releases = make([]*base.Release, 0, giteaPageSize)
+	err := g.getAll(func(page int) (int, error) {
+		var rs []*structs.Release
+		if err := g.get(&rs, "/releases?page=%d&limit=%d", page, giteaPageSize); err != nil {
+			return 0, err
+		}
+		for _, rel := range rs {
+			releases = append(releases, convertGiteaRelease(rel))
+		}
+		return len(rs), nil
+	})
+	return releases, err
+}
+
+// getReactions returns the reactions of an issue or a comment, gogs has none
+func (g *GiteaDownloader) getReactions(format string, args ...interface{}) ([]*base.Reaction, error) {
+	if g.gogs {
+		return nil, nil
+	}
+	var rs []*structs.Reaction
+	if err := g.get(&rs, format, args...); err != nil {
+		return nil, err
+	}
+	reactions := make([]*base.Reaction, 0, len(rs))
+	for _, r := range rs {
+		reaction := &base.Reaction{Content: r.Reaction}
+		reaction.UserID, reaction.UserName, _ = convertGiteaUser(r.User)
+		reactions = append(reactions, reaction)
+	}
+	return reactions, nil
+}
+
+func (g *GiteaDownloader) convertGiteaIssue(issue *structs.Issue) (*base.Issue, error) {
+	reactions, err := g.getReactions("/issues/%d/reactions", issue.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	var milestone string
+	if issue.Milestone != nil {
+		milestone = issue.Milestone.Title
+	}
+	res := &base.Issue{
+		Number:    issue.Index,
+		Title:     issue.Title,
+		Content:   issue.Body,
+		Milestone: milestone,
+		State:     string(issue.State),
+		IsLocked:  issue.IsLocked,
+		Created:   issue.Created,
+		Updated:   issue.Updated,
+		Closed:    issue.Closed,
+		Labels:    convertGiteaLabels(issue.Labels),
+		Reactions: reactions,
+	}
+	res.PosterID, res.PosterName, res.PosterEmail = convertGiteaUser(issue.Poster)
+	return res, nil
+}
+
+// GetIssues returns issues according start and limit
+func (g *GiteaDownloader) GetIssues(page, perPage int) ([]*base.Issue, bool, error) {
+	for len(g.issues) < perPage && len(g.issueStates) > 0 {
+		var issues []*structs.Issue
+		if err := g.get(&issues, "/issues?state=%s&type=issues&page=%d&limit=%d", g.issueStates[0], g.issuePage, giteaPageSize); err != nil {
+			return nil, false, err
+		}
+		if len(issues) == 0 {
+			g.issueStates = g.issueStates[1:]
+			g.issuePage = 1
+			continue
+		}
+
+		converted := make([]*base.Issue, 0, len(issues))
+		for _, issue := range issues {
+			// older versions of gitea list the pull requests as well
+			if issue.PullRequest != nil {
+				continue
+			}
+			res, err := g.convertGiteaIssue(issue)
+			if err != nil {
+				return nil, false, err
+			}
+			converted = append(converted, res)
+		}
+		g.issues = append(g.issues, converted...)
+		g.issuePage++
+	}
+
+	if perPage > len(g.issues) {
+		perPage = len(g.issues)
+	}
+	issues := g.issues[:perPage]
+	g.issues = g.issues[perPage:]
+	return issues, len(g.issues) == 0 && len(g.issueStates) == 0, nil
+}
+
+// GetComments returns comments according issueNumber
+func (g *GiteaDownloader) GetComments(issueNumber int64) ([]*base.Comment, error) {
+	var cs []*structs.Comment
+	if err := g.get(&cs, "/issues/%d/comments", issueNumber); err != nil {
+		return nil, err
+	}
+
+	comments := make([]*base.Comment, 0, len(cs))
+	for _, c := range cs {
+		reactions, err := g.getReactions("/issues/comments/%d/reactions", c.ID)
+		if err != nil {
+			return nil, err
+		}
+		comment := &base.Comment{
+			IssueIndex: issueNumber,
+			Created:    c.Created,
+			Updated:    c.Updated,
+			Content:    c.Body,
+			Reactions:  reactions,
+		}
+		comment.PosterID, comment.PosterName, comment.PosterEmail = convertGiteaUser(c.Poster)
+		comments = append(comments, comment)
+	}
+	return comments, nil
+}
+
+func convertGiteaPRBranch(branch *structs.PRBranchInfo) base.PullRequestBranch {
+	if branch == nil {
+		return base.PullRequestBranch{}
+	}
+	res := base.PullRequestBranch{
+		Ref: branch.Ref,
+		SHA: branch.Sha,
+	}
+	if branch.Repository != nil {
+		res.CloneURL = branch.Repository.CloneURL
+		res.RepoName = branch.Repository.Name
+		if branch.Repository.Owner != nil {
+			res.OwnerName = branch.Repository.Owner.UserName
+		}
+	}
+	return res
+}
+
+func (g *GiteaDownloader) convertGiteaPullRequest(pr *structs.PullRequest) (*base.PullRequest, error) {
+	reactions, err := g.getReactions("/issues/%d/reactions", pr.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	var milestone string
+	if pr.Milestone != nil {
+		milestone = pr.Milestone.Title
+	}
+	res := &base.PullRequest{
+		Number:     pr.Index,
+		Title:      pr.Title,
+		Content:    pr.Body,
+		Milestone:  milestone,
+		State:      string(pr.State),
+		Closed:     pr.Closed,
+		Labels:     convertGiteaLabels(pr.Labels),
+		PatchURL:   pr.PatchURL,
+		Merged:     pr.HasMerged,
+		MergedTime: pr.Merged,
+		Head:       convertGiteaPRBranch(pr.Head),
+		Base:       convertGiteaPRBranch(pr.Base),
+		IsLocked:   pr.IsLocked,
+		Reactions:  reactions,
+	}
+	res.PosterID, res.PosterName, res.PosterEmail = convertGiteaUser(pr.Poster)
+	if pr.Created != nil {
+		res.Created = *pr.Created
+	}
+	if pr.Updated != nil {
+		res.Updated = *pr.Updated
+	}
+	if pr.MergedCommitID != nil {
+		res.MergeCommitSHA = *pr.MergedCommitID
+	}
+	// the uploader uses the base sha as merge base
+	if pr.MergeBase != "" {
+		res.Base.SHA = pr.MergeBase
+	}
+	if pr.Assignee != nil {
+		res.Assignee = pr.Assignee.UserName
+	}
+	for _, assignee := range pr.Assignees {
+		res.Assignees = append(res.Assignees, assignee.UserName)
+	}
+	return res, nil
+}
+
+// GetPullRequests returns pull requests according page and perPage, gogs has none
+func (g *GiteaDownloader) GetPullRequests(page, perPage int) ([]*base.PullRequest, error) {
+	if g.gogs {
+		return []*base.PullRequest{}, nil
+	}
+
+	for len(g.prs) < perPage && !g.prsDone {
+		var prs []*structs.PullRequest
+		if err := g.get(&prs, "/pulls?state=all&sort=oldest&page=%d&limit=%d", g.prPage, giteaPageSize); err != nil {
+			return nil, err
+		}
+		if len(prs) == 0 {
+			g.prsDone = true
+			break
+		}
+
+		converted := make([]*base.PullRequest, 0, len(prs))
+		for _, pr := range prs {
+			res, err := g.convertGiteaPullRequest(pr)
+			if err != nil {
+				return nil, err
+			}
+			converted = append(converted, res)
+		}
+		g.prs = append(g.prs, converted...)
+		g.prPage++
+	}
+
+	if perPage > len(g.prs) {
+		perPage = len(g.prs)
+	}
+	prs := g.prs[:perPage]
+	g.prs = g.prs[perPage:]
+	return prs, nil
+}
+
+func convertGiteaReviewState(state structs.ReviewStateType) string {
+	switch state {
+	case structs.ReviewStateApproved:
+		return base.ReviewStateApproved
+	case structs.ReviewStateRequestChanges:
+		return base.ReviewStateChangesRequested
+	case structs.ReviewStateComment:
+		return base.ReviewStateCommented
+	default:
+		return base.ReviewStatePending
+	}
+}
+
+// convertGiteaReviewComment converts a review comment, gitea reports the line
+// of the comment while the uploader expects a position in the diff hunk
+func convertGiteaReviewComment(c *structs.PullReviewComment) *base.ReviewComment {
+	diffHunk := c.DiffHunk
+	if !strings.Contains(diffHunk, "@@") {
+		diffHunk = "@@ -0,0 +0,0 @@"
+	}
+	line := int(c.LineNum)
+	if line == 0 {
+		line = -int(c.OldLineNum)
+	}
+	_, _, hunkLine, _ := git.ParseDiffHunkString(diffHunk)
+
+	res := &base.ReviewComment{
+		ID:        c.ID,
+		Content:   c.Body,
+		TreePath:  c.Path,
+		DiffHunk:  diffHunk,
+		Position:  line - hunkLine + 1,
+		CommitID:  c.CommitID,
+		CreatedAt: c.Created,
+		UpdatedAt: c.Updated,
+	}
+	res.PosterID, _, _ = convertGiteaUser(c.Reviewer)
+	return res
+}
+
+// GetReviews returns pull requests review
+func (g *GiteaDownloader) GetReviews(pullRequestNumber int64) ([]*base.Review, error) {
+	if g.gogs {
+		return []*base.Review{}, nil
+	}
+
+	var reviews = make([]*base.Review, 0, giteaPageSize)
+	err := g.getAll(func(page int) (int, error) {
+		var rs []*structs.PullReview
+		if err := g.get(&rs, "/pulls/%d/reviews?page=%d&limit=%d", pullRequestNumber, page, giteaPageSize); err != nil {
+			return 0, err
+		}
+		for _, r := range rs {
+			// requested reviews are no reviews yet
+			if r.State == structs.ReviewStateRequestReview {
+				continue
+			}
+			review := &base.Review{
+				ID:         r.ID,
+				IssueIndex: pullRequestNumber,
+				Official:   r.Official,
+				CommitID:   r.CommitID,
+				Content:    r.Body,
+				CreatedAt:  r.Submitted,
+				State:      convertGiteaReviewState(r.State),
+			}
+			review.ReviewerID, review.ReviewerName, _ = convertGiteaUser(r.Reviewer)
+
+			if r.CodeCommentsCount > 0 {
+				var cs []*structs.PullReviewComment
+				if err := g.get(&cs, "/pulls/%d/reviews/%d/comments", pullRequestNumber, r.ID); err != nil {
+					return 0, err
+				}
+				for _, c := range cs {
+					review.Comments = append(review.Comments, convertGiteaReviewComment(c))
+				}
+			}
+			reviews = append(reviews, review)
+		}
+		return len(rs), nil
+	})
+	return reviews, err
+}
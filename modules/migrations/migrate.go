@@ -30,17 +30,43 @@ func RegisterDownloaderFactory(factory base.DownloaderFactory) {
 
 // MigrateRepository migrate repository according MigrateOptions
 func MigrateRepository(ctx context.Context, doer *models.User, ownerName string, opts base.MigrateOptions) (*models.Repository, error) {
+	downloader, err := newDownloader(ownerName, &opts)
+	if err != nil {
+		return nil, err
+	}
+
+	uploader := NewGiteaLocalUploader(ctx, doer, ownerName, opts.RepoName)
+	uploader.gitServiceType = opts.GitServiceType
+
+	downloader.SetContext(ctx)
+
+	if err := migrateRepository(downloader, uploader, opts); err != nil {
+		if err1 := uploader.Rollback(); err1 != nil {
+			log.Error("rollback failed: %v", err1)
+		}
+
+		if err2 := models.CreateRepositoryNotice(fmt.Sprintf("Migrate repository from %s failed: %v", opts.OriginalURL, err)); err2 != nil {
+			log.Error("create respotiry notice failed: ", err2)
+		}
+		return nil, err
+	}
+
+	return uploader.repo, nil
+}
+
+// newDownloader returns the downloader of the first matching factory or a plain
+// git downloader, the options are adjusted to what the downloader supports
+func newDownloader(ownerName string, opts *base.MigrateOptions) (base.Downloader, error) {
 	var (
 		downloader base.Downloader
-		uploader   = NewGiteaLocalUploader(ctx, doer, ownerName, opts.RepoName)
 		theFactory base.DownloaderFactory
 	)
 
 	for _, factory := range factories {
-		if match, err := factory.Match(opts); err != nil {
+		if match, err := factory.Match(*opts); err != nil {
 			return nil, err
 		} else if match {
-			downloader, err = factory.New(opts)
+			downloader, err = factory.New(*opts)
 			if err != nil {
 				return nil, err
 			}
@@ -64,26 +90,10 @@ func MigrateRepository(ctx context.Context, doer *models.User, ownerName string,
 		opts.GitServiceType = theFactory.GitServiceType()
 	}
 
-	uploader.gitServiceType = opts.GitServiceType
-
 	if setting.Migrations.MaxAttempts > 1 {
 		downloader = base.NewRetryDownloader(downloader, setting.Migrations.MaxAttempts, setting.Migrations.RetryBackoff)
 	}
-
-	downloader.SetContext(ctx)
-
-	if err := migrateRepository(downloader, uploader, opts); err != nil {
-		if err1 := uploader.Rollback(); err1 != nil {
-			log.Error("rollback failed: %v", err1)
-		}
-
-		if err2 := models.CreateRepositoryNotice(fmt.Sprintf("Migrate repository from %s failed: %v", opts.OriginalURL, err)); err2 != nil {
-			log.Error("create respotiry notice failed: ", err2)
-		}
-		return nil, err
-	}
-
-	return uploader.repo, nil
+	return downloader, nil
 }
 
 // migrateRepository will download information and then upload it to Uploader, this is a simple
@@ -0,0 +1,375 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/migrations/base"
+
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	_ base.Downloader = &RepositoryRestorer{}
+)
+
+// RepositoryRestorer implements a Downloader which reads a repository dump
+// written by RepositoryDumper
+type RepositoryRestorer struct {
+	ctx     context.Context
+	baseDir string
+	tmpDir  string
+	repo    *dumpedRepository
+	issues  []*base.Issue
+	prs     []*base.PullRequest
+}
+
+// NewRepositoryRestorer creates a repository restorer which reads the dump in baseDir
+func NewRepositoryRestorer(ctx context.Context, baseDir string) (*RepositoryRestorer, error) {
+	baseDir, err := filepath.Abs(baseDir)
+	if err != nil {
+		return nil, err
+	}
+	// the files of the dump are compared with their resolved paths
+	baseDir, err = filepath.EvalSymlinks(baseDir)
+	if err != nil {
+		return nil, err
+	}
+	r := &RepositoryRestorer{
+		ctx:     ctx,
+		baseDir: baseDir,
+	}
+	if err := r.readYAML(dumpRepoFile, &r.repo); err != nil {
+		return nil, err
+	}
+	if r.repo == nil {
+		return nil, fmt.Errorf("%s is not a repository dump", baseDir)
+	}
+	return r, nil
+}
+
+// SetContext set context
+func (r *RepositoryRestorer) SetContext(ctx context.Context) {
+	r.ctx = ctx
+}
+
+// MigrateOptions returns the options the repository was dumped with
+func (r *RepositoryRestorer) MigrateOptions() base.MigrateOptions {
+	return base.MigrateOptions{
+		OriginalURL:    r.repo.OriginalURL,
+		GitServiceType: gitServiceTypeByName(r.repo.GitServiceType),
+		Description:    r.repo.Description,
+		Private:        r.repo.IsPrivate,
+		Wiki:           r.repo.Wiki,
+		Milestones:     r.repo.Milestones,
+		Labels:         r.repo.Labels,
+		Releases:       r.repo.Releases,
+		Issues:         r.repo.Issues,
+		Comments:       r.repo.Comments,
+		PullRequests:   r.repo.PullRequests,
+	}
+}
+
+// Close removes the unbundled git repositories
+func (r *RepositoryRestorer) Close() {
+	if r.tmpDir != "" {
+		if err := os.RemoveAll(r.tmpDir); err != nil {
+			log.Error("Unable to remove %s: %v", r.tmpDir, err)
+		}
+		r.tmpDir = ""
+	}
+}
+
+// readYAML reads the YAML file below the base directory, a missing file is no error
+func (r *RepositoryRestorer) readYAML(name string, v interface{}) error {
+	p, err := r.resolve(filepath.Join(r.baseDir, name))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	bs, err := ioutil.ReadFile(p)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(bs, v)
+}
+
+// inBaseDir checks that the cleaned absolute path is below the base directory
+func (r *RepositoryRestorer) inBaseDir(p string) bool {
+	rel, err := filepath.Rel(r.baseDir, p)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// resolve follows the symbolic links of the path, which may come from the extracted
+// archive, and checks that it is a regular file below the base directory
+func (r *RepositoryRestorer) resolve(p string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		return "", err
+	}
+	if !r.inBaseDir(resolved) {
+		return "", fmt.Errorf("%s is not in the dump directory", p)
+	}
+	fi, err := os.Stat(resolved)
+	if err != nil {
+		return "", err
+	}
+	if !fi.Mode().IsRegular() {
+		return "", fmt.Errorf("%s is not a regular file", p)
+	}
+	return resolved, nil
+}
+
+// fileURL converts a path relative to the base directory into a file:// URL, paths
+// leaving the base directory are refused
+func (r *RepositoryRestorer) fileURL(name string) (string, error) {
+	if name == "" {
+		return "", nil
+	}
+	p := filepath.Join(r.baseDir, filepath.FromSlash(name))
+	if !r.inBaseDir(p) {
+		return "", fmt.Errorf("%s is not in the dump directory", name)
+	}
+	return "file://" + filepath.ToSlash(p), nil
+}
+
+// openFile opens a file:// URL returned by fileURL
+func (r *RepositoryRestorer) openFile(uri string) (io.ReadCloser, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	p := filepath.Clean(filepath.FromSlash(u.Path))
+	if u.Scheme != "file" || !r.inBaseDir(p) {
+		return nil, fmt.Errorf("%s is not a file of the dump directory", uri)
+	}
+	p, err = r.resolve(p)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(p)
+}
+
+// unbundle clones the bundle into the temporary directory
+func (r *RepositoryRestorer) unbundle(bundleFile, repoDir string) (bool, error) {
+	bundlePath, err := r.resolve(filepath.Join(r.baseDir, bundleFile))
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if err := git.Clone(bundlePath, filepath.Join(r.tmpDir, repoDir), git.CloneRepoOptions{
+		Mirror: true,
+		Quiet:  true,
+	}); err != nil {
+		return false, fmt.Errorf("Clone %s: %v", bundleFile, err)
+	}
+	return true, nil
+}
+
+// GetRepoInfo unbundles the git data and returns the repository information
+func (r *RepositoryRestorer) GetRepoInfo() (*base.Repository, error) {
+	if r.tmpDir == "" {
+		tmpDir, err := ioutil.TempDir(os.TempDir(), "gitea-restore")
+		if err != nil {
+			return nil, err
+		}
+		r.tmpDir = tmpDir
+	}
+
+	repo := r.repo.Repository
+	if ok, err := r.unbundle(dumpGitBundle, "repo.git"); err != nil {
+		return nil, err
+	} else if !ok {
+		if err := git.InitRepository(filepath.Join(r.tmpDir, "repo.git"), true); err != nil {
+			return nil, err
+		}
+	}
+	// the uploader finds the wiki next to the repository by its suffix
+	if _, err := r.unbundle(dumpWikiBundle, "repo.wiki.git"); err != nil {
+		return nil, err
+	}
+	repo.CloneURL = filepath.Join(r.tmpDir, "repo.git")
+	return &repo, nil
+}
+
+// GetTopics returns the topics
+func (r *RepositoryRestorer) GetTopics() ([]string, error) {
+	var topics = struct {
+		Topics []string `yaml:"topics"`
+	}{}
+	if err := r.readYAML(dumpTopicFile, &topics); err != nil {
+		return nil, err
+	}
+	return topics.Topics, nil
+}
+
+// GetMilestones returns milestones
+func (r *RepositoryRestorer) GetMilestones() ([]*base.Milestone, error) {
+	var milestones = make([]*base.Milestone, 0, 10)
+	if err := r.readYAML(dumpMilestoneFile, &milestones); err != nil {
+		return nil, err
+	}
+	return milestones, nil
+}
+
+// GetReleases returns releases
+func (r *RepositoryRestorer) GetReleases() ([]*base.Release, error) {
+	var releases = make([]*base.Release, 0, 10)
+	if err := r.readYAML(dumpReleaseFile, &releases); err != nil {
+		return nil, err
+	}
+	for _, rel := range releases {
+		for i := range rel.Assets {
+			u, err := r.fileURL(rel.Assets[i].URL)
+			if err != nil {
+				return nil, err
+			}
+			rel.Assets[i].URL = u
+		}
+	}
+	return releases, nil
+}
+
+// GetLabels returns labels
+func (r *RepositoryRestorer) GetLabels() ([]*base.Label, error) {
+	var labels = make([]*base.Label, 0, 10)
+	if err := r.readYAML(dumpLabelFile, &labels); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+// GetIssues returns issues according start and limit
+func (r *RepositoryRestorer) GetIssues(page, perPage int) ([]*base.Issue, bool, error) {
+	if r.issues == nil {
+		r.issues = make([]*base.Issue, 0, 10)
+		if err := r.readYAML(dumpIssueFile, &r.issues); err != nil {
+			return nil, false, err
+		}
+	}
+	start, end := pageRange(page, perPage, len(r.issues))
+	return r.issues[start:end], end >= len(r.issues), nil
+}
+
+// GetComments returns comments according issueNumber
+func (r *RepositoryRestorer) GetComments(issueNumber int64) ([]*base.Comment, error) {
+	var comments = make([]*base.Comment, 0, 10)
+	if err := r.readYAML(filepath.Join(dumpCommentDir, strconv.FormatInt(issueNumber, 10)+".yml"), &comments); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// GetPullRequests returns pull requests according page and perPage
+func (r *RepositoryRestorer) GetPullRequests(page, perPage int) ([]*base.PullRequest, error) {
+	if r.prs == nil {
+		r.prs = make([]*base.PullRequest, 0, 10)
+		if err := r.readYAML(dumpPullFile, &r.prs); err != nil {
+			return nil, err
+		}
+		for _, pr := range r.prs {
+			u, err := r.fileURL(pr.PatchURL)
+			if err != nil {
+				r.prs = nil
+				return nil, err
+			}
+			pr.PatchURL = u
+		}
+	}
+	start, end := pageRange(page, perPage, len(r.prs))
+	return r.prs[start:end], nil
+}
+
+// GetReviews returns pull requests review
+func (r *RepositoryRestorer) GetReviews(pullRequestNumber int64) ([]*base.Review, error) {
+	var reviews = make([]*base.Review, 0, 10)
+	if err := r.readYAML(filepath.Join(dumpReviewDir, strconv.FormatInt(pullRequestNumber, 10)+".yml"), &reviews); err != nil {
+		return nil, err
+	}
+	return reviews, nil
+}
+
+// pageRange returns the slice bounds of the 1-based page in a list of total items
+func pageRange(page, perPage, total int) (int, int) {
+	start := (page - 1) * perPage
+	if start > total {
+		start = total
+	}
+	end := start + perPage
+	if end > total {
+		end = total
+	}
+	return start, end
+}
+
+// RestoreRepository restores a repository dump from baseDir into a new repository
+// of ownerName, units not in the dump are skipped. The doer creates the repository
+// and is the poster of all items whose original author is not linked to a user.
+func RestoreRepository(ctx context.Context, baseDir string, doer *models.User, ownerName, repoName string, units []string) (*models.Repository, error) {
+	restorer, err := NewRepositoryRestorer(ctx, baseDir)
+	if err != nil {
+		return nil, err
+	}
+	defer restorer.Close()
+
+	opts := restorer.MigrateOptions()
+	opts.RepoName = repoName
+	if len(units) > 0 {
+		restricted := base.MigrateOptions{
+			OriginalURL:    opts.OriginalURL,
+			GitServiceType: opts.GitServiceType,
+			Description:    opts.Description,
+			Private:        opts.Private,
+			RepoName:       repoName,
+		}
+		for _, unit := range units {
+			switch unit {
+			case "wiki":
+				restricted.Wiki = opts.Wiki
+			case "milestones":
+				restricted.Milestones = opts.Milestones
+			case "labels":
+				restricted.Labels = opts.Labels
+			case "releases":
+				restricted.Releases = opts.Releases
+			case "issues":
+				restricted.Issues = opts.Issues
+			case "comments":
+				restricted.Comments = opts.Comments
+			case "pull_requests":
+				restricted.PullRequests = opts.PullRequests
+			default:
+				return nil, fmt.Errorf("unknown unit %q", unit)
+			}
+		}
+		opts = restricted
+	}
+
+	uploader := NewGiteaLocalUploader(ctx, doer, ownerName, repoName)
+	uploader.gitServiceType = opts.GitServiceType
+	uploader.restorer = restorer
+
+	if err := migrateRepository(restorer, uploader, opts); err != nil {
+		if err1 := uploader.Rollback(); err1 != nil {
+			log.Error("rollback failed: %v", err1)
+		}
+		return nil, err
+	}
+	return uploader.repo, nil
+}
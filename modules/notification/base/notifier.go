@@ -20,6 +20,14 @@ type Notifier interface {
 	NotifyRenameRepository(doer *models.User, repo *models.Repository, oldRepoName string)
 	NotifyTransferRepository(doer *models.User, repo *models.Repository, oldOwnerName string)
 
+	NotifyCreateUser(doer *models.User, u *models.User)
+	NotifyDeleteUser(doer *models.User, u *models.User)
+	NotifyCreateOrganization(doer *models.User, org *models.User)
+	NotifyDeleteOrganization(doer *models.User, org *models.User)
+	NotifyAddTeamMember(doer *models.User, team *models.Team, member *models.User)
+	NotifyRemoveTeamMember(doer *models.User, team *models.Team, member *models.User)
+	NotifyRemoveOrgMember(doer *models.User, org *models.User, member *models.User)
+
 	NotifyNewIssue(*models.Issue)
 	NotifyIssueChangeStatus(*models.User, *models.Issue, *models.Comment, bool)
 	NotifyIssueChangeMilestone(doer *models.User, issue *models.Issue, oldMilestoneID int64)
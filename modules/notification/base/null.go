@@ -154,3 +154,31 @@ func (*NullNotifier) NotifySyncCreateRef(doer *models.User, repo *models.Reposit
 // NotifySyncDeleteRef places a place holder function
 func (*NullNotifier) NotifySyncDeleteRef(doer *models.User, repo *models.Repository, refType, refFullName string) {
 }
+
+// NotifyCreateUser places a place holder function
+func (*NullNotifier) NotifyCreateUser(doer *models.User, u *models.User) {
+}
+
+// NotifyDeleteUser places a place holder function
+func (*NullNotifier) NotifyDeleteUser(doer *models.User, u *models.User) {
+}
+
+// NotifyCreateOrganization places a place holder function
+func (*NullNotifier) NotifyCreateOrganization(doer *models.User, org *models.User) {
+}
+
+// NotifyDeleteOrganization places a place holder function
+func (*NullNotifier) NotifyDeleteOrganization(doer *models.User, org *models.User) {
+}
+
+// NotifyAddTeamMember places a place holder function
+func (*NullNotifier) NotifyAddTeamMember(doer *models.User, team *models.Team, member *models.User) {
+}
+
+// NotifyRemoveTeamMember places a place holder function
+func (*NullNotifier) NotifyRemoveTeamMember(doer *models.User, team *models.Team, member *models.User) {
+}
+
+// NotifyRemoveOrgMember places a place holder function
+func (*NullNotifier) NotifyRemoveOrgMember(doer *models.User, org *models.User, member *models.User) {
+}
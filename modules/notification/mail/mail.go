@@ -98,14 +98,14 @@ func (m *mailNotifier) NotifyIssueChangeAssignee(doer *models.User, issue *model
 	// mail only sent to added assignees and not self-assignee
 	if !removed && doer.ID != assignee.ID && assignee.EmailNotifications() == models.EmailNotificationsEnabled {
 		ct := fmt.Sprintf("Assigned #%d.", issue.Index)
-		mailer.SendIssueAssignedMail(issue, doer, ct, comment, []string{assignee.Email})
+		mailer.SendIssueAssignedMail(issue, doer, ct, comment, []*models.User{assignee})
 	}
 }
 
 func (m *mailNotifier) NotifyPullReviewRequest(doer *models.User, issue *models.Issue, reviewer *models.User, isRequest bool, comment *models.Comment) {
 	if isRequest && doer.ID != reviewer.ID && reviewer.EmailNotifications() == models.EmailNotificationsEnabled {
 		ct := fmt.Sprintf("Requested to review #%d.", issue.Index)
-		mailer.SendIssueAssignedMail(issue, doer, ct, comment, []string{reviewer.Email})
+		mailer.SendIssueAssignedMail(issue, doer, ct, comment, []*models.User{reviewer})
 	}
 }
 
@@ -10,6 +10,7 @@ import (
 	"code.gitea.io/gitea/modules/notification/base"
 	"code.gitea.io/gitea/modules/notification/indexer"
 	"code.gitea.io/gitea/modules/notification/mail"
+	"code.gitea.io/gitea/modules/notification/project"
 	"code.gitea.io/gitea/modules/notification/ui"
 	"code.gitea.io/gitea/modules/notification/webhook"
 	"code.gitea.io/gitea/modules/repository"
@@ -35,6 +36,7 @@ func NewContext() {
 	RegisterNotifier(indexer.NewNotifier())
 	RegisterNotifier(webhook.NewNotifier())
 	RegisterNotifier(action.NewNotifier())
+	RegisterNotifier(project.NewNotifier())
 }
 
 // NotifyCreateIssueComment notifies issue comment related message to notifiers
@@ -269,3 +271,52 @@ func NotifySyncDeleteRef(pusher *models.User, repo *models.Repository, refType,
 		notifier.NotifySyncDeleteRef(pusher, repo, refType, refFullName)
 	}
 }
+
+// NotifyCreateUser notifies user creation to notifiers
+func NotifyCreateUser(doer *models.User, u *models.User) {
+	for _, notifier := range notifiers {
+		notifier.NotifyCreateUser(doer, u)
+	}
+}
+
+// NotifyDeleteUser notifies user deletion to notifiers
+func NotifyDeleteUser(doer *models.User, u *models.User) {
+	for _, notifier := range notifiers {
+		notifier.NotifyDeleteUser(doer, u)
+	}
+}
+
+// NotifyCreateOrganization notifies organization creation to notifiers
+func NotifyCreateOrganization(doer *models.User, org *models.User) {
+	for _, notifier := range notifiers {
+		notifier.NotifyCreateOrganization(doer, org)
+	}
+}
+
+// NotifyDeleteOrganization notifies organization deletion to notifiers
+func NotifyDeleteOrganization(doer *models.User, org *models.User) {
+	for _, notifier := range notifiers {
+		notifier.NotifyDeleteOrganization(doer, org)
+	}
+}
+
+// NotifyAddTeamMember notifies a member added to a team to notifiers
+func NotifyAddTeamMember(doer *models.User, team *models.Team, member *models.User) {
+	for _, notifier := range notifiers {
+		notifier.NotifyAddTeamMember(doer, team, member)
+	}
+}
+
+// NotifyRemoveTeamMember notifies a member removed from a team to notifiers
+func NotifyRemoveTeamMember(doer *models.User, team *models.Team, member *models.User) {
+	for _, notifier := range notifiers {
+		notifier.NotifyRemoveTeamMember(doer, team, member)
+	}
+}
+
+// NotifyRemoveOrgMember notifies a member removed from an organization to notifiers
+func NotifyRemoveOrgMember(doer *models.User, org *models.User, member *models.User) {
+	for _, notifier := range notifiers {
+		notifier.NotifyRemoveOrgMember(doer, org, member)
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package project
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification/base"
+)
+
+type projectNotifier struct {
+	base.NullNotifier
+}
+
+var (
+	_ base.Notifier = &projectNotifier{}
+)
+
+// NewNotifier create a new projectNotifier notifier which moves the cards
+// of closed and reopened issues on their project board
+func NewNotifier() base.Notifier {
+	return &projectNotifier{}
+}
+
+func (r *projectNotifier) NotifyIssueChangeStatus(doer *models.User, issue *models.Issue, actionComment *models.Comment, isClosed bool) {
+	if err := models.MoveIssueOnStatusChange(issue, isClosed); err != nil {
+		log.Error("MoveIssueOnStatusChange [%d]: %v", issue.ID, err)
+	}
+}
+
+func (r *projectNotifier) NotifyMergePullRequest(pr *models.PullRequest, doer *models.User) {
+	if err := pr.LoadIssue(); err != nil {
+		log.Error("LoadIssue [%d]: %v", pr.ID, err)
+		return
+	}
+	if err := models.MoveIssueOnStatusChange(pr.Issue, true); err != nil {
+		log.Error("MoveIssueOnStatusChange [%d]: %v", pr.Issue.ID, err)
+	}
+}
@@ -85,44 +85,39 @@ func (m *webhookNotifier) NotifyForkRepository(doer *models.User, oldRepo, repo
 	u := repo.MustOwner()
 
 	// Add to hook queue for created repo after session commit.
-	if u.IsOrganization() {
-		if err := webhook_module.PrepareWebhooks(repo, models.HookEventRepository, &api.RepositoryPayload{
-			Action:       api.HookRepoCreated,
-			Repository:   repo.APIFormat(models.AccessModeOwner),
-			Organization: u.APIFormat(),
-			Sender:       doer.APIFormat(),
-		}); err != nil {
-			log.Error("PrepareWebhooks [repo_id: %d]: %v", repo.ID, err)
-		}
-	}
+	sendRepositoryHook(doer, u, repo, api.HookRepoCreated)
 }
 
 func (m *webhookNotifier) NotifyCreateRepository(doer *models.User, u *models.User, repo *models.Repository) {
 	// Add to hook queue for created repo after session commit.
-	if u.IsOrganization() {
-		if err := webhook_module.PrepareWebhooks(repo, models.HookEventRepository, &api.RepositoryPayload{
-			Action:       api.HookRepoCreated,
-			Repository:   repo.APIFormat(models.AccessModeOwner),
-			Organization: u.APIFormat(),
-			Sender:       doer.APIFormat(),
-		}); err != nil {
-			log.Error("PrepareWebhooks [repo_id: %d]: %v", repo.ID, err)
-		}
-	}
+	sendRepositoryHook(doer, u, repo, api.HookRepoCreated)
 }
 
 func (m *webhookNotifier) NotifyDeleteRepository(doer *models.User, repo *models.Repository) {
-	u := repo.MustOwner()
+	sendRepositoryHook(doer, repo.MustOwner(), repo, api.HookRepoDeleted)
+}
 
+// sendRepositoryHook sends a repository event to the webhooks of the repository
+// if it belongs to an organization, the repositories of users only notify the
+// system webhooks.
+func sendRepositoryHook(doer, u *models.User, repo *models.Repository, action api.HookRepoAction) {
+	var err error
 	if u.IsOrganization() {
-		if err := webhook_module.PrepareWebhooks(repo, models.HookEventRepository, &api.RepositoryPayload{
-			Action:       api.HookRepoDeleted,
+		err = webhook_module.PrepareWebhooks(repo, models.HookEventRepository, &api.RepositoryPayload{
+			Action:       action,
 			Repository:   repo.APIFormat(models.AccessModeOwner),
 			Organization: u.APIFormat(),
 			Sender:       doer.APIFormat(),
-		}); err != nil {
-			log.Error("PrepareWebhooks [repo_id: %d]: %v", repo.ID, err)
-		}
+		})
+	} else {
+		err = webhook_module.PrepareSystemWebhooks(models.HookEventRepository, &api.RepositoryPayload{
+			Action:     action,
+			Repository: repo.APIFormat(models.AccessModeOwner),
+			Sender:     doer.APIFormat(),
+		})
+	}
+	if err != nil {
+		log.Error("PrepareWebhooks [repo_id: %d]: %v", repo.ID, err)
 	}
 }
 
@@ -801,3 +796,80 @@ func (m *webhookNotifier) NotifySyncPushCommits(pusher *models.User, repo *model
 		log.Error("PrepareWebhooks: %v", err)
 	}
 }
+
+func (m *webhookNotifier) NotifyCreateUser(doer *models.User, u *models.User) {
+	if err := webhook_module.PrepareSystemWebhooks(models.HookEventUser, &api.UserPayload{
+		Action: api.HookUserCreated,
+		User:   u.APIFormat(),
+		Sender: doer.APIFormat(),
+	}); err != nil {
+		log.Error("PrepareSystemWebhooks [user_id: %d]: %v", u.ID, err)
+	}
+}
+
+func (m *webhookNotifier) NotifyDeleteUser(doer *models.User, u *models.User) {
+	if err := webhook_module.PrepareSystemWebhooks(models.HookEventUser, &api.UserPayload{
+		Action: api.HookUserDeleted,
+		User:   u.APIFormat(),
+		Sender: doer.APIFormat(),
+	}); err != nil {
+		log.Error("PrepareSystemWebhooks [user_id: %d]: %v", u.ID, err)
+	}
+}
+
+func (m *webhookNotifier) NotifyCreateOrganization(doer *models.User, org *models.User) {
+	if err := webhook_module.PrepareSystemWebhooks(models.HookEventOrganization, &api.OrganizationPayload{
+		Action:       api.HookOrganizationCreated,
+		Organization: convert.ToOrganization(org),
+		Sender:       doer.APIFormat(),
+	}); err != nil {
+		log.Error("PrepareSystemWebhooks [org_id: %d]: %v", org.ID, err)
+	}
+}
+
+func (m *webhookNotifier) NotifyDeleteOrganization(doer *models.User, org *models.User) {
+	// the webhooks of the organization are gone with it
+	if err := webhook_module.PrepareSystemWebhooks(models.HookEventOrganization, &api.OrganizationPayload{
+		Action:       api.HookOrganizationDeleted,
+		Organization: convert.ToOrganization(org),
+		Sender:       doer.APIFormat(),
+	}); err != nil {
+		log.Error("PrepareSystemWebhooks [org_id: %d]: %v", org.ID, err)
+	}
+}
+
+func sendTeamMemberHook(doer *models.User, team *models.Team, member *models.User, action api.HookOrganizationAction) {
+	org, err := models.GetUserByID(team.OrgID)
+	if err != nil {
+		log.Error("GetUserByID [org_id: %d]: %v", team.OrgID, err)
+		return
+	}
+	sendOrgMemberHook(doer, org, team, member, action)
+}
+
+func sendOrgMemberHook(doer, org *models.User, team *models.Team, member *models.User, action api.HookOrganizationAction) {
+	p := &api.OrganizationPayload{
+		Action:       action,
+		Organization: convert.ToOrganization(org),
+		Member:       member.APIFormat(),
+		Sender:       doer.APIFormat(),
+	}
+	if team != nil {
+		p.Team = convert.ToTeam(team)
+	}
+	if err := webhook_module.PrepareOrgWebhooks(org, models.HookEventOrganization, p); err != nil {
+		log.Error("PrepareOrgWebhooks [org_id: %d]: %v", org.ID, err)
+	}
+}
+
+func (m *webhookNotifier) NotifyAddTeamMember(doer *models.User, team *models.Team, member *models.User) {
+	sendTeamMemberHook(doer, team, member, api.HookOrganizationMemberAdded)
+}
+
+func (m *webhookNotifier) NotifyRemoveTeamMember(doer *models.User, team *models.Team, member *models.User) {
+	sendTeamMemberHook(doer, team, member, api.HookOrganizationMemberRemoved)
+}
+
+func (m *webhookNotifier) NotifyRemoveOrgMember(doer *models.User, org *models.User, member *models.User) {
+	sendOrgMemberHook(doer, org, nil, member, api.HookOrganizationMemberRemoved)
+}
@@ -0,0 +1,103 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package packages
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
+)
+
+// AddFile stores the content read from r as file with the given name of the
+// version. The content is written to a temporary file first to compute its
+// hashes, blobs which are already known are not stored again.
+func AddFile(pv *models.PackageVersion, name string, r io.Reader) (*models.PackageFile, error) {
+	tmp, err := ioutil.TempFile("", "package-upload")
+	if err != nil {
+		return nil, fmt.Errorf("TempFile: %v", err)
+	}
+	defer func() {
+		_ = tmp.Close()
+		if err := os.Remove(tmp.Name()); err != nil {
+			log.Error("Unable to remove temporary package file %s: %v", tmp.Name(), err)
+		}
+	}()
+
+	blob, err := hashBlob(tmp, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.AddPackageFile(pv, name, blob, func() error {
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err := storage.LFS.Save(blob.RelativePath(), tmp, blob.Size); err != nil {
+			return fmt.Errorf("Save: %v", err)
+		}
+		return nil
+	})
+}
+
+// hashBlob copies r to w and returns a blob with the size and the hashes of the content
+func hashBlob(w io.Writer, r io.Reader) (*models.PackageBlob, error) {
+	hashMD5, hashSHA1, hashSHA256, hashSHA512 := md5.New(), sha1.New(), sha256.New(), sha512.New()
+	size, err := io.Copy(io.MultiWriter(w, hashMD5, hashSHA1, hashSHA256, hashSHA512), r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PackageBlob{
+		Size:       size,
+		HashMD5:    hex.EncodeToString(hashMD5.Sum(nil)),
+		HashSHA1:   hex.EncodeToString(hashSHA1.Sum(nil)),
+		HashSHA256: hex.EncodeToString(hashSHA256.Sum(nil)),
+		HashSHA512: hex.EncodeToString(hashSHA512.Sum(nil)),
+	}, nil
+}
+
+// OpenFile opens the content of the file for reading
+func OpenFile(pf *models.PackageFile) (storage.Object, error) {
+	if err := pf.LoadBlob(); err != nil {
+		return nil, err
+	}
+	return storage.LFS.Open(pf.Blob.RelativePath())
+}
+
+// RegistryURL returns the base URL of the package registry of the owner for the package type
+func RegistryURL(owner *models.User, packageType models.PackageType) string {
+	return setting.AppURL + "api/packages/" + url.PathEscape(owner.Name) + "/" + packageType.Name()
+}
+
+// FileURL returns the URL the file of the package version is downloaded from
+func FileURL(p *models.Package, pv *models.PackageVersion, filename string) string {
+	base := RegistryURL(p.Owner, p.Type)
+	switch p.Type {
+	case models.PackageTypeNpm:
+		return base + "/" + url.PathEscape(p.Name) + "/-/" + url.PathEscape(pv.Version) + "/" + url.PathEscape(filename)
+	case models.PackageTypeMaven:
+		// Maven packages are named groupId:artifactId
+		parts := strings.SplitN(p.Name, ":", 2)
+		if len(parts) != 2 {
+			return ""
+		}
+		return base + "/" + strings.ReplaceAll(parts[0], ".", "/") + "/" + parts[1] + "/" + url.PathEscape(pv.Version) + "/" + url.PathEscape(filename)
+	default:
+		return base + "/" + url.PathEscape(p.Name) + "/" + url.PathEscape(pv.Version) + "/" + url.PathEscape(filename)
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package packages
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddFile(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+
+	pv := models.AssertExistsAndLoadBean(t, &models.PackageVersion{ID: 1}).(*models.PackageVersion)
+
+	pf, err := AddFile(pv, "hello.txt", strings.NewReader("hello"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 5, pf.Blob.Size)
+	assert.Equal(t, "5d41402abc4b2a76b9719d911017c592", pf.Blob.HashMD5)
+	assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", pf.Blob.HashSHA256)
+
+	f, err := OpenFile(pf)
+	assert.NoError(t, err)
+	content, err := ioutil.ReadAll(f)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+	assert.Equal(t, "hello", string(content))
+
+	_, err = AddFile(pv, "HELLO.txt", strings.NewReader("hello again"))
+	assert.True(t, models.IsErrPackageFileAlreadyExist(err))
+}
+
+func TestFileURL(t *testing.T) {
+	owner := &models.User{Name: "user2"}
+	pv := &models.PackageVersion{Version: "1.0.0"}
+
+	assert.Equal(t, setting.AppURL+"api/packages/user2/generic/test-package/1.0.0/file.bin",
+		FileURL(&models.Package{Owner: owner, Type: models.PackageTypeGeneric, Name: "test-package"}, pv, "file.bin"))
+	assert.Equal(t, setting.AppURL+"api/packages/user2/npm/@scope%2Flib/-/1.0.0/lib-1.0.0.tgz",
+		FileURL(&models.Package{Owner: owner, Type: models.PackageTypeNpm, Name: "@scope/lib"}, pv, "lib-1.0.0.tgz"))
+	assert.Equal(t, setting.AppURL+"api/packages/user2/maven/org/example/lib/1.0.0/lib-1.0.0.jar",
+		FileURL(&models.Package{Owner: owner, Type: models.PackageTypeMaven, Name: "org.example:lib"}, pv, "lib-1.0.0.jar"))
+}
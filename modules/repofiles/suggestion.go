@@ -0,0 +1,175 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repofiles
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+)
+
+// ApplySuggestionsOptions holds the code comments whose suggested changes are to be
+// committed to a branch
+type ApplySuggestionsOptions struct {
+	Branch   string
+	Message  string
+	Comments []*models.Comment
+}
+
+type suggestedChange struct {
+	comment *models.Comment
+	// index of the first commented line in the file
+	index int
+	*models.Suggestion
+}
+
+// ApplySuggestions commits the first change suggested by each of the code comments to the
+// branch, all in a single commit. It fails without committing anything if any of the
+// commented lines has changed since the comment was made.
+func ApplySuggestions(repo *models.Repository, doer *models.User, opts *ApplySuggestionsOptions) error {
+	changes := make(map[string][]*suggestedChange)
+	treePaths := make([]string, 0, len(opts.Comments))
+	for _, comment := range opts.Comments {
+		suggestions := comment.Suggestions()
+		if len(suggestions) == 0 {
+			return fmt.Errorf("comment %d does not suggest any change", comment.ID)
+		}
+		if comment.Invalidated {
+			return models.ErrSuggestionOutdated{CommentID: comment.ID, Path: comment.TreePath, Line: comment.Line}
+		}
+		if _, ok := changes[comment.TreePath]; !ok {
+			treePaths = append(treePaths, comment.TreePath)
+		}
+		firstLine := comment.UnsignedStartLine()
+		if firstLine == 0 {
+			firstLine = comment.UnsignedLine()
+		}
+		changes[comment.TreePath] = append(changes[comment.TreePath], &suggestedChange{
+			comment:    comment,
+			index:      int(firstLine) - 1,
+			Suggestion: suggestions[0],
+		})
+	}
+	if len(treePaths) == 0 {
+		return nil
+	}
+
+	for _, treePath := range treePaths {
+		// Check file is not lfs locked, will return nil if lock setting not enabled
+		lfsLock, err := repo.GetTreePathLock(treePath)
+		if err != nil {
+			return err
+		}
+		if lfsLock != nil && lfsLock.OwnerID != doer.ID {
+			return models.ErrLFSFileLocked{RepoID: repo.ID, Path: treePath, UserName: lfsLock.Owner.Name}
+		}
+	}
+
+	t, err := NewTemporaryUploadRepository(repo)
+	if err != nil {
+		return err
+	}
+	defer t.Close()
+	if err := t.Clone(opts.Branch); err != nil {
+		return err
+	}
+	if err := t.SetDefaultIndex(); err != nil {
+		return err
+	}
+
+	commit, err := t.GetBranchCommit(opts.Branch)
+	if err != nil {
+		return err
+	}
+
+	for _, treePath := range treePaths {
+		fileChanges := changes[treePath]
+		outdated := models.ErrSuggestionOutdated{CommentID: fileChanges[0].comment.ID, Path: treePath, Line: fileChanges[0].comment.Line}
+
+		entry, err := commit.GetTreeEntryByPath(treePath)
+		if git.IsErrNotExist(err) {
+			return outdated
+		} else if err != nil {
+			return err
+		}
+		if !entry.IsRegular() && !entry.IsExecutable() {
+			return outdated
+		}
+
+		reader, err := entry.Blob().DataAsync()
+		if err != nil {
+			return err
+		}
+		content, err := ioutil.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return err
+		}
+		lines := strings.Split(string(content), "\n")
+
+		// Replace the lines from the bottom up so the indexes of the remaining changes hold
+		sort.Slice(fileChanges, func(i, j int) bool {
+			return fileChanges[i].index > fileChanges[j].index
+		})
+		for i, change := range fileChanges {
+			end := change.index + len(change.OldLines)
+			if i > 0 && end > fileChanges[i-1].index {
+				return models.ErrSuggestionsOverlap{Path: treePath, Line: change.comment.Line}
+			}
+			if change.index < 0 || end > len(lines) {
+				return models.ErrSuggestionOutdated{CommentID: change.comment.ID, Path: treePath, Line: change.comment.Line}
+			}
+
+			lineEnding := ""
+			for j, oldLine := range change.OldLines {
+				line := lines[change.index+j]
+				if strings.HasSuffix(line, "\r") {
+					line = line[:len(line)-1]
+					lineEnding = "\r"
+				}
+				if line != oldLine {
+					return models.ErrSuggestionOutdated{CommentID: change.comment.ID, Path: treePath, Line: change.comment.Line}
+				}
+			}
+
+			newLines := make([]string, 0, len(change.NewLines)+len(lines)-end)
+			for _, line := range change.NewLines {
+				newLines = append(newLines, line+lineEnding)
+			}
+			lines = append(lines[:change.index], append(newLines, lines[end:]...)...)
+		}
+
+		objectHash, err := t.HashObject(strings.NewReader(strings.Join(lines, "\n")))
+		if err != nil {
+			return err
+		}
+		mode := "100644"
+		if entry.IsExecutable() {
+			mode = "100755"
+		}
+		if err := t.AddObjectToIndex(mode, objectHash, treePath); err != nil {
+			return err
+		}
+	}
+
+	// Now write the tree
+	treeHash, err := t.WriteTree()
+	if err != nil {
+		return err
+	}
+
+	// Now commit the tree
+	commitHash, err := t.CommitTree(doer, doer, treeHash, opts.Message)
+	if err != nil {
+		return err
+	}
+
+	// Then push this tree to the branch
+	return t.Push(doer, commitHash, opts.Branch)
+}
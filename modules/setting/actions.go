@@ -0,0 +1,34 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package setting
+
+import (
+	"path/filepath"
+	"time"
+)
+
+var (
+	// Actions settings
+	Actions = struct {
+		Enabled          bool
+		LogStorage       Storage
+		LongPollTimeout  time.Duration
+		ZombieJobTimeout time.Duration
+	}{
+		Enabled:          false,
+		LongPollTimeout:  30 * time.Second,
+		ZombieJobTimeout: 10 * time.Minute,
+	}
+)
+
+func newActions() {
+	sec := Cfg.Section("actions")
+	Actions.Enabled = sec.Key("ENABLED").MustBool(Actions.Enabled)
+	Actions.LongPollTimeout = sec.Key("LONG_POLL_TIMEOUT").MustDuration(Actions.LongPollTimeout)
+	Actions.ZombieJobTimeout = sec.Key("ZOMBIE_JOB_TIMEOUT").MustDuration(Actions.ZombieJobTimeout)
+
+	logPath := sec.Key("LOG_PATH").MustString(filepath.Join(AppDataPath, "actions_log"))
+	Actions.LogStorage = getStorage("actions_log", logPath, sec)
+}
@@ -0,0 +1,84 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package setting
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/log"
+)
+
+// IncomingEmailTokenPlaceholder is replaced by the reply token in IncomingEmail.ReplyToAddress
+const IncomingEmailTokenPlaceholder = "%{token}"
+
+// IncomingEmail represents the settings of the incoming mail handling
+var IncomingEmail = struct {
+	Enabled              bool
+	ReplyToAddress       string
+	Type                 string
+	Host                 string
+	Port                 int
+	UseTLS               bool `ini:"USE_TLS"`
+	SkipTLSVerify        bool `ini:"SKIP_TLS_VERIFY"`
+	Username             string
+	Password             string
+	Mailbox              string
+	MaildirPath          string
+	DeleteHandledMessage bool
+	PollInterval         time.Duration
+}{
+	Type:                 "imap",
+	Port:                 993,
+	UseTLS:               true,
+	Mailbox:              "INBOX",
+	DeleteHandledMessage: true,
+	PollInterval:         time.Minute,
+}
+
+func newIncomingEmailService() {
+	sec := Cfg.Section("email.incoming")
+	if !sec.Key("ENABLED").MustBool(false) {
+		return
+	}
+	if MailService == nil {
+		log.Warn("Incoming Email Service: Mail Service is not enabled")
+		return
+	}
+
+	if err := sec.MapTo(&IncomingEmail); err != nil {
+		log.Fatal("Unable to map [email.incoming] section on to IncomingEmail. Error: %v", err)
+	}
+	IncomingEmail.Type = sec.Key("TYPE").In("imap", []string{"imap", "maildir"})
+
+	if err := checkReplyToAddress(IncomingEmail.ReplyToAddress); err != nil {
+		log.Fatal("Invalid [email.incoming] REPLY_TO_ADDRESS (%s): %v", IncomingEmail.ReplyToAddress, err)
+	}
+	if IncomingEmail.Type == "maildir" && IncomingEmail.MaildirPath == "" {
+		log.Fatal("[email.incoming] MAILDIR_PATH must be set for TYPE = maildir")
+	}
+
+	log.Info("Incoming Email Service Enabled")
+}
+
+func checkReplyToAddress(address string) error {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return err
+	}
+	if parsed.Name != "" {
+		return errors.New("the address must not contain a display name")
+	}
+	if strings.Count(address, IncomingEmailTokenPlaceholder) != 1 {
+		return fmt.Errorf("the address must contain %s exactly once", IncomingEmailTokenPlaceholder)
+	}
+	if at := strings.LastIndex(address, "@"); strings.Contains(address[at:], IncomingEmailTokenPlaceholder) {
+		return fmt.Errorf("%s must be in the local part of the address", IncomingEmailTokenPlaceholder)
+	}
+	return nil
+}
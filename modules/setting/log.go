@@ -279,6 +279,18 @@ func newAccessLogService() {
 	}
 }
 
+func newAuditLogService() {
+	EnableAuditLog = Cfg.Section("log").Key("ENABLE_AUDIT_LOG").MustBool(false)
+	Cfg.Section("log").Key("AUDIT").MustString("file")
+	if EnableAuditLog {
+		options := newDefaultLogOptions()
+		options.filename = filepath.Join(LogRootPath, "audit.log")
+		options.flags = "" // Audit events are self-contained JSON lines
+		options.bufferLength = Cfg.Section("log").Key("BUFFER_LEN").MustInt64(10000)
+		generateNamedLogger("audit", options)
+	}
+}
+
 func newRouterLogService() {
 	Cfg.Section("log").Key("ROUTER").MustString("console")
 	// Allow [log]  DISABLE_ROUTER_LOG to override [server] DISABLE_ROUTER_LOG
@@ -363,6 +375,7 @@ func NewLogServices(disableConsole bool) {
 	newMacaronLogService()
 	newRouterLogService()
 	newAccessLogService()
+	newAuditLogService()
 	NewXORMLogService(disableConsole)
 }
 
@@ -0,0 +1,22 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package setting
+
+var (
+	// Packages settings
+	Packages = struct {
+		Enabled     bool
+		MaxFileSize int64
+	}{
+		Enabled:     true,
+		MaxFileSize: 100 << 20,
+	}
+)
+
+func newPackagesService() {
+	sec := Cfg.Section("packages")
+	Packages.Enabled = sec.Key("ENABLED").MustBool(Packages.Enabled)
+	Packages.MaxFileSize = sec.Key("MAX_FILE_SIZE").MustInt64(Packages.MaxFileSize)
+}
@@ -160,6 +160,17 @@ func NewQueueService() {
 	if _, ok := sectionMap["LENGTH"]; !ok {
 		_, _ = section.NewKey("LENGTH", fmt.Sprintf("%d", Repository.PullRequestQueueLength))
 	}
+
+	// Handle the old webhook configuration
+	// Please note this will be a unique queue
+	section = Cfg.Section("queue.webhook_sender")
+	sectionMap = map[string]bool{}
+	for _, key := range section.Keys() {
+		sectionMap[key.Name()] = true
+	}
+	if _, ok := sectionMap["LENGTH"]; !ok {
+		_, _ = section.NewKey("LENGTH", fmt.Sprintf("%d", Cfg.Section("webhook").Key("QUEUE_LENGTH").MustInt(1000)))
+	}
 }
 
 // ParseQueueConnStr parses a queue connection string
@@ -27,7 +27,6 @@ import (
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/user"
 
-	shellquote "github.com/kballard/go-shellquote"
 	version "github.com/mcuadros/go-version"
 	"github.com/unknwon/com"
 	ini "gopkg.in/ini.v1"
@@ -142,6 +141,7 @@ var (
 		HTTPAuthExpiry  time.Duration `ini:"LFS_HTTP_AUTH_EXPIRY"`
 		MaxFileSize     int64         `ini:"LFS_MAX_FILE_SIZE"`
 		LocksPagingNum  int           `ini:"LFS_LOCKS_PAGING_NUM"`
+		Storage         Storage       `ini:"-"`
 	}
 
 	// Security settings
@@ -273,6 +273,7 @@ var (
 
 	// Picture settings
 	AvatarUploadPath              string
+	AvatarStorage                 Storage
 	AvatarMaxWidth                int
 	AvatarMaxHeight               int
 	GravatarSource                string
@@ -282,6 +283,7 @@ var (
 	LibravatarService             *libravatar.Libravatar
 	AvatarMaxFileSize             int64
 	RepositoryAvatarUploadPath    string
+	RepositoryAvatarStorage       Storage
 	RepositoryAvatarFallback      string
 	RepositoryAvatarFallbackImage string
 
@@ -295,10 +297,12 @@ var (
 	RouterLogMode      string
 	EnableAccessLog    bool
 	AccessLogTemplate  string
+	EnableAuditLog     bool
 	EnableXORMLog      bool
 
 	// Attachment settings
 	AttachmentPath         string
+	AttachmentStorage      Storage
 	AttachmentAllowedTypes string
 	AttachmentMaxSize      int64
 	AttachmentMaxFiles     int
@@ -351,9 +355,12 @@ var (
 		MaxTokenLength:             math.MaxInt16,
 	}
 
-	U2F = struct {
-		AppID         string
-		TrustedFacets []string
+	// WebAuthn settings
+	WebAuthn = struct {
+		RPID        string
+		RPName      string
+		Origin      string
+		LegacyAppID string
 	}{}
 
 	// Metrics settings
@@ -734,6 +741,7 @@ func NewContext() {
 	if !filepath.IsAbs(LFS.ContentPath) {
 		LFS.ContentPath = filepath.Join(AppWorkPath, LFS.ContentPath)
 	}
+	LFS.Storage = getStorage("lfs", LFS.ContentPath, Cfg.Section("lfs"))
 	if LFS.LocksPagingNum == 0 {
 		LFS.LocksPagingNum = 50
 	}
@@ -841,6 +849,7 @@ func NewContext() {
 	if !filepath.IsAbs(AttachmentPath) {
 		AttachmentPath = path.Join(AppWorkPath, AttachmentPath)
 	}
+	AttachmentStorage = getStorage("attachments", AttachmentPath, sec)
 	AttachmentAllowedTypes = strings.Replace(sec.Key("ALLOWED_TYPES").MustString("image/jpeg,image/png,application/zip,application/gzip"), "|", ",", -1)
 	AttachmentMaxSize = sec.Key("MAX_SIZE").MustInt64(4)
 	AttachmentMaxFiles = sec.Key("MAX_FILES").MustInt(5)
@@ -914,6 +923,8 @@ func NewContext() {
 	if !filepath.IsAbs(RepositoryAvatarUploadPath) {
 		RepositoryAvatarUploadPath = path.Join(AppWorkPath, RepositoryAvatarUploadPath)
 	}
+	AvatarStorage = getStorage("avatars", AvatarUploadPath, Cfg.Section("avatar"))
+	RepositoryAvatarStorage = getStorage("repo-avatars", RepositoryAvatarUploadPath, Cfg.Section("repo-avatar"))
 	RepositoryAvatarFallback = sec.Key("REPOSITORY_AVATAR_FALLBACK").MustString("none")
 	RepositoryAvatarFallbackImage = sec.Key("REPOSITORY_AVATAR_FALLBACK_IMAGE").MustString("/img/repo_default.png")
 	AvatarMaxWidth = sec.Key("AVATAR_MAX_WIDTH").MustInt(4096)
@@ -1014,10 +1025,15 @@ func NewContext() {
 	HasRobotsTxt = com.IsFile(path.Join(CustomPath, "robots.txt"))
 
 	newMarkup()
+	newActions()
 
-	sec = Cfg.Section("U2F")
-	U2F.TrustedFacets, _ = shellquote.Split(sec.Key("TRUSTED_FACETS").MustString(strings.TrimRight(AppURL, "/")))
-	U2F.AppID = sec.Key("APP_ID").MustString(strings.TrimRight(AppURL, "/"))
+	sec = Cfg.Section("webauthn")
+	WebAuthn.RPID = sec.Key("RP_ID").MustString(appURL.Hostname())
+	WebAuthn.RPName = sec.Key("RP_NAME").MustString(AppName)
+	WebAuthn.Origin = sec.Key("ORIGIN").MustString(appURL.Scheme + "://" + appURL.Host)
+	// Security keys registered with the U2F API are scoped to the U2F App ID
+	WebAuthn.LegacyAppID = sec.Key("LEGACY_APP_ID").MustString(
+		Cfg.Section("U2F").Key("APP_ID").MustString(strings.TrimRight(AppURL, "/")))
 
 	UI.ReactionsMap = make(map[string]bool)
 	for _, reaction := range UI.Reactions {
@@ -1096,7 +1112,7 @@ func loadOrGenerateInternalToken(sec *ini.Section) string {
 }
 
 func ensureLFSDirectory() {
-	if LFS.StartServer {
+	if LFS.StartServer && LFS.Storage.Type == LocalStorageType {
 		if err := os.MkdirAll(LFS.ContentPath, 0700); err != nil {
 			log.Fatal("Failed to create '%s': %v", LFS.ContentPath, err)
 		}
@@ -1117,6 +1133,8 @@ func NewServices() {
 	newNotifyMailService()
 	newWebhookService()
 	newMigrationsService()
+	newPackagesService()
+	newIncomingEmailService()
 	newIndexerService()
 	newTaskService()
 	NewQueueService()
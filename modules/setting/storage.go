@@ -0,0 +1,81 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package setting
+
+import (
+	"path/filepath"
+	"strings"
+
+	"code.gitea.io/gitea/modules/log"
+
+	ini "gopkg.in/ini.v1"
+)
+
+// enumerates all the storage types
+const (
+	LocalStorageType = "local"
+	MinioStorageType = "minio"
+)
+
+// MinioStorageConfig represents the configuration of a minio (S3 compatible) storage
+type MinioStorageConfig struct {
+	Endpoint        string
+	AccessKeyID     string
+	SecretAccessKey string
+	Bucket          string
+	Location        string
+	BasePath        string
+	UseSSL          bool
+}
+
+// Storage represents the configuration of a storage
+type Storage struct {
+	Type        string
+	Path        string
+	ServeDirect bool
+	Minio       MinioStorageConfig
+}
+
+// getStorage reads the storage configuration of the given section. Keys which are
+// not set on the section fall back to the shared [storage] section so that a
+// single minio configuration can be reused by attachments, LFS and avatars.
+func getStorage(name, defaultPath string, sec *ini.Section) Storage {
+	defaultSec := Cfg.Section("storage")
+	key := func(k string) *ini.Key {
+		if sec.HasKey(k) {
+			return sec.Key(k)
+		}
+		return defaultSec.Key(k)
+	}
+
+	storage := Storage{
+		Type:        strings.ToLower(key("STORAGE_TYPE").MustString(LocalStorageType)),
+		Path:        defaultPath,
+		ServeDirect: key("SERVE_DIRECT").MustBool(false),
+	}
+
+	switch storage.Type {
+	case LocalStorageType:
+		if !filepath.IsAbs(storage.Path) {
+			storage.Path = filepath.Join(AppWorkPath, storage.Path)
+		}
+	case MinioStorageType:
+		storage.Minio = MinioStorageConfig{
+			Endpoint:        key("MINIO_ENDPOINT").MustString("localhost:9000"),
+			AccessKeyID:     key("MINIO_ACCESS_KEY_ID").MustString(""),
+			SecretAccessKey: key("MINIO_SECRET_ACCESS_KEY").MustString(""),
+			Bucket:          key("MINIO_BUCKET").MustString("gitea"),
+			Location:        key("MINIO_LOCATION").MustString("us-east-1"),
+			UseSSL:          key("MINIO_USE_SSL").MustBool(false),
+		}
+		// The base path is not shared, otherwise all the storages would
+		// end up in the same directory of the bucket.
+		storage.Minio.BasePath = sec.Key("MINIO_BASE_PATH").MustString(name + "/")
+	default:
+		log.Fatal("Unsupported storage type %q for %s", storage.Type, name)
+	}
+
+	return storage
+}
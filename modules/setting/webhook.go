@@ -6,6 +6,7 @@ package setting
 
 import (
 	"net/url"
+	"time"
 
 	"code.gitea.io/gitea/modules/log"
 )
@@ -21,6 +22,10 @@ var (
 		ProxyURL       string
 		ProxyURLFixed  *url.URL
 		ProxyHosts     []string
+		MaxAttempts    int
+		RetryBackoff   int
+
+		SecretRotationPeriod time.Duration
 	}{
 		QueueLength:    1000,
 		DeliverTimeout: 5,
@@ -28,6 +33,10 @@ var (
 		PagingNum:      10,
 		ProxyURL:       "",
 		ProxyHosts:     []string{},
+		MaxAttempts:    5,
+		RetryBackoff:   30,
+
+		SecretRotationPeriod: 24 * time.Hour,
 	}
 )
 
@@ -36,7 +45,7 @@ func newWebhookService() {
 	Webhook.QueueLength = sec.Key("QUEUE_LENGTH").MustInt(1000)
 	Webhook.DeliverTimeout = sec.Key("DELIVER_TIMEOUT").MustInt(5)
 	Webhook.SkipTLSVerify = sec.Key("SKIP_TLS_VERIFY").MustBool()
-	Webhook.Types = []string{"gitea", "gogs", "slack", "discord", "dingtalk", "telegram", "msteams", "feishu", "matrix"}
+	Webhook.Types = []string{"gitea", "gogs", "slack", "discord", "dingtalk", "telegram", "msteams", "feishu", "matrix", "custom"}
 	Webhook.PagingNum = sec.Key("PAGING_NUM").MustInt(10)
 	Webhook.ProxyURL = sec.Key("PROXY_URL").MustString("")
 	if Webhook.ProxyURL != "" {
@@ -48,4 +57,10 @@ func newWebhookService() {
 		}
 	}
 	Webhook.ProxyHosts = sec.Key("PROXY_HOSTS").Strings(",")
+	Webhook.MaxAttempts = sec.Key("MAX_ATTEMPTS").MustInt(Webhook.MaxAttempts)
+	if Webhook.MaxAttempts < 1 {
+		Webhook.MaxAttempts = 1
+	}
+	Webhook.RetryBackoff = sec.Key("RETRY_BACKOFF").MustInt(Webhook.RetryBackoff)
+	Webhook.SecretRotationPeriod = sec.Key("SECRET_ROTATION_PERIOD").MustDuration(Webhook.SecretRotationPeriod)
 }
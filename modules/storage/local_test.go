@@ -0,0 +1,59 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "local-storage")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	l, err := NewLocalStorage(dir)
+	assert.NoError(t, err)
+
+	n, err := l.Save("a/b/c", strings.NewReader("hello world"), -1)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 11, n)
+
+	fi, err := l.Stat("a/b/c")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 11, fi.Size())
+
+	obj, err := l.Open("a/b/c")
+	assert.NoError(t, err)
+	content, err := ioutil.ReadAll(obj)
+	assert.NoError(t, err)
+	assert.NoError(t, obj.Close())
+	assert.EqualValues(t, "hello world", string(content))
+
+	_, err = l.URL("a/b/c", "c")
+	assert.Equal(t, ErrURLNotSupported, err)
+
+	var paths []string
+	assert.NoError(t, l.IterateObjects(func(p string, obj Object) error {
+		paths = append(paths, p)
+		return nil
+	}))
+	assert.EqualValues(t, []string{"a/b/c"}, paths)
+
+	dst, err := NewLocalStorage(dir + "-copy")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir + "-copy")
+	n, err = Copy(dst, "c", l, "a/b/c")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 11, n)
+
+	assert.NoError(t, l.Delete("a/b/c"))
+	_, err = l.Stat("a/b/c")
+	assert.True(t, IsNotExist(err))
+}
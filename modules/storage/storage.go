@@ -0,0 +1,128 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+var (
+	// ErrURLNotSupported represents url is not supported
+	ErrURLNotSupported = errors.New("url method not supported")
+)
+
+// Object represents the object on the storage
+type Object interface {
+	io.ReadCloser
+	io.Seeker
+	Stat() (os.FileInfo, error)
+}
+
+// ObjectStorage represents an object storage to handle a bucket and files
+type ObjectStorage interface {
+	Open(path string) (Object, error)
+	// Save stores the content of r, size is the length of the content or -1 when it is unknown
+	Save(path string, r io.Reader, size int64) (int64, error)
+	Stat(path string) (os.FileInfo, error)
+	Delete(path string) error
+	URL(path, name string) (*url.URL, error)
+	IterateObjects(func(path string, obj Object) error) error
+}
+
+// Copy copys a file from source ObjectStorage to dest ObjectStorage
+func Copy(dstStorage ObjectStorage, dstPath string, srcStorage ObjectStorage, srcPath string) (int64, error) {
+	f, err := srcStorage.Open(srcPath)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	size := int64(-1)
+	if fi, err := f.Stat(); err == nil {
+		size = fi.Size()
+	}
+	return dstStorage.Save(dstPath, f, size)
+}
+
+// SaveFrom saves data to the ObjectStorage with path p from the callback
+func SaveFrom(objStorage ObjectStorage, p string, callback func(w io.Writer) error) error {
+	pr, pw := io.Pipe()
+	defer pr.Close()
+	go func() {
+		defer pw.Close()
+		if err := callback(pw); err != nil {
+			_ = pw.CloseWithError(err)
+		}
+	}()
+
+	_, err := objStorage.Save(p, pr, -1)
+	return err
+}
+
+// IsNotExist returns true if the error means the object does not exist
+func IsNotExist(err error) bool {
+	return os.IsNotExist(err)
+}
+
+var (
+	// Attachments represents attachments storage
+	Attachments ObjectStorage
+
+	// LFS represents lfs storage
+	LFS ObjectStorage
+
+	// Avatars represents user avatars storage
+	Avatars ObjectStorage
+
+	// RepoAvatars represents repository avatars storage
+	RepoAvatars ObjectStorage
+
+	// ActionsLog represents the storage of the job logs of actions
+	ActionsLog ObjectStorage
+)
+
+// Init init the stoarge
+func Init() error {
+	var err error
+	if Attachments, err = NewStorage(setting.AttachmentStorage); err != nil {
+		return fmt.Errorf("attachments storage: %v", err)
+	}
+	if LFS, err = NewStorage(setting.LFS.Storage); err != nil {
+		return fmt.Errorf("lfs storage: %v", err)
+	}
+	if Avatars, err = NewStorage(setting.AvatarStorage); err != nil {
+		return fmt.Errorf("avatars storage: %v", err)
+	}
+	if RepoAvatars, err = NewStorage(setting.RepositoryAvatarStorage); err != nil {
+		return fmt.Errorf("repo-avatars storage: %v", err)
+	}
+	if setting.Actions.Enabled {
+		if ActionsLog, err = NewStorage(setting.Actions.LogStorage); err != nil {
+			return fmt.Errorf("actions_log storage: %v", err)
+		}
+	}
+	return nil
+}
+
+// NewStorage takes a storage configuration and returns an ObjectStorage
+func NewStorage(cfg setting.Storage) (ObjectStorage, error) {
+	switch cfg.Type {
+	case setting.LocalStorageType:
+		log.Info("Initialising local storage at %s", cfg.Path)
+		return NewLocalStorage(cfg.Path)
+	case setting.MinioStorageType:
+		log.Info("Initialising minio storage at %s/%s/%s", cfg.Minio.Endpoint, cfg.Minio.Bucket, cfg.Minio.BasePath)
+		return NewMinioStorage(cfg.Minio)
+	default:
+		return nil, fmt.Errorf("unsupported storage type: %s", cfg.Type)
+	}
+}
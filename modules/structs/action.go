@@ -0,0 +1,29 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+import "encoding/json"
+
+// ActionTask represents a job of an action run handed to a runner
+type ActionTask struct {
+	// ID of the job, used to report its logs and status
+	ID         int64  `json:"id"`
+	RunIndex   int64  `json:"run_index"`
+	Repository string `json:"repository"`
+	CloneURL   string `json:"clone_url"`
+	Event      string `json:"event"`
+	Ref        string `json:"ref"`
+	SHA        string `json:"sha"`
+	Workflow   string `json:"workflow"`
+	JobID      string `json:"job_id"`
+	// Job holds the name, runs_on, env, timeout_minutes and steps of the job
+	Job json.RawMessage `json:"job"`
+}
+
+// ActionStatusOption options to report the status of a job or a step to the server
+type ActionStatusOption struct {
+	// enum: running,success,failure,cancelled,skipped
+	Status string `json:"status" binding:"Required"`
+}
@@ -0,0 +1,29 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+import (
+	"time"
+)
+
+// AuditEvent represents an entry of the audit log
+type AuditEvent struct {
+	ID     int64  `json:"id"`
+	Action string `json:"action"`
+	// ID of the user who performed the action, 0 for the system
+	ActorID    int64  `json:"actor_id"`
+	ActorName  string `json:"actor_name"`
+	IPAddress  string `json:"ip_address"`
+	RepoID     int64  `json:"repo_id"`
+	RepoName   string `json:"repo_name"`
+	OrgID      int64  `json:"org_id"`
+	OrgName    string `json:"org_name"`
+	TargetType string `json:"target_type"`
+	TargetID   int64  `json:"target_id"`
+	TargetName string `json:"target_name"`
+	Details    string `json:"details"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+}
@@ -36,12 +36,16 @@ type HookList []*Hook
 
 // CreateHookOptionConfig has all config options in it
 // required are "content_type" and "url" Required
+// the payloads are signed if "secret" is set, "signature_algorithm" is one of sha1, sha256
+// (the default) or sha512 and "sign_timestamp" set to "true" signs the delivery time as well
+// custom hooks require "template" instead of "content_type", their "content_type" is the
+// MIME type of the body, "headers" are "Name: value" lines and "http_method" is POST, PUT or PATCH
 type CreateHookOptionConfig map[string]string
 
 // CreateHookOption options when create a hook
 type CreateHookOption struct {
 	// required: true
-	// enum: dingtalk,discord,gitea,gogs,msteams,slack,telegram,feishu
+	// enum: dingtalk,discord,gitea,gogs,msteams,slack,telegram,feishu,custom
 	Type string `json:"type" binding:"Required"`
 	// required: true
 	Config       CreateHookOptionConfig `json:"config" binding:"Required"`
@@ -59,6 +63,22 @@ type EditHookOption struct {
 	Active       *bool             `json:"active"`
 }
 
+// HookDelivery represents a delivery of a hook
+type HookDelivery struct {
+	ID          int64  `json:"id"`
+	UUID        string `json:"uuid"`
+	Event       string `json:"event"`
+	URL         string `json:"url"`
+	IsDelivered bool   `json:"is_delivered"`
+	IsSucceed   bool   `json:"is_succeed"`
+	StatusCode  int    `json:"status_code"`
+	Attempts    int    `json:"attempts"`
+	// swagger:strfmt date-time
+	Delivered *time.Time `json:"delivered_at"`
+	// swagger:strfmt date-time
+	NextAttempt *time.Time `json:"next_attempt_at"`
+}
+
 // Payloader payload is some part of one hook
 type Payloader interface {
 	SetSecret(string)
@@ -112,6 +132,8 @@ var (
 	_ Payloader = &PullRequestPayload{}
 	_ Payloader = &RepositoryPayload{}
 	_ Payloader = &ReleasePayload{}
+	_ Payloader = &UserPayload{}
+	_ Payloader = &OrganizationPayload{}
 )
 
 // _________                        __
@@ -481,3 +503,67 @@ func (p *RepositoryPayload) SetSecret(secret string) {
 func (p *RepositoryPayload) JSONPayload() ([]byte, error) {
 	return json.MarshalIndent(p, "", " ")
 }
+
+// HookUserAction an action that happens to a user
+type HookUserAction string
+
+const (
+	// HookUserCreated created
+	HookUserCreated HookUserAction = "created"
+	// HookUserDeleted deleted
+	HookUserDeleted HookUserAction = "deleted"
+)
+
+// UserPayload payload for user webhooks, they are only sent to system webhooks
+type UserPayload struct {
+	Secret string         `json:"secret"`
+	Action HookUserAction `json:"action"`
+	User   *User          `json:"user"`
+	Sender *User          `json:"sender"`
+}
+
+// SetSecret modifies the secret of the UserPayload
+func (p *UserPayload) SetSecret(secret string) {
+	p.Secret = secret
+}
+
+// JSONPayload JSON representation of the payload
+func (p *UserPayload) JSONPayload() ([]byte, error) {
+	return json.MarshalIndent(p, "", " ")
+}
+
+// HookOrganizationAction an action that happens to an organization
+type HookOrganizationAction string
+
+const (
+	// HookOrganizationCreated created
+	HookOrganizationCreated HookOrganizationAction = "created"
+	// HookOrganizationDeleted deleted
+	HookOrganizationDeleted HookOrganizationAction = "deleted"
+	// HookOrganizationMemberAdded member added to a team
+	HookOrganizationMemberAdded HookOrganizationAction = "member_added"
+	// HookOrganizationMemberRemoved member removed from a team or the organization
+	HookOrganizationMemberRemoved HookOrganizationAction = "member_removed"
+)
+
+// OrganizationPayload payload for organization webhooks
+type OrganizationPayload struct {
+	Secret       string                 `json:"secret"`
+	Action       HookOrganizationAction `json:"action"`
+	Organization *Organization          `json:"organization"`
+	// the team the member was added to or removed from, it is empty if the
+	// member left the whole organization
+	Team   *Team `json:"team,omitempty"`
+	Member *User `json:"member,omitempty"`
+	Sender *User `json:"sender"`
+}
+
+// SetSecret modifies the secret of the OrganizationPayload
+func (p *OrganizationPayload) SetSecret(secret string) {
+	p.Secret = secret
+}
+
+// JSONPayload JSON representation of the payload
+func (p *OrganizationPayload) JSONPayload() ([]byte, error) {
+	return json.MarshalIndent(p, "", " ")
+}
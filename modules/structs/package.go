@@ -0,0 +1,45 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+import (
+	"time"
+)
+
+// Package represents a package of a user or an organization
+type Package struct {
+	ID    int64 `json:"id"`
+	Owner *User `json:"owner"`
+	// enum: generic,npm,maven
+	Type string `json:"type"`
+	Name string `json:"name"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+	// swagger:strfmt date-time
+	Updated time.Time `json:"updated_at"`
+}
+
+// PackageVersion represents a published version of a package
+type PackageVersion struct {
+	ID            int64    `json:"id"`
+	Package       *Package `json:"package"`
+	Creator       *User    `json:"creator"`
+	Version       string   `json:"version"`
+	DownloadCount int64    `json:"download_count"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+}
+
+// PackageFile represents a file of a package version
+type PackageFile struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Size        int64  `json:"size"`
+	HashMD5     string `json:"md5"`
+	HashSHA1    string `json:"sha1"`
+	HashSHA256  string `json:"sha256"`
+	HashSHA512  string `json:"sha512"`
+	DownloadURL string `json:"download_url"`
+}
@@ -0,0 +1,104 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+import (
+	"time"
+)
+
+// Project represents a project of a repository or an organization
+type Project struct {
+	ID          int64  `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// enum: repository,organization
+	Type         string          `json:"type"`
+	Repo         *RepositoryMeta `json:"repository,omitempty"`
+	Owner        *User           `json:"owner,omitempty"`
+	Creator      *User           `json:"creator"`
+	State        StateType       `json:"state"`
+	OpenIssues   int             `json:"open_issues"`
+	ClosedIssues int             `json:"closed_issues"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+	// swagger:strfmt date-time
+	Updated time.Time `json:"updated_at"`
+	// swagger:strfmt date-time
+	Closed *time.Time `json:"closed_at"`
+}
+
+// CreateProjectOption options for creating a project
+type CreateProjectOption struct {
+	// required:true
+	Title       string `json:"title" binding:"Required;MaxSize(100)"`
+	Description string `json:"description"`
+	// the columns the project is created with
+	// enum: none,basic_kanban
+	BoardType string `json:"board_type"`
+}
+
+// EditProjectOption options for editing a project
+type EditProjectOption struct {
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	// enum: open,closed
+	State *string `json:"state"`
+}
+
+// ProjectBoard represents a column of a project
+type ProjectBoard struct {
+	ID        int64  `json:"id"`
+	ProjectID int64  `json:"project_id"`
+	Title     string `json:"title"`
+	Position  int8   `json:"position"`
+	// new and reopened issues are put on the default board
+	IsDefault bool `json:"is_default"`
+	// closed issues are moved to the done board
+	IsDone bool `json:"is_done"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+	// swagger:strfmt date-time
+	Updated time.Time `json:"updated_at"`
+}
+
+// CreateProjectBoardOption options for creating a project board
+type CreateProjectBoardOption struct {
+	// required:true
+	Title     string `json:"title" binding:"Required;MaxSize(100)"`
+	Position  int8   `json:"position"`
+	IsDefault bool   `json:"is_default"`
+	IsDone    bool   `json:"is_done"`
+}
+
+// EditProjectBoardOption options for editing a project board
+type EditProjectBoardOption struct {
+	Title     *string `json:"title"`
+	Position  *int8   `json:"position"`
+	IsDefault *bool   `json:"is_default"`
+	IsDone    *bool   `json:"is_done"`
+}
+
+// ProjectCard represents an issue or a pull request on a project board
+type ProjectCard struct {
+	// ID of the board, 0 if the card is uncategorized
+	BoardID  int64  `json:"board_id"`
+	Position int64  `json:"position"`
+	Issue    *Issue `json:"issue"`
+}
+
+// AddProjectCardOption options for adding an issue or a pull request to a project
+type AddProjectCardOption struct {
+	// ID of the issue or the pull request, not its index
+	// required:true
+	IssueID int64 `json:"issue_id" binding:"Required"`
+}
+
+// MoveProjectCardOption options for moving a card to a board of its project
+type MoveProjectCardOption struct {
+	// ID of the issue or the pull request, not its index
+	// required:true
+	IssueID  int64 `json:"issue_id" binding:"Required"`
+	Position int64 `json:"position"`
+}
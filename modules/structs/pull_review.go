@@ -55,12 +55,15 @@ type PullReviewComment struct {
 	// swagger:strfmt date-time
 	Updated time.Time `json:"updated_at"`
 
-	Path         string `json:"path"`
-	CommitID     string `json:"commit_id"`
-	OrigCommitID string `json:"original_commit_id"`
-	DiffHunk     string `json:"diff_hunk"`
-	LineNum      uint64 `json:"position"`
-	OldLineNum   uint64 `json:"original_position"`
+	Path            string `json:"path"`
+	CommitID        string `json:"commit_id"`
+	OrigCommitID    string `json:"original_commit_id"`
+	DiffHunk        string `json:"diff_hunk"`
+	LineNum         uint64 `json:"position"`
+	OldLineNum      uint64 `json:"original_position"`
+	StartLineNum    uint64 `json:"start_position"`
+	OldStartLineNum uint64 `json:"original_start_position"`
+	IsFileComment   bool   `json:"is_file_comment"`
 
 	HTMLURL     string `json:"html_url"`
 	HTMLPullURL string `json:"pull_request_url"`
@@ -83,6 +86,12 @@ type CreatePullReviewComment struct {
 	OldLineNum int64 `json:"old_position"`
 	// if comment to new file line or 0
 	NewLineNum int64 `json:"new_position"`
+	// first old file line of a comment on several lines or 0
+	OldStartLineNum int64 `json:"old_start_position"`
+	// first new file line of a comment on several lines or 0
+	NewStartLineNum int64 `json:"new_start_position"`
+	// comment on the whole file rather than on lines
+	IsFileComment bool `json:"is_file_comment"`
 }
 
 // SubmitPullReviewOptions are options to submit a pending pull review
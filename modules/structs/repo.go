@@ -87,6 +87,10 @@ type Repository struct {
 	AllowRebase               bool             `json:"allow_rebase"`
 	AllowRebaseMerge          bool             `json:"allow_rebase_explicit"`
 	AllowSquash               bool             `json:"allow_squash_merge"`
+	AllowFastForwardOnly      bool             `json:"allow_fast_forward_only"`
+	AllowSemiLinear           bool             `json:"allow_semi_linear"`
+	HasProjects               bool             `json:"has_projects"`
+	HasActions                bool             `json:"has_actions"`
 	AvatarURL                 string           `json:"avatar_url"`
 	Internal                  bool             `json:"internal"`
 }
@@ -157,6 +161,14 @@ type EditRepoOption struct {
 	AllowRebaseMerge *bool `json:"allow_rebase_explicit,omitempty"`
 	// either `true` to allow squash-merging pull requests, or `false` to prevent squash-merging. `has_pull_requests` must be `true`.
 	AllowSquash *bool `json:"allow_squash_merge,omitempty"`
+	// either `true` to allow fast-forward-only merging (--ff-only), or `false` to prevent it. `has_pull_requests` must be `true`.
+	AllowFastForwardOnly *bool `json:"allow_fast_forward_only,omitempty"`
+	// either `true` to allow semi-linear merging (rebase, then always create a merge commit), or `false` to prevent it. `has_pull_requests` must be `true`.
+	AllowSemiLinear *bool `json:"allow_semi_linear,omitempty"`
+	// either `true` to enable project boards, or `false` to disable them.
+	HasProjects *bool `json:"has_projects,omitempty"`
+	// either `true` to enable actions, or `false` to disable them.
+	HasActions *bool `json:"has_actions,omitempty"`
 	// set to `true` to archive this repository.
 	Archived *bool `json:"archived,omitempty"`
 }
@@ -220,7 +232,9 @@ var (
 	// TODO: add to this list after new git service added
 	SupportedFullGitService = []GitServiceType{
 		GithubService,
+		GiteaService,
 		GitlabService,
+		GogsService,
 	}
 )
 
@@ -40,6 +40,7 @@ type BranchProtection struct {
 	ApprovalsWhitelistTeams     []string `json:"approvals_whitelist_teams"`
 	BlockOnRejectedReviews      bool     `json:"block_on_rejected_reviews"`
 	BlockOnOutdatedBranch       bool     `json:"block_on_outdated_branch"`
+	RequireCodeOwnerApproval    bool     `json:"require_code_owner_approval"`
 	DismissStaleApprovals       bool     `json:"dismiss_stale_approvals"`
 	RequireSignedCommits        bool     `json:"require_signed_commits"`
 	ProtectedFilePatterns       string   `json:"protected_file_patterns"`
@@ -68,6 +69,7 @@ type CreateBranchProtectionOption struct {
 	ApprovalsWhitelistTeams     []string `json:"approvals_whitelist_teams"`
 	BlockOnRejectedReviews      bool     `json:"block_on_rejected_reviews"`
 	BlockOnOutdatedBranch       bool     `json:"block_on_outdated_branch"`
+	RequireCodeOwnerApproval    bool     `json:"require_code_owner_approval"`
 	DismissStaleApprovals       bool     `json:"dismiss_stale_approvals"`
 	RequireSignedCommits        bool     `json:"require_signed_commits"`
 	ProtectedFilePatterns       string   `json:"protected_file_patterns"`
@@ -91,6 +93,7 @@ type EditBranchProtectionOption struct {
 	ApprovalsWhitelistTeams     []string `json:"approvals_whitelist_teams"`
 	BlockOnRejectedReviews      *bool    `json:"block_on_rejected_reviews"`
 	BlockOnOutdatedBranch       *bool    `json:"block_on_outdated_branch"`
+	RequireCodeOwnerApproval    *bool    `json:"require_code_owner_approval"`
 	DismissStaleApprovals       *bool    `json:"dismiss_stale_approvals"`
 	RequireSignedCommits        *bool    `json:"require_signed_commits"`
 	ProtectedFilePatterns       *string  `json:"protected_file_patterns"`
@@ -0,0 +1,30 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+import "time"
+
+// PushMirror represents a remote repository the repository is pushed to
+type PushMirror struct {
+	RepoName      string `json:"repo_name"`
+	RemoteName    string `json:"remote_name"`
+	RemoteAddress string `json:"remote_address"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created"`
+	// swagger:strfmt date-time
+	LastUpdate *time.Time `json:"last_update"`
+	LastError  string     `json:"last_error"`
+	Interval   string     `json:"interval"`
+}
+
+// CreatePushMirrorOption represents need information to create a push mirror of a repository.
+type CreatePushMirrorOption struct {
+	// required: true
+	RemoteAddress  string `json:"remote_address" binding:"Required"`
+	RemoteUsername string `json:"remote_username"`
+	RemotePassword string `json:"remote_password"`
+	// Sync interval, 0 to only push on changes
+	Interval string `json:"interval"`
+}
@@ -0,0 +1,253 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// customPayloadSizeLimit limits the size of a rendered custom payload
+const customPayloadSizeLimit = 1024 * 1024
+
+// DefaultCustomTemplate is the template new custom webhooks start with
+const DefaultCustomTemplate = `{
+  "event": {{json .Event}},
+  "payload": {{json .Payload}}
+}`
+
+// CustomMeta contains the template and the request settings of a custom webhook
+type CustomMeta struct {
+	Template    string            `json:"template"`
+	ContentType string            `json:"content_type"`
+	Headers     map[string]string `json:"headers"`
+}
+
+// HeadersText returns the headers as "Name: value" lines sorted by name
+func (m *CustomMeta) HeadersText() string {
+	names := make([]string, 0, len(m.Headers))
+	for name := range m.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var lines []string
+	for _, name := range names {
+		lines = append(lines, name+": "+m.Headers[name])
+	}
+	return strings.Join(lines, "\n")
+}
+
+// GetCustomHook returns custom metadata
+func GetCustomHook(w *models.Webhook) *CustomMeta {
+	s := &CustomMeta{}
+	if err := json.Unmarshal([]byte(w.Meta), s); err != nil {
+		log.Error("webhook.GetCustomHook(%d): %v", w.ID, err)
+	}
+	return s
+}
+
+// ParseCustomHeaders parses the "Name: value" lines of custom webhook headers
+func ParseCustomHeaders(text string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		idx := strings.IndexByte(line, ':')
+		if idx <= 0 {
+			return nil, fmt.Errorf("invalid header line %q", line)
+		}
+		name := strings.TrimSpace(line[:idx])
+		if strings.ContainsAny(name, " \t\"(),/:;<=>?@[\\]{}") {
+			return nil, fmt.Errorf("invalid header name %q", name)
+		}
+		headers[textproto.CanonicalMIMEHeaderKey(name)] = strings.TrimSpace(line[idx+1:])
+	}
+	return headers, nil
+}
+
+// CustomPayload contains the rendered body of a custom webhook
+type CustomPayload struct {
+	Body string
+}
+
+// SetSecret sets the custom secret
+func (p *CustomPayload) SetSecret(_ string) {}
+
+// JSONPayload returns the rendered body, it is only JSON if the template renders JSON
+func (p *CustomPayload) JSONPayload() ([]byte, error) {
+	return []byte(p.Body), nil
+}
+
+// customTemplateData is what the template of a custom webhook is executed with
+type customTemplateData struct {
+	Event   string
+	Payload api.Payloader
+}
+
+var customTemplateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// limitedBuffer is a buffer which fails if more than limit bytes are written
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > b.limit {
+		return 0, fmt.Errorf("the rendered payload exceeds %d bytes", b.limit)
+	}
+	return b.Buffer.Write(p)
+}
+
+// renderCustomTemplate executes the template with the event and its payload
+func renderCustomTemplate(text string, event models.HookEventType, p api.Payloader) (string, error) {
+	tmpl, err := template.New("custom").Funcs(customTemplateFuncs).Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	buf := &limitedBuffer{limit: customPayloadSizeLimit}
+	if err := tmpl.Execute(buf, &customTemplateData{
+		Event:   event.Event(),
+		Payload: p,
+	}); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// GetCustomPayload renders the template of a custom webhook with the payload
+func GetCustomPayload(p api.Payloader, event models.HookEventType, meta string) (*CustomPayload, error) {
+	custom := &CustomMeta{}
+	if err := json.Unmarshal([]byte(meta), custom); err != nil {
+		return nil, errors.New("GetCustomPayload meta json:" + err.Error())
+	}
+
+	// the payload is shared by all webhooks of the event, it must not expose the
+	// secret of another webhook to the template
+	p.SetSecret("")
+	body, err := renderCustomTemplate(custom.Template, event, p)
+	if err != nil {
+		return nil, fmt.Errorf("render template: %v", err)
+	}
+	return &CustomPayload{Body: body}, nil
+}
+
+// isJSONContentType returns true if the content type is application/json or a +json type
+func isJSONContentType(contentType string) bool {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// PreviewCustomPayload renders the template of the custom webhook with a sample
+// push event. It fails if the template is invalid or, for JSON content types, if
+// the result is no valid JSON.
+func PreviewCustomPayload(meta *CustomMeta) (string, error) {
+	body, err := renderCustomTemplate(meta.Template, models.HookEventPush, samplePushPayload())
+	if err != nil {
+		return "", err
+	}
+	if isJSONContentType(meta.ContentType) && !json.Valid([]byte(body)) {
+		return body, errors.New("the rendered payload is not valid JSON")
+	}
+	return body, nil
+}
+
+// samplePushPayload returns a push event with fake data to validate templates with
+func samplePushPayload() *api.PushPayload {
+	now := time.Now()
+	user := &api.User{
+		ID:        1,
+		UserName:  "gitea",
+		FullName:  "Gitea",
+		Email:     "gitea@example.com",
+		AvatarURL: setting.AppURL + "img/favicon.png",
+	}
+	repo := &api.Repository{
+		ID:            1,
+		Owner:         user,
+		Name:          "example",
+		FullName:      "gitea/example",
+		HTMLURL:       setting.AppURL + "gitea/example",
+		CloneURL:      setting.AppURL + "gitea/example.git",
+		DefaultBranch: "master",
+		Created:       now,
+		Updated:       now,
+	}
+	commit := &api.PayloadCommit{
+		ID:      git.EmptySHA,
+		Message: "This is a fake commit",
+		URL:     repo.HTMLURL + "/commit/" + git.EmptySHA,
+		Author: &api.PayloadUser{
+			Name:     user.FullName,
+			Email:    user.Email,
+			UserName: user.UserName,
+		},
+		Committer: &api.PayloadUser{
+			Name:     user.FullName,
+			Email:    user.Email,
+			UserName: user.UserName,
+		},
+		Timestamp: now,
+	}
+	return &api.PushPayload{
+		Ref:        git.BranchPrefix + repo.DefaultBranch,
+		Before:     git.EmptySHA,
+		After:      git.EmptySHA,
+		CompareURL: repo.HTMLURL + "/compare/" + git.EmptySHA + "..." + git.EmptySHA,
+		Commits:    []*api.PayloadCommit{commit},
+		HeadCommit: commit,
+		Repo:       repo,
+		Pusher:     user,
+		Sender:     user,
+	}
+}
+
+// getCustomHookRequest builds the request of a custom hook task with the method,
+// content type and headers of its webhook
+func getCustomHookRequest(t *models.HookTask, w *models.Webhook) (*http.Request, error) {
+	custom := GetCustomHook(w)
+
+	method := t.HTTPMethod
+	if method == "" {
+		method = http.MethodPost
+	}
+	req, err := http.NewRequest(method, t.URL, strings.NewReader(t.PayloadContent))
+	if err != nil {
+		return nil, err
+	}
+
+	contentType := custom.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	req.Header.Set("Content-Type", contentType)
+	for name, value := range custom.Headers {
+		req.Header.Set(name, value)
+	}
+	return req, nil
+}
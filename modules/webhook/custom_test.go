@@ -0,0 +1,129 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCustomHeaders(t *testing.T) {
+	headers, err := ParseCustomHeaders("authorization: Bearer token\n\n X-Custom :  value \n")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"Authorization": "Bearer token",
+		"X-Custom":      "value",
+	}, headers)
+	assert.Equal(t, "Authorization: Bearer token\nX-Custom: value", (&CustomMeta{Headers: headers}).HeadersText())
+
+	_, err = ParseCustomHeaders("no separator")
+	assert.Error(t, err)
+	_, err = ParseCustomHeaders("in valid: value")
+	assert.Error(t, err)
+}
+
+func TestGetCustomPayload(t *testing.T) {
+	meta, err := json.Marshal(&CustomMeta{
+		Template: `{"event":{{json .Event}},"text":{{json .Payload.Repo.FullName}},"secret":{{json .Payload.Secret}}}`,
+	})
+	assert.NoError(t, err)
+
+	p := pushTestPayload()
+	p.SetSecret("secret of another webhook")
+	pl, err := GetCustomPayload(p, models.HookEventPush, string(meta))
+	assert.NoError(t, err)
+	data, err := pl.JSONPayload()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"event":"push","text":"test/repo","secret":""}`, string(data))
+
+	// fields of other payloads fail to render
+	_, err = GetCustomPayload(issueTestPayload(), models.HookEventIssues, string(meta))
+	assert.Error(t, err)
+}
+
+func TestPreviewCustomPayload(t *testing.T) {
+	body, err := PreviewCustomPayload(&CustomMeta{Template: DefaultCustomTemplate, ContentType: "application/json"})
+	assert.NoError(t, err)
+	var result struct {
+		Event   string          `json:"event"`
+		Payload api.PushPayload `json:"payload"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(body), &result))
+	assert.Equal(t, "push", result.Event)
+	assert.Equal(t, "gitea/example", result.Payload.Repo.FullName)
+
+	_, err = PreviewCustomPayload(&CustomMeta{Template: `{{.Payload.Unknown}}`, ContentType: "text/plain"})
+	assert.Error(t, err)
+	_, err = PreviewCustomPayload(&CustomMeta{Template: `{{if}}`, ContentType: "text/plain"})
+	assert.Error(t, err)
+
+	// only JSON content types have to render JSON
+	_, err = PreviewCustomPayload(&CustomMeta{Template: `pushed to {{.Payload.Repo.FullName}}`, ContentType: "application/json; charset=utf-8"})
+	assert.Error(t, err)
+	body, err = PreviewCustomPayload(&CustomMeta{Template: `pushed to {{.Payload.Repo.FullName}}`, ContentType: "text/plain"})
+	assert.NoError(t, err)
+	assert.Equal(t, "pushed to gitea/example", body)
+
+	_, err = PreviewCustomPayload(&CustomMeta{Template: `{{range .Payload.Commits}}{{printf "%2000000d" 1}}{{end}}`, ContentType: "text/plain"})
+	assert.Error(t, err)
+}
+
+func TestDeliverCustom(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+
+	defer func(client *http.Client) {
+		webhookHTTPClient = client
+	}(webhookHTTPClient)
+	webhookHTTPClient = http.DefaultClient
+
+	var method, contentType, auth, body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		contentType = r.Header.Get("Content-Type")
+		auth = r.Header.Get("Authorization")
+		data, _ := ioutil.ReadAll(r.Body)
+		body = string(data)
+	}))
+	defer srv.Close()
+
+	meta, err := json.Marshal(&CustomMeta{
+		Template:    `pushed to {{.Payload.Repo.FullName}}`,
+		ContentType: "text/plain",
+		Headers:     map[string]string{"Authorization": "Bearer token"},
+	})
+	assert.NoError(t, err)
+	w := &models.Webhook{
+		RepoID:       1,
+		URL:          srv.URL,
+		HTTPMethod:   http.MethodPut,
+		ContentType:  models.ContentTypeJSON,
+		HookTaskType: models.CUSTOM,
+		Meta:         string(meta),
+		IsActive:     true,
+		HookEvent:    &models.HookEvent{PushOnly: true},
+	}
+	assert.NoError(t, w.UpdateEvent())
+	assert.NoError(t, models.CreateWebhook(w))
+
+	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+	assert.NoError(t, PrepareWebhook(w, repo, models.HookEventPush, pushTestPayload()))
+	task := models.AssertExistsAndLoadBean(t, &models.HookTask{HookID: w.ID}).(*models.HookTask)
+	assert.Equal(t, "pushed to test/repo", task.PayloadContent)
+
+	assert.NoError(t, Deliver(task))
+	assert.True(t, task.IsSucceed)
+	assert.Equal(t, http.MethodPut, method)
+	assert.Equal(t, "text/plain", contentType)
+	assert.Equal(t, "Bearer token", auth)
+	assert.Equal(t, "pushed to test/repo", body)
+}
@@ -19,13 +19,14 @@ import (
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/graceful"
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/queue"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
 	"github.com/gobwas/glob"
-	"github.com/unknwon/com"
 )
 
 // Deliver deliver hook task
-func Deliver(t *models.HookTask) error {
+func Deliver(t *models.HookTask) (err error) {
 	defer func() {
 		err := recover()
 		if err == nil {
@@ -35,9 +36,68 @@ func Deliver(t *models.HookTask) error {
 		log.Error("PANIC whilst trying to deliver webhook[%d] for repo[%d] to %s Panic: %v\nStacktrace: %s", t.ID, t.RepoID, t.URL, err, log.Stack(2))
 	}()
 	t.IsDelivered = true
+	t.RequestInfo = &models.HookRequest{
+		Headers: map[string]string{},
+	}
+	t.ResponseInfo = &models.HookResponse{
+		Headers: map[string]string{},
+	}
+
+	// the attempt is recorded however the delivery fails, the task would otherwise
+	// neither be delivered nor retried
+	var w *models.Webhook
+	defer func() {
+		if err != nil && t.ResponseInfo.Body == "" {
+			t.ResponseInfo.Body = fmt.Sprintf("Delivery: %v", err)
+		}
+		t.Delivered = time.Now().UnixNano()
+		t.Attempts++
+		if t.IsSucceed {
+			log.Trace("Hook delivered: %s", t.UUID)
+		} else if t.Attempts < setting.Webhook.MaxAttempts {
+			// the task is delivered again by the queue after the backoff
+			t.IsDelivered = false
+			t.NextAttemptUnix = timeutil.TimeStampNow().AddDuration(retryBackoff(t.Attempts))
+			log.Trace("Hook delivery failed: %s, attempt %d will be at %v", t.UUID, t.Attempts+1, t.NextAttemptUnix.AsTime())
+		} else {
+			log.Trace("Hook delivery failed: %s", t.UUID)
+		}
+		if t.IsDelivered {
+			t.NextAttemptUnix = 0
+		}
+
+		if err := models.UpdateHookTask(t); err != nil {
+			log.Error("UpdateHookTask [%d]: %v", t.ID, err)
+		}
+
+		if w == nil {
+			return
+		}
+		// Update webhook last delivery status.
+		if t.IsSucceed {
+			w.LastStatus = models.HookStatusSucceed
+		} else {
+			w.LastStatus = models.HookStatusFail
+		}
+		if err := models.UpdateWebhookLastStatus(w); err != nil {
+			log.Error("UpdateWebhookLastStatus: %v", err)
+			return
+		}
+	}()
+
+	w, err = models.GetWebhookByID(t.HookID)
+	if err != nil {
+		return fmt.Errorf("GetWebhookByID: %v", err)
+	}
+
+	now := time.Now()
+	if t.Type == models.FEISHU && len(w.Secret) > 0 {
+		if t.PayloadContent, err = signFeishuPayload(t.PayloadContent, w.Secret, now); err != nil {
+			return fmt.Errorf("signFeishuPayload: %v", err)
+		}
+	}
 
 	var req *http.Request
-	var err error
 
 	switch t.HTTPMethod {
 	case "":
@@ -77,70 +137,47 @@ func Deliver(t *models.HookTask) error {
 		if err != nil {
 			return err
 		}
+	case http.MethodPut, http.MethodPatch:
+		// only custom webhooks are sent with these methods, their request is built below
+		if t.Type != models.CUSTOM {
+			return fmt.Errorf("Invalid http method for webhook: [%d] %v", t.ID, t.HTTPMethod)
+		}
 	default:
 		return fmt.Errorf("Invalid http method for webhook: [%d] %v", t.ID, t.HTTPMethod)
 	}
 
-	if t.Type == models.MATRIX {
+	switch t.Type {
+	case models.MATRIX:
 		req, err = getMatrixHookRequest(t)
 		if err != nil {
 			return err
 		}
+	case models.CUSTOM:
+		req, err = getCustomHookRequest(t, w)
+		if err != nil {
+			return err
+		}
+	}
+
+	if t.Type == models.DINGTALK && len(w.Secret) > 0 {
+		signDingtalkRequest(req, w.Secret, now)
 	}
 
 	req.Header.Add("X-Gitea-Delivery", t.UUID)
 	req.Header.Add("X-Gitea-Event", t.EventType.Event())
-	req.Header.Add("X-Gitea-Signature", t.Signature)
 	req.Header.Add("X-Gogs-Delivery", t.UUID)
 	req.Header.Add("X-Gogs-Event", t.EventType.Event())
-	req.Header.Add("X-Gogs-Signature", t.Signature)
+	t.Signature = addSignatureHeaders(req, w, t.PayloadContent, now)
 	req.Header["X-GitHub-Delivery"] = []string{t.UUID}
 	req.Header["X-GitHub-Event"] = []string{t.EventType.Event()}
 
 	// Record delivery information.
-	t.RequestInfo = &models.HookRequest{
-		Headers: map[string]string{},
-	}
 	for k, vals := range req.Header {
 		t.RequestInfo.Headers[k] = strings.Join(vals, ",")
 	}
 
-	t.ResponseInfo = &models.HookResponse{
-		Headers: map[string]string{},
-	}
-
-	defer func() {
-		t.Delivered = time.Now().UnixNano()
-		if t.IsSucceed {
-			log.Trace("Hook delivered: %s", t.UUID)
-		} else {
-			log.Trace("Hook delivery failed: %s", t.UUID)
-		}
-
-		if err := models.UpdateHookTask(t); err != nil {
-			log.Error("UpdateHookTask [%d]: %v", t.ID, err)
-		}
-
-		// Update webhook last delivery status.
-		w, err := models.GetWebhookByID(t.HookID)
-		if err != nil {
-			log.Error("GetWebhookByID: %v", err)
-			return
-		}
-		if t.IsSucceed {
-			w.LastStatus = models.HookStatusSucceed
-		} else {
-			w.LastStatus = models.HookStatusFail
-		}
-		if err = models.UpdateWebhookLastStatus(w); err != nil {
-			log.Error("UpdateWebhookLastStatus: %v", err)
-			return
-		}
-	}()
-
 	resp, err := webhookHTTPClient.Do(req)
 	if err != nil {
-		t.ResponseInfo.Body = fmt.Sprintf("Delivery: %v", err)
 		return err
 	}
 	defer resp.Body.Close()
@@ -161,70 +198,90 @@ func Deliver(t *models.HookTask) error {
 	return nil
 }
 
-// DeliverHooks checks and delivers undelivered hooks.
-// FIXME: graceful: This would likely benefit from either a worker pool with dummy queue
-// or a full queue. Then more hooks could be sent at same time.
-func DeliverHooks(ctx context.Context) {
-	select {
-	case <-ctx.Done():
+// retryBackoff returns the delay before the next attempt after the given
+// number of failed attempts, it doubles for every attempt.
+func retryBackoff(attempts int) time.Duration {
+	backoff := time.Duration(setting.Webhook.RetryBackoff) * time.Second
+	for i := 1; i < attempts && backoff < maxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxRetryBackoff {
+		backoff = maxRetryBackoff
+	}
+	return backoff
+}
+
+// handle delivers the queued hook tasks, failed tasks are queued again after their backoff
+func handle(data ...queue.Data) {
+	for _, datum := range data {
+		id := datum.(int64)
+		t, err := models.GetHookTaskByID(id)
+		if err != nil {
+			if !models.IsErrHookTaskNotExist(err) {
+				log.Error("GetHookTaskByID[%d]: %v", id, err)
+			}
+			continue
+		}
+		if t.IsDelivered {
+			continue
+		}
+		if t.NextAttemptUnix > timeutil.TimeStampNow() {
+			scheduleHookTask(t)
+			continue
+		}
+
+		if err := Deliver(t); err != nil {
+			log.Error("deliver: %v", err)
+		}
+		if !t.IsDelivered {
+			scheduleHookTask(t)
+		}
+	}
+}
+
+// enqueueHookTask adds the hook task to the delivery queue. Before the queue is
+// initialized the task stays in the database until DeliverHooks picks it up.
+func enqueueHookTask(id int64) {
+	if hookQueue == nil {
 		return
-	default:
 	}
+	if err := hookQueue.Push(id); err != nil && err != queue.ErrAlreadyInQueue {
+		log.Error("Unable to push hook task[%d] to the webhook_sender queue: %v", id, err)
+	}
+}
+
+// scheduleHookTask adds the hook task to the delivery queue at its next attempt time
+func scheduleHookTask(t *models.HookTask) {
+	id := t.ID
+	time.AfterFunc(time.Until(t.NextAttemptUnix.AsTime()), func() {
+		enqueueHookTask(id)
+	})
+}
+
+// DeliverHooks queues the undelivered hooks which were left over from the last run.
+func DeliverHooks(ctx context.Context) {
 	tasks, err := models.FindUndeliveredHookTasks()
 	if err != nil {
 		log.Error("DeliverHooks: %v", err)
 		return
 	}
 
-	// Update hook task status.
 	for _, t := range tasks {
 		select {
 		case <-ctx.Done():
 			return
 		default:
 		}
-		if err = Deliver(t); err != nil {
-			log.Error("deliver: %v", err)
-		}
-	}
-
-	// Start listening on new hook requests.
-	for {
-		select {
-		case <-ctx.Done():
-			hookQueue.Close()
-			return
-		case repoIDStr := <-hookQueue.Queue():
-			log.Trace("DeliverHooks [repo_id: %v]", repoIDStr)
-			hookQueue.Remove(repoIDStr)
-
-			repoID, err := com.StrTo(repoIDStr).Int64()
-			if err != nil {
-				log.Error("Invalid repo ID: %s", repoIDStr)
-				continue
-			}
-
-			tasks, err := models.FindRepoUndeliveredHookTasks(repoID)
-			if err != nil {
-				log.Error("Get repository [%d] hook tasks: %v", repoID, err)
-				continue
-			}
-			for _, t := range tasks {
-				select {
-				case <-ctx.Done():
-					return
-				default:
-				}
-				if err = Deliver(t); err != nil {
-					log.Error("deliver: %v", err)
-				}
-			}
-		}
+		enqueueHookTask(t.ID)
 	}
-
 }
 
+// maxRetryBackoff limits the delay between two attempts of a hook task
+const maxRetryBackoff = 24 * time.Hour
+
 var (
+	// hookQueue is a global queue of hook task IDs
+	hookQueue         queue.UniqueQueue
 	webhookHTTPClient *http.Client
 	once              sync.Once
 	hostMatchers      []glob.Glob
@@ -274,5 +331,10 @@ func InitDeliverHooks() {
 		},
 	}
 
+	hookQueue = queue.CreateUniqueQueue("webhook_sender", handle, int64(0))
+	if hookQueue == nil {
+		log.Fatal("Unable to create webhook_sender Queue")
+	}
+	go graceful.GetManager().RunWithShutdownFns(hookQueue.Run)
 	go graceful.GetManager().RunWithShutdownContext(DeliverHooks)
 }
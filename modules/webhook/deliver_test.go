@@ -6,10 +6,14 @@ package webhook
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
+	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -37,3 +41,103 @@ func TestWebhookProxy(t *testing.T) {
 		}
 	}
 }
+
+func TestRetryBackoff(t *testing.T) {
+	defer func(backoff int) {
+		setting.Webhook.RetryBackoff = backoff
+	}(setting.Webhook.RetryBackoff)
+	setting.Webhook.RetryBackoff = 30
+
+	assert.Equal(t, 30*time.Second, retryBackoff(1))
+	assert.Equal(t, 60*time.Second, retryBackoff(2))
+	assert.Equal(t, 4*time.Minute, retryBackoff(4))
+	assert.Equal(t, maxRetryBackoff, retryBackoff(100))
+}
+
+func TestDeliverRetry(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+
+	defer func(maxAttempts int, client *http.Client) {
+		setting.Webhook.MaxAttempts = maxAttempts
+		webhookHTTPClient = client
+	}(setting.Webhook.MaxAttempts, webhookHTTPClient)
+	setting.Webhook.MaxAttempts = 3
+	webhookHTTPClient = http.DefaultClient
+
+	var status = http.StatusInternalServerError
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	task := &models.HookTask{
+		RepoID:         1,
+		HookID:         1,
+		Type:           models.GITEA,
+		URL:            srv.URL,
+		PayloadContent: "{}",
+		HTTPMethod:     http.MethodPost,
+		ContentType:    models.ContentTypeJSON,
+		EventType:      models.HookEventPush,
+	}
+	assert.NoError(t, models.CreateHookTask(task))
+
+	// a failed delivery is attempted again later
+	assert.NoError(t, Deliver(task))
+	task = models.AssertExistsAndLoadBean(t, &models.HookTask{ID: task.ID}).(*models.HookTask)
+	assert.False(t, task.IsSucceed)
+	assert.False(t, task.IsDelivered)
+	assert.Equal(t, 1, task.Attempts)
+	assert.True(t, task.NextAttemptUnix > timeutil.TimeStampNow())
+	assert.EqualValues(t, http.StatusInternalServerError, task.ResponseInfo.Status)
+
+	status = http.StatusOK
+	assert.NoError(t, Deliver(task))
+	task = models.AssertExistsAndLoadBean(t, &models.HookTask{ID: task.ID}).(*models.HookTask)
+	assert.True(t, task.IsSucceed)
+	assert.True(t, task.IsDelivered)
+	assert.Equal(t, 2, task.Attempts)
+	assert.EqualValues(t, 0, task.NextAttemptUnix)
+
+	// the last attempt gives up
+	status = http.StatusInternalServerError
+	task.IsDelivered = false
+	assert.NoError(t, Deliver(task))
+	task = models.AssertExistsAndLoadBean(t, &models.HookTask{ID: task.ID}).(*models.HookTask)
+	assert.False(t, task.IsSucceed)
+	assert.True(t, task.IsDelivered)
+	assert.Equal(t, 3, task.Attempts)
+	assert.EqualValues(t, 0, task.NextAttemptUnix)
+}
+
+func TestDeliverRecordsFailuresBeforeRequest(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+
+	task := &models.HookTask{
+		RepoID:         1,
+		HookID:         1,
+		Type:           models.GITEA,
+		URL:            "http://localhost",
+		PayloadContent: "{}",
+		HTTPMethod:     http.MethodDelete,
+		ContentType:    models.ContentTypeJSON,
+		EventType:      models.HookEventPush,
+	}
+	assert.NoError(t, models.CreateHookTask(task))
+
+	// the request can not be built, the attempt is still recorded
+	assert.Error(t, Deliver(task))
+	task = models.AssertExistsAndLoadBean(t, &models.HookTask{ID: task.ID}).(*models.HookTask)
+	assert.False(t, task.IsSucceed)
+	assert.False(t, task.IsDelivered)
+	assert.Equal(t, 1, task.Attempts)
+	assert.True(t, task.NextAttemptUnix > timeutil.TimeStampNow())
+	assert.Contains(t, task.ResponseInfo.Body, "Invalid http method")
+
+	// the webhook was deleted
+	task.HookID = 1000
+	assert.Error(t, Deliver(task))
+	task = models.AssertExistsAndLoadBean(t, &models.HookTask{ID: task.ID}).(*models.HookTask)
+	assert.Equal(t, 2, task.Attempts)
+	assert.Contains(t, task.ResponseInfo.Body, "GetWebhookByID")
+}
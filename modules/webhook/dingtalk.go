@@ -250,6 +250,29 @@ func getDingtalkReleasePayload(p *api.ReleasePayload) (*DingtalkPayload, error)
 	}, nil
 }
 
+func getDingtalkUserPayload(p *api.UserPayload) (*DingtalkPayload, error) {
+	text, _ := getUserPayloadInfo(p, noneLinkFormatter, true)
+
+	return getDingtalkTextPayload(text), nil
+}
+
+func getDingtalkOrganizationPayload(p *api.OrganizationPayload) (*DingtalkPayload, error) {
+	text, _ := getOrganizationPayloadInfo(p, noneLinkFormatter, true)
+
+	return getDingtalkTextPayload(text), nil
+}
+
+func getDingtalkTextPayload(text string) *DingtalkPayload {
+	return &DingtalkPayload{
+		MsgType: "text",
+		Text: struct {
+			Content string `json:"content"`
+		}{
+			Content: text,
+		},
+	}
+}
+
 // GetDingtalkPayload converts a ding talk webhook into a DingtalkPayload
 func GetDingtalkPayload(p api.Payloader, event models.HookEventType, meta string) (*DingtalkPayload, error) {
 	s := new(DingtalkPayload)
@@ -280,6 +303,10 @@ func GetDingtalkPayload(p api.Payloader, event models.HookEventType, meta string
 		return getDingtalkRepositoryPayload(p.(*api.RepositoryPayload))
 	case models.HookEventRelease:
 		return getDingtalkReleasePayload(p.(*api.ReleasePayload))
+	case models.HookEventUser:
+		return getDingtalkUserPayload(p.(*api.UserPayload))
+	case models.HookEventOrganization:
+		return getDingtalkOrganizationPayload(p.(*api.OrganizationPayload))
 	}
 
 	return s, nil
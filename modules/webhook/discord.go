@@ -389,6 +389,36 @@ func getDiscordReleasePayload(p *api.ReleasePayload, meta *DiscordMeta) (*Discor
 	}, nil
 }
 
+func getDiscordUserPayload(p *api.UserPayload, meta *DiscordMeta) (*DiscordPayload, error) {
+	text, color := getUserPayloadInfo(p, noneLinkFormatter, false)
+
+	return getDiscordAccountPayload(text, color, p.Sender, meta), nil
+}
+
+func getDiscordOrganizationPayload(p *api.OrganizationPayload, meta *DiscordMeta) (*DiscordPayload, error) {
+	text, color := getOrganizationPayloadInfo(p, noneLinkFormatter, false)
+
+	return getDiscordAccountPayload(text, color, p.Sender, meta), nil
+}
+
+func getDiscordAccountPayload(title string, color int, sender *api.User, meta *DiscordMeta) *DiscordPayload {
+	return &DiscordPayload{
+		Username:  meta.Username,
+		AvatarURL: meta.IconURL,
+		Embeds: []DiscordEmbed{
+			{
+				Title: title,
+				Color: color,
+				Author: DiscordEmbedAuthor{
+					Name:    sender.UserName,
+					URL:     setting.AppURL + sender.UserName,
+					IconURL: sender.AvatarURL,
+				},
+			},
+		},
+	}
+}
+
 // GetDiscordPayload converts a discord webhook into a DiscordPayload
 func GetDiscordPayload(p api.Payloader, event models.HookEventType, meta string) (*DiscordPayload, error) {
 	s := new(DiscordPayload)
@@ -424,6 +454,10 @@ func GetDiscordPayload(p api.Payloader, event models.HookEventType, meta string)
 		return getDiscordRepositoryPayload(p.(*api.RepositoryPayload), discord)
 	case models.HookEventRelease:
 		return getDiscordReleasePayload(p.(*api.ReleasePayload), discord)
+	case models.HookEventUser:
+		return getDiscordUserPayload(p.(*api.UserPayload), discord)
+	case models.HookEventOrganization:
+		return getDiscordOrganizationPayload(p.(*api.OrganizationPayload), discord)
 	}
 
 	return s, nil
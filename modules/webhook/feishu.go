@@ -170,6 +170,24 @@ func getFeishuReleasePayload(p *api.ReleasePayload) (*FeishuPayload, error) {
 	}, nil
 }
 
+func getFeishuUserPayload(p *api.UserPayload) (*FeishuPayload, error) {
+	text, _ := getUserPayloadInfo(p, noneLinkFormatter, true)
+
+	return &FeishuPayload{
+		Text:  text,
+		Title: text,
+	}, nil
+}
+
+func getFeishuOrganizationPayload(p *api.OrganizationPayload) (*FeishuPayload, error) {
+	text, _ := getOrganizationPayloadInfo(p, noneLinkFormatter, true)
+
+	return &FeishuPayload{
+		Text:  text,
+		Title: text,
+	}, nil
+}
+
 // GetFeishuPayload converts a ding talk webhook into a FeishuPayload
 func GetFeishuPayload(p api.Payloader, event models.HookEventType, meta string) (*FeishuPayload, error) {
 	s := new(FeishuPayload)
@@ -199,6 +217,10 @@ func GetFeishuPayload(p api.Payloader, event models.HookEventType, meta string)
 		return getFeishuRepositoryPayload(p.(*api.RepositoryPayload))
 	case models.HookEventRelease:
 		return getFeishuReleasePayload(p.(*api.ReleasePayload))
+	case models.HookEventUser:
+		return getFeishuUserPayload(p.(*api.UserPayload))
+	case models.HookEventOrganization:
+		return getFeishuOrganizationPayload(p.(*api.OrganizationPayload))
 	}
 
 	return s, nil
@@ -191,3 +191,54 @@ func getIssueCommentPayloadInfo(p *api.IssueCommentPayload, linkFormatter linkFo
 
 	return text, issueTitle, color
 }
+
+func getUserPayloadInfo(p *api.UserPayload, linkFormatter linkFormatter, withSender bool) (text string, color int) {
+	switch p.Action {
+	case api.HookUserCreated:
+		text = fmt.Sprintf("User created: %s", linkFormatter(setting.AppURL+p.User.UserName, p.User.UserName))
+		color = greenColor
+	case api.HookUserDeleted:
+		text = fmt.Sprintf("User deleted: %s", p.User.UserName)
+		color = redColor
+	}
+	if withSender {
+		text += fmt.Sprintf(" by %s", linkFormatter(setting.AppURL+p.Sender.UserName, p.Sender.UserName))
+	}
+
+	return text, color
+}
+
+func getOrganizationPayloadInfo(p *api.OrganizationPayload, linkFormatter linkFormatter, withSender bool) (text string, color int) {
+	orgLink := linkFormatter(setting.AppURL+p.Organization.UserName, p.Organization.UserName)
+	var memberLink, teamLink string
+	if p.Member != nil {
+		memberLink = linkFormatter(setting.AppURL+p.Member.UserName, p.Member.UserName)
+	}
+	if p.Team != nil {
+		teamLink = linkFormatter(fmt.Sprintf("%sorg/%s/teams/%s", setting.AppURL, p.Organization.UserName, strings.ToLower(p.Team.Name)), p.Team.Name)
+	}
+
+	switch p.Action {
+	case api.HookOrganizationCreated:
+		text = fmt.Sprintf("[%s] Organization created", orgLink)
+		color = greenColor
+	case api.HookOrganizationDeleted:
+		text = fmt.Sprintf("[%s] Organization deleted", p.Organization.UserName)
+		color = redColor
+	case api.HookOrganizationMemberAdded:
+		text = fmt.Sprintf("[%s] Member %s added to team %s", orgLink, memberLink, teamLink)
+		color = greenColor
+	case api.HookOrganizationMemberRemoved:
+		if p.Team != nil {
+			text = fmt.Sprintf("[%s] Member %s removed from team %s", orgLink, memberLink, teamLink)
+		} else {
+			text = fmt.Sprintf("[%s] Member %s removed", orgLink, memberLink)
+		}
+		color = yellowColor
+	}
+	if withSender {
+		text += fmt.Sprintf(" by %s", linkFormatter(setting.AppURL+p.Sender.UserName, p.Sender.UserName))
+	}
+
+	return text, color
+}
@@ -8,6 +8,37 @@ import (
 	api "code.gitea.io/gitea/modules/structs"
 )
 
+func pushTestPayload() *api.PushPayload {
+	commit := &api.PayloadCommit{
+		ID:      "2020558fe2e34debb818a514715839cabd25e778",
+		Message: "commit message",
+		URL:     "http://localhost:3000/test/repo/commit/2020558fe2e34debb818a514715839cabd25e778",
+		Author: &api.PayloadUser{
+			Name:     "user1",
+			Email:    "user1@localhost",
+			UserName: "user1",
+		},
+	}
+	return &api.PushPayload{
+		Ref:        "refs/heads/test",
+		Before:     "2020558fe2e34debb818a514715839cabd25e777",
+		After:      "2020558fe2e34debb818a514715839cabd25e778",
+		Commits:    []*api.PayloadCommit{commit},
+		HeadCommit: commit,
+		Repo: &api.Repository{
+			HTMLURL:  "http://localhost:3000/test/repo",
+			Name:     "repo",
+			FullName: "test/repo",
+		},
+		Pusher: &api.User{
+			UserName: "user1",
+		},
+		Sender: &api.User{
+			UserName: "user1",
+		},
+	}
+}
+
 func issueTestPayload() *api.IssuePayload {
 	return &api.IssuePayload{
 		Index: 2,
@@ -123,3 +154,33 @@ func pullRequestTestPayload() *api.PullRequestPayload {
 		},
 	}
 }
+
+func userTestPayload() *api.UserPayload {
+	return &api.UserPayload{
+		Action: api.HookUserCreated,
+		User: &api.User{
+			UserName: "user2",
+		},
+		Sender: &api.User{
+			UserName: "user1",
+		},
+	}
+}
+
+func organizationTestPayload() *api.OrganizationPayload {
+	return &api.OrganizationPayload{
+		Action: api.HookOrganizationMemberAdded,
+		Organization: &api.Organization{
+			UserName: "org3",
+		},
+		Team: &api.Team{
+			Name: "Owners",
+		},
+		Member: &api.User{
+			UserName: "user2",
+		},
+		Sender: &api.User{
+			UserName: "user1",
+		},
+	}
+}
@@ -144,6 +144,18 @@ func getMatrixReleasePayload(p *api.ReleasePayload, matrix *MatrixMeta) (*Matrix
 	return getMatrixPayloadUnsafe(text, nil, matrix), nil
 }
 
+func getMatrixUserPayload(p *api.UserPayload, matrix *MatrixMeta) (*MatrixPayloadUnsafe, error) {
+	text, _ := getUserPayloadInfo(p, MatrixLinkFormatter, true)
+
+	return getMatrixPayloadUnsafe(text, nil, matrix), nil
+}
+
+func getMatrixOrganizationPayload(p *api.OrganizationPayload, matrix *MatrixMeta) (*MatrixPayloadUnsafe, error) {
+	text, _ := getOrganizationPayloadInfo(p, MatrixLinkFormatter, true)
+
+	return getMatrixPayloadUnsafe(text, nil, matrix), nil
+}
+
 func getMatrixPushPayload(p *api.PushPayload, matrix *MatrixMeta) (*MatrixPayloadUnsafe, error) {
 	var commitDesc string
 
@@ -246,6 +258,10 @@ func GetMatrixPayload(p api.Payloader, event models.HookEventType, meta string)
 		return getMatrixRepositoryPayload(p.(*api.RepositoryPayload), matrix)
 	case models.HookEventRelease:
 		return getMatrixReleasePayload(p.(*api.ReleasePayload), matrix)
+	case models.HookEventUser:
+		return getMatrixUserPayload(p.(*api.UserPayload), matrix)
+	case models.HookEventOrganization:
+		return getMatrixOrganizationPayload(p.(*api.OrganizationPayload), matrix)
 	}
 
 	return s, nil
@@ -544,6 +544,59 @@ func getMSTeamsReleasePayload(p *api.ReleasePayload) (*MSTeamsPayload, error) {
 	}, nil
 }
 
+func getMSTeamsUserPayload(p *api.UserPayload) (*MSTeamsPayload, error) {
+	text, color := getUserPayloadInfo(p, noneLinkFormatter, false)
+
+	return getMSTeamsAccountPayload(text, color, p.Sender, []MSTeamsFact{
+		{
+			Name:  "User:",
+			Value: p.User.UserName,
+		},
+	}), nil
+}
+
+func getMSTeamsOrganizationPayload(p *api.OrganizationPayload) (*MSTeamsPayload, error) {
+	text, color := getOrganizationPayloadInfo(p, noneLinkFormatter, false)
+
+	facts := []MSTeamsFact{
+		{
+			Name:  "Organization:",
+			Value: p.Organization.UserName,
+		},
+	}
+	if p.Team != nil {
+		facts = append(facts, MSTeamsFact{
+			Name:  "Team:",
+			Value: p.Team.Name,
+		})
+	}
+	if p.Member != nil {
+		facts = append(facts, MSTeamsFact{
+			Name:  "Member:",
+			Value: p.Member.UserName,
+		})
+	}
+	return getMSTeamsAccountPayload(text, color, p.Sender, facts), nil
+}
+
+func getMSTeamsAccountPayload(title string, color int, sender *api.User, facts []MSTeamsFact) *MSTeamsPayload {
+	return &MSTeamsPayload{
+		Type:       "MessageCard",
+		Context:    "https://schema.org/extensions",
+		ThemeColor: fmt.Sprintf("%x", color),
+		Title:      title,
+		Summary:    title,
+		Sections: []MSTeamsSection{
+			{
+				ActivityTitle:    sender.FullName,
+				ActivitySubtitle: sender.UserName,
+				ActivityImage:    sender.AvatarURL,
+				Facts:            facts,
+			},
+		},
+	}
+}
+
 // GetMSTeamsPayload converts a MSTeams webhook into a MSTeamsPayload
 func GetMSTeamsPayload(p api.Payloader, event models.HookEventType, meta string) (*MSTeamsPayload, error) {
 	s := new(MSTeamsPayload)
@@ -574,6 +627,10 @@ func GetMSTeamsPayload(p api.Payloader, event models.HookEventType, meta string)
 		return getMSTeamsRepositoryPayload(p.(*api.RepositoryPayload))
 	case models.HookEventRelease:
 		return getMSTeamsReleasePayload(p.(*api.ReleasePayload))
+	case models.HookEventUser:
+		return getMSTeamsUserPayload(p.(*api.UserPayload))
+	case models.HookEventOrganization:
+		return getMSTeamsOrganizationPayload(p.(*api.OrganizationPayload))
 	}
 
 	return s, nil
@@ -0,0 +1,118 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+)
+
+var signatureHashes = map[models.HookSignatureAlgorithm]func() hash.Hash{
+	models.HookSignatureSHA1:   sha1.New,
+	models.HookSignatureSHA256: sha256.New,
+	models.HookSignatureSHA512: sha512.New,
+}
+
+// hmacSum returns the HMAC of the data with the hash function of the algorithm
+func hmacSum(algorithm models.HookSignatureAlgorithm, secret string, data []byte) []byte {
+	newHash, ok := signatureHashes[algorithm]
+	if !ok {
+		newHash = sha256.New
+	}
+	sig := hmac.New(newHash, []byte(secret))
+	if _, err := sig.Write(data); err != nil {
+		log.Error("hmacSum.sigWrite: %v", err)
+	}
+	return sig.Sum(nil)
+}
+
+// signPayload returns the hex encoded HMAC-SHA256 of the payload
+func signPayload(data []byte, secret string) string {
+	return hex.EncodeToString(hmacSum(models.HookSignatureSHA256, secret, data))
+}
+
+// addSignatureHeaders signs the payload of the request with all valid secrets of the webhook.
+//
+// X-Gitea-Signature and X-Gogs-Signature carry the HMAC-SHA256 of the payload signed with
+// the current secret. X-Gitea-Signatures carries a comma separated list of "<algorithm>=<hex>"
+// signatures with the algorithm of the webhook, one for the current and one for the previous
+// secret while it is valid. If the webhook signs the timestamp, X-Gitea-Timestamp carries the
+// unix time of the delivery and these signatures cover "<timestamp>.<payload>" so receivers can
+// reject replayed deliveries.
+func addSignatureHeaders(req *http.Request, w *models.Webhook, payload string, now time.Time) string {
+	var signature string
+	if len(w.Secret) > 0 {
+		signature = signPayload([]byte(payload), w.Secret)
+	}
+	req.Header.Add("X-Gitea-Signature", signature)
+	req.Header.Add("X-Gogs-Signature", signature)
+
+	secrets := w.Secrets()
+	if len(secrets) == 0 {
+		return signature
+	}
+
+	data := []byte(payload)
+	if w.SignTimestamp {
+		timestamp := strconv.FormatInt(now.Unix(), 10)
+		req.Header.Set("X-Gitea-Timestamp", timestamp)
+		data = append([]byte(timestamp+"."), data...)
+	}
+
+	algorithm := w.SignatureHash()
+	signatures := make([]string, 0, len(secrets))
+	for _, secret := range secrets {
+		signatures = append(signatures, string(algorithm)+"="+hex.EncodeToString(hmacSum(algorithm, secret, data)))
+	}
+	req.Header.Set("X-Gitea-Signatures", strings.Join(signatures, ","))
+	return signature
+}
+
+// signDingtalkRequest adds the timestamp and signature query parameters of DingTalk
+// robots which have signing enabled.
+// See https://ding-doc.dingtalk.com/doc#/serverapi2/qf2nxq
+func signDingtalkRequest(req *http.Request, secret string, now time.Time) {
+	timestamp := strconv.FormatInt(now.UnixNano()/int64(time.Millisecond), 10)
+	sign := hmacSum(models.HookSignatureSHA256, secret, []byte(timestamp+"\n"+secret))
+
+	query := req.URL.Query()
+	query.Set("timestamp", timestamp)
+	query.Set("sign", base64.StdEncoding.EncodeToString(sign))
+	req.URL.RawQuery = query.Encode()
+}
+
+// signFeishuPayload adds the timestamp and sign fields of Feishu bots which have
+// signature verification enabled to the JSON payload.
+// See https://www.feishu.cn/hc/en-US/articles/360024984973
+func signFeishuPayload(payload, secret string, now time.Time) (string, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(payload), &fields); err != nil {
+		return "", err
+	}
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	sign := hmacSum(models.HookSignatureSHA256, timestamp+"\n"+secret, nil)
+	fields["timestamp"] = timestamp
+	fields["sign"] = base64.StdEncoding.EncodeToString(sign)
+
+	data, err := json.MarshalIndent(fields, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
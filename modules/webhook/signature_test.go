@@ -0,0 +1,100 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddSignatureHeaders(t *testing.T) {
+	payload := `{"ref":"refs/heads/master"}`
+	now := time.Unix(1600000000, 0)
+
+	req, err := http.NewRequest(http.MethodPost, "http://localhost/hook", nil)
+	assert.NoError(t, err)
+	w := &models.Webhook{}
+	assert.Empty(t, addSignatureHeaders(req, w, payload, now))
+	assert.Empty(t, req.Header.Get("X-Gitea-Signatures"))
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	_, _ = mac.Write([]byte(payload))
+	legacy := hex.EncodeToString(mac.Sum(nil))
+
+	req, err = http.NewRequest(http.MethodPost, "http://localhost/hook", nil)
+	assert.NoError(t, err)
+	w = &models.Webhook{Secret: "secret"}
+	assert.Equal(t, legacy, addSignatureHeaders(req, w, payload, now))
+	assert.Equal(t, legacy, req.Header.Get("X-Gitea-Signature"))
+	assert.Equal(t, legacy, req.Header.Get("X-Gogs-Signature"))
+	assert.Equal(t, "sha256="+legacy, req.Header.Get("X-Gitea-Signatures"))
+	assert.Empty(t, req.Header.Get("X-Gitea-Timestamp"))
+
+	// the timestamp is signed with all valid secrets
+	req, err = http.NewRequest(http.MethodPost, "http://localhost/hook", nil)
+	assert.NoError(t, err)
+	w = &models.Webhook{
+		Secret:               "secret",
+		OldSecret:            "old",
+		OldSecretExpiresUnix: timeutil.TimeStampNow().Add(60),
+		SignatureAlgorithm:   models.HookSignatureSHA512,
+		SignTimestamp:        true,
+	}
+	assert.Equal(t, legacy, addSignatureHeaders(req, w, payload, now))
+	assert.Equal(t, "1600000000", req.Header.Get("X-Gitea-Timestamp"))
+
+	var signatures []string
+	for _, secret := range []string{"secret", "old"} {
+		mac := hmac.New(sha512.New, []byte(secret))
+		_, _ = mac.Write([]byte("1600000000." + payload))
+		signatures = append(signatures, "sha512="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	assert.Equal(t, signatures[0]+","+signatures[1], req.Header.Get("X-Gitea-Signatures"))
+}
+
+func TestSignDingtalkRequest(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "https://oapi.dingtalk.com/robot/send?access_token=token", nil)
+	assert.NoError(t, err)
+	signDingtalkRequest(req, "secret", time.Unix(1600000000, 0))
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	_, _ = mac.Write([]byte("1600000000000\nsecret"))
+
+	query := req.URL.Query()
+	assert.Equal(t, "token", query.Get("access_token"))
+	assert.Equal(t, "1600000000000", query.Get("timestamp"))
+	assert.Equal(t, base64.StdEncoding.EncodeToString(mac.Sum(nil)), query.Get("sign"))
+}
+
+func TestSignFeishuPayload(t *testing.T) {
+	payload, err := signFeishuPayload(`{"title":"push","text":"commits"}`, "secret", time.Unix(1600000000, 0))
+	assert.NoError(t, err)
+
+	mac := hmac.New(sha256.New, []byte("1600000000\nsecret"))
+
+	var fields map[string]string
+	assert.NoError(t, json.Unmarshal([]byte(payload), &fields))
+	assert.Equal(t, map[string]string{
+		"title":     "push",
+		"text":      "commits",
+		"timestamp": "1600000000",
+		"sign":      base64.StdEncoding.EncodeToString(mac.Sum(nil)),
+	}, fields)
+
+	_, err = signFeishuPayload("not json", "secret", time.Now())
+	assert.Error(t, err)
+}
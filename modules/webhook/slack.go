@@ -302,6 +302,28 @@ func getSlackRepositoryPayload(p *api.RepositoryPayload, slack *SlackMeta) (*Sla
 	}, nil
 }
 
+func getSlackUserPayload(p *api.UserPayload, slack *SlackMeta) (*SlackPayload, error) {
+	text, _ := getUserPayloadInfo(p, SlackLinkFormatter, true)
+
+	return &SlackPayload{
+		Channel:  slack.Channel,
+		Text:     text,
+		Username: slack.Username,
+		IconURL:  slack.IconURL,
+	}, nil
+}
+
+func getSlackOrganizationPayload(p *api.OrganizationPayload, slack *SlackMeta) (*SlackPayload, error) {
+	text, _ := getOrganizationPayloadInfo(p, SlackLinkFormatter, true)
+
+	return &SlackPayload{
+		Channel:  slack.Channel,
+		Text:     text,
+		Username: slack.Username,
+		IconURL:  slack.IconURL,
+	}, nil
+}
+
 // GetSlackPayload converts a slack webhook into a SlackPayload
 func GetSlackPayload(p api.Payloader, event models.HookEventType, meta string) (*SlackPayload, error) {
 	s := new(SlackPayload)
@@ -337,6 +359,10 @@ func GetSlackPayload(p api.Payloader, event models.HookEventType, meta string) (
 		return getSlackRepositoryPayload(p.(*api.RepositoryPayload), slack)
 	case models.HookEventRelease:
 		return getSlackReleasePayload(p.(*api.ReleasePayload), slack)
+	case models.HookEventUser:
+		return getSlackUserPayload(p.(*api.UserPayload), slack)
+	case models.HookEventOrganization:
+		return getSlackOrganizationPayload(p.(*api.OrganizationPayload), slack)
 	}
 
 	return s, nil
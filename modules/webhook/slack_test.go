@@ -86,3 +86,37 @@ func TestSlackPullRequestPayload(t *testing.T) {
 
 	assert.Equal(t, "[<http://localhost:3000/test/repo|test/repo>] Pull request opened: <http://localhost:3000/test/repo/pulls/12|#2 Fix bug> by <https://try.gitea.io/user1|user1>", pl.Text)
 }
+
+func TestSlackUserPayload(t *testing.T) {
+	p := userTestPayload()
+
+	sl := &SlackMeta{
+		Username: p.Sender.UserName,
+	}
+
+	pl, err := getSlackUserPayload(p, sl)
+	require.NoError(t, err)
+	require.NotNil(t, pl)
+
+	assert.Equal(t, "User created: <https://try.gitea.io/user2|user2> by <https://try.gitea.io/user1|user1>", pl.Text)
+}
+
+func TestSlackOrganizationPayload(t *testing.T) {
+	p := organizationTestPayload()
+
+	sl := &SlackMeta{
+		Username: p.Sender.UserName,
+	}
+
+	pl, err := getSlackOrganizationPayload(p, sl)
+	require.NoError(t, err)
+	require.NotNil(t, pl)
+	assert.Equal(t, "[<https://try.gitea.io/org3|org3>] Member <https://try.gitea.io/user2|user2> added to team <https://try.gitea.io/org/org3/teams/owners|Owners> by <https://try.gitea.io/user1|user1>", pl.Text)
+
+	p.Action = api.HookOrganizationMemberRemoved
+	p.Team = nil
+	pl, err = getSlackOrganizationPayload(p, sl)
+	require.NoError(t, err)
+	require.NotNil(t, pl)
+	assert.Equal(t, "[<https://try.gitea.io/org3|org3>] Member <https://try.gitea.io/user2|user2> removed by <https://try.gitea.io/user1|user1>", pl.Text)
+}
@@ -192,6 +192,22 @@ func getTelegramReleasePayload(p *api.ReleasePayload) (*TelegramPayload, error)
 	}, nil
 }
 
+func getTelegramUserPayload(p *api.UserPayload) (*TelegramPayload, error) {
+	text, _ := getUserPayloadInfo(p, htmlLinkFormatter, true)
+
+	return &TelegramPayload{
+		Message: text + "\n",
+	}, nil
+}
+
+func getTelegramOrganizationPayload(p *api.OrganizationPayload) (*TelegramPayload, error) {
+	text, _ := getOrganizationPayloadInfo(p, htmlLinkFormatter, true)
+
+	return &TelegramPayload{
+		Message: text + "\n",
+	}, nil
+}
+
 // GetTelegramPayload converts a telegram webhook into a TelegramPayload
 func GetTelegramPayload(p api.Payloader, event models.HookEventType, meta string) (*TelegramPayload, error) {
 	s := new(TelegramPayload)
@@ -222,6 +238,10 @@ func GetTelegramPayload(p api.Payloader, event models.HookEventType, meta string
 		return getTelegramRepositoryPayload(p.(*api.RepositoryPayload))
 	case models.HookEventRelease:
 		return getTelegramReleasePayload(p.(*api.ReleasePayload))
+	case models.HookEventUser:
+		return getTelegramUserPayload(p.(*api.UserPayload))
+	case models.HookEventOrganization:
+		return getTelegramOrganizationPayload(p.(*api.OrganizationPayload))
 	}
 
 	return s, nil
@@ -5,24 +5,16 @@
 package webhook
 
 import (
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
 	"fmt"
 	"strings"
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
-	"code.gitea.io/gitea/modules/setting"
 	api "code.gitea.io/gitea/modules/structs"
-	"code.gitea.io/gitea/modules/sync"
 	"github.com/gobwas/glob"
 )
 
-// hookQueue is a global queue of web hooks
-var hookQueue = sync.NewUniqueQueue(setting.Webhook.QueueLength)
-
 // getPayloadBranch returns branch for hook event, if applicable.
 func getPayloadBranch(p api.Payloader) string {
 	switch pp := p.(type) {
@@ -44,12 +36,7 @@ func getPayloadBranch(p api.Payloader) string {
 
 // PrepareWebhook adds special webhook to task queue for given payload.
 func PrepareWebhook(w *models.Webhook, repo *models.Repository, event models.HookEventType, p api.Payloader) error {
-	if err := prepareWebhook(w, repo, event, p); err != nil {
-		return err
-	}
-
-	go hookQueue.Add(repo.ID)
-	return nil
+	return prepareWebhook(w, repo.ID, event, p)
 }
 
 func checkBranch(w *models.Webhook, branch string) bool {
@@ -67,7 +54,9 @@ func checkBranch(w *models.Webhook, branch string) bool {
 	return g.Match(branch)
 }
 
-func prepareWebhook(w *models.Webhook, repo *models.Repository, event models.HookEventType, p api.Payloader) error {
+// prepareWebhook adds a task for the webhook if it is subscribed to the event,
+// the repository ID is 0 for events which don't belong to a repository.
+func prepareWebhook(w *models.Webhook, repoID int64, event models.HookEventType, p api.Payloader) error {
 	for _, e := range w.EventCheckers() {
 		if event == e.Type {
 			if !e.Has() {
@@ -124,50 +113,64 @@ func prepareWebhook(w *models.Webhook, repo *models.Repository, event models.Hoo
 		if err != nil {
 			return fmt.Errorf("GetMatrixPayload: %v", err)
 		}
+	case models.CUSTOM:
+		payloader, err = GetCustomPayload(p, event, w.Meta)
+		if err != nil {
+			return fmt.Errorf("GetCustomPayload: %v", err)
+		}
 	default:
 		p.SetSecret(w.Secret)
 		payloader = p
 	}
 
-	var signature string
-	if len(w.Secret) > 0 {
-		data, err := payloader.JSONPayload()
-		if err != nil {
-			log.Error("prepareWebhooks.JSONPayload: %v", err)
-		}
-		sig := hmac.New(sha256.New, []byte(w.Secret))
-		_, err = sig.Write(data)
-		if err != nil {
-			log.Error("prepareWebhooks.sigWrite: %v", err)
-		}
-		signature = hex.EncodeToString(sig.Sum(nil))
-	}
-
-	if err = models.CreateHookTask(&models.HookTask{
-		RepoID:      repo.ID,
+	task := &models.HookTask{
+		RepoID:      repoID,
 		HookID:      w.ID,
 		Type:        w.HookTaskType,
 		URL:         w.URL,
-		Signature:   signature,
 		Payloader:   payloader,
 		HTTPMethod:  w.HTTPMethod,
 		ContentType: w.ContentType,
 		EventType:   event,
 		IsSSL:       w.IsSSL,
-	}); err != nil {
+	}
+	if err = models.CreateHookTask(task); err != nil {
 		return fmt.Errorf("CreateHookTask: %v", err)
 	}
+	enqueueHookTask(task.ID)
 	return nil
 }
 
-// PrepareWebhooks adds new webhooks to task queue for given payload.
-func PrepareWebhooks(repo *models.Repository, event models.HookEventType, p api.Payloader) error {
-	if err := prepareWebhooks(repo, event, p); err != nil {
-		return err
+// ReplayHookTask redelivers the payload of a historical hook task of the webhook,
+// it is sent as a new task to the current URL of the webhook and signed with its
+// current secrets on delivery.
+func ReplayHookTask(w *models.Webhook, taskID int64) (*models.HookTask, error) {
+	t, err := models.GetHookTaskByHookID(w.ID, taskID)
+	if err != nil {
+		return nil, err
 	}
 
-	go hookQueue.Add(repo.ID)
-	return nil
+	task := &models.HookTask{
+		RepoID:         t.RepoID,
+		HookID:         w.ID,
+		Type:           w.HookTaskType,
+		URL:            w.URL,
+		PayloadContent: t.PayloadContent,
+		HTTPMethod:     w.HTTPMethod,
+		ContentType:    w.ContentType,
+		EventType:      t.EventType,
+		IsSSL:          w.IsSSL,
+	}
+	if err := models.CreateHookTask(task); err != nil {
+		return nil, fmt.Errorf("CreateHookTask: %v", err)
+	}
+	enqueueHookTask(task.ID)
+	return task, nil
+}
+
+// PrepareWebhooks adds new webhooks to task queue for given payload.
+func PrepareWebhooks(repo *models.Repository, event models.HookEventType, p api.Payloader) error {
+	return prepareWebhooks(repo, event, p)
 }
 
 func prepareWebhooks(repo *models.Repository, event models.HookEventType, p api.Payloader) error {
@@ -187,18 +190,52 @@ func prepareWebhooks(repo *models.Repository, event models.HookEventType, p api.
 	}
 
 	// Add any admin-defined system webhooks
-	systemHooks, err := models.GetSystemWebhooks()
+	systemHooks, err := models.GetActiveSystemWebhooks()
+	if err != nil {
+		return fmt.Errorf("GetActiveSystemWebhooks: %v", err)
+	}
+	ws = append(ws, systemHooks...)
+
+	return prepareWebhooksOf(ws, repo.ID, event, p)
+}
+
+// PrepareOrgWebhooks adds the webhooks of the organization and the system webhooks
+// to task queue for given payload of an event which doesn't belong to a repository.
+func PrepareOrgWebhooks(org *models.User, event models.HookEventType, p api.Payloader) error {
+	ws, err := models.GetActiveWebhooksByOrgID(org.ID)
 	if err != nil {
-		return fmt.Errorf("GetSystemWebhooks: %v", err)
+		return fmt.Errorf("GetActiveWebhooksByOrgID: %v", err)
+	}
+
+	systemHooks, err := models.GetActiveSystemWebhooks()
+	if err != nil {
+		return fmt.Errorf("GetActiveSystemWebhooks: %v", err)
 	}
 	ws = append(ws, systemHooks...)
 
-	if len(ws) == 0 {
-		return nil
+	return prepareWebhooksOf(ws, 0, event, p)
+}
+
+// PrepareSystemWebhooks adds the system webhooks to task queue for given payload,
+// it is used for instance-wide events and for events of repositories whose other
+// webhooks are not notified.
+func PrepareSystemWebhooks(event models.HookEventType, p api.Payloader) error {
+	ws, err := models.GetActiveSystemWebhooks()
+	if err != nil {
+		return fmt.Errorf("GetActiveSystemWebhooks: %v", err)
 	}
+	return prepareWebhooksOf(ws, 0, event, p)
+}
 
+func prepareWebhooksOf(ws []*models.Webhook, repoID int64, event models.HookEventType, p api.Payloader) error {
 	for _, w := range ws {
-		if err = prepareWebhook(w, repo, event, p); err != nil {
+		if err := prepareWebhook(w, repoID, event, p); err != nil {
+			// the template of a custom webhook may not fit every event, it must not
+			// keep the other webhooks from being delivered
+			if w.HookTaskType == models.CUSTOM {
+				log.Error("prepareWebhook[%d]: %v", w.ID, err)
+				continue
+			}
 			return err
 		}
 	}
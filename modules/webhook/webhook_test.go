@@ -74,6 +74,79 @@ func TestPrepareWebhooksBranchFilterNoMatch(t *testing.T) {
 	}
 }
 
+func TestPrepareSystemWebhooks(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+
+	active := createSystemWebhook(t, true, models.HookEvents{User: true})
+	inactive := createSystemWebhook(t, false, models.HookEvents{User: true})
+	assert.NoError(t, PrepareSystemWebhooks(models.HookEventUser, &api.UserPayload{
+		Action: api.HookUserCreated,
+		User:   &api.User{UserName: "user2"},
+		Sender: &api.User{UserName: "user1"},
+	}))
+	models.AssertExistsAndLoadBean(t, &models.HookTask{RepoID: 0, HookID: active.ID, EventType: models.HookEventUser})
+	models.AssertNotExistsBean(t, &models.HookTask{HookID: inactive.ID})
+}
+
+func TestPrepareOrgWebhooks(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+
+	org := models.AssertExistsAndLoadBean(t, &models.User{ID: 3}).(*models.User)
+	orgHook := &models.Webhook{
+		OrgID:    org.ID,
+		URL:      "www.example.com/org",
+		IsActive: true,
+		HookEvent: &models.HookEvent{
+			ChooseEvents: true,
+			HookEvents:   models.HookEvents{Organization: true},
+		},
+	}
+	assert.NoError(t, orgHook.UpdateEvent())
+	assert.NoError(t, models.CreateWebhook(orgHook))
+	// system webhooks only get the events they have chosen
+	systemHook := createSystemWebhook(t, true, models.HookEvents{User: true})
+
+	assert.NoError(t, PrepareOrgWebhooks(org, models.HookEventOrganization, organizationTestPayload()))
+	models.AssertExistsAndLoadBean(t, &models.HookTask{RepoID: 0, HookID: orgHook.ID, EventType: models.HookEventOrganization})
+	models.AssertNotExistsBean(t, &models.HookTask{HookID: systemHook.ID})
+	// the other webhooks of the organization have not chosen the event
+	models.AssertNotExistsBean(t, &models.HookTask{HookID: 3, EventType: models.HookEventOrganization})
+}
+
+func createSystemWebhook(t *testing.T, active bool, events models.HookEvents) *models.Webhook {
+	w := &models.Webhook{
+		IsSystemWebhook: true,
+		URL:             "www.example.com/system",
+		IsActive:        active,
+		HookEvent: &models.HookEvent{
+			ChooseEvents: true,
+			HookEvents:   events,
+		},
+	}
+	assert.NoError(t, w.UpdateEvent())
+	assert.NoError(t, models.CreateWebhook(w))
+	return w
+}
+
+func TestReplayHookTask(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+
+	w := models.AssertExistsAndLoadBean(t, &models.Webhook{ID: 1}).(*models.Webhook)
+	original := models.AssertExistsAndLoadBean(t, &models.HookTask{ID: 1}).(*models.HookTask)
+
+	task, err := ReplayHookTask(w, original.ID)
+	assert.NoError(t, err)
+	assert.NotEqual(t, original.ID, task.ID)
+	assert.NotEqual(t, original.UUID, task.UUID)
+	assert.Equal(t, original.PayloadContent, task.PayloadContent)
+	models.AssertExistsAndLoadBean(t, &models.HookTask{ID: task.ID, HookID: w.ID, URL: w.URL, IsDelivered: false})
+
+	// the task must belong to the webhook
+	w = models.AssertExistsAndLoadBean(t, &models.Webhook{ID: 2}).(*models.Webhook)
+	_, err = ReplayHookTask(w, original.ID)
+	assert.True(t, models.IsErrHookTaskNotExist(err))
+}
+
 // TODO TestHookTask_deliver
 
 // TODO TestDeliverHooks
@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/auth"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
@@ -148,6 +149,11 @@ func DashboardPost(ctx *context.Context, form auth.AdminDashboardForm) {
 		task := cron.GetTask(form.Op)
 		if task != nil {
 			go task.RunWithUser(ctx.User, nil)
+			audit.Record(ctx.User, ctx.RemoteAddr(), &models.AuditEvent{
+				Action:     models.AuditAdminRunTask,
+				TargetType: models.AuditTargetTask,
+				TargetName: form.Op,
+			})
 			ctx.Flash.Success(ctx.Tr("admin.dashboard.task.started", ctx.Tr("admin.dashboard."+form.Op)))
 		} else {
 			ctx.Flash.Error(ctx.Tr("admin.dashboard.task.unknown", form.Op))
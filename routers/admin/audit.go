@@ -0,0 +1,69 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package admin
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+const (
+	tplAudit base.TplName = "admin/audit"
+)
+
+// Audit shows the audit log filtered by actor, repository, organization and action
+func Audit(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("admin.audit")
+	ctx.Data["PageIsAdmin"] = true
+	ctx.Data["PageIsAdminAudit"] = true
+
+	actor := ctx.QueryTrim("actor")
+	repo := ctx.QueryTrim("repo")
+	org := ctx.QueryTrim("org")
+	action := ctx.QueryTrim("action")
+	if !models.IsValidAuditAction(action) {
+		action = ""
+	}
+
+	opts, err := audit.FilterOptions(actor, repo, org, models.AuditAction(action))
+	if err != nil {
+		ctx.ServerError("FilterOptions", err)
+		return
+	}
+	page := ctx.QueryInt("page")
+	if page <= 1 {
+		page = 1
+	}
+	opts.ListOptions = models.ListOptions{
+		Page:     page,
+		PageSize: setting.UI.Admin.NoticePagingNum,
+	}
+
+	events, count, err := models.FindAuditEvents(opts)
+	if err != nil {
+		ctx.ServerError("FindAuditEvents", err)
+		return
+	}
+
+	ctx.Data["Events"] = events
+	ctx.Data["Total"] = count
+	ctx.Data["Actions"] = models.AuditActions
+	ctx.Data["Actor"] = actor
+	ctx.Data["Repo"] = repo
+	ctx.Data["Org"] = org
+	ctx.Data["Action"] = action
+
+	pager := context.NewPagination(int(count), opts.PageSize, page, 5)
+	pager.AddParam(ctx, "actor", "Actor")
+	pager.AddParam(ctx, "repo", "Repo")
+	pager.AddParam(ctx, "org", "Org")
+	pager.AddParam(ctx, "action", "Action")
+	ctx.Data["Page"] = pager
+
+	ctx.HTML(200, tplAudit)
+}
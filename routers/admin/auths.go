@@ -8,11 +8,14 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"strings"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/auth"
 	"code.gitea.io/gitea/modules/auth/ldap"
 	"code.gitea.io/gitea/modules/auth/oauth2"
+	"code.gitea.io/gitea/modules/auth/saml"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/log"
@@ -27,6 +30,8 @@ const (
 	tplAuths    base.TplName = "admin/auth/list"
 	tplAuthNew  base.TplName = "admin/auth/new"
 	tplAuthEdit base.TplName = "admin/auth/edit"
+
+	tplAuthTeamSync base.TplName = "admin/auth/team_sync"
 )
 
 var (
@@ -64,6 +69,7 @@ var (
 		{models.LoginNames[models.LoginPAM], models.LoginPAM},
 		{models.LoginNames[models.LoginOAuth2], models.LoginOAuth2},
 		{models.LoginNames[models.LoginSSPI], models.LoginSSPI},
+		{models.LoginNames[models.LoginSAML], models.LoginSAML},
 	}
 	securityProtocols = []dropdownItem{
 		{models.SecurityProtocolNames[ldap.SecurityProtocolUnencrypted], ldap.SecurityProtocolUnencrypted},
@@ -105,7 +111,12 @@ func NewAuthSource(ctx *context.Context) {
 	ctx.HTML(200, tplAuthNew)
 }
 
-func parseLDAPConfig(form auth.AuthenticationForm) *models.LDAPConfig {
+func parseLDAPConfig(ctx *context.Context, form auth.AuthenticationForm) (*models.LDAPConfig, error) {
+	if _, err := models.ParseGroupTeamMapping(form.GroupTeamMap); err != nil {
+		ctx.Data["Err_GroupTeamMap"] = true
+		return nil, errors.New(ctx.Tr("admin.auths.group_team_map_invalid", err.Error()))
+	}
+
 	var pageSize uint32
 	if form.UsePagedSearch {
 		pageSize = uint32(form.SearchPageSize)
@@ -127,6 +138,8 @@ func parseLDAPConfig(form auth.AuthenticationForm) *models.LDAPConfig {
 			AttributeMail:         form.AttributeMail,
 			AttributesInBind:      form.AttributesInBind,
 			AttributeSSHPublicKey: form.AttributeSSHPublicKey,
+			AttributeGroups:       form.AttributeGroups,
+			GroupTeamMap:          form.GroupTeamMap,
 			SearchPageSize:        pageSize,
 			Filter:                form.Filter,
 			AdminFilter:           form.AdminFilter,
@@ -134,7 +147,7 @@ func parseLDAPConfig(form auth.AuthenticationForm) *models.LDAPConfig {
 			AllowDeactivateAll:    form.AllowDeactivateAll,
 			Enabled:               true,
 		},
-	}
+	}, nil
 }
 
 func parseSMTPConfig(form auth.AuthenticationForm) *models.SMTPConfig {
@@ -148,7 +161,12 @@ func parseSMTPConfig(form auth.AuthenticationForm) *models.SMTPConfig {
 	}
 }
 
-func parseOAuth2Config(form auth.AuthenticationForm) *models.OAuth2Config {
+func parseOAuth2Config(ctx *context.Context, form auth.AuthenticationForm) (*models.OAuth2Config, error) {
+	if _, err := models.ParseGroupTeamMapping(form.Oauth2GroupTeamMap); err != nil {
+		ctx.Data["Err_Oauth2GroupTeamMap"] = true
+		return nil, errors.New(ctx.Tr("admin.auths.group_team_map_invalid", err.Error()))
+	}
+
 	var customURLMapping *oauth2.CustomURLMapping
 	if form.Oauth2UseCustomURL {
 		customURLMapping = &oauth2.CustomURLMapping{
@@ -166,7 +184,9 @@ func parseOAuth2Config(form auth.AuthenticationForm) *models.OAuth2Config {
 		ClientSecret:                  form.Oauth2Secret,
 		OpenIDConnectAutoDiscoveryURL: form.OpenIDConnectAutoDiscoveryURL,
 		CustomURLMapping:              customURLMapping,
-	}
+		GroupClaimName:                form.Oauth2GroupClaimName,
+		GroupTeamMap:                  form.Oauth2GroupTeamMap,
+	}, nil
 }
 
 func parseSSPIConfig(ctx *context.Context, form auth.AuthenticationForm) (*models.SSPIConfig, error) {
@@ -193,6 +213,64 @@ func parseSSPIConfig(ctx *context.Context, form auth.AuthenticationForm) (*model
 	}, nil
 }
 
+// parseSAMLConfig imports the identity provider metadata from its URL if given
+// and generates a key pair for the service provider if none is given. If the
+// private key is left empty on editing, the existing key pair of previous is
+// kept as long as the certificate is unchanged.
+func parseSAMLConfig(ctx *context.Context, form auth.AuthenticationForm, previous *models.SAMLConfig) (*models.SAMLConfig, error) {
+	metadata := form.SAMLIdentityProviderMetadata
+	if form.SAMLIdentityProviderMetadataURL != "" {
+		var err error
+		metadata, err = saml.FetchMetadata(form.SAMLIdentityProviderMetadataURL, form.SkipVerify)
+		if err != nil {
+			ctx.Data["Err_SAMLIdentityProviderMetadataURL"] = true
+			return nil, errors.New(ctx.Tr("admin.auths.saml_metadata_fetch_error", err.Error()))
+		}
+	}
+	if _, err := saml.ParseMetadata([]byte(metadata)); err != nil {
+		ctx.Data["Err_SAMLIdentityProviderMetadata"] = true
+		return nil, errors.New(ctx.Tr("admin.auths.saml_metadata_invalid", err.Error()))
+	}
+
+	if _, err := models.ParseGroupTeamMapping(form.SAMLGroupTeamMap); err != nil {
+		ctx.Data["Err_SAMLGroupTeamMap"] = true
+		return nil, errors.New(ctx.Tr("admin.auths.group_team_map_invalid", err.Error()))
+	}
+
+	cert, key := strings.TrimSpace(form.SAMLServiceProviderCertificate), strings.TrimSpace(form.SAMLServiceProviderPrivateKey)
+	switch {
+	case key == "" && previous != nil && (cert == "" || cert == strings.TrimSpace(previous.ServiceProviderCertificate)):
+		cert, key = previous.ServiceProviderCertificate, previous.ServiceProviderPrivateKey
+	case cert == "" && key == "":
+		var err error
+		if cert, key, err = saml.GenerateKeyPair(form.Name); err != nil {
+			return nil, err
+		}
+	case cert == "" || key == "":
+		ctx.Data["Err_SAMLServiceProviderCertificate"] = true
+		return nil, errors.New(ctx.Tr("admin.auths.saml_key_pair_incomplete"))
+	}
+
+	source := &saml.Source{
+		IdentityProviderMetadata:    metadata,
+		IdentityProviderMetadataURL: form.SAMLIdentityProviderMetadataURL,
+		SkipVerify:                  form.SkipVerify,
+		ServiceProviderCertificate:  cert,
+		ServiceProviderPrivateKey:   key,
+		AttributeUsername:           form.SAMLAttributeUsername,
+		AttributeEmail:              form.SAMLAttributeEmail,
+		AttributeFullName:           form.SAMLAttributeFullName,
+		AttributeGroups:             form.SAMLAttributeGroups,
+		GroupTeamMap:                form.SAMLGroupTeamMap,
+		GroupTeamMapRemoval:         form.SAMLGroupTeamMapRemoval,
+	}
+	if _, err := source.ServiceProvider(form.Name); err != nil {
+		ctx.Data["Err_SAMLServiceProviderCertificate"] = true
+		return nil, errors.New(ctx.Tr("admin.auths.saml_key_pair_invalid", err.Error()))
+	}
+	return &models.SAMLConfig{Source: source}, nil
+}
+
 // NewAuthSourcePost response for adding an auth source
 func NewAuthSourcePost(ctx *context.Context, form auth.AuthenticationForm) {
 	ctx.Data["Title"] = ctx.Tr("admin.auths.new")
@@ -217,7 +295,12 @@ func NewAuthSourcePost(ctx *context.Context, form auth.AuthenticationForm) {
 	var config convert.Conversion
 	switch models.LoginType(form.Type) {
 	case models.LoginLDAP, models.LoginDLDAP:
-		config = parseLDAPConfig(form)
+		var err error
+		config, err = parseLDAPConfig(ctx, form)
+		if err != nil {
+			ctx.RenderWithErr(err.Error(), tplAuthNew, form)
+			return
+		}
 		hasTLS = ldap.SecurityProtocol(form.SecurityProtocol) > ldap.SecurityProtocolUnencrypted
 	case models.LoginSMTP:
 		config = parseSMTPConfig(form)
@@ -227,7 +310,12 @@ func NewAuthSourcePost(ctx *context.Context, form auth.AuthenticationForm) {
 			ServiceName: form.PAMServiceName,
 		}
 	case models.LoginOAuth2:
-		config = parseOAuth2Config(form)
+		var err error
+		config, err = parseOAuth2Config(ctx, form)
+		if err != nil {
+			ctx.RenderWithErr(err.Error(), tplAuthNew, form)
+			return
+		}
 	case models.LoginSSPI:
 		var err error
 		config, err = parseSSPIConfig(ctx, form)
@@ -241,6 +329,13 @@ func NewAuthSourcePost(ctx *context.Context, form auth.AuthenticationForm) {
 			ctx.RenderWithErr(ctx.Tr("admin.auths.login_source_of_type_exist"), tplAuthNew, form)
 			return
 		}
+	case models.LoginSAML:
+		var err error
+		config, err = parseSAMLConfig(ctx, form, nil)
+		if err != nil {
+			ctx.RenderWithErr(err.Error(), tplAuthNew, form)
+			return
+		}
 	default:
 		ctx.Error(400)
 		return
@@ -252,13 +347,14 @@ func NewAuthSourcePost(ctx *context.Context, form auth.AuthenticationForm) {
 		return
 	}
 
-	if err := models.CreateLoginSource(&models.LoginSource{
+	source := &models.LoginSource{
 		Type:          models.LoginType(form.Type),
 		Name:          form.Name,
 		IsActived:     form.IsActive,
 		IsSyncEnabled: form.IsSyncEnabled,
 		Cfg:           config,
-	}); err != nil {
+	}
+	if err := models.CreateLoginSource(source); err != nil {
 		if models.IsErrLoginSourceAlreadyExist(err) {
 			ctx.Data["Err_Name"] = true
 			ctx.RenderWithErr(ctx.Tr("admin.auths.login_source_exist", err.(models.ErrLoginSourceAlreadyExist).Name), tplAuthNew, form)
@@ -269,6 +365,13 @@ func NewAuthSourcePost(ctx *context.Context, form auth.AuthenticationForm) {
 	}
 
 	log.Trace("Authentication created by admin(%s): %s", ctx.User.Name, form.Name)
+	audit.Record(ctx.User, ctx.RemoteAddr(), &models.AuditEvent{
+		Action:     models.AuditLoginSourceCreate,
+		TargetType: models.AuditTargetLoginSource,
+		TargetID:   source.ID,
+		TargetName: source.Name,
+		Details:    fmt.Sprintf("type: %s, active: %t", source.TypeName(), source.IsActived),
+	})
 
 	ctx.Flash.Success(ctx.Tr("admin.auths.new_success", form.Name))
 	ctx.Redirect(setting.AppSubURL + "/admin/auths")
@@ -296,6 +399,9 @@ func EditAuthSource(ctx *context.Context) {
 	if source.IsOAuth2() {
 		ctx.Data["CurrentOAuth2Provider"] = models.OAuth2Providers[source.OAuth2().Provider]
 	}
+	if source.IsSAML() {
+		ctx.Data["SAMLBaseURL"] = saml.BaseURL(source.Name)
+	}
 	ctx.HTML(200, tplAuthEdit)
 }
 
@@ -316,6 +422,9 @@ func EditAuthSourcePost(ctx *context.Context, form auth.AuthenticationForm) {
 	}
 	ctx.Data["Source"] = source
 	ctx.Data["HasTLS"] = source.HasTLS()
+	if source.IsSAML() {
+		ctx.Data["SAMLBaseURL"] = saml.BaseURL(source.Name)
+	}
 
 	if ctx.HasError() {
 		ctx.HTML(200, tplAuthEdit)
@@ -325,7 +434,11 @@ func EditAuthSourcePost(ctx *context.Context, form auth.AuthenticationForm) {
 	var config convert.Conversion
 	switch models.LoginType(form.Type) {
 	case models.LoginLDAP, models.LoginDLDAP:
-		config = parseLDAPConfig(form)
+		config, err = parseLDAPConfig(ctx, form)
+		if err != nil {
+			ctx.RenderWithErr(err.Error(), tplAuthEdit, form)
+			return
+		}
 	case models.LoginSMTP:
 		config = parseSMTPConfig(form)
 	case models.LoginPAM:
@@ -333,13 +446,23 @@ func EditAuthSourcePost(ctx *context.Context, form auth.AuthenticationForm) {
 			ServiceName: form.PAMServiceName,
 		}
 	case models.LoginOAuth2:
-		config = parseOAuth2Config(form)
+		config, err = parseOAuth2Config(ctx, form)
+		if err != nil {
+			ctx.RenderWithErr(err.Error(), tplAuthEdit, form)
+			return
+		}
 	case models.LoginSSPI:
 		config, err = parseSSPIConfig(ctx, form)
 		if err != nil {
 			ctx.RenderWithErr(err.Error(), tplAuthEdit, form)
 			return
 		}
+	case models.LoginSAML:
+		config, err = parseSAMLConfig(ctx, form, source.SAML())
+		if err != nil {
+			ctx.RenderWithErr(err.Error(), tplAuthEdit, form)
+			return
+		}
 	default:
 		ctx.Error(400)
 		return
@@ -359,11 +482,47 @@ func EditAuthSourcePost(ctx *context.Context, form auth.AuthenticationForm) {
 		return
 	}
 	log.Trace("Authentication changed by admin(%s): %d", ctx.User.Name, source.ID)
+	audit.Record(ctx.User, ctx.RemoteAddr(), &models.AuditEvent{
+		Action:     models.AuditLoginSourceEdit,
+		TargetType: models.AuditTargetLoginSource,
+		TargetID:   source.ID,
+		TargetName: source.Name,
+		Details:    fmt.Sprintf("type: %s, active: %t", source.TypeName(), source.IsActived),
+	})
 
 	ctx.Flash.Success(ctx.Tr("admin.auths.update_success"))
 	ctx.Redirect(setting.AppSubURL + "/admin/auths/" + com.ToStr(form.ID))
 }
 
+// AuthSourceTeamSyncPreview shows the team membership changes the next user
+// synchronization of an LDAP source would make
+func AuthSourceTeamSyncPreview(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("admin.auths.team_sync_preview")
+	ctx.Data["PageIsAdmin"] = true
+	ctx.Data["PageIsAdminAuthentications"] = true
+
+	source, err := models.GetLoginSourceByID(ctx.ParamsInt64(":authid"))
+	if err != nil {
+		ctx.ServerError("GetLoginSourceByID", err)
+		return
+	}
+	if !source.IsLDAP() {
+		ctx.NotFound("AuthSourceTeamSyncPreview", nil)
+		return
+	}
+	ctx.Data["Source"] = source
+
+	changes, err := models.PreviewLDAPGroupTeamSync(source)
+	if err != nil {
+		ctx.Flash.Error(ctx.Tr("admin.auths.team_sync_preview_failed", err.Error()))
+		ctx.Redirect(setting.AppSubURL + "/admin/auths/" + com.ToStr(source.ID))
+		return
+	}
+	ctx.Data["Changes"] = changes
+
+	ctx.HTML(200, tplAuthTeamSync)
+}
+
 // DeleteAuthSource response for deleting an auth source
 func DeleteAuthSource(ctx *context.Context) {
 	source, err := models.GetLoginSourceByID(ctx.ParamsInt64(":authid"))
@@ -384,6 +543,12 @@ func DeleteAuthSource(ctx *context.Context) {
 		return
 	}
 	log.Trace("Authentication deleted by admin(%s): %d", ctx.User.Name, source.ID)
+	audit.Record(ctx.User, ctx.RemoteAddr(), &models.AuditEvent{
+		Action:     models.AuditLoginSourceDelete,
+		TargetType: models.AuditTargetLoginSource,
+		TargetID:   source.ID,
+		TargetName: source.Name,
+	})
 
 	ctx.Flash.Success(ctx.Tr("admin.auths.deletion_success"))
 	ctx.JSON(200, map[string]interface{}{
@@ -0,0 +1,82 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package admin
+
+import (
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/auth"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/setting"
+	actions_service "code.gitea.io/gitea/services/actions"
+)
+
+const (
+	tplRunners base.TplName = "admin/runners/list"
+)
+
+func loadRunners(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("admin.runners")
+	ctx.Data["PageIsAdmin"] = true
+	ctx.Data["PageIsAdminRunners"] = true
+
+	runners, err := models.GetActionRunners()
+	if err != nil {
+		ctx.ServerError("GetActionRunners", err)
+		return
+	}
+	ctx.Data["Runners"] = runners
+}
+
+// Runners shows the action runners
+func Runners(ctx *context.Context) {
+	loadRunners(ctx)
+	if ctx.Written() {
+		return
+	}
+	ctx.HTML(200, tplRunners)
+}
+
+// NewRunnerPost registers a new action runner and shows its token once
+func NewRunnerPost(ctx *context.Context, form auth.AdminCreateActionRunnerForm) {
+	loadRunners(ctx)
+	if ctx.Written() {
+		return
+	}
+	if ctx.HasError() {
+		ctx.HTML(200, tplRunners)
+		return
+	}
+
+	runner := &models.ActionRunner{Name: form.Name}
+	for _, label := range strings.Split(form.Labels, ",") {
+		if label = strings.TrimSpace(label); label != "" {
+			runner.Labels = append(runner.Labels, label)
+		}
+	}
+	if err := models.NewActionRunner(runner); err != nil {
+		ctx.ServerError("NewActionRunner", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("admin.runners.new_success", runner.Name))
+	ctx.Flash.Info(runner.Token)
+	ctx.Redirect(setting.AppSubURL + "/admin/runners")
+}
+
+// DeleteRunner deletes an action runner, the jobs it is running fail
+func DeleteRunner(ctx *context.Context) {
+	if err := actions_service.DeleteRunner(ctx.QueryInt64("id")); err != nil {
+		ctx.ServerError("DeleteRunner", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("admin.runners.deletion_success"))
+	ctx.JSON(200, map[string]interface{}{
+		"redirect": setting.AppSubURL + "/admin/runners",
+	})
+}
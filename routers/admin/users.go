@@ -6,13 +6,16 @@
 package admin
 
 import (
+	"fmt"
 	"strings"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/auth"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification"
 	"code.gitea.io/gitea/modules/password"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/routers"
@@ -133,6 +136,13 @@ func NewUserPost(ctx *context.Context, form auth.AdminCreateUserForm) {
 		return
 	}
 	log.Trace("Account created by admin (%s): %s", ctx.User.Name, u.Name)
+	notification.NotifyCreateUser(ctx.User, u)
+	audit.Record(ctx.User, ctx.RemoteAddr(), &models.AuditEvent{
+		Action:     models.AuditUserCreate,
+		TargetType: models.AuditTargetUser,
+		TargetID:   u.ID,
+		TargetName: u.Name,
+	})
 
 	// Send email notification.
 	if form.SendNotify {
@@ -261,6 +271,14 @@ func EditUserPost(ctx *context.Context, form auth.AdminEditUserForm) {
 		return
 	}
 	log.Trace("Account profile updated by admin (%s): %s", ctx.User.Name, u.Name)
+	audit.Record(ctx.User, ctx.RemoteAddr(), &models.AuditEvent{
+		Action:     models.AuditUserEdit,
+		TargetType: models.AuditTargetUser,
+		TargetID:   u.ID,
+		TargetName: u.Name,
+		Details: fmt.Sprintf("admin: %t, restricted: %t, active: %t, prohibit login: %t, password changed: %t",
+			u.IsAdmin, u.IsRestricted, u.IsActive, u.ProhibitLogin, len(form.Password) > 0),
+	})
 
 	ctx.Flash.Success(ctx.Tr("admin.users.update_profile_success"))
 	ctx.Redirect(setting.AppSubURL + "/admin/users/" + ctx.Params(":userid"))
@@ -292,6 +310,13 @@ func DeleteUser(ctx *context.Context) {
 		return
 	}
 	log.Trace("Account deleted by admin (%s): %s", ctx.User.Name, u.Name)
+	notification.NotifyDeleteUser(ctx.User, u)
+	audit.Record(ctx.User, ctx.RemoteAddr(), &models.AuditEvent{
+		Action:     models.AuditUserDelete,
+		TargetType: models.AuditTargetUser,
+		TargetID:   u.ID,
+		TargetName: u.Name,
+	})
 
 	ctx.Flash.Success(ctx.Tr("admin.users.deletion_success"))
 	ctx.JSON(200, map[string]interface{}{
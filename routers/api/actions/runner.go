@@ -0,0 +1,168 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package actions
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	api "code.gitea.io/gitea/modules/structs"
+	actions_service "code.gitea.io/gitea/services/actions"
+
+	"gitea.com/macaron/binding"
+	"gitea.com/macaron/macaron"
+)
+
+// RegisterRoutes registers the routes of the runner protocol
+func RegisterRoutes(m *macaron.Macaron) {
+	bind := binding.Bind
+
+	m.Group("/actions/runner", func() {
+		m.Post("/fetch", FetchTask)
+		m.Group("/jobs/:id", func() {
+			m.Post("/logs", AppendLog)
+			m.Post("/steps/:index", bind(api.ActionStatusOption{}), UpdateStep)
+			m.Post("/status", bind(api.ActionStatusOption{}), UpdateJob)
+		}, jobAssignment())
+	}, context.APIContexter(), runnerAuth())
+}
+
+// runnerAuth authenticates the runner by the token of the Authorization header
+func runnerAuth() macaron.Handler {
+	return func(ctx *context.APIContext) {
+		auth := ctx.Req.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "Bearer ") {
+			ctx.Error(http.StatusUnauthorized, "runnerAuth", "runner token required")
+			return
+		}
+		runner, err := models.GetActionRunnerByToken(strings.TrimPrefix(auth, "Bearer "))
+		if err != nil {
+			if models.IsErrActionRunnerNotExist(err) {
+				ctx.Error(http.StatusUnauthorized, "runnerAuth", "invalid runner token")
+			} else {
+				ctx.Error(http.StatusInternalServerError, "GetActionRunnerByToken", err)
+			}
+			return
+		}
+		if err := models.UpdateActionRunnerLastOnline(runner); err != nil {
+			log.Error("UpdateActionRunnerLastOnline: %v", err)
+		}
+		ctx.Data["ActionRunner"] = runner
+	}
+}
+
+// jobAssignment loads the job of the request, it must be running on the runner
+func jobAssignment() macaron.Handler {
+	return func(ctx *context.APIContext) {
+		runner := ctx.Data["ActionRunner"].(*models.ActionRunner)
+		job, err := models.GetActionRunJobByID(ctx.ParamsInt64(":id"))
+		if err != nil {
+			if models.IsErrActionRunJobNotExist(err) {
+				ctx.NotFound()
+			} else {
+				ctx.Error(http.StatusInternalServerError, "GetActionRunJobByID", err)
+			}
+			return
+		}
+		if job.RunnerID != runner.ID {
+			ctx.NotFound()
+			return
+		}
+		// the job was cancelled or failed by the server, the runner has to stop it
+		if job.Status != models.ActionStatusRunning {
+			ctx.Error(http.StatusConflict, "jobAssignment", "job is "+job.Status.String())
+			return
+		}
+		if err := models.TouchActionRunJob(job); err != nil {
+			log.Error("TouchActionRunJob: %v", err)
+		}
+		ctx.Data["ActionRunJob"] = job
+	}
+}
+
+// FetchTask waits for a job the runner can run and hands it over, it responds
+// with 204 if there was none until the long poll timeout
+func FetchTask(ctx *context.APIContext) {
+	runner := ctx.Data["ActionRunner"].(*models.ActionRunner)
+	job, err := actions_service.FetchJob(ctx.Req.Context(), runner)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FetchJob", err)
+		return
+	}
+	if job == nil {
+		ctx.Status(http.StatusNoContent)
+		return
+	}
+
+	run := job.Run
+	ctx.JSON(http.StatusOK, &api.ActionTask{
+		ID:         job.ID,
+		RunIndex:   run.Index,
+		Repository: run.Repo.FullName(),
+		CloneURL:   run.Repo.CloneLink().HTTPS,
+		Event:      run.Event,
+		Ref:        run.Ref,
+		SHA:        run.CommitSHA,
+		Workflow:   run.WorkflowID,
+		JobID:      job.JobID,
+		Job:        json.RawMessage(job.Payload),
+	})
+}
+
+// AppendLog appends the request body to the log of the job
+func AppendLog(ctx *context.APIContext) {
+	job := ctx.Data["ActionRunJob"].(*models.ActionRunJob)
+	defer ctx.Req.Request.Body.Close()
+	if err := actions_service.AppendLog(job, ctx.Req.Request.Body); err != nil {
+		ctx.Error(http.StatusInternalServerError, "AppendLog", err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func parseStatus(ctx *context.APIContext, name string) (models.ActionStatus, bool) {
+	status, ok := models.ActionStatusFromString(name)
+	if !ok || status == models.ActionStatusWaiting || status == models.ActionStatusBlocked {
+		ctx.Error(http.StatusUnprocessableEntity, "parseStatus", "invalid status "+name)
+		return models.ActionStatusUnknown, false
+	}
+	return status, true
+}
+
+// UpdateStep updates the status of a step of the job
+func UpdateStep(ctx *context.APIContext, form api.ActionStatusOption) {
+	job := ctx.Data["ActionRunJob"].(*models.ActionRunJob)
+	status, ok := parseStatus(ctx, form.Status)
+	if !ok {
+		return
+	}
+	if err := models.UpdateActionRunStep(job, ctx.ParamsInt(":index"), status); err != nil {
+		ctx.Error(http.StatusInternalServerError, "UpdateActionRunStep", err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// UpdateJob reports the job as done, a running job only signals the runner is alive
+func UpdateJob(ctx *context.APIContext, form api.ActionStatusOption) {
+	job := ctx.Data["ActionRunJob"].(*models.ActionRunJob)
+	status, ok := parseStatus(ctx, form.Status)
+	if !ok {
+		return
+	}
+	if !status.IsDone() {
+		ctx.Status(http.StatusNoContent)
+		return
+	}
+	if err := actions_service.UpdateJobStatus(job, status); err != nil {
+		ctx.Error(http.StatusInternalServerError, "UpdateJobStatus", err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,52 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package packages
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/routers/api/packages/generic"
+	"code.gitea.io/gitea/routers/api/packages/helper"
+	"code.gitea.io/gitea/routers/api/packages/maven"
+	"code.gitea.io/gitea/routers/api/packages/npm"
+
+	"gitea.com/macaron/macaron"
+)
+
+// reqPackageTokenScope requires the access token of the request, if any, to grant the package scope
+func reqPackageTokenScope() macaron.Handler {
+	return func(ctx *context.APIContext) {
+		token, ok := ctx.Data["ApiToken"].(*models.AccessToken)
+		if !ok || token.Scope.HasAny(models.AccessTokenScopePackage) {
+			return
+		}
+		ctx.Error(http.StatusForbidden, "reqPackageTokenScope", "token does not have the package scope")
+	}
+}
+
+// RegisterRoutes registers the routes of the package registry protocols
+func RegisterRoutes(m *macaron.Macaron) {
+	m.Group("/packages/:username", func() {
+		m.Group("/generic", func() {
+			m.Group("/:packagename/:packageversion", func() {
+				m.Delete("", context.RequirePackageAccessAPI(models.AccessModeWrite, true), generic.DeletePackageVersion)
+				m.Get("/:filename", generic.DownloadPackageFile)
+				m.Put("/:filename", context.RequirePackageAccessAPI(models.AccessModeWrite, true), helper.LimitUploadSize(), generic.UploadPackageFile)
+				m.Delete("/:filename", context.RequirePackageAccessAPI(models.AccessModeWrite, true), generic.DeletePackageFile)
+			})
+		})
+		m.Group("/npm", func() {
+			m.Get("/:packagename", npm.PackageMetadata)
+			m.Put("/:packagename", context.RequirePackageAccessAPI(models.AccessModeWrite, true), helper.LimitUploadSize(), npm.UploadPackage)
+			m.Get("/:packagename/-/:packageversion/:filename", npm.DownloadPackageFile)
+		})
+		m.Group("/maven", func() {
+			m.Get("/*", maven.DownloadPackageFile)
+			m.Put("/*", context.RequirePackageAccessAPI(models.AccessModeWrite, true), helper.LimitUploadSize(), maven.UploadPackageFile)
+		})
+	}, context.APIContexter(), reqPackageTokenScope(), context.PackageAssignmentAPI(), context.RequirePackageAccessAPI(models.AccessModeRead, true))
+}
@@ -0,0 +1,152 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package generic
+
+import (
+	"net/http"
+	"regexp"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/packages"
+	"code.gitea.io/gitea/routers/api/packages/helper"
+)
+
+// names, versions and filenames of generic packages share the same set of characters
+var nameRegex = regexp.MustCompile(`\A[A-Za-z0-9\.\_\-\+]+\z`)
+
+func params(ctx *context.APIContext) (name, version, filename string, ok bool) {
+	name, version, filename = ctx.Params(":packagename"), ctx.Params(":packageversion"), ctx.Params(":filename")
+	if !nameRegex.MatchString(name) || !nameRegex.MatchString(version) ||
+		(filename != "" && !nameRegex.MatchString(filename)) {
+		ctx.Error(http.StatusBadRequest, "params", "invalid package name, version or filename")
+		return "", "", "", false
+	}
+	return name, version, filename, true
+}
+
+// DownloadPackageFile serves a file of a generic package
+func DownloadPackageFile(ctx *context.APIContext) {
+	name, version, filename, ok := params(ctx)
+	if !ok {
+		return
+	}
+
+	_, pv := helper.GetPackageVersion(ctx, models.PackageTypeGeneric, name, version)
+	if ctx.Written() {
+		return
+	}
+
+	pf, err := models.GetPackageFile(pv.ID, filename)
+	if err != nil {
+		if models.IsErrPackageFileNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetPackageFile", err)
+		}
+		return
+	}
+
+	helper.ServePackageFile(ctx, pv, pf)
+}
+
+// UploadPackageFile adds the request body as file to a generic package, the
+// package and the version are created if they do not exist yet
+func UploadPackageFile(ctx *context.APIContext) {
+	name, version, filename, ok := params(ctx)
+	if !ok {
+		return
+	}
+
+	pv, _, err := models.GetOrCreatePackageVersion(&models.Package{
+		OwnerID: ctx.Package.Owner.ID,
+		Type:    models.PackageTypeGeneric,
+		Name:    name,
+	}, &models.PackageVersion{
+		CreatorID: ctx.User.ID,
+		Version:   version,
+	})
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetOrCreatePackageVersion", err)
+		return
+	}
+
+	defer ctx.Req.Request.Body.Close()
+	if _, err := packages.AddFile(pv, filename, ctx.Req.Request.Body); err != nil {
+		if models.IsErrPackageFileAlreadyExist(err) {
+			ctx.Error(http.StatusConflict, "AddFile", err)
+		} else if err == helper.ErrUploadTooLarge {
+			ctx.Error(http.StatusRequestEntityTooLarge, "AddFile", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "AddFile", err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusCreated)
+}
+
+// DeletePackageFile deletes a file of a generic package, the version is
+// deleted as well if it has no files left
+func DeletePackageFile(ctx *context.APIContext) {
+	name, version, filename, ok := params(ctx)
+	if !ok {
+		return
+	}
+
+	_, pv := helper.GetPackageVersion(ctx, models.PackageTypeGeneric, name, version)
+	if ctx.Written() {
+		return
+	}
+
+	pf, err := models.GetPackageFile(pv.ID, filename)
+	if err != nil {
+		if models.IsErrPackageFileNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetPackageFile", err)
+		}
+		return
+	}
+
+	if err := models.DeletePackageFile(pf); err != nil {
+		ctx.Error(http.StatusInternalServerError, "DeletePackageFile", err)
+		return
+	}
+
+	files, err := models.GetPackageFiles(pv.ID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetPackageFiles", err)
+		return
+	}
+	if len(files) == 0 {
+		if err := models.DeletePackageVersion(pv); err != nil {
+			ctx.Error(http.StatusInternalServerError, "DeletePackageVersion", err)
+			return
+		}
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// DeletePackageVersion deletes a version of a generic package with all its files
+func DeletePackageVersion(ctx *context.APIContext) {
+	name, version, _, ok := params(ctx)
+	if !ok {
+		return
+	}
+
+	_, pv := helper.GetPackageVersion(ctx, models.PackageTypeGeneric, name, version)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.DeletePackageVersion(pv); err != nil {
+		ctx.Error(http.StatusInternalServerError, "DeletePackageVersion", err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
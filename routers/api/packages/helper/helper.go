@@ -0,0 +1,101 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package helper
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/packages"
+	"code.gitea.io/gitea/modules/setting"
+
+	"gitea.com/macaron/macaron"
+)
+
+// ErrUploadTooLarge is returned while reading an upload larger than the maximum file size of packages
+var ErrUploadTooLarge = errors.New("upload exceeds the maximum file size of packages")
+
+// LimitUploadSize returns a middleware rejecting uploads larger than the maximum file size of packages.
+// Uploads without a content length fail with ErrUploadTooLarge once they are read past the limit.
+func LimitUploadSize() macaron.Handler {
+	return func(ctx *context.APIContext) {
+		maxSize := setting.Packages.MaxFileSize
+		if maxSize <= 0 {
+			return
+		}
+		if ctx.Req.Request.ContentLength > maxSize {
+			ctx.Error(http.StatusRequestEntityTooLarge, "LimitUploadSize", ErrUploadTooLarge)
+			return
+		}
+		ctx.Req.Request.Body = &limitedBody{ReadCloser: ctx.Req.Request.Body, remaining: maxSize}
+	}
+}
+
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, ErrUploadTooLarge
+	}
+	// read one byte more than allowed to tell a body of exactly the maximum size from a larger one
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return n, ErrUploadTooLarge
+	}
+	return n, err
+}
+
+// ServePackageFile sends the content of the file and counts the download of the version
+func ServePackageFile(ctx *context.APIContext, pv *models.PackageVersion, pf *models.PackageFile) {
+	f, err := packages.OpenFile(pf)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "OpenFile", err)
+		return
+	}
+	defer f.Close()
+
+	if err := models.IncreasePackageVersionDownloadCount(pv); err != nil {
+		log.Error("IncreasePackageVersionDownloadCount: %v", err)
+	}
+
+	ctx.ServeContent(pf.Name, f, pf.CreatedUnix.AsTime())
+}
+
+// GetPackageVersion returns the version of the package of the owner and
+// responds with 404 if the package or the version do not exist
+func GetPackageVersion(ctx *context.APIContext, packageType models.PackageType, name, version string) (*models.Package, *models.PackageVersion) {
+	p, err := models.GetPackageByName(ctx.Package.Owner.ID, packageType, name)
+	if err != nil {
+		if models.IsErrPackageNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetPackageByName", err)
+		}
+		return nil, nil
+	}
+	p.Owner = ctx.Package.Owner
+
+	pv, err := models.GetPackageVersion(p.ID, version)
+	if err != nil {
+		if models.IsErrPackageVersionNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetPackageVersion", err)
+		}
+		return nil, nil
+	}
+	return p, pv
+}
@@ -0,0 +1,299 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package maven
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/xml"
+	"hash"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/packages"
+	"code.gitea.io/gitea/routers/api/packages/helper"
+)
+
+const (
+	metadataFilename = "maven-metadata.xml"
+	xmlHeader        = `<?xml version="1.0" encoding="UTF-8"?>` + "\n"
+)
+
+var (
+	// https://maven.apache.org/guides/mini/guide-naming-conventions.html
+	partRegex = regexp.MustCompile(`\A[A-Za-z0-9\.\_\-\+]+\z`)
+
+	checksumExtensions = []string{".md5", ".sha1", ".sha256", ".sha512"}
+)
+
+// artifactPath is a path of the repository layout:
+// group/id/parts/artifactId/version/filename or group/id/parts/artifactId/maven-metadata.xml
+type artifactPath struct {
+	GroupID    string
+	ArtifactID string
+	Version    string
+	Filename   string
+	Checksum   string
+}
+
+// IsMetadata reports whether the path points to the metadata of the artifact
+func (p *artifactPath) IsMetadata() bool {
+	return p.Version == "" && p.Filename == metadataFilename
+}
+
+// PackageName returns the name of the package the artifact is stored in
+func (p *artifactPath) PackageName() string {
+	return p.GroupID + ":" + p.ArtifactID
+}
+
+func parsePath(ctx *context.APIContext) (*artifactPath, bool) {
+	parts := strings.Split(strings.Trim(ctx.Params("*"), "/"), "/")
+	for _, part := range parts {
+		if !partRegex.MatchString(part) {
+			ctx.Error(http.StatusBadRequest, "parsePath", "invalid path")
+			return nil, false
+		}
+	}
+
+	p := &artifactPath{Filename: parts[len(parts)-1]}
+	for _, ext := range checksumExtensions {
+		if strings.HasSuffix(p.Filename, ext) {
+			p.Checksum = ext[1:]
+			p.Filename = strings.TrimSuffix(p.Filename, ext)
+			break
+		}
+	}
+
+	if p.Filename == metadataFilename {
+		if len(parts) < 3 {
+			ctx.Error(http.StatusBadRequest, "parsePath", "invalid path")
+			return nil, false
+		}
+		p.ArtifactID = parts[len(parts)-2]
+		p.GroupID = strings.Join(parts[:len(parts)-2], ".")
+		return p, true
+	}
+
+	if len(parts) < 4 {
+		ctx.Error(http.StatusBadRequest, "parsePath", "invalid path")
+		return nil, false
+	}
+	p.Version = parts[len(parts)-2]
+	p.ArtifactID = parts[len(parts)-3]
+	p.GroupID = strings.Join(parts[:len(parts)-3], ".")
+	return p, true
+}
+
+func newChecksumHash(checksum string) hash.Hash {
+	switch checksum {
+	case "md5":
+		return md5.New()
+	case "sha1":
+		return sha1.New()
+	case "sha256":
+		return sha256.New()
+	default:
+		return sha512.New()
+	}
+}
+
+func blobChecksum(blob *models.PackageBlob, checksum string) string {
+	switch checksum {
+	case "md5":
+		return blob.HashMD5
+	case "sha1":
+		return blob.HashSHA1
+	case "sha256":
+		return blob.HashSHA256
+	default:
+		return blob.HashSHA512
+	}
+}
+
+type metadataVersioning struct {
+	Latest      string   `xml:"latest"`
+	Release     string   `xml:"release"`
+	Versions    []string `xml:"versions>version"`
+	LastUpdated string   `xml:"lastUpdated"`
+}
+
+type metadata struct {
+	XMLName    xml.Name           `xml:"metadata"`
+	GroupID    string             `xml:"groupId"`
+	ArtifactID string             `xml:"artifactId"`
+	Versioning metadataVersioning `xml:"versioning"`
+}
+
+// DownloadPackageFile serves a file of the repository layout, the artifact
+// metadata and all checksums are generated on the fly
+func DownloadPackageFile(ctx *context.APIContext) {
+	ap, ok := parsePath(ctx)
+	if !ok {
+		return
+	}
+
+	if ap.IsMetadata() {
+		serveMetadata(ctx, ap)
+		return
+	}
+
+	_, pv := helper.GetPackageVersion(ctx, models.PackageTypeMaven, ap.PackageName(), ap.Version)
+	if ctx.Written() {
+		return
+	}
+
+	pf, err := models.GetPackageFile(pv.ID, ap.Filename)
+	if err != nil {
+		if models.IsErrPackageFileNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetPackageFile", err)
+		}
+		return
+	}
+
+	if ap.Checksum != "" {
+		ctx.PlainText(http.StatusOK, []byte(blobChecksum(pf.Blob, ap.Checksum)))
+		return
+	}
+
+	helper.ServePackageFile(ctx, pv, pf)
+}
+
+func serveMetadata(ctx *context.APIContext, ap *artifactPath) {
+	p, err := models.GetPackageByName(ctx.Package.Owner.ID, models.PackageTypeMaven, ap.PackageName())
+	if err != nil {
+		if models.IsErrPackageNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetPackageByName", err)
+		}
+		return
+	}
+
+	versions, err := models.GetPackageVersions(p.ID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetPackageVersions", err)
+		return
+	}
+
+	m := &metadata{
+		GroupID:    ap.GroupID,
+		ArtifactID: ap.ArtifactID,
+		Versioning: metadataVersioning{
+			LastUpdated: p.UpdatedUnix.AsTime().UTC().Format("20060102150405"),
+		},
+	}
+	// versions are sorted newest first, the metadata lists them oldest first
+	for i := len(versions) - 1; i >= 0; i-- {
+		m.Versioning.Versions = append(m.Versioning.Versions, versions[i].Version)
+	}
+	if len(versions) > 0 {
+		m.Versioning.Latest = versions[0].Version
+		for _, pv := range versions {
+			if !strings.HasSuffix(pv.Version, "-SNAPSHOT") {
+				m.Versioning.Release = pv.Version
+				break
+			}
+		}
+	}
+
+	body, err := xml.MarshalIndent(m, "", "  ")
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "MarshalIndent", err)
+		return
+	}
+	body = append([]byte(xmlHeader), body...)
+
+	if ap.Checksum != "" {
+		h := newChecksumHash(ap.Checksum)
+		_, _ = h.Write(body)
+		ctx.PlainText(http.StatusOK, []byte(hex.EncodeToString(h.Sum(nil))))
+		return
+	}
+
+	ctx.Resp.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	ctx.Resp.WriteHeader(http.StatusOK)
+	_, _ = ctx.Resp.Write(body)
+}
+
+// UploadPackageFile stores a file of the repository layout. The metadata and
+// the checksums maven uploads after every artifact are generated on download,
+// checksums are compared against the stored files.
+func UploadPackageFile(ctx *context.APIContext) {
+	ap, ok := parsePath(ctx)
+	if !ok {
+		return
+	}
+	defer ctx.Req.Request.Body.Close()
+
+	if ap.IsMetadata() {
+		ctx.Status(http.StatusOK)
+		return
+	}
+
+	if ap.Checksum != "" {
+		_, pv := helper.GetPackageVersion(ctx, models.PackageTypeMaven, ap.PackageName(), ap.Version)
+		if ctx.Written() {
+			return
+		}
+		pf, err := models.GetPackageFile(pv.ID, ap.Filename)
+		if err != nil {
+			if models.IsErrPackageFileNotExist(err) {
+				ctx.NotFound()
+			} else {
+				ctx.Error(http.StatusInternalServerError, "GetPackageFile", err)
+			}
+			return
+		}
+
+		checksum, err := ctx.Req.Body().String()
+		if err == helper.ErrUploadTooLarge {
+			ctx.Error(http.StatusRequestEntityTooLarge, "Body", err)
+			return
+		} else if err != nil {
+			ctx.Error(http.StatusInternalServerError, "Body", err)
+			return
+		}
+		if !strings.EqualFold(strings.TrimSpace(checksum), blobChecksum(pf.Blob, ap.Checksum)) {
+			ctx.Error(http.StatusBadRequest, "UploadPackageFile", "checksum mismatch")
+			return
+		}
+		ctx.Status(http.StatusOK)
+		return
+	}
+
+	pv, _, err := models.GetOrCreatePackageVersion(&models.Package{
+		OwnerID: ctx.Package.Owner.ID,
+		Type:    models.PackageTypeMaven,
+		Name:    ap.PackageName(),
+	}, &models.PackageVersion{
+		CreatorID: ctx.User.ID,
+		Version:   ap.Version,
+	})
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetOrCreatePackageVersion", err)
+		return
+	}
+
+	if _, err := packages.AddFile(pv, ap.Filename, ctx.Req.Request.Body); err != nil {
+		if models.IsErrPackageFileAlreadyExist(err) {
+			ctx.Error(http.StatusConflict, "AddFile", err)
+		} else if err == helper.ErrUploadTooLarge {
+			ctx.Error(http.StatusRequestEntityTooLarge, "AddFile", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "AddFile", err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusCreated)
+}
@@ -0,0 +1,231 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package npm
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/packages"
+	"code.gitea.io/gitea/routers/api/packages/helper"
+)
+
+var (
+	// https://github.com/npm/validate-npm-package-name
+	nameRegex = regexp.MustCompile(`\A(@[a-z0-9\-~][a-z0-9\-\._~]*/)?[a-z0-9\-~][a-z0-9\-\._~]*\z`)
+	// https://semver.org/#is-there-a-suggested-regular-expression-regex-to-check-a-semver-string
+	versionRegex = regexp.MustCompile(`\A(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?\z`)
+)
+
+// packageMetadata is the document the npm client requests for a package
+type packageMetadata struct {
+	ID       string                            `json:"_id"`
+	Name     string                            `json:"name"`
+	DistTags map[string]string                 `json:"dist-tags"`
+	Versions map[string]map[string]interface{} `json:"versions"`
+	Time     map[string]time.Time              `json:"time"`
+}
+
+// publishRequest is the document the npm client sends to publish a version
+type publishRequest struct {
+	Name        string                            `json:"name"`
+	DistTags    map[string]string                 `json:"dist-tags"`
+	Versions    map[string]map[string]interface{} `json:"versions"`
+	Attachments map[string]*attachment            `json:"_attachments"`
+}
+
+type attachment struct {
+	ContentType string `json:"content_type"`
+	Data        string `json:"data"`
+	Length      int    `json:"length"`
+}
+
+// PackageMetadata returns the metadata of all versions of a package
+func PackageMetadata(ctx *context.APIContext) {
+	name := ctx.Params(":packagename")
+
+	p, err := models.GetPackageByName(ctx.Package.Owner.ID, models.PackageTypeNpm, name)
+	if err != nil {
+		if models.IsErrPackageNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetPackageByName", err)
+		}
+		return
+	}
+	p.Owner = ctx.Package.Owner
+
+	versions, err := models.GetPackageVersions(p.ID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetPackageVersions", err)
+		return
+	}
+
+	resp := &packageMetadata{
+		ID:       p.Name,
+		Name:     p.Name,
+		DistTags: make(map[string]string),
+		Versions: make(map[string]map[string]interface{}, len(versions)),
+		Time:     make(map[string]time.Time, len(versions)),
+	}
+	for i, pv := range versions {
+		files, err := models.GetPackageFiles(pv.ID)
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "GetPackageFiles", err)
+			return
+		}
+		if len(files) == 0 {
+			continue
+		}
+
+		manifest := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(pv.MetadataJSON), &manifest); err != nil {
+			ctx.Error(http.StatusInternalServerError, "Unmarshal", err)
+			return
+		}
+		manifest["dist"] = map[string]string{
+			"tarball":   packages.FileURL(p, pv, files[0].Name),
+			"shasum":    files[0].Blob.HashSHA1,
+			"integrity": integrity(files[0].Blob),
+		}
+		resp.Versions[pv.Version] = manifest
+		resp.Time[pv.Version] = pv.CreatedUnix.AsTime()
+
+		// versions are sorted newest first
+		if i == 0 {
+			resp.DistTags["latest"] = pv.Version
+		}
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// integrity returns the subresource integrity string of the blob
+func integrity(blob *models.PackageBlob) string {
+	sum, err := hex.DecodeString(blob.HashSHA512)
+	if err != nil {
+		return ""
+	}
+	return "sha512-" + base64.StdEncoding.EncodeToString(sum)
+}
+
+// DownloadPackageFile serves the tarball of a package version
+func DownloadPackageFile(ctx *context.APIContext) {
+	_, pv := helper.GetPackageVersion(ctx, models.PackageTypeNpm, ctx.Params(":packagename"), ctx.Params(":packageversion"))
+	if ctx.Written() {
+		return
+	}
+
+	pf, err := models.GetPackageFile(pv.ID, ctx.Params(":filename"))
+	if err != nil {
+		if models.IsErrPackageFileNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetPackageFile", err)
+		}
+		return
+	}
+
+	helper.ServePackageFile(ctx, pv, pf)
+}
+
+// UploadPackage publishes a new version of a package
+func UploadPackage(ctx *context.APIContext) {
+	name := ctx.Params(":packagename")
+
+	var req publishRequest
+	if err := json.NewDecoder(ctx.Req.Request.Body).Decode(&req); err != nil {
+		if err == helper.ErrUploadTooLarge {
+			ctx.Error(http.StatusRequestEntityTooLarge, "Decode", err)
+		} else {
+			ctx.Error(http.StatusBadRequest, "Decode", err)
+		}
+		return
+	}
+
+	if req.Name != name || !nameRegex.MatchString(name) {
+		ctx.Error(http.StatusBadRequest, "UploadPackage", "invalid package name")
+		return
+	}
+	if len(req.Versions) != 1 || len(req.Attachments) != 1 {
+		ctx.Error(http.StatusBadRequest, "UploadPackage", "exactly one version and one attachment must be published")
+		return
+	}
+
+	var version string
+	var manifest map[string]interface{}
+	for v, m := range req.Versions {
+		version, manifest = v, m
+	}
+	if !versionRegex.MatchString(version) {
+		ctx.Error(http.StatusBadRequest, "UploadPackage", "invalid package version")
+		return
+	}
+
+	var filename string
+	var att *attachment
+	for f, a := range req.Attachments {
+		filename, att = f, a
+	}
+	data, err := base64.StdEncoding.DecodeString(att.Data)
+	if err != nil {
+		ctx.Error(http.StatusBadRequest, "DecodeString", err)
+		return
+	}
+
+	// the dist information is generated from the stored file
+	delete(manifest, "dist")
+	metadata, err := json.Marshal(manifest)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "Marshal", err)
+		return
+	}
+
+	pv, created, err := models.GetOrCreatePackageVersion(&models.Package{
+		OwnerID: ctx.Package.Owner.ID,
+		Type:    models.PackageTypeNpm,
+		Name:    name,
+	}, &models.PackageVersion{
+		CreatorID:    ctx.User.ID,
+		Version:      version,
+		MetadataJSON: string(metadata),
+	})
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetOrCreatePackageVersion", err)
+		return
+	}
+	if !created {
+		ctx.Error(http.StatusBadRequest, "UploadPackage", fmt.Sprintf("version %s already exists", version))
+		return
+	}
+
+	if _, err := packages.AddFile(pv, packageFilename(filename), bytes.NewReader(data)); err != nil {
+		if err := models.DeletePackageVersion(pv); err != nil {
+			ctx.Error(http.StatusInternalServerError, "DeletePackageVersion", err)
+			return
+		}
+		ctx.Error(http.StatusInternalServerError, "AddFile", err)
+		return
+	}
+
+	ctx.Status(http.StatusCreated)
+}
+
+// packageFilename strips the scope of scoped package tarballs
+func packageFilename(filename string) string {
+	if i := strings.LastIndex(filename, "/"); i >= 0 {
+		return filename[i+1:]
+	}
+	return filename
+}
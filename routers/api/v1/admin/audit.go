@@ -0,0 +1,86 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package admin
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+)
+
+// ListAuditEvents list the events of the audit log
+func ListAuditEvents(ctx *context.APIContext) {
+	// swagger:operation GET /admin/audit admin adminListAuditEvents
+	// ---
+	// summary: List the events of the audit log, the latest first
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: actor
+	//   in: query
+	//   description: username of the user who performed the actions
+	//   type: string
+	// - name: repo
+	//   in: query
+	//   description: full name ("owner/repo") of the repository the actions concern
+	//   type: string
+	// - name: org
+	//   in: query
+	//   description: name of the organization the actions concern
+	//   type: string
+	// - name: action
+	//   in: query
+	//   description: type of the actions
+	//   type: string
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/AuditEventList"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	action := ctx.QueryTrim("action")
+	if action != "" && !models.IsValidAuditAction(action) {
+		ctx.Error(http.StatusUnprocessableEntity, "", fmt.Errorf("invalid action: %s", action))
+		return
+	}
+
+	opts, err := audit.FilterOptions(ctx.QueryTrim("actor"), ctx.QueryTrim("repo"), ctx.QueryTrim("org"), models.AuditAction(action))
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FilterOptions", err)
+		return
+	}
+	opts.ListOptions = utils.GetListOptions(ctx)
+
+	events, count, err := models.FindAuditEvents(opts)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FindAuditEvents", err)
+		return
+	}
+
+	apiEvents := make([]*api.AuditEvent, len(events))
+	for i := range events {
+		apiEvents[i] = convert.ToAuditEvent(events[i])
+	}
+
+	ctx.SetLinkHeader(int(count), opts.PageSize)
+	ctx.Header().Set("X-Total-Count", fmt.Sprintf("%d", count))
+	ctx.JSON(http.StatusOK, &apiEvents)
+}
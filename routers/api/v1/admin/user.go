@@ -11,9 +11,11 @@ import (
 	"net/http"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/convert"
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification"
 	"code.gitea.io/gitea/modules/password"
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/routers/api/v1/user"
@@ -100,6 +102,13 @@ func CreateUser(ctx *context.APIContext, form api.CreateUserOption) {
 		return
 	}
 	log.Trace("Account created by admin (%s): %s", ctx.User.Name, u.Name)
+	notification.NotifyCreateUser(ctx.User, u)
+	audit.Record(ctx.User, ctx.RemoteAddr(), &models.AuditEvent{
+		Action:     models.AuditUserCreate,
+		TargetType: models.AuditTargetUser,
+		TargetID:   u.ID,
+		TargetName: u.Name,
+	})
 
 	// Send email notification.
 	if form.SendNotify {
@@ -199,6 +208,14 @@ func EditUser(ctx *context.APIContext, form api.EditUserOption) {
 		return
 	}
 	log.Trace("Account profile updated by admin (%s): %s", ctx.User.Name, u.Name)
+	audit.Record(ctx.User, ctx.RemoteAddr(), &models.AuditEvent{
+		Action:     models.AuditUserEdit,
+		TargetType: models.AuditTargetUser,
+		TargetID:   u.ID,
+		TargetName: u.Name,
+		Details: fmt.Sprintf("admin: %t, restricted: %t, active: %t, prohibit login: %t, password changed: %t",
+			u.IsAdmin, u.IsRestricted, u.IsActive, u.ProhibitLogin, len(form.Password) > 0),
+	})
 
 	ctx.JSON(http.StatusOK, convert.ToUser(u, ctx.IsSigned, ctx.User.IsAdmin))
 }
@@ -244,6 +261,13 @@ func DeleteUser(ctx *context.APIContext) {
 		return
 	}
 	log.Trace("Account deleted by admin(%s): %s", ctx.User.Name, u.Name)
+	notification.NotifyDeleteUser(ctx.User, u)
+	audit.Record(ctx.User, ctx.RemoteAddr(), &models.AuditEvent{
+		Action:     models.AuditUserDelete,
+		TargetType: models.AuditTargetUser,
+		TargetID:   u.ID,
+		TargetName: u.Name,
+	})
 
 	ctx.Status(http.StatusNoContent)
 }
@@ -59,6 +59,7 @@
 package v1
 
 import (
+	"fmt"
 	"net/http"
 	"strings"
 
@@ -72,6 +73,8 @@ import (
 	"code.gitea.io/gitea/routers/api/v1/misc"
 	"code.gitea.io/gitea/routers/api/v1/notify"
 	"code.gitea.io/gitea/routers/api/v1/org"
+	"code.gitea.io/gitea/routers/api/v1/packages"
+	"code.gitea.io/gitea/routers/api/v1/project"
 	"code.gitea.io/gitea/routers/api/v1/repo"
 	"code.gitea.io/gitea/routers/api/v1/settings"
 	_ "code.gitea.io/gitea/routers/api/v1/swagger" // for swagger generation
@@ -89,6 +92,12 @@ func sudo() macaron.Handler {
 		}
 
 		if len(sudo) > 0 {
+			if token, ok := ctx.Data["ApiToken"].(*models.AccessToken); ok && !token.Scope.Has(models.AccessTokenScopeAdmin) {
+				ctx.JSON(http.StatusForbidden, map[string]string{
+					"message": "Only tokens with the admin scope are allowed to sudo.",
+				})
+				return
+			}
 			if ctx.IsSigned && ctx.User.IsAdmin {
 				user, err := models.GetUserByName(sudo)
 				if err != nil {
@@ -155,6 +164,11 @@ func repoAssignment() macaron.Handler {
 			return
 		}
 
+		if token, ok := ctx.Data["ApiToken"].(*models.AccessToken); ok && !token.CanAccessRepo(repo.ID) {
+			ctx.NotFound()
+			return
+		}
+
 		repo.Owner = owner
 		ctx.Repo.Repository = repo
 
@@ -189,12 +203,71 @@ func reqToken() macaron.Handler {
 	}
 }
 
+// reqTokenScope requires the access token of the request, if any, to grant at least one of the scopes
+func reqTokenScope(scopes ...models.AccessTokenScope) macaron.Handler {
+	return func(ctx *context.APIContext) {
+		token, ok := ctx.Data["ApiToken"].(*models.AccessToken)
+		if !ok || token.Scope.HasAny(scopes...) {
+			return
+		}
+		ctx.Error(http.StatusForbidden, "reqTokenScope", fmt.Sprintf("token does not have at least one of the required scopes: %v", scopes))
+	}
+}
+
+// reqUnrestrictedToken requires the access token of the request, if any, to grant all the scopes
+// on all the repositories, as keys added to the account give access to all of its repositories
+func reqUnrestrictedToken() macaron.Handler {
+	return func(ctx *context.APIContext) {
+		token, ok := ctx.Data["ApiToken"].(*models.AccessToken)
+		if !ok || (token.Scope.Has(models.AccessTokenScopeAll) && len(token.RepoIDs) == 0) {
+			return
+		}
+		ctx.Error(http.StatusForbidden, "reqUnrestrictedToken", "token must not be restricted to some scopes or repositories")
+	}
+}
+
+// reqRepoTokenScope checks the access token of the request, if any, against the repository routes:
+// reading requires the repo:read scope and writing the repo:write scope. Issues, labels,
+// milestones and projects can also be accessed with the issue scope.
+func reqRepoTokenScope() macaron.Handler {
+	return func(ctx *context.APIContext) {
+		token, ok := ctx.Data["ApiToken"].(*models.AccessToken)
+		if !ok {
+			return
+		}
+
+		scopes := []models.AccessTokenScope{models.AccessTokenScopeRepoWrite}
+		if ctx.Req.Method == "GET" || ctx.Req.Method == "HEAD" {
+			scopes = []models.AccessTokenScope{models.AccessTokenScopeRepoRead}
+		}
+
+		// /api/v1/repos/{owner}/{repo}/{route}
+		parts := strings.SplitN(strings.TrimPrefix(ctx.Req.URL.Path, setting.AppSubURL+"/api/v1/repos/"), "/", 4)
+		if len(parts) >= 3 {
+			switch parts[2] {
+			case "issues", "labels", "milestones", "projects":
+				scopes = append(scopes, models.AccessTokenScopeIssue)
+			}
+		}
+
+		if !token.Scope.HasAny(scopes...) {
+			ctx.Error(http.StatusForbidden, "reqRepoTokenScope", fmt.Sprintf("token does not have at least one of the required scopes: %v", scopes))
+		}
+	}
+}
+
+// reqBasicAuth requires the user to authenticate with the password, a token passed
+// as the password would allow to create tokens with more scopes than its own
 func reqBasicAuth() macaron.Handler {
 	return func(ctx *context.APIContext) {
 		if !ctx.Context.IsBasicAuth {
 			ctx.Context.Error(http.StatusUnauthorized)
 			return
 		}
+		if true == ctx.Data["IsApiToken"] {
+			ctx.Error(http.StatusForbidden, "reqBasicAuth", "a password is required, tokens are not allowed")
+			return
+		}
 		ctx.CheckForOTP()
 	}
 }
@@ -409,6 +482,90 @@ func orgAssignment(args ...bool) macaron.Handler {
 	}
 }
 
+// projectAssignment loads the project given by the :id parameter and checks the doer can see it.
+// Projects of repositories follow the permissions of the projects unit, projects of organizations
+// are visible with the organization and can be changed by its owners.
+func projectAssignment() macaron.Handler {
+	return func(ctx *context.APIContext) {
+		p, err := models.GetProjectByID(ctx.ParamsInt64(":id"))
+		if err != nil {
+			if models.IsErrProjectNotExist(err) {
+				ctx.NotFound()
+			} else {
+				ctx.Error(http.StatusInternalServerError, "GetProjectByID", err)
+			}
+			return
+		}
+		if err := p.LoadAttributes(); err != nil {
+			ctx.Error(http.StatusInternalServerError, "LoadAttributes", err)
+			return
+		}
+
+		token, hasToken := ctx.Data["ApiToken"].(*models.AccessToken)
+		isGet := ctx.Req.Method == "GET" || ctx.Req.Method == "HEAD"
+
+		var canRead, canWrite bool
+		scopes := []models.AccessTokenScope{models.AccessTokenScopeIssue}
+		switch p.Type {
+		case models.ProjectTypeRepository:
+			if hasToken && !token.CanAccessRepo(p.RepoID) {
+				ctx.NotFound()
+				return
+			}
+			perm, err := models.GetUserRepoPermission(p.Repo, ctx.User)
+			if err != nil {
+				ctx.Error(http.StatusInternalServerError, "GetUserRepoPermission", err)
+				return
+			}
+			canRead = perm.CanRead(models.UnitTypeProjects)
+			canWrite = perm.CanWrite(models.UnitTypeProjects) && !p.Repo.IsArchived
+			if isGet {
+				scopes = append(scopes, models.AccessTokenScopeRepoRead)
+			} else {
+				scopes = append(scopes, models.AccessTokenScopeRepoWrite)
+			}
+		case models.ProjectTypeOrganization:
+			// organization projects span several repositories
+			if hasToken && len(token.RepoIDs) > 0 {
+				ctx.NotFound()
+				return
+			}
+			canRead = models.HasOrgVisible(p.Owner, ctx.User)
+			if ctx.IsSigned {
+				if canWrite, err = p.Owner.IsOwnedBy(ctx.User.ID); err != nil {
+					ctx.Error(http.StatusInternalServerError, "IsOwnedBy", err)
+					return
+				}
+			}
+			scopes = append(scopes, models.AccessTokenScopeOrg)
+		}
+		if ctx.IsUserSiteAdmin() {
+			canRead, canWrite = true, true
+		}
+		if !canRead {
+			ctx.NotFound()
+			return
+		}
+		if hasToken && !token.Scope.HasAny(scopes...) {
+			ctx.Error(http.StatusForbidden, "projectAssignment", fmt.Sprintf("token does not have at least one of the required scopes: %v", scopes))
+			return
+		}
+
+		ctx.Data["Project"] = p
+		ctx.Data["CanWriteProject"] = canWrite
+	}
+}
+
+// reqProjectWriter user should be able to change the project loaded by projectAssignment
+func reqProjectWriter() macaron.Handler {
+	return func(ctx *context.APIContext) {
+		if canWrite, _ := ctx.Data["CanWriteProject"].(bool); !canWrite {
+			ctx.Error(http.StatusForbidden, "reqProjectWriter", "user should be able to change the project")
+			return
+		}
+	}
+}
+
 func mustEnableIssues(ctx *context.APIContext) {
 	if !ctx.Repo.CanRead(models.UnitTypeIssues) {
 		if log.IsTrace() {
@@ -527,7 +684,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 			m.Combo("/threads/:id").
 				Get(notify.GetThread).
 				Patch(notify.ReadThread)
-		}, reqToken())
+		}, reqToken(), reqTokenScope(models.AccessTokenScopeUser))
 
 		// Users
 		m.Group("/users", func() {
@@ -561,45 +718,49 @@ func RegisterRoutes(m *macaron.Macaron) {
 
 				m.Get("/subscriptions", user.GetWatchedRepos)
 			})
-		}, reqToken())
+		}, reqToken(), reqTokenScope(models.AccessTokenScopeUser))
 
 		m.Group("/user", func() {
 			m.Get("", user.GetAuthenticatedUser)
-			m.Combo("/emails").Get(user.ListEmails).
-				Post(bind(api.CreateEmailOption{}), user.AddEmail).
-				Delete(bind(api.DeleteEmailOption{}), user.DeleteEmail)
-
-			m.Get("/followers", user.ListMyFollowers)
-			m.Group("/following", func() {
-				m.Get("", user.ListMyFollowing)
-				m.Combo("/:username").Get(user.CheckMyFollowing).Put(user.Follow).Delete(user.Unfollow)
-			})
+			m.Group("", func() {
+				m.Combo("/emails").Get(user.ListEmails).
+					Post(bind(api.CreateEmailOption{}), user.AddEmail).
+					Delete(bind(api.DeleteEmailOption{}), user.DeleteEmail)
 
-			m.Group("/keys", func() {
-				m.Combo("").Get(user.ListMyPublicKeys).
-					Post(bind(api.CreateKeyOption{}), user.CreatePublicKey)
-				m.Combo("/:id").Get(user.GetPublicKey).
-					Delete(user.DeletePublicKey)
-			})
-			m.Group("/applications", func() {
-				m.Combo("/oauth2").
-					Get(user.ListOauth2Applications).
-					Post(bind(api.CreateOAuth2ApplicationOptions{}), user.CreateOauth2Application)
-				m.Combo("/oauth2/:id").
-					Delete(user.DeleteOauth2Application).
-					Patch(bind(api.CreateOAuth2ApplicationOptions{}), user.UpdateOauth2Application).
-					Get(user.GetOauth2Application)
-			}, reqToken())
-
-			m.Group("/gpg_keys", func() {
-				m.Combo("").Get(user.ListMyGPGKeys).
-					Post(bind(api.CreateGPGKeyOption{}), user.CreateGPGKey)
-				m.Combo("/:id").Get(user.GetGPGKey).
-					Delete(user.DeleteGPGKey)
-			})
+				m.Get("/followers", user.ListMyFollowers)
+				m.Group("/following", func() {
+					m.Get("", user.ListMyFollowing)
+					m.Combo("/:username").Get(user.CheckMyFollowing).Put(user.Follow).Delete(user.Unfollow)
+				})
+
+				m.Group("/keys", func() {
+					m.Combo("").Get(user.ListMyPublicKeys).
+						Post(bind(api.CreateKeyOption{}), user.CreatePublicKey)
+					m.Combo("/:id").Get(user.GetPublicKey).
+						Delete(user.DeletePublicKey)
+				}, reqUnrestrictedToken())
+				m.Group("/applications", func() {
+					m.Combo("/oauth2").
+						Get(user.ListOauth2Applications).
+						Post(bind(api.CreateOAuth2ApplicationOptions{}), user.CreateOauth2Application)
+					m.Combo("/oauth2/:id").
+						Delete(user.DeleteOauth2Application).
+						Patch(bind(api.CreateOAuth2ApplicationOptions{}), user.UpdateOauth2Application).
+						Get(user.GetOauth2Application)
+				}, reqToken())
+
+				m.Group("/gpg_keys", func() {
+					m.Combo("").Get(user.ListMyGPGKeys).
+						Post(bind(api.CreateGPGKeyOption{}), user.CreateGPGKey)
+					m.Combo("/:id").Get(user.GetGPGKey).
+						Delete(user.DeleteGPGKey)
+				}, reqUnrestrictedToken())
+
+				m.Get("/subscriptions", user.GetMyWatchedRepos)
+			}, reqTokenScope(models.AccessTokenScopeUser))
 
-			m.Combo("/repos").Get(user.ListMyRepos).
-				Post(bind(api.CreateRepoOption{}), repo.Create)
+			m.Combo("/repos").Get(reqTokenScope(models.AccessTokenScopeRepoRead), user.ListMyRepos).
+				Post(reqTokenScope(models.AccessTokenScopeRepoWrite), bind(api.CreateRepoOption{}), repo.Create)
 
 			m.Group("/starred", func() {
 				m.Get("", user.GetMyStarredRepos)
@@ -608,27 +769,25 @@ func RegisterRoutes(m *macaron.Macaron) {
 					m.Put("", user.Star)
 					m.Delete("", user.Unstar)
 				}, repoAssignment())
-			})
-			m.Get("/times", repo.ListMyTrackedTimes)
-
-			m.Get("/stopwatches", repo.GetStopwatches)
+			}, reqTokenScope(models.AccessTokenScopeUser))
+			m.Get("/times", reqTokenScope(models.AccessTokenScopeIssue), repo.ListMyTrackedTimes)
 
-			m.Get("/subscriptions", user.GetMyWatchedRepos)
+			m.Get("/stopwatches", reqTokenScope(models.AccessTokenScopeIssue), repo.GetStopwatches)
 
-			m.Get("/teams", org.ListUserTeams)
+			m.Get("/teams", reqTokenScope(models.AccessTokenScopeOrg), org.ListUserTeams)
 		}, reqToken())
 
 		// Repositories
-		m.Post("/org/:org/repos", reqToken(), bind(api.CreateRepoOption{}), repo.CreateOrgRepoDeprecated)
+		m.Post("/org/:org/repos", reqToken(), reqTokenScope(models.AccessTokenScopeRepoWrite), bind(api.CreateRepoOption{}), repo.CreateOrgRepoDeprecated)
 
-		m.Combo("/repositories/:id", reqToken()).Get(repo.GetByID)
+		m.Combo("/repositories/:id", reqToken(), reqTokenScope(models.AccessTokenScopeRepoRead)).Get(repo.GetByID)
 
 		m.Group("/repos", func() {
-			m.Get("/search", repo.Search)
+			m.Get("/search", reqTokenScope(models.AccessTokenScopeRepoRead), repo.Search)
 
-			m.Get("/issues/search", repo.SearchIssues)
+			m.Get("/issues/search", reqTokenScope(models.AccessTokenScopeIssue, models.AccessTokenScopeRepoRead), repo.SearchIssues)
 
-			m.Post("/migrate", reqToken(), bind(auth.MigrateRepoForm{}), repo.Migrate)
+			m.Post("/migrate", reqToken(), reqTokenScope(models.AccessTokenScopeRepoWrite), bind(auth.MigrateRepoForm{}), repo.Migrate)
 
 			m.Group("/:username/:reponame", func() {
 				m.Combo("").Get(reqAnyRepoReader(), repo.Get).
@@ -646,6 +805,8 @@ func RegisterRoutes(m *macaron.Macaron) {
 							Patch(bind(api.EditHookOption{}), repo.EditHook).
 							Delete(repo.DeleteHook)
 						m.Post("/tests", context.RepoRef(), repo.TestHook)
+						m.Get("/deliveries", repo.ListHookDeliveries)
+						m.Post("/deliveries/:delivery_id/attempts", repo.RedeliverHookDelivery)
 					})
 					m.Group("/git", func() {
 						m.Combo("").Get(repo.ListGitHooks)
@@ -767,6 +928,8 @@ func RegisterRoutes(m *macaron.Macaron) {
 						Patch(reqToken(), reqRepoWriter(models.UnitTypeIssues, models.UnitTypePullRequests), bind(api.EditMilestoneOption{}), repo.EditMilestone).
 						Delete(reqToken(), reqRepoWriter(models.UnitTypeIssues, models.UnitTypePullRequests), repo.DeleteMilestone)
 				})
+				m.Combo("/projects", reqRepoReader(models.UnitTypeProjects)).Get(repo.ListProjects).
+					Post(reqToken(), reqRepoWriter(models.UnitTypeProjects), bind(api.CreateProjectOption{}), repo.CreateProject)
 				m.Get("/stargazers", repo.ListStargazers)
 				m.Get("/subscribers", repo.ListSubscribers)
 				m.Group("/subscription", func() {
@@ -791,6 +954,14 @@ func RegisterRoutes(m *macaron.Macaron) {
 					})
 				}, reqRepoReader(models.UnitTypeReleases))
 				m.Post("/mirror-sync", reqToken(), reqRepoWriter(models.UnitTypeCode), repo.MirrorSync)
+				m.Post("/push_mirrors-sync", reqToken(), reqAdmin(), repo.PushMirrorSync)
+				m.Group("/push_mirrors", func() {
+					m.Combo("").Get(repo.ListPushMirrors).
+						Post(bind(api.CreatePushMirrorOption{}), repo.AddPushMirror)
+					m.Combo("/:name").
+						Delete(repo.DeletePushMirrorByName).
+						Get(repo.GetPushMirrorByName)
+				}, reqToken(), reqAdmin())
 				m.Get("/editorconfig/:filename", context.RepoRef(), reqRepoReader(models.UnitTypeCode), repo.GetEditorconfig)
 				m.Group("/pulls", func() {
 					m.Combo("").Get(bind(api.ListPullRequestsOptions{}), repo.ListPullRequests).
@@ -801,7 +972,8 @@ func RegisterRoutes(m *macaron.Macaron) {
 						m.Get(".diff", repo.DownloadPullDiff)
 						m.Get(".patch", repo.DownloadPullPatch)
 						m.Combo("/merge").Get(repo.IsPullRequestMerged).
-							Post(reqToken(), mustNotBeArchived, bind(auth.MergePullRequestForm{}), repo.MergePullRequest)
+							Post(reqToken(), mustNotBeArchived, bind(auth.MergePullRequestForm{}), repo.MergePullRequest).
+							Delete(reqToken(), mustNotBeArchived, repo.CancelScheduledAutoMerge)
 						m.Group("/reviews", func() {
 							m.Combo("").
 								Get(repo.ListPullReviews).
@@ -858,14 +1030,14 @@ func RegisterRoutes(m *macaron.Macaron) {
 					}, reqAdmin())
 				}, reqAnyRepoReader())
 				m.Get("/languages", reqRepoReader(models.UnitTypeCode), repo.GetLanguages)
-			}, repoAssignment())
+			}, repoAssignment(), reqRepoTokenScope())
 		})
 
 		// Organizations
-		m.Get("/user/orgs", reqToken(), org.ListMyOrgs)
-		m.Get("/users/:username/orgs", org.ListUserOrgs)
-		m.Post("/orgs", reqToken(), bind(api.CreateOrgOption{}), org.Create)
-		m.Get("/orgs", org.GetAll)
+		m.Get("/user/orgs", reqToken(), reqTokenScope(models.AccessTokenScopeOrg), org.ListMyOrgs)
+		m.Get("/users/:username/orgs", reqTokenScope(models.AccessTokenScopeOrg), org.ListUserOrgs)
+		m.Post("/orgs", reqToken(), reqTokenScope(models.AccessTokenScopeOrg), bind(api.CreateOrgOption{}), org.Create)
+		m.Get("/orgs", reqTokenScope(models.AccessTokenScopeOrg), org.GetAll)
 		m.Group("/orgs/:org", func() {
 			m.Combo("").Get(org.Get).
 				Patch(reqToken(), reqOrgOwnership(), bind(api.EditOrgOption{}), org.Edit).
@@ -895,6 +1067,8 @@ func RegisterRoutes(m *macaron.Macaron) {
 					Patch(reqToken(), reqOrgOwnership(), bind(api.EditLabelOption{}), org.EditLabel).
 					Delete(reqToken(), reqOrgOwnership(), org.DeleteLabel)
 			})
+			m.Combo("/projects").Get(org.ListProjects).
+				Post(reqToken(), reqOrgOwnership(), bind(api.CreateProjectOption{}), org.CreateProject)
 			m.Group("/hooks", func() {
 				m.Combo("").Get(org.ListHooks).
 					Post(bind(api.CreateHookOption{}), org.CreateHook)
@@ -902,7 +1076,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 					Patch(bind(api.EditHookOption{}), org.EditHook).
 					Delete(org.DeleteHook)
 			}, reqToken(), reqOrgOwnership())
-		}, orgAssignment(true))
+		}, orgAssignment(true), reqTokenScope(models.AccessTokenScopeOrg))
 		m.Group("/teams/:teamid", func() {
 			m.Combo("").Get(org.GetTeam).
 				Patch(reqOrgOwnership(), bind(api.EditTeamOption{}), org.EditTeam).
@@ -920,13 +1094,44 @@ func RegisterRoutes(m *macaron.Macaron) {
 					Put(org.AddTeamRepository).
 					Delete(org.RemoveTeamRepository)
 			})
-		}, orgAssignment(false, true), reqToken(), reqTeamMembership())
+		}, orgAssignment(false, true), reqToken(), reqTokenScope(models.AccessTokenScopeOrg), reqTeamMembership())
+
+		m.Group("/projects/:id", func() {
+			m.Combo("").Get(project.GetProject).
+				Patch(reqToken(), reqProjectWriter(), bind(api.EditProjectOption{}), project.EditProject).
+				Delete(reqToken(), reqProjectWriter(), project.DeleteProject)
+			m.Group("/boards", func() {
+				m.Combo("").Get(project.ListProjectBoards).
+					Post(reqToken(), reqProjectWriter(), bind(api.CreateProjectBoardOption{}), project.CreateProjectBoard)
+				m.Combo("/:board_id").
+					Patch(reqToken(), reqProjectWriter(), bind(api.EditProjectBoardOption{}), project.EditProjectBoard).
+					Delete(reqToken(), reqProjectWriter(), project.DeleteProjectBoard)
+				m.Combo("/:board_id/cards").Get(project.ListProjectBoardCards).
+					Post(reqToken(), reqProjectWriter(), bind(api.MoveProjectCardOption{}), project.MoveProjectCard)
+			})
+			m.Group("/cards", func() {
+				m.Post("", bind(api.AddProjectCardOption{}), project.AddProjectCard)
+				m.Delete("/:issue_id", project.RemoveProjectCard)
+			}, reqToken(), reqProjectWriter())
+		}, projectAssignment())
+
+		if setting.Packages.Enabled {
+			m.Group("/packages/:username", func() {
+				m.Get("", packages.ListPackages)
+				m.Group("/:type/:name/:version", func() {
+					m.Combo("").Get(packages.GetPackage).
+						Delete(reqToken(), context.RequirePackageAccessAPI(models.AccessModeWrite, false), packages.DeletePackage)
+					m.Get("/files", packages.ListPackageFiles)
+				})
+			}, reqTokenScope(models.AccessTokenScopePackage), context.PackageAssignmentAPI(), context.RequirePackageAccessAPI(models.AccessModeRead, false))
+		}
 
 		m.Any("/*", func(ctx *context.APIContext) {
 			ctx.NotFound()
 		})
 
 		m.Group("/admin", func() {
+			m.Get("/audit", admin.ListAuditEvents)
 			m.Get("/orgs", admin.GetAllOrgs)
 			m.Group("/users", func() {
 				m.Get("", admin.GetAllUsers)
@@ -943,7 +1148,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 					m.Post("/repos", bind(api.CreateRepoOption{}), admin.CreateRepo)
 				})
 			})
-		}, reqToken(), reqSiteAdmin())
+		}, reqToken(), reqTokenScope(models.AccessTokenScopeAdmin), reqSiteAdmin())
 
 		m.Group("/topics", func() {
 			m.Get("/search", repo.TopicSearch)
@@ -69,6 +69,7 @@ func ListNotifications(ctx *context.APIContext) {
 	opts := models.FindNotificationOptions{
 		ListOptions:       utils.GetListOptions(ctx),
 		UserID:            ctx.User.ID,
+		RepoIDs:           utils.GetTokenRepoIDs(ctx),
 		UpdatedBeforeUnix: before,
 		UpdatedAfterUnix:  since,
 	}
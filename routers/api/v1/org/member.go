@@ -11,6 +11,7 @@ import (
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/convert"
+	"code.gitea.io/gitea/modules/notification"
 	"code.gitea.io/gitea/modules/setting"
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/routers/api/v1/user"
@@ -299,6 +300,8 @@ func DeleteMember(ctx *context.APIContext) {
 	}
 	if err := ctx.Org.Organization.RemoveMember(member.ID); err != nil {
 		ctx.Error(http.StatusInternalServerError, "RemoveMember", err)
+		return
 	}
+	notification.NotifyRemoveOrgMember(ctx.User, ctx.Org.Organization, member)
 	ctx.Status(http.StatusNoContent)
 }
@@ -12,6 +12,7 @@ import (
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/convert"
+	"code.gitea.io/gitea/modules/notification"
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/routers/api/v1/user"
 	"code.gitea.io/gitea/routers/api/v1/utils"
@@ -192,6 +193,7 @@ func Create(ctx *context.APIContext, form api.CreateOrgOption) {
 		}
 		return
 	}
+	notification.NotifyCreateOrganization(ctx.User, org)
 
 	ctx.JSON(http.StatusCreated, convert.ToOrganization(org))
 }
@@ -281,5 +283,6 @@ func Delete(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "DeleteOrganization", err)
 		return
 	}
+	notification.NotifyDeleteOrganization(ctx.User, ctx.Org.Organization)
 	ctx.Status(http.StatusNoContent)
 }
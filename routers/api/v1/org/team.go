@@ -11,9 +11,11 @@ import (
 	"strings"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/convert"
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification"
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/routers/api/v1/user"
 	"code.gitea.io/gitea/routers/api/v1/utils"
@@ -185,6 +187,7 @@ func CreateTeam(ctx *context.APIContext, form api.CreateTeamOption) {
 		}
 		return
 	}
+	audit.RecordTeam(ctx.User, ctx.RemoteAddr(), models.AuditTeamCreate, team, "")
 
 	ctx.JSON(http.StatusCreated, convert.ToTeam(team))
 }
@@ -265,6 +268,7 @@ func EditTeam(ctx *context.APIContext, form api.EditTeamOption) {
 		ctx.Error(http.StatusInternalServerError, "EditTeam", err)
 		return
 	}
+	audit.RecordTeam(ctx.User, ctx.RemoteAddr(), models.AuditTeamEdit, team, "")
 	ctx.JSON(http.StatusOK, convert.ToTeam(team))
 }
 
@@ -288,6 +292,7 @@ func DeleteTeam(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "DeleteTeam", err)
 		return
 	}
+	audit.RecordTeam(ctx.User, ctx.RemoteAddr(), models.AuditTeamDelete, ctx.Org.Team, "")
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -413,6 +418,8 @@ func AddTeamMember(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "AddMember", err)
 		return
 	}
+	notification.NotifyAddTeamMember(ctx.User, ctx.Org.Team, u)
+	audit.RecordTeam(ctx.User, ctx.RemoteAddr(), models.AuditTeamMemberAdd, ctx.Org.Team, "member: "+u.Name)
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -450,6 +457,8 @@ func RemoveTeamMember(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "RemoveMember", err)
 		return
 	}
+	notification.NotifyRemoveTeamMember(ctx.User, ctx.Org.Team, u)
+	audit.RecordTeam(ctx.User, ctx.RemoteAddr(), models.AuditTeamMemberRemove, ctx.Org.Team, "member: "+u.Name)
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -556,6 +565,7 @@ func AddTeamRepository(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "AddRepository", err)
 		return
 	}
+	audit.RecordTeamRepository(ctx.User, ctx.RemoteAddr(), models.AuditTeamRepoAdd, ctx.Org.Team, repo.ID)
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -606,6 +616,7 @@ func RemoveTeamRepository(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "RemoveRepository", err)
 		return
 	}
+	audit.RecordTeamRepository(ctx.User, ctx.RemoteAddr(), models.AuditTeamRepoRemove, ctx.Org.Team, repo.ID)
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -0,0 +1,262 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package packages
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+)
+
+// ListPackages lists the packages of a user or an organization
+func ListPackages(ctx *context.APIContext) {
+	// swagger:operation GET /packages/{owner} package listPackages
+	// ---
+	// summary: List the packages of an owner
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the packages
+	//   type: string
+	//   required: true
+	// - name: type
+	//   in: query
+	//   description: package type filter
+	//   type: string
+	//   enum: [generic, npm, maven]
+	// - name: q
+	//   in: query
+	//   description: name filter
+	//   type: string
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PackageList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	opts := models.PackageSearchOptions{
+		ListOptions: utils.GetListOptions(ctx),
+		OwnerID:     ctx.Package.Owner.ID,
+		Keyword:     ctx.Query("q"),
+	}
+	if opts.Page <= 0 {
+		opts.Page = 1
+	}
+	if t := ctx.Query("type"); t != "" {
+		packageType, ok := models.PackageTypeFromName(t)
+		if !ok {
+			ctx.Error(http.StatusUnprocessableEntity, "PackageTypeFromName", "invalid package type")
+			return
+		}
+		opts.Type = packageType
+	}
+
+	packages, count, err := models.GetPackages(opts)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetPackages", err)
+		return
+	}
+
+	apiPackages := make([]*api.Package, len(packages))
+	for i, p := range packages {
+		p.Owner = ctx.Package.Owner
+		apiPackages[i] = convert.ToAPIPackage(p)
+	}
+
+	ctx.SetLinkHeader(int(count), opts.PageSize)
+	ctx.Header().Set("X-Total-Count", fmt.Sprintf("%d", count))
+	ctx.JSON(http.StatusOK, apiPackages)
+}
+
+// getPackageVersion loads the package version given by the :type, :name and :version parameters
+func getPackageVersion(ctx *context.APIContext) (*models.Package, *models.PackageVersion) {
+	packageType, ok := models.PackageTypeFromName(ctx.Params(":type"))
+	if !ok {
+		ctx.NotFound()
+		return nil, nil
+	}
+
+	p, err := models.GetPackageByName(ctx.Package.Owner.ID, packageType, ctx.Params(":name"))
+	if err != nil {
+		if models.IsErrPackageNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetPackageByName", err)
+		}
+		return nil, nil
+	}
+	p.Owner = ctx.Package.Owner
+
+	pv, err := models.GetPackageVersion(p.ID, ctx.Params(":version"))
+	if err != nil {
+		if models.IsErrPackageVersionNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetPackageVersion", err)
+		}
+		return nil, nil
+	}
+	if err := pv.LoadCreator(); err != nil {
+		ctx.Error(http.StatusInternalServerError, "LoadCreator", err)
+		return nil, nil
+	}
+	return p, pv
+}
+
+// GetPackage gets a version of a package
+func GetPackage(ctx *context.APIContext) {
+	// swagger:operation GET /packages/{owner}/{type}/{name}/{version} package getPackage
+	// ---
+	// summary: Get a version of a package
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the package
+	//   type: string
+	//   required: true
+	// - name: type
+	//   in: path
+	//   description: type of the package
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of the package
+	//   type: string
+	//   required: true
+	// - name: version
+	//   in: path
+	//   description: version of the package
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PackageVersion"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	p, pv := getPackageVersion(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, convert.ToAPIPackageVersion(p, pv))
+}
+
+// ListPackageFiles lists the files of a version of a package
+func ListPackageFiles(ctx *context.APIContext) {
+	// swagger:operation GET /packages/{owner}/{type}/{name}/{version}/files package listPackageFiles
+	// ---
+	// summary: List the files of a version of a package
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the package
+	//   type: string
+	//   required: true
+	// - name: type
+	//   in: path
+	//   description: type of the package
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of the package
+	//   type: string
+	//   required: true
+	// - name: version
+	//   in: path
+	//   description: version of the package
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PackageFileList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	p, pv := getPackageVersion(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	files, err := models.GetPackageFiles(pv.ID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetPackageFiles", err)
+		return
+	}
+
+	apiFiles := make([]*api.PackageFile, len(files))
+	for i, pf := range files {
+		apiFiles[i] = convert.ToAPIPackageFile(p, pv, pf)
+	}
+	ctx.JSON(http.StatusOK, apiFiles)
+}
+
+// DeletePackage deletes a version of a package
+func DeletePackage(ctx *context.APIContext) {
+	// swagger:operation DELETE /packages/{owner}/{type}/{name}/{version} package deletePackage
+	// ---
+	// summary: Delete a version of a package
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the package
+	//   type: string
+	//   required: true
+	// - name: type
+	//   in: path
+	//   description: type of the package
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of the package
+	//   type: string
+	//   required: true
+	// - name: version
+	//   in: path
+	//   description: version of the package
+	//   type: string
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	_, pv := getPackageVersion(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.DeletePackageVersion(pv); err != nil {
+		ctx.Error(http.StatusInternalServerError, "DeletePackageVersion", err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
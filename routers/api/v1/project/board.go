@@ -0,0 +1,218 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package project
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// ListProjectBoards list the boards of a project
+func ListProjectBoards(ctx *context.APIContext) {
+	// swagger:operation GET /projects/{id}/boards project projectListBoards
+	// ---
+	// summary: List the boards of a project
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectBoardList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	p := ctx.Data["Project"].(*models.Project)
+	boards, err := models.GetProjectBoards(p.ID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetProjectBoards", err)
+		return
+	}
+
+	apiBoards := make([]*api.ProjectBoard, len(boards))
+	for i := range boards {
+		apiBoards[i] = convert.ToAPIProjectBoard(boards[i])
+	}
+	ctx.JSON(http.StatusOK, &apiBoards)
+}
+
+// CreateProjectBoard add a board to a project
+func CreateProjectBoard(ctx *context.APIContext, form api.CreateProjectBoardOption) {
+	// swagger:operation POST /projects/{id}/boards project projectCreateBoard
+	// ---
+	// summary: Add a board to a project
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateProjectBoardOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/ProjectBoard"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	p := ctx.Data["Project"].(*models.Project)
+	board := &models.ProjectBoard{
+		ProjectID: p.ID,
+		Title:     form.Title,
+		Sorting:   form.Position,
+		IsDefault: form.IsDefault,
+		IsDone:    form.IsDone,
+		CreatorID: ctx.User.ID,
+	}
+	if err := models.NewProjectBoard(board); err != nil {
+		ctx.Error(http.StatusInternalServerError, "NewProjectBoard", err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, convert.ToAPIProjectBoard(board))
+}
+
+// getProjectBoard loads the board given by the :board_id parameter, 0 being the uncategorized board
+func getProjectBoard(ctx *context.APIContext) *models.ProjectBoard {
+	p := ctx.Data["Project"].(*models.Project)
+	boardID := ctx.ParamsInt64(":board_id")
+	if boardID == 0 {
+		return models.GetUncategorizedBoard(p.ID)
+	}
+
+	board, err := models.GetProjectBoard(p.ID, boardID)
+	if err != nil {
+		if models.IsErrProjectBoardNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetProjectBoard", err)
+		}
+		return nil
+	}
+	return board
+}
+
+// EditProjectBoard update a board of a project
+func EditProjectBoard(ctx *context.APIContext, form api.EditProjectBoardOption) {
+	// swagger:operation PATCH /projects/{id}/boards/{board_id} project projectEditBoard
+	// ---
+	// summary: Update a board of a project
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: board_id
+	//   in: path
+	//   description: id of the board
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditProjectBoardOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectBoard"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	board := getProjectBoard(ctx)
+	if ctx.Written() {
+		return
+	}
+	if board.IsUncategorized() {
+		ctx.NotFound()
+		return
+	}
+
+	if form.Title != nil && len(*form.Title) > 0 {
+		board.Title = *form.Title
+	}
+	if form.Position != nil {
+		board.Sorting = *form.Position
+	}
+	if form.IsDefault != nil {
+		board.IsDefault = *form.IsDefault
+	}
+	if form.IsDone != nil {
+		board.IsDone = *form.IsDone
+	}
+	if err := models.UpdateProjectBoard(board); err != nil {
+		ctx.Error(http.StatusInternalServerError, "UpdateProjectBoard", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, convert.ToAPIProjectBoard(board))
+}
+
+// DeleteProjectBoard delete a board of a project
+func DeleteProjectBoard(ctx *context.APIContext) {
+	// swagger:operation DELETE /projects/{id}/boards/{board_id} project projectDeleteBoard
+	// ---
+	// summary: Delete a board of a project, its cards become uncategorized
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: board_id
+	//   in: path
+	//   description: id of the board to delete
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	board := getProjectBoard(ctx)
+	if ctx.Written() {
+		return
+	}
+	if board.IsUncategorized() {
+		ctx.NotFound()
+		return
+	}
+
+	if err := models.DeleteProjectBoardByID(board.ID); err != nil {
+		ctx.Error(http.StatusInternalServerError, "DeleteProjectBoardByID", err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
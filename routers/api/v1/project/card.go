@@ -0,0 +1,236 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package project
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// ListProjectBoardCards list the cards of a board
+func ListProjectBoardCards(ctx *context.APIContext) {
+	// swagger:operation GET /projects/{id}/boards/{board_id}/cards project projectListBoardCards
+	// ---
+	// summary: List the cards of a board ordered by their position
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: board_id
+	//   in: path
+	//   description: id of the board, 0 for the uncategorized cards
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectCardList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	board := getProjectBoard(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	cards, err := models.GetProjectBoardCards(board.ProjectID, board.ID, ctx.User)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetProjectBoardCards", err)
+		return
+	}
+
+	apiCards := make([]*api.ProjectCard, len(cards))
+	for i := range cards {
+		apiCards[i] = convert.ToAPIProjectCard(cards[i])
+	}
+	ctx.JSON(http.StatusOK, &apiCards)
+}
+
+// MoveProjectCard move a card to a board
+func MoveProjectCard(ctx *context.APIContext, form api.MoveProjectCardOption) {
+	// swagger:operation POST /projects/{id}/boards/{board_id}/cards project projectMoveCard
+	// ---
+	// summary: Move the card of an issue or a pull request of the project to a board
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: board_id
+	//   in: path
+	//   description: id of the board, 0 to uncategorize the card
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/MoveProjectCardOption"
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	board := getProjectBoard(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.MoveIssueOnProjectBoard(board, form.IssueID, form.Position); err != nil {
+		if models.IsErrProjectIssueNotExist(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "MoveIssueOnProjectBoard", err)
+		}
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// getWritableIssue loads the issue of the given ID if the doer may change it
+func getWritableIssue(ctx *context.APIContext, issueID int64) *models.Issue {
+	issue, err := models.GetIssueByID(issueID)
+	if err != nil {
+		if models.IsErrIssueNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetIssueByID", err)
+		}
+		return nil
+	}
+	if err := issue.LoadRepo(); err != nil {
+		ctx.Error(http.StatusInternalServerError, "LoadRepo", err)
+		return nil
+	}
+
+	if token, ok := ctx.Data["ApiToken"].(*models.AccessToken); ok && !token.CanAccessRepo(issue.RepoID) {
+		ctx.NotFound()
+		return nil
+	}
+	perm, err := models.GetUserRepoPermission(issue.Repo, ctx.User)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetUserRepoPermission", err)
+		return nil
+	}
+	if !perm.CanReadIssuesOrPulls(issue.IsPull) {
+		ctx.NotFound()
+		return nil
+	}
+	if !perm.CanWriteIssuesOrPulls(issue.IsPull) || issue.Repo.IsArchived {
+		ctx.Error(http.StatusForbidden, "", "user should be able to change the issue")
+		return nil
+	}
+	return issue
+}
+
+// AddProjectCard add an issue or a pull request to a project
+func AddProjectCard(ctx *context.APIContext, form api.AddProjectCardOption) {
+	// swagger:operation POST /projects/{id}/cards project projectAddCard
+	// ---
+	// summary: Add an issue or a pull request to the default board of a project, removing it from its previous project
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/AddProjectCardOption"
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	p := ctx.Data["Project"].(*models.Project)
+	issue := getWritableIssue(ctx, form.IssueID)
+	if ctx.Written() {
+		return
+	}
+	if !p.CanContainIssue(issue) {
+		ctx.Error(http.StatusUnprocessableEntity, "", "the issue cannot be added to the project")
+		return
+	}
+
+	if err := models.ChangeProjectAssign(issue, ctx.User, p.ID); err != nil {
+		ctx.Error(http.StatusInternalServerError, "ChangeProjectAssign", err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// RemoveProjectCard remove an issue or a pull request from a project
+func RemoveProjectCard(ctx *context.APIContext) {
+	// swagger:operation DELETE /projects/{id}/cards/{issue_id} project projectRemoveCard
+	// ---
+	// summary: Remove an issue or a pull request from a project
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: issue_id
+	//   in: path
+	//   description: id of the issue or the pull request, not its index
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	p := ctx.Data["Project"].(*models.Project)
+	issue := getWritableIssue(ctx, ctx.ParamsInt64(":issue_id"))
+	if ctx.Written() {
+		return
+	}
+	if issue.ProjectID() != p.ID {
+		ctx.NotFound()
+		return
+	}
+
+	if err := models.ChangeProjectAssign(issue, ctx.User, 0); err != nil {
+		ctx.Error(http.StatusInternalServerError, "ChangeProjectAssign", err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
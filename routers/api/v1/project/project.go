@@ -0,0 +1,126 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package project
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// GetProject get a project
+func GetProject(ctx *context.APIContext) {
+	// swagger:operation GET /projects/{id} project projectGetProject
+	// ---
+	// summary: Get a project
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/Project"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	p := ctx.Data["Project"].(*models.Project)
+	if err := models.ProjectList([]*models.Project{p}).LoadIssueCounts(); err != nil {
+		ctx.Error(http.StatusInternalServerError, "LoadIssueCounts", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, convert.ToAPIProject(p))
+}
+
+// EditProject update a project
+func EditProject(ctx *context.APIContext, form api.EditProjectOption) {
+	// swagger:operation PATCH /projects/{id} project projectEditProject
+	// ---
+	// summary: Update a project
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditProjectOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/Project"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	p := ctx.Data["Project"].(*models.Project)
+	if form.Title != nil && len(*form.Title) > 0 {
+		p.Title = *form.Title
+	}
+	if form.Description != nil {
+		p.Description = *form.Description
+	}
+	if err := models.UpdateProject(p); err != nil {
+		ctx.Error(http.StatusInternalServerError, "UpdateProject", err)
+		return
+	}
+
+	if form.State != nil {
+		if isClosed := *form.State == string(api.StateClosed); isClosed != p.IsClosed {
+			if err := models.ChangeProjectStatus(p, isClosed); err != nil {
+				ctx.Error(http.StatusInternalServerError, "ChangeProjectStatus", err)
+				return
+			}
+		}
+	}
+
+	if err := models.ProjectList([]*models.Project{p}).LoadIssueCounts(); err != nil {
+		ctx.Error(http.StatusInternalServerError, "LoadIssueCounts", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, convert.ToAPIProject(p))
+}
+
+// DeleteProject delete a project
+func DeleteProject(ctx *context.APIContext) {
+	// swagger:operation DELETE /projects/{id} project projectDeleteProject
+	// ---
+	// summary: Delete a project
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the project to delete
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	p := ctx.Data["Project"].(*models.Project)
+	if err := models.DeleteProjectByID(p.ID); err != nil {
+		ctx.Error(http.StatusInternalServerError, "DeleteProjectByID", err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
@@ -10,6 +10,7 @@ import (
 	"net/http"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/convert"
 	"code.gitea.io/gitea/modules/git"
@@ -532,6 +533,7 @@ func CreateBranchProtection(ctx *context.APIContext, form api.CreateBranchProtec
 		RequireSignedCommits:     form.RequireSignedCommits,
 		ProtectedFilePatterns:    form.ProtectedFilePatterns,
 		BlockOnOutdatedBranch:    form.BlockOnOutdatedBranch,
+		RequireCodeOwnerApproval: form.RequireCodeOwnerApproval,
 	}
 
 	err = models.UpdateProtectBranch(ctx.Repo.Repository, protectBranch, models.WhitelistOptions{
@@ -557,6 +559,7 @@ func CreateBranchProtection(ctx *context.APIContext, form api.CreateBranchProtec
 		ctx.Error(http.StatusInternalServerError, "New branch protection not found", err)
 		return
 	}
+	audit.RecordBranchProtection(ctx.User, ctx.RemoteAddr(), models.AuditBranchProtectionUpdate, bp)
 
 	ctx.JSON(http.StatusCreated, convert.ToBranchProtection(bp))
 
@@ -671,6 +674,10 @@ func EditBranchProtection(ctx *context.APIContext, form api.EditBranchProtection
 		protectBranch.BlockOnOutdatedBranch = *form.BlockOnOutdatedBranch
 	}
 
+	if form.RequireCodeOwnerApproval != nil {
+		protectBranch.RequireCodeOwnerApproval = *form.RequireCodeOwnerApproval
+	}
+
 	var whitelistUsers []int64
 	if form.PushWhitelistUsernames != nil {
 		whitelistUsers, err = models.GetUserIDsByNames(form.PushWhitelistUsernames, false)
@@ -780,6 +787,7 @@ func EditBranchProtection(ctx *context.APIContext, form api.EditBranchProtection
 		ctx.Error(http.StatusInternalServerError, "New branch protection not found", err)
 		return
 	}
+	audit.RecordBranchProtection(ctx.User, ctx.RemoteAddr(), models.AuditBranchProtectionUpdate, bp)
 
 	ctx.JSON(http.StatusOK, convert.ToBranchProtection(bp))
 }
@@ -829,6 +837,7 @@ func DeleteBranchProtection(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "DeleteProtectedBranch", err)
 		return
 	}
+	audit.RecordBranchProtection(ctx.User, ctx.RemoteAddr(), models.AuditBranchProtectionDelete, bp)
 
 	ctx.Status(http.StatusNoContent)
 }
@@ -10,6 +10,7 @@ import (
 	"net/http"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/convert"
 	api "code.gitea.io/gitea/modules/structs"
@@ -163,11 +164,28 @@ func AddCollaborator(ctx *context.APIContext, form api.AddCollaboratorOption) {
 		return
 	}
 
+	audit.Record(ctx.User, ctx.RemoteAddr(), &models.AuditEvent{
+		Action:     models.AuditCollaboratorAdd,
+		RepoID:     ctx.Repo.Repository.ID,
+		TargetType: models.AuditTargetUser,
+		TargetID:   collaborator.ID,
+		TargetName: collaborator.Name,
+	})
+
 	if form.Permission != nil {
-		if err := ctx.Repo.Repository.ChangeCollaborationAccessMode(collaborator.ID, models.ParseAccessMode(*form.Permission)); err != nil {
+		mode := models.ParseAccessMode(*form.Permission)
+		if err := ctx.Repo.Repository.ChangeCollaborationAccessMode(collaborator.ID, mode); err != nil {
 			ctx.Error(http.StatusInternalServerError, "ChangeCollaborationAccessMode", err)
 			return
 		}
+		audit.Record(ctx.User, ctx.RemoteAddr(), &models.AuditEvent{
+			Action:     models.AuditCollaboratorAccess,
+			RepoID:     ctx.Repo.Repository.ID,
+			TargetType: models.AuditTargetUser,
+			TargetID:   collaborator.ID,
+			TargetName: collaborator.Name,
+			Details:    "permission: " + mode.String(),
+		})
 	}
 
 	ctx.Status(http.StatusNoContent)
@@ -216,5 +234,12 @@ func DeleteCollaborator(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "DeleteCollaboration", err)
 		return
 	}
+	audit.Record(ctx.User, ctx.RemoteAddr(), &models.AuditEvent{
+		Action:     models.AuditCollaboratorRemove,
+		RepoID:     ctx.Repo.Repository.ID,
+		TargetType: models.AuditTargetUser,
+		TargetID:   collaborator.ID,
+		TargetName: collaborator.Name,
+	})
 	ctx.Status(http.StatusNoContent)
 }
@@ -157,6 +157,115 @@ func TestHook(ctx *context.APIContext) {
 	ctx.Status(http.StatusNoContent)
 }
 
+// ListHookDeliveries list the deliveries of a hook
+func ListHookDeliveries(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/hooks/{id}/deliveries repository repoListHookDeliveries
+	// ---
+	// summary: List the deliveries of a hook, the latest first
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the hook
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/HookDeliveryList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	hook, err := utils.GetRepoHook(ctx, ctx.Repo.Repository.ID, ctx.ParamsInt64(":id"))
+	if err != nil {
+		return
+	}
+
+	tasks, err := models.GetHookTasksByHookID(hook.ID, utils.GetListOptions(ctx))
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetHookTasksByHookID", err)
+		return
+	}
+
+	deliveries := make([]*api.HookDelivery, len(tasks))
+	for i := range tasks {
+		deliveries[i] = convert.ToHookDelivery(tasks[i])
+	}
+	ctx.JSON(http.StatusOK, &deliveries)
+}
+
+// RedeliverHookDelivery redelivers a historical delivery of a hook
+func RedeliverHookDelivery(ctx *context.APIContext) {
+	// swagger:operation POST /repos/{owner}/{repo}/hooks/{id}/deliveries/{delivery_id}/attempts repository repoRedeliverHookDelivery
+	// ---
+	// summary: Redeliver the payload of a delivery of a hook
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the hook
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: delivery_id
+	//   in: path
+	//   description: id of the delivery to redeliver
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/HookDelivery"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	hook, err := utils.GetRepoHook(ctx, ctx.Repo.Repository.ID, ctx.ParamsInt64(":id"))
+	if err != nil {
+		return
+	}
+
+	task, err := webhook.ReplayHookTask(hook, ctx.ParamsInt64(":delivery_id"))
+	if err != nil {
+		if models.IsErrHookTaskNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "ReplayHookTask", err)
+		}
+		return
+	}
+	ctx.JSON(http.StatusCreated, convert.ToHookDelivery(task))
+}
+
 // CreateHook create a hook for a repository
 func CreateHook(ctx *context.APIContext, form api.CreateHookOption) {
 	// swagger:operation POST /repos/{owner}/{repo}/hooks repository repoCreateHook
@@ -87,6 +87,7 @@ func SearchIssues(ctx *context.APIContext) {
 		// MySQL will return different results when sorting by null in some cases
 		OrderBy: models.SearchOrderByAlphabetically,
 		Actor:   ctx.User,
+		RepoIDs: utils.GetTokenRepoIDs(ctx),
 	}
 	if ctx.IsSigned {
 		opts.Private = true
@@ -120,6 +121,12 @@ func SearchIssues(ctx *context.APIContext) {
 	}
 
 	var issues []*models.Issue
+	if len(repoIDs) == 0 && len(opts.RepoIDs) > 0 {
+		// No repository of the access token matches, rather than no repository filter
+		ctx.SetLinkHeader(0, setting.UI.IssuePagingNum)
+		ctx.JSON(http.StatusOK, convert.ToAPIIssueList(issues))
+		return
+	}
 
 	keyword := strings.Trim(ctx.Query("q"), " ")
 	if strings.IndexByte(keyword, 0) >= 0 {
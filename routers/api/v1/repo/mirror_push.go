@@ -0,0 +1,250 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	api "code.gitea.io/gitea/modules/structs"
+	mirror_service "code.gitea.io/gitea/services/mirror"
+)
+
+func toPushMirror(m *models.PushMirror) *api.PushMirror {
+	apiMirror := &api.PushMirror{
+		RepoName:      m.Repo.Name,
+		RemoteName:    m.RemoteName,
+		RemoteAddress: mirror_service.PushMirrorAddress(m),
+		Created:       m.CreatedUnix.AsTime(),
+		LastError:     m.LastError,
+		Interval:      m.Interval.String(),
+	}
+	if m.LastUpdateUnix != 0 {
+		lastUpdate := m.LastUpdateUnix.AsTime()
+		apiMirror.LastUpdate = &lastUpdate
+	}
+	return apiMirror
+}
+
+// ListPushMirrors lists the push mirrors of a repository
+func ListPushMirrors(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/push_mirrors repository repoListPushMirrors
+	// ---
+	// summary: Get all push mirrors of the repository
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PushMirrorList"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+
+	mirrors, err := models.GetPushMirrorsByRepoID(ctx.Repo.Repository.ID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetPushMirrorsByRepoID", err)
+		return
+	}
+
+	apiMirrors := make([]*api.PushMirror, len(mirrors))
+	for i := range mirrors {
+		apiMirrors[i] = toPushMirror(mirrors[i])
+	}
+	ctx.JSON(http.StatusOK, &apiMirrors)
+}
+
+// GetPushMirrorByName gets a push mirror of a repository by its remote name
+func GetPushMirrorByName(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/push_mirrors/{name} repository repoGetPushMirrorByRemoteName
+	// ---
+	// summary: Get push mirror of the repository by remoteName
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: remote name of push mirror
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PushMirror"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	m, err := models.GetPushMirrorByRepoIDAndName(ctx.Repo.Repository.ID, ctx.Params(":name"))
+	if err != nil {
+		if err == models.ErrPushMirrorNotExist {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetPushMirrorByRepoIDAndName", err)
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, toPushMirror(m))
+}
+
+// AddPushMirror adds a push mirror to a repository
+func AddPushMirror(ctx *context.APIContext, form api.CreatePushMirrorOption) {
+	// swagger:operation POST /repos/{owner}/{repo}/push_mirrors repository repoAddPushMirror
+	// ---
+	// summary: add a push mirror to the repository
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreatePushMirrorOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/PushMirror"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	interval, err := mirror_service.ParsePushMirrorInterval(form.Interval)
+	if err != nil {
+		ctx.Error(http.StatusUnprocessableEntity, "", fmt.Errorf("invalid push mirror interval: %v", err))
+		return
+	}
+
+	address, err := mirror_service.ParseAddress(form.RemoteAddress, form.RemoteUsername, form.RemotePassword)
+	if err != nil {
+		if mirror_service.IsErrInvalidAddress(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "ParseAddress", err)
+		}
+		return
+	}
+
+	m, err := mirror_service.AddPushMirror(ctx.Repo.Repository, address, interval)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "AddPushMirror", err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, toPushMirror(m))
+}
+
+// DeletePushMirrorByName removes a push mirror of a repository by its remote name
+func DeletePushMirrorByName(ctx *context.APIContext) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/push_mirrors/{name} repository repoDeletePushMirror
+	// ---
+	// summary: deletes a push mirror from a repository by remoteName
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: remote name of the pushMirror
+	//   type: string
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	m, err := models.GetPushMirrorByRepoIDAndName(ctx.Repo.Repository.ID, ctx.Params(":name"))
+	if err != nil {
+		if err == models.ErrPushMirrorNotExist {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetPushMirrorByRepoIDAndName", err)
+		}
+		return
+	}
+
+	if err := mirror_service.RemovePushMirror(m); err != nil {
+		ctx.Error(http.StatusInternalServerError, "RemovePushMirror", err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// PushMirrorSync adds all push mirrors of a repository to the sync queue
+func PushMirrorSync(ctx *context.APIContext) {
+	// swagger:operation POST /repos/{owner}/{repo}/push_mirrors-sync repository repoPushMirrorSync
+	// ---
+	// summary: Sync all push mirrored repository
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo to sync
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo to sync
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+
+	mirrors, err := models.GetPushMirrorsByRepoID(ctx.Repo.Repository.ID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetPushMirrorsByRepoID", err)
+		return
+	}
+	for _, m := range mirrors {
+		mirror_service.StartToPushMirror(m)
+	}
+	ctx.Status(http.StatusOK)
+}
@@ -334,22 +334,28 @@ func CreatePullReview(ctx *context.APIContext, opts api.CreatePullReviewOptions)
 
 	// create review comments
 	for _, c := range opts.Comments {
-		line := c.NewLineNum
+		line, startLine := c.NewLineNum, c.NewStartLineNum
 		if c.OldLineNum > 0 {
-			line = c.OldLineNum * -1
+			line, startLine = c.OldLineNum*-1, c.OldStartLineNum*-1
 		}
 
 		if _, err := pull_service.CreateCodeComment(
 			ctx.User,
 			ctx.Repo.GitRepo,
 			pr.Issue,
+			startLine,
 			line,
+			c.IsFileComment,
 			c.Body,
 			c.Path,
 			true, // is review
 			0,    // no reply
 			opts.CommitID,
 		); err != nil {
+			if models.IsErrInvalidCodeCommentLines(err) {
+				ctx.Error(http.StatusUnprocessableEntity, "", err)
+				return
+			}
 			ctx.ServerError("CreateCodeComment", err)
 			return
 		}
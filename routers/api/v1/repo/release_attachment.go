@@ -198,7 +198,7 @@ func CreateReleaseAttachment(ctx *context.APIContext) {
 		UploaderID: ctx.User.ID,
 		Name:       filename,
 		ReleaseID:  release.ID,
-	}, buf, file)
+	}, buf, file, header.Size)
 	if err != nil {
 		ctx.Error(http.StatusInternalServerError, "NewAttachment", err)
 		return
@@ -136,6 +136,7 @@ func Search(ctx *context.APIContext) {
 		Template:           util.OptionalBoolNone,
 		StarredByID:        ctx.QueryInt64("starredBy"),
 		IncludeDescription: ctx.QueryBool("includeDesc"),
+		RepoIDs:            utils.GetTokenRepoIDs(ctx),
 	}
 
 	if ctx.Query("template") != "" {
@@ -428,6 +429,11 @@ func GetByID(ctx *context.APIContext) {
 		return
 	}
 
+	if token, ok := ctx.Data["ApiToken"].(*models.AccessToken); ok && !token.CanAccessRepo(repo.ID) {
+		ctx.NotFound()
+		return
+	}
+
 	perm, err := models.GetUserRepoPermission(repo, ctx.User)
 	if err != nil {
 		ctx.Error(http.StatusInternalServerError, "AccessLevel", err)
@@ -707,6 +713,12 @@ func updateRepoUnits(ctx *context.APIContext, opts api.EditRepoOption) error {
 			if opts.AllowSquash != nil {
 				config.AllowSquash = *opts.AllowSquash
 			}
+			if opts.AllowFastForwardOnly != nil {
+				config.AllowFastForwardOnly = *opts.AllowFastForwardOnly
+			}
+			if opts.AllowSemiLinear != nil {
+				config.AllowSemiLinear = *opts.AllowSemiLinear
+			}
 
 			units = append(units, models.RepoUnit{
 				RepoID: repo.ID,
@@ -718,6 +730,30 @@ func updateRepoUnits(ctx *context.APIContext, opts api.EditRepoOption) error {
 		}
 	}
 
+	if opts.HasProjects != nil && !models.UnitTypeProjects.UnitGlobalDisabled() {
+		if *opts.HasProjects {
+			units = append(units, models.RepoUnit{
+				RepoID: repo.ID,
+				Type:   models.UnitTypeProjects,
+				Config: new(models.UnitConfig),
+			})
+		} else {
+			deleteUnitTypes = append(deleteUnitTypes, models.UnitTypeProjects)
+		}
+	}
+
+	if opts.HasActions != nil && !models.UnitTypeActions.UnitGlobalDisabled() {
+		if *opts.HasActions {
+			units = append(units, models.RepoUnit{
+				RepoID: repo.ID,
+				Type:   models.UnitTypeActions,
+				Config: new(models.UnitConfig),
+			})
+		} else {
+			deleteUnitTypes = append(deleteUnitTypes, models.UnitTypeActions)
+		}
+	}
+
 	if err := models.UpdateRepositoryUnits(repo, units, deleteUnitTypes); err != nil {
 		ctx.Error(http.StatusInternalServerError, "UpdateRepositoryUnits", err)
 		return err
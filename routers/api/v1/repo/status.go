@@ -10,9 +10,11 @@ import (
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/repofiles"
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/routers/api/v1/utils"
+	"code.gitea.io/gitea/services/automerge"
 )
 
 // NewCommitStatus creates a new CommitStatus
@@ -64,6 +66,10 @@ func NewCommitStatus(ctx *context.APIContext, form api.CreateStatusOption) {
 		return
 	}
 
+	if err := automerge.StartPRCheckAndAutoMergeBySHA(sha, ctx.Repo.Repository); err != nil {
+		log.Error("StartPRCheckAndAutoMergeBySHA[%s]: %v", sha, err)
+	}
+
 	ctx.JSON(http.StatusCreated, status.APIFormat())
 }
 
@@ -62,6 +62,19 @@ type swaggerParameterBodies struct {
 	// in:body
 	EditMilestoneOption api.EditMilestoneOption
 
+	// in:body
+	CreateProjectOption api.CreateProjectOption
+	// in:body
+	EditProjectOption api.EditProjectOption
+	// in:body
+	CreateProjectBoardOption api.CreateProjectBoardOption
+	// in:body
+	EditProjectBoardOption api.EditProjectBoardOption
+	// in:body
+	AddProjectCardOption api.AddProjectCardOption
+	// in:body
+	MoveProjectCardOption api.MoveProjectCardOption
+
 	// in:body
 	CreateOrgOption api.CreateOrgOption
 	// in:body
@@ -149,4 +162,7 @@ type swaggerParameterBodies struct {
 
 	// in:body
 	SubmitPullReviewOptions api.SubmitPullReviewOptions
+
+	// in:body
+	CreatePushMirrorOption api.CreatePushMirrorOption
 }
@@ -8,11 +8,14 @@ package user
 import (
 	"errors"
 	"net/http"
+	"time"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/convert"
 	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
 	"code.gitea.io/gitea/routers/api/v1/utils"
 )
 
@@ -49,15 +52,35 @@ func ListAccessTokens(ctx *context.APIContext) {
 
 	apiTokens := make([]*api.AccessToken, len(tokens))
 	for i := range tokens {
-		apiTokens[i] = &api.AccessToken{
-			ID:             tokens[i].ID,
-			Name:           tokens[i].Name,
-			TokenLastEight: tokens[i].TokenLastEight,
+		apiTokens[i], err = toAccessToken(tokens[i])
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "toAccessToken", err)
+			return
 		}
 	}
 	ctx.JSON(http.StatusOK, &apiTokens)
 }
 
+// toAccessToken converts an access token to its API format, leaving out the token itself.
+func toAccessToken(t *models.AccessToken) (*api.AccessToken, error) {
+	repos, err := t.RepositoryFullNames()
+	if err != nil {
+		return nil, err
+	}
+	apiToken := &api.AccessToken{
+		ID:             t.ID,
+		Name:           t.Name,
+		TokenLastEight: t.TokenLastEight,
+		Scopes:         t.Scope.StringSlice(),
+		Repositories:   repos,
+	}
+	if t.ExpiresUnix != 0 {
+		expiresAt := t.ExpiresUnix.AsTime()
+		apiToken.ExpiresAt = &expiresAt
+	}
+	return apiToken, nil
+}
+
 // CreateAccessToken create access tokens
 func CreateAccessToken(ctx *context.APIContext, form api.CreateAccessTokenOption) {
 	// swagger:operation POST /users/{username}/tokens user userCreateToken
@@ -82,13 +105,54 @@ func CreateAccessToken(ctx *context.APIContext, form api.CreateAccessTokenOption
 	//     properties:
 	//       name:
 	//         type: string
+	//       scopes:
+	//         description: scopes granted to the token, defaults to all
+	//         type: array
+	//         items:
+	//           type: string
+	//           enum: [all, "repo:read", "repo:write", issue, admin, org, package, user]
+	//       repositories:
+	//         description: full names of the only repositories the token may access
+	//         type: array
+	//         items:
+	//           type: string
+	//       expires_at:
+	//         type: string
+	//         format: date-time
 	// responses:
-	//   "200":
+	//   "201":
 	//     "$ref": "#/responses/AccessToken"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	scope, err := models.ParseAccessTokenScope(form.Scopes)
+	if err != nil {
+		ctx.Error(http.StatusUnprocessableEntity, "ParseAccessTokenScope", err)
+		return
+	}
+
+	repoIDs, err := models.ResolveAccessTokenRepositories(ctx.User, form.Repositories)
+	if err != nil {
+		if models.IsErrRepoNotExist(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "ResolveAccessTokenRepositories", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "ResolveAccessTokenRepositories", err)
+		}
+		return
+	}
 
 	t := &models.AccessToken{
-		UID:  ctx.User.ID,
-		Name: form.Name,
+		UID:     ctx.User.ID,
+		Name:    form.Name,
+		Scope:   scope,
+		RepoIDs: repoIDs,
+	}
+	if form.ExpiresAt != nil {
+		if !form.ExpiresAt.After(time.Now()) {
+			ctx.Error(http.StatusUnprocessableEntity, "ExpiresAt", errors.New("expiry date must be in the future"))
+			return
+		}
+		t.ExpiresUnix = timeutil.TimeStamp(form.ExpiresAt.Unix())
 	}
 
 	exist, err := models.AccessTokenByNameExists(t)
@@ -105,12 +169,20 @@ func CreateAccessToken(ctx *context.APIContext, form api.CreateAccessTokenOption
 		ctx.Error(http.StatusInternalServerError, "NewAccessToken", err)
 		return
 	}
-	ctx.JSON(http.StatusCreated, &api.AccessToken{
-		Name:           t.Name,
-		Token:          t.Token,
-		ID:             t.ID,
-		TokenLastEight: t.TokenLastEight,
+	audit.Record(ctx.User, ctx.RemoteAddr(), &models.AuditEvent{
+		Action:     models.AuditAccessTokenCreate,
+		TargetType: models.AuditTargetAccessToken,
+		TargetID:   t.ID,
+		TargetName: t.Name,
+		Details:    "scopes: " + string(t.Scope),
 	})
+	apiToken, err := toAccessToken(t)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "toAccessToken", err)
+		return
+	}
+	apiToken.Token = t.Token
+	ctx.JSON(http.StatusCreated, apiToken)
 }
 
 // DeleteAccessToken delete access tokens
@@ -145,6 +217,11 @@ func DeleteAccessToken(ctx *context.APIContext) {
 		}
 		return
 	}
+	audit.Record(ctx.User, ctx.RemoteAddr(), &models.AuditEvent{
+		Action:     models.AuditAccessTokenDelete,
+		TargetType: models.AuditTargetAccessToken,
+		TargetID:   tokenID,
+	})
 
 	ctx.Status(http.StatusNoContent)
 }
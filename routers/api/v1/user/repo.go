@@ -23,6 +23,7 @@ func listUserRepos(ctx *context.APIContext, u *models.User, private bool) {
 		Private:     private,
 		ListOptions: opts,
 		OrderBy:     "id ASC",
+		RepoIDs:     utils.GetTokenRepoIDs(ctx),
 	})
 	if err != nil {
 		ctx.Error(http.StatusInternalServerError, "GetUserRepositories", err)
@@ -105,6 +106,7 @@ func ListMyRepos(ctx *context.APIContext) {
 		OwnerID:            ctx.User.ID,
 		Private:            ctx.IsSigned,
 		IncludeDescription: true,
+		RepoIDs:            utils.GetTokenRepoIDs(ctx),
 	}
 
 	var err error
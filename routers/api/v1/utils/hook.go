@@ -56,16 +56,25 @@ func CheckCreateHookOption(ctx *context.APIContext, form *api.CreateHookOption)
 		ctx.Error(http.StatusUnprocessableEntity, "", "Invalid hook type")
 		return false
 	}
-	for _, name := range []string{"url", "content_type"} {
+	required := []string{"url", "content_type"}
+	if form.Type == models.CUSTOM.Name() {
+		// the content type of custom hooks is a MIME type which defaults to JSON
+		required = []string{"url", "template"}
+	}
+	for _, name := range required {
 		if _, ok := form.Config[name]; !ok {
 			ctx.Error(http.StatusUnprocessableEntity, "", "Missing config option: "+name)
 			return false
 		}
 	}
-	if !models.IsValidHookContentType(form.Config["content_type"]) {
+	if form.Type != models.CUSTOM.Name() && !models.IsValidHookContentType(form.Config["content_type"]) {
 		ctx.Error(http.StatusUnprocessableEntity, "", "Invalid content type")
 		return false
 	}
+	if alg, ok := form.Config["signature_algorithm"]; ok && !models.IsValidHookSignatureAlgorithm(alg) {
+		ctx.Error(http.StatusUnprocessableEntity, "", "Invalid signature algorithm")
+		return false
+	}
 	return true
 }
 
@@ -108,6 +117,7 @@ func addHook(ctx *context.APIContext, form *api.CreateHookOption, orgID, repoID
 		ContentType: models.ToHookContentType(form.Config["content_type"]),
 		Secret:      form.Config["secret"],
 		HTTPMethod:  "POST",
+
 		HookEvent: &models.HookEvent{
 			ChooseEvents: true,
 			HookEvents: models.HookEvents{
@@ -129,11 +139,15 @@ func addHook(ctx *context.APIContext, form *api.CreateHookOption, orgID, repoID
 				PullRequestSync:      pullHook(form.Events, string(models.HookEventPullRequestSync)),
 				Repository:           com.IsSliceContainsStr(form.Events, string(models.HookEventRepository)),
 				Release:              com.IsSliceContainsStr(form.Events, string(models.HookEventRelease)),
+				// only organization webhooks are notified of organization events
+				Organization: orgID > 0 && com.IsSliceContainsStr(form.Events, string(models.HookEventOrganization)),
 			},
 			BranchFilter: form.BranchFilter,
 		},
-		IsActive:     form.Active,
-		HookTaskType: models.ToHookTaskType(form.Type),
+		IsActive:           form.Active,
+		HookTaskType:       models.ToHookTaskType(form.Type),
+		SignatureAlgorithm: models.HookSignatureAlgorithm(form.Config["signature_algorithm"]),
+		SignTimestamp:      form.Config["sign_timestamp"] == "true",
 	}
 	if w.HookTaskType == models.SLACK {
 		channel, ok := form.Config["channel"]
@@ -160,6 +174,13 @@ func addHook(ctx *context.APIContext, form *api.CreateHookOption, orgID, repoID
 		w.Meta = string(meta)
 	}
 
+	if w.HookTaskType == models.CUSTOM {
+		w.ContentType = models.ContentTypeJSON
+		if !applyCustomHookConfig(ctx, w, form.Config) {
+			return nil, false
+		}
+	}
+
 	if err := w.UpdateEvent(); err != nil {
 		ctx.Error(http.StatusInternalServerError, "UpdateEvent", err)
 		return nil, false
@@ -211,13 +232,30 @@ func editHook(ctx *context.APIContext, form *api.EditHookOption, w *models.Webho
 		if url, ok := form.Config["url"]; ok {
 			w.URL = url
 		}
-		if ct, ok := form.Config["content_type"]; ok {
+		if ct, ok := form.Config["content_type"]; ok && w.HookTaskType != models.CUSTOM {
 			if !models.IsValidHookContentType(ct) {
 				ctx.Error(http.StatusUnprocessableEntity, "", "Invalid content type")
 				return false
 			}
 			w.ContentType = models.ToHookContentType(ct)
 		}
+		if secret, ok := form.Config["secret"]; ok {
+			w.SetSecret(secret)
+		}
+		if alg, ok := form.Config["signature_algorithm"]; ok {
+			if !models.IsValidHookSignatureAlgorithm(alg) {
+				ctx.Error(http.StatusUnprocessableEntity, "", "Invalid signature algorithm")
+				return false
+			}
+			w.SignatureAlgorithm = models.HookSignatureAlgorithm(alg)
+		}
+		if signTimestamp, ok := form.Config["sign_timestamp"]; ok {
+			w.SignTimestamp = signTimestamp == "true"
+		}
+
+		if w.HookTaskType == models.CUSTOM && !applyCustomHookConfig(ctx, w, form.Config) {
+			return false
+		}
 
 		if w.HookTaskType == models.SLACK {
 			if channel, ok := form.Config["channel"]; ok {
@@ -255,6 +293,7 @@ func editHook(ctx *context.APIContext, form *api.EditHookOption, w *models.Webho
 	w.PullRequest = com.IsSliceContainsStr(form.Events, string(models.HookEventPullRequest))
 	w.Repository = com.IsSliceContainsStr(form.Events, string(models.HookEventRepository))
 	w.Release = com.IsSliceContainsStr(form.Events, string(models.HookEventRelease))
+	w.Organization = w.OrgID > 0 && com.IsSliceContainsStr(form.Events, string(models.HookEventOrganization))
 	w.BranchFilter = form.BranchFilter
 
 	if err := w.UpdateEvent(); err != nil {
@@ -272,3 +311,48 @@ func editHook(ctx *context.APIContext, form *api.EditHookOption, w *models.Webho
 	}
 	return true
 }
+
+// applyCustomHookConfig applies the "template", "content_type", "headers" and "http_method"
+// options of a custom hook to `w`. If they are invalid, write to `ctx` accordingly. Return
+// whether successful
+func applyCustomHookConfig(ctx *context.APIContext, w *models.Webhook, config map[string]string) bool {
+	custom := &webhook.CustomMeta{ContentType: "application/json"}
+	if len(w.Meta) > 0 {
+		custom = webhook.GetCustomHook(w)
+	}
+	if tmpl, ok := config["template"]; ok {
+		custom.Template = tmpl
+	}
+	if ct, ok := config["content_type"]; ok {
+		custom.ContentType = ct
+	}
+	if headers, ok := config["headers"]; ok {
+		parsed, err := webhook.ParseCustomHeaders(headers)
+		if err != nil {
+			ctx.Error(http.StatusUnprocessableEntity, "", "Invalid headers: "+err.Error())
+			return false
+		}
+		custom.Headers = parsed
+	}
+	if method, ok := config["http_method"]; ok {
+		switch method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+			w.HTTPMethod = method
+		default:
+			ctx.Error(http.StatusUnprocessableEntity, "", "Invalid http method")
+			return false
+		}
+	}
+	if _, err := webhook.PreviewCustomPayload(custom); err != nil {
+		ctx.Error(http.StatusUnprocessableEntity, "", "Invalid template: "+err.Error())
+		return false
+	}
+
+	meta, err := json.Marshal(custom)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "custom: JSON marshal failed", err)
+		return false
+	}
+	w.Meta = string(meta)
+	return true
+}
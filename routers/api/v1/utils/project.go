@@ -0,0 +1,78 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+)
+
+// ListProjects writes the projects matching the options to `ctx`, filtered and paginated
+// by the state, page and limit parameters
+func ListProjects(ctx *context.APIContext, opts models.ProjectSearchOptions) {
+	switch api.StateType(ctx.Query("state")) {
+	case api.StateClosed:
+		opts.IsClosed = util.OptionalBoolTrue
+	case api.StateAll:
+		opts.IsClosed = util.OptionalBoolNone
+	default:
+		opts.IsClosed = util.OptionalBoolFalse
+	}
+	listOptions := GetListOptions(ctx)
+	opts.Page = listOptions.Page
+	if opts.Page <= 0 {
+		opts.Page = 1
+	}
+	opts.PageSize = listOptions.PageSize
+
+	projects, _, err := models.GetProjects(opts)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetProjects", err)
+		return
+	}
+	if err := projects.LoadAttributes(); err != nil {
+		ctx.Error(http.StatusInternalServerError, "LoadAttributes", err)
+		return
+	}
+	if err := projects.LoadIssueCounts(); err != nil {
+		ctx.Error(http.StatusInternalServerError, "LoadIssueCounts", err)
+		return
+	}
+
+	apiProjects := make([]*api.Project, len(projects))
+	for i := range projects {
+		apiProjects[i] = convert.ToAPIProject(projects[i])
+	}
+	ctx.JSON(http.StatusOK, &apiProjects)
+}
+
+// CreateProject creates the project described by the form. The type and the
+// repository or organization of the project have to be set by the caller.
+func CreateProject(ctx *context.APIContext, form *api.CreateProjectOption, p *models.Project) {
+	boardType, ok := models.ProjectBoardTypeFromName(form.BoardType)
+	if !ok {
+		ctx.Error(http.StatusUnprocessableEntity, "", "Invalid board type")
+		return
+	}
+
+	p.Title = form.Title
+	p.Description = form.Description
+	p.BoardType = boardType
+	p.CreatorID = ctx.User.ID
+	if err := models.NewProject(p); err != nil {
+		ctx.Error(http.StatusInternalServerError, "NewProject", err)
+		return
+	}
+	if err := p.LoadAttributes(); err != nil {
+		ctx.Error(http.StatusInternalServerError, "LoadAttributes", err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, convert.ToAPIProject(p))
+}
@@ -13,6 +13,15 @@ import (
 	"code.gitea.io/gitea/modules/convert"
 )
 
+// GetTokenRepoIDs returns the repositories the access token of the request is restricted to,
+// or nil if the request may access all the repositories of the user
+func GetTokenRepoIDs(ctx *context.APIContext) []int64 {
+	if token, ok := ctx.Data["ApiToken"].(*models.AccessToken); ok {
+		return token.RepoIDs
+	}
+	return nil
+}
+
 // GetQueryBeforeSince return parsed time (unix format) from URL query's before and since
 func GetQueryBeforeSince(ctx *context.APIContext) (before, since int64, err error) {
 	qCreatedBefore := strings.Trim(ctx.Query("before"), " ")
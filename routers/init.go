@@ -28,10 +28,14 @@ import (
 	"code.gitea.io/gitea/modules/options"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/ssh"
+	"code.gitea.io/gitea/modules/storage"
 	"code.gitea.io/gitea/modules/svg"
 	"code.gitea.io/gitea/modules/task"
 	"code.gitea.io/gitea/modules/webhook"
+	actions_service "code.gitea.io/gitea/services/actions"
+	"code.gitea.io/gitea/services/automerge"
 	"code.gitea.io/gitea/services/mailer"
+	"code.gitea.io/gitea/services/mailer/incoming"
 	mirror_service "code.gitea.io/gitea/services/mirror"
 	pull_service "code.gitea.io/gitea/services/pull"
 
@@ -130,6 +134,9 @@ func GlobalInit(ctx context.Context) {
 		highlight.NewContext()
 		external.RegisterParsers()
 		markup.Init()
+		if err := storage.Init(); err != nil {
+			log.Fatal("Failed to initialize storage: %v", err)
+		}
 		if err := initDBEngine(ctx); err == nil {
 			log.Info("ORM engine initialization successful!")
 		} else {
@@ -151,9 +158,14 @@ func GlobalInit(ctx context.Context) {
 		}
 		mirror_service.InitSyncMirrors()
 		webhook.InitDeliverHooks()
+		incoming.Init()
+		actions_service.Init()
 		if err := pull_service.Init(); err != nil {
 			log.Fatal("Failed to initialize test pull requests queue: %v", err)
 		}
+		if err := automerge.Init(); err != nil {
+			log.Fatal("Failed to initialize pull auto merge queue: %v", err)
+		}
 		if err := task.Init(); err != nil {
 			log.Fatal("Failed to initialize task scheduler: %v", err)
 		}
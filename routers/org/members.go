@@ -10,6 +10,7 @@ import (
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification"
 	"code.gitea.io/gitea/modules/setting"
 
 	"github.com/unknwon/com"
@@ -78,6 +79,8 @@ func MembersAction(ctx *context.Context) {
 
 	org := ctx.Org.Organization
 	var err error
+	// the member who left the organization, for the notification
+	var member *models.User
 	switch ctx.Params(":action") {
 	case "private":
 		if ctx.User.ID != uid && !ctx.Org.IsOwner {
@@ -96,13 +99,17 @@ func MembersAction(ctx *context.Context) {
 			ctx.Error(404)
 			return
 		}
-		err = org.RemoveMember(uid)
+		member, err = models.GetUserByID(uid)
+		if err == nil {
+			err = org.RemoveMember(uid)
+		}
 		if models.IsErrLastOrgOwner(err) {
 			ctx.Flash.Error(ctx.Tr("form.last_org_owner"))
 			ctx.Redirect(ctx.Org.OrgLink + "/members")
 			return
 		}
 	case "leave":
+		member = ctx.User
 		err = org.RemoveMember(ctx.User.ID)
 		if models.IsErrLastOrgOwner(err) {
 			ctx.Flash.Error(ctx.Tr("form.last_org_owner"))
@@ -119,6 +126,9 @@ func MembersAction(ctx *context.Context) {
 		})
 		return
 	}
+	if member != nil {
+		notification.NotifyRemoveOrgMember(ctx.User, org, member)
+	}
 
 	if ctx.Params(":action") != "leave" {
 		ctx.Redirect(ctx.Org.OrgLink + "/members")
@@ -13,6 +13,7 @@ import (
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification"
 	"code.gitea.io/gitea/modules/setting"
 )
 
@@ -71,6 +72,7 @@ func CreatePost(ctx *context.Context, form auth.CreateOrgForm) {
 		return
 	}
 	log.Trace("Organization created: %s", org.Name)
+	notification.NotifyCreateOrganization(ctx.User, org)
 
 	ctx.Redirect(setting.AppSubURL + "/org/" + form.OrgName + "/dashboard")
 }
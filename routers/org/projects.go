@@ -0,0 +1,137 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package org
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/auth"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/routers/repo"
+)
+
+const (
+	tplProjects     base.TplName = "org/projects/list"
+	tplProjectsNew  base.TplName = "org/projects/new"
+	tplProjectsView base.TplName = "org/projects/view"
+)
+
+// MustEnableProjects sets the project links of the organization
+func MustEnableProjects(ctx *context.Context) {
+	ctx.Data["PageIsOrgProjects"] = true
+	ctx.Data["ProjectsLink"] = ctx.Org.OrgLink + "/projects"
+	ctx.Data["CanWriteProjects"] = ctx.Org.IsOwner
+}
+
+// ProjectAssignment loads the project of the organization given by the :id parameter
+func ProjectAssignment(ctx *context.Context) {
+	project, err := models.GetProjectByOwnerID(ctx.Org.Organization.ID, ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrProjectNotExist(err) {
+			ctx.NotFound("", nil)
+		} else {
+			ctx.ServerError("GetProjectByOwnerID", err)
+		}
+		return
+	}
+	project.Owner = ctx.Org.Organization
+	if err := project.LoadAttributes(); err != nil {
+		ctx.ServerError("LoadAttributes", err)
+		return
+	}
+	ctx.Data["Project"] = project
+}
+
+// Projects renders the projects of the organization
+func Projects(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects")
+
+	repo.RenderProjects(ctx, models.ProjectSearchOptions{
+		OwnerID: ctx.Org.Organization.ID,
+		Type:    models.ProjectTypeOrganization,
+	}, ctx.Org.OrgLink, nil)
+	if ctx.Written() {
+		return
+	}
+
+	ctx.HTML(200, tplProjects)
+}
+
+// NewProject renders the page to create a project of the organization
+func NewProject(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects.new")
+	ctx.Data["ProjectTypes"] = models.GetProjectBoardConfigs()
+	ctx.HTML(200, tplProjectsNew)
+}
+
+// NewProjectPost creates a project of the organization
+func NewProjectPost(ctx *context.Context, form auth.CreateProjectForm) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects.new")
+
+	if ctx.HasError() {
+		ctx.Data["ProjectTypes"] = models.GetProjectBoardConfigs()
+		ctx.HTML(200, tplProjectsNew)
+		return
+	}
+
+	if err := models.NewProject(&models.Project{
+		OwnerID:     ctx.Org.Organization.ID,
+		Title:       form.Title,
+		Description: form.Content,
+		CreatorID:   ctx.User.ID,
+		BoardType:   form.BoardType,
+		Type:        models.ProjectTypeOrganization,
+	}); err != nil {
+		ctx.ServerError("NewProject", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.projects.create_success", form.Title))
+	ctx.Redirect(ctx.Org.OrgLink + "/projects")
+}
+
+// EditProject renders the page to edit a project of the organization
+func EditProject(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects.edit")
+	ctx.Data["PageIsEditProjects"] = true
+
+	project := ctx.Data["Project"].(*models.Project)
+	ctx.Data["title"] = project.Title
+	ctx.Data["content"] = project.Description
+
+	ctx.HTML(200, tplProjectsNew)
+}
+
+// EditProjectPost updates a project of the organization
+func EditProjectPost(ctx *context.Context, form auth.CreateProjectForm) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects.edit")
+	ctx.Data["PageIsEditProjects"] = true
+
+	if ctx.HasError() {
+		ctx.HTML(200, tplProjectsNew)
+		return
+	}
+
+	project := ctx.Data["Project"].(*models.Project)
+	project.Title = form.Title
+	project.Description = form.Content
+	if err := models.UpdateProject(project); err != nil {
+		ctx.ServerError("UpdateProject", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.projects.edit_success", project.Title))
+	ctx.Redirect(ctx.Org.OrgLink + "/projects")
+}
+
+// ViewProject renders the boards of a project of the organization
+func ViewProject(ctx *context.Context) {
+	repo.RenderProjectBoards(ctx, ctx.Data["Project"].(*models.Project), ctx.Org.OrgLink, nil)
+	if ctx.Written() {
+		return
+	}
+
+	ctx.HTML(200, tplProjectsView)
+}
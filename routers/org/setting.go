@@ -13,6 +13,7 @@ import (
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification"
 	"code.gitea.io/gitea/modules/setting"
 	userSetting "code.gitea.io/gitea/routers/user/setting"
 )
@@ -160,6 +161,7 @@ func SettingsDelete(ctx *context.Context) {
 			}
 		} else {
 			log.Trace("Organization deleted: %s", org.Name)
+			notification.NotifyDeleteOrganization(ctx.User, org)
 			ctx.Redirect(setting.AppSubURL + "/")
 		}
 		return
@@ -11,10 +11,12 @@ import (
 	"strings"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/auth"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification"
 	"code.gitea.io/gitea/routers/utils"
 
 	"github.com/unknwon/com"
@@ -58,6 +60,9 @@ func TeamsAction(ctx *context.Context) {
 
 	page := ctx.Query("page")
 	var err error
+	// the member who joined or left the team, for the notification
+	var member *models.User
+	var added bool
 	switch ctx.Params(":action") {
 	case "join":
 		if !ctx.Org.IsOwner {
@@ -65,14 +70,19 @@ func TeamsAction(ctx *context.Context) {
 			return
 		}
 		err = ctx.Org.Team.AddMember(ctx.User.ID)
+		member, added = ctx.User, true
 	case "leave":
 		err = ctx.Org.Team.RemoveMember(ctx.User.ID)
+		member = ctx.User
 	case "remove":
 		if !ctx.Org.IsOwner {
 			ctx.Error(404)
 			return
 		}
-		err = ctx.Org.Team.RemoveMember(uid)
+		member, err = models.GetUserByID(uid)
+		if err == nil {
+			err = ctx.Org.Team.RemoveMember(uid)
+		}
 		page = "team"
 	case "add":
 		if !ctx.Org.IsOwner {
@@ -102,6 +112,7 @@ func TeamsAction(ctx *context.Context) {
 			ctx.Flash.Error(ctx.Tr("org.teams.add_duplicate_users"))
 		} else {
 			err = ctx.Org.Team.AddMember(u.ID)
+			member, added = u, true
 		}
 
 		page = "team"
@@ -118,6 +129,14 @@ func TeamsAction(ctx *context.Context) {
 			})
 			return
 		}
+	} else if member != nil {
+		if added {
+			notification.NotifyAddTeamMember(ctx.User, ctx.Org.Team, member)
+			audit.RecordTeam(ctx.User, ctx.RemoteAddr(), models.AuditTeamMemberAdd, ctx.Org.Team, "member: "+member.Name)
+		} else {
+			notification.NotifyRemoveTeamMember(ctx.User, ctx.Org.Team, member)
+			audit.RecordTeam(ctx.User, ctx.RemoteAddr(), models.AuditTeamMemberRemove, ctx.Org.Team, "member: "+member.Name)
+		}
 	}
 
 	switch page {
@@ -138,6 +157,8 @@ func TeamsRepoAction(ctx *context.Context) {
 	}
 
 	var err error
+	// the repository which was added or removed, 0 for all repositories
+	var repoID int64
 	action := ctx.Params(":action")
 	switch action {
 	case "add":
@@ -154,8 +175,10 @@ func TeamsRepoAction(ctx *context.Context) {
 			return
 		}
 		err = ctx.Org.Team.AddRepository(repo)
+		repoID = repo.ID
 	case "remove":
-		err = ctx.Org.Team.RemoveRepository(com.StrTo(ctx.Query("repoid")).MustInt64())
+		repoID = com.StrTo(ctx.Query("repoid")).MustInt64()
+		err = ctx.Org.Team.RemoveRepository(repoID)
 	case "addall":
 		err = ctx.Org.Team.AddAllRepositories()
 	case "removeall":
@@ -168,6 +191,13 @@ func TeamsRepoAction(ctx *context.Context) {
 		return
 	}
 
+	switch action {
+	case "add", "addall":
+		audit.RecordTeamRepository(ctx.User, ctx.RemoteAddr(), models.AuditTeamRepoAdd, ctx.Org.Team, repoID)
+	case "remove", "removeall":
+		audit.RecordTeamRepository(ctx.User, ctx.RemoteAddr(), models.AuditTeamRepoRemove, ctx.Org.Team, repoID)
+	}
+
 	if action == "addall" || action == "removeall" {
 		ctx.JSON(200, map[string]interface{}{
 			"redirect": ctx.Org.OrgLink + "/teams/" + ctx.Org.Team.LowerName + "/repositories",
@@ -238,6 +268,7 @@ func NewTeamPost(ctx *context.Context, form auth.CreateTeamForm) {
 		return
 	}
 	log.Trace("Team created: %s/%s", ctx.Org.Organization.Name, t.Name)
+	audit.RecordTeam(ctx.User, ctx.RemoteAddr(), models.AuditTeamCreate, t, "")
 	ctx.Redirect(ctx.Org.OrgLink + "/teams/" + t.LowerName)
 }
 
@@ -339,6 +370,7 @@ func EditTeamPost(ctx *context.Context, form auth.CreateTeamForm) {
 		}
 		return
 	}
+	audit.RecordTeam(ctx.User, ctx.RemoteAddr(), models.AuditTeamEdit, t, "")
 	ctx.Redirect(ctx.Org.OrgLink + "/teams/" + t.LowerName)
 }
 
@@ -347,6 +379,7 @@ func DeleteTeam(ctx *context.Context) {
 	if err := models.DeleteTeam(ctx.Org.Team); err != nil {
 		ctx.Flash.Error("DeleteTeam: " + err.Error())
 	} else {
+		audit.RecordTeam(ctx.User, ctx.RemoteAddr(), models.AuditTeamDelete, ctx.Org.Team, "")
 		ctx.Flash.Success(ctx.Tr("org.teams.delete_team_success"))
 	}
 
@@ -200,11 +200,53 @@ func HookPreReceive(ctx *macaron.Context, opts private.HookOptions) {
 			private.GitQuarantinePath+"="+opts.GitQuarantinePath)
 	}
 
+	// Readers are let through by serv so that they can propose AGit flow pull requests,
+	// make sure they do not push anything else
+	var pusher *models.User
+	var pusherPerm models.Permission
+	if !opts.IsDeployKey && opts.UserID > 0 {
+		pusher, err = models.GetUserByID(opts.UserID)
+		if err != nil {
+			log.Error("Unable to get User id %d Error: %v", opts.UserID, err)
+			ctx.JSON(http.StatusInternalServerError, map[string]interface{}{
+				"err": fmt.Sprintf("Unable to get User id %d Error: %v", opts.UserID, err),
+			})
+			return
+		}
+		pusherPerm, err = models.GetUserRepoPermission(repo, pusher)
+		if err != nil {
+			log.Error("Unable to get Repo permission of repo %s/%s of User %s: %v", repo.OwnerName, repo.Name, pusher.Name, err)
+			ctx.JSON(http.StatusInternalServerError, map[string]interface{}{
+				"err": fmt.Sprintf("Unable to get Repo permission of repo %s/%s of User %s: %v", repo.OwnerName, repo.Name, pusher.Name, err),
+			})
+			return
+		}
+	}
+
 	for i := range opts.OldCommitIDs {
 		oldCommitID := opts.OldCommitIDs[i]
 		newCommitID := opts.NewCommitIDs[i]
 		refFullName := opts.RefFullNames[i]
 
+		if strings.HasPrefix(refFullName, git.AGitPullRequestPrefix) {
+			if !preReceiveAGitPullRequest(ctx, repo, gitRepo, env, pusher, &pusherPerm, refFullName, newCommitID) {
+				return
+			}
+			continue
+		}
+
+		if pusher != nil && !pusherPerm.CanWrite(models.UnitTypeCode) {
+			log.Warn("Forbidden: User %d is not allowed to push to %s in %-v", opts.UserID, refFullName, repo)
+			ctx.JSON(http.StatusForbidden, map[string]interface{}{
+				"err": fmt.Sprintf("User permission denied for writing to %s, pull requests can be proposed by pushing to %s<branch>/<topic>", refFullName, git.AGitPullRequestPrefix),
+			})
+			return
+		}
+
+		if !strings.HasPrefix(refFullName, git.BranchPrefix) {
+			continue
+		}
+
 		branchName := strings.TrimPrefix(refFullName, git.BranchPrefix)
 		if branchName == repo.DefaultBranch && newCommitID == git.EmptySHA {
 			log.Warn("Forbidden: Branch: %s is the default branch in %-v and cannot be deleted", branchName, repo)
@@ -368,6 +410,49 @@ func HookPreReceive(ctx *macaron.Context, opts private.HookOptions) {
 	ctx.PlainText(http.StatusOK, []byte("ok"))
 }
 
+// preReceiveAGitPullRequest checks a push to refs/for/<base branch>/<topic>, which proposes
+// a pull request. It writes the error response and returns false if the push is refused.
+func preReceiveAGitPullRequest(ctx *macaron.Context, repo *models.Repository, gitRepo *git.Repository, env []string, pusher *models.User, perm *models.Permission, refFullName, newCommitID string) bool {
+	if pusher == nil || !repo.AllowsPulls() || !perm.CanPushAGitPullRequest() {
+		log.Warn("Forbidden: %s in %-v can only be pushed to by users allowed to create pull requests", refFullName, repo)
+		ctx.JSON(http.StatusForbidden, map[string]interface{}{
+			"err": fmt.Sprintf("Not allowed to create pull requests by pushing to %s", refFullName),
+		})
+		return false
+	}
+
+	if newCommitID == git.EmptySHA {
+		ctx.JSON(http.StatusForbidden, map[string]interface{}{
+			"err": fmt.Sprintf("%s can not be deleted, close the pull request instead", refFullName),
+		})
+		return false
+	}
+
+	baseBranch, _ := pull_service.ParseAGitRef(gitRepo, refFullName)
+	if len(baseBranch) == 0 {
+		ctx.JSON(http.StatusForbidden, map[string]interface{}{
+			"err": fmt.Sprintf("%s does not name an existing branch and a topic, push to %s<branch>/<topic>", refFullName, git.AGitPullRequestPrefix),
+		})
+		return false
+	}
+
+	output, err := git.NewCommand("rev-list", "--max-count=1", newCommitID, "^"+git.BranchPrefix+baseBranch).RunInDirWithEnv(repo.RepoPath(), env)
+	if err != nil {
+		log.Error("Unable to compare %s with branch %s in %-v Error: %v", newCommitID, baseBranch, repo, err)
+		ctx.JSON(http.StatusInternalServerError, map[string]interface{}{
+			"err": fmt.Sprintf("Unable to compare %s with branch %s: %v", newCommitID, baseBranch, err),
+		})
+		return false
+	} else if len(output) == 0 {
+		ctx.JSON(http.StatusForbidden, map[string]interface{}{
+			"err": fmt.Sprintf("%s has no commits which are not in branch %s", refFullName, baseBranch),
+		})
+		return false
+	}
+
+	return true
+}
+
 // HookPostReceive updates services and users
 func HookPostReceive(ctx *macaron.Context, opts private.HookOptions) {
 	ownerName := ctx.Params(":owner")
@@ -444,6 +529,20 @@ func HookPostReceive(ctx *macaron.Context, opts private.HookOptions) {
 		refFullName := opts.RefFullNames[i]
 		newCommitID := opts.NewCommitIDs[i]
 
+		if strings.HasPrefix(refFullName, git.AGitPullRequestPrefix) && newCommitID != git.EmptySHA {
+			result, err := postReceiveAGitPullRequest(ownerName, repoName, opts.UserID, refFullName, newCommitID)
+			if err != nil {
+				log.Error("Failed to push the pull request of %s in %s/%s Error: %v", refFullName, ownerName, repoName, err)
+				ctx.JSON(http.StatusInternalServerError, private.HookPostReceiveResult{
+					Err:          fmt.Sprintf("Failed to push the pull request of %s in %s/%s Error: %v", refFullName, ownerName, repoName, err),
+					RepoWasEmpty: wasEmpty,
+				})
+				return
+			}
+			results = append(results, result)
+			continue
+		}
+
 		branch := git.RefEndName(opts.RefFullNames[i])
 
 		if newCommitID != git.EmptySHA && strings.HasPrefix(refFullName, git.BranchPrefix) {
@@ -489,7 +588,7 @@ func HookPostReceive(ctx *macaron.Context, opts private.HookOptions) {
 				continue
 			}
 
-			pr, err := models.GetUnmergedPullRequest(repo.ID, baseRepo.ID, branch, baseRepo.DefaultBranch)
+			pr, err := models.GetUnmergedPullRequest(repo.ID, baseRepo.ID, branch, baseRepo.DefaultBranch, models.PullRequestFlowGithub)
 			if err != nil && !models.IsErrPullRequestNotExist(err) {
 				log.Error("Failed to get active PR in: %-v Branch: %s to: %-v Branch: %s Error: %v", repo, branch, baseRepo, baseRepo.DefaultBranch, err)
 				ctx.JSON(http.StatusInternalServerError, private.HookPostReceiveResult{
@@ -526,6 +625,40 @@ func HookPostReceive(ctx *macaron.Context, opts private.HookOptions) {
 	})
 }
 
+// postReceiveAGitPullRequest creates or updates the pull request proposed by pushing to
+// refs/for/<base branch>/<topic>
+func postReceiveAGitPullRequest(ownerName, repoName string, pusherID int64, refFullName, newCommitID string) (private.HookPostReceiveBranchResult, error) {
+	repo, err := models.GetRepositoryByOwnerAndName(ownerName, repoName)
+	if err != nil {
+		return private.HookPostReceiveBranchResult{}, err
+	}
+	pusher, err := models.GetUserByID(pusherID)
+	if err != nil {
+		return private.HookPostReceiveBranchResult{}, err
+	}
+
+	gitRepo, err := git.OpenRepository(repo.RepoPath())
+	if err != nil {
+		return private.HookPostReceiveBranchResult{}, err
+	}
+	baseBranch, topic := pull_service.ParseAGitRef(gitRepo, refFullName)
+	gitRepo.Close()
+	if len(baseBranch) == 0 {
+		return private.HookPostReceiveBranchResult{}, fmt.Errorf("%s does not name an existing branch and a topic", refFullName)
+	}
+
+	pr, _, err := pull_service.PushAGitPullRequest(pusher, repo, refFullName, baseBranch, topic, newCommitID)
+	if err != nil {
+		return private.HookPostReceiveBranchResult{}, err
+	}
+	return private.HookPostReceiveBranchResult{
+		Message: true,
+		Create:  false,
+		Branch:  pr.HeadBranch,
+		URL:     fmt.Sprintf("%s/pulls/%d", repo.HTMLURL(), pr.Index),
+	}, nil
+}
+
 // SetDefaultBranch updates the default branch
 func SetDefaultBranch(ctx *macaron.Context) {
 	ownerName := ctx.Params(":owner")
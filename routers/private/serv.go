@@ -276,6 +276,12 @@ func ServCommand(ctx *macaron.Context) {
 
 			userMode := perm.UnitAccessMode(unitType)
 
+			// Readers may push to propose AGit flow pull requests, the pre-receive hook
+			// rejects anything else they push
+			if userMode < mode && isAGitPush(ctx.QueryStrings("verb"), mode, unitType, repo, &perm) {
+				userMode = mode
+			}
+
 			if userMode < mode {
 				ctx.JSON(http.StatusUnauthorized, map[string]interface{}{
 					"results": results,
@@ -374,3 +380,15 @@ func ServCommand(ctx *macaron.Context) {
 	ctx.JSON(http.StatusOK, results)
 	// We will update the keys in a different call.
 }
+
+func isAGitPush(verbs []string, mode models.AccessMode, unitType models.UnitType, repo *models.Repository, perm *models.Permission) bool {
+	if mode != models.AccessModeWrite || unitType != models.UnitTypeCode || !repo.AllowsPulls() || !perm.CanPushAGitPullRequest() {
+		return false
+	}
+	for _, verb := range verbs {
+		if verb == "git-receive-pack" {
+			return true
+		}
+	}
+	return false
+}
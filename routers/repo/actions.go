@@ -0,0 +1,177 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"bytes"
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/actions"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	actions_service "code.gitea.io/gitea/services/actions"
+)
+
+const (
+	tplActions    base.TplName = "repo/actions/list"
+	tplActionsRun base.TplName = "repo/actions/view"
+)
+
+// MustEnableActions checks if actions are enabled for the instance and the repository
+func MustEnableActions(ctx *context.Context) {
+	if !setting.Actions.Enabled || models.UnitTypeActions.UnitGlobalDisabled() || !ctx.Repo.CanRead(models.UnitTypeActions) {
+		ctx.NotFound("EnableActions", nil)
+		return
+	}
+	ctx.Data["PageIsActions"] = true
+	ctx.Data["CanWriteActions"] = ctx.Repo.CanWrite(models.UnitTypeActions) && !ctx.Repo.Repository.IsArchived
+}
+
+// Actions renders the runs of the repository
+func Actions(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.actions")
+
+	page := ctx.QueryInt("page")
+	if page <= 1 {
+		page = 1
+	}
+	opts := models.ActionRunSearchOptions{
+		ListOptions: models.ListOptions{
+			Page:     page,
+			PageSize: setting.UI.IssuePagingNum,
+		},
+		RepoID: ctx.Repo.Repository.ID,
+	}
+	status := ctx.Query("status")
+	if status != "" {
+		opts.Status, _ = models.ActionStatusFromString(status)
+	}
+
+	runs, total, err := models.GetActionRuns(opts)
+	if err != nil {
+		ctx.ServerError("GetActionRuns", err)
+		return
+	}
+	for _, run := range runs {
+		run.Repo = ctx.Repo.Repository
+		if err := run.LoadAttributes(); err != nil {
+			ctx.ServerError("LoadAttributes", err)
+			return
+		}
+	}
+	ctx.Data["Runs"] = runs
+	ctx.Data["Status"] = status
+	ctx.Data["StatusFilters"] = []string{"waiting", "running", "success", "failure", "cancelled"}
+	ctx.Data["WorkflowsDir"] = actions.WorkflowsDir
+
+	pager := context.NewPagination(int(total), setting.UI.IssuePagingNum, page, 5)
+	pager.AddParam(ctx, "status", "Status")
+	ctx.Data["Page"] = pager
+
+	ctx.HTML(200, tplActions)
+}
+
+// ActionRunAssignment loads the run of the repository given by the :index parameter
+func ActionRunAssignment(ctx *context.Context) {
+	run, err := models.GetActionRunByIndex(ctx.Repo.Repository.ID, ctx.ParamsInt64(":index"))
+	if err != nil {
+		if models.IsErrActionRunNotExist(err) {
+			ctx.NotFound("GetActionRunByIndex", err)
+		} else {
+			ctx.ServerError("GetActionRunByIndex", err)
+		}
+		return
+	}
+	run.Repo = ctx.Repo.Repository
+	if err := run.LoadAttributes(); err != nil {
+		ctx.ServerError("LoadAttributes", err)
+		return
+	}
+	ctx.Data["Run"] = run
+}
+
+// actionRunJob returns the job of the run with the given ID
+func actionRunJob(ctx *context.Context, jobs []*models.ActionRunJob, id int64) *models.ActionRunJob {
+	for _, job := range jobs {
+		if job.ID == id {
+			return job
+		}
+	}
+	ctx.NotFound("actionRunJob", nil)
+	return nil
+}
+
+// ViewActionRun renders a run with its jobs, the steps and the log of the selected job
+func ViewActionRun(ctx *context.Context) {
+	run := ctx.Data["Run"].(*models.ActionRun)
+	ctx.Data["Title"] = run.Title
+
+	jobs, err := models.GetActionRunJobs(run.ID)
+	if err != nil {
+		ctx.ServerError("GetActionRunJobs", err)
+		return
+	}
+	ctx.Data["Jobs"] = jobs
+	if len(jobs) == 0 {
+		ctx.HTML(200, tplActionsRun)
+		return
+	}
+
+	job := jobs[0]
+	if id := ctx.QueryInt64("job"); id > 0 {
+		if job = actionRunJob(ctx, jobs, id); ctx.Written() {
+			return
+		}
+	}
+	ctx.Data["Job"] = job
+
+	steps, err := models.GetActionRunSteps(job.ID)
+	if err != nil {
+		ctx.ServerError("GetActionRunSteps", err)
+		return
+	}
+	ctx.Data["Steps"] = steps
+
+	var buf bytes.Buffer
+	if err := actions_service.ReadLog(job, &buf); err != nil {
+		log.Error("ReadLog: %v", err)
+	}
+	ctx.Data["Log"] = buf.String()
+
+	ctx.HTML(200, tplActionsRun)
+}
+
+// ActionRunJobLog serves the raw log of a job
+func ActionRunJobLog(ctx *context.Context) {
+	run := ctx.Data["Run"].(*models.ActionRun)
+	jobs, err := models.GetActionRunJobs(run.ID)
+	if err != nil {
+		ctx.ServerError("GetActionRunJobs", err)
+		return
+	}
+	job := actionRunJob(ctx, jobs, ctx.ParamsInt64(":job"))
+	if ctx.Written() {
+		return
+	}
+
+	ctx.Resp.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	ctx.Resp.WriteHeader(http.StatusOK)
+	if err := actions_service.ReadLog(job, ctx.Resp); err != nil {
+		log.Error("ReadLog: %v", err)
+	}
+}
+
+// CancelActionRun cancels the jobs of a run which are not done yet
+func CancelActionRun(ctx *context.Context) {
+	run := ctx.Data["Run"].(*models.ActionRun)
+	if err := actions_service.CancelRun(run); err != nil {
+		ctx.ServerError("CancelRun", err)
+		return
+	}
+	ctx.Redirect(run.Link())
+}
@@ -209,6 +209,17 @@ func HTTP(ctx *context.Context) {
 				if err = models.UpdateAccessToken(token); err != nil {
 					ctx.ServerError("UpdateAccessToken", err)
 				}
+
+				requiredScope := models.AccessTokenScopeRepoRead
+				if !isPull {
+					requiredScope = models.AccessTokenScopeRepoWrite
+				}
+				// a token restricted to some repositories can't create new ones by pushing
+				if !token.Scope.Has(requiredScope) || (repoExist && !token.CanAccessRepo(repo.ID)) ||
+					(!repoExist && len(token.RepoIDs) > 0) {
+					ctx.HandleText(http.StatusForbidden, "Token permission denied")
+					return
+				}
 			} else if !models.IsErrAccessTokenNotExist(err) && !models.IsErrAccessTokenEmpty(err) {
 				log.Error("GetAccessTokenBySha: %v", err)
 			}
@@ -250,7 +261,10 @@ func HTTP(ctx *context.Context) {
 				return
 			}
 
-			if !perm.CanAccess(accessMode, unitType) {
+			// Readers may push to propose AGit flow pull requests, the pre-receive hook
+			// rejects anything else they push
+			canProposeByPush := receivePack && unitType == models.UnitTypeCode && repo.AllowsPulls() && perm.CanPushAGitPullRequest()
+			if !perm.CanAccess(accessMode, unitType) && !canProposeByPush {
 				ctx.HandleText(http.StatusForbidden, "User permission denied")
 				return
 			}
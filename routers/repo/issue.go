@@ -393,6 +393,35 @@ func RetrieveRepoMilestonesAndAssignees(ctx *context.Context, repo *models.Repos
 	}
 }
 
+func retrieveRepoProjects(ctx *context.Context, repo *models.Repository) {
+	opts := models.ProjectSearchOptions{
+		RepoID:   repo.ID,
+		Type:     models.ProjectTypeRepository,
+		IsClosed: util.OptionalBoolFalse,
+	}
+	projects, _, err := models.GetProjects(opts)
+	if err != nil {
+		ctx.ServerError("GetProjects", err)
+		return
+	}
+	if repo.Owner.IsOrganization() {
+		opts.RepoID = 0
+		opts.OwnerID = repo.OwnerID
+		opts.Type = models.ProjectTypeOrganization
+		orgProjects, _, err := models.GetProjects(opts)
+		if err != nil {
+			ctx.ServerError("GetProjects", err)
+			return
+		}
+		projects = append(projects, orgProjects...)
+	}
+	if err := projects.LoadAttributes(); err != nil {
+		ctx.ServerError("LoadAttributes", err)
+		return
+	}
+	ctx.Data["OpenProjects"] = projects
+}
+
 // RetrieveRepoReviewers find all reviewers of a repository
 func RetrieveRepoReviewers(ctx *context.Context, repo *models.Repository, issuePosterID int64) {
 	var err error
@@ -835,6 +864,21 @@ func ViewIssue(ctx *context.Context) {
 		}
 	}
 
+	// Check project.
+	if ctx.Repo.CanRead(models.UnitTypeProjects) {
+		ctx.Data["IsProjectsEnabled"] = true
+		if err = issue.LoadProject(); err != nil {
+			ctx.ServerError("LoadProject", err)
+			return
+		}
+		if ctx.Repo.CanWriteIssuesOrPulls(issue.IsPull) {
+			retrieveRepoProjects(ctx, repo)
+			if ctx.Written() {
+				return
+			}
+		}
+	}
+
 	if issue.IsPull {
 		canChooseReviewer := ctx.Repo.CanWrite(models.UnitTypePullRequests)
 		if !canChooseReviewer && ctx.User != nil && ctx.IsSigned {
@@ -953,6 +997,11 @@ func ViewIssue(ctx *context.Context) {
 				ctx.ServerError("LoadLabel", err)
 				return
 			}
+		} else if comment.Type == models.CommentTypeProject {
+			if err = comment.LoadProject(); err != nil {
+				ctx.ServerError("LoadProject", err)
+				return
+			}
 		} else if comment.Type == models.CommentTypeMilestone {
 			if err = comment.LoadMilestone(); err != nil {
 				ctx.ServerError("LoadMilestone", err)
@@ -979,7 +1028,7 @@ func ViewIssue(ctx *context.Context) {
 				return
 			}
 		} else if comment.Type == models.CommentTypeCode || comment.Type == models.CommentTypeReview {
-			comment.RenderedContent = string(markdown.Render([]byte(comment.Content), ctx.Repo.RepoLink,
+			comment.RenderedContent = string(markdown.Render([]byte(comment.ContentWithSuggestionDiffs()), ctx.Repo.RepoLink,
 				ctx.Repo.Repository.ComposeMetas()))
 			if err = comment.LoadReview(); err != nil && !models.IsErrReviewNotExist(err) {
 				ctx.ServerError("LoadReview", err)
@@ -1024,7 +1073,7 @@ func ViewIssue(ctx *context.Context) {
 		if ctx.IsSigned {
 			if err := pull.LoadHeadRepo(); err != nil {
 				log.Error("LoadHeadRepo: %v", err)
-			} else if pull.HeadRepo != nil && pull.HeadBranch != pull.HeadRepo.DefaultBranch {
+			} else if pull.HeadRepo != nil && pull.Flow == models.PullRequestFlowGithub && pull.HeadBranch != pull.HeadRepo.DefaultBranch {
 				perm, err := models.GetUserRepoPermission(pull.HeadRepo, ctx.User)
 				if err != nil {
 					ctx.ServerError("GetUserRepoPermission", err)
@@ -1068,6 +1117,13 @@ func ViewIssue(ctx *context.Context) {
 		}
 		prConfig := prUnit.PullRequestsConfig()
 
+		if exist, autoMerge, err := models.GetScheduledMergeByPullID(pull.ID); err != nil && !models.IsErrUserNotExist(err) {
+			ctx.ServerError("GetScheduledMergeByPullID", err)
+			return
+		} else if exist && autoMerge.Doer != nil {
+			ctx.Data["PullAutoMerge"] = autoMerge
+		}
+
 		// Check correct values and select default
 		if ms, ok := ctx.Data["MergeStyle"].(models.MergeStyle); !ok ||
 			!prConfig.IsMergeStyleAllowed(ms) {
@@ -1079,6 +1135,10 @@ func ViewIssue(ctx *context.Context) {
 				ctx.Data["MergeStyle"] = models.MergeStyleRebaseMerge
 			} else if prConfig.AllowSquash {
 				ctx.Data["MergeStyle"] = models.MergeStyleSquash
+			} else if prConfig.AllowFastForwardOnly {
+				ctx.Data["MergeStyle"] = models.MergeStyleFastForwardOnly
+			} else if prConfig.AllowSemiLinear {
+				ctx.Data["MergeStyle"] = models.MergeStyleSemiLinear
 			} else {
 				ctx.Data["MergeStyle"] = ""
 			}
@@ -1092,6 +1152,14 @@ func ViewIssue(ctx *context.Context) {
 			ctx.Data["IsBlockedByApprovals"] = !pull.ProtectedBranch.HasEnoughApprovals(pull)
 			ctx.Data["IsBlockedByRejection"] = pull.ProtectedBranch.MergeBlockedByRejectedReview(pull)
 			ctx.Data["IsBlockedByOutdatedBranch"] = pull.ProtectedBranch.MergeBlockedByOutdatedBranch(pull)
+			if pull.ProtectedBranch.RequireCodeOwnerApproval {
+				approved, err := pull_service.HasCodeOwnerApprovals(pull)
+				if err != nil {
+					ctx.ServerError("HasCodeOwnerApprovals", err)
+					return
+				}
+				ctx.Data["IsBlockedByCodeOwners"] = !approved
+			}
 			ctx.Data["GrantedApprovals"] = cnt
 			ctx.Data["RequireSigned"] = pull.ProtectedBranch.RequireSignedCommits
 		}
@@ -1551,7 +1619,7 @@ func NewComment(ctx *context.Context, form auth.CreateCommentForm) {
 			if form.Status == "reopen" && issue.IsPull {
 				pull := issue.PullRequest
 				var err error
-				pr, err = models.GetUnmergedPullRequest(pull.HeadRepoID, pull.BaseRepoID, pull.HeadBranch, pull.BaseBranch)
+				pr, err = models.GetUnmergedPullRequest(pull.HeadRepoID, pull.BaseRepoID, pull.HeadBranch, pull.BaseBranch, pull.Flow)
 				if err != nil {
 					if !models.IsErrPullRequestNotExist(err) {
 						ctx.ServerError("GetUnmergedPullRequest", err)
@@ -1666,7 +1734,7 @@ func UpdateCommentContent(ctx *context.Context) {
 	}
 
 	ctx.JSON(200, map[string]interface{}{
-		"content":     string(markdown.Render([]byte(comment.Content), ctx.Query("context"), ctx.Repo.Repository.ComposeMetas())),
+		"content":     string(markdown.Render([]byte(comment.ContentWithSuggestionDiffs()), ctx.Query("context"), ctx.Repo.Repository.ComposeMetas())),
 		"attachments": attachmentsHTML(ctx, comment.Attachments),
 	})
 }
@@ -0,0 +1,425 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/auth"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/markup/markdown"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+)
+
+const (
+	tplProjects     base.TplName = "repo/projects/list"
+	tplProjectsNew  base.TplName = "repo/projects/new"
+	tplProjectsView base.TplName = "repo/projects/view"
+)
+
+// MustEnableProjects check if projects are enabled in settings
+func MustEnableProjects(ctx *context.Context) {
+	if models.UnitTypeProjects.UnitGlobalDisabled() || !ctx.Repo.CanRead(models.UnitTypeProjects) {
+		ctx.NotFound("EnableProjects", nil)
+		return
+	}
+	ctx.Data["PageIsProjects"] = true
+	ctx.Data["ProjectsLink"] = ctx.Repo.RepoLink + "/projects"
+	ctx.Data["CanWriteProjects"] = ctx.Repo.CanWrite(models.UnitTypeProjects) && !ctx.Repo.Repository.IsArchived
+}
+
+// ProjectAssignment loads the project of the repository given by the :id parameter
+func ProjectAssignment(ctx *context.Context) {
+	project, err := models.GetProjectByRepoID(ctx.Repo.Repository.ID, ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrProjectNotExist(err) {
+			ctx.NotFound("", nil)
+		} else {
+			ctx.ServerError("GetProjectByRepoID", err)
+		}
+		return
+	}
+	project.Repo = ctx.Repo.Repository
+	if err := project.LoadAttributes(); err != nil {
+		ctx.ServerError("LoadAttributes", err)
+		return
+	}
+	ctx.Data["Project"] = project
+}
+
+// Projects renders the home page of projects
+func Projects(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects")
+
+	RenderProjects(ctx, models.ProjectSearchOptions{
+		RepoID: ctx.Repo.Repository.ID,
+		Type:   models.ProjectTypeRepository,
+	}, ctx.Repo.RepoLink, ctx.Repo.Repository.ComposeMetas())
+	if ctx.Written() {
+		return
+	}
+
+	ctx.HTML(200, tplProjects)
+}
+
+// RenderProjects loads the page of projects matching the options into the context
+func RenderProjects(ctx *context.Context, opts models.ProjectSearchOptions, urlPrefix string, metas map[string]string) {
+	isShowClosed := ctx.Query("state") == "closed"
+	page := ctx.QueryInt("page")
+	if page <= 1 {
+		page = 1
+	}
+
+	openCount, closedCount, err := models.CountProjects(opts)
+	if err != nil {
+		ctx.ServerError("CountProjects", err)
+		return
+	}
+	ctx.Data["OpenCount"] = openCount
+	ctx.Data["ClosedCount"] = closedCount
+
+	opts.Page = page
+	opts.IsClosed = util.OptionalBoolOf(isShowClosed)
+	opts.SortType = ctx.Query("sort")
+	projects, total, err := models.GetProjects(opts)
+	if err != nil {
+		ctx.ServerError("GetProjects", err)
+		return
+	}
+	if err := projects.LoadAttributes(); err != nil {
+		ctx.ServerError("LoadAttributes", err)
+		return
+	}
+	if err := projects.LoadIssueCounts(); err != nil {
+		ctx.ServerError("LoadIssueCounts", err)
+		return
+	}
+	for _, p := range projects {
+		p.RenderedContent = string(markdown.Render([]byte(p.Description), urlPrefix, metas))
+	}
+	ctx.Data["Projects"] = projects
+
+	if isShowClosed {
+		ctx.Data["State"] = "closed"
+	} else {
+		ctx.Data["State"] = "open"
+	}
+	ctx.Data["IsShowClosed"] = isShowClosed
+	ctx.Data["SortType"] = opts.SortType
+
+	pager := context.NewPagination(int(total), setting.UI.IssuePagingNum, page, 5)
+	pager.AddParam(ctx, "state", "State")
+	ctx.Data["Page"] = pager
+}
+
+// NewProject renders the page to create a project
+func NewProject(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects.new")
+	ctx.Data["ProjectTypes"] = models.GetProjectBoardConfigs()
+	ctx.HTML(200, tplProjectsNew)
+}
+
+// NewProjectPost creates a new project
+func NewProjectPost(ctx *context.Context, form auth.CreateProjectForm) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects.new")
+
+	if ctx.HasError() {
+		ctx.Data["ProjectTypes"] = models.GetProjectBoardConfigs()
+		ctx.HTML(200, tplProjectsNew)
+		return
+	}
+
+	if err := models.NewProject(&models.Project{
+		RepoID:      ctx.Repo.Repository.ID,
+		Title:       form.Title,
+		Description: form.Content,
+		CreatorID:   ctx.User.ID,
+		BoardType:   form.BoardType,
+		Type:        models.ProjectTypeRepository,
+	}); err != nil {
+		ctx.ServerError("NewProject", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.projects.create_success", form.Title))
+	ctx.Redirect(ctx.Repo.RepoLink + "/projects")
+}
+
+// EditProject renders the page to edit a project
+func EditProject(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects.edit")
+	ctx.Data["PageIsEditProjects"] = true
+
+	project := ctx.Data["Project"].(*models.Project)
+	ctx.Data["title"] = project.Title
+	ctx.Data["content"] = project.Description
+
+	ctx.HTML(200, tplProjectsNew)
+}
+
+// EditProjectPost updates a project
+func EditProjectPost(ctx *context.Context, form auth.CreateProjectForm) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects.edit")
+	ctx.Data["PageIsEditProjects"] = true
+
+	if ctx.HasError() {
+		ctx.HTML(200, tplProjectsNew)
+		return
+	}
+
+	project := ctx.Data["Project"].(*models.Project)
+	project.Title = form.Title
+	project.Description = form.Content
+	if err := models.UpdateProject(project); err != nil {
+		ctx.ServerError("UpdateProject", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.projects.edit_success", project.Title))
+	ctx.Redirect(ctx.Data["ProjectsLink"].(string))
+}
+
+// ChangeProjectStatus opens or closes a project
+func ChangeProjectStatus(ctx *context.Context) {
+	project := ctx.Data["Project"].(*models.Project)
+	projectsLink := ctx.Data["ProjectsLink"].(string)
+
+	isClosed := ctx.Params(":action") == "close"
+	if project.IsClosed != isClosed {
+		if err := models.ChangeProjectStatus(project, isClosed); err != nil {
+			ctx.ServerError("ChangeProjectStatus", err)
+			return
+		}
+	}
+
+	if isClosed {
+		ctx.Redirect(projectsLink + "?state=closed")
+	} else {
+		ctx.Redirect(projectsLink + "?state=open")
+	}
+}
+
+// DeleteProject deletes a project
+func DeleteProject(ctx *context.Context) {
+	project := ctx.Data["Project"].(*models.Project)
+
+	if err := models.DeleteProjectByID(project.ID); err != nil {
+		ctx.Flash.Error("DeleteProjectByID: " + err.Error())
+	} else {
+		ctx.Flash.Success(ctx.Tr("repo.projects.deletion_success"))
+	}
+
+	ctx.JSON(200, map[string]interface{}{
+		"redirect": ctx.Data["ProjectsLink"],
+	})
+}
+
+// ViewProject renders the boards of a project
+func ViewProject(ctx *context.Context) {
+	project := ctx.Data["Project"].(*models.Project)
+
+	RenderProjectBoards(ctx, project, ctx.Repo.RepoLink, ctx.Repo.Repository.ComposeMetas())
+	if ctx.Written() {
+		return
+	}
+
+	ctx.HTML(200, tplProjectsView)
+}
+
+// RenderProjectBoards loads the boards of the project and their cards into the context
+func RenderProjectBoards(ctx *context.Context, project *models.Project, urlPrefix string, metas map[string]string) {
+	boards, err := models.GetProjectBoards(project.ID)
+	if err != nil {
+		ctx.ServerError("GetProjectBoards", err)
+		return
+	}
+	uncategorized := models.GetUncategorizedBoard(project.ID)
+	uncategorized.Title = ctx.Tr("repo.projects.type.uncategorized")
+	boards = append(models.ProjectBoardList{uncategorized}, boards...)
+
+	if err := boards.LoadIssues(ctx.User); err != nil {
+		ctx.ServerError("LoadIssues", err)
+		return
+	}
+
+	project.RenderedContent = string(markdown.Render([]byte(project.Description), urlPrefix, metas))
+	ctx.Data["Title"] = project.Title
+	ctx.Data["Boards"] = boards
+}
+
+// UpdateIssueProject adds the issues to a project or removes them from their project
+func UpdateIssueProject(ctx *context.Context) {
+	issues := getActionIssues(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	projectID := ctx.QueryInt64("id")
+	for _, issue := range issues {
+		if issue.RepoID != ctx.Repo.Repository.ID {
+			ctx.NotFound("", nil)
+			return
+		}
+		if err := models.ChangeProjectAssign(issue, ctx.User, projectID); err != nil {
+			if models.IsErrProjectNotExist(err) {
+				ctx.NotFound("", nil)
+			} else {
+				ctx.ServerError("ChangeProjectAssign", err)
+			}
+			return
+		}
+	}
+
+	ctx.JSON(200, map[string]interface{}{
+		"ok": true,
+	})
+}
+
+// AddBoardToProjectPost adds a board to the project
+func AddBoardToProjectPost(ctx *context.Context, form auth.EditProjectBoardForm) {
+	if ctx.HasError() {
+		ctx.JSON(422, map[string]string{
+			"message": ctx.GetErrMsg(),
+		})
+		return
+	}
+
+	project := ctx.Data["Project"].(*models.Project)
+	if err := models.NewProjectBoard(&models.ProjectBoard{
+		ProjectID: project.ID,
+		Title:     form.Title,
+		Sorting:   form.Sorting,
+		IsDefault: form.IsDefault,
+		IsDone:    form.IsDone,
+		CreatorID: ctx.User.ID,
+	}); err != nil {
+		ctx.ServerError("NewProjectBoard", err)
+		return
+	}
+
+	ctx.JSON(200, map[string]interface{}{
+		"ok": true,
+	})
+}
+
+func getProjectBoardFromContext(ctx *context.Context) *models.ProjectBoard {
+	project := ctx.Data["Project"].(*models.Project)
+
+	boardID := ctx.ParamsInt64(":boardID")
+	if boardID == 0 {
+		return models.GetUncategorizedBoard(project.ID)
+	}
+
+	board, err := models.GetProjectBoard(project.ID, boardID)
+	if err != nil {
+		if models.IsErrProjectBoardNotExist(err) {
+			ctx.NotFound("", nil)
+		} else {
+			ctx.ServerError("GetProjectBoard", err)
+		}
+		return nil
+	}
+	return board
+}
+
+// EditProjectBoard updates the title, position and flags of a board
+func EditProjectBoard(ctx *context.Context, form auth.EditProjectBoardForm) {
+	if ctx.HasError() {
+		ctx.JSON(422, map[string]string{
+			"message": ctx.GetErrMsg(),
+		})
+		return
+	}
+
+	board := getProjectBoardFromContext(ctx)
+	if ctx.Written() {
+		return
+	}
+	if board.IsUncategorized() {
+		ctx.NotFound("", nil)
+		return
+	}
+
+	board.Title = form.Title
+	board.Sorting = form.Sorting
+	board.IsDefault = form.IsDefault
+	board.IsDone = form.IsDone
+	if err := models.UpdateProjectBoard(board); err != nil {
+		ctx.ServerError("UpdateProjectBoard", err)
+		return
+	}
+
+	ctx.JSON(200, map[string]interface{}{
+		"ok": true,
+	})
+}
+
+// DeleteProjectBoard removes a board from its project
+func DeleteProjectBoard(ctx *context.Context) {
+	board := getProjectBoardFromContext(ctx)
+	if ctx.Written() {
+		return
+	}
+	if board.IsUncategorized() {
+		ctx.NotFound("", nil)
+		return
+	}
+
+	if err := models.DeleteProjectBoardByID(board.ID); err != nil {
+		ctx.ServerError("DeleteProjectBoardByID", err)
+		return
+	}
+
+	ctx.JSON(200, map[string]interface{}{
+		"ok": true,
+	})
+}
+
+type movedProjectCards struct {
+	Issues []struct {
+		IssueID int64 `json:"issueID"`
+		Sorting int64 `json:"sorting"`
+	} `json:"issues"`
+}
+
+// MoveIssues puts the cards of the posted issues on the board in the posted order
+func MoveIssues(ctx *context.Context) {
+	board := getProjectBoardFromContext(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	body, err := ctx.Req.Body().Bytes()
+	if err != nil {
+		ctx.ServerError("Body", err)
+		return
+	}
+	var moved movedProjectCards
+	if err := json.Unmarshal(body, &moved); err != nil {
+		ctx.Error(400, fmt.Sprintf("invalid body: %v", err))
+		return
+	}
+
+	issueSortings := make(map[int64]int64, len(moved.Issues))
+	for _, issue := range moved.Issues {
+		issueSortings[issue.IssueID] = issue.Sorting
+	}
+	if err := models.MoveIssuesOnProjectBoard(board, issueSortings); err != nil {
+		if models.IsErrProjectIssueNotExist(err) {
+			ctx.Error(422, err.Error())
+		} else {
+			ctx.ServerError("MoveIssuesOnProjectBoard", err)
+		}
+		return
+	}
+
+	ctx.JSON(200, map[string]interface{}{
+		"ok": true,
+	})
+}
@@ -27,6 +27,7 @@ import (
 	"code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/util"
 	"code.gitea.io/gitea/routers/utils"
+	"code.gitea.io/gitea/services/automerge"
 	"code.gitea.io/gitea/services/gitdiff"
 	pull_service "code.gitea.io/gitea/services/pull"
 	repo_service "code.gitea.io/gitea/services/repository"
@@ -408,12 +409,16 @@ func PrepareViewPullInfo(ctx *context.Context, issue *models.Issue) *git.Compare
 		}
 		defer headGitRepo.Close()
 
-		headBranchExist = headGitRepo.IsBranchExist(pull.HeadBranch)
+		if pull.Flow == models.PullRequestFlowGithub {
+			headBranchExist = headGitRepo.IsBranchExist(pull.HeadBranch)
+		} else {
+			headBranchExist = git.IsReferenceExist(pull.HeadRepo.RepoPath(), pull.GetGitHeadRefName())
+		}
 
 		if headBranchExist {
-			headBranchSha, err = headGitRepo.GetBranchCommitID(pull.HeadBranch)
+			headBranchSha, err = headGitRepo.GetRefCommitID(pull.GetGitHeadRefName())
 			if err != nil {
-				ctx.ServerError("GetBranchCommitID", err)
+				ctx.ServerError("GetRefCommitID", err)
 				return nil
 			}
 		}
@@ -663,6 +668,8 @@ func ViewPullFiles(ctx *context.Context) {
 	getBranchData(ctx, issue)
 	ctx.Data["IsIssuePoster"] = ctx.IsSigned && issue.IsPoster(ctx.User.ID)
 	ctx.Data["HasIssuesOrPullsWritePermission"] = ctx.Repo.CanWriteIssuesOrPulls(issue.IsPull)
+	// Suggested changes are committed to the head branch like an update of the pull request
+	ctx.Data["CanApplySuggestions"] = ctx.IsSigned && !issue.IsClosed && ctx.Data["UpdateAllowed"] == true
 	ctx.HTML(200, tplPullFiles)
 }
 
@@ -771,21 +778,6 @@ func MergePullRequest(ctx *context.Context, form auth.MergePullRequestForm) {
 		return
 	}
 
-	if err := pull_service.CheckPRReadyToMerge(pr); err != nil {
-		if !models.IsErrNotAllowedToMerge(err) {
-			ctx.ServerError("Merge PR status", err)
-			return
-		}
-		if isRepoAdmin, err := models.IsUserRepoAdmin(pr.BaseRepo, ctx.User); err != nil {
-			ctx.ServerError("IsUserRepoAdmin", err)
-			return
-		} else if !isRepoAdmin {
-			ctx.Flash.Error(ctx.Tr("repo.pulls.no_merge_not_ready"))
-			ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + com.ToStr(pr.Index))
-			return
-		}
-	}
-
 	if ctx.HasError() {
 		ctx.Flash.Error(ctx.Data["ErrorMsg"].(string))
 		ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + com.ToStr(pr.Index))
@@ -797,7 +789,7 @@ func MergePullRequest(ctx *context.Context, form auth.MergePullRequestForm) {
 		if models.MergeStyle(form.Do) == models.MergeStyleMerge {
 			message = pr.GetDefaultMergeMessage()
 		}
-		if models.MergeStyle(form.Do) == models.MergeStyleRebaseMerge {
+		if models.MergeStyle(form.Do) == models.MergeStyleRebaseMerge || models.MergeStyle(form.Do) == models.MergeStyleSemiLinear {
 			message = pr.GetDefaultMergeMessage()
 		}
 		if models.MergeStyle(form.Do) == models.MergeStyleSquash {
@@ -810,6 +802,43 @@ func MergePullRequest(ctx *context.Context, form auth.MergePullRequestForm) {
 		message += "\n\n" + form.MergeMessageField
 	}
 
+	if form.MergeWhenChecksSucceed {
+		scheduled, err := automerge.ScheduleAutoMerge(ctx.User, pr, models.MergeStyle(form.Do), message)
+		if err != nil {
+			if models.IsErrInvalidMergeStyle(err) {
+				ctx.Flash.Error(ctx.Tr("repo.pulls.invalid_merge_option"))
+				ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + com.ToStr(pr.Index))
+				return
+			} else if models.IsErrPullAlreadyScheduledToAutoMerge(err) {
+				ctx.Flash.Error(ctx.Tr("repo.pulls.auto_merge_already_scheduled"))
+				ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + com.ToStr(pr.Index))
+				return
+			}
+			ctx.ServerError("ScheduleAutoMerge", err)
+			return
+		} else if scheduled {
+			ctx.Flash.Success(ctx.Tr("repo.pulls.auto_merge_newly_scheduled"))
+			ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + com.ToStr(pr.Index))
+			return
+		}
+		// The pull request is ready already, so merge it right away
+	}
+
+	if err := pull_service.CheckPRReadyToMerge(pr); err != nil {
+		if !models.IsErrNotAllowedToMerge(err) {
+			ctx.ServerError("Merge PR status", err)
+			return
+		}
+		if isRepoAdmin, err := models.IsUserRepoAdmin(pr.BaseRepo, ctx.User); err != nil {
+			ctx.ServerError("IsUserRepoAdmin", err)
+			return
+		} else if !isRepoAdmin {
+			ctx.Flash.Error(ctx.Tr("repo.pulls.no_merge_not_ready"))
+			ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + com.ToStr(pr.Index))
+			return
+		}
+	}
+
 	pr.Issue = issue
 	pr.Issue.Repo = ctx.Repo.Repository
 
@@ -844,6 +873,11 @@ func MergePullRequest(ctx *context.Context, form auth.MergePullRequestForm) {
 			ctx.Flash.Error(ctx.Tr("repo.pulls.unrelated_histories"))
 			ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + com.ToStr(pr.Index))
 			return
+		} else if models.IsErrMergeDivergingBranches(err) {
+			log.Debug("MergeDivergingBranches error: %v", err)
+			ctx.Flash.Error(ctx.Tr("repo.pulls.merge_diverging_branches"))
+			ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + com.ToStr(pr.Index))
+			return
 		} else if git.IsErrPushOutOfDate(err) {
 			log.Debug("MergePushOutOfDate error: %v", err)
 			ctx.Flash.Error(ctx.Tr("repo.pulls.merge_out_of_date"))
@@ -874,6 +908,42 @@ func MergePullRequest(ctx *context.Context, form auth.MergePullRequestForm) {
 	ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + com.ToStr(pr.Index))
 }
 
+// CancelAutoMergePullRequest cancels the scheduled merge of a pull request
+func CancelAutoMergePullRequest(ctx *context.Context) {
+	issue := checkPullInfo(ctx)
+	if ctx.Written() {
+		return
+	}
+	pr := issue.PullRequest
+
+	allowedMerge, err := pull_service.IsUserAllowedToMerge(pr, ctx.Repo.Permission, ctx.User)
+	if err != nil {
+		ctx.ServerError("IsUserAllowedToMerge", err)
+		return
+	}
+	if !allowedMerge {
+		ctx.Flash.Error(ctx.Tr("repo.pulls.update_not_allowed"))
+		ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + com.ToStr(issue.Index))
+		return
+	}
+
+	if exist, _, err := models.GetScheduledMergeByPullID(pr.ID); err != nil && !models.IsErrUserNotExist(err) {
+		ctx.ServerError("GetScheduledMergeByPullID", err)
+		return
+	} else if !exist {
+		ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + com.ToStr(issue.Index))
+		return
+	}
+
+	if err := models.RemoveScheduledAutoMerge(ctx.User, pr); err != nil {
+		ctx.ServerError("RemoveScheduledAutoMerge", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.pulls.auto_merge_canceled_schedule"))
+	ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + com.ToStr(issue.Index))
+}
+
 func stopTimerIfAvailable(user *models.User, issue *models.Issue) error {
 
 	if models.StopwatchExists(user.ID, issue.ID) {
@@ -1030,8 +1100,8 @@ func CleanUpPullRequest(ctx *context.Context) {
 
 	pr := issue.PullRequest
 
-	// Don't cleanup unmerged and unclosed PRs
-	if !pr.HasMerged && !issue.IsClosed {
+	// Don't cleanup unmerged and unclosed PRs, nor AGit flow PRs which have no head branch
+	if (!pr.HasMerged && !issue.IsClosed) || pr.Flow == models.PullRequestFlowAGit {
 		ctx.NotFound("CleanUpPullRequest", nil)
 		return
 	}
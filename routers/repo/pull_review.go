@@ -6,11 +6,15 @@ package repo
 
 import (
 	"fmt"
+	"strings"
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/auth"
 	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/repofiles"
+	"code.gitea.io/gitea/routers/utils"
 	pull_service "code.gitea.io/gitea/services/pull"
 )
 
@@ -31,15 +35,19 @@ func CreateCodeComment(ctx *context.Context, form auth.CodeCommentForm) {
 	}
 
 	signedLine := form.Line
+	signedStartLine := form.StartLine
 	if form.Side == "previous" {
 		signedLine *= -1
+		signedStartLine *= -1
 	}
 
 	comment, err := pull_service.CreateCodeComment(
 		ctx.User,
 		ctx.Repo.GitRepo,
 		issue,
+		signedStartLine,
 		signedLine,
+		form.IsFileComment,
 		form.Content,
 		form.TreePath,
 		form.IsReview,
@@ -47,6 +55,11 @@ func CreateCodeComment(ctx *context.Context, form auth.CodeCommentForm) {
 		form.LatestCommitID,
 	)
 	if err != nil {
+		if models.IsErrInvalidCodeCommentLines(err) {
+			ctx.Flash.Error(ctx.Tr("repo.diff.comment.invalid_lines"))
+			ctx.Redirect(fmt.Sprintf("%s/pulls/%d/files", ctx.Repo.RepoLink, issue.Index))
+			return
+		}
 		ctx.ServerError("CreateCodeComment", err)
 		return
 	}
@@ -158,3 +171,100 @@ func SubmitReview(ctx *context.Context, form auth.SubmitReviewForm) {
 
 	ctx.Redirect(fmt.Sprintf("%s/pulls/%d#%s", ctx.Repo.RepoLink, issue.Index, comm.HashTag()))
 }
+
+// ApplySuggestions commits the changes suggested in the selected code comments to the head branch
+func ApplySuggestions(ctx *context.Context, form auth.ApplySuggestionsForm) {
+	issue := checkPullInfo(ctx)
+	if ctx.Written() {
+		return
+	}
+	filesLink := fmt.Sprintf("%s/pulls/%d/files", ctx.Repo.RepoLink, issue.Index)
+	if ctx.HasError() {
+		ctx.Flash.Error(ctx.Data["ErrorMsg"].(string))
+		ctx.Redirect(filesLink)
+		return
+	}
+
+	pr := issue.PullRequest
+	if issue.IsClosed || pr.HasMerged {
+		ctx.NotFound("ApplySuggestions", nil)
+		return
+	}
+	if err := pr.LoadHeadRepo(); err != nil {
+		ctx.ServerError("LoadHeadRepo", err)
+		return
+	}
+
+	allowed, err := pull_service.IsUserAllowedToUpdate(pr, ctx.User)
+	if err != nil {
+		ctx.ServerError("IsUserAllowedToUpdate", err)
+		return
+	}
+	if !allowed {
+		ctx.Flash.Error(ctx.Tr("repo.pulls.apply_suggestions_not_allowed"))
+		ctx.Redirect(filesLink)
+		return
+	}
+
+	comments := make([]*models.Comment, 0, len(form.CommentIDs))
+	for _, id := range form.CommentIDs {
+		comment, err := models.GetCommentByID(id)
+		if err != nil {
+			if models.IsErrCommentNotExist(err) {
+				ctx.NotFound("GetCommentByID", err)
+			} else {
+				ctx.ServerError("GetCommentByID", err)
+			}
+			return
+		}
+		if comment.IssueID != issue.ID || len(comment.Suggestions()) == 0 {
+			ctx.NotFound("ApplySuggestions", nil)
+			return
+		}
+		if err = comment.LoadReview(); err != nil && !models.IsErrReviewNotExist(err) {
+			ctx.ServerError("LoadReview", err)
+			return
+		}
+		// Comments of pending reviews are only visible to their author
+		if comment.Review != nil && comment.Review.Type == models.ReviewTypePending {
+			ctx.NotFound("ApplySuggestions", nil)
+			return
+		}
+		comments = append(comments, comment)
+	}
+
+	message := strings.TrimSpace(form.Message)
+	if len(message) == 0 {
+		message = "Apply suggestions from code review"
+	}
+
+	if err = repofiles.ApplySuggestions(pr.HeadRepo, ctx.User, &repofiles.ApplySuggestionsOptions{
+		Branch:   pr.HeadBranch,
+		Message:  message,
+		Comments: comments,
+	}); err != nil {
+		if models.IsErrSuggestionOutdated(err) || git.IsErrPushOutOfDate(err) {
+			ctx.Flash.Error(ctx.Tr("repo.pulls.suggestions_outdated"))
+		} else if models.IsErrSuggestionsOverlap(err) {
+			ctx.Flash.Error(ctx.Tr("repo.pulls.suggestions_overlap", err.(models.ErrSuggestionsOverlap).Path))
+		} else if models.IsErrLFSFileLocked(err) {
+			ctx.Flash.Error(ctx.Tr("repo.editor.upload_file_is_locked", err.(models.ErrLFSFileLocked).Path, err.(models.ErrLFSFileLocked).UserName))
+		} else if git.IsErrPushRejected(err) {
+			errPushRej := err.(*git.ErrPushRejected)
+			if len(errPushRej.Message) == 0 {
+				ctx.Flash.Error(ctx.Tr("repo.editor.push_rejected_no_message"))
+			} else {
+				ctx.Flash.Error(ctx.Tr("repo.editor.push_rejected", utils.SanitizeFlashErrorString(errPushRej.Message)))
+			}
+		} else {
+			ctx.ServerError("ApplySuggestions", err)
+			return
+		}
+		ctx.Redirect(filesLink)
+		return
+	}
+
+	log.Trace("Suggestions applied: %-v #%d[%d] Comments%v", ctx.Repo.Repository, issue.Index, issue.ID, form.CommentIDs)
+	ctx.Flash.Success(ctx.Tr("repo.pulls.apply_suggestions_success"))
+	ctx.Redirect(filesLink)
+}
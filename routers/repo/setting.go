@@ -9,12 +9,11 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
-	"net/url"
-	"regexp"
 	"strings"
 	"time"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/auth"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
@@ -22,6 +21,7 @@ import (
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/repository"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 	"code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/timeutil"
 	"code.gitea.io/gitea/modules/validation"
@@ -29,9 +29,6 @@ import (
 	"code.gitea.io/gitea/services/mailer"
 	mirror_service "code.gitea.io/gitea/services/mirror"
 	repo_service "code.gitea.io/gitea/services/repository"
-
-	"github.com/unknwon/com"
-	"mvdan.cc/xurls/v2"
 )
 
 const (
@@ -44,13 +41,19 @@ const (
 	tplProtectedBranch base.TplName = "repo/settings/protected_branch"
 )
 
-var validFormAddress *regexp.Regexp
-
 // Settings show a repository's settings page
 func Settings(ctx *context.Context) {
 	ctx.Data["Title"] = ctx.Tr("repo.settings")
 	ctx.Data["PageIsSettingsOptions"] = true
 	ctx.Data["ForcePrivate"] = setting.Repository.ForcePrivate
+
+	pushMirrors, err := models.GetPushMirrorsByRepoID(ctx.Repo.Repository.ID)
+	if err != nil {
+		ctx.ServerError("GetPushMirrorsByRepoID", err)
+		return
+	}
+	ctx.Data["PushMirrors"] = pushMirrors
+
 	ctx.HTML(200, tplSettingsOptions)
 }
 
@@ -61,6 +64,13 @@ func SettingsPost(ctx *context.Context, form auth.RepoSettingForm) {
 
 	repo := ctx.Repo.Repository
 
+	pushMirrors, err := models.GetPushMirrorsByRepoID(repo.ID)
+	if err != nil {
+		ctx.ServerError("GetPushMirrorsByRepoID", err)
+		return
+	}
+	ctx.Data["PushMirrors"] = pushMirrors
+
 	switch ctx.Query("action") {
 	case "update":
 		if ctx.HasError() {
@@ -151,57 +161,84 @@ func SettingsPost(ctx *context.Context, form auth.RepoSettingForm) {
 			}
 		}
 
-		// Validate the form.MirrorAddress
-		u, err := url.Parse(form.MirrorAddress)
+		address, err := mirror_service.ParseAddress(form.MirrorAddress, form.MirrorUsername, form.MirrorPassword)
 		if err != nil {
 			ctx.Data["Err_MirrorAddress"] = true
-			ctx.RenderWithErr(ctx.Tr("repo.mirror_address_url_invalid"), tplSettingsOptions, &form)
+			handleSettingRemoteAddrError(ctx, err, &form)
 			return
 		}
 
-		if u.Opaque != "" || !(u.Scheme == "http" || u.Scheme == "https" || u.Scheme == "git") {
-			ctx.Data["Err_MirrorAddress"] = true
-			ctx.RenderWithErr(ctx.Tr("repo.mirror_address_protocol_invalid"), tplSettingsOptions, &form)
+		if err := mirror_service.SaveAddress(ctx.Repo.Mirror, address); err != nil {
+			ctx.ServerError("SaveAddress", err)
 			return
 		}
 
-		if form.MirrorUsername != "" || form.MirrorPassword != "" {
-			u.User = url.UserPassword(form.MirrorUsername, form.MirrorPassword)
+		ctx.Flash.Success(ctx.Tr("repo.settings.update_settings_success"))
+		ctx.Redirect(repo.Link() + "/settings")
+
+	case "mirror-sync":
+		if !repo.IsMirror {
+			ctx.NotFound("", nil)
+			return
 		}
 
-		// Now use xurls
-		address := validFormAddress.FindString(form.MirrorAddress)
-		if address != form.MirrorAddress && form.MirrorAddress != "" {
-			ctx.Data["Err_MirrorAddress"] = true
-			ctx.RenderWithErr(ctx.Tr("repo.mirror_address_url_invalid"), tplSettingsOptions, &form)
+		mirror_service.StartToMirror(repo.ID)
+
+		ctx.Flash.Info(ctx.Tr("repo.settings.mirror_sync_in_progress"))
+		ctx.Redirect(repo.Link() + "/settings")
+
+	case "push-mirror-add":
+		// This section doesn't require repo_name/RepoName to be set in the form, don't show it
+		// as an error on the UI for this action
+		ctx.Data["Err_RepoName"] = nil
+
+		interval, err := mirror_service.ParsePushMirrorInterval(form.PushMirrorInterval)
+		if err != nil {
+			ctx.Data["Err_PushMirrorInterval"] = true
+			ctx.RenderWithErr(ctx.Tr("repo.mirror_interval_invalid"), tplSettingsOptions, &form)
 			return
 		}
 
-		if u.EscapedPath() == "" || u.Host == "" || !u.IsAbs() {
-			ctx.Data["Err_MirrorAddress"] = true
-			ctx.RenderWithErr(ctx.Tr("repo.mirror_address_url_invalid"), tplSettingsOptions, &form)
+		address, err := mirror_service.ParseAddress(form.PushMirrorAddress, form.PushMirrorUsername, form.PushMirrorPassword)
+		if err != nil {
+			ctx.Data["Err_PushMirrorAddress"] = true
+			handleSettingRemoteAddrError(ctx, err, &form)
 			return
 		}
 
-		address = u.String()
+		if _, err := mirror_service.AddPushMirror(repo, address, interval); err != nil {
+			ctx.ServerError("AddPushMirror", err)
+			return
+		}
 
-		if err := mirror_service.SaveAddress(ctx.Repo.Mirror, address); err != nil {
-			ctx.ServerError("SaveAddress", err)
+		ctx.Flash.Success(ctx.Tr("repo.settings.update_settings_success"))
+		ctx.Redirect(repo.Link() + "/settings")
+
+	case "push-mirror-remove":
+		m := selectPushMirrorByForm(form, pushMirrors)
+		if m == nil {
+			ctx.NotFound("", nil)
+			return
+		}
+
+		if err := mirror_service.RemovePushMirror(m); err != nil {
+			ctx.ServerError("RemovePushMirror", err)
 			return
 		}
 
 		ctx.Flash.Success(ctx.Tr("repo.settings.update_settings_success"))
 		ctx.Redirect(repo.Link() + "/settings")
 
-	case "mirror-sync":
-		if !repo.IsMirror {
+	case "push-mirror-sync":
+		m := selectPushMirrorByForm(form, pushMirrors)
+		if m == nil {
 			ctx.NotFound("", nil)
 			return
 		}
 
-		mirror_service.StartToMirror(repo.ID)
+		mirror_service.StartToPushMirror(m)
 
-		ctx.Flash.Info(ctx.Tr("repo.settings.mirror_sync_in_progress"))
+		ctx.Flash.Info(ctx.Tr("repo.settings.push_mirror_sync_in_progress", mirror_service.PushMirrorAddress(m)))
 		ctx.Redirect(repo.Link() + "/settings")
 
 	case "advanced":
@@ -294,12 +331,35 @@ func SettingsPost(ctx *context.Context, form auth.RepoSettingForm) {
 					AllowRebase:               form.PullsAllowRebase,
 					AllowRebaseMerge:          form.PullsAllowRebaseMerge,
 					AllowSquash:               form.PullsAllowSquash,
+					AllowFastForwardOnly:      form.PullsAllowFastForwardOnly,
+					AllowSemiLinear:           form.PullsAllowSemiLinear,
 				},
 			})
 		} else if !models.UnitTypePullRequests.UnitGlobalDisabled() {
 			deleteUnitTypes = append(deleteUnitTypes, models.UnitTypePullRequests)
 		}
 
+		if form.EnableProjects && !models.UnitTypeProjects.UnitGlobalDisabled() {
+			units = append(units, models.RepoUnit{
+				RepoID: repo.ID,
+				Type:   models.UnitTypeProjects,
+				Config: new(models.UnitConfig),
+			})
+		} else if !models.UnitTypeProjects.UnitGlobalDisabled() {
+			deleteUnitTypes = append(deleteUnitTypes, models.UnitTypeProjects)
+		}
+
+		// the option is only shown if actions are enabled
+		if form.EnableActions && setting.Actions.Enabled && !models.UnitTypeActions.UnitGlobalDisabled() {
+			units = append(units, models.RepoUnit{
+				RepoID: repo.ID,
+				Type:   models.UnitTypeActions,
+				Config: new(models.UnitConfig),
+			})
+		} else if setting.Actions.Enabled && !models.UnitTypeActions.UnitGlobalDisabled() {
+			deleteUnitTypes = append(deleteUnitTypes, models.UnitTypeActions)
+		}
+
 		if err := models.UpdateRepositoryUnits(repo, units, deleteUnitTypes); err != nil {
 			ctx.ServerError("UpdateRepositoryUnits", err)
 			return
@@ -596,6 +656,13 @@ func CollaborationPost(ctx *context.Context) {
 		ctx.ServerError("AddCollaborator", err)
 		return
 	}
+	audit.Record(ctx.User, ctx.RemoteAddr(), &models.AuditEvent{
+		Action:     models.AuditCollaboratorAdd,
+		RepoID:     ctx.Repo.Repository.ID,
+		TargetType: models.AuditTargetUser,
+		TargetID:   u.ID,
+		TargetName: u.Name,
+	})
 
 	if setting.Service.EnableNotifyMail {
 		mailer.SendCollaboratorMail(u, ctx.User, ctx.Repo.Repository)
@@ -607,18 +674,33 @@ func CollaborationPost(ctx *context.Context) {
 
 // ChangeCollaborationAccessMode response for changing access of a collaboration
 func ChangeCollaborationAccessMode(ctx *context.Context) {
-	if err := ctx.Repo.Repository.ChangeCollaborationAccessMode(
-		ctx.QueryInt64("uid"),
-		models.AccessMode(ctx.QueryInt("mode"))); err != nil {
+	uid := ctx.QueryInt64("uid")
+	mode := models.AccessMode(ctx.QueryInt("mode"))
+	if err := ctx.Repo.Repository.ChangeCollaborationAccessMode(uid, mode); err != nil {
 		log.Error("ChangeCollaborationAccessMode: %v", err)
+		return
 	}
+	audit.Record(ctx.User, ctx.RemoteAddr(), &models.AuditEvent{
+		Action:     models.AuditCollaboratorAccess,
+		RepoID:     ctx.Repo.Repository.ID,
+		TargetType: models.AuditTargetUser,
+		TargetID:   uid,
+		Details:    "permission: " + mode.String(),
+	})
 }
 
 // DeleteCollaboration delete a collaboration for a repository
 func DeleteCollaboration(ctx *context.Context) {
-	if err := ctx.Repo.Repository.DeleteCollaboration(ctx.QueryInt64("id")); err != nil {
+	uid := ctx.QueryInt64("id")
+	if err := ctx.Repo.Repository.DeleteCollaboration(uid); err != nil {
 		ctx.Flash.Error("DeleteCollaboration: " + err.Error())
 	} else {
+		audit.Record(ctx.User, ctx.RemoteAddr(), &models.AuditEvent{
+			Action:     models.AuditCollaboratorRemove,
+			RepoID:     ctx.Repo.Repository.ID,
+			TargetType: models.AuditTargetUser,
+			TargetID:   uid,
+		})
 		ctx.Flash.Success(ctx.Tr("repo.settings.remove_collaborator_success"))
 	}
 
@@ -668,6 +750,7 @@ func AddTeamPost(ctx *context.Context) {
 		ctx.ServerError("team.AddRepository", err)
 		return
 	}
+	audit.RecordTeamRepository(ctx.User, ctx.RemoteAddr(), models.AuditTeamRepoAdd, team, ctx.Repo.Repository.ID)
 
 	ctx.Flash.Success(ctx.Tr("repo.settings.add_team_success"))
 	ctx.Redirect(ctx.Repo.RepoLink + "/settings/collaboration")
@@ -691,6 +774,7 @@ func DeleteTeam(ctx *context.Context) {
 		ctx.ServerError("team.RemoveRepositorys", err)
 		return
 	}
+	audit.RecordTeamRepository(ctx.User, ctx.RemoteAddr(), models.AuditTeamRepoRemove, team, ctx.Repo.Repository.ID)
 
 	ctx.Flash.Success(ctx.Tr("repo.settings.remove_team_success"))
 	ctx.JSON(200, map[string]interface{}{
@@ -863,14 +947,6 @@ func DeleteDeployKey(ctx *context.Context) {
 	})
 }
 
-func init() {
-	var err error
-	validFormAddress, err = xurls.StrictMatchingScheme(`(https?)|(git)://`)
-	if err != nil {
-		panic(err)
-	}
-}
-
 // UpdateAvatarSetting update repo's avatar
 func UpdateAvatarSetting(ctx *context.Context, form auth.AvatarForm) error {
 	ctxRepo := ctx.Repo.Repository
@@ -879,7 +955,7 @@ func UpdateAvatarSetting(ctx *context.Context, form auth.AvatarForm) error {
 		// No avatar is uploaded and we not removing it here.
 		// No random avatar generated here.
 		// Just exit, no action.
-		if !com.IsFile(ctxRepo.CustomAvatarPath()) {
+		if _, err := storage.RepoAvatars.Stat(ctxRepo.CustomAvatarRelativePath()); err != nil {
 			log.Trace("No avatar was uploaded for repo: %d. Default icon will appear instead.", ctxRepo.ID)
 		}
 		return nil
@@ -908,6 +984,27 @@ func UpdateAvatarSetting(ctx *context.Context, form auth.AvatarForm) error {
 	return nil
 }
 
+func handleSettingRemoteAddrError(ctx *context.Context, err error, form *auth.RepoSettingForm) {
+	if mirror_service.IsErrInvalidAddress(err) {
+		if err.(mirror_service.ErrInvalidAddress).IsProtocolInvalid {
+			ctx.RenderWithErr(ctx.Tr("repo.mirror_address_protocol_invalid"), tplSettingsOptions, form)
+		} else {
+			ctx.RenderWithErr(ctx.Tr("repo.mirror_address_url_invalid"), tplSettingsOptions, form)
+		}
+		return
+	}
+	ctx.ServerError("ParseAddress", err)
+}
+
+func selectPushMirrorByForm(form auth.RepoSettingForm, pushMirrors []*models.PushMirror) *models.PushMirror {
+	for _, m := range pushMirrors {
+		if m.ID == form.PushMirrorID {
+			return m
+		}
+	}
+	return nil
+}
+
 // SettingsAvatar save new POSTed repository avatar
 func SettingsAvatar(ctx *context.Context, form auth.AvatarForm) {
 	form.Source = auth.AvatarLocal
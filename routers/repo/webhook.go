@@ -58,7 +58,20 @@ type orgRepoCtx struct {
 }
 
 // getOrgRepoCtx determines whether this is a repo, organization, or admin (both default and system) context.
+// It also tells the templates whether the user and organization events can be chosen.
 func getOrgRepoCtx(ctx *context.Context) (*orgRepoCtx, error) {
+	orCtx, err := getOrgRepoCtxByParams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// organization events are sent to the webhooks of the organization, user events
+	// are instance-wide and only sent to system webhooks
+	ctx.Data["CanChooseOrganizationEvent"] = orCtx.OrgID > 0 || orCtx.IsSystemWebhook
+	ctx.Data["CanChooseUserEvent"] = orCtx.IsSystemWebhook
+	return orCtx, nil
+}
+
+func getOrgRepoCtxByParams(ctx *context.Context) (*orgRepoCtx, error) {
 	if len(ctx.Repo.RepoLink) > 0 {
 		return &orgRepoCtx{
 			RepoID:      ctx.Repo.Repository.ID,
@@ -139,6 +152,12 @@ func WebhooksNew(ctx *context.Context) {
 			"IconURL":  setting.AppURL + "img/favicon.png",
 		}
 	}
+	if hookType == "custom" {
+		ctx.Data["CustomHook"] = &webhook.CustomMeta{
+			Template:    webhook.DefaultCustomTemplate,
+			ContentType: "application/json",
+		}
+	}
 	ctx.Data["BaseLink"] = orCtx.Link
 
 	ctx.HTML(200, orCtx.NewTemplate)
@@ -169,6 +188,8 @@ func ParseHookEvent(form auth.WebhookForm) *models.HookEvent {
 			PullRequestReview:    form.PullRequestReview,
 			PullRequestSync:      form.PullRequestSync,
 			Repository:           form.Repository,
+			User:                 form.User,
+			Organization:         form.Organization,
 		},
 		BranchFilter: form.BranchFilter,
 	}
@@ -200,16 +221,18 @@ func GiteaHooksNewPost(ctx *context.Context, form auth.NewWebhookForm) {
 	}
 
 	w := &models.Webhook{
-		RepoID:          orCtx.RepoID,
-		URL:             form.PayloadURL,
-		HTTPMethod:      form.HTTPMethod,
-		ContentType:     contentType,
-		Secret:          form.Secret,
-		HookEvent:       ParseHookEvent(form.WebhookForm),
-		IsActive:        form.Active,
-		HookTaskType:    models.GITEA,
-		OrgID:           orCtx.OrgID,
-		IsSystemWebhook: orCtx.IsSystemWebhook,
+		RepoID:             orCtx.RepoID,
+		URL:                form.PayloadURL,
+		HTTPMethod:         form.HTTPMethod,
+		ContentType:        contentType,
+		Secret:             form.Secret,
+		HookEvent:          ParseHookEvent(form.WebhookForm),
+		IsActive:           form.Active,
+		HookTaskType:       models.GITEA,
+		SignatureAlgorithm: models.HookSignatureAlgorithm(form.SignatureAlgorithm),
+		SignTimestamp:      form.SignTimestamp,
+		OrgID:              orCtx.OrgID,
+		IsSystemWebhook:    orCtx.IsSystemWebhook,
 	}
 	if err := w.UpdateEvent(); err != nil {
 		ctx.ServerError("UpdateEvent", err)
@@ -254,15 +277,17 @@ func newGogsWebhookPost(ctx *context.Context, form auth.NewGogshookForm, kind mo
 	}
 
 	w := &models.Webhook{
-		RepoID:          orCtx.RepoID,
-		URL:             form.PayloadURL,
-		ContentType:     contentType,
-		Secret:          form.Secret,
-		HookEvent:       ParseHookEvent(form.WebhookForm),
-		IsActive:        form.Active,
-		HookTaskType:    kind,
-		OrgID:           orCtx.OrgID,
-		IsSystemWebhook: orCtx.IsSystemWebhook,
+		RepoID:             orCtx.RepoID,
+		URL:                form.PayloadURL,
+		ContentType:        contentType,
+		Secret:             form.Secret,
+		HookEvent:          ParseHookEvent(form.WebhookForm),
+		IsActive:           form.Active,
+		HookTaskType:       kind,
+		SignatureAlgorithm: models.HookSignatureAlgorithm(form.SignatureAlgorithm),
+		SignTimestamp:      form.SignTimestamp,
+		OrgID:              orCtx.OrgID,
+		IsSystemWebhook:    orCtx.IsSystemWebhook,
 	}
 	if err := w.UpdateEvent(); err != nil {
 		ctx.ServerError("UpdateEvent", err)
@@ -350,6 +375,7 @@ func DingtalkHooksNewPost(ctx *context.Context, form auth.NewDingtalkHookForm) {
 		RepoID:          orCtx.RepoID,
 		URL:             form.PayloadURL,
 		ContentType:     models.ContentTypeJSON,
+		Secret:          form.Secret,
 		HookEvent:       ParseHookEvent(form.WebhookForm),
 		IsActive:        form.Active,
 		HookTaskType:    models.DINGTALK,
@@ -597,6 +623,7 @@ func FeishuHooksNewPost(ctx *context.Context, form auth.NewFeishuHookForm) {
 		RepoID:          orCtx.RepoID,
 		URL:             form.PayloadURL,
 		ContentType:     models.ContentTypeJSON,
+		Secret:          form.Secret,
 		HookEvent:       ParseHookEvent(form.WebhookForm),
 		IsActive:        form.Active,
 		HookTaskType:    models.FEISHU,
@@ -616,6 +643,95 @@ func FeishuHooksNewPost(ctx *context.Context, form auth.NewFeishuHookForm) {
 	ctx.Redirect(orCtx.Link)
 }
 
+// CustomHooksNewPost response for creating custom hook
+func CustomHooksNewPost(ctx *context.Context, form auth.NewCustomHookForm) {
+	ctx.Data["Title"] = ctx.Tr("repo.settings")
+	ctx.Data["PageIsSettingsHooks"] = true
+	ctx.Data["PageIsSettingsHooksNew"] = true
+	ctx.Data["HookType"] = models.CUSTOM.Name()
+
+	orCtx, err := getOrgRepoCtx(ctx)
+	if err != nil {
+		ctx.ServerError("getOrgRepoCtx", err)
+		return
+	}
+	ctx.Data["BaseLink"] = orCtx.Link
+
+	w := &models.Webhook{
+		RepoID:          orCtx.RepoID,
+		ContentType:     models.ContentTypeJSON,
+		HookTaskType:    models.CUSTOM,
+		OrgID:           orCtx.OrgID,
+		IsSystemWebhook: orCtx.IsSystemWebhook,
+	}
+	if !parseCustomHookForm(ctx, orCtx, form, w) {
+		return
+	}
+	if err := w.UpdateEvent(); err != nil {
+		ctx.ServerError("UpdateEvent", err)
+		return
+	} else if err := models.CreateWebhook(w); err != nil {
+		ctx.ServerError("CreateWebhook", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.settings.add_hook_success"))
+	ctx.Redirect(orCtx.Link)
+}
+
+// parseCustomHookForm applies the custom hook form to the webhook and renders the
+// template with a sample payload. If the form is invalid or only the preview was
+// requested, the page is rendered again and false is returned.
+func parseCustomHookForm(ctx *context.Context, orCtx *orgRepoCtx, form auth.NewCustomHookForm, w *models.Webhook) bool {
+	w.URL = form.PayloadURL
+	w.HTTPMethod = form.HTTPMethod
+	w.SetSecret(form.Secret)
+	w.SignatureAlgorithm = models.HookSignatureAlgorithm(form.SignatureAlgorithm)
+	w.SignTimestamp = form.SignTimestamp
+	w.HookEvent = ParseHookEvent(form.WebhookForm)
+	w.IsActive = form.Active
+	ctx.Data["Webhook"] = w
+
+	custom := &webhook.CustomMeta{
+		Template:    form.Template,
+		ContentType: form.ContentType,
+	}
+	ctx.Data["CustomHook"] = custom
+	ctx.Data["CustomHeaders"] = form.Headers
+
+	if ctx.HasError() {
+		ctx.HTML(200, orCtx.NewTemplate)
+		return false
+	}
+
+	var err error
+	if custom.Headers, err = webhook.ParseCustomHeaders(form.Headers); err != nil {
+		ctx.Data["Err_Headers"] = true
+		ctx.RenderWithErr(ctx.Tr("repo.settings.custom.invalid_headers", err.Error()), orCtx.NewTemplate, nil)
+		return false
+	}
+
+	preview, err := webhook.PreviewCustomPayload(custom)
+	ctx.Data["CustomPreview"] = preview
+	if err != nil {
+		ctx.Data["Err_Template"] = true
+		ctx.RenderWithErr(ctx.Tr("repo.settings.custom.invalid_template", err.Error()), orCtx.NewTemplate, nil)
+		return false
+	}
+	if form.Preview {
+		ctx.HTML(200, orCtx.NewTemplate)
+		return false
+	}
+
+	meta, err := json.Marshal(custom)
+	if err != nil {
+		ctx.ServerError("Marshal", err)
+		return false
+	}
+	w.Meta = string(meta)
+	return true
+}
+
 func checkWebhook(ctx *context.Context) (*orgRepoCtx, *models.Webhook) {
 	ctx.Data["RequireHighlightJS"] = true
 
@@ -655,6 +771,11 @@ func checkWebhook(ctx *context.Context) (*orgRepoCtx, *models.Webhook) {
 		ctx.Data["TelegramHook"] = webhook.GetTelegramHook(w)
 	case models.MATRIX:
 		ctx.Data["MatrixHook"] = webhook.GetMatrixHook(w)
+	case models.CUSTOM:
+		custom := webhook.GetCustomHook(w)
+		ctx.Data["CustomHook"] = custom
+		ctx.Data["CustomHeaders"] = custom.HeadersText()
+		ctx.Data["CustomPreview"], _ = webhook.PreviewCustomPayload(custom)
 	}
 
 	ctx.Data["History"], err = w.History(1)
@@ -703,7 +824,9 @@ func WebHooksEditPost(ctx *context.Context, form auth.NewWebhookForm) {
 
 	w.URL = form.PayloadURL
 	w.ContentType = contentType
-	w.Secret = form.Secret
+	w.SetSecret(form.Secret)
+	w.SignatureAlgorithm = models.HookSignatureAlgorithm(form.SignatureAlgorithm)
+	w.SignTimestamp = form.SignTimestamp
 	w.HookEvent = ParseHookEvent(form.WebhookForm)
 	w.IsActive = form.Active
 	w.HTTPMethod = form.HTTPMethod
@@ -743,7 +866,9 @@ func GogsHooksEditPost(ctx *context.Context, form auth.NewGogshookForm) {
 
 	w.URL = form.PayloadURL
 	w.ContentType = contentType
-	w.Secret = form.Secret
+	w.SetSecret(form.Secret)
+	w.SignatureAlgorithm = models.HookSignatureAlgorithm(form.SignatureAlgorithm)
+	w.SignTimestamp = form.SignTimestamp
 	w.HookEvent = ParseHookEvent(form.WebhookForm)
 	w.IsActive = form.Active
 	if err := w.UpdateEvent(); err != nil {
@@ -868,6 +993,7 @@ func DingtalkHooksEditPost(ctx *context.Context, form auth.NewDingtalkHookForm)
 	}
 
 	w.URL = form.PayloadURL
+	w.SetSecret(form.Secret)
 	w.HookEvent = ParseHookEvent(form.WebhookForm)
 	w.IsActive = form.Active
 	if err := w.UpdateEvent(); err != nil {
@@ -1015,6 +1141,7 @@ func FeishuHooksEditPost(ctx *context.Context, form auth.NewFeishuHookForm) {
 	}
 
 	w.URL = form.PayloadURL
+	w.SetSecret(form.Secret)
 	w.HookEvent = ParseHookEvent(form.WebhookForm)
 	w.IsActive = form.Active
 	if err := w.UpdateEvent(); err != nil {
@@ -1029,6 +1156,32 @@ func FeishuHooksEditPost(ctx *context.Context, form auth.NewFeishuHookForm) {
 	ctx.Redirect(fmt.Sprintf("%s/%d", orCtx.Link, w.ID))
 }
 
+// CustomHooksEditPost response for editing custom hook
+func CustomHooksEditPost(ctx *context.Context, form auth.NewCustomHookForm) {
+	ctx.Data["Title"] = ctx.Tr("repo.settings")
+	ctx.Data["PageIsSettingsHooks"] = true
+	ctx.Data["PageIsSettingsHooksEdit"] = true
+
+	orCtx, w := checkWebhook(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if !parseCustomHookForm(ctx, orCtx, form, w) {
+		return
+	}
+	if err := w.UpdateEvent(); err != nil {
+		ctx.ServerError("UpdateEvent", err)
+		return
+	} else if err := models.UpdateWebhook(w); err != nil {
+		ctx.ServerError("UpdateWebhook", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.settings.update_hook_success"))
+	ctx.Redirect(fmt.Sprintf("%s/%d", orCtx.Link, w.ID))
+}
+
 // TestWebhook test if web hook is work fine
 func TestWebhook(ctx *context.Context) {
 	hookID := ctx.ParamsInt64(":id")
@@ -1084,6 +1237,26 @@ func TestWebhook(ctx *context.Context) {
 	}
 }
 
+// ReplayWebhook redelivers a historical hook task of the webhook
+func ReplayWebhook(ctx *context.Context) {
+	orCtx, w := checkWebhook(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if _, err := webhook.ReplayHookTask(w, ctx.ParamsInt64(":taskid")); err != nil {
+		if models.IsErrHookTaskNotExist(err) {
+			ctx.NotFound("ReplayHookTask", nil)
+		} else {
+			ctx.ServerError("ReplayHookTask", err)
+		}
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.settings.webhook.redelivery_success"))
+	ctx.Redirect(fmt.Sprintf("%s/%d", orCtx.Link, w.ID))
+}
+
 // DeleteWebhook delete a webhook
 func DeleteWebhook(ctx *context.Context) {
 	if err := models.DeleteWebhookByRepoID(ctx.Repo.Repository.ID, ctx.QueryInt64("id")); err != nil {
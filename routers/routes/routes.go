@@ -9,11 +9,13 @@ import (
 	"encoding/gob"
 	"net/http"
 	"path"
+	"strings"
 	"text/template"
 	"time"
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/auth"
+	"code.gitea.io/gitea/modules/auth/webauthn"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/lfs"
 	"code.gitea.io/gitea/modules/log"
@@ -21,10 +23,13 @@ import (
 	"code.gitea.io/gitea/modules/options"
 	"code.gitea.io/gitea/modules/public"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 	"code.gitea.io/gitea/modules/templates"
 	"code.gitea.io/gitea/modules/validation"
 	"code.gitea.io/gitea/routers"
 	"code.gitea.io/gitea/routers/admin"
+	"code.gitea.io/gitea/routers/api/actions"
+	"code.gitea.io/gitea/routers/api/packages"
 	apiv1 "code.gitea.io/gitea/routers/api/v1"
 	"code.gitea.io/gitea/routers/dev"
 	"code.gitea.io/gitea/routers/events"
@@ -49,7 +54,6 @@ import (
 	"gitea.com/macaron/session"
 	"gitea.com/macaron/toolbox"
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/tstranex/u2f"
 )
 
 type routerLoggerOptions struct {
@@ -107,9 +111,78 @@ func RouterHandler(level log.Level) func(ctx *macaron.Context) {
 	}
 }
 
+// storageHandler serves the files of an object storage under the given prefix.
+// Local storages are served as static files, the others are streamed or, when
+// SERVE_DIRECT is enabled, redirected to the signed URL of the object.
+func storageHandler(storageSetting setting.Storage, prefix string, objStore storage.ObjectStorage) macaron.Handler {
+	if storageSetting.Type == setting.LocalStorageType {
+		return public.StaticHandler(
+			storageSetting.Path,
+			&public.Options{
+				Prefix:       prefix,
+				SkipLogging:  setting.DisableRouterLog,
+				ExpiresAfter: setting.StaticCacheTime,
+			},
+		)
+	}
+
+	return func(ctx *macaron.Context) {
+		if ctx.Req.Method != "GET" && ctx.Req.Method != "HEAD" {
+			return
+		}
+
+		if !strings.HasPrefix(ctx.Req.URL.Path, "/"+prefix+"/") {
+			return
+		}
+		rPath := strings.TrimPrefix(ctx.Req.URL.Path, "/"+prefix+"/")
+		rPath = strings.TrimPrefix(path.Clean("/"+rPath), "/")
+		if rPath == "" {
+			ctx.Error(http.StatusNotFound)
+			return
+		}
+
+		if storageSetting.ServeDirect {
+			u, err := objStore.URL(rPath, path.Base(rPath))
+			if err == nil {
+				http.Redirect(ctx.Resp, ctx.Req.Request, u.String(), http.StatusTemporaryRedirect)
+				return
+			}
+			log.Warn("Unable to get the URL of %s in the %s storage: %v", rPath, prefix, err)
+		}
+
+		fr, err := objStore.Open(rPath)
+		if err != nil {
+			if storage.IsNotExist(err) {
+				ctx.Error(http.StatusNotFound)
+				return
+			}
+			log.Error("Error whilst opening %s in the %s storage: %v", rPath, prefix, err)
+			ctx.Error(http.StatusInternalServerError)
+			return
+		}
+		defer fr.Close()
+
+		fi, err := fr.Stat()
+		if err != nil {
+			if storage.IsNotExist(err) {
+				ctx.Error(http.StatusNotFound)
+				return
+			}
+			log.Error("Error whilst stating %s in the %s storage: %v", rPath, prefix, err)
+			ctx.Error(http.StatusInternalServerError)
+			return
+		}
+
+		if setting.StaticCacheTime > 0 {
+			ctx.Resp.Header().Set("Expires", time.Now().Add(setting.StaticCacheTime).UTC().Format(http.TimeFormat))
+		}
+		http.ServeContent(ctx.Resp, ctx.Req.Request, path.Base(rPath), fi.ModTime(), fr)
+	}
+}
+
 // NewMacaron initializes Macaron instance.
 func NewMacaron() *macaron.Macaron {
-	gob.Register(&u2f.Challenge{})
+	gob.Register(&webauthn.SessionData{})
 	var m *macaron.Macaron
 	if setting.RedirectMacaronLog {
 		loggerAsWriter := log.NewLoggerAsWriter("INFO", log.GetLogger("macaron"))
@@ -149,22 +222,8 @@ func NewMacaron() *macaron.Macaron {
 			ExpiresAfter: setting.StaticCacheTime,
 		},
 	))
-	m.Use(public.StaticHandler(
-		setting.AvatarUploadPath,
-		&public.Options{
-			Prefix:       "avatars",
-			SkipLogging:  setting.DisableRouterLog,
-			ExpiresAfter: setting.StaticCacheTime,
-		},
-	))
-	m.Use(public.StaticHandler(
-		setting.RepositoryAvatarUploadPath,
-		&public.Options{
-			Prefix:       "repo-avatars",
-			SkipLogging:  setting.DisableRouterLog,
-			ExpiresAfter: setting.StaticCacheTime,
-		},
-	))
+	m.Use(storageHandler(setting.AvatarStorage, "avatars", storage.Avatars))
+	m.Use(storageHandler(setting.RepositoryAvatarStorage, "repo-avatars", storage.RepoAvatars))
 
 	m.Use(templates.HTMLRenderer())
 	mailer.InitMailRender(templates.Mailer())
@@ -324,6 +383,10 @@ func RegisterRoutes(m *macaron.Macaron) {
 			m.Get("/:provider", user.SignInOAuth)
 			m.Get("/:provider/callback", user.SignInOAuthCallback)
 		})
+		m.Group("/saml", func() {
+			m.Get("/:provider", user.SignInSAML)
+			m.Post("/:provider/acs", user.SAMLAssertionConsumer)
+		})
 		m.Get("/link_account", user.LinkAccount)
 		m.Post("/link_account_signin", bindIgnErr(auth.SignInForm{}), user.LinkAccountPostSignIn)
 		m.Post("/link_account_signup", bindIgnErr(auth.RegisterForm{}), user.LinkAccountPostRegister)
@@ -333,15 +396,17 @@ func RegisterRoutes(m *macaron.Macaron) {
 			m.Get("/scratch", user.TwoFactorScratch)
 			m.Post("/scratch", bindIgnErr(auth.TwoFactorScratchAuthForm{}), user.TwoFactorScratchPost)
 		})
-		m.Group("/u2f", func() {
-			m.Get("", user.U2F)
-			m.Get("/challenge", user.U2FChallenge)
-			m.Post("/sign", bindIgnErr(u2f.SignResponse{}), user.U2FSign)
-
+		m.Group("/webauthn", func() {
+			m.Get("", user.WebAuthn)
+			m.Get("/assertion", user.WebAuthnLoginAssertion)
+			m.Post("/assertion", bindIgnErr(webauthn.CredentialResponse{}), user.WebAuthnLoginAssertionPost)
+			m.Get("/passwordless", user.WebAuthnPasswordlessAssertion)
+			m.Post("/passwordless", bindIgnErr(webauthn.CredentialResponse{}), user.WebAuthnPasswordlessAssertionPost)
 		})
 	}, reqSignOut)
 
 	m.Any("/user/events", reqSignIn, events.Events)
+	m.Get("/user/saml/:provider/metadata", user.SAMLMetadata)
 
 	m.Group("/login/oauth", func() {
 		m.Get("/authorize", bindIgnErr(auth.AuthorizationForm{}), user.AuthorizeOAuth)
@@ -373,10 +438,10 @@ func RegisterRoutes(m *macaron.Macaron) {
 				m.Get("/enroll", userSetting.EnrollTwoFactor)
 				m.Post("/enroll", bindIgnErr(auth.TwoFactorAuthForm{}), userSetting.EnrollTwoFactorPost)
 			})
-			m.Group("/u2f", func() {
-				m.Post("/request_register", bindIgnErr(auth.U2FRegistrationForm{}), userSetting.U2FRegister)
-				m.Post("/register", bindIgnErr(u2f.RegisterResponse{}), userSetting.U2FRegisterPost)
-				m.Post("/delete", bindIgnErr(auth.U2FDeleteForm{}), userSetting.U2FDelete)
+			m.Group("/webauthn", func() {
+				m.Post("/request_register", bindIgnErr(auth.WebAuthnRegistrationForm{}), userSetting.WebAuthnRegister)
+				m.Post("/register", bindIgnErr(webauthn.CredentialResponse{}), userSetting.WebAuthnRegisterPost)
+				m.Post("/delete", bindIgnErr(auth.WebAuthnDeleteForm{}), userSetting.WebAuthnDelete)
 			})
 			m.Group("/openid", func() {
 				m.Post("", bindIgnErr(auth.AddOpenIDForm{}), userSetting.OpenIDPost)
@@ -476,7 +541,9 @@ func RegisterRoutes(m *macaron.Macaron) {
 			m.Post("/matrix/new", bindIgnErr(auth.NewMatrixHookForm{}), repo.MatrixHooksNewPost)
 			m.Post("/msteams/new", bindIgnErr(auth.NewMSTeamsHookForm{}), repo.MSTeamsHooksNewPost)
 			m.Post("/feishu/new", bindIgnErr(auth.NewFeishuHookForm{}), repo.FeishuHooksNewPost)
+			m.Post("/custom/new", bindIgnErr(auth.NewCustomHookForm{}), repo.CustomHooksNewPost)
 			m.Get("/:id", repo.WebHooksEdit)
+			m.Post("/:id/replay/:taskid", repo.ReplayWebhook)
 			m.Post("/gitea/:id", bindIgnErr(auth.NewWebhookForm{}), repo.WebHooksEditPost)
 			m.Post("/gogs/:id", bindIgnErr(auth.NewGogshookForm{}), repo.GogsHooksEditPost)
 			m.Post("/slack/:id", bindIgnErr(auth.NewSlackHookForm{}), repo.SlackHooksEditPost)
@@ -486,6 +553,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 			m.Post("/matrix/:id", bindIgnErr(auth.NewMatrixHookForm{}), repo.MatrixHooksEditPost)
 			m.Post("/msteams/:id", bindIgnErr(auth.NewMSTeamsHookForm{}), repo.MSTeamsHooksEditPost)
 			m.Post("/feishu/:id", bindIgnErr(auth.NewFeishuHookForm{}), repo.FeishuHooksEditPost)
+			m.Post("/custom/:id", bindIgnErr(auth.NewCustomHookForm{}), repo.CustomHooksEditPost)
 		})
 
 		m.Group("/auths", func() {
@@ -494,6 +562,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 			m.Combo("/:authid").Get(admin.EditAuthSource).
 				Post(bindIgnErr(auth.AuthenticationForm{}), admin.EditAuthSourcePost)
 			m.Post("/:authid/delete", admin.DeleteAuthSource)
+			m.Get("/:authid/team_sync", admin.AuthSourceTeamSyncPreview)
 		})
 
 		m.Group("/notices", func() {
@@ -501,6 +570,18 @@ func RegisterRoutes(m *macaron.Macaron) {
 			m.Post("/delete", admin.DeleteNotices)
 			m.Post("/empty", admin.EmptyNotices)
 		})
+
+		m.Get("/audit", admin.Audit)
+
+		m.Group("/runners", func() {
+			m.Get("", admin.Runners)
+			m.Post("/new", bindIgnErr(auth.AdminCreateActionRunnerForm{}), admin.NewRunnerPost)
+			m.Post("/delete", admin.DeleteRunner)
+		}, func(ctx *context.Context) {
+			if !setting.Actions.Enabled {
+				ctx.NotFound("Runners", nil)
+			}
+		})
 	}, adminReq)
 	// ***** END: Admin *****
 
@@ -518,6 +599,17 @@ func RegisterRoutes(m *macaron.Macaron) {
 		m.Post("/action/:action", user.Action)
 	}, reqSignIn)
 
+	if setting.Packages.Enabled {
+		m.Group("/:username/-/packages", func() {
+			m.Get("", user.Packages)
+			m.Group("/:type/:name", func() {
+				m.Get("", user.PackageView)
+				m.Get("/:version", user.PackageView)
+				m.Post("/:version/delete", reqSignIn, context.RequirePackageWriter(), user.DeletePackageVersion)
+			})
+		}, ignSignIn, context.PackageAssignment(), user.MustEnablePackages)
+	}
+
 	if macaron.Env == macaron.DEV {
 		m.Get("/template/*", dev.TemplatePreview)
 	}
@@ -533,6 +625,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 	reqRepoPullsReader := context.RequireRepoReader(models.UnitTypePullRequests)
 	reqRepoIssuesOrPullsWriter := context.RequireRepoWriterOr(models.UnitTypeIssues, models.UnitTypePullRequests)
 	reqRepoIssuesOrPullsReader := context.RequireRepoReaderOr(models.UnitTypeIssues, models.UnitTypePullRequests)
+	reqRepoProjectsWriter := context.RequireRepoWriter(models.UnitTypeProjects)
 
 	// ***** START: Organization *****
 	m.Group("/org", func() {
@@ -584,7 +677,9 @@ func RegisterRoutes(m *macaron.Macaron) {
 					m.Post("/matrix/new", bindIgnErr(auth.NewMatrixHookForm{}), repo.MatrixHooksNewPost)
 					m.Post("/msteams/new", bindIgnErr(auth.NewMSTeamsHookForm{}), repo.MSTeamsHooksNewPost)
 					m.Post("/feishu/new", bindIgnErr(auth.NewFeishuHookForm{}), repo.FeishuHooksNewPost)
+					m.Post("/custom/new", bindIgnErr(auth.NewCustomHookForm{}), repo.CustomHooksNewPost)
 					m.Get("/:id", repo.WebHooksEdit)
+					m.Post("/:id/replay/:taskid", repo.ReplayWebhook)
 					m.Post("/gitea/:id", bindIgnErr(auth.NewWebhookForm{}), repo.WebHooksEditPost)
 					m.Post("/gogs/:id", bindIgnErr(auth.NewGogshookForm{}), repo.GogsHooksEditPost)
 					m.Post("/slack/:id", bindIgnErr(auth.NewSlackHookForm{}), repo.SlackHooksEditPost)
@@ -594,6 +689,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 					m.Post("/matrix/:id", bindIgnErr(auth.NewMatrixHookForm{}), repo.MatrixHooksEditPost)
 					m.Post("/msteams/:id", bindIgnErr(auth.NewMSTeamsHookForm{}), repo.MSTeamsHooksEditPost)
 					m.Post("/feishu/:id", bindIgnErr(auth.NewFeishuHookForm{}), repo.FeishuHooksEditPost)
+					m.Post("/custom/:id", bindIgnErr(auth.NewCustomHookForm{}), repo.CustomHooksEditPost)
 				})
 
 				m.Group("/labels", func() {
@@ -606,8 +702,30 @@ func RegisterRoutes(m *macaron.Macaron) {
 
 				m.Route("/delete", "GET,POST", org.SettingsDelete)
 			})
+
+			m.Group("/projects", func() {
+				m.Combo("/new").Get(org.NewProject).
+					Post(bindIgnErr(auth.CreateProjectForm{}), org.NewProjectPost)
+				m.Group("/:id", func() {
+					m.Post("", bindIgnErr(auth.EditProjectBoardForm{}), repo.AddBoardToProjectPost)
+					m.Get("/edit", org.EditProject)
+					m.Post("/edit", bindIgnErr(auth.CreateProjectForm{}), org.EditProjectPost)
+					m.Post("/^:action(open|close)$", repo.ChangeProjectStatus)
+					m.Post("/delete", repo.DeleteProject)
+					m.Group("/:boardID", func() {
+						m.Post("", bindIgnErr(auth.EditProjectBoardForm{}), repo.EditProjectBoard)
+						m.Post("/delete", repo.DeleteProjectBoard)
+						m.Post("/move", repo.MoveIssues)
+					})
+				}, org.ProjectAssignment)
+			}, org.MustEnableProjects)
 		}, context.OrgAssignment(true, true))
 	}, reqSignIn)
+
+	m.Group("/org/:org/projects", func() {
+		m.Get("", org.Projects)
+		m.Get("/:id", org.ProjectAssignment, org.ViewProject)
+	}, ignSignIn, context.OrgAssignment(), org.MustEnableProjects)
 	// ***** END: Organization *****
 
 	// ***** START: Repository *****
@@ -660,8 +778,10 @@ func RegisterRoutes(m *macaron.Macaron) {
 				m.Post("/matrix/new", bindIgnErr(auth.NewMatrixHookForm{}), repo.MatrixHooksNewPost)
 				m.Post("/msteams/new", bindIgnErr(auth.NewMSTeamsHookForm{}), repo.MSTeamsHooksNewPost)
 				m.Post("/feishu/new", bindIgnErr(auth.NewFeishuHookForm{}), repo.FeishuHooksNewPost)
+				m.Post("/custom/new", bindIgnErr(auth.NewCustomHookForm{}), repo.CustomHooksNewPost)
 				m.Get("/:id", repo.WebHooksEdit)
 				m.Post("/:id/test", repo.TestWebhook)
+				m.Post("/:id/replay/:taskid", repo.ReplayWebhook)
 				m.Post("/gitea/:id", bindIgnErr(auth.NewWebhookForm{}), repo.WebHooksEditPost)
 				m.Post("/gogs/:id", bindIgnErr(auth.NewGogshookForm{}), repo.GogsHooksEditPost)
 				m.Post("/slack/:id", bindIgnErr(auth.NewSlackHookForm{}), repo.SlackHooksEditPost)
@@ -671,6 +791,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 				m.Post("/matrix/:id", bindIgnErr(auth.NewMatrixHookForm{}), repo.MatrixHooksEditPost)
 				m.Post("/msteams/:id", bindIgnErr(auth.NewMSTeamsHookForm{}), repo.MSTeamsHooksEditPost)
 				m.Post("/feishu/:id", bindIgnErr(auth.NewFeishuHookForm{}), repo.FeishuHooksEditPost)
+				m.Post("/custom/:id", bindIgnErr(auth.NewCustomHookForm{}), repo.CustomHooksEditPost)
 
 				m.Group("/git", func() {
 					m.Get("", repo.GitHooks)
@@ -712,6 +833,18 @@ func RegisterRoutes(m *macaron.Macaron) {
 		m.Group("/milestone", func() {
 			m.Get("/:id", repo.MilestoneIssuesAndPulls)
 		}, reqRepoIssuesOrPullsReader, context.RepoRef())
+		m.Group("/projects", func() {
+			m.Get("", repo.Projects)
+			m.Get("/:id", repo.ProjectAssignment, repo.ViewProject)
+		}, repo.MustEnableProjects)
+		m.Group("/actions", func() {
+			m.Get("", repo.Actions)
+			m.Group("/runs/:index", func() {
+				m.Get("", repo.ViewActionRun)
+				m.Get("/jobs/:job/logs", repo.ActionRunJobLog)
+				m.Post("/cancel", reqSignIn, context.RequireRepoWriter(models.UnitTypeActions), context.RepoMustNotBeArchived(), repo.CancelActionRun)
+			}, repo.ActionRunAssignment)
+		}, repo.MustEnableActions)
 		m.Combo("/compare/*", repo.MustBeNotEmpty, reqRepoCodeReader, repo.SetEditorconfigIfExists).
 			Get(repo.SetDiffViewStyle, repo.CompareDiff).
 			Post(reqSignIn, context.RepoMustNotBeArchived(), reqRepoPullsReader, repo.MustAllowPulls, bindIgnErr(auth.CreateIssueForm{}), repo.CompareAndPullRequestPost)
@@ -750,6 +883,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 
 			m.Post("/labels", reqRepoIssuesOrPullsWriter, repo.UpdateIssueLabel)
 			m.Post("/milestone", reqRepoIssuesOrPullsWriter, repo.UpdateIssueMilestone)
+			m.Post("/projects", reqRepoIssuesOrPullsWriter, repo.UpdateIssueProject)
 			m.Post("/assignee", reqRepoIssuesOrPullsWriter, repo.UpdateIssueAssignee)
 			m.Post("/request_review", reqRepoIssuesOrPullsReader, repo.UpdatePullReviewRequest)
 			m.Post("/status", reqRepoIssuesOrPullsWriter, repo.UpdateIssueStatus)
@@ -775,6 +909,22 @@ func RegisterRoutes(m *macaron.Macaron) {
 			m.Post("/:id/:action", repo.ChangeMilestonStatus)
 			m.Post("/delete", repo.DeleteMilestone)
 		}, context.RepoMustNotBeArchived(), reqRepoIssuesOrPullsWriter, context.RepoRef())
+		m.Group("/projects", func() {
+			m.Combo("/new").Get(repo.NewProject).
+				Post(bindIgnErr(auth.CreateProjectForm{}), repo.NewProjectPost)
+			m.Group("/:id", func() {
+				m.Post("", bindIgnErr(auth.EditProjectBoardForm{}), repo.AddBoardToProjectPost)
+				m.Get("/edit", repo.EditProject)
+				m.Post("/edit", bindIgnErr(auth.CreateProjectForm{}), repo.EditProjectPost)
+				m.Post("/^:action(open|close)$", repo.ChangeProjectStatus)
+				m.Post("/delete", repo.DeleteProject)
+				m.Group("/:boardID", func() {
+					m.Post("", bindIgnErr(auth.EditProjectBoardForm{}), repo.EditProjectBoard)
+					m.Post("/delete", repo.DeleteProjectBoard)
+					m.Post("/move", repo.MoveIssues)
+				})
+			}, repo.ProjectAssignment)
+		}, context.RepoMustNotBeArchived(), reqRepoProjectsWriter, repo.MustEnableProjects)
 		m.Group("/pull", func() {
 			m.Post("/:index/target_branch", repo.UpdatePullRequestTarget)
 		}, context.RepoMustNotBeArchived())
@@ -902,6 +1052,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 			m.Get(".patch", repo.DownloadPullPatch)
 			m.Get("/commits", context.RepoRef(), repo.ViewPullCommits)
 			m.Post("/merge", context.RepoMustNotBeArchived(), bindIgnErr(auth.MergePullRequestForm{}), repo.MergePullRequest)
+			m.Post("/cancel_auto_merge", context.RepoMustNotBeArchived(), repo.CancelAutoMergePullRequest)
 			m.Post("/update", repo.UpdatePullRequest)
 			m.Post("/cleanup", context.RepoMustNotBeArchived(), context.RepoRef(), repo.CleanUpPullRequest)
 			m.Group("/files", func() {
@@ -910,6 +1061,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 					m.Post("/comments", bindIgnErr(auth.CodeCommentForm{}), repo.CreateCodeComment)
 					m.Post("/submit", bindIgnErr(auth.SubmitReviewForm{}), repo.SubmitReview)
 				}, context.RepoMustNotBeArchived())
+				m.Post("/suggestions", context.RepoMustNotBeArchived(), bindIgnErr(auth.ApplySuggestionsForm{}), repo.ApplySuggestions)
 			})
 		}, repo.MustAllowPulls)
 
@@ -1023,6 +1175,12 @@ func RegisterRoutes(m *macaron.Macaron) {
 	handlers = append(handlers, ignSignIn)
 	m.Group("/api", func() {
 		apiv1.RegisterRoutes(m)
+		if setting.Packages.Enabled {
+			packages.RegisterRoutes(m)
+		}
+		if setting.Actions.Enabled {
+			actions.RegisterRoutes(m)
+		}
 	}, handlers...)
 
 	m.Group("/api/internal", func() {
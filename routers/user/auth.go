@@ -14,10 +14,12 @@ import (
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/auth"
 	"code.gitea.io/gitea/modules/auth/oauth2"
+	"code.gitea.io/gitea/modules/auth/webauthn"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/eventsource"
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification"
 	"code.gitea.io/gitea/modules/password"
 	"code.gitea.io/gitea/modules/recaptcha"
 	"code.gitea.io/gitea/modules/setting"
@@ -28,7 +30,6 @@ import (
 
 	"gitea.com/macaron/captcha"
 	"github.com/markbates/goth"
-	"github.com/tstranex/u2f"
 )
 
 const (
@@ -45,7 +46,7 @@ const (
 	tplTwofa          base.TplName = "user/auth/twofa"
 	tplTwofaScratch   base.TplName = "user/auth/twofa_scratch"
 	tplLinkAccount    base.TplName = "user/auth/link_account"
-	tplU2F            base.TplName = "user/auth/u2f"
+	tplWebAuthn       base.TplName = "user/auth/webauthn"
 )
 
 // AutoSignIn reads cookie and try to auto-login.
@@ -138,6 +139,11 @@ func SignIn(ctx *context.Context) {
 	}
 	ctx.Data["OrderedOAuth2Names"] = orderedOAuth2Names
 	ctx.Data["OAuth2Providers"] = oauth2Providers
+	ctx.Data["SAMLSources"], err = models.ActiveLoginSources(models.LoginSAML)
+	if err != nil {
+		ctx.ServerError("UserSignIn", err)
+		return
+	}
 	ctx.Data["Title"] = ctx.Tr("sign_in")
 	ctx.Data["SignInLink"] = setting.AppSubURL + "/user/login"
 	ctx.Data["PageIsSignIn"] = true
@@ -158,6 +164,11 @@ func SignInPost(ctx *context.Context, form auth.SignInForm) {
 	}
 	ctx.Data["OrderedOAuth2Names"] = orderedOAuth2Names
 	ctx.Data["OAuth2Providers"] = oauth2Providers
+	ctx.Data["SAMLSources"], err = models.ActiveLoginSources(models.LoginSAML)
+	if err != nil {
+		ctx.ServerError("UserSignIn", err)
+		return
+	}
 	ctx.Data["Title"] = ctx.Tr("sign_in")
 	ctx.Data["SignInLink"] = setting.AppSubURL + "/user/login"
 	ctx.Data["PageIsSignIn"] = true
@@ -221,9 +232,8 @@ func SignInPost(ctx *context.Context, form auth.SignInForm) {
 		return
 	}
 
-	regs, err := models.GetU2FRegistrationsByUID(u.ID)
-	if err == nil && len(regs) > 0 {
-		ctx.Redirect(setting.AppSubURL + "/user/u2f")
+	if has, err := models.HasWebAuthnCredentialsByUID(u.ID); err == nil && has {
+		ctx.Redirect(setting.AppSubURL + "/user/webauthn")
 		return
 	}
 
@@ -373,10 +383,10 @@ func TwoFactorScratchPost(ctx *context.Context, form auth.TwoFactorScratchAuthFo
 	ctx.RenderWithErr(ctx.Tr("auth.twofa_scratch_token_incorrect"), tplTwofaScratch, auth.TwoFactorScratchAuthForm{})
 }
 
-// U2F shows the U2F login page
-func U2F(ctx *context.Context) {
+// WebAuthn shows the WebAuthn login page
+func WebAuthn(ctx *context.Context) {
 	ctx.Data["Title"] = ctx.Tr("twofa")
-	ctx.Data["RequireU2F"] = true
+
 	// Check auto-login.
 	if checkAutoLogin(ctx) {
 		return
@@ -384,104 +394,195 @@ func U2F(ctx *context.Context) {
 
 	// Ensure user is in a 2FA session.
 	if ctx.Session.Get("twofaUid") == nil {
-		ctx.ServerError("UserSignIn", errors.New("not in U2F session"))
+		ctx.ServerError("UserSignIn", errors.New("not in WebAuthn session"))
 		return
 	}
 
-	ctx.HTML(200, tplU2F)
+	ctx.HTML(200, tplWebAuthn)
 }
 
-// U2FChallenge submits a sign challenge to the browser
-func U2FChallenge(ctx *context.Context) {
-	// Ensure user is in a U2F session.
-	idSess := ctx.Session.Get("twofaUid")
-	if idSess == nil {
-		ctx.ServerError("UserSignIn", errors.New("not in U2F session"))
+// WebAuthnLoginAssertion submits the options of an assertion of one of the
+// credentials of the user to the browser
+func WebAuthnLoginAssertion(ctx *context.Context) {
+	// Ensure user is in a 2FA session.
+	id, ok := ctx.Session.Get("twofaUid").(int64)
+	if !ok {
+		ctx.ServerError("UserSignIn", errors.New("not in WebAuthn session"))
 		return
 	}
-	id := idSess.(int64)
-	regs, err := models.GetU2FRegistrationsByUID(id)
+	creds, err := models.GetWebAuthnCredentialsByUID(id)
 	if err != nil {
 		ctx.ServerError("UserSignIn", err)
 		return
 	}
-	if len(regs) == 0 {
+	if len(creds) == 0 {
 		ctx.ServerError("UserSignIn", errors.New("no device registered"))
 		return
 	}
-	challenge, err := u2f.NewChallenge(setting.U2F.AppID, setting.U2F.TrustedFacets)
+	list, err := creds.Credentials()
 	if err != nil {
-		ctx.ServerError("u2f.NewChallenge", err)
+		ctx.ServerError("UserSignIn", err)
 		return
 	}
-	if err := ctx.Session.Set("u2fChallenge", challenge); err != nil {
-		ctx.ServerError("UserSignIn: unable to set u2fChallenge in session", err)
+
+	opts, sessionData, err := webauthn.BeginLogin(id, list)
+	if err != nil {
+		ctx.ServerError("webauthn.BeginLogin", err)
+		return
+	}
+	if err := ctx.Session.Set("webauthnAssertion", sessionData); err != nil {
+		ctx.ServerError("UserSignIn: unable to set webauthnAssertion in session", err)
 		return
 	}
 	if err := ctx.Session.Release(); err != nil {
 		ctx.ServerError("UserSignIn: unable to store session", err)
+		return
 	}
 
-	ctx.JSON(200, challenge.SignRequest(regs.ToRegistrations()))
+	ctx.JSON(200, opts)
 }
 
-// U2FSign authenticates the user by signResp
-func U2FSign(ctx *context.Context, signResp u2f.SignResponse) {
-	challSess := ctx.Session.Get("u2fChallenge")
-	idSess := ctx.Session.Get("twofaUid")
-	if challSess == nil || idSess == nil {
-		ctx.ServerError("UserSignIn", errors.New("not in U2F session"))
+// WebAuthnLoginAssertionPost authenticates the user by the assertion of the authenticator
+func WebAuthnLoginAssertionPost(ctx *context.Context, response webauthn.CredentialResponse) {
+	sessionData, ok := ctx.Session.Get("webauthnAssertion").(*webauthn.SessionData)
+	id, idOk := ctx.Session.Get("twofaUid").(int64)
+	if !ok || !idOk || sessionData.UserID != id {
+		ctx.ServerError("UserSignIn", errors.New("not in WebAuthn session"))
 		return
 	}
-	challenge := challSess.(*u2f.Challenge)
-	id := idSess.(int64)
-	regs, err := models.GetU2FRegistrationsByUID(id)
+	_ = ctx.Session.Delete("webauthnAssertion")
+
+	cred := verifyWebAuthnAssertion(ctx, sessionData, &response)
+	if cred == nil {
+		return
+	}
+	if cred.UserID != id {
+		ctx.Error(401)
+		return
+	}
+
+	user, err := models.GetUserByID(id)
 	if err != nil {
 		ctx.ServerError("UserSignIn", err)
 		return
 	}
-	for _, reg := range regs {
-		r, err := reg.Parse()
+	remember := ctx.Session.Get("twofaRemember").(bool)
+
+	if ctx.Session.Get("linkAccount") != nil {
+		gothUser := ctx.Session.Get("linkAccountGothUser")
+		if gothUser == nil {
+			ctx.ServerError("UserSignIn", errors.New("not in LinkAccount session"))
+			return
+		}
+
+		err = externalaccount.LinkAccountToUser(user, gothUser.(goth.User))
 		if err != nil {
-			log.Fatal("parsing u2f registration: %v", err)
-			continue
+			ctx.ServerError("UserSignIn", err)
+			return
 		}
-		newCounter, authErr := r.Authenticate(signResp, *challenge, reg.Counter)
-		if authErr == nil {
-			reg.Counter = newCounter
-			user, err := models.GetUserByID(id)
-			if err != nil {
-				ctx.ServerError("UserSignIn", err)
-				return
-			}
-			remember := ctx.Session.Get("twofaRemember").(bool)
-			if err := reg.UpdateCounter(); err != nil {
-				ctx.ServerError("UserSignIn", err)
-				return
-			}
+	}
+	redirect := handleSignInFull(ctx, user, remember, false)
+	if redirect == "" {
+		redirect = setting.AppSubURL + "/"
+	}
+	ctx.PlainText(200, []byte(redirect))
+}
 
-			if ctx.Session.Get("linkAccount") != nil {
-				gothUser := ctx.Session.Get("linkAccountGothUser")
-				if gothUser == nil {
-					ctx.ServerError("UserSignIn", errors.New("not in LinkAccount session"))
-					return
-				}
+// WebAuthnPasswordlessAssertion submits the options of an assertion of any
+// passwordless credential to the browser
+func WebAuthnPasswordlessAssertion(ctx *context.Context) {
+	opts, sessionData, err := webauthn.BeginLogin(0, nil)
+	if err != nil {
+		ctx.ServerError("webauthn.BeginLogin", err)
+		return
+	}
+	if err := ctx.Session.Set("webauthnAssertion", sessionData); err != nil {
+		ctx.ServerError("UserSignIn: unable to set webauthnAssertion in session", err)
+		return
+	}
+	if err := ctx.Session.Release(); err != nil {
+		ctx.ServerError("UserSignIn: unable to store session", err)
+		return
+	}
 
-				err = externalaccount.LinkAccountToUser(user, gothUser.(goth.User))
-				if err != nil {
-					ctx.ServerError("UserSignIn", err)
-					return
-				}
-			}
-			redirect := handleSignInFull(ctx, user, remember, false)
-			if redirect == "" {
-				redirect = setting.AppSubURL + "/"
-			}
-			ctx.PlainText(200, []byte(redirect))
-			return
+	ctx.JSON(200, opts)
+}
+
+// WebAuthnPasswordlessAssertionPost signs the user in without a password by the
+// assertion of a passwordless credential
+func WebAuthnPasswordlessAssertionPost(ctx *context.Context, response webauthn.CredentialResponse) {
+	sessionData, ok := ctx.Session.Get("webauthnAssertion").(*webauthn.SessionData)
+	if !ok || sessionData.UserID != 0 || !sessionData.UserVerification {
+		ctx.ServerError("UserSignIn", errors.New("not in WebAuthn session"))
+		return
+	}
+	_ = ctx.Session.Delete("webauthnAssertion")
+
+	cred := verifyWebAuthnAssertion(ctx, sessionData, &response)
+	if cred == nil {
+		return
+	}
+	if userID, err := response.UserID(); !cred.Passwordless || err != nil || (userID != 0 && userID != cred.UserID) {
+		log.Info("Failed passwordless authentication attempt with credential %d from %s", cred.ID, ctx.RemoteAddr())
+		ctx.Error(401)
+		return
+	}
+
+	u, err := models.GetUserByID(cred.UserID)
+	if err != nil {
+		ctx.ServerError("UserSignIn", err)
+		return
+	}
+	// The activation of the user is checked on reqSign like for password sign in
+	if u.ProhibitLogin {
+		log.Info("Failed authentication attempt for %s from %s", u.Name, ctx.RemoteAddr())
+		ctx.Error(403)
+		return
+	}
+
+	redirect := handleSignInFull(ctx, u, false, false)
+	if redirect == "" {
+		redirect = setting.AppSubURL + "/"
+	}
+	ctx.PlainText(200, []byte(redirect))
+}
+
+// verifyWebAuthnAssertion verifies the assertion of the authenticator and updates
+// the signature counter of the credential. It returns nil and writes the response
+// if the assertion is invalid.
+func verifyWebAuthnAssertion(ctx *context.Context, sessionData *webauthn.SessionData, response *webauthn.CredentialResponse) *models.WebAuthnCredential {
+	credID, err := response.CredentialID()
+	if err != nil {
+		ctx.Error(400)
+		return nil
+	}
+	// a second factor must be a credential of the user signing in
+	cred, err := models.GetWebAuthnCredentialByCredID(sessionData.UserID, credID)
+	if err != nil {
+		if models.IsErrWebAuthnCredentialNotExist(err) {
+			ctx.Error(401)
+			return nil
 		}
+		ctx.ServerError("UserSignIn", err)
+		return nil
 	}
-	ctx.Error(401)
+	c, err := cred.Credential()
+	if err != nil {
+		ctx.ServerError("UserSignIn", err)
+		return nil
+	}
+
+	cred.SignCount, err = webauthn.FinishLogin(sessionData, response, c)
+	if err != nil {
+		log.Info("Failed WebAuthn authentication attempt with credential %d of user %d from %s: %v", cred.ID, cred.UserID, ctx.RemoteAddr(), err)
+		ctx.Error(401)
+		return nil
+	}
+	if err := cred.UpdateSignCount(); err != nil {
+		ctx.ServerError("UserSignIn", err)
+		return nil
+	}
+	return cred
 }
 
 // This handles the final part of the sign-in process of the user.
@@ -503,7 +604,7 @@ func handleSignInFull(ctx *context.Context, u *models.User, remember bool, obeyR
 	_ = ctx.Session.Delete("openid_determined_username")
 	_ = ctx.Session.Delete("twofaUid")
 	_ = ctx.Session.Delete("twofaRemember")
-	_ = ctx.Session.Delete("u2fChallenge")
+	_ = ctx.Session.Delete("webauthnAssertion")
 	_ = ctx.Session.Delete("linkAccount")
 	if err := ctx.Session.Set("uid", u.ID); err != nil {
 		log.Error("Error setting uid %d in session: %v", u.ID, err)
@@ -670,10 +771,9 @@ func handleOAuth2SignIn(u *models.User, gothUser goth.User, ctx *context.Context
 		log.Error("Error storing session: %v", err)
 	}
 
-	// If U2F is enrolled -> Redirect to U2F instead
-	regs, err := models.GetU2FRegistrationsByUID(u.ID)
-	if err == nil && len(regs) > 0 {
-		ctx.Redirect(setting.AppSubURL + "/user/u2f")
+	// If WebAuthn is enrolled -> Redirect to WebAuthn instead
+	if has, err := models.HasWebAuthnCredentialsByUID(u.ID); err == nil && has {
+		ctx.Redirect(setting.AppSubURL + "/user/webauthn")
 		return
 	}
 
@@ -704,27 +804,29 @@ func oAuth2UserLoginCallback(loginSource *models.LoginSource, request *http.Requ
 		return nil, goth.User{}, err
 	}
 
-	if hasUser {
-		return user, gothUser, nil
+	if !hasUser {
+		// search in external linked users
+		externalLoginUser := &models.ExternalLoginUser{
+			ExternalID:    gothUser.UserID,
+			LoginSourceID: loginSource.ID,
+		}
+		hasUser, err = models.GetExternalLogin(externalLoginUser)
+		if err != nil {
+			return nil, goth.User{}, err
+		}
+		if !hasUser {
+			// no user found to login
+			return nil, gothUser, nil
+		}
+		if user, err = models.GetUserByID(externalLoginUser.UserID); err != nil {
+			return nil, goth.User{}, err
+		}
 	}
 
-	// search in external linked users
-	externalLoginUser := &models.ExternalLoginUser{
-		ExternalID:    gothUser.UserID,
-		LoginSourceID: loginSource.ID,
-	}
-	hasUser, err = models.GetExternalLogin(externalLoginUser)
-	if err != nil {
+	if err := models.SyncOAuth2GroupTeams(loginSource, user, gothUser); err != nil {
 		return nil, goth.User{}, err
 	}
-	if hasUser {
-		user, err = models.GetUserByID(externalLoginUser.UserID)
-		return user, gothUser, err
-	}
-
-	// no user found to login
-	return nil, gothUser, nil
-
+	return user, gothUser, nil
 }
 
 // LinkAccount shows the page where the user can decide to login or create a new account
@@ -849,10 +951,9 @@ func LinkAccountPostSignIn(ctx *context.Context, signInForm auth.SignInForm) {
 		log.Error("Error storing session: %v", err)
 	}
 
-	// If U2F is enrolled -> Redirect to U2F instead
-	regs, err := models.GetU2FRegistrationsByUID(u.ID)
-	if err == nil && len(regs) > 0 {
-		ctx.Redirect(setting.AppSubURL + "/user/u2f")
+	// If WebAuthn is enrolled -> Redirect to WebAuthn instead
+	if has, err := models.HasWebAuthnCredentialsByUID(u.ID); err == nil && has {
+		ctx.Redirect(setting.AppSubURL + "/user/webauthn")
 		return
 	}
 
@@ -972,6 +1073,7 @@ func LinkAccountPostRegister(ctx *context.Context, cpt *captcha.Captcha, form au
 		return
 	}
 	log.Trace("Account created: %s", u.Name)
+	notification.NotifyCreateUser(u, u)
 
 	// Auto-set admin for the only user.
 	if models.CountUsers() == 1 {
@@ -988,6 +1090,9 @@ func LinkAccountPostRegister(ctx *context.Context, cpt *captcha.Captcha, form au
 	if err := models.UpdateExternalUser(u, gothUser.(goth.User)); err != nil {
 		log.Error("UpdateExternalUser failed: %v", err)
 	}
+	if err := models.SyncOAuth2GroupTeams(loginSource, u, gothUser.(goth.User)); err != nil {
+		log.Error("SyncOAuth2GroupTeams failed: %v", err)
+	}
 
 	// Send confirmation email
 	if setting.Service.RegisterEmailConfirm && u.ID > 1 {
@@ -1137,6 +1242,7 @@ func SignUpPost(ctx *context.Context, cpt *captcha.Captcha, form auth.RegisterFo
 		return
 	}
 	log.Trace("Account created: %s", u.Name)
+	notification.NotifyCreateUser(u, u)
 
 	// Auto-set admin for the only user.
 	if models.CountUsers() == 1 {
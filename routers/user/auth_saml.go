@@ -0,0 +1,208 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"encoding/xml"
+	"errors"
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/crewjam/saml"
+	"github.com/crewjam/saml/samlsp"
+)
+
+func samlLoginSource(ctx *context.Context) (*models.LoginSource, *saml.ServiceProvider) {
+	provider := ctx.Params(":provider")
+
+	loginSource, err := models.GetActiveSAMLLoginSourceByName(provider)
+	if err != nil {
+		ctx.ServerError("GetActiveSAMLLoginSourceByName", err)
+		return nil, nil
+	} else if loginSource == nil {
+		ctx.NotFound("GetActiveSAMLLoginSourceByName", errors.New("no active SAML login source with this name"))
+		return nil, nil
+	}
+
+	sp, err := loginSource.SAML().ServiceProvider(loginSource.Name)
+	if err != nil {
+		ctx.ServerError("ServiceProvider", err)
+		return nil, nil
+	}
+	return loginSource, sp
+}
+
+// samlRequestTracker tracks the pending authentication requests in signed cookies bound to the
+// RelayState. The response of the identity provider is a cross-site POST, which doesn't carry
+// the SameSite=Lax session cookie.
+func samlRequestTracker(sp *saml.ServiceProvider) samlsp.CookieRequestTracker {
+	sameSite := http.SameSiteDefaultMode
+	if sp.AcsURL.Scheme == "https" {
+		sameSite = http.SameSiteNoneMode
+	}
+	return samlsp.DefaultRequestTracker(samlsp.Options{
+		URL:            sp.MetadataURL,
+		Key:            sp.Key,
+		CookieSameSite: sameSite,
+	}, sp)
+}
+
+// SignInSAML redirects the user to the identity provider of a SAML login source
+func SignInSAML(ctx *context.Context) {
+	_, sp := samlLoginSource(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	req, err := sp.MakeAuthenticationRequest(sp.GetSSOBindingLocation(saml.HTTPRedirectBinding), saml.HTTPRedirectBinding, saml.HTTPPostBinding)
+	if err != nil {
+		ctx.ServerError("MakeAuthenticationRequest", err)
+		return
+	}
+	relayState, err := samlRequestTracker(sp).TrackRequest(ctx.Resp, ctx.Req.Request, req.ID)
+	if err != nil {
+		ctx.ServerError("TrackRequest", err)
+		return
+	}
+	redirectURL, err := req.Redirect(relayState, sp)
+	if err != nil {
+		ctx.ServerError("Redirect", err)
+		return
+	}
+
+	ctx.Redirect(redirectURL.String())
+}
+
+// SAMLMetadata serves the service provider metadata of a SAML login source
+func SAMLMetadata(ctx *context.Context) {
+	_, sp := samlLoginSource(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	buf, err := xml.MarshalIndent(sp.Metadata(), "", "  ")
+	if err != nil {
+		ctx.ServerError("MarshalIndent", err)
+		return
+	}
+	ctx.Resp.Header().Set("Content-Type", "application/samlmetadata+xml")
+	ctx.Resp.WriteHeader(http.StatusOK)
+	if _, err := ctx.Resp.Write(buf); err != nil {
+		log.Error("Unable to write SAML metadata: %v", err)
+	}
+}
+
+// SAMLAssertionConsumer handles the response of the identity provider, signs
+// in the user and registers them if necessary
+func SAMLAssertionConsumer(ctx *context.Context) {
+	loginSource, sp := samlLoginSource(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := ctx.Req.ParseForm(); err != nil {
+		ctx.ServerError("ParseForm", err)
+		return
+	}
+
+	tracker := samlRequestTracker(sp)
+	relayState := ctx.Req.Form.Get("RelayState")
+	trackedRequest, err := tracker.GetTrackedRequest(ctx.Req.Request, relayState)
+	if err != nil {
+		log.Info("Failed SAML authentication via %s from %s: no pending request: %v", loginSource.Name, ctx.RemoteAddr(), err)
+		ctx.Flash.Error(ctx.Tr("auth.saml_sign_in_failed"))
+		ctx.Redirect(setting.AppSubURL + "/user/login")
+		return
+	}
+	if err := tracker.StopTrackingRequest(ctx.Resp, ctx.Req.Request, relayState); err != nil {
+		log.Error("StopTrackingRequest: %v", err)
+	}
+
+	assertion, err := sp.ParseResponse(ctx.Req.Request, []string{trackedRequest.SAMLRequestID})
+	if err != nil {
+		if invalid, ok := err.(*saml.InvalidResponseError); ok {
+			err = invalid.PrivateErr
+		}
+		log.Info("Failed SAML authentication via %s from %s: %v", loginSource.Name, ctx.RemoteAddr(), err)
+		ctx.Flash.Error(ctx.Tr("auth.saml_sign_in_failed"))
+		ctx.Redirect(setting.AppSubURL + "/user/login")
+		return
+	}
+
+	info, err := loginSource.SAML().UserInfo(assertion)
+	if err != nil {
+		log.Info("Failed SAML authentication via %s from %s: %v", loginSource.Name, ctx.RemoteAddr(), err)
+		ctx.Flash.Error(ctx.Tr("auth.saml_sign_in_failed"))
+		ctx.Redirect(setting.AppSubURL + "/user/login")
+		return
+	}
+
+	u, created, err := models.UserSignInSAML(loginSource, info)
+	if err != nil {
+		switch {
+		case models.IsErrUserProhibitLogin(err):
+			log.Info("Failed authentication attempt for %s from %s", info.Username, ctx.RemoteAddr())
+			ctx.Data["Title"] = ctx.Tr("auth.prohibit_login")
+			ctx.HTML(200, "user/auth/prohibit_login")
+		case models.IsErrUserAlreadyExist(err):
+			ctx.Flash.Error(ctx.Tr("auth.saml_username_taken", info.Username))
+			ctx.Redirect(setting.AppSubURL + "/user/login")
+		case models.IsErrEmailAlreadyUsed(err):
+			ctx.Flash.Error(ctx.Tr("auth.saml_email_used", info.Email))
+			ctx.Redirect(setting.AppSubURL + "/user/login")
+		case models.IsErrExternalUserMissingEmail(err):
+			ctx.Flash.Error(ctx.Tr("auth.saml_missing_email"))
+			ctx.Redirect(setting.AppSubURL + "/user/login")
+		case models.IsErrNameReserved(err), models.IsErrNamePatternNotAllowed(err), models.IsErrNameCharsNotAllowed(err):
+			ctx.Flash.Error(ctx.Tr("auth.saml_username_invalid", info.Username))
+			ctx.Redirect(setting.AppSubURL + "/user/login")
+		default:
+			ctx.ServerError("UserSignInSAML", err)
+		}
+		return
+	}
+	if created {
+		log.Trace("Account created via %s: %s", loginSource.Name, u.Name)
+		notification.NotifyCreateUser(u, u)
+	}
+
+	// If this user is enrolled in 2FA, we can't sign the user in just yet.
+	// Instead, redirect them to the 2FA authentication page.
+	_, err = models.GetTwoFactorByUID(u.ID)
+	if err != nil {
+		if models.IsErrTwoFactorNotEnrolled(err) {
+			handleSignIn(ctx, u, false)
+		} else {
+			ctx.ServerError("UserSignIn", err)
+		}
+		return
+	}
+
+	// User needs to use 2FA, save data and redirect to 2FA page.
+	if err := ctx.Session.Set("twofaUid", u.ID); err != nil {
+		ctx.ServerError("UserSignIn: Unable to set twofaUid in session", err)
+		return
+	}
+	if err := ctx.Session.Set("twofaRemember", false); err != nil {
+		ctx.ServerError("UserSignIn: Unable to set twofaRemember in session", err)
+		return
+	}
+	if err := ctx.Session.Release(); err != nil {
+		ctx.ServerError("UserSignIn: Unable to save session", err)
+		return
+	}
+
+	if has, err := models.HasWebAuthnCredentialsByUID(u.ID); err == nil && has {
+		ctx.Redirect(setting.AppSubURL + "/user/webauthn")
+		return
+	}
+
+	ctx.Redirect(setting.AppSubURL + "/user/two_factor")
+}
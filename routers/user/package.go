@@ -0,0 +1,201 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"net/http"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/packages"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+const (
+	tplPackages    base.TplName = "user/packages/list"
+	tplPackageView base.TplName = "user/packages/view"
+)
+
+// MustEnablePackages sets the links of the package pages of the owner
+func MustEnablePackages(ctx *context.Context) {
+	if ctx.Package.AccessMode < models.AccessModeRead {
+		ctx.NotFound("MustEnablePackages", nil)
+		return
+	}
+
+	owner := ctx.Package.Owner
+	ctx.Data["PageIsPackages"] = true
+	ctx.Data["PackagesLink"] = owner.HomeLink() + "/-/packages"
+	if owner.IsOrganization() {
+		// the organization header is shown above the package pages
+		ctx.Data["Org"] = owner
+		ctx.Data["OrgLink"] = setting.AppSubURL + "/org/" + owner.Name
+	}
+}
+
+// Packages renders the packages of a user or an organization
+func Packages(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("packages.title")
+
+	page := ctx.QueryInt("page")
+	if page <= 0 {
+		page = 1
+	}
+
+	opts := models.PackageSearchOptions{
+		ListOptions: models.ListOptions{
+			Page:     page,
+			PageSize: setting.UI.IssuePagingNum,
+		},
+		OwnerID: ctx.Package.Owner.ID,
+		Keyword: ctx.Query("q"),
+	}
+	packageType := ctx.Query("type")
+	if t, ok := models.PackageTypeFromName(packageType); ok {
+		opts.Type = t
+	}
+
+	pkgs, count, err := models.GetPackages(opts)
+	if err != nil {
+		ctx.ServerError("GetPackages", err)
+		return
+	}
+	for _, p := range pkgs {
+		p.Owner = ctx.Package.Owner
+	}
+
+	ctx.Data["Packages"] = pkgs
+	ctx.Data["Keyword"] = opts.Keyword
+	ctx.Data["PackageType"] = packageType
+
+	pager := context.NewPagination(int(count), opts.PageSize, page, 5)
+	pager.AddParam(ctx, "q", "Keyword")
+	pager.AddParam(ctx, "type", "PackageType")
+	ctx.Data["Page"] = pager
+
+	ctx.HTML(http.StatusOK, tplPackages)
+}
+
+// packageAssignment loads the package given by the :type and :name parameters
+func packageAssignment(ctx *context.Context) *models.Package {
+	packageType, ok := models.PackageTypeFromName(ctx.Params(":type"))
+	if !ok {
+		ctx.NotFound("PackageTypeFromName", nil)
+		return nil
+	}
+
+	p, err := models.GetPackageByName(ctx.Package.Owner.ID, packageType, ctx.Params(":name"))
+	if err != nil {
+		if models.IsErrPackageNotExist(err) {
+			ctx.NotFound("GetPackageByName", err)
+		} else {
+			ctx.ServerError("GetPackageByName", err)
+		}
+		return nil
+	}
+	p.Owner = ctx.Package.Owner
+	return p
+}
+
+// PackageView renders a version of a package, the latest version if none is given
+func PackageView(ctx *context.Context) {
+	p := packageAssignment(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	versions, err := models.GetPackageVersions(p.ID)
+	if err != nil {
+		ctx.ServerError("GetPackageVersions", err)
+		return
+	}
+	if len(versions) == 0 {
+		ctx.NotFound("GetPackageVersions", nil)
+		return
+	}
+
+	pv := versions[0]
+	if version := ctx.Params(":version"); version != "" {
+		pv = nil
+		for _, v := range versions {
+			if v.LowerVersion == strings.ToLower(version) {
+				pv = v
+				break
+			}
+		}
+		if pv == nil {
+			ctx.NotFound("GetPackageVersion", nil)
+			return
+		}
+	}
+	if err := pv.LoadCreator(); err != nil {
+		ctx.ServerError("LoadCreator", err)
+		return
+	}
+
+	files, err := models.GetPackageFiles(pv.ID)
+	if err != nil {
+		ctx.ServerError("GetPackageFiles", err)
+		return
+	}
+	fileURLs := make(map[int64]string, len(files))
+	for _, pf := range files {
+		fileURLs[pf.ID] = packages.FileURL(p, pv, pf.Name)
+	}
+
+	ctx.Data["Title"] = p.Name
+	ctx.Data["Package"] = p
+	ctx.Data["PackageVersion"] = pv
+	ctx.Data["PackageVersions"] = versions
+	ctx.Data["PackageFiles"] = files
+	ctx.Data["PackageFileURLs"] = fileURLs
+	ctx.Data["RegistryURL"] = packages.RegistryURL(p.Owner, p.Type)
+
+	switch p.Type {
+	case models.PackageTypeNpm:
+		// scoped packages are looked up in the registry configured for the scope
+		if strings.HasPrefix(p.Name, "@") {
+			ctx.Data["NpmScope"] = strings.SplitN(p.Name, "/", 2)[0]
+		}
+	case models.PackageTypeMaven:
+		parts := strings.SplitN(p.Name, ":", 2)
+		if len(parts) == 2 {
+			ctx.Data["MavenGroupID"] = parts[0]
+			ctx.Data["MavenArtifactID"] = parts[1]
+		}
+	}
+
+	ctx.HTML(http.StatusOK, tplPackageView)
+}
+
+// DeletePackageVersion deletes a version of a package
+func DeletePackageVersion(ctx *context.Context) {
+	p := packageAssignment(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	pv, err := models.GetPackageVersion(p.ID, ctx.Params(":version"))
+	if err != nil {
+		if models.IsErrPackageVersionNotExist(err) {
+			ctx.NotFound("GetPackageVersion", err)
+		} else {
+			ctx.ServerError("GetPackageVersion", err)
+		}
+		return
+	}
+
+	if err := models.DeletePackageVersion(pv); err != nil {
+		ctx.Flash.Error("DeletePackageVersion: " + err.Error())
+	} else {
+		ctx.Flash.Success(ctx.Tr("packages.delete_success", p.Name, pv.Version))
+	}
+
+	ctx.JSON(http.StatusOK, map[string]interface{}{
+		"redirect": ctx.Data["PackagesLink"],
+	})
+}
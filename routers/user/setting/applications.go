@@ -6,11 +6,16 @@
 package setting
 
 import (
+	"strings"
+	"time"
+
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/auth"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
 )
 
 const (
@@ -39,9 +44,39 @@ func ApplicationsPost(ctx *context.Context, form auth.NewAccessTokenForm) {
 		return
 	}
 
+	scope, err := models.ParseAccessTokenScope(form.Scopes)
+	if err != nil {
+		ctx.Flash.Error(err.Error())
+		ctx.Redirect(setting.AppSubURL + "/user/settings/applications")
+		return
+	}
+
+	repoIDs, err := models.ResolveAccessTokenRepositories(ctx.User, strings.Split(form.Repositories, ","))
+	if err != nil {
+		if models.IsErrRepoNotExist(err) {
+			ctx.Flash.Error(ctx.Tr("settings.token_repositories_invalid", form.Repositories))
+			ctx.Redirect(setting.AppSubURL + "/user/settings/applications")
+			return
+		}
+		ctx.ServerError("ResolveAccessTokenRepositories", err)
+		return
+	}
+
 	t := &models.AccessToken{
-		UID:  ctx.User.ID,
-		Name: form.Name,
+		UID:     ctx.User.ID,
+		Name:    form.Name,
+		Scope:   scope,
+		RepoIDs: repoIDs,
+	}
+
+	if len(form.ExpiresAt) > 0 {
+		expiresAt, err := time.ParseInLocation("2006-01-02", form.ExpiresAt, time.Local)
+		if err != nil || !expiresAt.After(time.Now()) {
+			ctx.Flash.Error(ctx.Tr("settings.token_expires_at_invalid"))
+			ctx.Redirect(setting.AppSubURL + "/user/settings/applications")
+			return
+		}
+		t.ExpiresUnix = timeutil.TimeStamp(expiresAt.Unix())
 	}
 
 	exist, err := models.AccessTokenByNameExists(t)
@@ -59,6 +94,13 @@ func ApplicationsPost(ctx *context.Context, form auth.NewAccessTokenForm) {
 		ctx.ServerError("NewAccessToken", err)
 		return
 	}
+	audit.Record(ctx.User, ctx.RemoteAddr(), &models.AuditEvent{
+		Action:     models.AuditAccessTokenCreate,
+		TargetType: models.AuditTargetAccessToken,
+		TargetID:   t.ID,
+		TargetName: t.Name,
+		Details:    "scopes: " + string(t.Scope),
+	})
 
 	ctx.Flash.Success(ctx.Tr("settings.generate_token_success"))
 	ctx.Flash.Info(t.Token)
@@ -68,9 +110,15 @@ func ApplicationsPost(ctx *context.Context, form auth.NewAccessTokenForm) {
 
 // DeleteApplication response for delete user access token
 func DeleteApplication(ctx *context.Context) {
-	if err := models.DeleteAccessTokenByID(ctx.QueryInt64("id"), ctx.User.ID); err != nil {
+	tokenID := ctx.QueryInt64("id")
+	if err := models.DeleteAccessTokenByID(tokenID, ctx.User.ID); err != nil {
 		ctx.Flash.Error("DeleteAccessTokenByID: " + err.Error())
 	} else {
+		audit.Record(ctx.User, ctx.RemoteAddr(), &models.AuditEvent{
+			Action:     models.AuditAccessTokenDelete,
+			TargetType: models.AuditTargetAccessToken,
+			TargetID:   tokenID,
+		})
 		ctx.Flash.Success(ctx.Tr("settings.delete_token_success"))
 	}
 
@@ -86,6 +134,7 @@ func loadApplicationsData(ctx *context.Context) {
 		return
 	}
 	ctx.Data["Tokens"] = tokens
+	ctx.Data["AccessTokenScopes"] = models.AllAccessTokenScopes
 	ctx.Data["EnableOAuth2"] = setting.OAuth2.Enable
 	if setting.OAuth2.Enable {
 		ctx.Data["Applications"], err = models.GetOAuth2ApplicationsByUserID(ctx.User.ID)
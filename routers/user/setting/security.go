@@ -62,13 +62,11 @@ func loadSecurityData(ctx *context.Context) {
 		}
 	}
 	ctx.Data["TwofaEnrolled"] = enrolled
-	if enrolled {
-		ctx.Data["U2FRegistrations"], err = models.GetU2FRegistrationsByUID(ctx.User.ID)
-		if err != nil {
-			ctx.ServerError("GetU2FRegistrationsByUID", err)
-			return
-		}
-		ctx.Data["RequireU2F"] = true
+
+	ctx.Data["WebAuthnCredentials"], err = models.GetWebAuthnCredentialsByUID(ctx.User.ID)
+	if err != nil {
+		ctx.ServerError("GetWebAuthnCredentialsByUID", err)
+		return
 	}
 
 	tokens, err := models.ListAccessTokens(ctx.User.ID, models.ListOptions{})
@@ -0,0 +1,145 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package setting
+
+import (
+	"errors"
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
+	"code.gitea.io/gitea/modules/auth"
+	"code.gitea.io/gitea/modules/auth/webauthn"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// WebAuthnRegister initializes the WebAuthn registration procedure
+func WebAuthnRegister(ctx *context.Context, form auth.WebAuthnRegistrationForm) {
+	if ctx.HasError() {
+		ctx.Error(http.StatusConflict)
+		return
+	}
+
+	// Security keys used as second factor require two-factor authentication,
+	// passwordless credentials can be used on their own
+	if !form.Passwordless {
+		if _, err := models.GetTwoFactorByUID(ctx.User.ID); err != nil {
+			if models.IsErrTwoFactorNotEnrolled(err) {
+				ctx.Error(http.StatusForbidden, "Two-factor authentication is not enrolled")
+				return
+			}
+			ctx.ServerError("GetTwoFactorByUID", err)
+			return
+		}
+	}
+
+	exist, err := models.ExistWebAuthnCredentialName(ctx.User.ID, form.Name)
+	if err != nil {
+		ctx.ServerError("ExistWebAuthnCredentialName", err)
+		return
+	} else if exist {
+		ctx.Error(http.StatusConflict, "Name already taken")
+		return
+	}
+
+	creds, err := models.GetWebAuthnCredentialsByUID(ctx.User.ID)
+	if err != nil {
+		ctx.ServerError("GetWebAuthnCredentialsByUID", err)
+		return
+	}
+	exclude := make([][]byte, 0, len(creds))
+	for _, cred := range creds {
+		id, err := webauthn.DecodeString(cred.CredentialID)
+		if err != nil {
+			ctx.ServerError("DecodeString", err)
+			return
+		}
+		exclude = append(exclude, id)
+	}
+
+	opts, session, err := webauthn.BeginRegistration(ctx.User.ID, ctx.User.Name, ctx.User.DisplayName(), exclude, form.Passwordless)
+	if err != nil {
+		ctx.ServerError("BeginRegistration", err)
+		return
+	}
+	if err := ctx.Session.Set("webauthnRegistration", session); err != nil {
+		ctx.ServerError("Unable to set session key for webauthnRegistration", err)
+		return
+	}
+	if err := ctx.Session.Set("webauthnName", form.Name); err != nil {
+		ctx.ServerError("Unable to set session key for webauthnName", err)
+		return
+	}
+	// Here we're just going to try to release the session early
+	if err := ctx.Session.Release(); err != nil {
+		// we'll tolerate errors here as they *should* get saved elsewhere
+		log.Error("Unable to save changes to the session: %v", err)
+	}
+	ctx.JSON(http.StatusOK, opts)
+}
+
+// WebAuthnRegisterPost receives the response of the authenticator
+func WebAuthnRegisterPost(ctx *context.Context, response webauthn.CredentialResponse) {
+	sessionData, ok := ctx.Session.Get("webauthnRegistration").(*webauthn.SessionData)
+	name, nameOk := ctx.Session.Get("webauthnName").(string)
+	if !ok || !nameOk || sessionData.UserID != ctx.User.ID {
+		ctx.ServerError("WebAuthnRegisterPost", errors.New("not in WebAuthn session"))
+		return
+	}
+	_ = ctx.Session.Delete("webauthnRegistration")
+	_ = ctx.Session.Delete("webauthnName")
+
+	cred, err := webauthn.FinishRegistration(sessionData, &response)
+	if err != nil {
+		log.Info("Failed WebAuthn registration for %s from %s: %v", ctx.User.Name, ctx.RemoteAddr(), err)
+		ctx.Error(http.StatusBadRequest, err.Error())
+		return
+	}
+	if _, err := models.CreateWebAuthnCredential(ctx.User, name, sessionData.UserVerification, cred); err != nil {
+		if models.IsErrWebAuthnCredentialAlreadyExist(err) {
+			ctx.Error(http.StatusConflict, err.Error())
+			return
+		}
+		ctx.ServerError("CreateWebAuthnCredential", err)
+		return
+	}
+
+	audit.Record(ctx.User, ctx.RemoteAddr(), &models.AuditEvent{
+		Action:     models.AuditWebAuthnRegister,
+		TargetType: models.AuditTargetUser,
+		TargetID:   ctx.User.ID,
+		TargetName: ctx.User.Name,
+		Details:    name,
+	})
+
+	ctx.Status(http.StatusCreated)
+}
+
+// WebAuthnDelete deletes a WebAuthn credential by id
+func WebAuthnDelete(ctx *context.Context, form auth.WebAuthnDeleteForm) {
+	cred, err := models.DeleteWebAuthnCredential(form.ID, ctx.User.ID)
+	if err != nil {
+		if models.IsErrWebAuthnCredentialNotExist(err) {
+			ctx.Status(http.StatusOK)
+			return
+		}
+		ctx.ServerError("DeleteWebAuthnCredential", err)
+		return
+	}
+
+	audit.Record(ctx.User, ctx.RemoteAddr(), &models.AuditEvent{
+		Action:     models.AuditWebAuthnRemove,
+		TargetType: models.AuditTargetUser,
+		TargetID:   ctx.User.ID,
+		TargetName: ctx.User.Name,
+		Details:    cred.Name,
+	})
+
+	ctx.JSON(http.StatusOK, map[string]interface{}{
+		"redirect": setting.AppSubURL + "/user/settings/security",
+	})
+}
@@ -0,0 +1,175 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/actions"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification"
+	"code.gitea.io/gitea/modules/notification/base"
+	"code.gitea.io/gitea/modules/repository"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// Init registers the notifier which creates the runs of the workflows
+func Init() {
+	if !setting.Actions.Enabled {
+		return
+	}
+	notification.RegisterNotifier(&actionsNotifier{})
+}
+
+type actionsNotifier struct {
+	base.NullNotifier
+}
+
+var (
+	_ base.Notifier = &actionsNotifier{}
+)
+
+func (n *actionsNotifier) NotifyPushCommits(pusher *models.User, repo *models.Repository, refName, oldCommitID, newCommitID string, commits *repository.PushCommits) {
+	if newCommitID == git.EmptySHA {
+		return
+	}
+	if err := createRuns(repo, repo, pusher, actions.EventPush, refName, refName, newCommitID, 0); err != nil {
+		log.Error("Unable to create the action runs of the push to %s in %s: %v", refName, repo.FullName(), err)
+	}
+}
+
+func (n *actionsNotifier) NotifyNewPullRequest(pr *models.PullRequest) {
+	if err := pr.LoadIssue(); err != nil {
+		log.Error("LoadIssue: %v", err)
+		return
+	}
+	if err := pr.Issue.LoadPoster(); err != nil {
+		log.Error("LoadPoster: %v", err)
+		return
+	}
+	createPullRequestRuns(pr.Issue.Poster, pr)
+}
+
+func (n *actionsNotifier) NotifyPullRequestSynchronized(doer *models.User, pr *models.PullRequest) {
+	createPullRequestRuns(doer, pr)
+}
+
+func createPullRequestRuns(doer *models.User, pr *models.PullRequest) {
+	if err := pr.LoadBaseRepo(); err != nil {
+		log.Error("LoadBaseRepo: %v", err)
+		return
+	}
+	if err := pr.LoadHeadRepo(); err != nil {
+		log.Error("LoadHeadRepo: %v", err)
+		return
+	}
+	if pr.HeadRepo == nil {
+		return
+	}
+
+	headGitRepo, err := git.OpenRepository(pr.HeadRepo.RepoPath())
+	if err != nil {
+		log.Error("OpenRepository: %v", err)
+		return
+	}
+	defer headGitRepo.Close()
+	sha, err := headGitRepo.GetRefCommitID(pr.GetGitHeadRefName())
+	if err != nil {
+		log.Error("GetRefCommitID: %v", err)
+		return
+	}
+
+	// the workflows of the head commit are run against the base repository,
+	// which is where branch protection looks for the statuses
+	if err := createRuns(pr.BaseRepo, pr.HeadRepo, doer, actions.EventPullRequest,
+		git.BranchPrefix+pr.BaseBranch, pr.GetGitRefName(), sha, pr.ID); err != nil {
+		log.Error("Unable to create the action runs of pull request %d: %v", pr.ID, err)
+	}
+}
+
+// createRuns creates a run for every workflow of the commit triggered by the event.
+// matchRef is the ref the workflow filters are matched against, the commit is read
+// from headRepo and the statuses are written to repo.
+func createRuns(repo, headRepo *models.Repository, doer *models.User, event, matchRef, ref, sha string, pullRequestID int64) error {
+	if repo.IsMirror || repo.IsArchived || !repo.UnitEnabled(models.UnitTypeActions) {
+		return nil
+	}
+
+	gitRepo, err := git.OpenRepository(headRepo.RepoPath())
+	if err != nil {
+		return fmt.Errorf("OpenRepository: %v", err)
+	}
+	defer gitRepo.Close()
+	commit, err := gitRepo.GetCommit(sha)
+	if err != nil {
+		return fmt.Errorf("GetCommit: %v", err)
+	}
+	workflows, err := actions.ListWorkflows(commit)
+	if err != nil {
+		return fmt.Errorf("ListWorkflows: %v", err)
+	}
+
+	for name, content := range workflows {
+		w, err := actions.Parse(content)
+		if err != nil {
+			log.Warn("Invalid workflow %s in commit %s of %s: %v", name, sha, headRepo.FullName(), err)
+			continue
+		}
+		if !w.Match(event, matchRef) {
+			continue
+		}
+
+		run := &models.ActionRun{
+			RepoID:        repo.ID,
+			Repo:          repo,
+			WorkflowID:    name,
+			Title:         w.Name,
+			TriggerUserID: doer.ID,
+			TriggerUser:   doer,
+			Event:         event,
+			Ref:           ref,
+			CommitSHA:     sha,
+			PullRequestID: pullRequestID,
+		}
+		if run.Title == "" {
+			run.Title = name
+		}
+
+		jobs := make([]*models.ActionRunJob, 0, len(w.Jobs))
+		for _, id := range w.JobIDs() {
+			job := w.Jobs[id]
+			payload, err := json.Marshal(job)
+			if err != nil {
+				return err
+			}
+			steps := make([]*models.ActionRunStep, 0, len(job.Steps))
+			for i, step := range job.Steps {
+				steps = append(steps, &models.ActionRunStep{Index: i, Name: step.Name})
+			}
+			jobs = append(jobs, &models.ActionRunJob{
+				JobID:   id,
+				Name:    job.Name,
+				RunsOn:  job.RunsOn,
+				Needs:   job.Needs,
+				Payload: string(payload),
+				Steps:   steps,
+			})
+		}
+
+		if err := models.InsertActionRun(run, jobs); err != nil {
+			return fmt.Errorf("InsertActionRun: %v", err)
+		}
+		for _, job := range jobs {
+			job.Run = run
+		}
+		createCommitStatuses(jobs)
+	}
+
+	notifyJobsQueued()
+	return nil
+}
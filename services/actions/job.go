@@ -0,0 +1,229 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package actions
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
+	"code.gitea.io/gitea/services/automerge"
+)
+
+// MaxLogChunkSize is the size of the largest log chunk a runner can send at once
+const MaxLogChunkSize = 1024 * 1024
+
+var (
+	queuedLock sync.Mutex
+	queued     = make(chan struct{})
+)
+
+// notifyJobsQueued wakes up the runners waiting for jobs
+func notifyJobsQueued() {
+	queuedLock.Lock()
+	close(queued)
+	queued = make(chan struct{})
+	queuedLock.Unlock()
+}
+
+func jobsQueued() <-chan struct{} {
+	queuedLock.Lock()
+	defer queuedLock.Unlock()
+	return queued
+}
+
+// FetchJob assigns a waiting job to the runner. If there is none, it waits
+// until a job is queued or the long poll timeout expires and returns nil.
+func FetchJob(ctx context.Context, runner *models.ActionRunner) (*models.ActionRunJob, error) {
+	timeout := time.NewTimer(setting.Actions.LongPollTimeout)
+	defer timeout.Stop()
+
+	for {
+		// wait for the jobs queued after the query
+		ch := jobsQueued()
+		job, err := models.AssignActionRunJob(runner)
+		if err != nil {
+			return nil, err
+		}
+		if job != nil {
+			if err := job.LoadRun(); err != nil {
+				return nil, err
+			}
+			createCommitStatuses([]*models.ActionRunJob{job})
+			return job, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case <-timeout.C:
+			return nil, nil
+		case <-ch:
+		}
+	}
+}
+
+// UpdateJobStatus updates the status of the job and writes the commit statuses
+// of all the jobs of the run whose status changed
+func UpdateJobStatus(job *models.ActionRunJob, status models.ActionStatus) error {
+	changed, err := models.UpdateActionRunJobStatus(job, status)
+	if err != nil {
+		return err
+	}
+	if err := loadRuns(changed); err != nil {
+		return err
+	}
+	createCommitStatuses(changed)
+	notifyJobsQueued()
+	return nil
+}
+
+// CancelRun cancels the jobs of the run which are not done yet
+func CancelRun(run *models.ActionRun) error {
+	cancelled, err := models.CancelActionRun(run)
+	if err != nil {
+		return err
+	}
+	for _, job := range cancelled {
+		job.Run = run
+	}
+	createCommitStatuses(cancelled)
+	return nil
+}
+
+// DeleteRunner deletes the runner and fails the jobs it is running
+func DeleteRunner(id int64) error {
+	changed, err := models.DeleteActionRunner(id)
+	if err != nil {
+		return err
+	}
+	if err := loadRuns(changed); err != nil {
+		return err
+	}
+	createCommitStatuses(changed)
+	return nil
+}
+
+// StopZombieJobs fails the running jobs whose runner did not report for too long
+func StopZombieJobs(ctx context.Context) error {
+	jobs, err := models.GetZombieActionRunJobs(setting.Actions.ZombieJobTimeout)
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("Aborted due to shutdown")
+		default:
+		}
+
+		log.Trace("Failing zombie job %d of run %d", job.ID, job.RunID)
+		if err := UpdateJobStatus(job, models.ActionStatusFailure); err != nil {
+			log.Error("Unable to fail zombie job %d: %v", job.ID, err)
+		}
+	}
+	return nil
+}
+
+// AppendLog stores the content as the next chunk of the log of the job
+func AppendLog(job *models.ActionRunJob, r io.Reader) error {
+	path := job.LogPath(job.LogChunks)
+	if _, err := storage.ActionsLog.Save(path, io.LimitReader(r, MaxLogChunkSize), -1); err != nil {
+		return err
+	}
+	ok, err := models.UpdateActionRunJobLogChunks(job)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("log of job %d was written concurrently", job.ID)
+	}
+	return nil
+}
+
+// ReadLog writes the complete log of the job to w
+func ReadLog(job *models.ActionRunJob, w io.Writer) error {
+	for i := 0; i < job.LogChunks; i++ {
+		if err := copyLogChunk(job.LogPath(i), w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyLogChunk(path string, w io.Writer) error {
+	f, err := storage.ActionsLog.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+func loadRuns(jobs []*models.ActionRunJob) error {
+	runs := make(map[int64]*models.ActionRun)
+	for _, job := range jobs {
+		if run, ok := runs[job.RunID]; ok {
+			job.Run = run
+			continue
+		}
+		if err := job.LoadRun(); err != nil {
+			return err
+		}
+		runs[job.RunID] = job.Run
+	}
+	return nil
+}
+
+var statusDescriptions = map[models.ActionStatus]string{
+	models.ActionStatusWaiting:   "Waiting for a runner",
+	models.ActionStatusBlocked:   "Waiting for the jobs it needs",
+	models.ActionStatusRunning:   "Running",
+	models.ActionStatusSuccess:   "Successful",
+	models.ActionStatusFailure:   "Failed",
+	models.ActionStatusCancelled: "Cancelled",
+	models.ActionStatusSkipped:   "Skipped",
+}
+
+// createCommitStatuses reports the status of the jobs as commit statuses, the
+// runs of the jobs must be loaded
+func createCommitStatuses(jobs []*models.ActionRunJob) {
+	for _, job := range jobs {
+		if err := job.Run.LoadAttributes(); err != nil {
+			log.Error("LoadAttributes: %v", err)
+			continue
+		}
+		run := job.Run
+
+		description := statusDescriptions[job.Status]
+		if job.Status.IsDone() && job.Status != models.ActionStatusSkipped {
+			description = fmt.Sprintf("%s in %s", description, job.Duration())
+		}
+		if err := models.NewCommitStatus(models.NewCommitStatusOptions{
+			Repo:    run.Repo,
+			Creator: run.TriggerUser,
+			SHA:     run.CommitSHA,
+			CommitStatus: &models.CommitStatus{
+				State:       job.Status.CommitStatusState(),
+				TargetURL:   run.HTMLURL(),
+				Description: description,
+				Context:     fmt.Sprintf("%s / %s (%s)", run.Title, job.Name, run.Event),
+			},
+		}); err != nil {
+			log.Error("Unable to create the commit status of job %d: %v", job.ID, err)
+			continue
+		}
+		if err := automerge.StartPRCheckAndAutoMergeBySHA(run.CommitSHA, run.Repo); err != nil {
+			log.Error("StartPRCheckAndAutoMergeBySHA[%s]: %v", run.CommitSHA, err)
+		}
+	}
+}
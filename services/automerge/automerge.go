@@ -0,0 +1,329 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package automerge
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification"
+	"code.gitea.io/gitea/modules/queue"
+	"code.gitea.io/gitea/modules/sync"
+	pull_service "code.gitea.io/gitea/services/pull"
+)
+
+// prAutoMergeQueue represents a queue to handle the pull requests scheduled to be merged
+var prAutoMergeQueue queue.UniqueQueue
+
+// baseBranchPool serializes the merges into the same base branch, so every pull request
+// is tested on top of the merges landed before it. The auto merge lock of the base branch
+// does the same across the Gitea instances sharing the database.
+var baseBranchPool = sync.NewExclusivePool()
+
+const (
+	// autoMergeLockDuration is the time after which the auto merge lock of a base branch
+	// which was not released is taken over
+	autoMergeLockDuration = 30 * time.Minute
+	// autoMergeRetryDelay is the delay before a pull request whose base branch is locked
+	// by another instance is queued again
+	autoMergeRetryDelay = 10 * time.Second
+)
+
+// Init runs the task queue to merge the scheduled pull requests
+func Init() error {
+	prAutoMergeQueue = queue.CreateUniqueQueue("pr_auto_merge", handle, "").(queue.UniqueQueue)
+
+	if prAutoMergeQueue == nil {
+		return fmt.Errorf("Unable to create pr_auto_merge Queue")
+	}
+
+	go graceful.GetManager().RunWithShutdownFns(prAutoMergeQueue.Run)
+	notification.RegisterNotifier(NewNotifier())
+	return nil
+}
+
+// AddToQueue adds a pull request to the queue of pull requests to be checked and merged
+// if they are scheduled to be merged
+func AddToQueue(pr *models.PullRequest) {
+	go func() {
+		if err := prAutoMergeQueue.Push(strconv.FormatInt(pr.ID, 10)); err != nil && err != queue.ErrAlreadyInQueue {
+			log.Error("Error adding prID %d to the auto merge queue: %v", pr.ID, err)
+		}
+	}()
+}
+
+// ScheduleAutoMerge schedules a pull request to be merged by doer once all its checks
+// succeed. If the pull request is ready to be merged already nothing is scheduled and
+// false is returned, so the caller can merge it right away.
+func ScheduleAutoMerge(doer *models.User, pr *models.PullRequest, style models.MergeStyle, message string) (bool, error) {
+	if err := pr.LoadBaseRepo(); err != nil {
+		return false, err
+	}
+	prUnit, err := pr.BaseRepo.GetUnit(models.UnitTypePullRequests)
+	if err != nil {
+		return false, err
+	}
+	if !prUnit.PullRequestsConfig().IsMergeStyleAllowed(style) {
+		return false, models.ErrInvalidMergeStyle{ID: pr.BaseRepo.ID, Style: style}
+	}
+
+	if err := pull_service.CheckPRReadyToMerge(pr); err == nil {
+		return false, nil
+	} else if !models.IsErrNotAllowedToMerge(err) {
+		return false, err
+	}
+
+	if err := models.ScheduleAutoMerge(doer, pr, style, message); err != nil {
+		return false, err
+	}
+
+	// The checks may have succeeded in the meantime
+	AddToQueue(pr)
+	return true, nil
+}
+
+// StartPRCheckAndAutoMergeBySHA queues the scheduled pull requests into repo whose head
+// commit is sha, e.g. because a commit status was reported for it
+func StartPRCheckAndAutoMergeBySHA(sha string, repo *models.Repository) error {
+	scheduledMerges, err := models.GetScheduledMerges(repo.ID, "")
+	if err != nil {
+		return err
+	}
+
+	for _, scheduledMerge := range scheduledMerges {
+		pr, err := models.GetPullRequestByID(scheduledMerge.PullID)
+		if err != nil {
+			return err
+		}
+		if err = pr.LoadHeadRepo(); err != nil {
+			return err
+		}
+		headCommitID, err := git.GetFullCommitID(pr.HeadRepo.RepoPath(), pr.GetGitHeadRefName())
+		if err != nil {
+			log.Error("GetFullCommitID[%s:%s]: %v", pr.HeadRepo.FullName(), pr.HeadBranch, err)
+			continue
+		}
+		if headCommitID == sha {
+			AddToQueue(pr)
+		}
+	}
+	return nil
+}
+
+// handle passed PR IDs and merge the PRs that are ready
+func handle(data ...queue.Data) {
+	for _, datum := range data {
+		id, err := strconv.ParseInt(datum.(string), 10, 64)
+		if err != nil {
+			log.Error("Invalid PR ID %v in the auto merge queue: %v", datum, err)
+			continue
+		}
+
+		pr, err := models.GetPullRequestByID(id)
+		if err != nil {
+			log.Error("GetPullRequestByID[%d]: %v", id, err)
+			continue
+		}
+
+		identity := fmt.Sprintf("%d:%s", pr.BaseRepoID, pr.BaseBranch)
+		baseBranchPool.CheckIn(identity)
+		handleLockedPull(pr)
+		baseBranchPool.CheckOut(identity)
+	}
+}
+
+// handleLockedPull handles the pull request while it holds the auto merge lock of its base
+// branch, the pull request is queued again later if another instance holds the lock
+func handleLockedPull(pr *models.PullRequest) {
+	token, err := models.TryLockAutoMerge(pr.BaseRepoID, pr.BaseBranch, autoMergeLockDuration)
+	if err != nil {
+		log.Error("TryLockAutoMerge[%d:%s]: %v", pr.BaseRepoID, pr.BaseBranch, err)
+		return
+	}
+	if token == "" {
+		id := pr.ID
+		time.AfterFunc(autoMergeRetryDelay, func() {
+			AddToQueue(&models.PullRequest{ID: id})
+		})
+		return
+	}
+
+	handlePull(pr.ID)
+
+	if err := models.UnlockAutoMerge(pr.BaseRepoID, pr.BaseBranch, token); err != nil {
+		log.Error("UnlockAutoMerge[%d:%s]: %v", pr.BaseRepoID, pr.BaseBranch, err)
+	}
+}
+
+// handlePull merges the pull request if it is scheduled and ready to be merged
+func handlePull(pullID int64) {
+	// Reload the pull request as a merge into the same base branch may have landed
+	// while we were waiting for our turn
+	pr, err := models.GetPullRequestByID(pullID)
+	if err != nil {
+		log.Error("GetPullRequestByID[%d]: %v", pullID, err)
+		return
+	}
+
+	exist, scheduledMerge, err := models.GetScheduledMergeByPullID(pr.ID)
+	if models.IsErrUserNotExist(err) {
+		// The user who scheduled the merge has been deleted
+		if err = models.RemoveScheduledAutoMerge(nil, pr); err != nil {
+			log.Error("RemoveScheduledAutoMerge[%d]: %v", pr.ID, err)
+		}
+		return
+	} else if err != nil {
+		log.Error("GetScheduledMergeByPullID[%d]: %v", pr.ID, err)
+		return
+	} else if !exist {
+		return
+	}
+
+	if err = pr.LoadIssue(); err != nil {
+		log.Error("LoadIssue[%d]: %v", pr.ID, err)
+		return
+	}
+	if pr.HasMerged || pr.Issue.IsClosed {
+		if err = models.RemoveScheduledAutoMerge(nil, pr); err != nil {
+			log.Error("RemoveScheduledAutoMerge[%d]: %v", pr.ID, err)
+		}
+		return
+	}
+	if err = pr.LoadBaseRepo(); err != nil {
+		log.Error("LoadBaseRepo[%d]: %v", pr.ID, err)
+		return
+	}
+
+	perm, err := models.GetUserRepoPermission(pr.BaseRepo, scheduledMerge.Doer)
+	if err != nil {
+		log.Error("GetUserRepoPermission[%d]: %v", pr.ID, err)
+		return
+	}
+	if allowed, err := pull_service.IsUserAllowedToMerge(pr, perm, scheduledMerge.Doer); err != nil {
+		log.Error("IsUserAllowedToMerge[%d]: %v", pr.ID, err)
+		return
+	} else if !allowed {
+		log.Warn("%s is no longer allowed to merge PR #%d in %s, cancelling the scheduled merge", scheduledMerge.Doer.Name, pr.Index, pr.BaseRepo.FullName())
+		if err = models.RemoveScheduledAutoMerge(nil, pr); err != nil {
+			log.Error("RemoveScheduledAutoMerge[%d]: %v", pr.ID, err)
+		}
+		return
+	}
+
+	if pr.IsWorkInProgress() {
+		log.Trace("PR #%d in %s is a work in progress, not merging it yet", pr.Index, pr.BaseRepo.FullName())
+		return
+	}
+	if noDeps, err := models.IssueNoDependenciesLeft(pr.Issue); err != nil {
+		log.Error("IssueNoDependenciesLeft[%d]: %v", pr.ID, err)
+		return
+	} else if !noDeps {
+		log.Trace("PR #%d in %s has open dependencies, not merging it yet", pr.Index, pr.BaseRepo.FullName())
+		return
+	}
+
+	// Re-test the pull request on top of the current base branch, which contains the
+	// merges of the pull requests queued before it
+	if err = pull_service.TestPatch(pr); err != nil {
+		log.Error("TestPatch[%d]: %v", pr.ID, err)
+		return
+	}
+	if pr.Status == models.PullRequestStatusChecking {
+		pr.Status = models.PullRequestStatusMergeable
+	}
+	if err = pr.UpdateColsIfNotMerged("merge_base", "status", "conflicted_files"); err != nil {
+		log.Error("UpdateColsIfNotMerged[%d]: %v", pr.ID, err)
+		return
+	}
+	if !pr.CanAutoMerge() {
+		log.Trace("PR #%d in %s can not be merged automatically, not merging it yet", pr.Index, pr.BaseRepo.FullName())
+		return
+	}
+
+	// The required status checks were reported for the head commit and do not cover the
+	// merges landed into the base branch since. The base branch is merged into the head
+	// branch first and the pull request is queued again by the status checks of the
+	// combined commit.
+	if err = pr.LoadProtectedBranch(); err != nil {
+		log.Error("LoadProtectedBranch[%d]: %v", pr.ID, err)
+		return
+	}
+	if pr.ProtectedBranch != nil && pr.ProtectedBranch.EnableStatusCheck {
+		if outdated, err := updateOutdatedPull(pr, scheduledMerge.Doer); err != nil {
+			log.Error("updateOutdatedPull[%d]: %v", pr.ID, err)
+			return
+		} else if outdated {
+			return
+		}
+	}
+
+	if err = pull_service.CheckPRReadyToMerge(pr); err != nil {
+		if !models.IsErrNotAllowedToMerge(err) {
+			log.Error("CheckPRReadyToMerge[%d]: %v", pr.ID, err)
+		}
+		return
+	}
+
+	baseGitRepo, err := git.OpenRepository(pr.BaseRepo.RepoPath())
+	if err != nil {
+		log.Error("OpenRepository[%s]: %v", pr.BaseRepo.RepoPath(), err)
+		return
+	}
+	defer baseGitRepo.Close()
+
+	if err = pull_service.Merge(pr, scheduledMerge.Doer, baseGitRepo, scheduledMerge.MergeStyle, scheduledMerge.Message); err != nil {
+		log.Error("Merge[%d]: %v", pr.ID, err)
+		return
+	}
+
+	if err = models.RemoveScheduledAutoMerge(nil, pr); err != nil {
+		log.Error("RemoveScheduledAutoMerge[%d]: %v", pr.ID, err)
+	}
+
+	// The base branch has moved, so the remaining scheduled pull requests must be tested
+	// against it again
+	scheduledMerges, err := models.GetScheduledMerges(pr.BaseRepoID, pr.BaseBranch)
+	if err != nil {
+		log.Error("GetScheduledMerges[%d:%s]: %v", pr.BaseRepoID, pr.BaseBranch, err)
+		return
+	}
+	for _, next := range scheduledMerges {
+		AddToQueue(&models.PullRequest{ID: next.PullID})
+	}
+}
+
+// updateOutdatedPull merges the base branch into the head branch of the pull request if the
+// head branch is behind, it returns whether the head branch was behind. If doer can not
+// update the head branch the pull request has to be updated by its author.
+func updateOutdatedPull(pr *models.PullRequest, doer *models.User) (bool, error) {
+	divergence, err := pull_service.GetDiverging(pr)
+	if err != nil {
+		return false, fmt.Errorf("GetDiverging: %v", err)
+	}
+	if err = pr.UpdateCommitDivergence(divergence.Ahead, divergence.Behind); err != nil {
+		return false, fmt.Errorf("UpdateCommitDivergence: %v", err)
+	}
+	if divergence.Behind == 0 {
+		return false, nil
+	}
+
+	if allowed, err := pull_service.IsUserAllowedToUpdate(pr, doer); err != nil {
+		return false, fmt.Errorf("IsUserAllowedToUpdate: %v", err)
+	} else if !allowed {
+		log.Trace("PR #%d in %s is behind its base branch and %s can not update it, not merging it yet", pr.Index, pr.BaseRepo.FullName(), doer.Name)
+		return true, nil
+	}
+
+	message := fmt.Sprintf("Merge branch '%s' into %s", pr.BaseBranch, pr.HeadBranch)
+	if err = pull_service.Update(pr, doer, message); err != nil {
+		return false, fmt.Errorf("Update: %v", err)
+	}
+	return true, nil
+}
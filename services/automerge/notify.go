@@ -0,0 +1,75 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package automerge
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification/base"
+)
+
+type autoMergeNotifier struct {
+	base.NullNotifier
+}
+
+var (
+	_ base.Notifier = &autoMergeNotifier{}
+)
+
+// NewNotifier create a new autoMergeNotifier notifier
+func NewNotifier() base.Notifier {
+	return &autoMergeNotifier{}
+}
+
+// NotifyPullRequestReview checks a scheduled pull request again once it is approved
+func (*autoMergeNotifier) NotifyPullRequestReview(pr *models.PullRequest, review *models.Review, comment *models.Comment) {
+	if review.Type == models.ReviewTypeApprove {
+		AddToQueue(pr)
+	}
+}
+
+// NotifyIssueChangeStatus cancels the scheduled merge of a pull request when it is closed
+func (*autoMergeNotifier) NotifyIssueChangeStatus(doer *models.User, issue *models.Issue, actionComment *models.Comment, isClosed bool) {
+	if !issue.IsPull || !isClosed {
+		return
+	}
+	if err := issue.LoadPullRequest(); err != nil {
+		log.Error("LoadPullRequest[%d]: %v", issue.ID, err)
+		return
+	}
+	if err := models.RemoveScheduledAutoMerge(nil, issue.PullRequest); err != nil {
+		log.Error("RemoveScheduledAutoMerge[%d]: %v", issue.PullRequest.ID, err)
+	}
+}
+
+// NotifyPullRequestSynchronized cancels the scheduled merge of a pull request when commits
+// are pushed to it by someone who could not merge them, as they have not been reviewed by
+// the user who scheduled the merge
+func (*autoMergeNotifier) NotifyPullRequestSynchronized(doer *models.User, pr *models.PullRequest) {
+	exist, scheduledMerge, err := models.GetScheduledMergeByPullID(pr.ID)
+	if err != nil {
+		log.Error("GetScheduledMergeByPullID[%d]: %v", pr.ID, err)
+		return
+	} else if !exist || scheduledMerge.DoerID == doer.ID {
+		return
+	}
+
+	if err := pr.LoadBaseRepo(); err != nil {
+		log.Error("LoadBaseRepo[%d]: %v", pr.ID, err)
+		return
+	}
+	perm, err := models.GetUserRepoPermission(pr.BaseRepo, doer)
+	if err != nil {
+		log.Error("GetUserRepoPermission[%d]: %v", pr.ID, err)
+		return
+	}
+	if perm.CanWrite(models.UnitTypeCode) {
+		return
+	}
+
+	if err := models.RemoveScheduledAutoMerge(doer, pr); err != nil {
+		log.Error("RemoveScheduledAutoMerge[%d]: %v", pr.ID, err)
+	}
+}
@@ -44,6 +44,10 @@ func LinkAccountToUser(user *models.User, gothUser goth.User) error {
 		return err
 	}
 
+	if err := models.SyncOAuth2GroupTeams(loginSource, user, gothUser); err != nil {
+		return err
+	}
+
 	externalID := externalLoginUser.ExternalID
 
 	var tp structs.GitServiceType
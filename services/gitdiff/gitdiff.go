@@ -344,6 +344,7 @@ type DiffFile struct {
 	IsSubmodule        bool
 	Sections           []*DiffSection
 	IsIncomplete       bool
+	Comments           []*models.Comment // comments on the whole file
 }
 
 // GetType returns type of diff file.
@@ -413,12 +414,14 @@ func (diff *Diff) LoadComments(issue *models.Issue, currentUser *models.User) er
 	}
 	for _, file := range diff.Files {
 		if lineCommits, ok := allComments[file.Name]; ok {
+			// Comments on the whole file are not attached to any line
+			file.Comments = lineCommits[0]
 			for _, section := range file.Sections {
 				for _, line := range section.Lines {
-					if comments, ok := lineCommits[int64(line.LeftIdx*-1)]; ok {
+					if comments, ok := lineCommits[int64(line.LeftIdx*-1)]; ok && line.LeftIdx > 0 {
 						line.Comments = append(line.Comments, comments...)
 					}
-					if comments, ok := lineCommits[int64(line.RightIdx)]; ok {
+					if comments, ok := lineCommits[int64(line.RightIdx)]; ok && line.RightIdx > 0 {
 						line.Comments = append(line.Comments, comments...)
 					}
 					sort.SliceStable(line.Comments, func(i, j int) bool {
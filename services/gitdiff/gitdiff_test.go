@@ -134,6 +134,34 @@ func TestDiff_LoadComments(t *testing.T) {
 	assert.Len(t, diff.Files[0].Sections[0].Lines[0].Comments, 2)
 }
 
+func TestDiff_LoadFileComments(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+
+	issue := models.AssertExistsAndLoadBean(t, &models.Issue{ID: 2}).(*models.Issue)
+	user := models.AssertExistsAndLoadBean(t, &models.User{ID: 1}).(*models.User)
+	assert.NoError(t, issue.LoadRepo())
+	comment, err := models.CreateComment(&models.CreateCommentOptions{
+		Type:          models.CommentTypeCode,
+		Doer:          user,
+		Repo:          issue.Repo,
+		Issue:         issue,
+		TreePath:      "README.md",
+		IsFileComment: true,
+		Content:       "on the whole file",
+	})
+	assert.NoError(t, err)
+
+	diff := setupDefaultDiff()
+	addedLine := &DiffLine{RightIdx: 5, Type: DiffLineAdd}
+	diff.Files[0].Sections[0].Lines = append(diff.Files[0].Sections[0].Lines, addedLine)
+	assert.NoError(t, diff.LoadComments(issue, user))
+	if assert.Len(t, diff.Files[0].Comments, 1) {
+		assert.EqualValues(t, comment.ID, diff.Files[0].Comments[0].ID)
+	}
+	assert.Len(t, diff.Files[0].Sections[0].Lines[0].Comments, 2)
+	assert.Empty(t, addedLine.Comments)
+}
+
 func TestDiffLine_CanComment(t *testing.T) {
 	assert.False(t, (&DiffLine{Type: DiffLineSection}).CanComment())
 	assert.False(t, (&DiffLine{Type: DiffLineAdd, Comments: []*models.Comment{{Content: "bla"}}}).CanComment())
@@ -0,0 +1,144 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package incoming
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"regexp"
+	"strings"
+
+	"github.com/jaytaylor/html2text"
+	"golang.org/x/net/html/charset"
+)
+
+var (
+	// "On Mon, 1 Jan 2020 at 10:00, User <user@example.com> wrote:", mail clients may wrap it
+	quoteHeaderRegex = regexp.MustCompile(`(?m)^On\s(?:.+\n)?.*wrote:\s*$`)
+	// Outlook puts a separator line before the quoted mail
+	outlookSeparatorRegex = regexp.MustCompile(`(?m)^(?:-{5}\s*Original Message\s*-{5}|_{20,})\s*$`)
+)
+
+// getContentFromMail returns the text of the mail without quoted text and signature
+func getContentFromMail(msg *mail.Message) (string, error) {
+	text, err := getText(textproto.MIMEHeader(msg.Header), msg.Body)
+	if err != nil {
+		return "", err
+	}
+	return stripReply(text), nil
+}
+
+// getText returns the text content of a mail part, text/plain is preferred
+// over text/html in multipart/alternative parts
+func getText(header textproto.MIMEHeader, body io.Reader) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		// RFC 2045: the default is plain US-ASCII text
+		mediaType, params = "text/plain", map[string]string{}
+	}
+
+	// multipart.Reader decodes quoted-printable parts itself and removes the header
+	switch strings.ToLower(header.Get("Content-Transfer-Encoding")) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		var html string
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return "", fmt.Errorf("NextPart: %v", err)
+			}
+			if isAttachment(part.Header) {
+				continue
+			}
+
+			partMediaType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+			text, err := getText(part.Header, part)
+			if err != nil {
+				return "", err
+			}
+			if text == "" {
+				continue
+			}
+			if partMediaType == "text/html" {
+				html = text
+				continue
+			}
+			return text, nil
+		}
+		return html, nil
+	}
+
+	if mediaType != "text/plain" && mediaType != "text/html" {
+		return "", nil
+	}
+
+	r, err := charset.NewReaderLabel(params["charset"], body)
+	if err != nil {
+		// unknown charsets are read as UTF-8
+		r = body
+	}
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("ReadAll: %v", err)
+	}
+
+	if mediaType == "text/html" {
+		return html2text.FromString(string(content))
+	}
+	return string(content), nil
+}
+
+func isAttachment(header textproto.MIMEHeader) bool {
+	disposition, _, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	return disposition == "attachment"
+}
+
+// stripReply removes the signature and the quoted mail from the reply
+func stripReply(content string) string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	if loc := quoteHeaderRegex.FindStringIndex(content); loc != nil {
+		content = content[:loc[0]]
+	}
+	if loc := outlookSeparatorRegex.FindStringIndex(content); loc != nil {
+		content = content[:loc[0]]
+	}
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		// RFC 3676: the signature separator is "-- "
+		if line == "-- " || line == "--" {
+			lines = lines[:i]
+			break
+		}
+	}
+
+	// remove the quote at the end, inline quotes are kept as they give the reply its context
+	end := len(lines)
+	for end > 0 {
+		line := strings.TrimSpace(lines[end-1])
+		if line != "" && !strings.HasPrefix(line, ">") {
+			break
+		}
+		end--
+	}
+
+	return strings.TrimSpace(strings.Join(lines[:end], "\n"))
+}
@@ -0,0 +1,93 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package incoming
+
+import (
+	"context"
+	"fmt"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	comment_service "code.gitea.io/gitea/services/comments"
+	"code.gitea.io/gitea/services/mailer/token"
+)
+
+// handler handles the mails sent to the address of a token
+type handler interface {
+	Handle(ctx context.Context, content string, doer *models.User, data []byte) error
+}
+
+var handlers = map[token.HandlerType]handler{
+	token.ReplyHandlerType:       &replyHandler{},
+	token.UnsubscribeHandlerType: &unsubscribeHandler{},
+}
+
+// loadIssue loads the issue of the token data and checks the doer can still read it
+func loadIssue(doer *models.User, data []byte) (*models.Issue, *models.Permission, error) {
+	if !doer.IsActive || doer.ProhibitLogin {
+		return nil, nil, fmt.Errorf("user %d is not allowed to sign in", doer.ID)
+	}
+
+	issueID, err := token.DecodeID(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	issue, err := models.GetIssueByID(issueID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("GetIssueByID [%d]: %v", issueID, err)
+	}
+	if err := issue.LoadRepo(); err != nil {
+		return nil, nil, fmt.Errorf("LoadRepo [%d]: %v", issue.RepoID, err)
+	}
+
+	perm, err := models.GetUserRepoPermission(issue.Repo, doer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("GetUserRepoPermission: %v", err)
+	}
+	if !perm.CanReadIssuesOrPulls(issue.IsPull) {
+		return nil, nil, fmt.Errorf("user %d cannot read issue %d", doer.ID, issue.ID)
+	}
+	return issue, &perm, nil
+}
+
+// replyHandler posts the content of the mail as a comment
+type replyHandler struct{}
+
+// Handle implements handler
+func (h *replyHandler) Handle(ctx context.Context, content string, doer *models.User, data []byte) error {
+	issue, perm, err := loadIssue(doer, data)
+	if err != nil {
+		return err
+	}
+	if issue.Repo.IsArchived {
+		log.Trace("Incoming reply of user %d to issue %d of archived repository %d ignored", doer.ID, issue.ID, issue.RepoID)
+		return nil
+	}
+	if issue.IsLocked && !perm.CanWriteIssuesOrPulls(issue.IsPull) && !doer.IsAdmin {
+		log.Trace("Incoming reply of user %d to locked issue %d ignored", doer.ID, issue.ID)
+		return nil
+	}
+	if content == "" {
+		log.Trace("Incoming reply of user %d to issue %d is empty", doer.ID, issue.ID)
+		return nil
+	}
+
+	if _, err := comment_service.CreateIssueComment(doer, issue.Repo, issue, content, nil); err != nil {
+		return fmt.Errorf("CreateIssueComment: %v", err)
+	}
+	return nil
+}
+
+// unsubscribeHandler stops the notifications of the thread for the user
+type unsubscribeHandler struct{}
+
+// Handle implements handler
+func (h *unsubscribeHandler) Handle(ctx context.Context, content string, doer *models.User, data []byte) error {
+	issue, _, err := loadIssue(doer, data)
+	if err != nil {
+		return err
+	}
+	return models.CreateOrUpdateIssueWatch(doer.ID, issue.ID, false)
+}
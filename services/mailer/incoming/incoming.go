@@ -0,0 +1,133 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package incoming
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/services/mailer/token"
+)
+
+// source is a mailbox the incoming mails are read from
+type source interface {
+	// Process calls fn for every unhandled mail of the mailbox and marks the mail as handled
+	Process(ctx context.Context, fn func(io.Reader) error) error
+}
+
+// Init starts the polling of the incoming mails
+func Init() {
+	if !setting.IncomingEmail.Enabled {
+		return
+	}
+
+	var s source
+	switch setting.IncomingEmail.Type {
+	case "maildir":
+		s = &maildirSource{Path: setting.IncomingEmail.MaildirPath}
+	default:
+		s = &imapSource{}
+	}
+
+	go graceful.GetManager().RunWithShutdownContext(func(ctx context.Context) {
+		poll(ctx, s)
+	})
+}
+
+func poll(ctx context.Context, s source) {
+	for {
+		if err := s.Process(ctx, func(r io.Reader) error {
+			return processMail(ctx, r)
+		}); err != nil {
+			log.Error("Unable to process incoming mails: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(setting.IncomingEmail.PollInterval):
+		}
+	}
+}
+
+// processMail looks for a token in the recipients of the mail and passes the
+// mail to the handler of the token
+func processMail(ctx context.Context, r io.Reader) error {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return fmt.Errorf("ReadMessage: %v", err)
+	}
+
+	if isAutomaticReply(msg.Header) {
+		log.Trace("Incoming mail is an automatic reply: %s", msg.Header.Get("Message-ID"))
+		return nil
+	}
+
+	t := searchTokenInHeaders(msg.Header)
+	if t == "" {
+		log.Trace("Incoming mail has no token: %s", msg.Header.Get("Message-ID"))
+		return nil
+	}
+
+	ht, doer, data, err := token.ExtractToken(t)
+	if err != nil {
+		if err == token.ErrInvalidToken {
+			log.Trace("Incoming mail has an invalid token: %s", msg.Header.Get("Message-ID"))
+			return nil
+		}
+		return fmt.Errorf("ExtractToken: %v", err)
+	}
+
+	handler, ok := handlers[ht]
+	if !ok {
+		return fmt.Errorf("unsupported token handler type: %d", ht)
+	}
+
+	content, err := getContentFromMail(msg)
+	if err != nil {
+		return fmt.Errorf("getContentFromMail: %v", err)
+	}
+
+	return handler.Handle(ctx, content, doer, data)
+}
+
+// isAutomaticReply reports whether the mail was sent by an auto responder, see RFC 3834
+func isAutomaticReply(header mail.Header) bool {
+	if autoSubmitted := header.Get("Auto-Submitted"); autoSubmitted != "" && !strings.EqualFold(autoSubmitted, "no") {
+		return true
+	}
+	if header.Get("X-Autoreply") != "" || header.Get("X-Autorespond") != "" {
+		return true
+	}
+	return strings.EqualFold(header.Get("Precedence"), "auto_reply")
+}
+
+// searchTokenInHeaders returns the token of the first recipient matching the reply address
+func searchTokenInHeaders(header mail.Header) string {
+	pattern := regexp.QuoteMeta(setting.IncomingEmail.ReplyToAddress)
+	pattern = strings.Replace(pattern, regexp.QuoteMeta(setting.IncomingEmailTokenPlaceholder), "([a-zA-Z0-9]+)", 1)
+	re := regexp.MustCompile(`(?i)\A` + pattern + `\z`)
+
+	for _, key := range []string{"To", "Delivered-To", "X-Original-To", "Cc"} {
+		addresses, err := header.AddressList(key)
+		if err != nil {
+			continue
+		}
+		for _, address := range addresses {
+			if match := re.FindStringSubmatch(address.Address); match != nil {
+				return match[1]
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,128 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package incoming
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/services/mailer/token"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStripReply(t *testing.T) {
+	kases := map[string]string{
+		"Thanks!\n": "Thanks!",
+		"Thanks!\r\n\r\nOn Mon, 1 Jan 2020 at 10:00, User <user@example.com> wrote:\r\n> original\r\n> text\r\n": "Thanks!",
+		"Thanks!\n\nOn Mon, 1 Jan 2020 at 10:00, User\n<user@example.com> wrote:\n> original\n":                  "Thanks!",
+		"Thanks!\n\n-- \nUser\nExample Inc.\n":                       "Thanks!",
+		"Thanks!\n\n-----Original Message-----\nFrom: Gitea\n":       "Thanks!",
+		"> first\nanswer\n> second\nanswer\n\n> trailing\n> quote\n": "> first\nanswer\n> second\nanswer",
+	}
+	for content, expected := range kases {
+		assert.Equal(t, expected, stripReply(content))
+	}
+}
+
+func TestGetContentFromMail(t *testing.T) {
+	kases := map[string]string{
+		"Content-Type: text/plain; charset=utf-8\r\n\r\nplain text\r\n":                                                 "plain text",
+		"Content-Type: text/plain; charset=iso-8859-1\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\nK=E4se\r\n": "Käse",
+		"Content-Type: multipart/alternative; boundary=b\r\n\r\n" +
+			"--b\r\nContent-Type: text/html\r\n\r\n<p>html text</p>\r\n" +
+			"--b\r\nContent-Type: text/plain\r\nContent-Transfer-Encoding: base64\r\n\r\ncGxhaW4gdGV4dA==\r\n" +
+			"--b--\r\n": "plain text",
+		"Content-Type: multipart/mixed; boundary=b\r\n\r\n" +
+			"--b\r\nContent-Type: text/plain\r\nContent-Disposition: attachment; filename=a.txt\r\n\r\nattachment\r\n" +
+			"--b\r\nContent-Type: text/html\r\n\r\n<p>html <b>text</b></p>\r\n" +
+			"--b--\r\n": "html *text*",
+	}
+	for raw, expected := range kases {
+		msg, err := mail.ReadMessage(strings.NewReader(raw))
+		assert.NoError(t, err)
+		content, err := getContentFromMail(msg)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, content)
+	}
+}
+
+func TestProcessMail(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+
+	setting.IncomingEmail.ReplyToAddress = "incoming+%{token}@localhost"
+
+	user := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	issue := models.AssertExistsAndLoadBean(t, &models.Issue{ID: 1}).(*models.Issue)
+
+	createAddress := func(ht token.HandlerType) string {
+		tok, err := token.CreateToken(ht, user, token.EncodeID(issue.ID))
+		assert.NoError(t, err)
+		return "incoming+" + tok + "@localhost"
+	}
+
+	dir, err := ioutil.TempDir("", "maildir")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "new"), 0700))
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "cur"), 0700))
+
+	mails := []string{
+		"To: " + createAddress(token.ReplyHandlerType) + "\r\n\r\nreply by mail\r\n\r\n> quoted\r\n",
+		"To: " + createAddress(token.ReplyHandlerType) + "\r\nAuto-Submitted: auto-replied\r\n\r\nout of office\r\n",
+		"To: other@localhost\r\nCc: " + createAddress(token.UnsubscribeHandlerType) + "\r\n\r\nunsubscribe\r\n",
+	}
+	for i, m := range mails {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "new", string(rune('a'+i))), []byte(m), 0600))
+	}
+
+	setting.IncomingEmail.DeleteHandledMessage = false
+	s := &maildirSource{Path: dir}
+	assert.NoError(t, s.Process(context.Background(), func(r io.Reader) error {
+		return processMail(context.Background(), r)
+	}))
+
+	models.AssertExistsAndLoadBean(t, &models.Comment{IssueID: issue.ID, PosterID: user.ID, Content: "reply by mail"})
+	models.AssertNotExistsBean(t, &models.Comment{IssueID: issue.ID, Content: "out of office"})
+	models.AssertExistsAndLoadBean(t, &models.IssueWatch{IssueID: issue.ID, UserID: user.ID}, models.Cond("is_watching = ?", false))
+
+	files, err := ioutil.ReadDir(filepath.Join(dir, "new"))
+	assert.NoError(t, err)
+	assert.Len(t, files, 0)
+	files, err = ioutil.ReadDir(filepath.Join(dir, "cur"))
+	assert.NoError(t, err)
+	assert.Len(t, files, len(mails))
+}
+
+func TestReplyHandler(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+
+	h := &replyHandler{}
+	owner := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	reader := models.AssertExistsAndLoadBean(t, &models.User{ID: 4}).(*models.User)
+	issue := models.AssertExistsAndLoadBean(t, &models.Issue{ID: 1}).(*models.Issue)
+	assert.NoError(t, issue.LoadRepo())
+	data := token.EncodeID(issue.ID)
+
+	// only writers comment on locked issues
+	assert.NoError(t, models.LockIssue(&models.IssueLockOptions{Doer: owner, Issue: issue}))
+	assert.NoError(t, h.Handle(context.Background(), "locked reply", reader, data))
+	models.AssertNotExistsBean(t, &models.Comment{IssueID: issue.ID, Content: "locked reply"})
+	assert.NoError(t, h.Handle(context.Background(), "locked reply", owner, data))
+	models.AssertExistsAndLoadBean(t, &models.Comment{IssueID: issue.ID, PosterID: owner.ID, Content: "locked reply"})
+
+	// nobody comments on issues of archived repositories
+	assert.NoError(t, issue.Repo.SetArchiveRepoState(true))
+	assert.NoError(t, h.Handle(context.Background(), "archived reply", owner, data))
+	models.AssertNotExistsBean(t, &models.Comment{IssueID: issue.ID, Content: "archived reply"})
+}
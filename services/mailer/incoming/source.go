@@ -0,0 +1,158 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package incoming
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/client"
+)
+
+// imapSource reads the unseen mails of an IMAP mailbox
+type imapSource struct{}
+
+// Process implements source
+func (s *imapSource) Process(ctx context.Context, fn func(io.Reader) error) error {
+	addr := setting.IncomingEmail.Host + ":" + strconv.Itoa(setting.IncomingEmail.Port)
+
+	var c *client.Client
+	var err error
+	if setting.IncomingEmail.UseTLS {
+		c, err = client.DialTLS(addr, &tls.Config{
+			ServerName:         setting.IncomingEmail.Host,
+			InsecureSkipVerify: setting.IncomingEmail.SkipTLSVerify,
+		})
+	} else {
+		c, err = client.Dial(addr)
+	}
+	if err != nil {
+		return fmt.Errorf("Dial: %v", err)
+	}
+	defer func() {
+		if err := c.Logout(); err != nil {
+			log.Error("Logout: %v", err)
+		}
+	}()
+
+	if err := c.Login(setting.IncomingEmail.Username, setting.IncomingEmail.Password); err != nil {
+		return fmt.Errorf("Login: %v", err)
+	}
+	if _, err := c.Select(setting.IncomingEmail.Mailbox, false); err != nil {
+		return fmt.Errorf("Select: %v", err)
+	}
+
+	criteria := imap.NewSearchCriteria()
+	criteria.WithoutFlags = []string{imap.SeenFlag, imap.DeletedFlag}
+	uids, err := c.UidSearch(criteria)
+	if err != nil {
+		return fmt.Errorf("UidSearch: %v", err)
+	}
+	if len(uids) == 0 {
+		return nil
+	}
+
+	seqset := new(imap.SeqSet)
+	seqset.AddNum(uids...)
+
+	// The connection is busy until all mails are fetched, so read them before handling them
+	section := &imap.BodySectionName{Peek: true}
+	messages := make(chan *imap.Message, len(uids))
+	if err := c.UidFetch(seqset, []imap.FetchItem{section.FetchItem(), imap.FetchUid}, messages); err != nil {
+		return fmt.Errorf("UidFetch: %v", err)
+	}
+
+	handled := new(imap.SeqSet)
+	for msg := range messages {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		body := msg.GetBody(section)
+		if body == nil {
+			continue
+		}
+		if err := fn(body); err != nil {
+			log.Error("Unable to process incoming mail [uid: %d]: %v", msg.Uid, err)
+		}
+		handled.AddNum(msg.Uid)
+	}
+	if handled.Empty() {
+		return nil
+	}
+
+	flag := imap.SeenFlag
+	if setting.IncomingEmail.DeleteHandledMessage {
+		flag = imap.DeletedFlag
+	}
+	if err := c.UidStore(handled, imap.FormatFlagsOp(imap.AddFlags, true), []interface{}{flag}, nil); err != nil {
+		return fmt.Errorf("UidStore: %v", err)
+	}
+	if setting.IncomingEmail.DeleteHandledMessage {
+		if err := c.Expunge(nil); err != nil {
+			return fmt.Errorf("Expunge: %v", err)
+		}
+	}
+	return nil
+}
+
+// maildirSource reads the new mails of a local maildir, mostly useful for testing
+type maildirSource struct {
+	Path string
+}
+
+// Process implements source
+func (s *maildirSource) Process(ctx context.Context, fn func(io.Reader) error) error {
+	newDir := filepath.Join(s.Path, "new")
+	files, err := ioutil.ReadDir(newDir)
+	if err != nil {
+		return fmt.Errorf("ReadDir: %v", err)
+	}
+
+	for _, f := range files {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		if f.IsDir() {
+			continue
+		}
+
+		p := filepath.Join(newDir, f.Name())
+		content, err := ioutil.ReadFile(p)
+		if err != nil {
+			return fmt.Errorf("ReadFile: %v", err)
+		}
+		if err := fn(bytes.NewReader(content)); err != nil {
+			log.Error("Unable to process incoming mail [%s]: %v", p, err)
+		}
+
+		if setting.IncomingEmail.DeleteHandledMessage {
+			err = os.Remove(p)
+		} else {
+			// mark the mail as seen, see https://cr.yp.to/proto/maildir.html
+			err = os.Rename(p, filepath.Join(s.Path, "cur", f.Name()+":2,S"))
+		}
+		if err != nil {
+			return fmt.Errorf("unable to mark mail as handled: %v", err)
+		}
+	}
+	return nil
+}
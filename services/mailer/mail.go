@@ -22,6 +22,7 @@ import (
 	"code.gitea.io/gitea/modules/markup/markdown"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/services/mailer/token"
 
 	"gopkg.in/gomail.v2"
 )
@@ -164,7 +165,7 @@ func SendCollaboratorMail(u, doer *models.User, repo *models.Repository) {
 	SendAsync(msg)
 }
 
-func composeIssueCommentMessages(ctx *mailCommentContext, tos []string, fromMention bool, info string) []*Message {
+func composeIssueCommentMessages(ctx *mailCommentContext, recipients []*models.User, fromMention bool, info string) []*Message {
 
 	var (
 		subject string
@@ -222,6 +223,7 @@ func composeIssueCommentMessages(ctx *mailCommentContext, tos []string, fromMent
 		"ActionType":      actType,
 		"ActionName":      actName,
 		"ReviewComments":  reviewComments,
+		"CanReply":        setting.IncomingEmail.Enabled,
 	}
 
 	var mailSubject bytes.Buffer
@@ -246,9 +248,9 @@ func composeIssueCommentMessages(ctx *mailCommentContext, tos []string, fromMent
 	}
 
 	// Make sure to compose independent messages to avoid leaking user emails
-	msgs := make([]*Message, 0, len(tos))
-	for _, to := range tos {
-		msg := NewMessageFrom([]string{to}, ctx.Doer.DisplayName(), setting.MailService.FromEmail, subject, mailBody.String())
+	msgs := make([]*Message, 0, len(recipients))
+	for _, recipient := range recipients {
+		msg := NewMessageFrom([]string{recipient.Email}, ctx.Doer.DisplayName(), setting.MailService.FromEmail, subject, mailBody.String())
 		msg.Info = fmt.Sprintf("Subject: %s, %s", subject, info)
 
 		// Set Message-ID on first message so replies know what to reference
@@ -258,12 +260,36 @@ func composeIssueCommentMessages(ctx *mailCommentContext, tos []string, fromMent
 			msg.SetHeader("In-Reply-To", "<"+ctx.Issue.ReplyReference()+">")
 			msg.SetHeader("References", "<"+ctx.Issue.ReplyReference()+">")
 		}
+
+		if setting.IncomingEmail.Enabled {
+			if replyAddress, err := createReplyAddress(token.ReplyHandlerType, recipient, ctx.Issue); err != nil {
+				log.Error("createReplyAddress [%d]: %v", recipient.ID, err)
+			} else {
+				msg.SetHeader("Reply-To", replyAddress)
+			}
+			if unsubscribeAddress, err := createReplyAddress(token.UnsubscribeHandlerType, recipient, ctx.Issue); err != nil {
+				log.Error("createReplyAddress [%d]: %v", recipient.ID, err)
+			} else {
+				msg.SetHeader("List-Unsubscribe", "<mailto:"+unsubscribeAddress+">")
+			}
+		}
+
 		msgs = append(msgs, msg)
 	}
 
 	return msgs
 }
 
+// createReplyAddress creates an address of the incoming mail handling which
+// lets the recipient act on the issue by sending a mail to it
+func createReplyAddress(ht token.HandlerType, recipient *models.User, issue *models.Issue) (string, error) {
+	t, err := token.CreateToken(ht, recipient, token.EncodeID(issue.ID))
+	if err != nil {
+		return "", err
+	}
+	return strings.Replace(setting.IncomingEmail.ReplyToAddress, setting.IncomingEmailTokenPlaceholder, t, 1), nil
+}
+
 func sanitizeSubject(subject string) string {
 	runes := []rune(strings.TrimSpace(subjectRemoveSpaces.ReplaceAllLiteralString(subject, " ")))
 	if len(runes) > mailMaxSubjectRunes {
@@ -274,14 +300,14 @@ func sanitizeSubject(subject string) string {
 }
 
 // SendIssueAssignedMail composes and sends issue assigned email
-func SendIssueAssignedMail(issue *models.Issue, doer *models.User, content string, comment *models.Comment, tos []string) {
+func SendIssueAssignedMail(issue *models.Issue, doer *models.User, content string, comment *models.Comment, recipients []*models.User) {
 	SendAsyncs(composeIssueCommentMessages(&mailCommentContext{
 		Issue:      issue,
 		Doer:       doer,
 		ActionType: models.ActionType(0),
 		Content:    content,
 		Comment:    comment,
-	}, tos, false, "issue assigned"))
+	}, recipients, false, "issue assigned"))
 }
 
 // actionToTemplate returns the type and name of the action facing the user
@@ -7,11 +7,13 @@ package mailer
 import (
 	"bytes"
 	"html/template"
+	"strings"
 	"testing"
 	texttmpl "text/template"
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/services/mailer/token"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -57,9 +59,9 @@ func TestComposeIssueCommentMessage(t *testing.T) {
 	btpl := template.Must(template.New("issue/comment").Parse(bodyTpl))
 	InitMailRender(stpl, btpl)
 
-	tos := []string{"test@gitea.com", "test2@gitea.com"}
+	recipients := []*models.User{{Name: "Test", Email: "test@gitea.com"}, {Name: "Test2", Email: "test2@gitea.com"}}
 	msgs := composeIssueCommentMessages(&mailCommentContext{Issue: issue, Doer: doer, ActionType: models.ActionCommentIssue,
-		Content: "test body", Comment: comment}, tos, false, "issue comment")
+		Content: "test body", Comment: comment}, recipients, false, "issue comment")
 	assert.Len(t, msgs, 2)
 	gomailMsg := msgs[0].ToMessage()
 	mailto := gomailMsg.GetHeader("To")
@@ -91,9 +93,9 @@ func TestComposeIssueMessage(t *testing.T) {
 	btpl := template.Must(template.New("issue/new").Parse(bodyTpl))
 	InitMailRender(stpl, btpl)
 
-	tos := []string{"test@gitea.com", "test2@gitea.com"}
+	recipients := []*models.User{{Name: "Test", Email: "test@gitea.com"}, {Name: "Test2", Email: "test2@gitea.com"}}
 	msgs := composeIssueCommentMessages(&mailCommentContext{Issue: issue, Doer: doer, ActionType: models.ActionCreateIssue,
-		Content: "test body"}, tos, false, "issue create")
+		Content: "test body"}, recipients, false, "issue create")
 	assert.Len(t, msgs, 2)
 
 	gomailMsg := msgs[0].ToMessage()
@@ -108,6 +110,52 @@ func TestComposeIssueMessage(t *testing.T) {
 	assert.Equal(t, messageID[0], "<user2/repo1/issues/1@localhost>", "Message-ID header doesn't match")
 }
 
+func TestComposeIssueMessageReplyTo(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+	var mailService = setting.Mailer{
+		From: "test@gitea.com",
+	}
+
+	setting.MailService = &mailService
+	setting.Domain = "localhost"
+	setting.IncomingEmail.Enabled = true
+	setting.IncomingEmail.ReplyToAddress = "incoming+%{token}@localhost"
+	defer func() {
+		setting.IncomingEmail.Enabled = false
+	}()
+
+	doer := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	recipient := models.AssertExistsAndLoadBean(t, &models.User{ID: 4}).(*models.User)
+	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1, Owner: doer}).(*models.Repository)
+	issue := models.AssertExistsAndLoadBean(t, &models.Issue{ID: 1, Repo: repo, Poster: doer}).(*models.Issue)
+
+	stpl := texttmpl.Must(texttmpl.New("issue/new").Parse(subjectTpl))
+	btpl := template.Must(template.New("issue/new").Parse(bodyTpl))
+	InitMailRender(stpl, btpl)
+
+	msg := testComposeIssueCommentMessage(t, &mailCommentContext{Issue: issue, Doer: doer, ActionType: models.ActionCreateIssue,
+		Content: "test body"}, []*models.User{recipient}, false, "issue create")
+
+	gomailMsg := msg.ToMessage()
+	replyTo := gomailMsg.GetHeader("Reply-To")
+	if assert.Len(t, replyTo, 1) {
+		tokenString := strings.TrimSuffix(strings.TrimPrefix(replyTo[0], "incoming+"), "@localhost")
+		ht, u, data, err := token.ExtractToken(tokenString)
+		assert.NoError(t, err)
+		assert.Equal(t, token.ReplyHandlerType, ht)
+		assert.Equal(t, recipient.ID, u.ID)
+		issueID, err := token.DecodeID(data)
+		assert.NoError(t, err)
+		assert.Equal(t, issue.ID, issueID)
+	}
+
+	unsubscribe := gomailMsg.GetHeader("List-Unsubscribe")
+	if assert.Len(t, unsubscribe, 1) {
+		assert.True(t, strings.HasPrefix(unsubscribe[0], "<mailto:incoming+"))
+		assert.NotContains(t, unsubscribe[0], replyTo[0])
+	}
+}
+
 func TestTemplateSelection(t *testing.T) {
 	assert.NoError(t, models.PrepareTestDatabase())
 	var mailService = setting.Mailer{
@@ -120,7 +168,7 @@ func TestTemplateSelection(t *testing.T) {
 	doer := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
 	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1, Owner: doer}).(*models.Repository)
 	issue := models.AssertExistsAndLoadBean(t, &models.Issue{ID: 1, Repo: repo, Poster: doer}).(*models.Issue)
-	tos := []string{"test@gitea.com"}
+	recipients := []*models.User{{Name: "Test", Email: "test@gitea.com"}}
 
 	stpl := texttmpl.Must(texttmpl.New("issue/default").Parse("issue/default/subject"))
 	texttmpl.Must(stpl.New("issue/new").Parse("issue/new/subject"))
@@ -144,22 +192,22 @@ func TestTemplateSelection(t *testing.T) {
 	}
 
 	msg := testComposeIssueCommentMessage(t, &mailCommentContext{Issue: issue, Doer: doer, ActionType: models.ActionCreateIssue,
-		Content: "test body"}, tos, false, "TestTemplateSelection")
+		Content: "test body"}, recipients, false, "TestTemplateSelection")
 	expect(t, msg, "issue/new/subject", "issue/new/body")
 
 	comment := models.AssertExistsAndLoadBean(t, &models.Comment{ID: 2, Issue: issue}).(*models.Comment)
 	msg = testComposeIssueCommentMessage(t, &mailCommentContext{Issue: issue, Doer: doer, ActionType: models.ActionCommentIssue,
-		Content: "test body", Comment: comment}, tos, false, "TestTemplateSelection")
+		Content: "test body", Comment: comment}, recipients, false, "TestTemplateSelection")
 	expect(t, msg, "issue/default/subject", "issue/default/body")
 
 	pull := models.AssertExistsAndLoadBean(t, &models.Issue{ID: 2, Repo: repo, Poster: doer}).(*models.Issue)
 	comment = models.AssertExistsAndLoadBean(t, &models.Comment{ID: 4, Issue: pull}).(*models.Comment)
 	msg = testComposeIssueCommentMessage(t, &mailCommentContext{Issue: pull, Doer: doer, ActionType: models.ActionCommentPull,
-		Content: "test body", Comment: comment}, tos, false, "TestTemplateSelection")
+		Content: "test body", Comment: comment}, recipients, false, "TestTemplateSelection")
 	expect(t, msg, "pull/comment/subject", "pull/comment/body")
 
 	msg = testComposeIssueCommentMessage(t, &mailCommentContext{Issue: issue, Doer: doer, ActionType: models.ActionCloseIssue,
-		Content: "test body", Comment: comment}, tos, false, "TestTemplateSelection")
+		Content: "test body", Comment: comment}, recipients, false, "TestTemplateSelection")
 	expect(t, msg, "Re: [user2/repo1] issue1 (#1)", "issue/close/body")
 }
 
@@ -185,9 +233,9 @@ func TestTemplateServices(t *testing.T) {
 		btpl := template.Must(template.New("issue/default").Parse(tplBody))
 		InitMailRender(stpl, btpl)
 
-		tos := []string{"test@gitea.com"}
+		recipients := []*models.User{{Name: "Test", Email: "test@gitea.com"}}
 		msg := testComposeIssueCommentMessage(t, &mailCommentContext{Issue: issue, Doer: doer, ActionType: actionType,
-			Content: "test body", Comment: comment}, tos, fromMention, "TestTemplateServices")
+			Content: "test body", Comment: comment}, recipients, fromMention, "TestTemplateServices")
 
 		subject := msg.ToMessage().GetHeader("Subject")
 		msgbuf := new(bytes.Buffer)
@@ -217,8 +265,8 @@ func TestTemplateServices(t *testing.T) {
 		"//Re: //")
 }
 
-func testComposeIssueCommentMessage(t *testing.T, ctx *mailCommentContext, tos []string, fromMention bool, info string) *Message {
-	msgs := composeIssueCommentMessages(ctx, tos, fromMention, info)
+func testComposeIssueCommentMessage(t *testing.T, ctx *mailCommentContext, recipients []*models.User, fromMention bool, info string) *Message {
+	msgs := composeIssueCommentMessages(ctx, recipients, fromMention, info)
 	assert.Len(t, msgs, 1)
 	return msgs[0]
 }
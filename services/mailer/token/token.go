@@ -0,0 +1,134 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// A token is the payload [version][handler type][user id][data] followed by a
+// HMAC of the payload. The HMAC key is derived from the secret key of the
+// instance and the random salt of the user, so changing the password of the
+// user invalidates all tokens sent to them.
+
+// HandlerType defines the type of the handler a token belongs to
+type HandlerType byte
+
+const (
+	// ReplyHandlerType posts the content of the mail as a comment
+	ReplyHandlerType HandlerType = 1
+	// UnsubscribeHandlerType unsubscribes the user from the thread
+	UnsubscribeHandlerType HandlerType = 2
+)
+
+const (
+	tokenVersion1 byte = 1
+	macLength          = 10
+)
+
+var (
+	// ErrInvalidToken is returned for malformed tokens and tokens with a wrong signature
+	ErrInvalidToken = errors.New("invalid token")
+
+	// email addresses are case insensitive, the token must be too
+	encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+)
+
+// CreateToken creates a token for the handler type, the user and the handler data
+func CreateToken(ht HandlerType, user *models.User, data []byte) (string, error) {
+	payload := make([]byte, 2, 2+binary.MaxVarintLen64+len(data))
+	payload[0] = tokenVersion1
+	payload[1] = byte(ht)
+	payload = appendVarint(payload, user.ID)
+	payload = append(payload, data...)
+
+	mac, err := createMAC(user, payload)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.ToLower(encoding.EncodeToString(append(payload, mac...))), nil
+}
+
+// ExtractToken extracts the handler type, the user and the handler data from the token
+func ExtractToken(token string) (HandlerType, *models.User, []byte, error) {
+	raw, err := encoding.DecodeString(strings.ToUpper(token))
+	if err != nil || len(raw) < 2+1+macLength {
+		return 0, nil, nil, ErrInvalidToken
+	}
+	if raw[0] != tokenVersion1 {
+		return 0, nil, nil, ErrInvalidToken
+	}
+
+	payload, mac := raw[:len(raw)-macLength], raw[len(raw)-macLength:]
+
+	userID, n := binary.Varint(payload[2:])
+	if n <= 0 {
+		return 0, nil, nil, ErrInvalidToken
+	}
+
+	user, err := models.GetUserByID(userID)
+	if err != nil {
+		if models.IsErrUserNotExist(err) {
+			return 0, nil, nil, ErrInvalidToken
+		}
+		return 0, nil, nil, err
+	}
+
+	expected, err := createMAC(user, payload)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	if !hmac.Equal(mac, expected) {
+		return 0, nil, nil, ErrInvalidToken
+	}
+
+	return HandlerType(payload[1]), user, payload[2+n:], nil
+}
+
+// EncodeID encodes an ID as handler data
+func EncodeID(id int64) []byte {
+	return appendVarint(nil, id)
+}
+
+// DecodeID decodes handler data created by EncodeID
+func DecodeID(data []byte) (int64, error) {
+	id, n := binary.Varint(data)
+	if n <= 0 || n != len(data) {
+		return 0, fmt.Errorf("invalid id data: %x", data)
+	}
+	return id, nil
+}
+
+func appendVarint(b []byte, v int64) []byte {
+	buf := make([]byte, binary.MaxVarintLen64)
+	return append(b, buf[:binary.PutVarint(buf, v)]...)
+}
+
+func createMAC(user *models.User, payload []byte) ([]byte, error) {
+	if user.Rands == "" {
+		// the salt is only set on sign-up and password changes, older accounts may not have one yet
+		var err error
+		if user.Rands, err = models.GetUserSalt(); err != nil {
+			return nil, err
+		}
+		if err := models.UpdateUserCols(user, "rands"); err != nil {
+			return nil, err
+		}
+	}
+
+	h := hmac.New(sha256.New, []byte(setting.SecretKey+user.Rands))
+	_, _ = h.Write(payload)
+	return h.Sum(nil)[:macLength], nil
+}
@@ -0,0 +1,51 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package token
+
+import (
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToken(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+
+	user := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+
+	token, err := CreateToken(ReplyHandlerType, user, EncodeID(42))
+	assert.NoError(t, err)
+	// tokens are used in the local part of mail addresses
+	assert.Equal(t, strings.ToLower(token), token)
+	assert.True(t, len(token) < 64)
+
+	ht, u, data, err := ExtractToken(strings.ToUpper(token))
+	assert.NoError(t, err)
+	assert.Equal(t, ReplyHandlerType, ht)
+	assert.Equal(t, user.ID, u.ID)
+	id, err := DecodeID(data)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 42, id)
+
+	tampered := []byte(token)
+	if tampered[len(tampered)-4] == 'a' {
+		tampered[len(tampered)-4] = 'b'
+	} else {
+		tampered[len(tampered)-4] = 'a'
+	}
+	_, _, _, err = ExtractToken(string(tampered))
+	assert.Equal(t, ErrInvalidToken, err)
+	_, _, _, err = ExtractToken("invalid")
+	assert.Equal(t, ErrInvalidToken, err)
+
+	// changing the salt of the user invalidates the token
+	u.Rands = "changed"
+	assert.NoError(t, models.UpdateUserCols(u, "rands"))
+	_, _, _, err = ExtractToken(token)
+	assert.Equal(t, ErrInvalidToken, err)
+}
@@ -8,6 +8,7 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"regexp"
 	"strings"
 	"time"
 
@@ -25,32 +26,90 @@ import (
 
 	"github.com/mcuadros/go-version"
 	"github.com/unknwon/com"
+	"mvdan.cc/xurls/v2"
 )
 
 // mirrorQueue holds an UniqueQueue object of the mirror
 var mirrorQueue = sync.NewUniqueQueue(setting.Repository.MirrorQueueLength)
 
+var validFormAddress *regexp.Regexp
+
+func init() {
+	var err error
+	validFormAddress, err = xurls.StrictMatchingScheme(`(https?)|(git)://`)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// ErrInvalidAddress represents an invalid mirror address
+type ErrInvalidAddress struct {
+	IsProtocolInvalid bool
+}
+
+// IsErrInvalidAddress checks if an error is a ErrInvalidAddress.
+func IsErrInvalidAddress(err error) bool {
+	_, ok := err.(ErrInvalidAddress)
+	return ok
+}
+
+func (err ErrInvalidAddress) Error() string {
+	if err.IsProtocolInvalid {
+		return "invalid mirror address, only http(s):// or git:// locations are supported"
+	}
+	return "invalid mirror address, all components of the url must be escaped"
+}
+
+// ParseAddress validates the address of a pull or push mirror and adds the given
+// credentials to it.
+func ParseAddress(addr, username, password string) (string, error) {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return "", ErrInvalidAddress{}
+	}
+
+	if u.Opaque != "" || !(u.Scheme == "http" || u.Scheme == "https" || u.Scheme == "git") {
+		return "", ErrInvalidAddress{IsProtocolInvalid: true}
+	}
+
+	if username != "" || password != "" {
+		u.User = url.UserPassword(username, password)
+	}
+
+	// Now use xurls
+	address := validFormAddress.FindString(addr)
+	if address != addr && addr != "" {
+		return "", ErrInvalidAddress{}
+	}
+
+	if u.EscapedPath() == "" || u.Host == "" || !u.IsAbs() {
+		return "", ErrInvalidAddress{}
+	}
+
+	return u.String(), nil
+}
+
 func readAddress(m *models.Mirror) {
 	if len(m.Address) > 0 {
 		return
 	}
 	var err error
-	m.Address, err = remoteAddress(m.Repo.RepoPath())
+	m.Address, err = remoteAddress(m.Repo.RepoPath(), "origin")
 	if err != nil {
 		log.Error("remoteAddress: %v", err)
 	}
 }
 
-func remoteAddress(repoPath string) (string, error) {
+func remoteAddress(repoPath, remoteName string) (string, error) {
 	var cmd *git.Command
 	binVersion, err := git.BinVersion()
 	if err != nil {
 		return "", err
 	}
 	if version.Compare(binVersion, "2.7", ">=") {
-		cmd = git.NewCommand("remote", "get-url", "origin")
+		cmd = git.NewCommand("remote", "get-url", remoteName)
 	} else {
-		cmd = git.NewCommand("config", "--get", "remote.origin.url")
+		cmd = git.NewCommand("config", "--get", "remote."+remoteName+".url")
 	}
 
 	result, err := cmd.RunInDir(repoPath)
@@ -69,7 +128,7 @@ func remoteAddress(repoPath string) (string, error) {
 // sanitizeOutput sanitizes output of a command, replacing occurrences of the
 // repository's remote address with a sanitized version.
 func sanitizeOutput(output, repoPath string) (string, error) {
-	remoteAddr, err := remoteAddress(repoPath)
+	remoteAddr, err := remoteAddress(repoPath, "origin")
 	if err != nil {
 		// if we're unable to load the remote address, then we're unable to
 		// sanitize.
@@ -313,7 +372,7 @@ func Password(m *models.Mirror) string {
 	return password
 }
 
-// Update checks and updates mirror repositories.
+// Update checks and updates mirror repositories and push mirrors.
 func Update(ctx context.Context) error {
 	log.Trace("Doing: Update")
 	if err := models.MirrorsIterate(func(idx int, bean interface{}) error {
@@ -333,6 +392,26 @@ func Update(ctx context.Context) error {
 		log.Trace("Update: %v", err)
 		return err
 	}
+	if err := models.PushMirrorsIterate(func(idx int, bean interface{}) error {
+		m := bean.(*models.PushMirror)
+		if m.Repo == nil {
+			log.Error("Disconnected push-mirror repository found: %d", m.ID)
+			return nil
+		}
+		if !m.IsDue() {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("Aborted")
+		default:
+			mirrorQueue.Add(pushMirrorQueueKey(m.ID))
+			return nil
+		}
+	}); err != nil {
+		log.Trace("Update: %v", err)
+		return err
+	}
 	log.Trace("Finished: Update")
 	return nil
 }
@@ -346,8 +425,12 @@ func SyncMirrors(ctx context.Context) {
 		case <-ctx.Done():
 			mirrorQueue.Close()
 			return
-		case repoID := <-mirrorQueue.Queue():
-			syncMirror(repoID)
+		case id := <-mirrorQueue.Queue():
+			if strings.HasPrefix(id, pushMirrorQueuePrefix) {
+				syncPushMirror(id)
+				continue
+			}
+			syncMirror(id)
 		}
 	}
 }
@@ -456,6 +539,7 @@ func syncMirror(repoID string) {
 
 // InitSyncMirrors initializes a go routine to sync the mirrors
 func InitSyncMirrors() {
+	notification.RegisterNotifier(&pushMirrorNotifier{})
 	go graceful.GetManager().RunWithShutdownContext(SyncMirrors)
 }
 
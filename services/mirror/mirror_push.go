@@ -0,0 +1,211 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package mirror
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/generate"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification/base"
+	"code.gitea.io/gitea/modules/repository"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/unknwon/com"
+)
+
+// pushMirrorQueuePrefix distinguishes push mirror IDs from repository IDs in the mirror queue
+const pushMirrorQueuePrefix = "push-"
+
+func pushMirrorQueueKey(id int64) string {
+	return fmt.Sprintf("%s%d", pushMirrorQueuePrefix, id)
+}
+
+// ParsePushMirrorInterval parses the sync interval of a push mirror. An empty interval or 0 only
+// pushes on changes, any other interval must not be shorter than the minimum mirror interval.
+func ParsePushMirrorInterval(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	interval, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if interval != 0 && interval < setting.Mirror.MinInterval {
+		return 0, fmt.Errorf("interval %s is shorter than the minimum mirror interval %s", interval, setting.Mirror.MinInterval)
+	}
+	return interval, nil
+}
+
+// AddPushMirror creates a new push mirror for the repository and registers its
+// remote in the git repository config.
+func AddPushMirror(repo *models.Repository, addr string, interval time.Duration) (*models.PushMirror, error) {
+	remoteSuffix, err := generate.GetRandomString(10)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &models.PushMirror{
+		RepoID:     repo.ID,
+		Repo:       repo,
+		RemoteName: "remote_mirror_" + remoteSuffix,
+		Interval:   interval,
+	}
+
+	if _, err := git.NewCommand("remote", "add", "--mirror=push", m.RemoteName, addr).RunInDir(repo.RepoPath()); err != nil {
+		return nil, err
+	}
+
+	if err := models.InsertPushMirror(m); err != nil {
+		if rmErr := removePushMirrorRemote(m); rmErr != nil {
+			log.Error("removePushMirrorRemote [%s]: %v", m.RemoteName, rmErr)
+		}
+		return nil, err
+	}
+	return m, nil
+}
+
+func removePushMirrorRemote(m *models.PushMirror) error {
+	_, err := git.NewCommand("remote", "rm", m.RemoteName).RunInDir(m.Repo.RepoPath())
+	if err != nil && !strings.HasPrefix(err.Error(), "exit status 128 - fatal: No such remote") {
+		return err
+	}
+	return nil
+}
+
+// RemovePushMirror removes the push mirror and its remote from the git repository config.
+func RemovePushMirror(m *models.PushMirror) error {
+	if err := removePushMirrorRemote(m); err != nil {
+		return err
+	}
+	return models.DeletePushMirrorByID(m.ID)
+}
+
+// PushMirrorAddress returns the push mirror address from Git repository config without credentials.
+func PushMirrorAddress(m *models.PushMirror) string {
+	addr, err := remoteAddress(m.Repo.RepoPath(), m.RemoteName)
+	if err != nil {
+		log.Error("remoteAddress: %v", err)
+		return ""
+	}
+	u, err := url.Parse(addr)
+	if err != nil {
+		// this shouldn't happen but just return it sanitised
+		return util.SanitizeURLCredentials(addr, false)
+	}
+	u.User = nil
+	return u.String()
+}
+
+// StartToPushMirror adds the push mirror to the mirror queue
+func StartToPushMirror(m *models.PushMirror) {
+	go mirrorQueue.Add(pushMirrorQueueKey(m.ID))
+}
+
+// startToPushMirrors adds all push mirrors of the repository to the mirror queue
+func startToPushMirrors(repo *models.Repository) {
+	mirrors, err := models.GetPushMirrorsByRepoID(repo.ID)
+	if err != nil {
+		log.Error("GetPushMirrorsByRepoID [%d]: %v", repo.ID, err)
+		return
+	}
+	for _, m := range mirrors {
+		StartToPushMirror(m)
+	}
+}
+
+func runPushSync(m *models.PushMirror) error {
+	repoPath := m.Repo.RepoPath()
+	timeout := time.Duration(setting.Git.Timeout.Mirror) * time.Second
+
+	stdoutBuilder := strings.Builder{}
+	stderrBuilder := strings.Builder{}
+	if err := git.NewCommand("push", "--mirror", m.RemoteName).
+		SetDescription(fmt.Sprintf("PushMirror.runPushSync: %s", m.Repo.FullName())).
+		RunInDirTimeoutPipeline(timeout, repoPath, &stdoutBuilder, &stderrBuilder); err != nil {
+		// sanitize the output, since it may contain the remote address, which may
+		// contain a password
+		remoteAddr, addrErr := remoteAddress(repoPath, m.RemoteName)
+		if addrErr != nil {
+			log.Error("remoteAddress failed: %v", addrErr)
+			log.Error("Failed to push mirror %s of repository %v: %v", m.RemoteName, m.Repo, err)
+			return errors.New("push mirror failed")
+		}
+		stderrMessage := util.SanitizeMessage(stderrBuilder.String(), remoteAddr)
+		errMessage := util.SanitizeMessage(err.Error(), remoteAddr)
+		log.Error("Failed to push mirror %s of repository %v:\nStderr: %s\nErr: %s", m.RemoteName, m.Repo, stderrMessage, errMessage)
+		if len(strings.TrimSpace(stderrMessage)) > 0 {
+			return errors.New(strings.TrimSpace(stderrMessage))
+		}
+		return errors.New(errMessage)
+	}
+	return nil
+}
+
+func syncPushMirror(key string) {
+	log.Trace("SyncPushMirror [%s]", key)
+	defer func() {
+		err := recover()
+		if err == nil {
+			return
+		}
+		// There was a panic whilst syncPushMirror...
+		log.Error("PANIC whilst syncPushMirror[%s] Panic: %v\nStacktrace: %s", key, err, log.Stack(2))
+	}()
+	mirrorQueue.Remove(key)
+
+	m, err := models.GetPushMirrorByID(com.StrTo(strings.TrimPrefix(key, pushMirrorQueuePrefix)).MustInt64())
+	if err != nil {
+		log.Error("GetPushMirrorByID [%s]: %v", key, err)
+		return
+	}
+	if m.Repo == nil {
+		log.Error("Disconnected push-mirror repository found: %d", m.ID)
+		return
+	}
+
+	m.LastError = ""
+	if err := runPushSync(m); err != nil {
+		m.LastError = err.Error()
+	}
+	m.LastUpdateUnix = timeutil.TimeStampNow()
+
+	if err := models.UpdatePushMirror(m); err != nil {
+		log.Error("UpdatePushMirror [%d]: %v", m.ID, err)
+	}
+}
+
+type pushMirrorNotifier struct {
+	base.NullNotifier
+}
+
+var (
+	_ base.Notifier = &pushMirrorNotifier{}
+)
+
+func (n *pushMirrorNotifier) NotifyPushCommits(pusher *models.User, repo *models.Repository, refName, oldCommitID, newCommitID string, commits *repository.PushCommits) {
+	startToPushMirrors(repo)
+}
+
+func (n *pushMirrorNotifier) NotifyDeleteRef(doer *models.User, repo *models.Repository, refType, refFullName string) {
+	startToPushMirrors(repo)
+}
+
+func (n *pushMirrorNotifier) NotifySyncPushCommits(pusher *models.User, repo *models.Repository, refName, oldCommitID, newCommitID string, commits *repository.PushCommits) {
+	startToPushMirrors(repo)
+}
+
+func (n *pushMirrorNotifier) NotifySyncDeleteRef(doer *models.User, repo *models.Repository, refType, refFullName string) {
+	startToPushMirrors(repo)
+}
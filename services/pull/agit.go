@@ -0,0 +1,130 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package pull
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/log"
+)
+
+// ParseAGitRef splits a refs/for/<base branch>/<topic> reference into the base branch and
+// the topic. As both may contain slashes, the longest existing branch of the repository
+// is taken as the base branch. Empty strings are returned if the reference does not
+// name an existing branch followed by a topic.
+func ParseAGitRef(gitRepo *git.Repository, refFullName string) (baseBranch, topic string) {
+	name := strings.TrimPrefix(refFullName, git.AGitPullRequestPrefix)
+	for i := strings.LastIndex(name, "/"); i > 0; i = strings.LastIndex(name[:i], "/") {
+		if i < len(name)-1 && gitRepo.IsBranchExist(name[:i]) {
+			return name[:i], name[i+1:]
+		}
+	}
+	return "", ""
+}
+
+// AGitHeadBranch returns the head branch name recorded for the AGit flow pull request
+// pushed by doer with the given topic
+func AGitHeadBranch(doer *models.User, topic string) string {
+	return doer.Name + "/" + topic
+}
+
+// PushAGitPullRequest creates the AGit flow pull request of doer into baseBranch for the
+// topic, or updates it if it is still open, with the newCommitID pushed to refFullName.
+// The pushed reference is removed afterwards as the head of the pull request is kept in
+// its hidden pull request reference.
+func PushAGitPullRequest(doer *models.User, repo *models.Repository, refFullName, baseBranch, topic, newCommitID string) (pr *models.PullRequest, created bool, err error) {
+	defer func() {
+		if _, err := git.NewCommand("update-ref", "-d", refFullName).RunInDir(repo.RepoPath()); err != nil {
+			log.Error("Unable to remove %s from %-v: %v", refFullName, repo, err)
+		}
+	}()
+
+	// Only the poster updates the pull request of a topic, other users pushing the same
+	// topic propose their own pull requests
+	pr, err = models.GetUnmergedAGitPullRequest(repo.ID, doer.ID, baseBranch, topic)
+	if err != nil && !models.IsErrPullRequestNotExist(err) {
+		return nil, false, err
+	}
+
+	gitRepo, err := git.OpenRepository(repo.RepoPath())
+	if err != nil {
+		return nil, false, err
+	}
+	defer gitRepo.Close()
+
+	if pr == nil {
+		commit, err := gitRepo.GetCommit(newCommitID)
+		if err != nil {
+			return nil, false, fmt.Errorf("GetCommit: %v", err)
+		}
+		mergeBase, _, err := gitRepo.GetMergeBase("", git.BranchPrefix+baseBranch, newCommitID)
+		if err != nil {
+			return nil, false, fmt.Errorf("GetMergeBase: %v", err)
+		}
+
+		// The first commit pushed describes the pull request, like on the compare page
+		title := commit.Summary()
+		content := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(commit.Message()), title))
+
+		prIssue := &models.Issue{
+			RepoID:   repo.ID,
+			Title:    title,
+			PosterID: doer.ID,
+			Poster:   doer,
+			IsPull:   true,
+			Content:  content,
+		}
+		pr = &models.PullRequest{
+			HeadRepoID:   repo.ID,
+			BaseRepoID:   repo.ID,
+			HeadBranch:   AGitHeadBranch(doer, topic),
+			HeadCommitID: newCommitID,
+			BaseBranch:   baseBranch,
+			HeadRepo:     repo,
+			BaseRepo:     repo,
+			MergeBase:    mergeBase,
+			Type:         models.PullRequestGitea,
+			Flow:         models.PullRequestFlowAGit,
+			AGitTopic:    topic,
+		}
+		if err := NewPullRequest(repo, prIssue, nil, nil, pr, nil); err != nil {
+			return nil, false, err
+		}
+		return pr, true, nil
+	}
+
+	oldCommitID, err := gitRepo.GetRefCommitID(pr.GetGitRefName())
+	if err != nil {
+		return nil, false, fmt.Errorf("GetRefCommitID: %v", err)
+	}
+	if oldCommitID == newCommitID {
+		return pr, false, nil
+	}
+	if err := updateAGitHeadRef(pr, newCommitID); err != nil {
+		return nil, false, err
+	}
+
+	go graceful.GetManager().RunWithShutdownContext(func(ctx context.Context) {
+		updateHeadPullRequests(doer, []*models.PullRequest{pr}, repo.ID, pr.GetGitRefName(), true, oldCommitID, newCommitID)
+	})
+	return pr, false, nil
+}
+
+// updateAGitHeadRef points the hidden reference of an AGit flow pull request, which is
+// its only head, at commitID
+func updateAGitHeadRef(pr *models.PullRequest, commitID string) error {
+	if err := pr.LoadBaseRepo(); err != nil {
+		return err
+	}
+	if _, err := git.NewCommand("update-ref", pr.GetGitRefName(), commitID).RunInDir(pr.BaseRepo.RepoPath()); err != nil {
+		return fmt.Errorf("update-ref %s %s in %s: %v", pr.GetGitRefName(), commitID, pr.BaseRepo.FullName(), err)
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package pull
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	issue_service "code.gitea.io/gitea/services/issue"
+)
+
+// codeOwnersFiles are the locations of the CODEOWNERS file in the base branch, in order of precedence
+var codeOwnersFiles = []string{"CODEOWNERS", ".gitea/CODEOWNERS", "docs/CODEOWNERS"}
+
+// maxCodeOwnersFileSize is the maximum size of a CODEOWNERS file which is parsed
+const maxCodeOwnersFileSize = 3 * 1024 * 1024
+
+// readCodeOwners returns the content of the CODEOWNERS file of the given commit, or an empty string if there is none.
+func readCodeOwners(commit *git.Commit) (string, error) {
+	for _, name := range codeOwnersFiles {
+		entry, err := commit.GetTreeEntryByPath(name)
+		if err != nil {
+			if git.IsErrNotExist(err) {
+				continue
+			}
+			return "", err
+		}
+		if entry.IsDir() || entry.Blob().Size() > maxCodeOwnersFileSize {
+			continue
+		}
+
+		rd, err := entry.Blob().DataAsync()
+		if err != nil {
+			return "", err
+		}
+		data, err := ioutil.ReadAll(rd)
+		rd.Close()
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+	return "", nil
+}
+
+// getCodeOwnersOfChangedFiles returns the code owner rule applying to each file
+// changed by the pull request, according to the CODEOWNERS file of the base branch.
+// Files without code owners are omitted.
+func getCodeOwnersOfChangedFiles(pr *models.PullRequest) (map[string]*models.CodeOwnerRule, error) {
+	if err := pr.LoadBaseRepo(); err != nil {
+		return nil, fmt.Errorf("LoadBaseRepo: %v", err)
+	}
+
+	gitRepo, err := git.OpenRepository(pr.BaseRepo.RepoPath())
+	if err != nil {
+		return nil, fmt.Errorf("OpenRepository: %v", err)
+	}
+	defer gitRepo.Close()
+
+	commit, err := gitRepo.GetBranchCommit(pr.BaseBranch)
+	if err != nil {
+		return nil, fmt.Errorf("GetBranchCommit: %v", err)
+	}
+
+	content, err := readCodeOwners(commit)
+	if err != nil {
+		return nil, fmt.Errorf("readCodeOwners: %v", err)
+	}
+	if content == "" {
+		return nil, nil
+	}
+
+	rules, _ := models.ParseCodeOwners(pr.BaseRepo, content)
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	files, err := gitRepo.GetFilesChangedBetween(git.BranchPrefix+pr.BaseBranch, pr.GetGitRefName())
+	if err != nil {
+		return nil, fmt.Errorf("GetFilesChangedBetween: %v", err)
+	}
+
+	owners := make(map[string]*models.CodeOwnerRule, len(files))
+	for _, file := range files {
+		if rule := models.GetCodeOwnerRule(rules, file); rule != nil && rule.HasOwners() {
+			owners[file] = rule
+		}
+	}
+	return owners, nil
+}
+
+// RequestCodeOwnersReview requests a review from the code owners of the files changed
+// by the pull request. Owners who already reviewed or were already requested are skipped.
+func RequestCodeOwnersReview(pr *models.PullRequest) error {
+	owners, err := getCodeOwnersOfChangedFiles(pr)
+	if err != nil {
+		return err
+	}
+	if len(owners) == 0 {
+		return nil
+	}
+
+	if err := pr.LoadIssue(); err != nil {
+		return fmt.Errorf("LoadIssue: %v", err)
+	}
+	if err := pr.Issue.LoadRepo(); err != nil {
+		return fmt.Errorf("LoadRepo: %v", err)
+	}
+	if err := pr.Issue.LoadPoster(); err != nil {
+		return fmt.Errorf("LoadPoster: %v", err)
+	}
+
+	reviewers := make(map[int64]*models.User)
+	seen := make(map[*models.CodeOwnerRule]bool)
+	for _, rule := range owners {
+		if seen[rule] {
+			continue
+		}
+		seen[rule] = true
+
+		users, err := rule.GetOwnerUsers()
+		if err != nil {
+			return err
+		}
+		for _, u := range users {
+			if u.IsOrganization() || u.ID == pr.Issue.PosterID {
+				continue
+			}
+			reviewers[u.ID] = u
+		}
+	}
+
+	ids := make([]int64, 0, len(reviewers))
+	for id := range reviewers {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for _, id := range ids {
+		reviewer := reviewers[id]
+
+		perm, err := models.GetUserRepoPermission(pr.BaseRepo, reviewer)
+		if err != nil {
+			return err
+		}
+		if !perm.CanAccessAny(models.AccessModeRead, models.UnitTypePullRequests) {
+			continue
+		}
+
+		review, err := models.GetReviewerByIssueIDAndUserID(pr.IssueID, reviewer.ID)
+		if err != nil {
+			return err
+		}
+		if review != nil && review.ID != 0 {
+			continue
+		}
+
+		if err := issue_service.ReviewRequest(pr.Issue, pr.Issue.Poster, reviewer, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// requestCodeOwnersReview requests the code owners review and logs failures,
+// as they must not prevent the creation or synchronization of the pull request.
+func requestCodeOwnersReview(pr *models.PullRequest) {
+	if err := RequestCodeOwnersReview(pr); err != nil {
+		log.Error("RequestCodeOwnersReview [pr_id: %d]: %v", pr.ID, err)
+	}
+}
+
+// HasCodeOwnerApprovals returns true if every file changed by the pull request which
+// has code owners got an official approval from one of its owners.
+func HasCodeOwnerApprovals(pr *models.PullRequest) (bool, error) {
+	owners, err := getCodeOwnersOfChangedFiles(pr)
+	if err != nil {
+		return false, err
+	}
+	if len(owners) == 0 {
+		return true, nil
+	}
+
+	if err := pr.LoadProtectedBranch(); err != nil {
+		return false, fmt.Errorf("LoadProtectedBranch: %v", err)
+	}
+	excludeStale := pr.ProtectedBranch != nil && pr.ProtectedBranch.DismissStaleApprovals
+
+	reviews, err := models.GetReviewersByIssueID(pr.IssueID)
+	if err != nil {
+		return false, err
+	}
+	approvers := make([]*models.User, 0, len(reviews))
+	for _, review := range reviews {
+		if review.Type != models.ReviewTypeApprove || !review.Official || (excludeStale && review.Stale) {
+			continue
+		}
+		approvers = append(approvers, review.Reviewer)
+	}
+
+	approved := make(map[*models.CodeOwnerRule]bool)
+	for _, rule := range owners {
+		if _, checked := approved[rule]; checked {
+			continue
+		}
+		approved[rule] = false
+		for _, approver := range approvers {
+			isOwner, err := rule.IsOwner(approver)
+			if err != nil {
+				return false, err
+			}
+			if isOwner {
+				approved[rule] = true
+				break
+			}
+		}
+		if !approved[rule] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -108,13 +108,13 @@ func GetPullRequestCommitStatusState(pr *models.PullRequest) (structs.CommitStat
 	}
 	defer headGitRepo.Close()
 
-	if !headGitRepo.IsBranchExist(pr.HeadBranch) {
+	if pr.Flow == models.PullRequestFlowGithub && !headGitRepo.IsBranchExist(pr.HeadBranch) {
 		return "", errors.New("Head branch does not exist, can not merge")
 	}
 
-	sha, err := headGitRepo.GetBranchCommitID(pr.HeadBranch)
+	sha, err := headGitRepo.GetRefCommitID(pr.GetGitHeadRefName())
 	if err != nil {
-		return "", errors.Wrap(err, "GetBranchCommitID")
+		return "", errors.Wrap(err, "GetRefCommitID")
 	}
 
 	if err := pr.LoadBaseRepo(); err != nil {
@@ -248,9 +248,15 @@ func rawMerge(pr *models.PullRequest, doer *models.User, mergeStyle models.Merge
 			log.Error("Unable to make final commit: %v", err)
 			return "", err
 		}
+	case models.MergeStyleFastForwardOnly:
+		cmd := git.NewCommand("merge", "--ff-only", trackingBranch)
+		if err := runMergeCommand(pr, mergeStyle, cmd, tmpBasePath); err != nil {
+			log.Error("Unable to fast-forward base to tracking: %v", err)
+			return "", err
+		}
 	case models.MergeStyleRebase:
 		fallthrough
-	case models.MergeStyleRebaseMerge:
+	case models.MergeStyleRebaseMerge, models.MergeStyleSemiLinear:
 		// Checkout head branch
 		if err := git.NewCommand("checkout", "-b", stagingBranch, trackingBranch).RunInDirPipeline(tmpBasePath, &outbuf, &errbuf); err != nil {
 			log.Error("git checkout base prior to merge post staging rebase [%s:%s -> %s:%s]: %v\n%s\n%s", pr.HeadRepo.FullName(), pr.HeadBranch, pr.BaseRepo.FullName(), pr.BaseBranch, err, outbuf.String(), errbuf.String())
@@ -323,7 +329,7 @@ func rawMerge(pr *models.PullRequest, doer *models.User, mergeStyle models.Merge
 			log.Error("Unable to merge staging into base: %v", err)
 			return "", err
 		}
-		if mergeStyle == models.MergeStyleRebaseMerge {
+		if mergeStyle != models.MergeStyleRebase {
 			if err := commitAndSignNoAuthor(pr, message, signArg, tmpBasePath, env); err != nil {
 				log.Error("Unable to make final commit: %v", err)
 				return "", err
@@ -465,6 +471,14 @@ func runMergeCommand(pr *models.PullRequest, mergeStyle models.MergeStyle, cmd *
 				StdErr: errbuf.String(),
 				Err:    err,
 			}
+		} else if mergeStyle == models.MergeStyleFastForwardOnly && strings.Contains(errbuf.String(), "Not possible to fast-forward") {
+			log.Debug("MergeDivergingBranches [%s:%s -> %s:%s]: %v\n%s\n%s", pr.HeadRepo.FullName(), pr.HeadBranch, pr.BaseRepo.FullName(), pr.BaseBranch, err, outbuf.String(), errbuf.String())
+			return models.ErrMergeDivergingBranches{
+				Style:  mergeStyle,
+				StdOut: outbuf.String(),
+				StdErr: errbuf.String(),
+				Err:    err,
+			}
 		}
 		log.Error("git merge [%s:%s -> %s:%s]: %v\n%s\n%s", pr.HeadRepo.FullName(), pr.HeadBranch, pr.BaseRepo.FullName(), pr.BaseBranch, err, outbuf.String(), errbuf.String())
 		return fmt.Errorf("git merge [%s:%s -> %s:%s]: %v\n%s\n%s", pr.HeadRepo.FullName(), pr.HeadBranch, pr.BaseRepo.FullName(), pr.BaseBranch, err, outbuf.String(), errbuf.String())
@@ -591,5 +605,17 @@ func CheckPRReadyToMerge(pr *models.PullRequest) (err error) {
 		}
 	}
 
+	if pr.ProtectedBranch.RequireCodeOwnerApproval {
+		approved, err := HasCodeOwnerApprovals(pr)
+		if err != nil {
+			return err
+		}
+		if !approved {
+			return models.ErrNotAllowedToMerge{
+				Reason: "Not all changed files have been approved by their code owners",
+			}
+		}
+	}
+
 	return nil
 }
@@ -52,12 +52,18 @@ func NewPullRequest(repo *models.Repository, pull *models.Issue, labelIDs []int6
 	pr.Issue = pull
 	pull.PullRequest = pr
 
-	if err := PushToBaseRepo(pr); err != nil {
+	if pr.Flow == models.PullRequestFlowGithub {
+		if err := PushToBaseRepo(pr); err != nil {
+			return err
+		}
+	} else if err := updateAGitHeadRef(pr, pr.HeadCommitID); err != nil {
 		return err
 	}
 
 	notification.NotifyNewPullRequest(pr)
 
+	requestCodeOwnersReview(pr)
+
 	// add first push codes comment
 	baseGitRepo, err := git.OpenRepository(pr.BaseRepo.RepoPath())
 	if err != nil {
@@ -137,7 +143,7 @@ func ChangeTargetBranch(pr *models.PullRequest, doer *models.User, targetBranch
 	}
 
 	// Check if pull request for the new target branch already exists
-	existingPr, err := models.GetUnmergedPullRequest(pr.HeadRepoID, pr.BaseRepoID, pr.HeadBranch, targetBranch)
+	existingPr, err := models.GetUnmergedPullRequest(pr.HeadRepoID, pr.BaseRepoID, pr.HeadBranch, targetBranch, pr.Flow)
 	if existingPr != nil {
 		return models.ErrPullRequestAlreadyExists{
 			ID:         existingPr.ID,
@@ -218,9 +224,12 @@ func checkForInvalidation(requests models.PullRequestList, repoID int64, doer *m
 func addHeadRepoTasks(prs []*models.PullRequest) {
 	for _, pr := range prs {
 		log.Trace("addHeadRepoTasks[%d]: composing new test task", pr.ID)
-		if err := PushToBaseRepo(pr); err != nil {
-			log.Error("PushToBaseRepo: %v", err)
-			continue
+		// The head of an AGit flow pull request is pushed to the base repository directly
+		if pr.Flow == models.PullRequestFlowGithub {
+			if err := PushToBaseRepo(pr); err != nil {
+				log.Error("PushToBaseRepo: %v", err)
+				continue
+			}
 		}
 
 		AddToTaskQueue(pr)
@@ -242,54 +251,7 @@ func AddTestPullRequestTask(doer *models.User, repoID int64, branch string, isSy
 			return
 		}
 
-		if isSync {
-			requests := models.PullRequestList(prs)
-			if err = requests.LoadAttributes(); err != nil {
-				log.Error("PullRequestList.LoadAttributes: %v", err)
-			}
-			if invalidationErr := checkForInvalidation(requests, repoID, doer, branch); invalidationErr != nil {
-				log.Error("checkForInvalidation: %v", invalidationErr)
-			}
-			if err == nil {
-				for _, pr := range prs {
-					if newCommitID != "" && newCommitID != git.EmptySHA {
-						changed, err := checkIfPRContentChanged(pr, oldCommitID, newCommitID)
-						if err != nil {
-							log.Error("checkIfPRContentChanged: %v", err)
-						}
-						if changed {
-							// Mark old reviews as stale if diff to mergebase has changed
-							if err := models.MarkReviewsAsStale(pr.IssueID); err != nil {
-								log.Error("MarkReviewsAsStale: %v", err)
-							}
-						}
-						if err := models.MarkReviewsAsNotStale(pr.IssueID, newCommitID); err != nil {
-							log.Error("MarkReviewsAsNotStale: %v", err)
-						}
-						divergence, err := GetDiverging(pr)
-						if err != nil {
-							log.Error("GetDiverging: %v", err)
-						} else {
-							err = pr.UpdateCommitDivergence(divergence.Ahead, divergence.Behind)
-							if err != nil {
-								log.Error("UpdateCommitDivergence: %v", err)
-							}
-						}
-					}
-
-					pr.Issue.PullRequest = pr
-					notification.NotifyPullRequestSynchronized(doer, pr)
-				}
-			}
-		}
-
-		addHeadRepoTasks(prs)
-		for _, pr := range prs {
-			comment, err := models.CreatePushPullComment(doer, pr, oldCommitID, newCommitID)
-			if err == nil && comment != nil {
-				notification.NotifyPullRequestPushCommits(doer, pr, comment)
-			}
-		}
+		updateHeadPullRequests(doer, prs, repoID, branch, isSync, oldCommitID, newCommitID)
 
 		log.Trace("AddTestPullRequestTask [base_repo_id: %d, base_branch: %s]: finding pull requests", repoID, branch)
 		prs, err = models.GetUnmergedPullRequestsByBaseInfo(repoID, branch)
@@ -312,6 +274,62 @@ func AddTestPullRequestTask(doer *models.User, repoID int64, branch string, isSy
 	})
 }
 
+// updateHeadPullRequests synchronizes the pull requests whose head has been pushed to and
+// queues them to be tested again
+func updateHeadPullRequests(doer *models.User, prs []*models.PullRequest, repoID int64, branch string, isSync bool, oldCommitID, newCommitID string) {
+	if isSync {
+		requests := models.PullRequestList(prs)
+		err := requests.LoadAttributes()
+		if err != nil {
+			log.Error("PullRequestList.LoadAttributes: %v", err)
+		}
+		if invalidationErr := checkForInvalidation(requests, repoID, doer, branch); invalidationErr != nil {
+			log.Error("checkForInvalidation: %v", invalidationErr)
+		}
+		if err == nil {
+			for _, pr := range prs {
+				if newCommitID != "" && newCommitID != git.EmptySHA {
+					changed, err := checkIfPRContentChanged(pr, oldCommitID, newCommitID)
+					if err != nil {
+						log.Error("checkIfPRContentChanged: %v", err)
+					}
+					if changed {
+						// Mark old reviews as stale if diff to mergebase has changed
+						if err := models.MarkReviewsAsStale(pr.IssueID); err != nil {
+							log.Error("MarkReviewsAsStale: %v", err)
+						}
+					}
+					if err := models.MarkReviewsAsNotStale(pr.IssueID, newCommitID); err != nil {
+						log.Error("MarkReviewsAsNotStale: %v", err)
+					}
+					divergence, err := GetDiverging(pr)
+					if err != nil {
+						log.Error("GetDiverging: %v", err)
+					} else {
+						err = pr.UpdateCommitDivergence(divergence.Ahead, divergence.Behind)
+						if err != nil {
+							log.Error("UpdateCommitDivergence: %v", err)
+						}
+					}
+				}
+
+				pr.Issue.PullRequest = pr
+				notification.NotifyPullRequestSynchronized(doer, pr)
+
+				requestCodeOwnersReview(pr)
+			}
+		}
+	}
+
+	addHeadRepoTasks(prs)
+	for _, pr := range prs {
+		comment, err := models.CreatePushPullComment(doer, pr, oldCommitID, newCommitID)
+		if err == nil && comment != nil {
+			notification.NotifyPullRequestPushCommits(doer, pr, comment)
+		}
+	}
+}
+
 // checkIfPRContentChanged checks if diff to target branch has changed by push
 // A commit can be considered to leave the PR untouched if the patch/diff with its merge base is unchanged
 func checkIfPRContentChanged(pr *models.PullRequest, oldCommitID, newCommitID string) (hasChanged bool, err error) {
@@ -343,7 +361,7 @@ func checkIfPRContentChanged(pr *models.PullRequest, oldCommitID, newCommitID st
 		}
 	}()
 	// To synchronize repo and get a base ref
-	_, base, err := headGitRepo.GetMergeBase(tmpRemote, pr.BaseBranch, pr.HeadBranch)
+	_, base, err := headGitRepo.GetMergeBase(tmpRemote, pr.BaseBranch, pr.GetGitHeadRefName())
 	if err != nil {
 		return false, fmt.Errorf("GetMergeBase: %v", err)
 	}
@@ -572,9 +590,9 @@ func GetCommitMessages(pr *models.PullRequest) string {
 	}
 	defer gitRepo.Close()
 
-	headCommit, err := gitRepo.GetBranchCommit(pr.HeadBranch)
+	headCommit, err := gitRepo.GetCommit(pr.GetGitHeadRefName())
 	if err != nil {
-		log.Error("Unable to get head commit: %s Error: %v", pr.HeadBranch, err)
+		log.Error("Unable to get head commit: %s Error: %v", pr.GetGitHeadRefName(), err)
 		return ""
 	}
 
@@ -697,7 +715,7 @@ func GetLastCommitStatus(pr *models.PullRequest) (status *models.CommitStatus, e
 	}
 	defer headGitRepo.Close()
 
-	lastCommitID, err := headGitRepo.GetBranchCommitID(pr.HeadBranch)
+	lastCommitID, err := headGitRepo.GetRefCommitID(pr.GetGitHeadRefName())
 	if err != nil {
 		return nil, err
 	}
@@ -736,7 +754,7 @@ func IsHeadEqualWithBranch(pr *models.PullRequest, branchName string) (bool, err
 	if err != nil {
 		return false, err
 	}
-	headCommit, err := headGitRepo.GetBranchCommit(pr.HeadBranch)
+	headCommit, err := headGitRepo.GetCommit(pr.GetGitHeadRefName())
 	if err != nil {
 		return false, err
 	}
@@ -16,14 +16,25 @@ import (
 	"code.gitea.io/gitea/modules/setting"
 )
 
-// CreateCodeComment creates a comment on the code line
-func CreateCodeComment(doer *models.User, gitRepo *git.Repository, issue *models.Issue, line int64, content string, treePath string, isReview bool, replyReviewID int64, latestCommitID string) (*models.Comment, error) {
+// CreateCodeComment creates a comment on the code line, on the lines from startLine to line if
+// startLine is not 0, or on the whole file if isFileComment is set
+func CreateCodeComment(doer *models.User, gitRepo *git.Repository, issue *models.Issue, startLine, line int64, isFileComment bool, content string, treePath string, isReview bool, replyReviewID int64, latestCommitID string) (*models.Comment, error) {
 
 	var (
 		existsReview bool
 		err          error
 	)
 
+	if isFileComment {
+		startLine, line = 0, 0
+	} else if startLine == line {
+		startLine = 0
+	} else if lines := (&models.Comment{StartLine: startLine, Line: line}); startLine != 0 &&
+		((startLine < 0) != (line < 0) || lines.UnsignedStartLine() > lines.UnsignedLine()) {
+		// The lines must be on the same side of the diff
+		return nil, models.ErrInvalidCodeCommentLines{StartLine: startLine, Line: line}
+	}
+
 	// CreateCodeComment() is used for:
 	// - Single comments
 	// - Comments that are part of a review
@@ -49,7 +60,9 @@ func CreateCodeComment(doer *models.User, gitRepo *git.Repository, issue *models
 			issue,
 			content,
 			treePath,
+			startLine,
 			line,
+			isFileComment,
 			replyReviewID,
 		)
 		if err != nil {
@@ -85,7 +98,9 @@ func CreateCodeComment(doer *models.User, gitRepo *git.Repository, issue *models
 		issue,
 		content,
 		treePath,
+		startLine,
 		line,
+		isFileComment,
 		review.ID,
 	)
 	if err != nil {
@@ -104,8 +119,8 @@ func CreateCodeComment(doer *models.User, gitRepo *git.Repository, issue *models
 	return comment, nil
 }
 
-// createCodeComment creates a plain code comment at the specified line / path
-func createCodeComment(doer *models.User, repo *models.Repository, issue *models.Issue, content, treePath string, line, reviewID int64) (*models.Comment, error) {
+// createCodeComment creates a plain code comment at the specified lines / path
+func createCodeComment(doer *models.User, repo *models.Repository, issue *models.Issue, content, treePath string, startLine, line int64, isFileComment bool, reviewID int64) (*models.Comment, error) {
 	var commitID, patch string
 	if err := issue.LoadPullRequest(); err != nil {
 		return nil, fmt.Errorf("GetPullRequestByIssueID: %v", err)
@@ -121,19 +136,23 @@ func createCodeComment(doer *models.User, repo *models.Repository, issue *models
 	defer gitRepo.Close()
 
 	// FIXME validate treePath
-	// Get latest commit referencing the commented line
+	// Get latest commit referencing the commented lines
 	// No need for get commit for base branch changes
 	if line > 0 {
-		commit, err := gitRepo.LineBlame(pr.GetGitRefName(), gitRepo.Path, treePath, uint(line))
+		firstLine := startLine
+		if firstLine == 0 {
+			firstLine = line
+		}
+		commit, err := gitRepo.LinesBlame(pr.GetGitRefName(), gitRepo.Path, treePath, uint(firstLine), uint(line))
 		if err == nil {
 			commitID = commit.ID.String()
 		} else if !strings.Contains(err.Error(), "exit status 128 - fatal: no such path") {
-			return nil, fmt.Errorf("LineBlame[%s, %s, %s, %d]: %v", pr.GetGitRefName(), gitRepo.Path, treePath, line, err)
+			return nil, fmt.Errorf("LinesBlame[%s, %s, %s, %d, %d]: %v", pr.GetGitRefName(), gitRepo.Path, treePath, firstLine, line, err)
 		}
 	}
 
-	// Only fetch diff if comment is review comment
-	if reviewID != 0 {
+	// Only fetch diff if comment is review comment on lines
+	if reviewID != 0 && !isFileComment {
 		headCommitID, err := gitRepo.GetRefCommitID(pr.GetGitRefName())
 		if err != nil {
 			return nil, fmt.Errorf("GetRefCommitID[%s]: %v", pr.GetGitRefName(), err)
@@ -142,19 +161,27 @@ func createCodeComment(doer *models.User, repo *models.Repository, issue *models
 		if err := git.GetRepoRawDiffForFile(gitRepo, pr.MergeBase, headCommitID, git.RawDiffNormal, treePath, patchBuf); err != nil {
 			return nil, fmt.Errorf("GetRawDiffForLine[%s, %s, %s, %s]: %v", err, gitRepo.Path, pr.MergeBase, headCommitID, treePath)
 		}
-		patch = git.CutDiffAroundLine(patchBuf, int64((&models.Comment{Line: line}).UnsignedLine()), line < 0, setting.UI.CodeCommentLines)
+		comment := &models.Comment{StartLine: startLine, Line: line}
+		numberOfLines := setting.UI.CodeCommentLines
+		if startLine != 0 {
+			// Include all the commented lines
+			numberOfLines += int(comment.UnsignedLine() - comment.UnsignedStartLine())
+		}
+		patch = git.CutDiffAroundLine(patchBuf, int64(comment.UnsignedLine()), line < 0, numberOfLines)
 	}
 	return models.CreateComment(&models.CreateCommentOptions{
-		Type:      models.CommentTypeCode,
-		Doer:      doer,
-		Repo:      repo,
-		Issue:     issue,
-		Content:   content,
-		LineNum:   line,
-		TreePath:  treePath,
-		CommitSHA: commitID,
-		ReviewID:  reviewID,
-		Patch:     patch,
+		Type:          models.CommentTypeCode,
+		Doer:          doer,
+		Repo:          repo,
+		Issue:         issue,
+		Content:       content,
+		LineNum:       line,
+		StartLineNum:  startLine,
+		IsFileComment: isFileComment,
+		TreePath:      treePath,
+		CommitSHA:     commitID,
+		ReviewID:      reviewID,
+		Patch:         patch,
 	})
 }
 
@@ -139,8 +139,13 @@ func createTemporaryRepo(pr *models.PullRequest) (string, error) {
 	errbuf.Reset()
 
 	trackingBranch := "tracking"
+	headBranch := pr.GetGitHeadRefName()
+	if pr.Flow == models.PullRequestFlowAGit && len(pr.HeadCommitID) == 40 {
+		// The AGit flow pull request is being created, its hidden ref does not exist yet
+		headBranch = pr.HeadCommitID
+	}
 	// Fetch head branch
-	if err := git.NewCommand("fetch", "--no-tags", remoteRepoName, git.BranchPrefix+pr.HeadBranch+":"+trackingBranch).RunInDirPipeline(tmpBasePath, &outbuf, &errbuf); err != nil {
+	if err := git.NewCommand("fetch", "--no-tags", remoteRepoName, headBranch+":"+trackingBranch).RunInDirPipeline(tmpBasePath, &outbuf, &errbuf); err != nil {
 		log.Error("Unable to fetch head_repo head branch [%s:%s -> tracking in %s]: %v:\n%s\n%s", pr.HeadRepo.FullName(), pr.HeadBranch, tmpBasePath, err, outbuf.String(), errbuf.String())
 		if err := models.RemoveTemporaryPath(tmpBasePath); err != nil {
 			log.Error("CreateTempRepo: RemoveTemporaryPath: %s", err)
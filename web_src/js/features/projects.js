@@ -0,0 +1,72 @@
+const {csrf} = window.config;
+
+export default function initProject() {
+  const board = document.querySelector('.board[data-project-link]');
+  if (!board) return;
+
+  const projectLink = board.dataset.projectLink;
+
+  // board forms answer with JSON, reload the boards once they succeed
+  $('.project-board-form').on('submit', function (e) {
+    e.preventDefault();
+    $.post($(this).attr('action'), $(this).serialize()).done(() => {
+      window.location.reload();
+    });
+  });
+
+  if (board.dataset.editable !== 'true') return;
+
+  let dragged = null;
+  for (const card of board.querySelectorAll('.board-card')) {
+    card.addEventListener('dragstart', (e) => {
+      dragged = card;
+      e.dataTransfer.effectAllowed = 'move';
+      e.dataTransfer.setData('text/plain', card.dataset.issue);
+      card.classList.add('dragging');
+    });
+    card.addEventListener('dragend', () => {
+      card.classList.remove('dragging');
+      dragged = null;
+    });
+  }
+
+  for (const column of board.querySelectorAll('.board-cards')) {
+    column.addEventListener('dragover', (e) => {
+      if (!dragged) return;
+      e.preventDefault();
+      const after = cardAfterPosition(column, e.clientY);
+      if (after) {
+        column.insertBefore(dragged, after);
+      } else {
+        column.appendChild(dragged);
+      }
+    });
+    column.addEventListener('drop', (e) => {
+      e.preventDefault();
+      moveCards(projectLink, column);
+    });
+  }
+}
+
+// cardAfterPosition returns the card the dragged card has to be put before
+function cardAfterPosition(column, y) {
+  for (const card of column.querySelectorAll('.board-card:not(.dragging)')) {
+    const box = card.getBoundingClientRect();
+    if (y < box.top + box.height / 2) return card;
+  }
+  return null;
+}
+
+async function moveCards(projectLink, column) {
+  const issues = [...column.querySelectorAll('.board-card')].map((card, i) => {
+    return {issueID: parseInt(card.dataset.issue), sorting: i};
+  });
+  await fetch(`${projectLink}/${column.dataset.boardId}/move`, {
+    method: 'POST',
+    headers: {
+      'X-Csrf-Token': csrf,
+      'Content-Type': 'application/json',
+    },
+    body: JSON.stringify({issues}),
+  });
+}
@@ -0,0 +1,187 @@
+const {AppSubUrl, csrf} = window.config;
+
+// binary values are exchanged with the server as unpadded base64url strings
+function decodeBase64URL(value) {
+  const base64 = value.replace(/-/g, '+').replace(/_/g, '/');
+  return Uint8Array.from(atob(base64), (c) => c.charCodeAt(0));
+}
+
+function encodeBase64URL(buffer) {
+  return btoa(String.fromCharCode(...new Uint8Array(buffer)))
+    .replace(/\+/g, '-')
+    .replace(/\//g, '_')
+    .replace(/=/g, '');
+}
+
+function encodeCredential(credential) {
+  const {response} = credential;
+  const encoded = {
+    id: credential.id,
+    rawId: encodeBase64URL(credential.rawId),
+    type: credential.type,
+    response: {
+      clientDataJSON: encodeBase64URL(response.clientDataJSON),
+    },
+    clientExtensionResults: credential.getClientExtensionResults(),
+  };
+  if (response.attestationObject) {
+    encoded.response.attestationObject = encodeBase64URL(response.attestationObject);
+  }
+  if (response.authenticatorData) {
+    encoded.response.authenticatorData = encodeBase64URL(response.authenticatorData);
+    encoded.response.signature = encodeBase64URL(response.signature);
+  }
+  if (response.userHandle) {
+    encoded.response.userHandle = encodeBase64URL(response.userHandle);
+  }
+  return encoded;
+}
+
+function isSupported() {
+  return window.isSecureContext && window.PublicKeyCredential !== undefined;
+}
+
+function webAuthnError(errorType) {
+  const errors = ['browser', 'unknown', 'insecure', 'unable-to-process', 'duplicated', 'empty', 'not-allowed'];
+  for (const type of errors) {
+    $(`#webauthn-error-${type}`).toggleClass('hide', type !== errorType);
+  }
+  $('#webauthn-error-reload').toggleClass('hide', errorType !== 'not-allowed');
+  $('#register-device').modal('hide');
+  $('#webauthn-error').modal('show');
+}
+
+function handleCredentialError(err) {
+  switch (err && err.name) {
+    case 'NotAllowedError':
+    case 'AbortError':
+      webAuthnError('not-allowed');
+      break;
+    case 'InvalidStateError':
+      webAuthnError('duplicated');
+      break;
+    case 'SecurityError':
+      webAuthnError('insecure');
+      break;
+    default:
+      webAuthnError('unknown');
+  }
+}
+
+function postCredential(url, credential) {
+  return $.ajax({
+    url,
+    type: 'POST',
+    headers: {'X-Csrf-Token': csrf},
+    data: JSON.stringify(encodeCredential(credential)),
+    contentType: 'application/json; charset=utf-8',
+  });
+}
+
+async function getAssertion(url) {
+  const options = await $.getJSON(url);
+  options.challenge = decodeBase64URL(options.challenge);
+  for (const cred of options.allowCredentials) {
+    cred.id = decodeBase64URL(cred.id);
+  }
+  return navigator.credentials.get({publicKey: options});
+}
+
+async function signIn(url) {
+  let credential;
+  try {
+    credential = await getAssertion(url);
+  } catch (err) {
+    handleCredentialError(err);
+    return;
+  }
+  postCredential(url, credential).done((res) => {
+    window.location.replace(res);
+  }).fail(() => {
+    webAuthnError('unable-to-process');
+  });
+}
+
+export function initWebAuthnAuth() {
+  if ($('#wait-for-key').length === 0) {
+    return;
+  }
+  if (!isSupported()) {
+    // Fallback in case the browser does not support WebAuthn
+    window.location.href = `${AppSubUrl}/user/two_factor`;
+    return;
+  }
+  $('#webauthn-error').modal({allowMultiple: false});
+  signIn(`${AppSubUrl}/user/webauthn/assertion`);
+}
+
+export function initWebAuthnPasswordless() {
+  const $passwordless = $('#webauthn-passwordless');
+  if ($passwordless.length === 0 || !isSupported()) {
+    return;
+  }
+  $passwordless.removeClass('hide');
+  $('#webauthn-error').modal({allowMultiple: false});
+  $passwordless.find('button').on('click', (e) => {
+    e.preventDefault();
+    signIn(`${AppSubUrl}/user/webauthn/passwordless`);
+  });
+}
+
+async function register(options) {
+  options.challenge = decodeBase64URL(options.challenge);
+  options.user.id = decodeBase64URL(options.user.id);
+  for (const cred of options.excludeCredentials) {
+    cred.id = decodeBase64URL(cred.id);
+  }
+
+  let credential;
+  try {
+    credential = await navigator.credentials.create({publicKey: options});
+  } catch (err) {
+    handleCredentialError(err);
+    return;
+  }
+  postCredential(`${AppSubUrl}/user/settings/security/webauthn/register`, credential).done(() => {
+    window.location.reload();
+  }).fail((xhr) => {
+    webAuthnError(xhr.status === 409 ? 'duplicated' : 'unable-to-process');
+  });
+}
+
+export function initWebAuthnRegister() {
+  if ($('#register-security-key').length === 0) {
+    return;
+  }
+  $('#register-device').modal({allowMultiple: false});
+  $('#webauthn-error').modal({allowMultiple: false});
+  $('#register-security-key').on('click', (e) => {
+    e.preventDefault();
+    if (!isSupported()) {
+      webAuthnError('browser');
+      return;
+    }
+
+    const $nickname = $('#nickname');
+    if ($nickname.val().trim() === '') {
+      $nickname.closest('div.field').addClass('error');
+      webAuthnError('empty');
+      return;
+    }
+    $.post(`${AppSubUrl}/user/settings/security/webauthn/request_register`, {
+      _csrf: csrf,
+      name: $nickname.val(),
+      passwordless: $('#passwordless').is(':checked'),
+    }).done((options) => {
+      $nickname.closest('div.field').removeClass('error');
+      $('#register-device').modal('show');
+      register(options);
+    }).fail((xhr) => {
+      if (xhr.status === 409) {
+        $nickname.closest('div.field').addClass('error');
+      } else {
+        webAuthnError('unable-to-process');
+      }
+    });
+  });
+}
@@ -16,6 +16,8 @@ import initMarkdownAnchors from './markdown/anchors.js';
 import attachTribute from './features/tribute.js';
 import createDropzone from './features/dropzone.js';
 import initTableSort from './features/tablesort.js';
+import initProject from './features/projects.js';
+import {initWebAuthnAuth, initWebAuthnPasswordless, initWebAuthnRegister} from './features/webauthn.js';
 import ActivityTopAuthors from './components/ActivityTopAuthors.vue';
 import {initNotificationsTable, initNotificationCount} from './features/notification.js';
 import {createCodeEditor} from './features/codeeditor.js';
@@ -523,6 +525,7 @@ function initCommentForm() {
       }
       switch (input_id) {
         case '#milestone_id':
+        case '#project_id':
           $list.find('.selected').html(`<a class="item" href=${$(this).data('href')}>${
             htmlEncode($(this).text())}</a>`);
           break;
@@ -555,8 +558,9 @@ function initCommentForm() {
     });
   }
 
-  // Milestone and assignee
+  // Milestone, project and assignee
   selectItem('.select-milestone', '#milestone_id');
+  selectItem('.select-project', '#project_id');
   selectItem('.select-assignee', '#assignee_id');
 }
 
@@ -1151,8 +1155,9 @@ function initMigration() {
   const toggleMigrations = function () {
     const authUserName = $('#auth_username').val();
     const cloneAddr = $('#clone_addr').val();
-    if (!$('#mirror').is(':checked') && (authUserName && authUserName.length > 0) &&
-        (cloneAddr !== undefined && (cloneAddr.startsWith('https://github.com') || cloneAddr.startsWith('http://github.com') || cloneAddr.startsWith('http://gitlab.com') || cloneAddr.startsWith('https://gitlab.com')))) {
+    const service = $('#service').val();
+    if (!$('#mirror').is(':checked') && (service === 'gitea' || service === 'gogs' || ((authUserName && authUserName.length > 0) &&
+        (cloneAddr !== undefined && (cloneAddr.startsWith('https://github.com') || cloneAddr.startsWith('http://github.com') || cloneAddr.startsWith('http://gitlab.com') || cloneAddr.startsWith('https://gitlab.com')))))) {
       $('#migrate_items').show();
     } else {
       $('#migrate_items').hide();
@@ -1164,6 +1169,7 @@ function initMigration() {
   $('#clone_addr').on('input', toggleMigrations);
   $('#auth_username').on('input', toggleMigrations);
   $('#mirror').on('change', toggleMigrations);
+  $('#service').on('change', toggleMigrations);
 }
 
 function initPullRequestReview() {
@@ -1227,6 +1233,8 @@ function initPullRequestReview() {
     .on('mouseleave', function () {
       $(this).closest('tr').removeClass('focus-lines-new focus-lines-old');
     });
+  // Shift-clicking a second line of the same side of a file comments on all the lines in between
+  let lastCommentedLine = null;
   $('.add-code-comment').on('click', function (e) {
     if ($(e.target).hasClass('btn-add-single')) return; // https://github.com/go-gitea/gitea/issues/4745
     e.preventDefault();
@@ -1235,13 +1243,30 @@ function initPullRequestReview() {
     const side = $(this).data('side');
     const idx = $(this).data('idx');
     const path = $(this).data('path');
+    let startIdx = '';
+    let endIdx = idx;
+    if (e.shiftKey && lastCommentedLine && lastCommentedLine.path === path && lastCommentedLine.side === side && lastCommentedLine.idx !== idx) {
+      startIdx = Math.min(lastCommentedLine.idx, idx);
+      endIdx = Math.max(lastCommentedLine.idx, idx);
+    }
+    lastCommentedLine = {path, side, idx};
     const form = $('#pull_review_add_comment').html();
-    const tr = $(this).closest('tr');
+    // The form is added below the last commented line, also when shift-clicking upwards
+    const tr = endIdx === idx ? $(this).closest('tr') :
+      $(this).closest('.code-diff').find(`.add-code-comment[data-side="${side}"][data-idx="${endIdx}"]`).closest('tr');
+    const setCommentedLines = (td) => {
+      td.find("input[name='line']").val(endIdx);
+      td.find("input[name='start_line']").val(startIdx);
+    };
 
     const oldLineNum = tr.find('.lines-num-old').data('line-num');
     const newLineNum = tr.find('.lines-num-new').data('line-num');
     const addCommentKey = `${oldLineNum}|${newLineNum}`;
-    if (document.querySelector(`[data-add-comment-key="${addCommentKey}"]`)) return; // don't add same comment box twice
+    const existingComment = tr.closest('.code-diff').find(`[data-add-comment-key="${addCommentKey}"]`);
+    if (existingComment.length) { // don't add same comment box twice, but comment on the newly selected lines
+      setCommentedLines(existingComment.find(`.add-comment-${side}`));
+      return;
+    }
 
     let ntr = tr.next();
     if (!ntr.hasClass('add-comment')) {
@@ -1270,10 +1295,10 @@ function initPullRequestReview() {
       commentCloud = td.find('.comment-code-cloud');
       assingMenuAttributes(commentCloud.find('.menu'));
 
-      td.find("input[name='line']").val(idx);
       td.find("input[name='side']").val(side === 'left' ? 'previous' : 'proposed');
       td.find("input[name='path']").val(path);
     }
+    setCommentedLines(td);
     const $textarea = commentCloud.find('textarea');
     attachTribute($textarea.get(), {mentions: true, emoji: true});
 
@@ -1810,9 +1835,9 @@ function initAdmin() {
   // New authentication
   if ($('.admin.new.authentication').length > 0) {
     $('#auth_type').on('change', function () {
-      $('.ldap, .dldap, .smtp, .pam, .oauth2, .has-tls, .search-page-size, .sspi').hide();
+      $('.ldap, .dldap, .smtp, .pam, .oauth2, .has-tls, .search-page-size, .sspi, .saml').hide();
 
-      $('.ldap input[required], .binddnrequired input[required], .dldap input[required], .smtp input[required], .pam input[required], .oauth2 input[required], .has-tls input[required], .sspi input[required]').removeAttr('required');
+      $('.ldap input[required], .binddnrequired input[required], .dldap input[required], .smtp input[required], .pam input[required], .oauth2 input[required], .has-tls input[required], .sspi input[required], .saml input[required]').removeAttr('required');
       $('.binddnrequired').removeClass('required');
 
       const authType = $(this).val();
@@ -1844,6 +1869,10 @@ function initAdmin() {
           $('.sspi').show();
           $('.sspi div.required input').attr('required', 'required');
           break;
+        case '8': // SAML
+          $('.saml').show();
+          $('.has-tls').show();
+          break;
       }
       if (authType === '2' || authType === '5') {
         onSecurityProtocolChange();
@@ -2041,130 +2070,6 @@ function initCodeView() {
   });
 }
 
-function initU2FAuth() {
-  if ($('#wait-for-key').length === 0) {
-    return;
-  }
-  u2fApi.ensureSupport()
-    .then(() => {
-      $.getJSON(`${AppSubUrl}/user/u2f/challenge`).done((req) => {
-        u2fApi.sign(req.appId, req.challenge, req.registeredKeys, 30)
-          .then(u2fSigned)
-          .catch((err) => {
-            if (err === undefined) {
-              u2fError(1);
-              return;
-            }
-            u2fError(err.metaData.code);
-          });
-      });
-    }).catch(() => {
-      // Fallback in case browser do not support U2F
-      window.location.href = `${AppSubUrl}/user/two_factor`;
-    });
-}
-function u2fSigned(resp) {
-  $.ajax({
-    url: `${AppSubUrl}/user/u2f/sign`,
-    type: 'POST',
-    headers: {'X-Csrf-Token': csrf},
-    data: JSON.stringify(resp),
-    contentType: 'application/json; charset=utf-8',
-  }).done((res) => {
-    window.location.replace(res);
-  }).fail(() => {
-    u2fError(1);
-  });
-}
-
-function u2fRegistered(resp) {
-  if (checkError(resp)) {
-    return;
-  }
-  $.ajax({
-    url: `${AppSubUrl}/user/settings/security/u2f/register`,
-    type: 'POST',
-    headers: {'X-Csrf-Token': csrf},
-    data: JSON.stringify(resp),
-    contentType: 'application/json; charset=utf-8',
-    success() {
-      reload();
-    },
-    fail() {
-      u2fError(1);
-    }
-  });
-}
-
-function checkError(resp) {
-  if (!('errorCode' in resp)) {
-    return false;
-  }
-  if (resp.errorCode === 0) {
-    return false;
-  }
-  u2fError(resp.errorCode);
-  return true;
-}
-
-function u2fError(errorType) {
-  const u2fErrors = {
-    browser: $('#unsupported-browser'),
-    1: $('#u2f-error-1'),
-    2: $('#u2f-error-2'),
-    3: $('#u2f-error-3'),
-    4: $('#u2f-error-4'),
-    5: $('.u2f-error-5')
-  };
-  u2fErrors[errorType].removeClass('hide');
-
-  Object.keys(u2fErrors).forEach((type) => {
-    if (type !== errorType) {
-      u2fErrors[type].addClass('hide');
-    }
-  });
-  $('#u2f-error').modal('show');
-}
-
-function initU2FRegister() {
-  $('#register-device').modal({allowMultiple: false});
-  $('#u2f-error').modal({allowMultiple: false});
-  $('#register-security-key').on('click', (e) => {
-    e.preventDefault();
-    u2fApi.ensureSupport()
-      .then(u2fRegisterRequest)
-      .catch(() => {
-        u2fError('browser');
-      });
-  });
-}
-
-function u2fRegisterRequest() {
-  $.post(`${AppSubUrl}/user/settings/security/u2f/request_register`, {
-    _csrf: csrf,
-    name: $('#nickname').val()
-  }).done((req) => {
-    $('#nickname').closest('div.field').removeClass('error');
-    $('#register-device').modal('show');
-    if (req.registeredKeys === null) {
-      req.registeredKeys = [];
-    }
-    u2fApi.register(req.appId, req.registerRequests, req.registeredKeys, 30)
-      .then(u2fRegistered)
-      .catch((reason) => {
-        if (reason === undefined) {
-          u2fError(1);
-          return;
-        }
-        u2fError(reason.metaData.code);
-      });
-  }).fail((xhr) => {
-    if (xhr.status === 409) {
-      $('#nickname').closest('div.field').addClass('error');
-    }
-  });
-}
-
 function initWipTitle() {
   $('.title_wip_desc > a').on('click', (e) => {
     e.preventDefault();
@@ -2429,8 +2334,9 @@ $(document).ready(async () => {
   initCtrlEnterSubmit();
   initNavbarContentToggle();
   initTopicbar();
-  initU2FAuth();
-  initU2FRegister();
+  initWebAuthnAuth();
+  initWebAuthnPasswordless();
+  initWebAuthnRegister();
   initIssueList();
   initWipTitle();
   initPullRequestReview();
@@ -2439,6 +2345,7 @@ $(document).ready(async () => {
   initContextPopups();
   initTableSort();
   initNotificationsTable();
+  initProject();
 
   // Repo clone url.
   if ($('#repo-clone-url').length > 0) {